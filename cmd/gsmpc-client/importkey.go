@@ -0,0 +1,202 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/ecies"
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/sha3"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+)
+
+// importKeyDeal the shares of the private key dealt to the nodes of the group,the same as smpc.ImportKeyDeal
+type importKeyDeal struct {
+	PubKey string            // hex of the pubkey,the same format as the pubkey generated by keygen
+	PolyG  []string          // hex of the feldman commitments,PolyG[0] is the pubkey
+	C      string            // hex of the chain code for bip32
+	Shares map[string]string // enode id --> hex of the share encrypted to the enode
+}
+
+// parseEnodeID get the node id from "enode://id@ip:port"
+func parseEnodeID(enode string) string {
+	id := strings.TrimPrefix(enode, "enode://")
+	if i := strings.Index(id, "@"); i >= 0 {
+		id = id[:i]
+	}
+	return id
+}
+
+// nodeHash hash the node id to sort the nodes of the group,the same as smpc.DoubleHash
+func nodeHash(id string, keytype string) *big.Int {
+	keccak256 := sha3.NewKeccak256()
+	keccak256.Write([]byte(id))
+	sha3256 := sha3.New256()
+	sha3256.Write(keccak256.Sum(nil))
+
+	if keytype == "ED25519" {
+		var digest [32]byte
+		var zero [32]byte
+		var one [32]byte
+		copy(digest[:], sha3256.Sum(nil))
+		one[0] = 1
+		ed.ScMulAdd(&digest, &digest, &one, &zero)
+		return new(big.Int).SetBytes(digest[:])
+	}
+
+	return new(big.Int).SetBytes(sha3256.Sum(nil))
+}
+
+// getNodeUIDs get the uid of every node of the group,the same as smpc.GetNodeUID:
+// the uid of a node is its position(from 1) in the sorted hashes of the node ids
+func getNodeUIDs(ids []string, keytype string) map[string]*big.Int {
+	hashes := make([]*big.Int, len(ids))
+	for k, id := range ids {
+		hashes[k] = nodeHash(id, keytype)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i].Cmp(hashes[j]) < 0 })
+
+	uids := make(map[string]*big.Int)
+	for _, id := range ids {
+		h := nodeHash(id, keytype)
+		for k, v := range hashes {
+			if v.Cmp(h) == 0 {
+				uids[id] = big.NewInt(int64(k + 1))
+				break
+			}
+		}
+	}
+	return uids
+}
+
+// encryptShare encrypt the share to the node id,the node decrypts it with smpc.DecryptMsg
+func encryptShare(share []byte, id string) (string, error) {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != 64 {
+		return "", errors.New("invalid node id " + id)
+	}
+
+	p := &ecdsa.PublicKey{Curve: secp256k1.S256(), X: new(big.Int).SetBytes(b[:32]), Y: new(big.Int).SetBytes(b[32:])}
+	if !p.Curve.IsOnCurve(p.X, p.Y) {
+		return "", errors.New("invalid node id " + id)
+	}
+
+	cm, err := ecies.Encrypt(crand.Reader, ecies.ImportECDSAPublic(p), []byte(hex.EncodeToString(share)), nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(cm), nil
+}
+
+// dealImportKey split the private key among the nodes ids as a trusted dealer,every share is encrypted to its node,
+// so the private key never leaves the client
+// sk is the EC256K1 private key or the ED25519 seed(or seed||pubkey)
+func dealImportKey(keytype string, ids []string, t int, sk []byte) (*importKeyDeal, error) {
+	if t < 2 || t > len(ids) {
+		return nil, errors.New("threshold does not match the group")
+	}
+
+	uids := getNodeUIDs(ids, keytype)
+	deal := &importKeyDeal{Shares: make(map[string]string)}
+	shares := make(map[string][]byte)
+
+	switch keytype {
+	case "EC256K1":
+		curve := secp256k1.S256()
+		secret := new(big.Int).SetBytes(sk)
+		if len(sk) != 32 || secret.Sign() == 0 || secret.Cmp(curve.Params().N) >= 0 {
+			return nil, errors.New("invalid private key")
+		}
+
+		poly, polyG, err := ec2.Vss2Init(curve, secret, t)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range polyG.PolyG {
+			deal.PolyG = append(deal.PolyG, hex.EncodeToString(curve.Marshal(v[0], v[1])))
+		}
+
+		for _, id := range ids {
+			ss, err := poly.Vss2(curve, []*big.Int{uids[id]})
+			if err != nil {
+				return nil, err
+			}
+			shares[id] = ss[0].Share.Bytes()
+		}
+
+		c, err := crand.Int(crand.Reader, curve.Params().N)
+		if err != nil {
+			return nil, err
+		}
+		deal.C = hex.EncodeToString(c.Bytes())
+	case "ED25519":
+		if len(sk) != 32 && len(sk) != 64 {
+			return nil, errors.New("invalid private key")
+		}
+
+		var seed [32]byte
+		copy(seed[:], sk[:32])
+
+		edids := make([][32]byte, len(ids))
+		for k, id := range ids {
+			copy(edids[k][:], uids[id].Bytes())
+		}
+
+		_, cfsBBytes, ss, err := ed.Vss(ed.SecretFromSeed(seed), edids, t, len(ids))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range cfsBBytes {
+			deal.PolyG = append(deal.PolyG, hex.EncodeToString(v[:]))
+		}
+
+		for k, id := range ids {
+			shares[id] = ss[k][:]
+		}
+
+		var c [32]byte
+		if _, err := io.ReadFull(crand.Reader, c[:]); err != nil {
+			return nil, err
+		}
+		deal.C = hex.EncodeToString(c[:])
+	default:
+		return nil, errors.New("only EC256K1 and ED25519 key can be imported")
+	}
+
+	deal.PubKey = deal.PolyG[0]
+	for id, share := range shares {
+		cm, err := encryptShare(share, id)
+		if err != nil {
+			return nil, err
+		}
+		deal.Shares[id] = cm
+	}
+
+	return deal, nil
+}
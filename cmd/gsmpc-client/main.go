@@ -85,6 +85,10 @@ var (
 	keyType     *string
 	pubkey      *string
 	inputcode   *string
+	taptweak    *string
+	paillierLen *string
+	signProtocol *string
+	importPrivKey *string
 	msghash     *string
 	enode       *string
 	tsgid       *string
@@ -113,6 +117,9 @@ func main() {
 	case "REQSMPCADDR":
 		// req SMPC account
 		reqSmpcAddr()
+	case "IMPORTKEY":
+		// import an existing private key
+		importKey()
 	case "ACCEPTREQADDR":
 		// req condominium account
 		acceptReqAddr()
@@ -172,6 +179,15 @@ func main() {
 	case "ACCEPTRESHARE":
 		// approve condominium account reshare
 		acceptReshare()
+	case "RECOVERSHARE":
+		// recover the lost share of a node
+		recoverShare()
+	case "REFRESH":
+		// refresh the shares of the keygen group
+		recoverShare()
+	case "ACCEPTRECOVERSHARE":
+		// approve recover share or refresh
+		acceptRecoverShare()
 	case "CREATECONTRACT":
 		err := createContract()
 		if err != nil {
@@ -185,7 +201,7 @@ func main() {
 			return
 		}
 	default:
-		fmt.Printf("\nCMD('%v') not support\nSupport cmd: EnodeSig|SetGroup|REQSMPCADDR|ACCEPTREQADDR|ACCEPTLOCKOUT|SIGN|PRESIGNDATA|DELPRESIGNDATA|GETPRESIGNDATA|ACCEPTSIGN|RESHARE|ACCEPTRESHARE|CREATECONTRACT|GETSMPCADDR\n", *cmd)
+		fmt.Printf("\nCMD('%v') not support\nSupport cmd: EnodeSig|SetGroup|REQSMPCADDR|IMPORTKEY|ACCEPTREQADDR|ACCEPTLOCKOUT|SIGN|PRESIGNDATA|DELPRESIGNDATA|GETPRESIGNDATA|ACCEPTSIGN|RESHARE|ACCEPTRESHARE|RECOVERSHARE|REFRESH|ACCEPTRECOVERSHARE|CREATECONTRACT|GETSMPCADDR\n", *cmd)
 	}
 }
 
@@ -198,10 +214,10 @@ func init() {
 	passwd = flag.String("passwd", "111111", "Password")
 	passwdfile = flag.String("passwdfile", "", "Password file")
 	url = flag.String("url", "http://127.0.0.1:9011", "Set node RPC URL")
-	cmd = flag.String("cmd", "", "EnodeSig|SetGroup|REQSMPCADDR|ACCEPTREQADDR|ACCEPTLOCKOUT|SIGN|PRESIGNDATA|DELPRESIGNDATA|GETPRESIGNDATA|ACCEPTSIGN|RESHARE|ACCEPTRESHARE|CREATECONTRACT|GETSMPCADDR")
+	cmd = flag.String("cmd", "", "EnodeSig|SetGroup|REQSMPCADDR|IMPORTKEY|ACCEPTREQADDR|ACCEPTLOCKOUT|SIGN|PRESIGNDATA|DELPRESIGNDATA|GETPRESIGNDATA|ACCEPTSIGN|RESHARE|ACCEPTRESHARE|RECOVERSHARE|REFRESH|ACCEPTRECOVERSHARE|CREATECONTRACT|GETSMPCADDR")
 	gid = flag.String("gid", "", "groupID")
 	ts = flag.String("ts", "2/3", "Threshold")
-	mode = flag.String("mode", "1", "Mode:private=1/managed=0")
+	mode = flag.String("mode", "1", "Mode:private=1/managed=0/managed by the approval policy of the nodes=2")
 	toAddr = flag.String("to", "0x0520e8e5E08169c4dbc1580Dc9bF56638532773A", "To address")
 	value = flag.String("value", "10000000000000000", "lockout value")
 	coin = flag.String("coin", "FSN", "Coin type")
@@ -210,9 +226,13 @@ func init() {
 	memo = flag.String("memo", "smpcwallet.com", "Memo")
 	accept = flag.String("accept", "AGREE", "AGREE|DISAGREE")
 	key = flag.String("key", "", "Accept key")
-	keyType = flag.String("keytype", "EC256K1", "EC256K1|ED25519")
+	keyType = flag.String("keytype", "EC256K1", "EC256K1|EC256R1|ED25519|SR25519|SCHNORR256K1")
 	pubkey = flag.String("pubkey", "", "Smpc pubkey")
 	inputcode = flag.String("inputcode", "", "bip32 input code")
+	taptweak = flag.String("taptweak", "", "SCHNORR256K1 only,TAPROOT or hex of taproot merkle root")
+	paillierLen = flag.String("paillierlen", "", "EC256K1/EC256R1 only,bit length of paillier N and Ntilde: 2048|3072|4096,default 2048")
+	signProtocol = flag.String("signprotocol", "", "sign protocol of the key: GG20|CGGMP21 for EC256K1/EC256R1,default GG20; FROST for ED25519,default the 7 rounds ed sign")
+	importPrivKey = flag.String("importkey", "", "IMPORTKEY only,hex of the EC256K1 private key or the ED25519 seed to import")
 	//msghash = flag.String("msghash", "", "msghash=Keccak256(unsignTX)")
 	pkey := flag.String("pkey", "", "Private key")
 	enode = flag.String("enode", "", "enode")
@@ -370,7 +390,7 @@ func reqSmpcAddr() {
 	fmt.Printf("smpc_getReqAddrNonce = %s\nNonce = %d\n", reqAddrNonce, nonce)
 	// build Sigs list parameter
 	sigs := ""
-	if *mode == "0" {
+	if *mode == "0" || *mode == "2" {
 		for i := 0; i < len(enodesSig)-1; i++ {
 			sigs = sigs + enodesSig[i] + "|"
 		}
@@ -387,6 +407,8 @@ func reqSmpcAddr() {
 		AcceptTimeOut: "600",
 		TimeStamp: timestamp,
 		Sigs:      sigs,
+		PaillierKeyLength: *paillierLen,
+		SignProtocol:      *signProtocol,
 	}
 	playload, _ := json.Marshal(txdata)
 
@@ -407,6 +429,11 @@ func reqSmpcAddr() {
 	}
 	fmt.Printf("\nsmpc_reqSmpcAddr keyID = %s\n\n", keyID)
 
+	waitReqAddrStatus(keyID)
+}
+
+// waitReqAddrStatus wait for the result of generating pubkey(or importing key) by keyID
+func waitReqAddrStatus(keyID string) {
 	fmt.Printf("\nWaiting for stats result...\n")
 	// get accounts
 	time.Sleep(time.Duration(20) * time.Second)
@@ -442,6 +469,121 @@ func reqSmpcAddr() {
 	}
 }
 
+// importKey  Import an existing private key into the group
+// the key is split and every share is encrypted to its node here,the private key is never sent to any node
+func importKey() {
+	if *importPrivKey == "" {
+		fmt.Println("importkey is empty")
+		return
+	}
+
+	sk, err := hex.DecodeString(strings.TrimPrefix(*importPrivKey, "0x"))
+	if err != nil {
+		panic(err)
+	}
+
+	nums := strings.Split(*ts, "/")
+	if len(nums) != 2 {
+		panic("threshold format error")
+	}
+	t, err := strconv.Atoi(nums[0])
+	if err != nil {
+		panic(err)
+	}
+	n, err := strconv.Atoi(nums[1])
+	if err != nil {
+		panic(err)
+	}
+
+	// get the nodes of the group
+	groupRep, err := client.Call("smpc_getGroupByID", *gid)
+	if err != nil {
+		panic(err)
+	}
+	groupData, err := getJSONData(groupRep)
+	if err != nil {
+		panic(err)
+	}
+	var group struct {
+		Enodes []string `json:"Enodes"`
+	}
+	if err := json.Unmarshal(groupData, &group); err != nil {
+		panic(err)
+	}
+	if len(group.Enodes) != n {
+		panic("threshold does not match the group")
+	}
+	ids := make([]string, len(group.Enodes))
+	for k, enode := range group.Enodes {
+		ids[k] = parseEnodeID(enode)
+	}
+
+	// split the key
+	deal, err := dealImportKey(*keyType, ids, t, sk)
+	if err != nil {
+		panic(err)
+	}
+	dealData, err := json.Marshal(deal)
+	if err != nil {
+		panic(err)
+	}
+
+	// get nonce,import key shares the nonce with reqaddr
+	reqAddrNonce, err := client.Call("smpc_getReqAddrNonce", keyWrapper.Address.String())
+	if err != nil {
+		panic(err)
+	}
+	nonceStr, _ := getJSONResult(reqAddrNonce)
+	nonce, _ := strconv.ParseUint(nonceStr, 0, 64)
+	fmt.Printf("smpc_getReqAddrNonce = %s\nNonce = %d\n", reqAddrNonce, nonce)
+
+	// build Sigs list parameter
+	sigs := ""
+	if *mode == "0" || *mode == "2" {
+		for i := 0; i < len(enodesSig)-1; i++ {
+			sigs = sigs + enodesSig[i] + "|"
+		}
+		sigs = sigs + enodesSig[len(enodesSig)-1]
+	}
+	// build tx data
+	timestamp := strconv.FormatInt((time.Now().UnixNano() / 1e6), 10)
+	txdata := importKeyData{
+		reqAddrData: reqAddrData{
+			TxType:            *cmd,
+			Keytype:           *keyType,
+			GroupID:           *gid,
+			ThresHold:         *ts,
+			Mode:              *mode,
+			AcceptTimeOut:     "600",
+			TimeStamp:         timestamp,
+			Sigs:              sigs,
+			PaillierKeyLength: *paillierLen,
+			SignProtocol:      *signProtocol,
+		},
+		Deal: dealData,
+	}
+	playload, _ := json.Marshal(txdata)
+
+	// sign tx
+	rawTX, err := signTX(signer, keyWrapper.PrivateKey, nonce, playload)
+	if err != nil {
+		panic(err)
+	}
+	// send rawTx
+	reqKeyID, err := client.Call("smpc_importKey", rawTX)
+	if err != nil {
+		panic(err)
+	}
+	// get keyID
+	keyID, err := getJSONResult(reqKeyID)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("\nsmpc_importKey keyID = %s\n\n", keyID)
+
+	waitReqAddrStatus(keyID)
+}
+
 // acceptReqAddr  Agree to generate pubkey 
 func acceptReqAddr() {
 	// get reqAddr account list
@@ -976,6 +1118,7 @@ func signMsgHash(hashs []string, contexts []string, loopCount int) (rsv []string
 		MsgContext: contexts,
 		MsgHash:    hashs,
 		Keytype:    *keyType,
+		TapTweak:   *taptweak,
 		GroupID:    *gid,
 		ThresHold:  *ts,
 		Mode:       *mode,
@@ -1138,6 +1281,8 @@ func reshare() {
 		AcceptTimeOut: "600",
 		Sigs:      sigs,
 		TimeStamp: timestamp,
+		Keytype:   *keyType,
+		SignProtocol:      *signProtocol,
 	}
 	playload, err := json.Marshal(txdata)
 	if err != nil {
@@ -1218,6 +1363,103 @@ func acceptReshare() {
 	}
 }
 
+// recoverShare  Execute recover share,-enode is the enode id of the node that lost its share
+// it also executes refresh (-cmd REFRESH),which has no enode
+func recoverShare() {
+	// build tx data
+	timestamp := strconv.FormatInt((time.Now().UnixNano() / 1e6), 10)
+	txdata := recoverShareData{
+		TxType:        *cmd,
+		PubKey:        *pubkey,
+		GroupID:       *gid,
+		Enode:         *enode,
+		AcceptTimeOut: "600",
+		TimeStamp:     timestamp,
+	}
+	method := "smpc_recoverShare"
+	if *cmd == "REFRESH" {
+		txdata.Enode = ""
+		method = "smpc_refresh"
+	}
+	playload, err := json.Marshal(txdata)
+	if err != nil {
+		panic(err)
+	}
+
+	// sign tx
+	rawTX, err := signTX(signer, keyWrapper.PrivateKey, 0, playload)
+	if err != nil {
+		panic(err)
+	}
+	// send rawTx
+	reqKeyID, err := client.Call(method, rawTX)
+	if err != nil {
+		panic(err)
+	}
+	// get keyID
+	keyID, err := getJSONResult(reqKeyID)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("\n%v keyID = %s\n\n", method, keyID)
+}
+
+// acceptRecoverShare accept recover share
+func acceptRecoverShare() {
+	// get recover share approve list
+	reqListRep, err := client.Call("smpc_getCurNodeRecoverShareInfo")
+	if err != nil {
+		panic(err)
+	}
+	reqListJSON, _ := getJSONData(reqListRep)
+	fmt.Printf("smpc_getCurNodeRecoverShareInfo = %s\n", reqListJSON)
+
+	var keyList []recoverShareCurNodeInfo
+	if err := json.Unmarshal(reqListJSON, &keyList); err != nil {
+		fmt.Println("Unmarshal recoverShareCurNodeInfo fail:", err)
+		return
+	}
+	// gen key list which not approve, auto accept replace input by arg -key
+	for i := 0; i < len(keyList); i++ {
+		// build tx data
+		var keyStr string
+		if *key != "" {
+			i = len(keyList)
+			keyStr = *key
+		} else {
+			keyStr = keyList[i].Key
+		}
+		timestamp := strconv.FormatInt((time.Now().UnixNano() / 1e6), 10)
+		data := acceptData{
+			TxType:    *cmd,
+			Key:       keyStr,
+			Accept:    *accept,
+			TimeStamp: timestamp,
+		}
+		playload, err := json.Marshal(data)
+		if err != nil {
+			fmt.Println("error:", err)
+			panic(err)
+		}
+		// sign tx
+		rawTX, err := signTX(signer, keyWrapper.PrivateKey, 0, playload)
+		if err != nil {
+			panic(err)
+		}
+		// send rawTx
+		acceptRep, err := client.Call("smpc_acceptRecoverShare", rawTX)
+		if err != nil {
+			panic(err)
+		}
+		// get result
+		acceptRet, err := getJSONResult(acceptRep)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("\nsmpc_acceptRecoverShare result: key[%d]\t%s = %s\n\n", i+1, keyStr, acceptRet)
+	}
+}
+
 // getSmpcAddr get smpc addr by pubkey
 func getSmpcAddr() error {
 	if pubkey == nil {
@@ -1402,6 +1644,12 @@ type reqAddrData struct {
 	AcceptTimeOut  string `json:"AcceptTimeOut"` //unit: second
 	TimeStamp string `json:"TimeStamp"`
 	Sigs      string `json:"Sigs"`
+	PaillierKeyLength string `json:"PaillierKeyLength,omitempty"`
+	SignProtocol      string `json:"SignProtocol,omitempty"`
+}
+type importKeyData struct {
+	reqAddrData
+	Deal json.RawMessage `json:"Deal"`
 }
 type acceptData struct {
 	TxType    string `json:"TxType"`
@@ -1436,6 +1684,7 @@ type signData struct {
 	MsgContext []string `json:"MsgContext"`
 	MsgHash    []string `json:"MsgHash"`
 	Keytype    string   `json:"Keytype"`
+	TapTweak   string   `json:"TapTweak,omitempty"`
 	GroupID    string   `json:"GroupId"`
 	ThresHold  string   `json:"ThresHold"`
 	Mode       string   `json:"Mode"`
@@ -1458,6 +1707,16 @@ type reshareData struct {
 	AcceptTimeOut  string `json:"AcceptTimeOut"` //unit: second
 	Sigs      string `json:"Sigs"`
 	TimeStamp string `json:"TimeStamp"`
+	Keytype   string `json:"Keytype"`
+	SignProtocol      string `json:"SignProtocol,omitempty"`
+}
+type recoverShareData struct {
+	TxType        string `json:"TxType"`
+	PubKey        string `json:"PubKey"`
+	GroupID       string `json:"GroupId"`
+	Enode         string `json:"Enode,omitempty"`
+	AcceptTimeOut string `json:"AcceptTimeOut"` //unit: second
+	TimeStamp     string `json:"TimeStamp"`
 }
 type reqAddrStatus struct {
 	Status    string      `json:"Status"`
@@ -1529,6 +1788,15 @@ type reshareCurNodeInfo struct {
 	Mode      string `json:"Mode"`
 	TimeStamp string `json:"TimeStamp"`
 }
+type recoverShareCurNodeInfo struct {
+	TxType    string `json:"TxType"`
+	Key       string `json:"Key"`
+	PubKey    string `json:"PubKey"`
+	GroupID   string `json:"GroupId"`
+	Enode     string `json:"Enode"`
+	Account   string `json:"Account"`
+	TimeStamp string `json:"TimeStamp"`
+}
 
 // Value set args to start
 type Value interface {
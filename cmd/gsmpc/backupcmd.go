@@ -0,0 +1,224 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	backupDataDirFlag = cli.StringFlag{
+		Name:  "datadir",
+		Usage: "data dir of the node",
+	}
+	backupNodeKeyFlag = cli.StringFlag{
+		Name:  "nodekey",
+		Usage: "private key filename of the node",
+	}
+	backupPassphraseFlag = cli.StringSliceFlag{
+		Name:  "passphrasefile",
+		Usage: "file containing the passphrase,give it once for all the parts or once per part in order",
+	}
+
+	backupCommand = cli.Command{
+		Name:     "backup",
+		Usage:    "Export or import the encrypted backup of the key material of the node",
+		Category: "BACKUP COMMANDS",
+		Description: `
+The gsmpc node must be stopped before running these commands.
+`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the key material of one pubkey or of all pubkeys into a passphrase-encrypted file",
+				Action:    backupExport,
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					backupDataDirFlag,
+					backupNodeKeyFlag,
+					backupPassphraseFlag,
+					cli.StringFlag{Name: "pubkey", Usage: "the pubkey to back up,all pubkeys if it is empty"},
+					cli.StringFlag{Name: "out", Value: "smpc-backup.json", Usage: "the backup file,the parts are written to <out>.1 ... <out>.n if the backup is split"},
+					cli.StringFlag{Name: "split", Usage: "shamir-split the backup among custodians,format is threshold/total,such as 2/3"},
+				},
+				Description: `
+The key material (sku1,bip32 c value and the paillier/ntilde save data) is encrypted
+with AES-256-GCM under a random data key,the data key is encrypted with the key derived
+from the passphrase with scrypt.
+With --split t/n,the data key is shamir-split into n parts and any t of them restore the backup.
+`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Verify the key material in the backup and write it into the datadir",
+				Action:    backupImport,
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					backupDataDirFlag,
+					backupNodeKeyFlag,
+					backupPassphraseFlag,
+					cli.StringSliceFlag{Name: "in", Usage: "the backup file,give it once per part if the backup is split"},
+					cli.BoolFlag{Name: "force", Usage: "overwrite the sku1 already in the datadir even if the backup can not verify it"},
+				},
+				Description: `
+The node key must be the key of the node that exported the backup.
+The shares are verified against the stored pubkeys before anything is written,
+and the import fails if any of the pubkeys already exists in the datadir.
+
+Limitation: EC256K1/EC256R1 keys generated before the public shares were saved
+have none in the backup,their sku1 is only checked to be in [1,N) and can not be
+verified to be the share of the pubkey. The import refuses to overwrite a sku1
+already in the datadir with such an unverified one unless --force is given.
+`,
+			},
+		},
+	}
+)
+
+// openBackupDb open the local databases of the node given by --datadir and --nodekey
+func openBackupDb(ctx *cli.Context) error {
+	if ctx.String("nodekey") == "" {
+		return errors.New("--nodekey is required")
+	}
+
+	common.InitDir(ctx.String("datadir"))
+	return smpc.OpenLocalDbForBackup(ctx.String("nodekey"))
+}
+
+// readPassphrases read the passphrases from the files given by --passphrasefile
+func readPassphrases(ctx *cli.Context) ([]string, error) {
+	files := ctx.StringSlice("passphrasefile")
+	if len(files) == 0 {
+		return nil, errors.New("--passphrasefile is required")
+	}
+
+	ret := make([]string, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, strings.TrimRight(string(b), "\r\n"))
+	}
+
+	return ret, nil
+}
+
+// parseSplit parse threshold/total
+func parseSplit(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	tn := strings.Split(s, "/")
+	if len(tn) != 2 {
+		return 0, 0, errors.New("invalid --split,format is threshold/total")
+	}
+
+	t, err := strconv.Atoi(tn[0])
+	if err != nil {
+		return 0, 0, errors.New("invalid --split,format is threshold/total")
+	}
+
+	n, err := strconv.Atoi(tn[1])
+	if err != nil {
+		return 0, 0, errors.New("invalid --split,format is threshold/total")
+	}
+
+	return t, n, nil
+}
+
+func backupExport(ctx *cli.Context) error {
+	threshold, total, err := parseSplit(ctx.String("split"))
+	if err != nil {
+		return err
+	}
+
+	passphrases, err := readPassphrases(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := openBackupDb(ctx); err != nil {
+		return err
+	}
+	defer smpc.CloseLocalDbForBackup()
+
+	files, err := smpc.ExportBackup(ctx.String("pubkey"), passphrases, threshold, total)
+	if err != nil {
+		return err
+	}
+
+	out := ctx.String("out")
+	for i, b := range files {
+		name := out
+		if total != 0 {
+			name = fmt.Sprintf("%v.%v", out, i+1)
+		}
+
+		if err := ioutil.WriteFile(name, b, 0600); err != nil {
+			return err
+		}
+
+		fmt.Println("backup written to", name)
+	}
+
+	return nil
+}
+
+func backupImport(ctx *cli.Context) error {
+	in := ctx.StringSlice("in")
+	if len(in) == 0 {
+		return errors.New("--in is required")
+	}
+
+	passphrases, err := readPassphrases(ctx)
+	if err != nil {
+		return err
+	}
+
+	files := make([][]byte, 0, len(in))
+	for _, f := range in {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, b)
+	}
+
+	if err := openBackupDb(ctx); err != nil {
+		return err
+	}
+	defer smpc.CloseLocalDbForBackup()
+
+	pubkeys, err := smpc.ImportBackup(files, passphrases, ctx.Bool("force"))
+	for _, pk := range pubkeys {
+		fmt.Println("imported pubkey", pk)
+	}
+
+	return err
+}
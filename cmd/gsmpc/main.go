@@ -97,9 +97,9 @@ func StartSmpc(c *cli.Context) {
 
 	time.Sleep(time.Duration(30) * time.Second)
 
-	rpcsmpc.RPCInit(rpcport)
+	rpcsmpc.RPCInit(rpcport, wsport, wsorigins)
 
-	params := &smpc.LunchParams{WaitMsg: waitmsg, TryTimes: trytimes, PreSignNum: presignnum, MaxAcceptTime: maxaccepttime, Bip32Pre: bip32pre, SyncPreSign: syncpresign}
+	params := &smpc.LunchParams{WaitMsg: waitmsg, TryTimes: trytimes, PreSignNum: presignnum, MaxAcceptTime: maxaccepttime, Bip32Pre: bip32pre, SyncPreSign: syncpresign, RefreshInterval: refreshinterval, PreParamsNum: preparamsnum, Webhooks: webhooks, ApprovalPolicy: approvalpolicy}
 	smpc.Start(params)
 	select {} // note for server, or for client
 }
@@ -135,6 +135,12 @@ var (
 	maxaccepttime    uint64
 	bip32pre     uint64
 	syncpresign string
+	refreshinterval uint64
+	preparamsnum uint64
+	wsport       int
+	wsorigins    string
+	webhooks     []string
+	approvalpolicy string
 
 	statDir = "stat"
 
@@ -153,6 +159,7 @@ type gsmpcConf struct {
 	Bootnodes string
 	Port      int
 	Rpcport   int
+	Webhooks  []string
 }
 
 func init() {
@@ -162,6 +169,7 @@ func init() {
 	app.Commands = []cli.Command{
 		versionCommand,
 		licenseCommand,
+		backupCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 	app.Flags = []cli.Flag{
@@ -186,6 +194,11 @@ func init() {
 		cli.Uint64Flag{Name: "maxaccepttime", Value: 604800, Usage: "the max time to wait for accept from all nodes", Destination: &maxaccepttime},
 		cli.Uint64Flag{Name: "bip32pre", Value: 4, Usage: "the total counts of pre-sign data for bip32 child pubkey", Destination: &bip32pre},
 		cli.StringFlag{Name: "sync-presign", Value: "true", Usage: "synchronize presign data between group nodes", Destination: &syncpresign},
+		cli.Uint64Flag{Name: "refreshinterval", Value: 0, Usage: "the interval(seconds) of refreshing the shares of all EC256K1 pubkeys,0 means disabled", Destination: &refreshinterval},
+		cli.Uint64Flag{Name: "preparamsnum", Value: 2, Usage: "the number of pre-generated paillier key and ntilde data kept in local db for keygen and reshare,0 means disabled", Destination: &preparamsnum},
+		cli.IntFlag{Name: "wsport", Value: 0, Usage: "the listen port of the websocket rpc endpoint for subscribing the request events,0 means disabled", Destination: &wsport},
+		cli.StringFlag{Name: "wsorigins", Value: "", Usage: "origins from which to accept websocket requests,comma separated,* means any origin", Destination: &wsorigins},
+		cli.StringFlag{Name: "approvalpolicy", Value: "", Usage: "the toml/json policy file,the node agrees or disagrees the keygen/sign requests of mode 2 by the policy instead of waiting for AcceptReqAddr/AcceptSign,the requests of mode 0 still wait for the manual approval", Destination: &approvalpolicy},
 	}
 	gitVersion = params.VersionWithMeta
 }
@@ -212,6 +225,7 @@ func getConfig() error {
 		bnodes = cf.Gsmpc.Bootnodes
 		pt = cf.Gsmpc.Port
 		rport = cf.Gsmpc.Rpcport
+		webhooks = cf.Gsmpc.Webhooks
 	}
 	if nkey != "" && keyfile == "" {
 		keyfile = nkey
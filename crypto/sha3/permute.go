@@ -0,0 +1,13 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sha3
+
+// KeccakF1600 applies the Keccak permutation to a 1600b-wide
+// state represented as a slice of 25 uint64s.
+// It is exported for the sponge constructions built outside
+// this package, such as STROBE.
+func KeccakF1600(a *[25]uint64) {
+	keccakF1600(a)
+}
@@ -64,13 +64,17 @@ func GetRandomIntFromZnStar(n *big.Int) *big.Int {
 
 // GetSafeRandomPrimeInt get safe big prime
 func GetSafeRandomPrimeInt() (*big.Int, *big.Int) {
+	return GetSafeRandomPrimeIntByLength(1024) // L/2
+}
+
+// GetSafeRandomPrimeIntByLength get safe big prime p = 2q+1 with bit length `length`
+func GetSafeRandomPrimeIntByLength(length int) (*big.Int, *big.Int) {
 	var q *big.Int
 	var p *big.Int
 	var err error
 
 	one := big.NewInt(1)
 	two := big.NewInt(2)
-	length := 1024 // L/2
 
 	for {
 		q, err = rand.Prime(rand.Reader, length-1)
@@ -1501,10 +1501,15 @@ func GetEnodeStatus(enode string) (string, error) {
 	common.Debug("GetEnodeStatus", "selfid", selfid, "node.ID", n.ID)
 	if n.ID.String() == selfid {
 		return "OnLine", nil
-	} else {
-		return getOnLine(n.ID), nil
 	}
-	return "OffLine", nil
+
+	// the peer is connected but can not run any session with this node
+	status := getOnLine(n.ID)
+	if vers, advertised := GetNodeVersions(n.ID); advertised && !IsCompatible(n.ID) {
+		return "Incompatible", fmt.Errorf("%v, protocol versions %v are incompatible with local versions %v", status, vers, GetLocalVersions())
+	}
+
+	return status, nil
 }
 
 func StoreGroupToDb(groupInfo *Group) error { //nooo
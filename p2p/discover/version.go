@@ -0,0 +1,158 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  huangweijun@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package discover
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+)
+
+var (
+	// DefaultVersions the protocol versions assumed for the nodes that have not advertised theirs,
+	// such as the nodes that are not connected yet or run an old release
+	DefaultVersions = []uint{1}
+
+	localVersions = DefaultVersions
+	nodeVersions  = make(map[NodeID][]uint)
+	versionLock   sync.RWMutex
+)
+
+// SetLocalVersions set the protocol versions supported by this node,they are advertised to every peer on connection
+func SetLocalVersions(vers []uint) {
+	versionLock.Lock()
+	defer versionLock.Unlock()
+	localVersions = sortVersions(vers)
+}
+
+// GetLocalVersions get the protocol versions supported by this node
+func GetLocalVersions() []uint {
+	versionLock.RLock()
+	defer versionLock.RUnlock()
+	return append([]uint{}, localVersions...)
+}
+
+// UpdateNodeVersions save the protocol versions advertised by the node
+func UpdateNodeVersions(nodeID NodeID, vers []uint) {
+	versionLock.Lock()
+	nodeVersions[nodeID] = sortVersions(vers)
+	versionLock.Unlock()
+	common.Debug("==== UpdateNodeVersions() ====", "nodeid", nodeID, "versions", vers)
+}
+
+// GetNodeVersions get the protocol versions of the node,advertised is false if DefaultVersions is assumed
+func GetNodeVersions(nodeID NodeID) (vers []uint, advertised bool) {
+	if nodeID == GetLocalID() {
+		return GetLocalVersions(), true
+	}
+
+	versionLock.RLock()
+	defer versionLock.RUnlock()
+	if v, ok := nodeVersions[nodeID]; ok {
+		return append([]uint{}, v...), true
+	}
+
+	return append([]uint{}, DefaultVersions...), false
+}
+
+// IsCompatible does the node share at least one protocol version with this node
+func IsCompatible(nodeID NodeID) bool {
+	vers, _ := GetNodeVersions(nodeID)
+	_, ok := highestCommonVersion([][]uint{GetLocalVersions(), vers})
+	return ok
+}
+
+// NegotiateVersion get the highest protocol version supported by this node and all the nodes,
+// the error names every node that shares no version with this node
+func NegotiateVersion(nodeIDs []NodeID) (uint, error) {
+	local := GetLocalVersions()
+	all := [][]uint{local}
+	var incompatible []string
+	for _, id := range nodeIDs {
+		vers, _ := GetNodeVersions(id)
+		all = append(all, vers)
+		if _, ok := highestCommonVersion([][]uint{local, vers}); !ok {
+			incompatible = append(incompatible, fmt.Sprintf("%v supports %v", id.String()[:16], vers))
+		}
+	}
+
+	if len(incompatible) != 0 {
+		return 0, fmt.Errorf("incompatible protocol versions, local node supports %v, %v", local, strings.Join(incompatible, ", "))
+	}
+
+	v, ok := highestCommonVersion(all)
+	if !ok {
+		return 0, errors.New("incompatible protocol versions, no version is supported by all nodes of the group")
+	}
+
+	return v, nil
+}
+
+// sortVersions copy vers in ascending order without duplicates
+func sortVersions(vers []uint) []uint {
+	ret := make([]uint, 0, len(vers))
+	for _, v := range vers {
+		found := false
+		for _, r := range ret {
+			if r == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ret = append(ret, v)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// highestCommonVersion get the highest version that is in every list of vers
+func highestCommonVersion(vers [][]uint) (uint, bool) {
+	if len(vers) == 0 {
+		return 0, false
+	}
+
+	for i := len(vers[0]) - 1; i >= 0; i-- {
+		v := vers[0][i]
+		shared := true
+		for _, vs := range vers[1:] {
+			found := false
+			for _, x := range vs {
+				if x == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				shared = false
+				break
+			}
+		}
+
+		if shared {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
@@ -231,6 +231,9 @@ func (e *Emitter) removePeer(p *p2p.Peer) {
 
 func HandlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
 	emitter.addPeer(peer, rw)
+	if err := p2p.Send(rw, Version_msgCode, discover.GetLocalVersions()); err != nil {
+		common.Debug("==== handle() ====", "peerID", peer.ID(), "send versions err", err)
+	}
 	//go discover.UpdateGroupSDKNode(peer.ID(), peer.RemoteAddr())
 	for {
 		msg, err := rw.ReadMsg()
@@ -286,6 +289,16 @@ func HandlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
 				go Xp_callEvent(string(recv))
 			}
 			break
+		case Version_msgCode:
+			var vers []uint
+			err := rlp.Decode(msg.Payload, &vers)
+			if err != nil {
+				common.Debug("Err: decode versions msg", "err", err)
+				return err
+			} else {
+				discover.UpdateNodeVersions(peer.ID(), vers)
+			}
+			break
 		default:
 			common.Debug("unkown msg code", "", "")
 			break
@@ -44,6 +44,9 @@ const (
 
 	maxKnownTxs = 30 // Maximum transactions hashes to keep in the known list (prevent DOS)
 
+	// Version_msgCode advertise the protocol versions supported by the node,it is in the range of NumberOfMessageCodes so the old nodes just ignore it
+	Version_msgCode = Xp_msgCode + 1
+
 	broatcastFailTimes = 0 //30 Redo Send times( 30 * 2s = 60 s)
 	broatcastFailOnce  = 2
 )
@@ -349,6 +349,14 @@ func CreateSDKGroup(threshold string, enodes []string, subGroup bool) (string, i
 	if err != nil {
 		return "",0,err.Error()
 	}
+	ids := make([]discover.NodeID, 0, len(enode))
+	for _, n := range enode {
+		ids = append(ids, n.ID)
+	}
+	if _, err := discover.NegotiateVersion(ids); err != nil {
+		common.Info("CreateSDKGroup", "gid", gid, "err", err)
+		return "", 0, err.Error()
+	}
 	discover.GroupSDK.Lock()
 	exist := false
 	for i := range SdkGroup {
@@ -396,6 +404,40 @@ func GetEnodeStatus(enode string) (string, error) {
 	return discover.GetEnodeStatus(enode)
 }
 
+// SetProtocolVersions set the protocol versions supported by this node
+func SetProtocolVersions(vers []uint) {
+	discover.SetLocalVersions(vers)
+}
+
+// GetLocalVersions get the protocol versions supported by this node
+func GetLocalVersions() []uint {
+	return discover.GetLocalVersions()
+}
+
+// GetEnodeVersions get the protocol versions of enode,advertised is false if they are assumed
+func GetEnodeVersions(enode string) ([]uint, bool, error) {
+	node, err := discover.ParseNode(enode)
+	if err != nil {
+		return nil, false, err
+	}
+	vers, advertised := discover.GetNodeVersions(node.ID)
+	return vers, advertised, nil
+}
+
+// NegotiateGroupVersion get the highest protocol version supported by all nodes of the group
+func NegotiateGroupVersion(gID string) (uint, error) {
+	gid, err := discover.HexID(gID)
+	if err != nil || checkExistGroup(gid) == false {
+		return 0, fmt.Errorf("group %v not exist", gID)
+	}
+
+	ids := make([]discover.NodeID, 0)
+	for _, n := range getSDKGroupNodes(gid) {
+		ids = append(ids, n.ID)
+	}
+	return discover.NegotiateVersion(ids)
+}
+
 func CheckAddPeer(threshold string, enodes []string, subGroup bool) (bool, error) {
 	thshall := false
 	es := strings.Split(threshold, "/")
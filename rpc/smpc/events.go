@@ -0,0 +1,83 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"context"
+
+	"github.com/anyswap/FastMulThreshold-DSA/rpc"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc"
+)
+
+// EventFilter the filter of the event subscriptions,the empty fields match any event
+type EventFilter = smpc.EventFilter
+
+// subscribeEvents push the events of type evtype that pass the filter to the subscriber,all types if evtype is empty,
+// the lagged events are always pushed so that the subscriber knows it missed some events
+func subscribeEvents(ctx context.Context, evtype string, filter *EventFilter) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		id, ch := smpc.SubscribeEvents(0)
+		defer smpc.UnsubscribeEvents(id)
+
+		for {
+			select {
+			case ev := <-ch:
+				if (evtype == "" || ev.Type == evtype || ev.Type == smpc.EventLagged) && filter.Match(ev) {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// PendingApproval subscribe the requests waiting for the approval of this node,smpc_subscribe with "pendingApproval"
+func (service *Service) PendingApproval(ctx context.Context, filter *EventFilter) (*rpc.Subscription, error) {
+	return subscribeEvents(ctx, smpc.EventPendingApproval, filter)
+}
+
+// Approval subscribe the approvals received from the nodes,smpc_subscribe with "approval"
+func (service *Service) Approval(ctx context.Context, filter *EventFilter) (*rpc.Subscription, error) {
+	return subscribeEvents(ctx, smpc.EventApproval, filter)
+}
+
+// Round subscribe the round progress of the mpc process,smpc_subscribe with "round"
+func (service *Service) Round(ctx context.Context, filter *EventFilter) (*rpc.Subscription, error) {
+	return subscribeEvents(ctx, smpc.EventRound, filter)
+}
+
+// Finished subscribe the result or error of the finished requests,smpc_subscribe with "finished"
+func (service *Service) Finished(ctx context.Context, filter *EventFilter) (*rpc.Subscription, error) {
+	return subscribeEvents(ctx, smpc.EventFinished, filter)
+}
+
+// Events subscribe all the request lifecycle events,smpc_subscribe with "events"
+func (service *Service) Events(ctx context.Context, filter *EventFilter) (*rpc.Subscription, error) {
+	return subscribeEvents(ctx, "", filter)
+}
@@ -69,6 +69,8 @@ type Version struct {
 type EnodeStatus struct {
 	Enode  string
 	Status string
+	Versions []uint // protocol versions of the enode
+	Advertised bool // false if the enode has not advertised its versions and the default ones are assumed
 }
 
 func packageResult(status, tip, errors string, msg interface{}) map[string]interface{} {
@@ -103,6 +105,7 @@ func (service *Service) GetEnode() map[string]interface{} {
 type GroupID struct {
 	Gid  string
 	Sgid string
+	Version uint // the protocol version that the sessions of the group run on
 }
 
 // GroupInfo group info
@@ -110,6 +113,7 @@ type GroupInfo struct {
 	Gid    string
 	Count  int
 	Enodes []string
+	Version uint // the protocol version that the sessions of the group run on,0 if the nodes are incompatible
 }
 
 // ReshareGroup create reshare group
@@ -169,7 +173,8 @@ func (service *Service) CreateSDKGroup(threshold string, enodes []string, subGro
 	}
 	common.Debug("==== CreateSDKGroup() ====","gid",gid,"count",count)
 	//fmt.Printf("==== CreateSDKGroup() ====, gid: %v, count: %v\n", gid, count)
-	ret := &GroupID{Gid: gid}
+	ver, _ := layer2.NegotiateGroupVersion(gid)
+	ret := &GroupID{Gid: gid, Version: ver}
 	return packageResult(SUCCESS, "", "", ret)
 }
 
@@ -216,7 +221,11 @@ func getGroupByID(gID string) map[string]interface{} {
 				fmt.Printf("==== getGroupByID() ====, gid: %v, enode: %v\n", gid, enode)
 				addGroupChanged = true
 			}
-			ret := &GroupInfo{Gid: gID, Count: len(g.Nodes), Enodes: enodes}
+			ver, err := layer2.NegotiateGroupVersion(gID)
+			if err != nil {
+				tip = err.Error()
+			}
+			ret := &GroupInfo{Gid: gID, Count: len(g.Nodes), Enodes: enodes, Version: ver}
 			fmt.Printf("==== getGroupByID() ====, gid: %v, ret: %v\n", gid, ret)
 			return packageResult(stat, tip, tip, ret)
 		}
@@ -251,7 +260,8 @@ func getSDKGroup(enode, groupType string) map[string]interface{} {
 			}
 		}
 		if addGroup {
-			ret := &GroupInfo{Gid: gid.String(), Count: len(g.Nodes), Enodes: enodes}
+			ver, _ := layer2.NegotiateGroupVersion(gid.String())
+			ret := &GroupInfo{Gid: gid.String(), Count: len(g.Nodes), Enodes: enodes, Version: ver}
 			group = append(group, *ret)
 		}
 	}
@@ -274,6 +284,7 @@ func (service *Service) GetEnodeStatus(enode string) map[string]interface{} {
 		status = FAIL
 	}
 	es.Status = stat
+	es.Versions, es.Advertised, _ = layer2.GetEnodeVersions(enode)
 
 	errString := ""
 	if err != nil {
@@ -18,6 +18,7 @@
 package smpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -116,6 +117,37 @@ func (service *Service) AcceptReqAddr(raw string) map[string]interface{} {
 	}
 }
 
+// ImportKey  Import an existing private key into the group,it is approved just like generating pubkey
+//  Raw is a special signed transaction,the data is the same as reqaddr except:
+// {
+// "TxType":"IMPORTKEY",
+// ...
+// "Deal":{the shares dealt and encrypted to the nodes by the key owner,see gsmpc-client -cmd IMPORTKEY}
+// }
+func (service *Service) ImportKey(raw string) map[string]interface{} {
+	common.Debug("===============ImportKey================", "raw", raw)
+
+	data := make(map[string]interface{})
+	key, tip, err := smpc.ImportKey(raw)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = key
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
 // GetReqAddrNonce  Get the nonce value of the special transaction generating pubkey 
 func (service *Service) GetReqAddrNonce(account string) map[string]interface{} {
 	//fmt.Println("%v =========call rpc.GetReqAddrNonce from web,account = %v =================", common.CurrentTime(), account)
@@ -353,6 +385,109 @@ func (service *Service) GetSignStatus(key string) map[string]interface{} {
 	}
 }
 
+// GetBlameRecords get the signed blame records by sign key or pubkey(for pre-sign)
+func (service *Service) GetBlameRecords(key string) map[string]interface{} {
+	common.Debug("==================GetBlameRecords====================", "key", key)
+	data := make(map[string]interface{})
+	records := smpc.GetBlameRecords(key)
+	ret, err := json.Marshal(records)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    "",
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = string(ret)
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
+// GetPreParamsStatus get the number of pre-generated paillier key and ntilde data in the local pool for every paillier key length
+func (service *Service) GetPreParamsStatus() map[string]interface{} {
+	common.Debug("==================GetPreParamsStatus====================")
+	data := make(map[string]interface{})
+	status := smpc.GetPreParamsStatus()
+	ret, err := json.Marshal(status)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    "",
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = string(ret)
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
+// Refresh refresh the shares of pubkey in its keygen group,the pubkey does not change
+// raw is the refresh command signed by the account of one node in the keygen group,all nodes of the group approve it by AcceptRecoverShare
+// return the refresh key,which is also the key of the blame records and of GetRecoverShareStatus
+func (service *Service) Refresh(raw string) map[string]interface{} {
+	common.Debug("===================Refresh=====================", "raw", raw)
+
+	data := make(map[string]interface{})
+	key, tip, err := smpc.Refresh(raw)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = key
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
+// GetSchnorrPubKey get the x-only pubkey that verifies the SCHNORR256K1 signatures of pubkey
+// taptweak is the same as the TapTweak of sign command
+func (service *Service) GetSchnorrPubKey(pubkey string, taptweak string) map[string]interface{} {
+	common.Debug("===================GetSchnorrPubKey=====================", "pubkey", pubkey, "taptweak", taptweak)
+
+	data := make(map[string]interface{})
+	xonly, err := smpc.GetSchnorrPubKey(pubkey, taptweak)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    "",
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = xonly
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
 // ReShare do reshare
 func (service *Service) ReShare(raw string) map[string]interface{} {
 	common.Debug("===================ReShare=====================", "raw", raw)
@@ -482,6 +617,98 @@ func (service *Service) GetReShareStatus(key string) map[string]interface{} {
 	}
 }
 
+// RecoverShare  Recover the lost share of a node with the other nodes of the keygen group 
+func (service *Service) RecoverShare(raw string) map[string]interface{} {
+	common.Debug("===================RecoverShare=====================", "raw", raw)
+
+	data := make(map[string]interface{})
+	key, tip, err := smpc.RecoverShare(raw)
+	common.Debug("===================recover share=====================", "key", key, "err", err, "raw", raw)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = key
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
+// AcceptRecoverShare Agree to recover share
+func (service *Service) AcceptRecoverShare(raw string) map[string]interface{} {
+	data := make(map[string]interface{})
+	ret, tip, err := smpc.RPCAcceptRecoverShare(raw)
+	if err != nil {
+		data["result"] = "Failure"
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = ret
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
+// GetCurNodeRecoverShareInfo  Get the recover share command approval list 
+func (service *Service) GetCurNodeRecoverShareInfo() map[string]interface{} {
+	s, tip, err := smpc.GetCurNodeRecoverShareInfo()
+	if err != nil {
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   "",
+		}
+	}
+
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   s,
+	}
+}
+
+// GetRecoverShareStatus  Get the result of the recover share command  
+func (service *Service) GetRecoverShareStatus(key string) map[string]interface{} {
+	data := make(map[string]interface{})
+	ret, tip, err := smpc.GetRecoverShareStatus(key)
+	if err != nil {
+		data["result"] = ""
+		return map[string]interface{}{
+			"Status": "Error",
+			"Tip":    tip,
+			"Error":  err.Error(),
+			"Data":   data,
+		}
+	}
+
+	data["result"] = ret
+	return map[string]interface{}{
+		"Status": "Success",
+		"Tip":    "",
+		"Error":  "",
+		"Data":   data,
+	}
+}
+
 // PreGenSignData  Generate the relevant data required by the sign command in advance 
 // raw tx:
 // data = pubkey + subgids
@@ -656,14 +883,18 @@ func (service *Service) GetSmpcAddr(pubkey string) map[string]interface{} {
 
 var (
 	rpcport  int
+	wsport   int
+	wsorigins string
 	endpoint string = "0.0.0.0"
 	server   *rpc.Server
 	err      error
 )
 
-// RPCInit rpc start init
-func RPCInit(port int) {
+// RPCInit rpc start init,the websocket endpoint is started only if ws port is not 0
+func RPCInit(port int, wport int, worigins string) {
 	rpcport = port
+	wsport = wport
+	wsorigins = worigins
 	go func() {
 	    err := startRPCServer()
 	    if err != nil {
@@ -689,6 +920,9 @@ func startRPCServer() error {
 		if err := server.RegisterName("smpc", service); err != nil {
 			panic(err)
 		}
+		if err := server.RegisterName("smpc2", new(ServiceV2)); err != nil {
+			panic(err)
+		}
 
 		// All APIs registered, start the HTTP listener
 		var (
@@ -767,6 +1001,23 @@ func startRPCServer() error {
 		rpcstring := "==================== RPC Service Start! url = " + fmt.Sprintf("http://%s", endpoint) + " ====================="
 		log.Info(rpcstring)
 
+		if wsport != 0 {
+			wsendpoint := "0.0.0.0:" + strconv.Itoa(wsport)
+			wslistener, err := net.Listen("tcp", wsendpoint)
+			if err != nil {
+				panic(err)
+			}
+
+			go func() {
+			    err2 := rpc.NewWSServer(splitAndTrim(wsorigins), server).Serve(wslistener)
+			    if err2 != nil {
+				log.Error("============== new websocket server fail ==============","err",err2)
+				return
+			    }
+			}()
+			log.Info("==================== WebSocket RPC Service Start! url = " + fmt.Sprintf("ws://%s", wsendpoint) + " =====================")
+		}
+
 		exit := make(chan int)
 		<-exit
 
@@ -0,0 +1,501 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc"
+)
+
+// the error codes of the smpc2 namespace,they are stable and never reused
+const (
+	// ErrCodeInvalidParams the params are missing or malformed,the same as the json-rpc standard code
+	ErrCodeInvalidParams = -32602
+
+	// ErrCodeInternal the node fails to process the request for an internal reason,the same as the json-rpc standard code
+	ErrCodeInternal = -32603
+
+	// ErrCodeNotFound the request key or pubkey is not found in the node
+	ErrCodeNotFound = 1001
+
+	// ErrCodeRejected the command data is invalid or the command is rejected by the node
+	ErrCodeRejected = 1002
+)
+
+// Error the json-rpc error object of the smpc2 namespace
+type Error struct {
+	Code    int
+	Message string
+	Data    *ErrorData
+}
+
+// ErrorData the machine-readable details of the error,it is the data field of the json-rpc error object
+type ErrorData struct {
+	SmpcCode int    `json:",omitempty"` // the code of the smpc error,see smpc/errors.go,0 if it has no code
+	Tip      string `json:",omitempty"` // the hint of the smpc back-end
+}
+
+// Error the message of the error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCode the code of the error
+func (e *Error) ErrorCode() int {
+	return e.Code
+}
+
+// ErrorData the data of the error
+func (e *Error) ErrorData() interface{} {
+	if e.Data == nil {
+		return nil
+	}
+
+	return e.Data
+}
+
+// invalidParams the error of missing or malformed params
+func invalidParams(msg string) error {
+	return &Error{Code: ErrCodeInvalidParams, Message: msg}
+}
+
+// smpcError convert the error returned by smpc to the error with code
+func smpcError(code int, tip string, err error) error {
+	scode, msg := smpc.GetRetErrCode(err)
+	e := &Error{Code: code, Message: msg}
+	if scode != 0 || tip != "" {
+		e.Data = &ErrorData{SmpcCode: scode, Tip: tip}
+	}
+
+	return e
+}
+
+// ServiceV2 the smpc2 namespace,the methods take and return typed data and report failures as json-rpc errors
+// the group methods of p2p are only in the smpc namespace
+type ServiceV2 struct{}
+
+// CommandReply the reply of submitting a command
+type CommandReply struct {
+	Key string // the key of the command,it is used to query the status of the command
+}
+
+// Bip32ChildKeyArgs the args of smpc2_getBip32ChildKey
+type Bip32ChildKeyArgs struct {
+	RootPubKey string
+	InputCode  string // m/x1/x2/..../xn
+}
+
+// AccountsArgs the args of smpc2_getAccounts
+type AccountsArgs struct {
+	Account string
+	Mode    string
+}
+
+// SchnorrPubKeyArgs the args of smpc2_getSchnorrPubKey
+type SchnorrPubKeyArgs struct {
+	PubKey   string
+	TapTweak string
+}
+
+// parseNonce parse the nonce returned by smpc
+func parseNonce(nonce string) (uint64, error) {
+	n, err := strconv.ParseUint(nonce, 10, 64)
+	if err != nil {
+		return 0, &Error{Code: ErrCodeInternal, Message: "invalid nonce " + nonce}
+	}
+
+	return n, nil
+}
+
+// ReqSmpcAddr submit the keygen command,see Service.ReqSmpcAddr for raw
+func (service *ServiceV2) ReqSmpcAddr(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.ReqKeyGen(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// ImportKey submit the import key command
+func (service *ServiceV2) ImportKey(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.ImportKey(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// AcceptReqAddr agree or disagree to generate pubkey,see Service.AcceptReqAddr for raw
+func (service *ServiceV2) AcceptReqAddr(raw string) (bool, error) {
+	if raw == "" {
+		return false, invalidParams("raw is empty")
+	}
+
+	_, tip, err := smpc.RPCAcceptReqAddr(raw)
+	if err != nil {
+		return false, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return true, nil
+}
+
+// GetReqAddrNonce get the nonce of the keygen command of account
+func (service *ServiceV2) GetReqAddrNonce(account string) (uint64, error) {
+	if account == "" {
+		return 0, invalidParams("account is empty")
+	}
+
+	ret, tip, err := smpc.GetReqAddrNonce(account)
+	if err != nil {
+		return 0, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return parseNonce(ret)
+}
+
+// GetCurNodeReqAddrInfo get the keygen commands to be approved by account
+func (service *ServiceV2) GetCurNodeReqAddrInfo(account string) ([]*smpc.ReqAddrReply, error) {
+	ret, tip, err := smpc.GetCurNodeReqAddrInfo(account)
+	if err != nil {
+		return nil, smpcError(ErrCodeInternal, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetReqAddrStatus get the result of the keygen command
+func (service *ServiceV2) GetReqAddrStatus(key string) (*smpc.ReqAddrStatus, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	ret, tip, err := smpc.GetReqAddrStatusData(key)
+	if err != nil {
+		return nil, smpcError(ErrCodeNotFound, tip, err)
+	}
+
+	return ret, nil
+}
+
+// Sign submit the sign command,see Service.Sign for raw
+func (service *ServiceV2) Sign(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.Sign(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// AcceptSign agree or disagree to sign,see Service.AcceptSign for raw
+func (service *ServiceV2) AcceptSign(raw string) (bool, error) {
+	if raw == "" {
+		return false, invalidParams("raw is empty")
+	}
+
+	_, tip, err := smpc.RPCAcceptSign(raw)
+	if err != nil {
+		return false, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return true, nil
+}
+
+// GetSignNonce get the nonce of the sign command of account
+func (service *ServiceV2) GetSignNonce(account string) (uint64, error) {
+	if account == "" {
+		return 0, invalidParams("account is empty")
+	}
+
+	ret, tip, err := smpc.GetSignNonce(account)
+	if err != nil {
+		return 0, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return parseNonce(ret)
+}
+
+// GetCurNodeSignInfo get the sign commands to be approved by account
+func (service *ServiceV2) GetCurNodeSignInfo(account string) ([]*smpc.SignCurNodeInfo, error) {
+	ret, tip, err := smpc.GetCurNodeSignInfo(account)
+	if err != nil {
+		return nil, smpcError(ErrCodeInternal, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetSignStatus get the result of the sign command
+func (service *ServiceV2) GetSignStatus(key string) (*smpc.SignStatus, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	ret, tip, err := smpc.GetSignStatusData(key)
+	if err != nil {
+		return nil, smpcError(ErrCodeNotFound, tip, err)
+	}
+
+	return ret, nil
+}
+
+// PreGenSignData generate the pre-sign data of the pubkey in advance,see Service.PreGenSignData for raw
+func (service *ServiceV2) PreGenSignData(raw string) (bool, error) {
+	if raw == "" {
+		return false, invalidParams("raw is empty")
+	}
+
+	tip, err := smpc.PreGenSignData(raw)
+	if err != nil {
+		return false, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return true, nil
+}
+
+// GetBlameRecords get the signed blame records by sign key or pubkey(for pre-sign)
+func (service *ServiceV2) GetBlameRecords(key string) ([]*smpc.BlameRecord, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	return smpc.GetBlameRecords(key), nil
+}
+
+// GetPreParamsStatus get the number of pre-generated paillier key and ntilde data in the local pool
+func (service *ServiceV2) GetPreParamsStatus() ([]*smpc.PreParamsStatus, error) {
+	return smpc.GetPreParamsStatus(), nil
+}
+
+// Refresh submit the refresh command,it is approved by AcceptRecoverShare and its result is got by GetRecoverShareStatus
+func (service *ServiceV2) Refresh(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.Refresh(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// GetSchnorrPubKey get the x-only pubkey that verifies the SCHNORR256K1 signatures of pubkey
+func (service *ServiceV2) GetSchnorrPubKey(args SchnorrPubKeyArgs) (string, error) {
+	if args.PubKey == "" {
+		return "", invalidParams("PubKey is required")
+	}
+
+	xonly, err := smpc.GetSchnorrPubKey(args.PubKey, args.TapTweak)
+	if err != nil {
+		return "", smpcError(ErrCodeNotFound, "", err)
+	}
+
+	return xonly, nil
+}
+
+// ReShare submit the reshare command
+func (service *ServiceV2) ReShare(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.ReShare(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// AcceptReShare agree or disagree to reshare
+func (service *ServiceV2) AcceptReShare(raw string) (bool, error) {
+	if raw == "" {
+		return false, invalidParams("raw is empty")
+	}
+
+	_, tip, err := smpc.RPCAcceptReShare(raw)
+	if err != nil {
+		return false, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return true, nil
+}
+
+// GetReShareNonce get the nonce of the reshare command of account
+func (service *ServiceV2) GetReShareNonce(account string) (uint64, error) {
+	if account == "" {
+		return 0, invalidParams("account is empty")
+	}
+
+	ret, tip, err := smpc.GetReShareNonce(account)
+	if err != nil {
+		return 0, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return parseNonce(ret)
+}
+
+// GetCurNodeReShareInfo get the reshare commands to be approved
+func (service *ServiceV2) GetCurNodeReShareInfo() ([]*smpc.ReShareCurNodeInfo, error) {
+	ret, tip, err := smpc.GetCurNodeReShareInfo()
+	if err != nil {
+		return nil, smpcError(ErrCodeInternal, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetReShareStatus get the result of the reshare command
+func (service *ServiceV2) GetReShareStatus(key string) (*smpc.ReShareStatus, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	ret, tip, err := smpc.GetReShareStatusData(key)
+	if err != nil {
+		return nil, smpcError(ErrCodeNotFound, tip, err)
+	}
+
+	return ret, nil
+}
+
+// RecoverShare submit the recover share command
+func (service *ServiceV2) RecoverShare(raw string) (*CommandReply, error) {
+	if raw == "" {
+		return nil, invalidParams("raw is empty")
+	}
+
+	key, tip, err := smpc.RecoverShare(raw)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return &CommandReply{Key: key}, nil
+}
+
+// AcceptRecoverShare agree or disagree to recover share
+func (service *ServiceV2) AcceptRecoverShare(raw string) (bool, error) {
+	if raw == "" {
+		return false, invalidParams("raw is empty")
+	}
+
+	_, tip, err := smpc.RPCAcceptRecoverShare(raw)
+	if err != nil {
+		return false, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return true, nil
+}
+
+// GetCurNodeRecoverShareInfo get the recover share commands to be approved
+func (service *ServiceV2) GetCurNodeRecoverShareInfo() ([]*smpc.RecoverShareCurNodeInfo, error) {
+	ret, tip, err := smpc.GetCurNodeRecoverShareInfo()
+	if err != nil {
+		return nil, smpcError(ErrCodeInternal, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetRecoverShareStatus get the result of the recover share command
+func (service *ServiceV2) GetRecoverShareStatus(key string) (*smpc.RecoverShareStatus, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	ret, tip, err := smpc.GetRecoverShareStatusData(key)
+	if err != nil {
+		return nil, smpcError(ErrCodeNotFound, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetBip32ChildKey get the child pubkey of the root pubkey by the bip32 path
+func (service *ServiceV2) GetBip32ChildKey(args Bip32ChildKeyArgs) (string, error) {
+	if args.RootPubKey == "" || args.InputCode == "" {
+		return "", invalidParams("RootPubKey and InputCode are required")
+	}
+
+	pub, tip, err := smpc.GetBip32ChildKey(args.RootPubKey, args.InputCode)
+	if err != nil {
+		return "", smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return pub, nil
+}
+
+// GetAccounts get all pubkeys generated by account in mode
+func (service *ServiceV2) GetAccounts(args AccountsArgs) (*smpc.PubAccounts, error) {
+	ret, tip, err := smpc.GetAccounts(args.Account, args.Mode)
+	if err != nil {
+		return nil, smpcError(ErrCodeInternal, tip, err)
+	}
+
+	pa, ok := ret.(*smpc.PubAccounts)
+	if !ok {
+		return nil, smpcError(ErrCodeInternal, "", errors.New("get accounts fail"))
+	}
+
+	return pa, nil
+}
+
+// GetSmpcAddr get the coin addresses of the pubkey
+func (service *ServiceV2) GetSmpcAddr(pubkey string) (*smpc.PubkeyRes, error) {
+	if pubkey == "" {
+		return nil, invalidParams("pubkey is empty")
+	}
+
+	ret, tip, err := smpc.GetSmpcAddrData(pubkey)
+	if err != nil {
+		return nil, smpcError(ErrCodeRejected, tip, err)
+	}
+
+	return ret, nil
+}
+
+// GetApprovalDecision get the decision made by the approval policy of this node for the keygen/sign command
+func (service *ServiceV2) GetApprovalDecision(key string) (*smpc.ApprovalDecision, error) {
+	if key == "" {
+		return nil, invalidParams("key is empty")
+	}
+
+	ret, err := smpc.GetApprovalDecision(key)
+	if err != nil {
+		return nil, smpcError(ErrCodeNotFound, "", err)
+	}
+
+	return ret, nil
+}
@@ -92,6 +92,12 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError wraps RPC errors that carry additional data in the error object besides the code and the message.
+type DataError interface {
+	Error
+	ErrorData() interface{} // returns the data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
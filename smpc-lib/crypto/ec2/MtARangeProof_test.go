@@ -18,6 +18,7 @@
 package ec2_test
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
@@ -26,21 +27,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var (
-	testNtildeLength      = 2048
-	testPaillierKeyLength = 2048
-)
+func TestMtARangeProofVerify(t *testing.T) {
+	pre := getPreParams(t)
+	publicKey := pre.PaillierPk()
+	nt := pre.NtildeH1H2
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 2)
 
-func TestMtAZK1Verify_nhh(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
-	assert.NotZero(t, publicKey)
-	assert.NotZero(t, privateKey)
 	u1K := random.GetRandomIntFromZn(secp256k1.S256().N)
-	nt, _, _, _, _ := ec2.CreateNt(testNtildeLength)
-	assert.NotZero(t, nt)
-	u1KCipher, u1R, _ := publicKey.Encrypt(u1K)
-	u1u1MtAZK1Proof := ec2.MtAZK1Prove_nhh(u1K, u1R, publicKey, nt)
+	u1KCipher, u1R, err := publicKey.Encrypt(u1K)
+	assert.NoError(t, err)
+	u1u1MtAZK1Proof := ec2.MtARangeProofProve(secp256k1.S256(), ctx, u1KCipher, u1K, u1R, publicKey, nt)
 	assert.NotZero(t, u1u1MtAZK1Proof)
-	u1rlt1 := u1u1MtAZK1Proof.MtAZK1Verify_nhh(u1KCipher, publicKey, nt)
+	u1rlt1 := u1u1MtAZK1Proof.MtARangeProofVerify(secp256k1.S256(), ctx, u1KCipher, publicKey, nt)
 	assert.True(t, u1rlt1, "u1rlt1 must be true")
+
+	// the proof is bound to ctx and to the cipher
+	u1rlt1 = u1u1MtAZK1Proof.MtARangeProofVerify(secp256k1.S256(), ec2.NewProofContext("session", big.NewInt(2), 2), u1KCipher, publicKey, nt)
+	assert.False(t, u1rlt1, "u1rlt1 must be false")
+	other, _, _ := publicKey.Encrypt(u1K)
+	u1rlt1 = u1u1MtAZK1Proof.MtARangeProofVerify(secp256k1.S256(), ctx, other, publicKey, nt)
+	assert.False(t, u1rlt1, "u1rlt1 must be false")
 }
@@ -17,27 +17,22 @@
 package ec2_test
 
 import (
-	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
 
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
 )
 
-var (
-	testNtildeLength      = 2048
-	testPaillierKeyLength = 2048
-)
+func TestMtARespZKProofVerify(t *testing.T) {
+	pre := getPreParams(t)
+	publicKey := pre.PaillierPk()
+	nt := pre.NtildeH1H2
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 4)
 
-func TestMtAZK2Verify_nhh(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
-	assert.NotZero(t, publicKey)
-	assert.NotZero(t, privateKey)
-	nt, _, _, _, _ := ec2.CreateNt(testNtildeLength)
-	assert.NotZero(t, nt)
-	NSalt := new(big.Int).Lsh(big.NewInt(1), uint(testPaillierKeyLength-testPaillierKeyLength/10))
+	NSalt := new(big.Int).Lsh(big.NewInt(1), uint(ec2.DefaultPaillierKeyLength-ec2.DefaultPaillierKeyLength/10))
 	NSubN2 := new(big.Int).Mul(secp256k1.S256().N, secp256k1.S256().N)
 	NSubN2 = new(big.Int).Sub(NSalt, NSubN2)
 	beta1U1Star := random.GetRandomIntFromZn(NSubN2)
@@ -47,8 +42,11 @@ func TestMtAZK2Verify_nhh(t *testing.T) {
 	u1KCipher, _, _ := publicKey.Encrypt(u1K)
 	u1KGamma1Cipher := publicKey.HomoMul(u1KCipher, u1Gamma)
 	u1KGamma1Cipher = publicKey.HomoAdd(u1KGamma1Cipher, beta1U1StarCipher)
-	u1u1MtAZK2Proof := ec2.MtAZK2Prove_nhh(u1Gamma, beta1U1Star, u1BetaR1, u1KCipher, publicKey, nt)
+	u1u1MtAZK2Proof := ec2.MtARespZKProofProve(secp256k1.S256(), ctx, u1Gamma, beta1U1Star, u1BetaR1, u1KCipher, u1KGamma1Cipher, publicKey, nt)
 	assert.NotZero(t, u1u1MtAZK2Proof)
-	ret := u1u1MtAZK2Proof.MtAZK2Verify_nhh(u1KCipher, u1KGamma1Cipher, publicKey, nt)
+	ret := u1u1MtAZK2Proof.MtARespZKProofVerify(secp256k1.S256(), ctx, u1KCipher, u1KGamma1Cipher, publicKey, nt)
 	assert.True(t, ret, "must be true")
+
+	ret = u1u1MtAZK2Proof.MtARespZKProofVerify(secp256k1.S256(), ec2.NewProofContext("other", big.NewInt(1), 4), u1KCipher, u1KGamma1Cipher, publicKey, nt)
+	assert.False(t, ret, "must be false")
 }
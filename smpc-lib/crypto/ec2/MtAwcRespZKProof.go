@@ -17,12 +17,11 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
 	"errors"
 	"math/big"
-
-	s256 "github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 )
 
@@ -47,11 +46,11 @@ type MtAwcRespZKProof struct {
 // The input for this proof is a Paillier public key (N,G) and two values c1, c2 ∈ ZN2, together with a value X in curve the DSA group.
 // The Prover knows x ∈ Zq , y ∈ ZN and r ∈ Z* such that c2 = c1^x*G^y*r^N mod N^2, and X = g^x on the curve, where q is the order of the DSA group.
 // At the end of the protocol the Verifier is convinced of the above and that x ∈ [−q^3 , q^3].
-func MtAwcRespZKProofProve(x *big.Int, y *big.Int, r *big.Int, c1 *big.Int, c2 *big.Int,publicKey *PublicKey, ntildeH1H2 *NtildeH1H2) *MtAwcRespZKProof {
-	q3Ntilde := new(big.Int).Mul(s256.S256().N3(), ntildeH1H2.Ntilde)
-	qNtilde := new(big.Int).Mul(s256.S256().N, ntildeH1H2.Ntilde)
+func MtAwcRespZKProofProve(curve elliptic.Curve, ctx *ProofContext, x *big.Int, y *big.Int, r *big.Int, c1 *big.Int, c2 *big.Int,publicKey *PublicKey, ntildeH1H2 *NtildeH1H2) *MtAwcRespZKProof {
+	q3Ntilde := new(big.Int).Mul(CurveN3(curve), ntildeH1H2.Ntilde)
+	qNtilde := new(big.Int).Mul(curve.Params().N, ntildeH1H2.Ntilde)
 
-	alpha := random.GetRandomIntFromZn(s256.S256().N3())
+	alpha := random.GetRandomIntFromZn(CurveN3(curve))
 	rho := random.GetRandomIntFromZn(qNtilde)
 	rhoBar := random.GetRandomIntFromZn(q3Ntilde)
 	sigma := random.GetRandomIntFromZn(qNtilde)
@@ -59,13 +58,13 @@ func MtAwcRespZKProofProve(x *big.Int, y *big.Int, r *big.Int, c1 *big.Int, c2 *
 	gamma := random.GetRandomIntFromZnStar(publicKey.N)
 	delta := random.GetRandomIntFromZn(qNtilde)
 
-	tmp := new(big.Int).Mod(alpha,s256.S256().N)
-	ux, uy := s256.S256().ScalarBaseMult(tmp.Bytes())
-	xtmp := new(big.Int).Mod(x,s256.S256().N)
+	tmp := new(big.Int).Mod(alpha,curve.Params().N)
+	ux, uy := curve.ScalarBaseMult(tmp.Bytes())
+	xtmp := new(big.Int).Mod(x,curve.Params().N)
 	if xtmp.Cmp(big.NewInt(0)) < 0 {
-	    xtmp = new(big.Int).Add(s256.S256().N,xtmp)
+	    xtmp = new(big.Int).Add(curve.Params().N,xtmp)
 	}
-	Xx, Xy := s256.S256().ScalarBaseMult(xtmp.Bytes())
+	Xx, Xy := curve.ScalarBaseMult(xtmp.Bytes())
 
 	z := new(big.Int).Exp(ntildeH1H2.H1, x, ntildeH1H2.Ntilde)
 	z = new(big.Int).Mul(z, new(big.Int).Exp(ntildeH1H2.H2, rho, ntildeH1H2.Ntilde))
@@ -89,8 +88,8 @@ func MtAwcRespZKProofProve(x *big.Int, y *big.Int, r *big.Int, c1 *big.Int, c2 *
 	w = new(big.Int).Mul(w, new(big.Int).Exp(ntildeH1H2.H2, delta, ntildeH1H2.Ntilde))
 	w = new(big.Int).Mod(w, ntildeH1H2.Ntilde)
 
-	e := Sha512_256(ux,uy,Xx,Xy,z,zBar,t,v,w,c1,c2,publicKey.N)
-	e = new(big.Int).Mod(e, s256.S256().N)
+	e := Sha512_256(ctx.Int(),ux,uy,Xx,Xy,z,zBar,t,v,w,c1,c2,publicKey.N)
+	e = new(big.Int).Mod(e, curve.Params().N)
 
 	s := new(big.Int).Exp(r, e, publicKey.N)
 	s = new(big.Int).Mul(s, beta)
@@ -119,7 +118,7 @@ func MtAwcRespZKProofProve(x *big.Int, y *big.Int, r *big.Int, c1 *big.Int, c2 *
 // The Prover knows x ∈ Zq , y ∈ ZN and r ∈ Z* such that c2 = c1^x*G^y*r^N mod N^2, and X = g^x on the curve, where q is the order of the DSA group.
 // At the end of the protocol the Verifier is convinced of the above and that x ∈ [−q^3 , q^3].
 // The Verifier checks that s1 ≤ q^3, g^s1 = X^e*u on the curve, h1^s1*h2^s2 = z^e*zBar mode Ntilde, h1^t1*h2^t2 = t^e*w mod Ntilde, and c1^s1*s^N*G^t1 = c2^e*v mod N^2.
-func (mtAZK3Proof *MtAwcRespZKProof) MtAwcRespZKProofVefify(xG []*big.Int,c1 *big.Int, c2 *big.Int, publicKey *PublicKey, ntildeH1H2 *NtildeH1H2) bool {
+func (mtAZK3Proof *MtAwcRespZKProof) MtAwcRespZKProofVefify(curve elliptic.Curve, ctx *ProofContext, xG []*big.Int,c1 *big.Int, c2 *big.Int, publicKey *PublicKey, ntildeH1H2 *NtildeH1H2) bool {
     	if xG == nil || len(xG) == 0 || c1 == nil || c2 == nil || publicKey == nil || ntildeH1H2 == nil || mtAZK3Proof == nil || mtAZK3Proof.S1 == nil || mtAZK3Proof.Z == nil || mtAZK3Proof.ZBar == nil || mtAZK3Proof.T == nil || mtAZK3Proof.W == nil || mtAZK3Proof.V == nil || mtAZK3Proof.S == nil {
 	    return false
 	}
@@ -132,7 +131,7 @@ func (mtAZK3Proof *MtAwcRespZKProof) MtAwcRespZKProofVefify(xG []*big.Int,c1 *bi
 	    return false
 	}
 	
-	if mtAZK3Proof.S1.Cmp(s256.S256().N3()) > 0 {
+	if mtAZK3Proof.S1.Cmp(CurveN3(curve)) > 0 {
 		return false
 	}
 
@@ -188,7 +187,7 @@ func (mtAZK3Proof *MtAwcRespZKProof) MtAwcRespZKProofVefify(xG []*big.Int,c1 *bi
 	if len(xG) != 2 || xG[0] == nil || xG[1] == nil {
 	    return false
 	}
-	if !checkPointOnCurve(xG) {
+	if !checkPointOnCurve(curve, xG) {
 	    return false
 	}
 
@@ -197,19 +196,19 @@ func (mtAZK3Proof *MtAwcRespZKProof) MtAwcRespZKProofVefify(xG []*big.Int,c1 *bi
 	//paillier pubkey.N2
 	N2 := new(big.Int).Mul(publicKey.N,publicKey.N)
 
-	e := Sha512_256(mtAZK3Proof.Ux,mtAZK3Proof.Uy,xG[0],xG[1],mtAZK3Proof.Z,mtAZK3Proof.ZBar,mtAZK3Proof.T,mtAZK3Proof.V,mtAZK3Proof.W,c1,c2,publicKey.N)
-	e = new(big.Int).Mod(e, s256.S256().N)
+	e := Sha512_256(ctx.Int(),mtAZK3Proof.Ux,mtAZK3Proof.Uy,xG[0],xG[1],mtAZK3Proof.Z,mtAZK3Proof.ZBar,mtAZK3Proof.T,mtAZK3Proof.V,mtAZK3Proof.W,c1,c2,publicKey.N)
+	e = new(big.Int).Mod(e, curve.Params().N)
 
 	// check g^s1 == (X^e)u and on curve
-	tmp := new(big.Int).Mod(mtAZK3Proof.S1,s256.S256().N)
+	tmp := new(big.Int).Mod(mtAZK3Proof.S1,curve.Params().N)
 	if tmp.Cmp(big.NewInt(0)) < 0 {
-	    tmp = new(big.Int).Add(s256.S256().N,tmp)
+	    tmp = new(big.Int).Add(curve.Params().N,tmp)
 	}
-	s1Gx, s1Gy := s256.S256().ScalarBaseMult(tmp.Bytes())
+	s1Gx, s1Gy := curve.ScalarBaseMult(tmp.Bytes())
 
-	exGx, exGy := s256.S256().ScalarMult(xG[0],xG[1],e.Bytes())
-	uexGx, uexGy := s256.S256().Add(exGx,exGy,mtAZK3Proof.Ux,mtAZK3Proof.Uy)
-	if !s256.S256().IsOnCurve(s1Gx,s1Gy) || !s256.S256().IsOnCurve(uexGx,uexGy) || s1Gx.Cmp(uexGx) != 0 || s1Gy.Cmp(uexGy) != 0 {
+	exGx, exGy := curve.ScalarMult(xG[0],xG[1],e.Bytes())
+	uexGx, uexGy := curve.Add(exGx,exGy,mtAZK3Proof.Ux,mtAZK3Proof.Uy)
+	if !curve.IsOnCurve(s1Gx,s1Gy) || !curve.IsOnCurve(uexGx,uexGy) || s1Gx.Cmp(uexGx) != 0 || s1Gy.Cmp(uexGy) != 0 {
 	    return false
 	}
 
@@ -0,0 +1,321 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// AffGProof
+// paillier affine operation with group commitment in range proof:
+// D = C^x * (1+N0)^y * rho^N0 mod N0^2, Y = (1+N1)^y * rhoy^N1 mod N1^2, X = x*G, x in +-2^l, y in +-2^l'
+// N0 is the paillier key of the verifier,N1 is the paillier key of the prover,using the ring-pedersen parameters (Ntilde,h1,h2) of the verifier
+// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts (CGGMP21), appendix C.3, figure 15
+type AffGProof struct {
+	A   *big.Int
+	Bxx *big.Int
+	Bxy *big.Int
+	By  *big.Int
+	E   *big.Int
+	S   *big.Int
+	F   *big.Int
+	T   *big.Int
+	Z1  *big.Int
+	Z2  *big.Int
+	Z3  *big.Int
+	Z4  *big.Int
+	W   *big.Int
+	Wy  *big.Int
+}
+
+//------------------------------------------------------------------------------------
+
+// PaillierAffine return C^x * (1+N)^y * rho^N mod N^2
+func PaillierAffine(pk *PublicKey, c *big.Int, x *big.Int, y *big.Int, rho *big.Int) *big.Int {
+	if pk == nil || pk.N == nil || c == nil || x == nil {
+		return nil
+	}
+
+	n2 := new(big.Int).Mul(pk.N, pk.N)
+	cx := expWithNegative(c, x, n2)
+	enc := PaillierEncrypt(pk, y, rho)
+	if cx == nil || enc == nil {
+		return nil
+	}
+
+	cx.Mul(cx, enc)
+	return cx.Mod(cx, n2)
+}
+
+// AffGProve create AffGProof,the challenge is bound to ctx
+// prover sample alpha in +-2^(l+e), beta in +-2^(l'+e), r in ZN0*, ry in ZN1*, gamma,delta in +-2^(l+e)*Ntilde, m,mu in +-2^l*Ntilde
+// A = C^alpha*(1+N0)^beta*r^N0, Bx = alpha*G, By = (1+N1)^beta*ry^N1, E = h1^alpha*h2^gamma, S = h1^x*h2^m, F = h1^beta*h2^delta, T = h1^y*h2^mu
+// e = H(ctx,N0,N1,Ntilde,h1,h2,C,D,Y,X,A,Bx,By,E,S,F,T) mod q
+// z1 = alpha + e*x, z2 = beta + e*y, z3 = gamma + e*m, z4 = delta + e*mu, w = r*rho^e mod N0, wy = ry*rhoy^e mod N1
+func AffGProve(curve elliptic.Curve, ctx *ProofContext, pk0 *PublicKey, pk1 *PublicKey, ntilde *NtildeH1H2, c *big.Int, d *big.Int, y *big.Int, xx *big.Int, xy *big.Int, x *big.Int, yy *big.Int, rho *big.Int, rhoy *big.Int) *AffGProof {
+	if curve == nil || pk0 == nil || pk0.N == nil || pk1 == nil || pk1.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil {
+		return nil
+	}
+
+	if c == nil || d == nil || y == nil || xx == nil || xy == nil || x == nil || yy == nil || rho == nil || rhoy == nil {
+		return nil
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk0.N
+	n1 := pk1.N
+	q := curve.Params().N
+
+	alpha := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nil)
+	beta := GetRandomIntInRange(CGGMPLPrime+CGGMPEpsilon, nil)
+	r := GetRandomPositiveRelativelyPrimeInt(n0)
+	ry := GetRandomPositiveRelativelyPrimeInt(n1)
+	gamma := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nt)
+	m := GetRandomIntInRange(CGGMPL, nt)
+	delta := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nt)
+	mu := GetRandomIntInRange(CGGMPL, nt)
+	if alpha == nil || beta == nil || r == nil || ry == nil || gamma == nil || m == nil || delta == nil || mu == nil {
+		return nil
+	}
+
+	A := PaillierAffine(pk0, c, alpha, beta, r)
+	bxx, bxy := curve.ScalarBaseMult(new(big.Int).Mod(alpha, q).Bytes())
+	By := PaillierEncrypt(pk1, beta, ry)
+	E := ringPedersenCommit(s, alpha, t, gamma, nt)
+	S := ringPedersenCommit(s, x, t, m, nt)
+	F := ringPedersenCommit(s, beta, t, delta, nt)
+	T := ringPedersenCommit(s, yy, t, mu, nt)
+	if A == nil || By == nil || E == nil || S == nil || F == nil || T == nil {
+		return nil
+	}
+
+	e := Sha512_256(ctx.Int(), n0, n1, nt, s, t, c, d, y, xx, xy, A, bxx, bxy, By, E, S, F, T)
+	if e == nil {
+		return nil
+	}
+	e.Mod(e, q)
+
+	z1 := new(big.Int).Add(alpha, new(big.Int).Mul(e, x))
+	z2 := new(big.Int).Add(beta, new(big.Int).Mul(e, yy))
+	z3 := new(big.Int).Add(gamma, new(big.Int).Mul(e, m))
+	z4 := new(big.Int).Add(delta, new(big.Int).Mul(e, mu))
+	w := new(big.Int).Exp(rho, e, n0)
+	w.Mul(w, r)
+	w.Mod(w, n0)
+	wy := new(big.Int).Exp(rhoy, e, n1)
+	wy.Mul(wy, ry)
+	wy.Mod(wy, n1)
+
+	return &AffGProof{A: A, Bxx: bxx, Bxy: bxy, By: By, E: E, S: S, F: F, T: T, Z1: z1, Z2: z2, Z3: z3, Z4: z4, W: w, Wy: wy}
+}
+
+// AffGVerify verify AffGProof made in the context ctx
+// check:
+// C^z1*(1+N0)^z2*w^N0 = A*D^e (mod N0^2)
+// z1*G = Bx + e*X
+// (1+N1)^z2*wy^N1 = By*Y^e (mod N1^2)
+// h1^z1*h2^z3 = E*S^e (mod Ntilde)
+// h1^z2*h2^z4 = F*T^e (mod Ntilde)
+// z1 in +-2^(l+e), z2 in +-2^(l'+e)
+func AffGVerify(curve elliptic.Curve, ctx *ProofContext, pk0 *PublicKey, pk1 *PublicKey, ntilde *NtildeH1H2, c *big.Int, d *big.Int, y *big.Int, xx *big.Int, xy *big.Int, proof *AffGProof) bool {
+	if curve == nil || pk0 == nil || pk0.N == nil || pk1 == nil || pk1.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil {
+		return false
+	}
+
+	if c == nil || d == nil || y == nil || xx == nil || xy == nil || proof == nil {
+		return false
+	}
+
+	if proof.A == nil || proof.Bxx == nil || proof.Bxy == nil || proof.By == nil || proof.E == nil || proof.S == nil || proof.F == nil || proof.T == nil || proof.Z1 == nil || proof.Z2 == nil || proof.Z3 == nil || proof.Z4 == nil || proof.W == nil || proof.Wy == nil {
+		return false
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk0.N
+	n1 := pk1.N
+	n02 := new(big.Int).Mul(n0, n0)
+	n12 := new(big.Int).Mul(n1, n1)
+	q := curve.Params().N
+
+	for _, v := range []*big.Int{proof.E, proof.S, proof.F, proof.T} {
+		if !IsNumberInMultiplicativeGroup(nt, v) {
+			return false
+		}
+	}
+
+	if !IsNumberInMultiplicativeGroup(n02, proof.A) || !IsNumberInMultiplicativeGroup(n02, c) || !IsNumberInMultiplicativeGroup(n02, d) || !IsNumberInMultiplicativeGroup(n12, proof.By) || !IsNumberInMultiplicativeGroup(n12, y) {
+		return false
+	}
+
+	if !IsNumberInMultiplicativeGroup(n0, proof.W) || !IsNumberInMultiplicativeGroup(n1, proof.Wy) {
+		return false
+	}
+
+	if !curve.IsOnCurve(xx, xy) || !curve.IsOnCurve(proof.Bxx, proof.Bxy) {
+		return false
+	}
+
+	if !inSignedRange(proof.Z1, CGGMPL+CGGMPEpsilon) || !inSignedRange(proof.Z2, CGGMPLPrime+CGGMPEpsilon) {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, z1 or z2 out of range\n")
+		return false
+	}
+
+	e := Sha512_256(ctx.Int(), n0, n1, nt, s, t, c, d, y, xx, xy, proof.A, proof.Bxx, proof.Bxy, proof.By, proof.E, proof.S, proof.F, proof.T)
+	if e == nil {
+		return false
+	}
+	e.Mod(e, q)
+
+	// C^z1*(1+N0)^z2*w^N0 = A*D^e
+	left := PaillierAffine(pk0, c, proof.Z1, proof.Z2, proof.W)
+	right := new(big.Int).Exp(d, e, n02)
+	right.Mul(right, proof.A)
+	right.Mod(right, n02)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, C^z1*enc(z2,w) != A*D^e\n")
+		return false
+	}
+
+	// z1*G = Bx + e*X
+	lx, ly := curve.ScalarBaseMult(new(big.Int).Mod(proof.Z1, q).Bytes())
+	ex, ey := curve.ScalarMult(xx, xy, e.Bytes())
+	rx, ry := curve.Add(proof.Bxx, proof.Bxy, ex, ey)
+	if lx.Cmp(rx) != 0 || ly.Cmp(ry) != 0 {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, z1*G != Bx + e*X\n")
+		return false
+	}
+
+	// (1+N1)^z2*wy^N1 = By*Y^e
+	left = PaillierEncrypt(pk1, proof.Z2, proof.Wy)
+	right = new(big.Int).Exp(y, e, n12)
+	right.Mul(right, proof.By)
+	right.Mod(right, n12)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, enc(z2,wy) != By*Y^e\n")
+		return false
+	}
+
+	// h1^z1*h2^z3 = E*S^e
+	left = ringPedersenCommit(s, proof.Z1, t, proof.Z3, nt)
+	right = new(big.Int).Exp(proof.S, e, nt)
+	right.Mul(right, proof.E)
+	right.Mod(right, nt)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, s^z1*t^z3 != E*S^e\n")
+		return false
+	}
+
+	// h1^z2*h2^z4 = F*T^e
+	left = ringPedersenCommit(s, proof.Z2, t, proof.Z4, nt)
+	right = new(big.Int).Exp(proof.T, e, nt)
+	right.Mul(right, proof.F)
+	right.Mod(right, nt)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier affine operation with group commitment in range proof fail, s^z2*t^z4 != F*T^e\n")
+		return false
+	}
+
+	return true
+}
+
+//----------------------------------------------------------------------------------
+
+// MarshalJSON marshal AffGProof to json bytes
+func (affgpf *AffGProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		A   string `json:"A"`
+		Bxx string `json:"Bxx"`
+		Bxy string `json:"Bxy"`
+		By  string `json:"By"`
+		E   string `json:"E"`
+		S   string `json:"S"`
+		F   string `json:"F"`
+		T   string `json:"T"`
+		Z1  string `json:"Z1"`
+		Z2  string `json:"Z2"`
+		Z3  string `json:"Z3"`
+		Z4  string `json:"Z4"`
+		W   string `json:"W"`
+		Wy  string `json:"Wy"`
+	}{
+		A:   fmt.Sprintf("%v", affgpf.A),
+		Bxx: fmt.Sprintf("%v", affgpf.Bxx),
+		Bxy: fmt.Sprintf("%v", affgpf.Bxy),
+		By:  fmt.Sprintf("%v", affgpf.By),
+		E:   fmt.Sprintf("%v", affgpf.E),
+		S:   fmt.Sprintf("%v", affgpf.S),
+		F:   fmt.Sprintf("%v", affgpf.F),
+		T:   fmt.Sprintf("%v", affgpf.T),
+		Z1:  fmt.Sprintf("%v", affgpf.Z1),
+		Z2:  fmt.Sprintf("%v", affgpf.Z2),
+		Z3:  fmt.Sprintf("%v", affgpf.Z3),
+		Z4:  fmt.Sprintf("%v", affgpf.Z4),
+		W:   fmt.Sprintf("%v", affgpf.W),
+		Wy:  fmt.Sprintf("%v", affgpf.Wy),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to AffGProof
+func (affgpf *AffGProof) UnmarshalJSON(raw []byte) error {
+	var zk struct {
+		A   string `json:"A"`
+		Bxx string `json:"Bxx"`
+		Bxy string `json:"Bxy"`
+		By  string `json:"By"`
+		E   string `json:"E"`
+		S   string `json:"S"`
+		F   string `json:"F"`
+		T   string `json:"T"`
+		Z1  string `json:"Z1"`
+		Z2  string `json:"Z2"`
+		Z3  string `json:"Z3"`
+		Z4  string `json:"Z4"`
+		W   string `json:"W"`
+		Wy  string `json:"Wy"`
+	}
+	if err := json.Unmarshal(raw, &zk); err != nil {
+		return err
+	}
+
+	out, err := parseBigInts(zk.A, zk.Bxx, zk.Bxy, zk.By, zk.E, zk.S, zk.F, zk.T, zk.Z1, zk.Z2, zk.Z3, zk.Z4, zk.W, zk.Wy)
+	if err != nil {
+		return err
+	}
+
+	affgpf.A = out[0]
+	affgpf.Bxx = out[1]
+	affgpf.Bxy = out[2]
+	affgpf.By = out[3]
+	affgpf.E = out[4]
+	affgpf.S = out[5]
+	affgpf.F = out[6]
+	affgpf.T = out[7]
+	affgpf.Z1 = out[8]
+	affgpf.Z2 = out[9]
+	affgpf.Z3 = out[10]
+	affgpf.Z4 = out[11]
+	affgpf.W = out[12]
+	affgpf.Wy = out[13]
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffGProveVerify(t *testing.T) {
+	pre := getPreParams(t)
+	curve := secp256k1.S256()
+	pk := &pre.PaillierSk.PublicKey
+	nt := pre.NtildeH1H2
+
+	// D = C^x*enc(y),Y = enc(y),X = x*G
+	c := ec2.PaillierEncrypt(pk, random.GetRandomIntFromZn(curve.N), ec2.GetRandomPositiveRelativelyPrimeInt(pk.N))
+	x := random.GetRandomIntFromZn(curve.N)
+	y := ec2.GetRandomPositiveInt(new(big.Int).Lsh(big.NewInt(1), ec2.CGGMPLPrime))
+	rho := ec2.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	rhoy := ec2.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	d := ec2.PaillierAffine(pk, c, x, y, rho)
+	yc := ec2.PaillierEncrypt(pk, y, rhoy)
+	xx, xy := curve.ScalarBaseMult(x.Bytes())
+
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 2)
+	proof := ec2.AffGProve(curve, ctx, pk, pk, nt, c, d, yc, xx, xy, x, y, rho, rhoy)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.AffGVerify(curve, ctx, pk, pk, nt, c, d, yc, xx, xy, proof))
+
+	// replay in another context
+	assert.False(t, ec2.AffGVerify(curve, ec2.NewProofContext("session2", big.NewInt(1), 2), pk, pk, nt, c, d, yc, xx, xy, proof))
+	assert.False(t, ec2.AffGVerify(curve, ec2.NewProofContext("session", big.NewInt(2), 2), pk, pk, nt, c, d, yc, xx, xy, proof))
+	assert.False(t, ec2.AffGVerify(curve, ec2.NewProofContext("session", big.NewInt(1), 3), pk, pk, nt, c, d, yc, xx, xy, proof))
+
+	// another affine ciphertext
+	d2 := new(big.Int).Add(d, big.NewInt(1))
+	assert.False(t, ec2.AffGVerify(curve, ctx, pk, pk, nt, c, d2, yc, xx, xy, proof))
+
+	bad := *proof
+	bad.Z2 = new(big.Int).Add(proof.Z2, big.NewInt(1))
+	assert.False(t, ec2.AffGVerify(curve, ctx, pk, pk, nt, c, d, yc, xx, xy, &bad))
+	assert.False(t, ec2.AffGVerify(curve, ctx, pk, pk, nt, c, d, yc, xx, xy, nil))
+}
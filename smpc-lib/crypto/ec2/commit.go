@@ -17,9 +17,8 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"math/big"
-
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/sha3"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 )
@@ -74,7 +73,7 @@ func (commitment *Commitment) Commit(secrets ...*big.Int) *Commitment {
 }
 
 // Verify  Verify commitment data 
-func (commitment *Commitment) Verify() bool {
+func (commitment *Commitment) Verify(curve elliptic.Curve) bool {
 	C := commitment.C
 	D := commitment.D
 
@@ -86,6 +85,12 @@ func (commitment *Commitment) Verify() bool {
 	    return false
 	}
 
+	for _, d := range D {
+	    if d == nil {
+		return false
+	    }
+	}
+
 	sha3256 := sha3.New256()
 	sha3256.Write(D[0].Bytes())
 	
@@ -106,7 +111,7 @@ func (commitment *Commitment) Verify() bool {
 	}
 
 	// Check whether the point is on the curve
-	if !checkPointOnCurve(D[1:]) {
+	if !checkPointOnCurve(curve, D[1:]) {
 		return false
 	}
 
@@ -114,8 +119,8 @@ func (commitment *Commitment) Verify() bool {
 }
 
 // DeCommit get commitment data secrets
-func (commitment *Commitment) DeCommit() (bool, []*big.Int) {
-	if commitment.Verify() {
+func (commitment *Commitment) DeCommit(curve elliptic.Curve) (bool, []*big.Int) {
+	if commitment.Verify(curve) {
 		return true, commitment.D[1:]
 	}
 	
@@ -126,7 +131,7 @@ func (commitment *Commitment) DeCommit() (bool, []*big.Int) {
 //-----------------------------------------------------------
 
 // checkCommitmentGammaGOnCurve Check whether the point is on the curve
-func checkPointOnCurve(secrets []*big.Int) bool {
+func checkPointOnCurve(curve elliptic.Curve, secrets []*big.Int) bool {
 	if len(secrets) == 0 || (len(secrets)%2) != 0 {
 		return false
 	}
@@ -135,7 +140,7 @@ func checkPointOnCurve(secrets []*big.Int) bool {
 	for i := 0; i < l; i++ {
 		x := secrets[2*i]
 		y := secrets[2*i+1]
-		if x == nil || y == nil || !secp256k1.S256().IsOnCurve(x, y) {
+		if x == nil || y == nil || !curve.IsOnCurve(x, y) {
 			return false
 		}
 	}
@@ -17,6 +17,7 @@
 package ec2_test
 
 import (
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -28,7 +29,7 @@ func TestVerify(t *testing.T) {
 	zero := big.NewInt(0)
 
 	com := new(ec2.Commitment).Commit(zero, one)
-	succ := com.Verify()
+	succ := com.Verify(secp256k1.S256())
 	//assert.True(t, succ, "success")
 	assert.False(t, succ, "fail")
 }
@@ -38,7 +39,7 @@ func TestDeCommit(t *testing.T) {
 	zero := big.NewInt(0)
 
 	com := new(ec2.Commitment).Commit(zero, one)
-	succ, u1G := com.DeCommit()
+	succ, u1G := com.DeCommit(secp256k1.S256())
 	//assert.True(t, succ, "success")
 	//assert.NotZero(t, len(u1G), "len(u1G) must be non-zero")
 	assert.False(t, succ, "fail")
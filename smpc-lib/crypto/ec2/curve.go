@@ -0,0 +1,105 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  xing.chang@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	s256 "github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+)
+
+// GetCurve get the elliptic curve of keytype
+// EC256R1 : secp256r1(P-256),others : secp256k1
+func GetCurve(keytype string) elliptic.Curve {
+	if keytype == "EC256R1" {
+		return p256
+	}
+
+	return s256.S256()
+}
+
+// CurveN3 get N^3 of the curve
+func CurveN3(curve elliptic.Curve) *big.Int {
+	n := curve.Params().N
+	n3 := new(big.Int).Mul(n, n)
+	return n3.Mul(n3, n)
+}
+
+// IsSecp256k1 is the curve secp256k1?
+func IsSecp256k1(curve elliptic.Curve) bool {
+	_, ok := curve.(*s256.BitCurve)
+	return ok
+}
+
+//----------------------------------------------------------------------------
+
+var p256 = &p256Curve{elliptic.P256()}
+
+// p256Curve secp256r1,return nil instead of panic if the point is not on the curve,the same as secp256k1.BitCurve
+type p256Curve struct {
+	elliptic.Curve
+}
+
+// isValid the point must be on the curve or the point at infinity (0,0)
+func (curve *p256Curve) isValid(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return false
+	}
+
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return true
+	}
+
+	return curve.Curve.IsOnCurve(x, y)
+}
+
+// Add returns the sum of (x1,y1) and (x2,y2)
+func (curve *p256Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if !curve.isValid(x1, y1) || !curve.isValid(x2, y2) {
+		return nil, nil
+	}
+
+	return curve.Curve.Add(x1, y1, x2, y2)
+}
+
+// Double returns 2*(x,y)
+func (curve *p256Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	if !curve.isValid(x1, y1) {
+		return nil, nil
+	}
+
+	return curve.Curve.Double(x1, y1)
+}
+
+// ScalarMult returns k*(Bx,By)
+func (curve *p256Curve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	if !curve.isValid(Bx, By) {
+		return nil, nil
+	}
+
+	return curve.Curve.ScalarMult(Bx, By, k)
+}
+
+// IsOnCurve reports whether the given (x,y) lies on the curve
+func (curve *p256Curve) IsOnCurve(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return false
+	}
+
+	return curve.Curve.IsOnCurve(x, y)
+}
@@ -0,0 +1,252 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// CGGMPL the bit length of the secrets (k,gamma,x) in CGGMP21 proofs
+	CGGMPL = 256
+
+	// CGGMPLPrime the bit length of the mask beta of the affine operation in CGGMP21 proofs
+	CGGMPLPrime = 5 * CGGMPL
+
+	// CGGMPEpsilon the slackness parameter of CGGMP21 proofs
+	CGGMPEpsilon = 2 * CGGMPL
+)
+
+// EncRangeProof
+// Paillier encryption in range proof: C = (1+N0)^k * rho^N0 mod N0^2 and k in +-2^l, using the ring-pedersen parameters (Ntilde,h1,h2) of the verifier
+// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts (CGGMP21), appendix C.1, figure 14
+type EncRangeProof struct {
+	S  *big.Int
+	A  *big.Int
+	C  *big.Int
+	Z1 *big.Int
+	Z2 *big.Int
+	Z3 *big.Int
+}
+
+//------------------------------------------------------------------------------------
+
+// PaillierEncrypt return (1+N)^m * r^N mod N^2, m may be negative
+func PaillierEncrypt(pk *PublicKey, m *big.Int, r *big.Int) *big.Int {
+	if pk == nil || pk.N == nil || m == nil || r == nil {
+		return nil
+	}
+
+	n2 := new(big.Int).Mul(pk.N, pk.N)
+
+	// (1+N)^m = 1 + m*N mod N^2
+	gm := new(big.Int).Mod(m, pk.N)
+	gm.Mul(gm, pk.N)
+	gm.Add(gm, one)
+
+	rn := new(big.Int).Exp(r, pk.N, n2)
+	c := new(big.Int).Mul(gm, rn)
+	return c.Mod(c, n2)
+}
+
+// GetRandomIntInRange get a random number in [-2^bits,2^bits)*times
+func GetRandomIntInRange(bits uint, times *big.Int) *big.Int {
+	bound := new(big.Int).Lsh(one, bits)
+	if times != nil {
+		bound.Mul(bound, times)
+	}
+
+	return getRandomIntInRange(bound)
+}
+
+// inSignedRange weather |x| <= 2^bits
+func inSignedRange(x *big.Int, bits uint) bool {
+	if x == nil {
+		return false
+	}
+
+	return new(big.Int).Abs(x).Cmp(new(big.Int).Lsh(one, bits)) <= 0
+}
+
+// EncRangeProve create EncRangeProof,the challenge is bound to ctx
+// prover sample alpha in +-2^(l+e), mu in +-2^l*Ntilde, r in ZN0*, gamma in +-2^(l+e)*Ntilde
+// S = h1^k*h2^mu, A = (1+N0)^alpha*r^N0, C = h1^alpha*h2^gamma
+// e = H(ctx,N0,Ntilde,h1,h2,K,S,A,C) mod q
+// z1 = alpha + e*k, z2 = r*rho^e mod N0, z3 = gamma + e*mu
+func EncRangeProve(curve elliptic.Curve, ctx *ProofContext, pk *PublicKey, ntilde *NtildeH1H2, c *big.Int, k *big.Int, rho *big.Int) *EncRangeProof {
+	if curve == nil || pk == nil || pk.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil || c == nil || k == nil || rho == nil {
+		return nil
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk.N
+
+	alpha := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nil)
+	mu := GetRandomIntInRange(CGGMPL, nt)
+	r := GetRandomPositiveRelativelyPrimeInt(n0)
+	gamma := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nt)
+	if alpha == nil || mu == nil || r == nil || gamma == nil {
+		return nil
+	}
+
+	S := ringPedersenCommit(s, k, t, mu, nt)
+	A := PaillierEncrypt(pk, alpha, r)
+	C := ringPedersenCommit(s, alpha, t, gamma, nt)
+	if S == nil || A == nil || C == nil {
+		return nil
+	}
+
+	e := Sha512_256(ctx.Int(), n0, nt, s, t, c, S, A, C)
+	if e == nil {
+		return nil
+	}
+	e.Mod(e, curve.Params().N)
+
+	z1 := new(big.Int).Add(alpha, new(big.Int).Mul(e, k))
+	z2 := new(big.Int).Exp(rho, e, n0)
+	z2.Mul(z2, r)
+	z2.Mod(z2, n0)
+	z3 := new(big.Int).Add(gamma, new(big.Int).Mul(e, mu))
+
+	return &EncRangeProof{S: S, A: A, C: C, Z1: z1, Z2: z2, Z3: z3}
+}
+
+// EncRangeVerify verify EncRangeProof made in the context ctx
+// check:
+// (1+N0)^z1*z2^N0 = A*K^e (mod N0^2)
+// h1^z1*h2^z3 = C*S^e (mod Ntilde)
+// z1 in +-2^(l+e)
+func EncRangeVerify(curve elliptic.Curve, ctx *ProofContext, pk *PublicKey, ntilde *NtildeH1H2, c *big.Int, proof *EncRangeProof) bool {
+	if curve == nil || pk == nil || pk.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil || c == nil {
+		return false
+	}
+
+	if proof == nil || proof.S == nil || proof.A == nil || proof.C == nil || proof.Z1 == nil || proof.Z2 == nil || proof.Z3 == nil {
+		return false
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk.N
+	n2 := new(big.Int).Mul(n0, n0)
+
+	if !IsNumberInMultiplicativeGroup(nt, proof.S) || !IsNumberInMultiplicativeGroup(nt, proof.C) || !IsNumberInMultiplicativeGroup(n2, proof.A) || !IsNumberInMultiplicativeGroup(n2, c) || !IsNumberInMultiplicativeGroup(n0, proof.Z2) {
+		return false
+	}
+
+	if !inSignedRange(proof.Z1, CGGMPL+CGGMPEpsilon) {
+		fmt.Printf("check paillier encryption in range proof fail, z1 out of range\n")
+		return false
+	}
+
+	e := Sha512_256(ctx.Int(), n0, nt, s, t, c, proof.S, proof.A, proof.C)
+	if e == nil {
+		return false
+	}
+	e.Mod(e, curve.Params().N)
+
+	// (1+N0)^z1*z2^N0 = A*K^e
+	left := PaillierEncrypt(pk, proof.Z1, proof.Z2)
+	right := new(big.Int).Exp(c, e, n2)
+	right.Mul(right, proof.A)
+	right.Mod(right, n2)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier encryption in range proof fail, enc(z1,z2) != A*K^e\n")
+		return false
+	}
+
+	// h1^z1*h2^z3 = C*S^e
+	left = ringPedersenCommit(s, proof.Z1, t, proof.Z3, nt)
+	right = new(big.Int).Exp(proof.S, e, nt)
+	right.Mul(right, proof.C)
+	right.Mod(right, nt)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check paillier encryption in range proof fail, s^z1*t^z3 != C*S^e\n")
+		return false
+	}
+
+	return true
+}
+
+//----------------------------------------------------------------------------------
+
+// MarshalJSON marshal EncRangeProof to json bytes
+func (encpf *EncRangeProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		S  string `json:"S"`
+		A  string `json:"A"`
+		C  string `json:"C"`
+		Z1 string `json:"Z1"`
+		Z2 string `json:"Z2"`
+		Z3 string `json:"Z3"`
+	}{
+		S:  fmt.Sprintf("%v", encpf.S),
+		A:  fmt.Sprintf("%v", encpf.A),
+		C:  fmt.Sprintf("%v", encpf.C),
+		Z1: fmt.Sprintf("%v", encpf.Z1),
+		Z2: fmt.Sprintf("%v", encpf.Z2),
+		Z3: fmt.Sprintf("%v", encpf.Z3),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to EncRangeProof
+func (encpf *EncRangeProof) UnmarshalJSON(raw []byte) error {
+	var zk struct {
+		S  string `json:"S"`
+		A  string `json:"A"`
+		C  string `json:"C"`
+		Z1 string `json:"Z1"`
+		Z2 string `json:"Z2"`
+		Z3 string `json:"Z3"`
+	}
+	if err := json.Unmarshal(raw, &zk); err != nil {
+		return err
+	}
+
+	out, err := parseBigInts(zk.S, zk.A, zk.C, zk.Z1, zk.Z2, zk.Z3)
+	if err != nil {
+		return err
+	}
+
+	encpf.S = out[0]
+	encpf.A = out[1]
+	encpf.C = out[2]
+	encpf.Z1 = out[3]
+	encpf.Z2 = out[4]
+	encpf.Z3 = out[5]
+	return nil
+}
+
+// parseBigInts parse the decimal strings to big ints
+func parseBigInts(in ...string) ([]*big.Int, error) {
+	out := make([]*big.Int, len(in))
+	for k, v := range in {
+		tmp, _ := new(big.Int).SetString(v, 10)
+		if tmp == nil {
+			return nil, fmt.Errorf("get big int fail")
+		}
+		out[k] = tmp
+	}
+
+	return out, nil
+}
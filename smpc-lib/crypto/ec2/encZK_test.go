@@ -0,0 +1,59 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncRangeProveVerify(t *testing.T) {
+	pre := getPreParams(t)
+	curve := secp256k1.S256()
+	pk := &pre.PaillierSk.PublicKey
+	nt := pre.NtildeH1H2
+
+	k := random.GetRandomIntFromZn(curve.N)
+	rho := ec2.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	c := ec2.PaillierEncrypt(pk, k, rho)
+
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 1)
+	proof := ec2.EncRangeProve(curve, ctx, pk, nt, c, k, rho)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.EncRangeVerify(curve, ctx, pk, nt, c, proof))
+
+	// replay in another context
+	assert.False(t, ec2.EncRangeVerify(curve, ec2.NewProofContext("session2", big.NewInt(1), 1), pk, nt, c, proof))
+	assert.False(t, ec2.EncRangeVerify(curve, ec2.NewProofContext("session", big.NewInt(2), 1), pk, nt, c, proof))
+	assert.False(t, ec2.EncRangeVerify(curve, ec2.NewProofContext("session", big.NewInt(1), 2), pk, nt, c, proof))
+
+	// another ciphertext
+	c2 := ec2.PaillierEncrypt(pk, new(big.Int).Add(k, big.NewInt(1)), rho)
+	assert.False(t, ec2.EncRangeVerify(curve, ctx, pk, nt, c2, proof))
+
+	bad := *proof
+	bad.Z1 = new(big.Int).Add(proof.Z1, big.NewInt(1))
+	assert.False(t, ec2.EncRangeVerify(curve, ctx, pk, nt, c, &bad))
+	assert.False(t, ec2.EncRangeVerify(curve, ctx, pk, nt, c, nil))
+}
@@ -256,6 +256,13 @@ func HvVerify(n *big.Int,num *big.Int,proof *HvProof) bool {
 	if n == nil || proof == nil || proof.Sigma == nil || num == nil || num.Cmp(zero) < 0 {
 	    return false
 	}
+
+	vals := append([]*big.Int{n,num},proof.Sigma...)
+	return verifyOnce("HvProof",func() bool { return hvVerify(n,num,proof) },vals...)
+}
+
+// hvVerify check the hv proof,the args have been checked by HvVerify
+func hvVerify(n *big.Int,num *big.Int,proof *HvProof) bool {
 	
 	ROH := CalcRoh(n,num)
 	if ROH == nil {
@@ -0,0 +1,218 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// LogStarProof
+// knowledge of exponent vs paillier encryption: C = (1+N0)^x * rho^N0 mod N0^2, X = x*B and x in +-2^l, B is a point of the curve,
+// using the ring-pedersen parameters (Ntilde,h1,h2) of the verifier
+// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts (CGGMP21), appendix C.2, figure 25
+type LogStarProof struct {
+	S  *big.Int
+	A  *big.Int
+	Yx *big.Int
+	Yy *big.Int
+	D  *big.Int
+	Z1 *big.Int
+	Z2 *big.Int
+	Z3 *big.Int
+}
+
+//------------------------------------------------------------------------------------
+
+// LogStarProve create LogStarProof,the challenge is bound to ctx
+// prover sample alpha in +-2^(l+e), mu in +-2^l*Ntilde, r in ZN0*, gamma in +-2^(l+e)*Ntilde
+// S = h1^x*h2^mu, A = (1+N0)^alpha*r^N0, Y = alpha*B, D = h1^alpha*h2^gamma
+// e = H(ctx,N0,Ntilde,h1,h2,C,B,X,S,A,Y,D) mod q
+// z1 = alpha + e*x, z2 = r*rho^e mod N0, z3 = gamma + e*mu
+func LogStarProve(curve elliptic.Curve, ctx *ProofContext, pk *PublicKey, ntilde *NtildeH1H2, c *big.Int, bx *big.Int, by *big.Int, xx *big.Int, xy *big.Int, x *big.Int, rho *big.Int) *LogStarProof {
+	if curve == nil || pk == nil || pk.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil || c == nil || bx == nil || by == nil || xx == nil || xy == nil || x == nil || rho == nil {
+		return nil
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk.N
+	q := curve.Params().N
+
+	alpha := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nil)
+	mu := GetRandomIntInRange(CGGMPL, nt)
+	r := GetRandomPositiveRelativelyPrimeInt(n0)
+	gamma := GetRandomIntInRange(CGGMPL+CGGMPEpsilon, nt)
+	if alpha == nil || mu == nil || r == nil || gamma == nil {
+		return nil
+	}
+
+	S := ringPedersenCommit(s, x, t, mu, nt)
+	A := PaillierEncrypt(pk, alpha, r)
+	yx, yy := curve.ScalarMult(bx, by, new(big.Int).Mod(alpha, q).Bytes())
+	D := ringPedersenCommit(s, alpha, t, gamma, nt)
+	if S == nil || A == nil || D == nil {
+		return nil
+	}
+
+	e := Sha512_256(ctx.Int(), n0, nt, s, t, c, bx, by, xx, xy, S, A, yx, yy, D)
+	if e == nil {
+		return nil
+	}
+	e.Mod(e, q)
+
+	z1 := new(big.Int).Add(alpha, new(big.Int).Mul(e, x))
+	z2 := new(big.Int).Exp(rho, e, n0)
+	z2.Mul(z2, r)
+	z2.Mod(z2, n0)
+	z3 := new(big.Int).Add(gamma, new(big.Int).Mul(e, mu))
+
+	return &LogStarProof{S: S, A: A, Yx: yx, Yy: yy, D: D, Z1: z1, Z2: z2, Z3: z3}
+}
+
+// LogStarVerify verify LogStarProof made in the context ctx
+// check:
+// (1+N0)^z1*z2^N0 = A*C^e (mod N0^2)
+// z1*B = Y + e*X
+// h1^z1*h2^z3 = D*S^e (mod Ntilde)
+// z1 in +-2^(l+e)
+func LogStarVerify(curve elliptic.Curve, ctx *ProofContext, pk *PublicKey, ntilde *NtildeH1H2, c *big.Int, bx *big.Int, by *big.Int, xx *big.Int, xy *big.Int, proof *LogStarProof) bool {
+	if curve == nil || pk == nil || pk.N == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil || c == nil || bx == nil || by == nil || xx == nil || xy == nil {
+		return false
+	}
+
+	if proof == nil || proof.S == nil || proof.A == nil || proof.Yx == nil || proof.Yy == nil || proof.D == nil || proof.Z1 == nil || proof.Z2 == nil || proof.Z3 == nil {
+		return false
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+	n0 := pk.N
+	n2 := new(big.Int).Mul(n0, n0)
+	q := curve.Params().N
+
+	if !IsNumberInMultiplicativeGroup(nt, proof.S) || !IsNumberInMultiplicativeGroup(nt, proof.D) || !IsNumberInMultiplicativeGroup(n2, proof.A) || !IsNumberInMultiplicativeGroup(n2, c) || !IsNumberInMultiplicativeGroup(n0, proof.Z2) {
+		return false
+	}
+
+	if !curve.IsOnCurve(bx, by) || !curve.IsOnCurve(xx, xy) || !curve.IsOnCurve(proof.Yx, proof.Yy) {
+		return false
+	}
+
+	if !inSignedRange(proof.Z1, CGGMPL+CGGMPEpsilon) {
+		fmt.Printf("check knowledge of exponent vs paillier encryption proof fail, z1 out of range\n")
+		return false
+	}
+
+	e := Sha512_256(ctx.Int(), n0, nt, s, t, c, bx, by, xx, xy, proof.S, proof.A, proof.Yx, proof.Yy, proof.D)
+	if e == nil {
+		return false
+	}
+	e.Mod(e, q)
+
+	// (1+N0)^z1*z2^N0 = A*C^e
+	left := PaillierEncrypt(pk, proof.Z1, proof.Z2)
+	right := new(big.Int).Exp(c, e, n2)
+	right.Mul(right, proof.A)
+	right.Mod(right, n2)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check knowledge of exponent vs paillier encryption proof fail, enc(z1,z2) != A*C^e\n")
+		return false
+	}
+
+	// z1*B = Y + e*X
+	lx, ly := curve.ScalarMult(bx, by, new(big.Int).Mod(proof.Z1, q).Bytes())
+	ex, ey := curve.ScalarMult(xx, xy, e.Bytes())
+	rx, ry := curve.Add(proof.Yx, proof.Yy, ex, ey)
+	if lx.Cmp(rx) != 0 || ly.Cmp(ry) != 0 {
+		fmt.Printf("check knowledge of exponent vs paillier encryption proof fail, z1*B != Y + e*X\n")
+		return false
+	}
+
+	// h1^z1*h2^z3 = D*S^e
+	left = ringPedersenCommit(s, proof.Z1, t, proof.Z3, nt)
+	right = new(big.Int).Exp(proof.S, e, nt)
+	right.Mul(right, proof.D)
+	right.Mod(right, nt)
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check knowledge of exponent vs paillier encryption proof fail, s^z1*t^z3 != D*S^e\n")
+		return false
+	}
+
+	return true
+}
+
+//----------------------------------------------------------------------------------
+
+// MarshalJSON marshal LogStarProof to json bytes
+func (lspf *LogStarProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		S  string `json:"S"`
+		A  string `json:"A"`
+		Yx string `json:"Yx"`
+		Yy string `json:"Yy"`
+		D  string `json:"D"`
+		Z1 string `json:"Z1"`
+		Z2 string `json:"Z2"`
+		Z3 string `json:"Z3"`
+	}{
+		S:  fmt.Sprintf("%v", lspf.S),
+		A:  fmt.Sprintf("%v", lspf.A),
+		Yx: fmt.Sprintf("%v", lspf.Yx),
+		Yy: fmt.Sprintf("%v", lspf.Yy),
+		D:  fmt.Sprintf("%v", lspf.D),
+		Z1: fmt.Sprintf("%v", lspf.Z1),
+		Z2: fmt.Sprintf("%v", lspf.Z2),
+		Z3: fmt.Sprintf("%v", lspf.Z3),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to LogStarProof
+func (lspf *LogStarProof) UnmarshalJSON(raw []byte) error {
+	var zk struct {
+		S  string `json:"S"`
+		A  string `json:"A"`
+		Yx string `json:"Yx"`
+		Yy string `json:"Yy"`
+		D  string `json:"D"`
+		Z1 string `json:"Z1"`
+		Z2 string `json:"Z2"`
+		Z3 string `json:"Z3"`
+	}
+	if err := json.Unmarshal(raw, &zk); err != nil {
+		return err
+	}
+
+	out, err := parseBigInts(zk.S, zk.A, zk.Yx, zk.Yy, zk.D, zk.Z1, zk.Z2, zk.Z3)
+	if err != nil {
+		return err
+	}
+
+	lspf.S = out[0]
+	lspf.A = out[1]
+	lspf.Yx = out[2]
+	lspf.Yy = out[3]
+	lspf.D = out[4]
+	lspf.Z1 = out[5]
+	lspf.Z2 = out[6]
+	lspf.Z3 = out[7]
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogStarProveVerify(t *testing.T) {
+	pre := getPreParams(t)
+	curve := secp256k1.S256()
+	pk := &pre.PaillierSk.PublicKey
+	nt := pre.NtildeH1H2
+
+	// C = enc(x),X = x*G
+	x := random.GetRandomIntFromZn(curve.N)
+	rho := ec2.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	c := ec2.PaillierEncrypt(pk, x, rho)
+	gx, gy := curve.Params().Gx, curve.Params().Gy
+	xx, xy := curve.ScalarBaseMult(x.Bytes())
+
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 3)
+	proof := ec2.LogStarProve(curve, ctx, pk, nt, c, gx, gy, xx, xy, x, rho)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.LogStarVerify(curve, ctx, pk, nt, c, gx, gy, xx, xy, proof))
+
+	// replay in another context
+	assert.False(t, ec2.LogStarVerify(curve, ec2.NewProofContext("session2", big.NewInt(1), 3), pk, nt, c, gx, gy, xx, xy, proof))
+	assert.False(t, ec2.LogStarVerify(curve, ec2.NewProofContext("session", big.NewInt(2), 3), pk, nt, c, gx, gy, xx, xy, proof))
+	assert.False(t, ec2.LogStarVerify(curve, ec2.NewProofContext("session", big.NewInt(1), 2), pk, nt, c, gx, gy, xx, xy, proof))
+
+	// another point
+	x2, y2 := curve.Double(xx, xy)
+	assert.False(t, ec2.LogStarVerify(curve, ctx, pk, nt, c, gx, gy, x2, y2, proof))
+
+	bad := *proof
+	bad.Z1 = new(big.Int).Add(proof.Z1, big.NewInt(1))
+	assert.False(t, ec2.LogStarVerify(curve, ctx, pk, nt, c, gx, gy, xx, xy, &bad))
+	assert.False(t, ec2.LogStarVerify(curve, ctx, pk, nt, c, gx, gy, xx, xy, nil))
+}
@@ -0,0 +1,294 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// NoSmallFactorL the bit length of the smallest prime factor accepted, the challenge e is in [0,2^NoSmallFactorL)
+	NoSmallFactorL = 256
+
+	// NoSmallFactorEpsilon the slackness parameter
+	NoSmallFactorEpsilon = 512
+)
+
+// NoSmallFactorProof
+// no small factor proof: N0 = p*q, p,q > 2^NoSmallFactorL, using the ring-pedersen parameters (Ntilde,h1,h2) of the verifier
+// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts (CGGMP21), appendix C.5, figure 28
+type NoSmallFactorProof struct {
+	P     *big.Int
+	Q     *big.Int
+	A     *big.Int
+	B     *big.Int
+	T     *big.Int
+	Sigma *big.Int
+	Z1    *big.Int
+	Z2    *big.Int
+	W1    *big.Int
+	W2    *big.Int
+	V     *big.Int
+}
+
+//------------------------------------------------------------------------------------
+
+// NoSmallFactorProve
+// prover sample alpha,beta in +-2^(l+e)*sqrt(N0), mu,nu in +-2^l*Ntilde, sigma in +-2^l*N0*Ntilde, r in +-2^(l+e)*N0*Ntilde, x,y in +-2^(l+e)*Ntilde
+// P = h1^p*h2^mu, Q = h1^q*h2^nu, A = h1^alpha*h2^x, B = h1^beta*h2^y, T = Q^alpha*h2^r
+// e = H(N0,Ntilde,h1,h2,P,Q,A,B,T,sigma,ctx)
+// z1 = alpha + e*p, z2 = beta + e*q, w1 = x + e*mu, w2 = y + e*nu, v = r + e*(sigma - nu*p)
+func NoSmallFactorProve(ctx *ProofContext, n0 *big.Int, p *big.Int, q *big.Int, ntilde *NtildeH1H2) *NoSmallFactorProof {
+	if n0 == nil || p == nil || q == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil {
+		return nil
+	}
+
+	if new(big.Int).Mul(p, q).Cmp(n0) != 0 {
+		return nil
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+
+	sqrtN0 := new(big.Int).Sqrt(n0)
+	twoL := new(big.Int).Lsh(one, NoSmallFactorL)
+	twoLE := new(big.Int).Lsh(one, NoSmallFactorL+NoSmallFactorEpsilon)
+	n0nt := new(big.Int).Mul(n0, nt)
+
+	alpha := getRandomIntInRange(new(big.Int).Mul(twoLE, sqrtN0))
+	beta := getRandomIntInRange(new(big.Int).Mul(twoLE, sqrtN0))
+	mu := getRandomIntInRange(new(big.Int).Mul(twoL, nt))
+	nu := getRandomIntInRange(new(big.Int).Mul(twoL, nt))
+	sigma := getRandomIntInRange(new(big.Int).Mul(twoL, n0nt))
+	r := getRandomIntInRange(new(big.Int).Mul(twoLE, n0nt))
+	x := getRandomIntInRange(new(big.Int).Mul(twoLE, nt))
+	y := getRandomIntInRange(new(big.Int).Mul(twoLE, nt))
+	if alpha == nil || beta == nil || mu == nil || nu == nil || sigma == nil || r == nil || x == nil || y == nil {
+		return nil
+	}
+
+	P := ringPedersenCommit(s, p, t, mu, nt)
+	Q := ringPedersenCommit(s, q, t, nu, nt)
+	A := ringPedersenCommit(s, alpha, t, x, nt)
+	B := ringPedersenCommit(s, beta, t, y, nt)
+	T := ringPedersenCommit(Q, alpha, t, r, nt)
+	if P == nil || Q == nil || A == nil || B == nil || T == nil {
+		return nil
+	}
+
+	e := Sha512_256(n0, nt, s, t, P, Q, A, B, T, sigma, ctx.Int())
+	if e == nil {
+		return nil
+	}
+
+	sigmaHat := new(big.Int).Sub(sigma, new(big.Int).Mul(nu, p))
+
+	z1 := new(big.Int).Add(alpha, new(big.Int).Mul(e, p))
+	z2 := new(big.Int).Add(beta, new(big.Int).Mul(e, q))
+	w1 := new(big.Int).Add(x, new(big.Int).Mul(e, mu))
+	w2 := new(big.Int).Add(y, new(big.Int).Mul(e, nu))
+	v := new(big.Int).Add(r, new(big.Int).Mul(e, sigmaHat))
+
+	return &NoSmallFactorProof{P: P, Q: Q, A: A, B: B, T: T, Sigma: sigma, Z1: z1, Z2: z2, W1: w1, W2: w2, V: v}
+}
+
+// NoSmallFactorVerify
+// check:
+// h1^z1*h2^w1 = A*P^e (mod Ntilde)
+// h1^z2*h2^w2 = B*Q^e (mod Ntilde)
+// Q^z1*h2^v = T*R^e (mod Ntilde), R = h1^N0*h2^sigma
+// |z1|,|z2| <= 2^(l+e)*sqrt(N0)
+func NoSmallFactorVerify(ctx *ProofContext, n0 *big.Int, ntilde *NtildeH1H2, proof *NoSmallFactorProof) bool {
+	if n0 == nil || ntilde == nil || ntilde.Ntilde == nil || ntilde.H1 == nil || ntilde.H2 == nil {
+		return false
+	}
+
+	if proof == nil || proof.P == nil || proof.Q == nil || proof.A == nil || proof.B == nil || proof.T == nil || proof.Sigma == nil || proof.Z1 == nil || proof.Z2 == nil || proof.W1 == nil || proof.W2 == nil || proof.V == nil {
+		return false
+	}
+
+	nt := ntilde.Ntilde
+	s := ntilde.H1
+	t := ntilde.H2
+
+	for _, v := range []*big.Int{proof.P, proof.Q, proof.A, proof.B, proof.T} {
+		if !IsNumberInMultiplicativeGroup(nt, v) {
+			return false
+		}
+	}
+
+	bound := new(big.Int).Lsh(new(big.Int).Sqrt(n0), NoSmallFactorL+NoSmallFactorEpsilon)
+	if new(big.Int).Abs(proof.Z1).Cmp(bound) > 0 || new(big.Int).Abs(proof.Z2).Cmp(bound) > 0 {
+		fmt.Printf("check that a zero-knowledge proof that paillier.N has no small factor fail, z1 or z2 out of range\n")
+		return false
+	}
+
+	e := Sha512_256(n0, nt, s, t, proof.P, proof.Q, proof.A, proof.B, proof.T, proof.Sigma, ctx.Int())
+	if e == nil {
+		return false
+	}
+
+	modNt := ModInt(nt)
+
+	// h1^z1*h2^w1 = A*P^e
+	left := ringPedersenCommit(s, proof.Z1, t, proof.W1, nt)
+	right := modNt.Mul(proof.A, modNt.Exp(proof.P, e))
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check that a zero-knowledge proof that paillier.N has no small factor fail, s^z1*t^w1 != A*P^e\n")
+		return false
+	}
+
+	// h1^z2*h2^w2 = B*Q^e
+	left = ringPedersenCommit(s, proof.Z2, t, proof.W2, nt)
+	right = modNt.Mul(proof.B, modNt.Exp(proof.Q, e))
+	if left == nil || left.Cmp(right) != 0 {
+		fmt.Printf("check that a zero-knowledge proof that paillier.N has no small factor fail, s^z2*t^w2 != B*Q^e\n")
+		return false
+	}
+
+	// Q^z1*h2^v = T*R^e
+	R := ringPedersenCommit(s, n0, t, proof.Sigma, nt)
+	left = ringPedersenCommit(proof.Q, proof.Z1, t, proof.V, nt)
+	if R == nil || left == nil {
+		return false
+	}
+	right = modNt.Mul(proof.T, modNt.Exp(R, e))
+	if left.Cmp(right) != 0 {
+		fmt.Printf("check that a zero-knowledge proof that paillier.N has no small factor fail, Q^z1*t^v != T*R^e\n")
+		return false
+	}
+
+	return true
+}
+
+// getRandomIntInRange get a random number in [-bound,bound)
+// the bound may be longer than mustGetRandomIntMaxBits, so use rand.Int directly
+func getRandomIntInRange(bound *big.Int) *big.Int {
+	if bound == nil || bound.Sign() <= 0 {
+		return nil
+	}
+
+	r, err := rand.Int(rand.Reader, new(big.Int).Lsh(bound, 1))
+	if err != nil {
+		return nil
+	}
+
+	return r.Sub(r, bound)
+}
+
+// ringPedersenCommit return s^a*t^b mod n, a and b may be negative
+func ringPedersenCommit(s *big.Int, a *big.Int, t *big.Int, b *big.Int, n *big.Int) *big.Int {
+	sa := expWithNegative(s, a, n)
+	tb := expWithNegative(t, b, n)
+	if sa == nil || tb == nil {
+		return nil
+	}
+
+	return ModInt(n).Mul(sa, tb)
+}
+
+// expWithNegative return x^e mod n, x^e = (x^-1)^(-e) when e < 0
+func expWithNegative(x *big.Int, e *big.Int, n *big.Int) *big.Int {
+	if e.Sign() >= 0 {
+		return new(big.Int).Exp(x, e, n)
+	}
+
+	inv := new(big.Int).ModInverse(x, n)
+	if inv == nil {
+		return nil
+	}
+
+	return new(big.Int).Exp(inv, new(big.Int).Neg(e), n)
+}
+
+//----------------------------------------------------------------------------------
+
+// MarshalJSON marshal NoSmallFactorProof to json bytes
+func (nsfpf *NoSmallFactorProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		P     string `json:"P"`
+		Q     string `json:"Q"`
+		A     string `json:"A"`
+		B     string `json:"B"`
+		T     string `json:"T"`
+		Sigma string `json:"Sigma"`
+		Z1    string `json:"Z1"`
+		Z2    string `json:"Z2"`
+		W1    string `json:"W1"`
+		W2    string `json:"W2"`
+		V     string `json:"V"`
+	}{
+		P:     fmt.Sprintf("%v", nsfpf.P),
+		Q:     fmt.Sprintf("%v", nsfpf.Q),
+		A:     fmt.Sprintf("%v", nsfpf.A),
+		B:     fmt.Sprintf("%v", nsfpf.B),
+		T:     fmt.Sprintf("%v", nsfpf.T),
+		Sigma: fmt.Sprintf("%v", nsfpf.Sigma),
+		Z1:    fmt.Sprintf("%v", nsfpf.Z1),
+		Z2:    fmt.Sprintf("%v", nsfpf.Z2),
+		W1:    fmt.Sprintf("%v", nsfpf.W1),
+		W2:    fmt.Sprintf("%v", nsfpf.W2),
+		V:     fmt.Sprintf("%v", nsfpf.V),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to NoSmallFactorProof
+func (nsfpf *NoSmallFactorProof) UnmarshalJSON(raw []byte) error {
+	var zk struct {
+		P     string `json:"P"`
+		Q     string `json:"Q"`
+		A     string `json:"A"`
+		B     string `json:"B"`
+		T     string `json:"T"`
+		Sigma string `json:"Sigma"`
+		Z1    string `json:"Z1"`
+		Z2    string `json:"Z2"`
+		W1    string `json:"W1"`
+		W2    string `json:"W2"`
+		V     string `json:"V"`
+	}
+	if err := json.Unmarshal(raw, &zk); err != nil {
+		return err
+	}
+
+	in := []string{zk.P, zk.Q, zk.A, zk.B, zk.T, zk.Sigma, zk.Z1, zk.Z2, zk.W1, zk.W2, zk.V}
+	out := make([]*big.Int, len(in))
+	for k, v := range in {
+		tmp, _ := new(big.Int).SetString(v, 10)
+		if tmp == nil {
+			return fmt.Errorf("get no small factor proof fail")
+		}
+		out[k] = tmp
+	}
+
+	nsfpf.P = out[0]
+	nsfpf.Q = out[1]
+	nsfpf.A = out[2]
+	nsfpf.B = out[3]
+	nsfpf.T = out[4]
+	nsfpf.Sigma = out[5]
+	nsfpf.Z1 = out[6]
+	nsfpf.Z2 = out[7]
+	nsfpf.W1 = out[8]
+	nsfpf.W2 = out[9]
+	nsfpf.V = out[10]
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoSmallFactorProveVerify(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.NoSmallFactorProve(ctx, n, p, q, pre.NtildeH1H2)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, proof))
+
+	b, err := json.Marshal(proof)
+	assert.NoError(t, err)
+	dec := &ec2.NoSmallFactorProof{}
+	assert.NoError(t, json.Unmarshal(b, dec))
+	assert.True(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, dec))
+
+	// wrong factors
+	assert.Nil(t, ec2.NoSmallFactorProve(ctx, n, p, big.NewInt(3), pre.NtildeH1H2))
+}
+
+func TestNoSmallFactorVerifyTamper(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.NoSmallFactorProve(ctx, n, p, q, pre.NtildeH1H2)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+
+	// the proof is bound to the session,the prover and the round
+	assert.False(t, ec2.NoSmallFactorVerify(ec2.NewProofContext("other", big.NewInt(1), 5), n, pre.NtildeH1H2, proof))
+	assert.False(t, ec2.NoSmallFactorVerify(ec2.NewProofContext("session", big.NewInt(2), 5), n, pre.NtildeH1H2, proof))
+	assert.False(t, ec2.NoSmallFactorVerify(ec2.NewProofContext("session", big.NewInt(1), 6), n, pre.NtildeH1H2, proof))
+
+	// the proof is for n only
+	assert.False(t, ec2.NoSmallFactorVerify(ctx, new(big.Int).Add(n, big.NewInt(2)), pre.NtildeH1H2, proof))
+
+	for _, v := range []**big.Int{&proof.Z1, &proof.Z2, &proof.W1, &proof.V, &proof.Sigma} {
+		old := *v
+		*v = new(big.Int).Add(old, big.NewInt(1))
+		assert.False(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, proof))
+		*v = old
+	}
+
+	// z1 out of range
+	z1 := proof.Z1
+	proof.Z1 = new(big.Int).Lsh(new(big.Int).Sqrt(n), ec2.NoSmallFactorL+ec2.NoSmallFactorEpsilon+1)
+	assert.False(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, proof))
+	proof.Z1 = z1
+
+	assert.True(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, proof))
+	assert.False(t, ec2.NoSmallFactorVerify(ctx, n, pre.NtildeH1H2, nil))
+}
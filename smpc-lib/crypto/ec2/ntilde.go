@@ -43,16 +43,22 @@ func GenerateNtildeH1H2(length int) (*NtildeH1H2, *big.Int, *big.Int, *big.Int,
 		return nil, nil, nil, nil, nil,nil,nil
 	}
 
-	sp1 := <-SafePrimeCh
-	sp2 := <-SafePrimeCh
+	ch := GetSafePrimeCh(length)
+	sp1 := <-ch
+	sp2 := <-ch
 
 	if sp1.p == nil || sp2.p == nil {
 		return nil, nil, nil, nil, nil,nil,nil
 	}
 
-	SafePrimeCh <- sp1
-	SafePrimeCh <- sp2
+	ch <- sp1
+	ch <- sp2
 
+	return newNtildeH1H2(sp1, sp2)
+}
+
+// newNtildeH1H2 create ntilde data from the safe primes sp1 and sp2
+func newNtildeH1H2(sp1 SafePrime, sp2 SafePrime) (*NtildeH1H2, *big.Int, *big.Int, *big.Int, *big.Int,*big.Int,*big.Int) {
 	NTildei := new(big.Int).Mul(sp1.P(), sp2.P())
 	modNTildeI := ModInt(NTildei)
 
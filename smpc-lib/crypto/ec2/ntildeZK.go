@@ -62,6 +62,14 @@ func (p *NtildeProof) Verify(h1, h2, N *big.Int) bool {
 		return false
 	}
 
+	vals := append([]*big.Int{h1, h2, N}, p.Alpha[:]...)
+	vals = append(vals, p.T[:]...)
+	return verifyOnce("NtildeProof", func() bool { return p.verify(h1, h2, N) }, vals...)
+}
+
+// verify check the ntilde proof,the args have been checked by Verify
+func (p *NtildeProof) verify(h1, h2, N *big.Int) bool {
+
 	// check
 	zero := big.NewInt(0)
 	one := big.NewInt(1)
@@ -36,7 +36,9 @@ func checkH1H2(H1 *big.Int, H2 *big.Int) bool {
 }
 
 func TestNtildeVerify(t *testing.T) {
-	nt, alpha, beta, p, q := ec2.CreateNt(testNtildeLength)
+	pre := getPreParams(t)
+	nt := pre.NtildeH1H2
+	alpha, beta, p, q := pre.NtildePriv.Alpha, pre.NtildePriv.Beta, pre.NtildePriv.Q1, pre.NtildePriv.Q2
 	assert.NotZero(t, nt)
 	assert.NotZero(t, alpha)
 	assert.NotZero(t, beta)
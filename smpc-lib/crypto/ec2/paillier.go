@@ -51,19 +51,30 @@ func GenerateKeyPair(length int) (*PublicKey, *PrivateKey,*big.Int,*big.Int) {
 	    return nil,nil,nil,nil
 	}
 
-	one := big.NewInt(1)
-
-	sp1 := <-SafePrimeCh
+	ch := GetSafePrimeCh(length)
+	sp1 := <-ch
 	p := sp1.p
-	sp2 := <-SafePrimeCh
+	sp2 := <-ch
 	q := sp2.p
 
 	if p == nil || q == nil {
 		return nil, nil,nil,nil
 	}
 
-	SafePrimeCh <- sp1
-	SafePrimeCh <- sp2
+	ch <- sp1
+	ch <- sp2
+
+	publicKey, privateKey := newKeyPair(length, p, q)
+	if publicKey == nil {
+		return nil, nil,nil,nil
+	}
+
+	return publicKey, privateKey,p,q
+}
+
+// newKeyPair create paillier pubkey and private key from the safe primes p and q
+func newKeyPair(length int, p *big.Int, q *big.Int) (*PublicKey, *PrivateKey) {
+	one := big.NewInt(1)
 
 	n := new(big.Int).Mul(p, q)
 	n2 := new(big.Int).Mul(n, n)
@@ -75,13 +86,13 @@ func GenerateKeyPair(length int) (*PublicKey, *PrivateKey,*big.Int,*big.Int) {
 	l := new(big.Int).Mul(pMinus1, qMinus1)
 	u := new(big.Int).ModInverse(l, n)
 	if u == nil {
-		return nil, nil,nil,nil
+		return nil, nil
 	}
 
 	publicKey := &PublicKey{Length: strconv.Itoa(length), N: n, G: g, N2: n2}
 	privateKey := &PrivateKey{Length: strconv.Itoa(length), PublicKey: *publicKey, L: l, U: u}
 
-	return publicKey, privateKey,p,q
+	return publicKey, privateKey
 }
 
 // Encrypt paillier encrypt by public key
@@ -160,6 +171,36 @@ func (publicKey *PublicKey) HomoMul(cipher, k *big.Int) *big.Int {
 	return newCipher
 }
 
+// Primes  get the prime factors p,q of N from N and L = (p-1)*(q-1)
+// p + q = N - L + 1, p - q = sqrt((p+q)^2 - 4N)
+func (privateKey *PrivateKey) Primes() (*big.Int, *big.Int) {
+	if privateKey == nil || privateKey.N == nil || privateKey.L == nil {
+		return nil, nil
+	}
+
+	one := big.NewInt(1)
+	sum := new(big.Int).Sub(privateKey.N, privateKey.L)
+	sum.Add(sum, one)
+
+	disc := new(big.Int).Mul(sum, sum)
+	disc.Sub(disc, new(big.Int).Lsh(privateKey.N, 2))
+	if disc.Sign() < 0 {
+		return nil, nil
+	}
+
+	diff := new(big.Int).Sqrt(disc)
+	p := new(big.Int).Add(sum, diff)
+	p.Rsh(p, 1)
+	q := new(big.Int).Sub(sum, diff)
+	q.Rsh(q, 1)
+
+	if new(big.Int).Mul(p, q).Cmp(privateKey.N) != 0 {
+		return nil, nil
+	}
+
+	return p, q
+}
+
 //------------------------------------------------------------------------------
 
 // MarshalJSON marshal PublicKey to json bytes
@@ -0,0 +1,354 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PaillierBlumIterations the count of challenges y_i, the soundness error is 2^-PaillierBlumIterations
+	PaillierBlumIterations = 80
+)
+
+// PaillierBlumProof
+// Paillier-Blum modulus proof: N = p*q, p = q = 3 mod 4, gcd(N,OuLa(N)) = 1
+// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts (CGGMP21), section 6.3, figure 16
+type PaillierBlumProof struct {
+	W *big.Int
+	X []*big.Int
+	A []int
+	B []int
+	Z []*big.Int
+}
+
+//------------------------------------------------------------------------------------
+
+// CalcPaillierBlumY
+// return PaillierBlumIterations random int: Yi belong to ZN*
+// Yi = H(N,ctx,w,i), len(Yi) == len(N)
+func CalcPaillierBlumY(n *big.Int, ctx *ProofContext, w *big.Int) []*big.Int {
+	if n == nil || zero.Cmp(n) != -1 || w == nil {
+		return nil
+	}
+
+	num := ctx.Int()
+
+	l := len(n.Bytes())
+	str := "paillierblummodulusproof"
+	strnum := new(big.Int).SetBytes([]byte(str))
+
+	y := make([]*big.Int, PaillierBlumIterations)
+	for i := 0; i < PaillierBlumIterations; i++ {
+		for j := 0; ; j++ {
+			buf := make([]byte, 0, l+32)
+			for k := 0; len(buf) < l; k++ {
+				h := Sha512_256(strnum, n, num, w, big.NewInt(int64(i)), big.NewInt(int64(j)), big.NewInt(int64(k)))
+				if h == nil {
+					return nil
+				}
+				buf = append(buf, h.FillBytes(make([]byte, 32))...)
+			}
+
+			tmp := new(big.Int).SetBytes(buf[:l])
+			tmp.Mod(tmp, n)
+			if IsNumberInMultiplicativeGroup(n, tmp) {
+				y[i] = tmp
+				break
+			}
+		}
+	}
+
+	return y
+}
+
+// PaillierBlumProve
+// prover pick w with Jacobi-Symbol (w/N) = -1 and, for every Yi:
+// Zi = Yi^(N^-1 mod OuLa(N)) mod N
+// Xi = 4-th root of Yi' = (-1)^Ai * w^Bi * Yi mod N, Ai and Bi in {0,1} such as Yi' is a quadratic residue mod N
+// p and q are the prime factors of N,the challenges are bound to ctx
+func PaillierBlumProve(ctx *ProofContext, n *big.Int, p *big.Int, q *big.Int) *PaillierBlumProof {
+	if n == nil || p == nil || q == nil {
+		return nil
+	}
+
+	if new(big.Int).Mul(p, q).Cmp(n) != 0 {
+		return nil
+	}
+
+	four := big.NewInt(4)
+	three := big.NewInt(3)
+	if new(big.Int).Mod(p, four).Cmp(three) != 0 || new(big.Int).Mod(q, four).Cmp(three) != 0 {
+		return nil
+	}
+
+	pMinus1 := new(big.Int).Sub(p, one)
+	qMinus1 := new(big.Int).Sub(q, one)
+	l := new(big.Int).Mul(pMinus1, qMinus1)
+	nInv := new(big.Int).ModInverse(n, l)
+	if nInv == nil {
+		return nil
+	}
+
+	var w *big.Int
+	for {
+		w = GetRandomPositiveRelativelyPrimeInt(n)
+		if w == nil {
+			return nil
+		}
+
+		if big.Jacobi(w, n) == -1 {
+			break
+		}
+	}
+
+	Y := CalcPaillierBlumY(n, ctx, w)
+	if Y == nil {
+		return nil
+	}
+
+	// p = 3 mod 4, the square root of a quadratic residue r mod p is r^((p+1)/4) and it is a quadratic residue too,
+	// so the 4-th root is r^(((p+1)/4)^2)
+	ep := new(big.Int).Div(new(big.Int).Add(p, one), four)
+	ep.Mul(ep, ep)
+	eq := new(big.Int).Div(new(big.Int).Add(q, one), four)
+	eq.Mul(eq, eq)
+	pInv := new(big.Int).ModInverse(p, q)
+	if pInv == nil {
+		return nil
+	}
+
+	proof := &PaillierBlumProof{
+		W: w,
+		X: make([]*big.Int, PaillierBlumIterations),
+		A: make([]int, PaillierBlumIterations),
+		B: make([]int, PaillierBlumIterations),
+		Z: make([]*big.Int, PaillierBlumIterations),
+	}
+
+	for i, y := range Y {
+		proof.Z[i] = new(big.Int).Exp(y, nInv, n)
+
+		found := false
+		for a := 0; a < 2 && !found; a++ {
+			for b := 0; b < 2 && !found; b++ {
+				yi := paillierBlumAdjust(n, y, w, a, b)
+				yp := new(big.Int).Mod(yi, p)
+				yq := new(big.Int).Mod(yi, q)
+				if big.Jacobi(yp, p) != 1 || big.Jacobi(yq, q) != 1 {
+					continue
+				}
+
+				// x = xp + p * ((xq - xp) * p^-1 mod q)
+				xp := new(big.Int).Exp(yp, ep, p)
+				xq := new(big.Int).Exp(yq, eq, q)
+				h := new(big.Int).Sub(xq, xp)
+				h.Mul(h, pInv)
+				h.Mod(h, q)
+				x := new(big.Int).Mul(h, p)
+				x.Add(x, xp)
+
+				proof.X[i] = x
+				proof.A[i] = a
+				proof.B[i] = b
+				found = true
+			}
+		}
+
+		if !found {
+			return nil
+		}
+	}
+
+	return proof
+}
+
+// PaillierBlumVerify
+// check:
+// N is odd and not a prime
+// Jacobi-Symbol (w/N) = -1
+// Zi^N = Yi (mod N)
+// Xi^4 = (-1)^Ai * w^Bi * Yi (mod N)
+func PaillierBlumVerify(ctx *ProofContext, n *big.Int, proof *PaillierBlumProof) bool {
+	if n == nil || proof == nil || proof.W == nil {
+		return false
+	}
+
+	if len(proof.X) != PaillierBlumIterations || len(proof.A) != PaillierBlumIterations || len(proof.B) != PaillierBlumIterations || len(proof.Z) != PaillierBlumIterations {
+		return false
+	}
+
+	if n.Cmp(one) <= 0 || n.Bit(0) == 0 || n.ProbablyPrime(PrimeTestTimes) {
+		return false
+	}
+
+	if proof.W.Cmp(zero) <= 0 || proof.W.Cmp(n) >= 0 || big.Jacobi(proof.W, n) != -1 {
+		return false
+	}
+
+	Y := CalcPaillierBlumY(n, ctx, proof.W)
+	if Y == nil {
+		return false
+	}
+
+	four := big.NewInt(4)
+	for i, y := range Y {
+		z := proof.Z[i]
+		x := proof.X[i]
+		if z == nil || x == nil || z.Cmp(zero) <= 0 || z.Cmp(n) >= 0 || x.Cmp(zero) <= 0 || x.Cmp(n) >= 0 {
+			return false
+		}
+
+		if proof.A[i] != 0 && proof.A[i] != 1 || proof.B[i] != 0 && proof.B[i] != 1 {
+			return false
+		}
+
+		if new(big.Int).Exp(z, n, n).Cmp(y) != 0 {
+			fmt.Printf("check that a zero-knowledge proof that paillier.N is a paillier-blum modulus fail, zi^N != yi\n")
+			return false
+		}
+
+		yi := paillierBlumAdjust(n, y, proof.W, proof.A[i], proof.B[i])
+		if new(big.Int).Exp(x, four, n).Cmp(yi) != 0 {
+			fmt.Printf("check that a zero-knowledge proof that paillier.N is a paillier-blum modulus fail, xi^4 != yi'\n")
+			return false
+		}
+	}
+
+	return true
+}
+
+// paillierBlumAdjust return (-1)^a * w^b * y mod N
+func paillierBlumAdjust(n *big.Int, y *big.Int, w *big.Int, a int, b int) *big.Int {
+	ret := new(big.Int).Set(y)
+	if b == 1 {
+		ret.Mul(ret, w)
+		ret.Mod(ret, n)
+	}
+
+	if a == 1 {
+		ret.Sub(n, ret)
+		ret.Mod(ret, n)
+	}
+
+	return ret
+}
+
+//----------------------------------------------------------------------------------
+
+// MarshalJSON marshal PaillierBlumProof to json bytes
+func (pbpf *PaillierBlumProof) MarshalJSON() ([]byte, error) {
+	x := make([]string, 0)
+	for _, v := range pbpf.X {
+		x = append(x, fmt.Sprintf("%v", v))
+	}
+
+	a := make([]string, 0)
+	for _, v := range pbpf.A {
+		a = append(a, strconv.Itoa(v))
+	}
+
+	b := make([]string, 0)
+	for _, v := range pbpf.B {
+		b = append(b, strconv.Itoa(v))
+	}
+
+	z := make([]string, 0)
+	for _, v := range pbpf.Z {
+		z = append(z, fmt.Sprintf("%v", v))
+	}
+
+	return json.Marshal(struct {
+		W string `json:"W"`
+		X string `json:"X"`
+		A string `json:"A"`
+		B string `json:"B"`
+		Z string `json:"Z"`
+	}{
+		W: fmt.Sprintf("%v", pbpf.W),
+		X: strings.Join(x, ":"),
+		A: strings.Join(a, ":"),
+		B: strings.Join(b, ":"),
+		Z: strings.Join(z, ":"),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to PaillierBlumProof
+func (pbpf *PaillierBlumProof) UnmarshalJSON(raw []byte) error {
+	var zk struct {
+		W string `json:"W"`
+		X string `json:"X"`
+		A string `json:"A"`
+		B string `json:"B"`
+		Z string `json:"Z"`
+	}
+	if err := json.Unmarshal(raw, &zk); err != nil {
+		return err
+	}
+
+	w, _ := new(big.Int).SetString(zk.W, 10)
+	if w == nil {
+		return fmt.Errorf("get w fail")
+	}
+
+	x := make([]*big.Int, 0)
+	for _, v := range strings.Split(zk.X, ":") {
+		tmp, _ := new(big.Int).SetString(v, 10)
+		if tmp == nil {
+			return fmt.Errorf("get x fail")
+		}
+		x = append(x, tmp)
+	}
+
+	a := make([]int, 0)
+	for _, v := range strings.Split(zk.A, ":") {
+		tmp, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("get a fail")
+		}
+		a = append(a, tmp)
+	}
+
+	b := make([]int, 0)
+	for _, v := range strings.Split(zk.B, ":") {
+		tmp, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("get b fail")
+		}
+		b = append(b, tmp)
+	}
+
+	z := make([]*big.Int, 0)
+	for _, v := range strings.Split(zk.Z, ":") {
+		tmp, _ := new(big.Int).SetString(v, 10)
+		if tmp == nil {
+			return fmt.Errorf("get z fail")
+		}
+		z = append(z, tmp)
+	}
+
+	pbpf.W = w
+	pbpf.X = x
+	pbpf.A = a
+	pbpf.B = b
+	pbpf.Z = z
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaillierBlumProveVerify(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.PaillierBlumProve(ctx, n, p, q)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.PaillierBlumVerify(ctx, n, proof))
+
+	b, err := json.Marshal(proof)
+	assert.NoError(t, err)
+	dec := &ec2.PaillierBlumProof{}
+	assert.NoError(t, json.Unmarshal(b, dec))
+	assert.True(t, ec2.PaillierBlumVerify(ctx, n, dec))
+
+	// wrong factors
+	assert.Nil(t, ec2.PaillierBlumProve(ctx, n, p, new(big.Int).Add(q, big.NewInt(2))))
+}
+
+func TestPaillierBlumVerifyTamper(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.PaillierBlumProve(ctx, n, p, q)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+
+	// the proof is bound to the session,the prover and the round
+	assert.False(t, ec2.PaillierBlumVerify(ec2.NewProofContext("other", big.NewInt(1), 5), n, proof))
+	assert.False(t, ec2.PaillierBlumVerify(ec2.NewProofContext("session", big.NewInt(2), 5), n, proof))
+	assert.False(t, ec2.PaillierBlumVerify(ec2.NewProofContext("session", big.NewInt(1), 6), n, proof))
+
+	// the proof is for n only
+	other := pre.NtildeH1H2.Ntilde
+	assert.False(t, ec2.PaillierBlumVerify(ctx, other, proof))
+
+	x := proof.X[0]
+	proof.X[0] = new(big.Int).Add(x, big.NewInt(1))
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, proof))
+	proof.X[0] = x
+
+	z := proof.Z[0]
+	proof.Z[0] = new(big.Int).Add(z, big.NewInt(1))
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, proof))
+	proof.Z[0] = z
+
+	proof.A[0] ^= 1
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, proof))
+	proof.A[0] ^= 1
+
+	proof.X = proof.X[1:]
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, proof))
+
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, nil))
+}
+
+func TestPaillierBlumVerifyTamperCopy(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.PaillierBlumProve(ctx, n, p, q)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+
+	// tamper with a copy the way a malicious party would in keygen round 5
+	bad := *proof
+	bad.X = append([]*big.Int{}, proof.X...)
+	bad.X[0] = new(big.Int).Add(bad.X[0], big.NewInt(1))
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, &bad))
+	assert.True(t, ec2.PaillierBlumVerify(ctx, n, proof))
+
+	bad = *proof
+	bad.B = append([]int{}, proof.B...)
+	bad.B[0] ^= 1
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, &bad))
+
+	// w must be a quadratic non-residue with jacobi symbol -1
+	bad = *proof
+	bad.W = new(big.Int).Exp(proof.W, big.NewInt(2), n)
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, &bad))
+
+	bad = *proof
+	bad.W = new(big.Int).Add(n, proof.W)
+	assert.False(t, ec2.PaillierBlumVerify(ctx, n, &bad))
+}
+
+func TestPaillierBlumVerifyBadModulus(t *testing.T) {
+	pre := getPreParams(t)
+	n := pre.PaillierSk.N
+	p, q := pre.PaillierSk.Primes()
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+
+	proof := ec2.PaillierBlumProve(ctx, n, p, q)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+
+	assert.False(t, ec2.PaillierBlumVerify(ctx, p, proof), "prime modulus")
+	assert.False(t, ec2.PaillierBlumVerify(ctx, new(big.Int).Lsh(n, 1), proof), "even modulus")
+	assert.False(t, ec2.PaillierBlumVerify(ctx, big.NewInt(1), proof))
+	assert.False(t, ec2.PaillierBlumVerify(ctx, nil, proof))
+}
@@ -18,20 +18,24 @@ package ec2_test
 
 import (
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
 	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
 )
 
 func TestGenerateKeyPair(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
+	simulate.LoadTestSafePrimes()
+	publicKey, privateKey, p, q := ec2.GenerateKeyPair(ec2.DefaultPaillierKeyLength)
 	assert.NotZero(t, publicKey)
 	assert.NotZero(t, privateKey)
-	t.Log(privateKey)
+	assert.Equal(t, 0, new(big.Int).Mul(p, q).Cmp(publicKey.N))
+	assert.Equal(t, ec2.DefaultPaillierKeyLength, publicKey.N.BitLen())
 }
 
 func TestEncrypt(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
+	pre := getPreParams(t)
+	publicKey, privateKey := pre.PaillierPk(), pre.PaillierSk
 	assert.NotZero(t, publicKey)
 	assert.NotZero(t, privateKey)
 	cipher, rndstar, err := publicKey.Encrypt(big.NewInt(1))
@@ -43,7 +47,8 @@ func TestEncrypt(t *testing.T) {
 
 func TestEncryptDecrypt(t *testing.T) {
 	m := big.NewInt(50)
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
+	pre := getPreParams(t)
+	publicKey, privateKey := pre.PaillierPk(), pre.PaillierSk
 	assert.NotZero(t, publicKey)
 	assert.NotZero(t, privateKey)
 	cipher, _, err := publicKey.Encrypt(m)
@@ -57,7 +62,8 @@ func TestEncryptDecrypt(t *testing.T) {
 }
 
 func TestHomoAdd(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
+	pre := getPreParams(t)
+	publicKey, privateKey := pre.PaillierPk(), pre.PaillierSk
 	assert.NotZero(t, publicKey)
 	assert.NotZero(t, privateKey)
 	five := big.NewInt(5)
@@ -71,7 +77,8 @@ func TestHomoAdd(t *testing.T) {
 }
 
 func TestHomoMul(t *testing.T) {
-	publicKey, privateKey := ec2.CreatPair(testPaillierKeyLength)
+	pre := getPreParams(t)
+	publicKey, privateKey := pre.PaillierPk(), pre.PaillierSk
 	assert.NotZero(t, publicKey)
 	assert.NotZero(t, privateKey)
 	five, _, err := publicKey.Encrypt(big.NewInt(5))
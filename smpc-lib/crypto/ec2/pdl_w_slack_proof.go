@@ -17,12 +17,11 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
 	"errors"
 	"math/big"
-
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
@@ -59,39 +58,39 @@ const (
 //------------------------------------------------------------------------------------
 
 // NewPDLwSlackProof new PDLwSlackProof
-func NewPDLwSlackProof(wit *PDLwSlackWitness, st *PDLwSlackStatement) *PDLwSlackProof {
+func NewPDLwSlackProof(curve elliptic.Curve, ctx *ProofContext, wit *PDLwSlackWitness, st *PDLwSlackStatement) *PDLwSlackProof {
     if wit == nil || st == nil {
 	return nil
     }
 
-    q3 := new(big.Int).Mul(secp256k1.S256().N, secp256k1.S256().N)
-    q3.Mul(q3, secp256k1.S256().N)
-    qNTilde := new(big.Int).Mul(secp256k1.S256().N, st.NTilde)
+    q3 := new(big.Int).Mul(curve.Params().N, curve.Params().N)
+    q3.Mul(q3, curve.Params().N)
+    qNTilde := new(big.Int).Mul(curve.Params().N, st.NTilde)
     q3NTilde := new(big.Int).Mul(q3, st.NTilde)
 
     alpha := random.GetRandomIntFromZn(q3)
-    alpha = new(big.Int).Mod(alpha,secp256k1.S256().N)
+    alpha = new(big.Int).Mod(alpha,curve.Params().N)
 
     nAddOne := new(big.Int).Add(st.PK.N, one)
     tmp := random.GetRandomIntFromZn(nAddOne)
-    tmp = new(big.Int).Mod(tmp,secp256k1.S256().N)
+    tmp = new(big.Int).Mod(tmp,curve.Params().N)
     beta := new(big.Int).Add(one,tmp)
     
     N2 := new(big.Int).Mul(st.PK.N,st.PK.N)
     
     rho := random.GetRandomIntFromZn(qNTilde)
-    rho = new(big.Int).Mod(rho,secp256k1.S256().N)
+    rho = new(big.Int).Mod(rho,curve.Params().N)
     
     gamma := random.GetRandomIntFromZn(q3NTilde)
-    gamma = new(big.Int).Mod(gamma,secp256k1.S256().N)
+    gamma = new(big.Int).Mod(gamma,curve.Params().N)
 
     z := commitmentUnknownOrder(st.H1, st.H2, st.NTilde, wit.K1, rho)
-    u1Gx,u1Gy := secp256k1.S256().ScalarMult(st.Rx,st.Ry,alpha.Bytes())
+    u1Gx,u1Gy := curve.ScalarMult(st.Rx,st.Ry,alpha.Bytes())
     u2 := commitmentUnknownOrder(nAddOne, beta, N2, alpha, st.PK.N)
     u3 := commitmentUnknownOrder(st.H1, st.H2, st.NTilde, alpha, gamma)
 
-    e := Sha512_256(st.Rx, st.Ry, st.K1RX, st.K1RY, st.CipherText, z, u1Gx, u1Gy, u2, u3,st.PK.N,nAddOne,N2,st.H1,st.H2,st.NTilde)
-    e = new(big.Int).Mod(e, secp256k1.S256().N)
+    e := Sha512_256(ctx.Int(), st.Rx, st.Ry, st.K1RX, st.K1RY, st.CipherText, z, u1Gx, u1Gy, u2, u3,st.PK.N,nAddOne,N2,st.H1,st.H2,st.NTilde)
+    e = new(big.Int).Mod(e, curve.Params().N)
     if e == nil {
 	return nil
     }
@@ -122,7 +121,7 @@ func commitmentUnknownOrder(h1, h2, NTilde, x, r *big.Int) (com *big.Int) {
 //----------------------------------------------------------------------------------
 
 // PDLwSlackVerify verify PDLwSlackProof
-func PDLwSlackVerify(st *PDLwSlackStatement,p *PDLwSlackProof) bool {
+func PDLwSlackVerify(curve elliptic.Curve, ctx *ProofContext, st *PDLwSlackStatement,p *PDLwSlackProof) bool {
     if st == nil || p == nil {
 	return false
     }
@@ -165,16 +164,16 @@ func PDLwSlackVerify(st *PDLwSlackStatement,p *PDLwSlackProof) bool {
 
     nOne := new(big.Int).Add(st.PK.N, one)
 
-    e := Sha512_256(st.Rx, st.Ry, st.K1RX, st.K1RY, st.CipherText, p.Z, p.U1X, p.U1Y, p.U2, p.U3,st.PK.N,nOne,N2,st.H1,st.H2,st.NTilde)
-    e = new(big.Int).Mod(e, secp256k1.S256().N)
+    e := Sha512_256(ctx.Int(), st.Rx, st.Ry, st.K1RX, st.K1RY, st.CipherText, p.Z, p.U1X, p.U1Y, p.U2, p.U3,st.PK.N,nOne,N2,st.H1,st.H2,st.NTilde)
+    e = new(big.Int).Mod(e, curve.Params().N)
 
     eNeg := new(big.Int).Neg(e)
-    tmp := new(big.Int).Mod(p.S1,secp256k1.S256().N)
-    gS1X,gS1Y := secp256k1.S256().ScalarMult(st.Rx, st.Ry,tmp.Bytes())
-    eFeNeg := new(big.Int).Sub(secp256k1.S256().N, e)
-    yMinusEX,yMinusEY := secp256k1.S256().ScalarMult(st.K1RX, st.K1RY,eFeNeg.Bytes())
-    u1TestX,u1TestY := secp256k1.S256().Add(gS1X,gS1Y,yMinusEX,yMinusEY)
-    if !secp256k1.S256().IsOnCurve(u1TestX,u1TestY) {
+    tmp := new(big.Int).Mod(p.S1,curve.Params().N)
+    gS1X,gS1Y := curve.ScalarMult(st.Rx, st.Ry,tmp.Bytes())
+    eFeNeg := new(big.Int).Sub(curve.Params().N, e)
+    yMinusEX,yMinusEY := curve.ScalarMult(st.K1RX, st.K1RY,eFeNeg.Bytes())
+    u1TestX,u1TestY := curve.Add(gS1X,gS1Y,yMinusEX,yMinusEY)
+    if !curve.IsOnCurve(u1TestX,u1TestY) {
 	return false
     }
 
@@ -0,0 +1,398 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  xing.chang@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+)
+
+// PreParams the paillier key and ntilde data (with the ntilde zk proofs) of one party.
+// They only depend on the safe primes, so they can be generated before keygen/reshare and consumed by it.
+type PreParams struct {
+	PaillierSk   *PrivateKey
+	NtildeH1H2   *NtildeH1H2
+	NtildePriv   *NtildePrivData
+	NtildeProof1 *NtildeProof
+	NtildeProof2 *NtildeProof
+
+	// the square free proofs of paillier N and ntilde and the hv proof of ntilde,the prover chooses the num of them,
+	// so they are made with the pre params and sent by keygen/import key instead of being made in the session.
+	// They are nil in the pre params saved by the old version,then keygen/import key make them itself.
+	SfNum       *big.Int
+	SfPf        *SquareFreeProof
+	NtildeSfNum *big.Int
+	NtildeSfPf  *SquareFreeProof
+	HvNum       *big.Int
+	HvPf        *HvProof
+}
+
+// PreParamsFunc take one pre-generated PreParams for the party `id` and the modulus of bit length `length`, nil means there is none
+type PreParamsFunc func(id string, length int) *PreParams
+
+var (
+	preParamsFunc PreParamsFunc
+	preParamsLock sync.RWMutex
+)
+
+// RegPreParamsCallBack register the pool that keygen and reshare take PreParams from
+func RegPreParamsCallBack(f PreParamsFunc) {
+	preParamsLock.Lock()
+	defer preParamsLock.Unlock()
+	preParamsFunc = f
+}
+
+// TakePreParams take one PreParams for the party `id` (the dnode id of the caller) and the modulus of bit length `length` from the registered pool.
+// It returns nil if no pool is registered or the pool has no valid one,then the caller generate the data from the safe primes itself.
+func TakePreParams(id string, length int) *PreParams {
+	preParamsLock.RLock()
+	f := preParamsFunc
+	preParamsLock.RUnlock()
+
+	if f == nil {
+		return nil
+	}
+
+	pre := f(id, length)
+	if pre == nil || pre.Validate(length) != nil {
+		return nil
+	}
+
+	return pre
+}
+
+// GeneratePreParams generate 4 new safe primes and create the PreParams for the modulus of bit length `length`.
+// It takes minutes and is supposed to run in background.
+func GeneratePreParams(length int) (*PreParams, error) {
+	if length < MinPaillierKeyLength {
+		return nil, errors.New("paillier key length is too small")
+	}
+
+	sps := make([]SafePrime, 0, 4)
+	for len(sps) < 4 {
+		q, p := random.GetSafeRandomPrimeIntByLength(length / 2)
+		if q == nil || p == nil {
+			return nil, errors.New("generate safe prime fail")
+		}
+
+		sp := SafePrime{q: q, p: p}
+		if sp.CheckValidateByLength(length) {
+			sps = append(sps, sp)
+		}
+	}
+
+	return NewPreParams(length, sps)
+}
+
+// NewPreParams create the PreParams for the modulus of bit length `length` from 4 different safe primes,
+// paillier N = sps[0].P * sps[1].P, Ntilde = sps[2].P * sps[3].P
+func NewPreParams(length int, sps []SafePrime) (*PreParams, error) {
+	if len(sps) != 4 {
+		return nil, errors.New("need 4 safe primes")
+	}
+
+	for i := range sps {
+		if sps[i].p == nil || sps[i].q == nil {
+			return nil, errors.New("invalid safe prime")
+		}
+
+		for j := 0; j < i; j++ {
+			if sps[i].p.Cmp(sps[j].p) == 0 {
+				return nil, errors.New("the safe primes must be different")
+			}
+		}
+	}
+
+	_, sk := newKeyPair(length, sps[0].p, sps[1].p)
+	if sk == nil {
+		return nil, errors.New("generate paillier key fail")
+	}
+
+	ntilde, alpha, beta, q1, q2, _, _ := newNtildeH1H2(sps[2], sps[3])
+	if ntilde == nil {
+		return nil, errors.New("gen ntilde h1 h2 fail")
+	}
+
+	pre := &PreParams{
+		PaillierSk:   sk,
+		NtildeH1H2:   ntilde,
+		NtildePriv:   &NtildePrivData{Alpha: alpha, Beta: beta, Q1: q1, Q2: q2},
+		NtildeProof1: NewNtildeProof(ntilde.H1, ntilde.H2, alpha, q1, q2, ntilde.Ntilde),
+		NtildeProof2: NewNtildeProof(ntilde.H2, ntilde.H1, beta, q1, q2, ntilde.Ntilde),
+	}
+
+	if err := pre.proveModulus(); err != nil {
+		return nil, err
+	}
+
+	if err := pre.Validate(length); err != nil {
+		return nil, err
+	}
+
+	return pre, nil
+}
+
+// proveModulus make the square free proofs of paillier N and ntilde and the hv proof of ntilde
+func (pre *PreParams) proveModulus() error {
+	n := pre.PaillierSk.N
+	pre.SfNum = MustGetRandomInt(n.BitLen())
+	pre.SfPf = SquareFreeProve(n, pre.SfNum, pre.PaillierSk.L)
+
+	ntilde := pre.NtildeH1H2.Ntilde
+	p1, p2 := pre.NtildePrimes()
+	l := new(big.Int).Mul(new(big.Int).Sub(p1, one), new(big.Int).Sub(p2, one))
+	pre.NtildeSfNum = MustGetRandomInt(ntilde.BitLen())
+	pre.NtildeSfPf = SquareFreeProve(ntilde, pre.NtildeSfNum, l)
+
+	pre.HvNum = MustGetRandomInt(ntilde.BitLen())
+	pre.HvPf = HvProve(ntilde, pre.HvNum, p1, p2)
+
+	if pre.SfPf == nil || pre.NtildeSfPf == nil || pre.HvPf == nil {
+		return errors.New("make the modulus proofs fail")
+	}
+
+	return nil
+}
+
+// hasModulusProofs weather the modulus proofs are made with the pre params
+func (pre *PreParams) hasModulusProofs() bool {
+	return pre.SfNum != nil && pre.SfPf != nil && pre.NtildeSfNum != nil && pre.NtildeSfPf != nil && pre.HvNum != nil && pre.HvPf != nil
+}
+
+// SquareFreeProof get the num and the square free proof of paillier N made with the pre params,nil if there is none
+func (pre *PreParams) SquareFreeProof() (*big.Int, *SquareFreeProof) {
+	if !pre.hasModulusProofs() {
+		return nil, nil
+	}
+
+	return pre.SfNum, pre.SfPf
+}
+
+// NtildeSquareFreeProof get the num and the square free proof of ntilde made with the pre params,nil if there is none
+func (pre *PreParams) NtildeSquareFreeProof() (*big.Int, *SquareFreeProof) {
+	if !pre.hasModulusProofs() {
+		return nil, nil
+	}
+
+	return pre.NtildeSfNum, pre.NtildeSfPf
+}
+
+// NtildeHvProof get the num and the hv proof of ntilde made with the pre params,nil if there is none
+func (pre *PreParams) NtildeHvProof() (*big.Int, *HvProof) {
+	if !pre.hasModulusProofs() {
+		return nil, nil
+	}
+
+	return pre.HvNum, pre.HvPf
+}
+
+// PaillierPk get the paillier pubkey
+func (pre *PreParams) PaillierPk() *PublicKey {
+	pk := pre.PaillierSk.PublicKey
+	return &pk
+}
+
+// NtildePrimes get the safe primes P1,P2 of ntilde, Ntilde = P1 * P2
+func (pre *PreParams) NtildePrimes() (*big.Int, *big.Int) {
+	return GetP(pre.NtildePriv.Q1), GetP(pre.NtildePriv.Q2)
+}
+
+// Validate check that the PreParams is complete and can be used for the modulus of bit length `length`
+func (pre *PreParams) Validate(length int) error {
+	if pre.PaillierSk == nil || pre.PaillierSk.N == nil || pre.NtildeH1H2 == nil || pre.NtildePriv == nil || pre.NtildePriv.Q1 == nil || pre.NtildePriv.Q2 == nil || pre.NtildeProof1 == nil || pre.NtildeProof2 == nil {
+		return errors.New("incomplete pre params")
+	}
+
+	if pre.PaillierSk.N.BitLen() != length || pre.NtildeH1H2.Ntilde.BitLen() != length {
+		return fmt.Errorf("pre params length is not %v", length)
+	}
+
+	if l, err := strconv.Atoi(pre.PaillierSk.Length); err != nil || l != length {
+		return fmt.Errorf("pre params length is not %v", length)
+	}
+
+	if p, q := pre.PaillierSk.Primes(); p == nil || q == nil {
+		return errors.New("invalid paillier private key")
+	}
+
+	p1, p2 := pre.NtildePrimes()
+	if new(big.Int).Mul(p1, p2).Cmp(pre.NtildeH1H2.Ntilde) != 0 {
+		return errors.New("invalid ntilde private data")
+	}
+
+	if !pre.NtildeProof1.Verify(pre.NtildeH1H2.H1, pre.NtildeH1H2.H2, pre.NtildeH1H2.Ntilde) || !pre.NtildeProof2.Verify(pre.NtildeH1H2.H2, pre.NtildeH1H2.H1, pre.NtildeH1H2.Ntilde) {
+		return errors.New("ntilde zk proof check fail")
+	}
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// MarshalJSON marshal PreParams to json bytes
+func (pre *PreParams) MarshalJSON() ([]byte, error) {
+	sk, err := pre.PaillierSk.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	nt, err := pre.NtildeH1H2.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := pre.NtildePriv.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	pf1, err := pre.NtildeProof1.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	pf2, err := pre.NtildeProof2.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var sf, ntsf, hv []byte
+	var sfnum, ntsfnum, hvnum string
+	if pre.hasModulusProofs() {
+		sfnum, ntsfnum, hvnum = pre.SfNum.String(), pre.NtildeSfNum.String(), pre.HvNum.String()
+		if sf, err = pre.SfPf.MarshalJSON(); err != nil {
+			return nil, err
+		}
+
+		if ntsf, err = pre.NtildeSfPf.MarshalJSON(); err != nil {
+			return nil, err
+		}
+
+		if hv, err = pre.HvPf.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(struct {
+		PaillierSk   string `json:"PaillierSk"`
+		NtildeH1H2   string `json:"NtildeH1H2"`
+		NtildePriv   string `json:"NtildePriv"`
+		NtildeProof1 string `json:"NtildeProof1"`
+		NtildeProof2 string `json:"NtildeProof2"`
+		SfNum        string `json:"SfNum,omitempty"`
+		SfPf         string `json:"SfPf,omitempty"`
+		NtildeSfNum  string `json:"NtildeSfNum,omitempty"`
+		NtildeSfPf   string `json:"NtildeSfPf,omitempty"`
+		HvNum        string `json:"HvNum,omitempty"`
+		HvPf         string `json:"HvPf,omitempty"`
+	}{
+		PaillierSk:   string(sk),
+		NtildeH1H2:   string(nt),
+		NtildePriv:   string(priv),
+		NtildeProof1: string(pf1),
+		NtildeProof2: string(pf2),
+		SfNum:        sfnum,
+		SfPf:         string(sf),
+		NtildeSfNum:  ntsfnum,
+		NtildeSfPf:   string(ntsf),
+		HvNum:        hvnum,
+		HvPf:         string(hv),
+	})
+}
+
+// UnmarshalJSON unmarshal raw to PreParams
+func (pre *PreParams) UnmarshalJSON(raw []byte) error {
+	var pp struct {
+		PaillierSk   string `json:"PaillierSk"`
+		NtildeH1H2   string `json:"NtildeH1H2"`
+		NtildePriv   string `json:"NtildePriv"`
+		NtildeProof1 string `json:"NtildeProof1"`
+		NtildeProof2 string `json:"NtildeProof2"`
+		SfNum        string `json:"SfNum,omitempty"`
+		SfPf         string `json:"SfPf,omitempty"`
+		NtildeSfNum  string `json:"NtildeSfNum,omitempty"`
+		NtildeSfPf   string `json:"NtildeSfPf,omitempty"`
+		HvNum        string `json:"HvNum,omitempty"`
+		HvPf         string `json:"HvPf,omitempty"`
+	}
+	if err := json.Unmarshal(raw, &pp); err != nil {
+		return err
+	}
+
+	pre.PaillierSk = &PrivateKey{}
+	if err := pre.PaillierSk.UnmarshalJSON([]byte(pp.PaillierSk)); err != nil {
+		return err
+	}
+
+	pre.NtildeH1H2 = &NtildeH1H2{}
+	if err := pre.NtildeH1H2.UnmarshalJSON([]byte(pp.NtildeH1H2)); err != nil {
+		return err
+	}
+
+	pre.NtildePriv = &NtildePrivData{}
+	if err := pre.NtildePriv.UnmarshalJSON([]byte(pp.NtildePriv)); err != nil {
+		return err
+	}
+
+	pre.NtildeProof1 = &NtildeProof{}
+	if err := pre.NtildeProof1.UnmarshalJSON([]byte(pp.NtildeProof1)); err != nil {
+		return err
+	}
+
+	pre.NtildeProof2 = &NtildeProof{}
+	if err := pre.NtildeProof2.UnmarshalJSON([]byte(pp.NtildeProof2)); err != nil {
+		return err
+	}
+
+	// the pre params saved by the old version have no modulus proofs
+	if pp.SfPf == "" || pp.NtildeSfPf == "" || pp.HvPf == "" {
+		return nil
+	}
+
+	nums := make([]*big.Int, 3)
+	for i, v := range []string{pp.SfNum, pp.NtildeSfNum, pp.HvNum} {
+		num, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return errors.New("invalid num of the modulus proofs")
+		}
+		nums[i] = num
+	}
+
+	sf, ntsf, hv := &SquareFreeProof{}, &SquareFreeProof{}, &HvProof{}
+	if err := sf.UnmarshalJSON([]byte(pp.SfPf)); err != nil {
+		return err
+	}
+
+	if err := ntsf.UnmarshalJSON([]byte(pp.NtildeSfPf)); err != nil {
+		return err
+	}
+
+	if err := hv.UnmarshalJSON([]byte(pp.HvPf)); err != nil {
+		return err
+	}
+
+	pre.SfNum, pre.SfPf = nums[0], sf
+	pre.NtildeSfNum, pre.NtildeSfPf = nums[1], ntsf
+	pre.HvNum, pre.HvPf = nums[2], hv
+	return nil
+}
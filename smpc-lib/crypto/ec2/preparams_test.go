@@ -0,0 +1,81 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
+	"github.com/stretchr/testify/assert"
+)
+
+// getPreParams get the paillier key and ntilde made from the fixed test safe primes,
+// so that the tests do not wait minutes for new safe primes
+func getPreParams(t *testing.T) *ec2.PreParams {
+	pre, err := simulate.TestPreParams(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pre
+}
+
+func TestPreParamsValidate(t *testing.T) {
+	pre := getPreParams(t)
+	assert.NoError(t, pre.Validate(ec2.DefaultPaillierKeyLength))
+	assert.Error(t, pre.Validate(3072))
+
+	b, err := json.Marshal(pre)
+	assert.NoError(t, err)
+	dec := &ec2.PreParams{}
+	assert.NoError(t, json.Unmarshal(b, dec))
+	assert.NoError(t, dec.Validate(ec2.DefaultPaillierKeyLength))
+	assert.Equal(t, 0, dec.PaillierSk.N.Cmp(pre.PaillierSk.N))
+
+	// the modulus proofs made with the pre params are kept
+	n, ntilde := dec.PaillierSk.N, dec.NtildeH1H2.Ntilde
+	num, sf := dec.SquareFreeProof()
+	assert.True(t, ec2.SquareFreeVerify(n, num, sf), "square free proof of paillier N")
+	num, sf = dec.NtildeSquareFreeProof()
+	assert.True(t, ec2.SquareFreeVerify(ntilde, num, sf), "square free proof of ntilde")
+	num, hv := dec.NtildeHvProof()
+	assert.True(t, ec2.HvVerify(ntilde, num, hv), "hv proof of ntilde")
+
+	pre.NtildeProof1 = nil
+	assert.Error(t, pre.Validate(ec2.DefaultPaillierKeyLength))
+}
+
+func TestPreParamsWithoutModulusProofs(t *testing.T) {
+	pre := getPreParams(t)
+	pre.SfNum, pre.SfPf, pre.NtildeSfNum, pre.NtildeSfPf, pre.HvNum, pre.HvPf = nil, nil, nil, nil, nil, nil
+
+	// the pre params saved by the old version have no modulus proofs
+	b, err := json.Marshal(pre)
+	assert.NoError(t, err)
+	dec := &ec2.PreParams{}
+	assert.NoError(t, json.Unmarshal(b, dec))
+	assert.NoError(t, dec.Validate(ec2.DefaultPaillierKeyLength))
+
+	num, sf := dec.SquareFreeProof()
+	assert.Nil(t, num)
+	assert.Nil(t, sf)
+	num, hv := dec.NtildeHvProof()
+	assert.Nil(t, num)
+	assert.Nil(t, hv)
+}
@@ -0,0 +1,66 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+)
+
+// proofContextDomain separate the context digest from the other hash inputs
+const proofContextDomain = "smpc-lib proof context v1"
+
+// ProofContext the context that the Fiat–Shamir challenge of a non-interactive proof is bound to.
+// A proof made in one session,by one party or in one round does not verify in any other context,so it can not be replayed.
+type ProofContext struct {
+	SessionKey string   // the key of the keygen/sign session (msgprex)
+	PartyID    *big.Int // the id of the prover in the group
+	Round      int      // the round that the proof is made in
+}
+
+// NewProofContext new a ProofContext
+func NewProofContext(key string, id *big.Int, round int) *ProofContext {
+	return &ProofContext{SessionKey: key, PartyID: id, Round: round}
+}
+
+// Int get the digest of the context that is hashed into the challenge,nil context is the empty context
+func (ctx *ProofContext) Int() *big.Int {
+	state := sha512.New512_256()
+	state.Write([]byte(proofContextDomain))
+
+	if ctx == nil {
+		return new(big.Int).SetBytes(state.Sum(nil))
+	}
+
+	var id []byte
+	if ctx.PartyID != nil {
+		id = ctx.PartyID.Bytes()
+	}
+
+	buf := make([]byte, 8)
+	for _, b := range [][]byte{[]byte(ctx.SessionKey), id} {
+		binary.BigEndian.PutUint64(buf, uint64(len(b)))
+		state.Write(buf)
+		state.Write(b)
+	}
+
+	binary.BigEndian.PutUint64(buf, uint64(ctx.Round))
+	state.Write(buf)
+
+	return new(big.Int).SetBytes(state.Sum(nil))
+}
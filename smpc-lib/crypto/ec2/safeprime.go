@@ -17,21 +17,39 @@
 package ec2
 
 import (
+	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"math/big"
+	"sync"
 	"time"
 )
 
 const (
         // PrimeTestTimes the times to try to juede weather is prime
 	PrimeTestTimes = 30
+
+	// DefaultPaillierKeyLength the default bit length of paillier N and Ntilde
+	DefaultPaillierKeyLength = 2048
+
+	// MinPaillierKeyLength the min bit length of paillier N accepted from peers
+	MinPaillierKeyLength = 2048
+
+	// MinNtildeLength the min bit length of Ntilde accepted from peers
+	MinNtildeLength = 2048
 )
 
 var (
         // SafePrimeCh the channel to save safeprime
 	SafePrimeCh = make(chan SafePrime, 4)
 
+	// SupportedPaillierKeyLengths the bit lengths of paillier N and Ntilde that can be requested in keygen
+	SupportedPaillierKeyLengths = []int{2048, 3072, 4096}
+
+	safePrimeChs  = map[int]chan SafePrime{DefaultPaillierKeyLength: SafePrimeCh}
+	safePrimeGens = make(map[int]bool)
+	safePrimeLock sync.Mutex
+
 	zero        = big.NewInt(0)
 	one         = big.NewInt(1)
 	two         = big.NewInt(2)
@@ -66,12 +84,17 @@ func (sp *SafePrime) SetP(p *big.Int) {
 // CheckValidate check p < 2^(L/2) ?
 // p = 2*q + 1
 func (sp *SafePrime) CheckValidate() bool {
+	return sp.CheckValidateByLength(DefaultPaillierKeyLength)
+}
+
+// CheckValidateByLength check p < 2^(L/2),L = length
+// p = 2*q + 1
+func (sp *SafePrime) CheckValidateByLength(length int) bool {
 	if sp.p == nil || sp.q == nil {
 		return false
 	}
 
-	//check p < 2^(L/2),   L = 2048
-	lhalf := big.NewInt(1024)
+	lhalf := big.NewInt(int64(length / 2))
 	m := new(big.Int).Exp(two, lhalf, nil)
 	if sp.p.Cmp(m) < 0 {
 		return probablyPrime(sp.q) &&
@@ -117,6 +140,73 @@ func GenRandomSafePrime() {
 	}
 }
 
+// CheckPaillierKeyLength check weather the bit length of paillier N and Ntilde can be requested in keygen
+func CheckPaillierKeyLength(length int) error {
+	for _, v := range SupportedPaillierKeyLengths {
+		if v == length {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported paillier key length %v,it must be one of %v", length, SupportedPaillierKeyLengths)
+}
+
+// GetSafePrimeCh get the channel saving safeprime for the modulus of bit length `length`
+func GetSafePrimeCh(length int) chan SafePrime {
+	safePrimeLock.Lock()
+	defer safePrimeLock.Unlock()
+
+	ch, ok := safePrimeChs[length]
+	if !ok {
+		ch = make(chan SafePrime, 4)
+		safePrimeChs[length] = ch
+	}
+
+	return ch
+}
+
+// GenRandomSafePrimeByLength  Generate 4 random large host primes for the modulus of bit length `length`
+func GenRandomSafePrimeByLength(length int) error {
+	if length < MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	}
+
+	ch := GetSafePrimeCh(length)
+	for {
+		if len(ch) < 4 {
+			q, p := random.GetSafeRandomPrimeIntByLength(length / 2)
+			sp := SafePrime{q: q, p: p}
+			if sp.CheckValidateByLength(length) {
+				fmt.Printf("=============================Success Generate Safe Random Prime, length = %v.=============================\n", length)
+				ch <- sp
+			}
+		}
+
+		if len(ch) == 4 {
+			return nil
+		}
+
+		time.Sleep(time.Duration(1000000)) //1000 000 000 == 1s
+	}
+}
+
+// PrepareSafePrime start generating safeprime for the modulus of bit length `length` in background,only once for every length.
+// The safeprime of the default length is generated at startup by GenRandomSafePrime.
+func PrepareSafePrime(length int) {
+	if length == DefaultPaillierKeyLength || length < MinPaillierKeyLength {
+		return
+	}
+
+	safePrimeLock.Lock()
+	defer safePrimeLock.Unlock()
+	if safePrimeGens[length] {
+		return
+	}
+
+	safePrimeGens[length] = true
+	go GenRandomSafePrimeByLength(length)
+}
+
 // GetRandomPrime add for go test
 func GetRandomPrime() (*big.Int, *big.Int) {
 	q, p := random.GetSafeRandomPrimeInt()
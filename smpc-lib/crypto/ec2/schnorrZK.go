@@ -17,12 +17,11 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
 	"errors"
 	"math/big"
-
-	s256 "github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 )
 
@@ -34,54 +33,54 @@ type ZkUProof struct {
 	S *big.Int
 }
 
-// ZkUProve create ZkUProof
-func ZkUProve(u *big.Int) *ZkUProof {
+// ZkUProve create ZkUProof,the challenge is bound to ctx
+func ZkUProve(curve elliptic.Curve, ctx *ProofContext, u *big.Int) *ZkUProof {
     	// R = r*G
-	r := random.GetRandomIntFromZn(s256.S256().N)
-	rGx, rGy := s256.S256().ScalarBaseMult(r.Bytes())
+	r := random.GetRandomIntFromZn(curve.Params().N)
+	rGx, rGy := curve.ScalarBaseMult(r.Bytes())
 
 	// U = u*G
-	uGx, uGy := s256.S256().ScalarBaseMult(u.Bytes())
+	uGx, uGy := curve.ScalarBaseMult(u.Bytes())
 
-	// e = HASH(R||U)
-	e := Sha512_256(rGx,rGy,uGx,uGy)
+	// e = HASH(ctx||R||U)
+	e := Sha512_256(ctx.Int(),rGx,rGy,uGx,uGy)
 
 	// s = r + e*u mod q
 	s := new(big.Int).Mul(e, u)
 	s = new(big.Int).Add(r, s)
-	s = new(big.Int).Mod(s, s256.S256().N)
+	s = new(big.Int).Mod(s, curve.Params().N)
 
 	// send (U,e,s) to verifier
 	zkUProof := &ZkUProof{E: e, S: s}
 	return zkUProof
 }
 
-// ZkUVerify verify ZkUProof
-func ZkUVerify(uG []*big.Int, zkUProof *ZkUProof) bool {
+// ZkUVerify verify ZkUProof made in the context ctx
+func ZkUVerify(curve elliptic.Curve, ctx *ProofContext, uG []*big.Int, zkUProof *ZkUProof) bool {
     	if uG == nil || len(uG) == 0 || zkUProof == nil || zkUProof.E == nil || zkUProof.S == nil {
 	    return false
 	}
 
 	// Check whether the point is on the curve
-	if !checkPointOnCurve(uG) {
+	if !checkPointOnCurve(curve, uG) {
 		return false
 	}
 
 	// s*G
-	sGx, sGy := s256.S256().ScalarBaseMult(zkUProof.S.Bytes())
+	sGx, sGy := curve.ScalarBaseMult(zkUProof.S.Bytes())
 
 	// -e*U
 	minusE := new(big.Int).Mul(big.NewInt(-1), zkUProof.E)
-	minusE = new(big.Int).Mod(minusE, s256.S256().N)
-	eUx, eUy := s256.S256().ScalarMult(uG[0], uG[1], minusE.Bytes())
+	minusE = new(big.Int).Mod(minusE, curve.Params().N)
+	eUx, eUy := curve.ScalarMult(uG[0], uG[1], minusE.Bytes())
 
 	// R = s*G - eU
-	rGx, rGy := s256.S256().Add(sGx, sGy, eUx, eUy)
+	rGx, rGy := curve.Add(sGx, sGy, eUx, eUy)
 
-	// HASH(R||U)
-	e := Sha512_256(rGx,rGy,uG[0],uG[1])
+	// HASH(ctx||R||U)
+	e := Sha512_256(ctx.Int(),rGx,rGy,uG[0],uG[1])
 
-	// check HASH(R||U) == e ??
+	// check HASH(ctx||R||U) == e ??
 	if e.Cmp(zkUProof.E) == 0 {
 		return true
 	}
@@ -128,54 +127,54 @@ type ZkXiProof struct {
 	S *big.Int
 }
 
-// ZkXiProve create ZkXiProof
-func ZkXiProve(sku1 *big.Int) *ZkXiProof {
+// ZkXiProve create ZkXiProof,the challenge is bound to ctx
+func ZkXiProve(curve elliptic.Curve, ctx *ProofContext, sku1 *big.Int) *ZkXiProof {
     	// R = r*G
-	r := random.GetRandomIntFromZn(s256.S256().N)
-	rGx, rGy := s256.S256().ScalarBaseMult(r.Bytes())
+	r := random.GetRandomIntFromZn(curve.Params().N)
+	rGx, rGy := curve.ScalarBaseMult(r.Bytes())
 
 	// X = x*G
-	xGx, xGy := s256.S256().ScalarBaseMult(sku1.Bytes())
+	xGx, xGy := curve.ScalarBaseMult(sku1.Bytes())
 
-	// e = HASH(R||X)
-	e := Sha512_256(rGx,rGy,xGx,xGy)
+	// e = HASH(ctx||R||X)
+	e := Sha512_256(ctx.Int(),rGx,rGy,xGx,xGy)
 
 	// s = r + e*x
 	s := new(big.Int).Mul(e, sku1)
 	s = new(big.Int).Add(r, s)
-	s = new(big.Int).Mod(s, s256.S256().N)
+	s = new(big.Int).Mod(s, curve.Params().N)
 
 	// send (X,e,s) to verifier
 	zkxiProof := &ZkXiProof{E: e, S: s}
 	return zkxiProof
 }
 
-// ZkXiVerify verify ZkXiProof
-func ZkXiVerify(xiG []*big.Int, zkXiProof *ZkXiProof) bool {
+// ZkXiVerify verify ZkXiProof made in the context ctx
+func ZkXiVerify(curve elliptic.Curve, ctx *ProofContext, xiG []*big.Int, zkXiProof *ZkXiProof) bool {
 	if xiG == nil || len(xiG) == 0 || zkXiProof == nil || zkXiProof.E == nil || zkXiProof.S == nil {
 	    return false
 	}
 
 	// Check whether the point is on the curve
-	if !checkPointOnCurve(xiG) {
+	if !checkPointOnCurve(curve, xiG) {
 		return false
 	}
 
 	// s*G
-	sGx, sGy := s256.S256().ScalarBaseMult(zkXiProof.S.Bytes())
+	sGx, sGy := curve.ScalarBaseMult(zkXiProof.S.Bytes())
 
 	// -e*X
 	minusE := new(big.Int).Mul(big.NewInt(-1), zkXiProof.E)
-	minusE = new(big.Int).Mod(minusE, s256.S256().N)
-	eUx, eUy := s256.S256().ScalarMult(xiG[0],xiG[1], minusE.Bytes())
+	minusE = new(big.Int).Mod(minusE, curve.Params().N)
+	eUx, eUy := curve.ScalarMult(xiG[0],xiG[1], minusE.Bytes())
 
 	// R = s*G - e*X
-	rGx, rGy := s256.S256().Add(sGx, sGy, eUx, eUy)
+	rGx, rGy := curve.Add(sGx, sGy, eUx, eUy)
 
-	// HASH(R||X)
-	e := Sha512_256(rGx,rGy,xiG[0],xiG[1])
+	// HASH(ctx||R||X)
+	e := Sha512_256(ctx.Int(),rGx,rGy,xiG[0],xiG[1])
 
-	// check HASH(R||X) == e ??
+	// check HASH(ctx||R||X) == e ??
 	if e.Cmp(zkXiProof.E) == 0 {
 		return true
 	}
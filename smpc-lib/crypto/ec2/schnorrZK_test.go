@@ -30,14 +30,14 @@ import (
 
 func TestZkUProveVerify(t *testing.T) {
 	u1 := random.GetRandomIntFromZn(secp256k1.S256().N)
-	u1zkUProof := ec2.ZkUProve(u1)
+	u1zkUProof := ec2.ZkUProve(secp256k1.S256(), nil, u1)
 	assert.NotZero(t, u1zkUProof)
 	u1Gx, u1Gy := secp256k1.S256().ScalarBaseMult(u1.Bytes())
 	u1Secrets := make([]*big.Int, 0)
 	u1Secrets = append(u1Secrets, u1Gx)
 	u1Secrets = append(u1Secrets, u1Gy)
 
-	_, u1PolyG, err := ec2.Vss2Init(u1, 3)
+	_, u1PolyG, err := ec2.Vss2Init(secp256k1.S256(), u1, 3)
 	assert.NoError(t, err)
 
 	for i := 1; i < len(u1PolyG.PolyG); i++ {
@@ -45,22 +45,22 @@ func TestZkUProveVerify(t *testing.T) {
 		u1Secrets = append(u1Secrets, u1PolyG.PolyG[i][1])
 	}
 	commitU1G := new(ec2.Commitment).Commit(u1Secrets...)
-	ret, u1G := commitU1G.DeCommit()
+	ret, u1G := commitU1G.DeCommit(secp256k1.S256())
 	assert.True(t, ret)
-	ret = ec2.ZkUVerify(u1G, u1zkUProof)
+	ret = ec2.ZkUVerify(secp256k1.S256(), nil, u1G, u1zkUProof)
 	assert.True(t, ret)
 }
 
 func TestZkXiProveVerify(t *testing.T) {
 	sk := random.GetRandomIntFromZn(secp256k1.S256().N)
-	u1zkXiProof := ec2.ZkXiProve(sk)
+	u1zkXiProof := ec2.ZkXiProve(secp256k1.S256(), nil, sk)
 	assert.NotZero(t, u1zkXiProof)
 	xGx, xGy := secp256k1.S256().ScalarBaseMult(sk.Bytes())
 	u1Secrets := make([]*big.Int, 0)
 	u1Secrets = append(u1Secrets, xGx)
 	u1Secrets = append(u1Secrets, xGy)
 
-	_, u1PolyG, err := ec2.Vss2Init(sk, 3)
+	_, u1PolyG, err := ec2.Vss2Init(secp256k1.S256(), sk, 3)
 	assert.NoError(t, err)
 
 	for i := 1; i < len(u1PolyG.PolyG); i++ {
@@ -68,9 +68,48 @@ func TestZkXiProveVerify(t *testing.T) {
 		u1Secrets = append(u1Secrets, u1PolyG.PolyG[i][1])
 	}
 	commitXiG := new(ec2.Commitment).Commit(u1Secrets...)
-	ret, xiG := commitXiG.DeCommit()
+	ret, xiG := commitXiG.DeCommit(secp256k1.S256())
 	assert.True(t, ret)
-	ret = ec2.ZkXiVerify(xiG, u1zkXiProof)
+	ret = ec2.ZkXiVerify(secp256k1.S256(), nil, xiG, u1zkXiProof)
 	assert.True(t, ret)
 }
 
+
+func TestZkUReplay(t *testing.T) {
+	curve := secp256k1.S256()
+	u := random.GetRandomIntFromZn(curve.N)
+	uGx, uGy := curve.ScalarBaseMult(u.Bytes())
+	uG := []*big.Int{uGx, uGy}
+
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 1)
+	proof := ec2.ZkUProve(curve, ctx, u)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.ZkUVerify(curve, ctx, uG, proof))
+
+	// the proof can not be replayed in another session,by another party or in another round
+	assert.False(t, ec2.ZkUVerify(curve, ec2.NewProofContext("session2", big.NewInt(1), 1), uG, proof))
+	assert.False(t, ec2.ZkUVerify(curve, ec2.NewProofContext("session", big.NewInt(2), 1), uG, proof))
+	assert.False(t, ec2.ZkUVerify(curve, ec2.NewProofContext("session", big.NewInt(1), 2), uG, proof))
+	assert.False(t, ec2.ZkUVerify(curve, nil, uG, proof))
+}
+
+func TestZkXiReplay(t *testing.T) {
+	curve := secp256k1.S256()
+	sk := random.GetRandomIntFromZn(curve.N)
+	xGx, xGy := curve.ScalarBaseMult(sk.Bytes())
+	xiG := []*big.Int{xGx, xGy}
+
+	ctx := ec2.NewProofContext("session", big.NewInt(1), 5)
+	proof := ec2.ZkXiProve(curve, ctx, sk)
+	if !assert.NotNil(t, proof) {
+		return
+	}
+	assert.True(t, ec2.ZkXiVerify(curve, ctx, xiG, proof))
+
+	assert.False(t, ec2.ZkXiVerify(curve, ec2.NewProofContext("session2", big.NewInt(1), 5), xiG, proof))
+	assert.False(t, ec2.ZkXiVerify(curve, ec2.NewProofContext("session", big.NewInt(2), 5), xiG, proof))
+	assert.False(t, ec2.ZkXiVerify(curve, ec2.NewProofContext("session", big.NewInt(1), 6), xiG, proof))
+	assert.False(t, ec2.ZkXiVerify(curve, nil, xiG, proof))
+}
@@ -30,8 +30,30 @@ const (
 
 var (
     alpha = 65537
+
+    smallPrimes []*big.Int
+    smallPrimesOnce sync.Once
 )
 
+// getSmallPrimes get the primes less than alpha,they are found by the sieve once
+func getSmallPrimes() []*big.Int {
+    smallPrimesOnce.Do(func() {
+	composite := make([]bool,alpha)
+	for i:=2;i < alpha;i++ {
+	    if composite[i] {
+		continue
+	    }
+
+	    smallPrimes = append(smallPrimes,big.NewInt(int64(i)))
+	    for j:=i*i;j < alpha;j += i {
+		composite[j] = true
+	    }
+	}
+    })
+
+    return smallPrimes
+}
+
 // SquareFreeProof 
 // add for GG20: keygen phase 3. Each player Pi proves in ZK that Ni is square-free using the proof of Gennaro, Micciancio, and Rabin [30]
 // An Efficient Non-Interactive Statistical Zero-Knowledge Proof System for Quasi-Safe Prime Products, section 3.1 
@@ -156,6 +178,13 @@ func SquareFreeVerify(n *big.Int,num *big.Int,proof *SquareFreeProof) bool {
 	if n == nil || proof == nil || proof.Sigma == nil || num == nil || num.Cmp(zero) < 0 {
 	    return false
 	}
+
+	vals := append([]*big.Int{n,num},proof.Sigma...)
+	return verifyOnce("SquareFreeProof",func() bool { return squareFreeVerify(n,num,proof) },vals...)
+}
+
+// squareFreeVerify check the square free proof,the args have been checked by SquareFreeVerify
+func squareFreeVerify(n *big.Int,num *big.Int,proof *SquareFreeProof) bool {
 	if len(proof.Sigma) != m {
 	    return false
 	}
@@ -170,13 +199,10 @@ func SquareFreeVerify(n *big.Int,num *big.Int,proof *SquareFreeProof) bool {
 	    return false
 	}
 
-	for i:=2;i< alpha;i++ {
-	    ii := big.NewInt(int64(i))
-	    if ii.ProbablyPrime(PrimeTestTimes) {
-		qua := new(big.Int).Mod(n,ii)
-		if qua.Cmp(zero) == 0 {
-		    return false
-		}
+	for _,ii := range getSmallPrimes() {
+	    qua := new(big.Int).Mod(n,ii)
+	    if qua.Cmp(zero) == 0 {
+		return false
 	    }
 	}
 
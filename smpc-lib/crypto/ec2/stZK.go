@@ -17,12 +17,11 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
 	"errors"
 	"math/big"
-
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
@@ -39,36 +38,37 @@ type STProof struct {
 
 //------------------------------------------------------------------------------------
 
-// NewSTProof new STProof
-func NewSTProof(T1X *big.Int,T1Y *big.Int,S1X *big.Int,S1Y *big.Int,Rx *big.Int,Ry *big.Int,hGx *big.Int,hGy *big.Int,sigma1 *big.Int,l1 *big.Int) *STProof {
+// NewSTProof new STProof,the challenge is bound to ctx
+func NewSTProof(curve elliptic.Curve, ctx *ProofContext, T1X *big.Int,T1Y *big.Int,S1X *big.Int,S1Y *big.Int,Rx *big.Int,Ry *big.Int,hGx *big.Int,hGy *big.Int,sigma1 *big.Int,l1 *big.Int) *STProof {
     if T1X == nil || T1Y == nil || S1X == nil || S1Y == nil || Rx == nil || Ry == nil || hGx == nil || hGy == nil || sigma1 == nil || l1 == nil {
 	return nil
     }
     
-    Gx,Gy := secp256k1.S256().ScalarBaseMult(one.Bytes())
-    a := random.GetRandomIntFromZn(secp256k1.S256().N)
-    b := random.GetRandomIntFromZn(secp256k1.S256().N)
-    alphax,alphay := secp256k1.S256().ScalarMult(Rx,Ry,a.Bytes())
-    aGx,aGy := secp256k1.S256().ScalarBaseMult(a.Bytes())
-    bHGx,bHGy := secp256k1.S256().ScalarMult(hGx,hGy,b.Bytes())
-    betaX,betaY := secp256k1.S256().Add(aGx,aGy,bHGx,bHGy)
+    Gx,Gy := curve.ScalarBaseMult(one.Bytes())
+    a := random.GetRandomIntFromZn(curve.Params().N)
+    b := random.GetRandomIntFromZn(curve.Params().N)
+    alphax,alphay := curve.ScalarMult(Rx,Ry,a.Bytes())
+    aGx,aGy := curve.ScalarBaseMult(a.Bytes())
+    bHGx,bHGy := curve.ScalarMult(hGx,hGy,b.Bytes())
+    betaX,betaY := curve.Add(aGx,aGy,bHGx,bHGy)
     
-    e := Sha512_256(T1X, T1Y, S1X,S1Y,Rx,Ry,hGx, hGy, Gx, Gy, alphax, alphay, betaX, betaY)
-    e = new(big.Int).Mod(e, secp256k1.S256().N)
+    e := Sha512_256(ctx.Int(), T1X, T1Y, S1X,S1Y,Rx,Ry,hGx, hGy, Gx, Gy, alphax, alphay, betaX, betaY)
+    e = new(big.Int).Mod(e, curve.Params().N)
 
-    t, u := calculateTAndU(secp256k1.S256().N, a, e, sigma1, b, l1)
+    t, u := calculateTAndU(curve.Params().N, a, e, sigma1, b, l1)
     
     return &STProof{AlphaX: alphax, AlphaY:alphay, BetaX: betaX, BetaY:betaY, T: t, U: u}
 }
 
-func STVerify(S1X *big.Int,S1Y *big.Int,T1X *big.Int,T1Y *big.Int,Rx *big.Int,Ry *big.Int,hGx *big.Int,hGy *big.Int,stpf *STProof) bool {
+// STVerify verify STProof made in the context ctx
+func STVerify(curve elliptic.Curve, ctx *ProofContext, S1X *big.Int,S1Y *big.Int,T1X *big.Int,T1Y *big.Int,Rx *big.Int,Ry *big.Int,hGx *big.Int,hGy *big.Int,stpf *STProof) bool {
     if S1X == nil || S1Y == nil || T1X == nil || T1Y == nil || Rx == nil || Ry == nil || hGx == nil || hGy == nil || stpf == nil {
 	return false
     }
     
     // Check whether the point is on the curve
     var tmp = []*big.Int{S1X,S1Y,T1X,T1Y,Rx,Ry,hGx,hGy,stpf.AlphaX,stpf.AlphaY,stpf.BetaX,stpf.BetaY}
-    if !checkPointOnCurve(tmp) {
+    if !checkPointOnCurve(curve, tmp) {
 	    return false
     }
 
@@ -76,28 +76,28 @@ func STVerify(S1X *big.Int,S1Y *big.Int,T1X *big.Int,T1Y *big.Int,Rx *big.Int,Ry
 	return false
     }
 
-    mt := new(big.Int).Mod(stpf.T,secp256k1.S256().N)
-    mu := new(big.Int).Mod(stpf.U,secp256k1.S256().N)
+    mt := new(big.Int).Mod(stpf.T,curve.Params().N)
+    mu := new(big.Int).Mod(stpf.U,curve.Params().N)
     if mt.Cmp(big.NewInt(0)) == 0 || mt.Cmp(big.NewInt(1)) == 0 || mu.Cmp(big.NewInt(0)) == 0 || mu.Cmp(big.NewInt(1)) == 0 {
 	return false
     }
 
-    Gx,Gy := secp256k1.S256().ScalarBaseMult(one.Bytes())
-    e := Sha512_256(T1X, T1Y, S1X,S1Y,Rx,Ry,hGx, hGy, Gx, Gy, stpf.AlphaX, stpf.AlphaY, stpf.BetaX, stpf.BetaY)
-    e = new(big.Int).Mod(e, secp256k1.S256().N)
+    Gx,Gy := curve.ScalarBaseMult(one.Bytes())
+    e := Sha512_256(ctx.Int(), T1X, T1Y, S1X,S1Y,Rx,Ry,hGx, hGy, Gx, Gy, stpf.AlphaX, stpf.AlphaY, stpf.BetaX, stpf.BetaY)
+    e = new(big.Int).Mod(e, curve.Params().N)
     
-    tRx,tRy := secp256k1.S256().ScalarMult(Rx,Ry,stpf.T.Bytes())
-    eSx,eSy := secp256k1.S256().ScalarMult(S1X,S1Y,e.Bytes())
-    aScx,aScy := secp256k1.S256().Add(stpf.AlphaX,stpf.AlphaY,eSx,eSy)
+    tRx,tRy := curve.ScalarMult(Rx,Ry,stpf.T.Bytes())
+    eSx,eSy := curve.ScalarMult(S1X,S1Y,e.Bytes())
+    aScx,aScy := curve.Add(stpf.AlphaX,stpf.AlphaY,eSx,eSy)
     if tRx.Cmp(aScx) != 0 || tRy.Cmp(aScy) != 0 {
 	return false
     }
     
-    tGx,tGy := secp256k1.S256().ScalarBaseMult(stpf.T.Bytes())
-    uHGx,uHGy := secp256k1.S256().ScalarMult(hGx,hGy,stpf.U.Bytes())
-    eT1x,eT1y := secp256k1.S256().ScalarMult(T1X,T1Y,e.Bytes())
-    tGuHx,tGuHy := secp256k1.S256().Add(tGx,tGy,uHGx,uHGy)
-    betaTx,betaTy := secp256k1.S256().Add(stpf.BetaX,stpf.BetaY,eT1x,eT1y)
+    tGx,tGy := curve.ScalarBaseMult(stpf.T.Bytes())
+    uHGx,uHGy := curve.ScalarMult(hGx,hGy,stpf.U.Bytes())
+    eT1x,eT1y := curve.ScalarMult(T1X,T1Y,e.Bytes())
+    tGuHx,tGuHy := curve.Add(tGx,tGy,uHGx,uHGy)
+    betaTx,betaTy := curve.Add(stpf.BetaX,stpf.BetaY,eT1x,eT1y)
     if betaTx.Cmp(tGuHx) != 0 || betaTy.Cmp(tGuHy) != 0 {
 	return false
     }
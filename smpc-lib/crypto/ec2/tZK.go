@@ -17,12 +17,12 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
 	"errors"
 	"math/big"
 	"crypto/sha256"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
@@ -40,9 +40,9 @@ type TProof struct {
 // CalcHPoint returns a shared point of unknown discrete logarithm for the curve
 // Mimics the KZen-networks/curv impl: https://git.io/JfwSa
 // Not so efficient due to 3x sha256 but it's only used once during a signing round.
-func CalcHPoint() (*big.Int,*big.Int,error) {
-    minRounds := 3 // minimum to generate a curve point for secp256k1
-    bz := secp256k1.S256().Marshal(secp256k1.S256().Gx,secp256k1.S256().Gy)
+func CalcHPoint(curve elliptic.Curve) (*big.Int,*big.Int,error) {
+    minRounds := 3 // minimum to generate a curve point
+    bz := elliptic.Marshal(curve, curve.Params().Gx,curve.Params().Gy)
 
     var hx *big.Int
     var hy *big.Int
@@ -53,21 +53,34 @@ func CalcHPoint() (*big.Int,*big.Int,error) {
 	    sum := sha256.Sum256(bz)
 	    bz = sum[:]
 	    if i >= minRounds-1 {
-		    hx,hy, _ = decompressPoint(new(big.Int).SetBytes(bz), 0x2)
+		    hx,hy, _ = decompressPoint(curve, new(big.Int).SetBytes(bz), 0x2)
 	    }
     }
 
     return hx,hy,nil
 }
 
-func decompressPoint(x *big.Int, sign byte) (*big.Int,*big.Int, error) {
-	params := secp256k1.S256().Params()
+func decompressPoint(curve elliptic.Curve, x *big.Int, sign byte) (*big.Int,*big.Int, error) {
+	params := curve.Params()
 
-	// secp256k1: y^2 = x^3 + 7
 	x3 := new(big.Int).Mul(x, x)
 	x3.Mul(x3, x)
 
-	y2 := x3.Add(x3, big.NewInt(7))
+	var y2 *big.Int
+	if IsSecp256k1(curve) {
+	    // secp256k1: y^2 = x^3 + 7
+	    y2 = x3.Add(x3, big.NewInt(7))
+	} else {
+	    // secp256r1: y^2 = x^3 - 3x + b
+	    if x.Cmp(params.P) >= 0 {
+		return nil,nil,errors.New("invalid point")
+	    }
+
+	    threeX := new(big.Int).Mul(x, big.NewInt(3))
+	    y2 = x3.Sub(x3, threeX)
+	    y2.Add(y2, params.B)
+	    y2.Mod(y2, params.P)
+	}
 
 	// find the sq root mod P
 	y := new(big.Int).ModSqrt(y2,params.P)
@@ -86,31 +99,31 @@ func decompressPoint(x *big.Int, sign byte) (*big.Int,*big.Int, error) {
 //---------------------------------------------------------------------------------
 
 // TProve add for gg20: calculate T_i = g^sigma_i * h^l_i = sigma_i*G + l_i*h*G
-func TProve(t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, sigma1 *big.Int,l1 *big.Int) *TProof {
+func TProve(curve elliptic.Curve, ctx *ProofContext, t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, sigma1 *big.Int,l1 *big.Int) *TProof {
 	if t1X == nil || t1Y == nil || hx == nil || hy == nil || sigma1 == nil || l1 == nil {
 	    return nil
 	}
 
-	a := random.GetRandomIntFromZn(secp256k1.S256().N)
-	b := random.GetRandomIntFromZn(secp256k1.S256().N)
+	a := random.GetRandomIntFromZn(curve.Params().N)
+	b := random.GetRandomIntFromZn(curve.Params().N)
 
-	aGx,aGy := secp256k1.S256().ScalarBaseMult(a.Bytes())
-	bHx,bHy := secp256k1.S256().ScalarMult(hx,hy,b.Bytes())
-	alphaX,alphaY := secp256k1.S256().Add(aGx,aGy,bHx,bHy)
+	aGx,aGy := curve.ScalarBaseMult(a.Bytes())
+	bHx,bHy := curve.ScalarMult(hx,hy,b.Bytes())
+	alphaX,alphaY := curve.Add(aGx,aGy,bHx,bHy)
 
-	Gx,Gy := secp256k1.S256().ScalarBaseMult(one.Bytes())
-	e := Sha512_256(t1X,t1Y,hx,hy,Gx,Gy,alphaX,alphaY)
-	e = new(big.Int).Mod(e, secp256k1.S256().N)
+	Gx,Gy := curve.ScalarBaseMult(one.Bytes())
+	e := Sha512_256(ctx.Int(),t1X,t1Y,hx,hy,Gx,Gy,alphaX,alphaY)
+	e = new(big.Int).Mod(e, curve.Params().N)
 
 	t := new(big.Int).Add(a, new(big.Int).Mul(e, sigma1))
-	t = new(big.Int).Mod(t, secp256k1.S256().N)
+	t = new(big.Int).Mod(t, curve.Params().N)
 	u := new(big.Int).Add(b, new(big.Int).Mul(e, l1))
-	u = new(big.Int).Mod(u, secp256k1.S256().N)
+	u = new(big.Int).Mod(u, curve.Params().N)
 	return &TProof{AlphaX: alphaX,AlphaY: alphaY,T: t,U: u}
 }
 
 // TVerify add for gg20: calculate T_i = g^sigma_i * h^l_i = sigma_i*G + l_i*h*G
-func TVerify(t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, proof *TProof) bool {
+func TVerify(curve elliptic.Curve, ctx *ProofContext, t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, proof *TProof) bool {
 
 	if t1X == nil || t1Y == nil || hx == nil || hy == nil || proof == nil {
 	    return false 
@@ -118,7 +131,7 @@ func TVerify(t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, proof *TProo
 
     // Check whether the point is on the curve
     var tmp = []*big.Int{proof.AlphaX,proof.AlphaY,t1X,t1Y,hx,hy}
-    if !checkPointOnCurve(tmp) {
+    if !checkPointOnCurve(curve, tmp) {
 	    return false
     }
 
@@ -126,22 +139,22 @@ func TVerify(t1X *big.Int, t1Y *big.Int,  hx *big.Int, hy *big.Int, proof *TProo
 	return false
     }
 
-    mt := new(big.Int).Mod(proof.T,secp256k1.S256().N)
-    mu := new(big.Int).Mod(proof.U,secp256k1.S256().N)
+    mt := new(big.Int).Mod(proof.T,curve.Params().N)
+    mu := new(big.Int).Mod(proof.U,curve.Params().N)
     if mt.Cmp(big.NewInt(0)) == 0 || mt.Cmp(big.NewInt(1)) == 0 || mu.Cmp(big.NewInt(0)) == 0 || mu.Cmp(big.NewInt(1)) == 0 {
 	return false
     }
 
-	Gx,Gy := secp256k1.S256().ScalarBaseMult(one.Bytes())
-	e := Sha512_256(t1X,t1Y,hx,hy,Gx,Gy,proof.AlphaX,proof.AlphaY)
-	e = new(big.Int).Mod(e, secp256k1.S256().N)
+	Gx,Gy := curve.ScalarBaseMult(one.Bytes())
+	e := Sha512_256(ctx.Int(),t1X,t1Y,hx,hy,Gx,Gy,proof.AlphaX,proof.AlphaY)
+	e = new(big.Int).Mod(e, curve.Params().N)
 
-	tGx,tGy := secp256k1.S256().ScalarBaseMult(proof.T.Bytes())
-	uHx,uHy := secp256k1.S256().ScalarMult(hx,hy,proof.U.Bytes())
-	tGuX,tGuY := secp256k1.S256().Add(tGx,tGy,uHx,uHy)
+	tGx,tGy := curve.ScalarBaseMult(proof.T.Bytes())
+	uHx,uHy := curve.ScalarMult(hx,hy,proof.U.Bytes())
+	tGuX,tGuY := curve.Add(tGx,tGy,uHx,uHy)
 
-	et1X,et1Y := secp256k1.S256().ScalarMult(t1X,t1Y,e.Bytes())
-	ateX,ateY := secp256k1.S256().Add(proof.AlphaX,proof.AlphaY,et1X,et1Y)
+	et1X,et1Y := curve.ScalarMult(t1X,t1Y,e.Bytes())
+	ateX,ateY := curve.Add(proof.AlphaX,proof.AlphaY,et1X,et1Y)
 	
 	if tGuX.Cmp(ateX) != 0 || tGuY.Cmp(ateY) != 0 {
 		return false
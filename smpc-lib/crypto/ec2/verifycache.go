@@ -0,0 +1,115 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"sync"
+)
+
+// VerifyCacheSize the max number of the proofs remembered by the verify cache,it is cleared when it is full
+const VerifyCacheSize = 1024
+
+var (
+	verifyCacheOn   bool
+	verifyCache     = make(map[[32]byte]*verifyResult)
+	verifyCacheLock sync.Mutex
+)
+
+// verifyResult the result of one proof,done is closed after ok is set
+type verifyResult struct {
+	done chan struct{}
+	ok   bool
+}
+
+// EnableVerifyCache remember the ntilde,square free and hv proofs that pass the verification,so that the same proof is checked only once.
+// These proofs only depend on the modulus of the prover and the num chosen by it,so checking them again gives the same result.
+// It is off by default and meant for the simulation,where the parties take fixed pre params and run in one process.
+func EnableVerifyCache(on bool) {
+	verifyCacheLock.Lock()
+	defer verifyCacheLock.Unlock()
+
+	if verifyCacheOn != on {
+		verifyCacheOn = on
+		verifyCache = make(map[[32]byte]*verifyResult)
+	}
+}
+
+// verifyOnce call verify if the proof of kind with the data vals is not in the verify cache,and put it to the cache if it passes.
+// The callers checking the same proof at the same time wait for the first one.
+func verifyOnce(kind string, verify func() bool, vals ...*big.Int) bool {
+	verifyCacheLock.Lock()
+	if !verifyCacheOn {
+		verifyCacheLock.Unlock()
+		return verify()
+	}
+
+	key := verifyCacheKey(kind, vals...)
+	if r, ok := verifyCache[key]; ok {
+		verifyCacheLock.Unlock()
+		<-r.done
+		return r.ok
+	}
+
+	if len(verifyCache) >= VerifyCacheSize {
+		verifyCache = make(map[[32]byte]*verifyResult)
+	}
+
+	r := &verifyResult{done: make(chan struct{})}
+	verifyCache[key] = r
+	verifyCacheLock.Unlock()
+
+	r.ok = verify()
+	close(r.done)
+
+	// only the passed proofs are kept
+	if !r.ok {
+		verifyCacheLock.Lock()
+		if verifyCache[key] == r {
+			delete(verifyCache, key)
+		}
+		verifyCacheLock.Unlock()
+	}
+
+	return r.ok
+}
+
+// verifyCacheKey hash the kind and every value with its sign and length,nil is different from 0
+func verifyCacheKey(kind string, vals ...*big.Int) [32]byte {
+	h := sha256.New()
+	h.Write([]byte(kind))
+
+	var l [5]byte
+	for _, v := range vals {
+		if v == nil {
+			h.Write([]byte{0})
+			continue
+		}
+
+		b := v.Bytes()
+		l[0] = byte(v.Sign() + 2)
+		binary.BigEndian.PutUint32(l[1:], uint32(len(b)))
+		h.Write(l[:])
+		h.Write(b)
+	}
+
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
@@ -0,0 +1,61 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ec2_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCache(t *testing.T) {
+	pre := getPreParams(t)
+	ntilde := pre.NtildeH1H2.Ntilde
+	num, hv := pre.NtildeHvProof()
+	if !assert.NotNil(t, hv) {
+		return
+	}
+
+	ec2.EnableVerifyCache(true)
+	defer ec2.EnableVerifyCache(false)
+
+	for i := 0; i < 2; i++ {
+		assert.True(t, ec2.HvVerify(ntilde, num, hv), "hv proof")
+		assert.True(t, pre.NtildeProof1.Verify(pre.NtildeH1H2.H1, pre.NtildeH1H2.H2, ntilde), "ntilde proof")
+	}
+
+	// the proof that is not the cached one is checked
+	bad := &ec2.HvProof{Sigma: append([]*big.Int{}, hv.Sigma...)}
+	for k, v := range bad.Sigma {
+		if v.Sign() != 0 {
+			bad.Sigma[k] = new(big.Int).Add(v, big.NewInt(1))
+			break
+		}
+	}
+	for i := 0; i < 2; i++ {
+		assert.False(t, ec2.HvVerify(ntilde, num, bad), "tampered hv proof")
+	}
+	assert.False(t, ec2.HvVerify(ntilde, new(big.Int).Add(num, big.NewInt(1)), hv), "other num")
+	assert.False(t, pre.NtildeProof1.Verify(pre.NtildeH1H2.H2, pre.NtildeH1H2.H1, ntilde), "swapped h1 h2")
+
+	num, sf := pre.SquareFreeProof()
+	n := pre.PaillierSk.N
+	assert.True(t, ec2.SquareFreeVerify(n, num, sf), "square free proof")
+	assert.False(t, ec2.SquareFreeVerify(ntilde, num, sf), "square free proof of other modulus")
+}
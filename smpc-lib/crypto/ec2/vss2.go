@@ -17,10 +17,9 @@
 package ec2
 
 import (
+	"crypto/elliptic"
 	"math/big"
 	"errors"
-
-	s256 "github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 )
 
@@ -50,7 +49,7 @@ func GetSharesID(ss *ShareStruct2) *big.Int {
 }
 
 // Vss2Init  Initialize Lagrange polynomial coefficients 
-func Vss2Init(secret *big.Int, t int) (*PolyStruct2, *PolyGStruct2, error) {
+func Vss2Init(curve elliptic.Curve, secret *big.Int, t int) (*PolyStruct2, *PolyGStruct2, error) {
     	if secret == nil || t <= 1 {
 	    return nil,nil,errors.New("param error")
 	}
@@ -59,14 +58,39 @@ func Vss2Init(secret *big.Int, t int) (*PolyStruct2, *PolyGStruct2, error) {
 	polyG := make([][]*big.Int, 0)
 
 	poly = append(poly, secret)
-	pointX, pointY := s256.S256().ScalarBaseMult(secret.Bytes())
+	pointX, pointY := curve.ScalarBaseMult(secret.Bytes())
 	polyG = append(polyG, []*big.Int{pointX, pointY})
 
 	for i := 0; i < t-1; i++ {
-		rndInt := random.GetRandomIntFromZn(s256.S256().N)
+		rndInt := random.GetRandomIntFromZn(curve.Params().N)
 		poly = append(poly, rndInt)
 
-		pointX, pointY := s256.S256().ScalarBaseMult(rndInt.Bytes())
+		pointX, pointY := curve.ScalarBaseMult(rndInt.Bytes())
+		polyG = append(polyG, []*big.Int{pointX, pointY})
+	}
+	polyStruct := &PolyStruct2{Poly: poly}
+	polyGStruct := &PolyGStruct2{PolyG: polyG}
+
+	return polyStruct, polyGStruct, nil
+}
+
+// Vss2InitZero  Initialize Lagrange polynomial coefficients with zero secret,it is used to refresh the shares.
+// polyG does not include the commitment of the constant term,polyG.PolyG[i] is the commitment of poly.Poly[i+1]
+func Vss2InitZero(curve elliptic.Curve, t int) (*PolyStruct2, *PolyGStruct2, error) {
+	if t <= 1 {
+	    return nil,nil,errors.New("param error")
+	}
+
+	poly := make([]*big.Int, 0)
+	polyG := make([][]*big.Int, 0)
+
+	poly = append(poly, big.NewInt(0))
+
+	for i := 0; i < t-1; i++ {
+		rndInt := random.GetRandomIntFromZn(curve.Params().N)
+		poly = append(poly, rndInt)
+
+		pointX, pointY := curve.ScalarBaseMult(rndInt.Bytes())
 		polyG = append(polyG, []*big.Int{pointX, pointY})
 	}
 	polyStruct := &PolyStruct2{Poly: poly}
@@ -76,7 +100,7 @@ func Vss2Init(secret *big.Int, t int) (*PolyStruct2, *PolyGStruct2, error) {
 }
 
 // Vss2  Calculate Lagrange polynomial value 
-func (polyStruct *PolyStruct2) Vss2(ids []*big.Int) ([]*ShareStruct2, error) {
+func (polyStruct *PolyStruct2) Vss2(curve elliptic.Curve, ids []*big.Int) ([]*ShareStruct2, error) {
 	if ids == nil || len(ids) == 0 {
 	    return nil,errors.New("param error")
 	}
@@ -89,7 +113,7 @@ func (polyStruct *PolyStruct2) Vss2(ids []*big.Int) ([]*ShareStruct2, error) {
 	shares := make([]*ShareStruct2, 0)
 
 	for i := 0; i < len(ids); i++ {
-		shareVal,err := calculatePolynomial2(polyStruct.Poly, ids[i])
+		shareVal,err := calculatePolynomial2(curve, polyStruct.Poly, ids[i])
 		if err != nil {
 		    return nil,err
 		}
@@ -102,21 +126,21 @@ func (polyStruct *PolyStruct2) Vss2(ids []*big.Int) ([]*ShareStruct2, error) {
 }
 
 // Verify2 Verify Lagrange polynomial value
-func (share *ShareStruct2) Verify2(polyG *PolyGStruct2) bool {
+func (share *ShareStruct2) Verify2(curve elliptic.Curve, polyG *PolyGStruct2) bool {
 
 	idVal := share.ID
 
 	computePointX, computePointY := polyG.PolyG[0][0], polyG.PolyG[0][1]
 
 	for i := 1; i < len(polyG.PolyG); i++ {
-		pointX, pointY := s256.S256().ScalarMult(polyG.PolyG[i][0], polyG.PolyG[i][1], idVal.Bytes())
+		pointX, pointY := curve.ScalarMult(polyG.PolyG[i][0], polyG.PolyG[i][1], idVal.Bytes())
 
-		computePointX, computePointY = s256.S256().Add(computePointX, computePointY, pointX, pointY)
+		computePointX, computePointY = curve.Add(computePointX, computePointY, pointX, pointY)
 		idVal = new(big.Int).Mul(idVal, share.ID)
-		idVal = new(big.Int).Mod(idVal, s256.S256().N)
+		idVal = new(big.Int).Mod(idVal, curve.Params().N)
 	}
 
-	originalPointX, originalPointY := s256.S256().ScalarBaseMult(share.Share.Bytes())
+	originalPointX, originalPointY := curve.ScalarBaseMult(share.Share.Bytes())
 
 	if computePointX.Cmp(originalPointX) == 0 && computePointY.Cmp(originalPointY) == 0 {
 		return true
@@ -125,8 +149,130 @@ func (share *ShareStruct2) Verify2(polyG *PolyGStruct2) bool {
 	return false
 }
 
+// VerifyZero2 Verify Lagrange polynomial value of the zero secret polynomial generated by Vss2InitZero
+func (share *ShareStruct2) VerifyZero2(curve elliptic.Curve, polyG *PolyGStruct2) bool {
+	if share == nil || share.ID == nil || share.Share == nil || polyG == nil || len(polyG.PolyG) == 0 {
+	    return false
+	}
+
+	idVal := new(big.Int).Mod(share.ID, curve.Params().N)
+	if idVal.Sign() == 0 {
+	    return false
+	}
+
+	var computePointX, computePointY *big.Int
+
+	for i := 0; i < len(polyG.PolyG); i++ {
+		if len(polyG.PolyG[i]) != 2 || !curve.IsOnCurve(polyG.PolyG[i][0], polyG.PolyG[i][1]) {
+		    return false
+		}
+
+		pointX, pointY := curve.ScalarMult(polyG.PolyG[i][0], polyG.PolyG[i][1], idVal.Bytes())
+		if i == 0 {
+			computePointX, computePointY = pointX, pointY
+		} else {
+			computePointX, computePointY = curve.Add(computePointX, computePointY, pointX, pointY)
+		}
+
+		idVal = new(big.Int).Mul(idVal, share.ID)
+		idVal = new(big.Int).Mod(idVal, curve.Params().N)
+	}
+
+	originalPointX, originalPointY := curve.ScalarBaseMult(share.Share.Bytes())
+
+	if computePointX.Cmp(originalPointX) == 0 && computePointY.Cmp(originalPointY) == 0 {
+		return true
+	}
+
+	return false
+}
+
+// PubShare get the public share of id,that is the share of id multiplied by G: sum(PolyG[i] * id^i)
+func (polyG *PolyGStruct2) PubShare(curve elliptic.Curve, id *big.Int) (*big.Int, *big.Int) {
+	return polyG.pubShare(curve, id, big.NewInt(1))
+}
+
+// PubShareZero get the public share of id of the zero secret polynomial generated by Vss2InitZero: sum(PolyG[i] * id^(i+1))
+func (polyG *PolyGStruct2) PubShareZero(curve elliptic.Curve, id *big.Int) (*big.Int, *big.Int) {
+	return polyG.pubShare(curve, id, id)
+}
+
+// pubShare sum(PolyG[i] * id^i * x0),nil if polyG is invalid
+func (polyG *PolyGStruct2) pubShare(curve elliptic.Curve, id *big.Int, x0 *big.Int) (*big.Int, *big.Int) {
+	if polyG == nil || len(polyG.PolyG) == 0 || id == nil {
+		return nil, nil
+	}
+
+	var x, y *big.Int
+	idVal := new(big.Int).Mod(x0, curve.Params().N)
+	for i, v := range polyG.PolyG {
+		if len(v) != 2 || v[0] == nil || v[1] == nil || !curve.IsOnCurve(v[0], v[1]) {
+			return nil, nil
+		}
+
+		px, py := curve.ScalarMult(v[0], v[1], idVal.Bytes())
+		if i == 0 {
+			x, y = px, py
+		} else {
+			x, y = curve.Add(x, y, px, py)
+		}
+
+		idVal = new(big.Int).Mul(idVal, id)
+		idVal = new(big.Int).Mod(idVal, curve.Params().N)
+	}
+
+	return x, y
+}
+
+// VerifyPubShares check the public shares of ids lie on one polynomial of degree threshold-1 whose value at 0 is the pubkey (pkx,pky):
+// the public shares of ids[:threshold] interpolate to the pubkey and to the public shares of the other ids
+func VerifyPubShares(curve elliptic.Curve, ids []*big.Int, pubShares [][]*big.Int, threshold int, pkx *big.Int, pky *big.Int) bool {
+	if threshold < 1 || len(ids) < threshold || len(pubShares) != len(ids) || pkx == nil || pky == nil {
+		return false
+	}
+
+	for _, v := range pubShares {
+		if len(v) != 2 || v[0] == nil || v[1] == nil || !curve.IsOnCurve(v[0], v[1]) {
+			return false
+		}
+	}
+
+	// interpolate the public shares of ids[:threshold] at x
+	interpolate := func(x *big.Int) (*big.Int, *big.Int, error) {
+		var rx, ry *big.Int
+		for i := 0; i < threshold; i++ {
+			lambda, err := LagrangeCoefficient(curve, ids[:threshold], i, x)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			px, py := curve.ScalarMult(pubShares[i][0], pubShares[i][1], lambda.Bytes())
+			if i == 0 {
+				rx, ry = px, py
+			} else {
+				rx, ry = curve.Add(rx, ry, px, py)
+			}
+		}
+		return rx, ry, nil
+	}
+
+	x, y, err := interpolate(big.NewInt(0))
+	if err != nil || x.Cmp(pkx) != 0 || y.Cmp(pky) != 0 {
+		return false
+	}
+
+	for j := threshold; j < len(ids); j++ {
+		x, y, err = interpolate(ids[j])
+		if err != nil || x.Cmp(pubShares[j][0]) != 0 || y.Cmp(pubShares[j][1]) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Combine2 Calculating Lagrange interpolation formula 
-func Combine2(shares []*ShareStruct2) (*big.Int, error) {
+func Combine2(curve elliptic.Curve, shares []*ShareStruct2) (*big.Int, error) {
     	if shares == nil || len(shares) == 0 {
 	    return nil,errors.New("param error")
 	}
@@ -147,31 +293,59 @@ func Combine2(shares []*ShareStruct2) (*big.Int, error) {
 		for j := 0; j < len(xSet); j++ {
 			if j != i {
 				sub := new(big.Int).Sub(xSet[j], share.ID)
-				subInverse := new(big.Int).ModInverse(sub, s256.S256().N)
+				subInverse := new(big.Int).ModInverse(sub, curve.Params().N)
 				if subInverse == nil {
 				    return nil,errors.New("calc times fail")
 				}
 				div := new(big.Int).Mul(xSet[j], subInverse)
 				times = new(big.Int).Mul(times, div)
-				times = new(big.Int).Mod(times, s256.S256().N)
+				times = new(big.Int).Mod(times, curve.Params().N)
 			}
 		}
 
 		// calculate sum(f(x) * times())
 		fTimes := new(big.Int).Mul(share.Share, times)
 		secret = new(big.Int).Add(secret, fTimes)
-		secret = new(big.Int).Mod(secret, s256.S256().N)
+		secret = new(big.Int).Mod(secret, curve.Params().N)
 	}
 
 	return secret, nil
 }
 
-func calculatePolynomial2(poly []*big.Int, id *big.Int) (*big.Int,error) {
+// LagrangeCoefficient calc the lagrange coefficient of ids[i] at x over the x coordinate set ids
+// lambda_i(x) = prod( (x - ids[j])/(ids[i] - ids[j]) ), j != i
+func LagrangeCoefficient(curve elliptic.Curve, ids []*big.Int, i int, x *big.Int) (*big.Int, error) {
+	if curve == nil || i < 0 || i >= len(ids) || x == nil {
+		return nil, errors.New("param error")
+	}
+
+	times := big.NewInt(1)
+	for j := 0; j < len(ids); j++ {
+		if j == i {
+			continue
+		}
+
+		sub := new(big.Int).Sub(ids[i], ids[j])
+		subInverse := new(big.Int).ModInverse(sub, curve.Params().N)
+		if subInverse == nil {
+			return nil, errors.New("calc times fail")
+		}
+
+		num := new(big.Int).Sub(x, ids[j])
+		times = new(big.Int).Mul(times, num)
+		times = new(big.Int).Mul(times, subInverse)
+		times = new(big.Int).Mod(times, curve.Params().N)
+	}
+
+	return times, nil
+}
+
+func calculatePolynomial2(curve elliptic.Curve, poly []*big.Int, id *big.Int) (*big.Int,error) {
     if poly == nil || id == nil {
 	return nil,errors.New("param error")
     }
 
-    idnum := new(big.Int).Mod(id,s256.S256().N)
+    idnum := new(big.Int).Mod(id,curve.Params().N)
     if idnum.Cmp(zero) == 0 || id.Cmp(zero) == 0 {
 	return nil,errors.New("id can not be equal to 0 or 0 modulo the order of the curve")
     }
@@ -182,7 +356,7 @@ func calculatePolynomial2(poly []*big.Int, id *big.Int) (*big.Int,error) {
 	for i := lastIndex - 1; i >= 0; i-- {
 		result = new(big.Int).Mul(result, id)
 		result = new(big.Int).Add(result, poly[i])
-		result = new(big.Int).Mod(result, s256.S256().N)
+		result = new(big.Int).Mod(result, curve.Params().N)
 	}
 
 	return result,nil
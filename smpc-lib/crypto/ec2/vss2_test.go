@@ -19,7 +19,6 @@ package ec2_test
 import (
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
-	"github.com/anyswap/FastMulThreshold-DSA/smpc"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/stretchr/testify/assert"
@@ -30,7 +29,7 @@ import (
 
 func TestVss2Init(t *testing.T) {
 	u1 := random.GetRandomIntFromZn(secp256k1.S256().N)
-	_, u1PolyG, _ := ec2.Vss2Init(u1, 3)
+	_, u1PolyG, _ := ec2.Vss2Init(secp256k1.S256(), u1, 3)
 	for i := 0; i < len(u1PolyG.PolyG); i++ {
 		assert.NotZero(t, u1PolyG.PolyG[i][0])
 		assert.NotZero(t, u1PolyG.PolyG[i][1])
@@ -41,7 +40,7 @@ func TestVss2Init(t *testing.T) {
 
 func TestVss2(t *testing.T) {
 	u1 := random.GetRandomIntFromZn(secp256k1.S256().N)
-	u1Poly, u1PolyG, _ := ec2.Vss2Init(u1, 3)
+	u1Poly, u1PolyG, _ := ec2.Vss2Init(secp256k1.S256(), u1, 3)
 	for i := 0; i < len(u1PolyG.PolyG); i++ {
 		assert.NotZero(t, u1PolyG.PolyG[i][0])
 		assert.NotZero(t, u1PolyG.PolyG[i][1])
@@ -56,17 +55,16 @@ func TestVss2(t *testing.T) {
 	//enode5,_ := new(big.Int).SetString("730c8fc7142d15669e8329138953d9484fd4cce0c690e35e105a9714deb741f10b52be1c5d49eeeb6f00aab8f3d2dec4e3352d0bf56bdbc2d86cb5f89c8e90d0",10)
 
 	var ids smpclib.SortableIDSSlice
-	enodes := []string{"524da89d8bd8f051e9b24660941e772f60e2e7a4ab0c48d1671ecfb9844e9cf1f0a9ef697be8118fe7bc9f2782a5a2bad912856bcb3a4dfda0aafcbdc9c282af", "8908863d56914eaa420afca83f43206f65ff56e42faeecb9e24f77740838819313f789bf7c4941b162e696147df409e47eb95ea351eac016ce8b7bf38fd269b2", "ee10b450a564e9cda30b37b9497a11ede5583e8964ba01ae85bbba7b421e403b1ab710f87d5b93c700ca459661b889412b9d781fbef8094ee282b46f4a90508b", "785708c037dcb3527075fe85797e5997c33202508daaa8ee45e99f73121047f3bb3dd2662ce5f0bb9b8e83cd2e56b3d99d2156433fef5185f66d2bb21d944e25", "730c8fc7142d15669e8329138953d9484fd4cce0c690e35e105a9714deb741f10b52be1c5d49eeeb6f00aab8f3d2dec4e3352d0bf56bdbc2d86cb5f89c8e90d0"}
 	for i := 0; i < 5; i++ {
-		uid := big.NewInt(i+1) 
+		uid := big.NewInt(int64(i + 1))
 		ids = append(ids, uid)
 	}
 	sort.Sort(ids)
 
-	shares, err := u1Poly.Vss2(ids)
+	shares, err := u1Poly.Vss2(secp256k1.S256(), ids)
 	assert.NoError(t, err)
 	for _, share := range shares {
-		ret := share.Verify2(u1PolyG)
+		ret := share.Verify2(secp256k1.S256(), u1PolyG)
 		assert.True(t, ret)
 	}
 }
@@ -104,7 +102,124 @@ func TestCombine2(t *testing.T) {
 	shareU5 := &ec2.ShareStruct2{ID: id5, Share: sku5}
 
 	shares := []*ec2.ShareStruct2{shareU1, shareU2, shareU3, shareU4, shareU5}
-	computeSK, _ := ec2.Combine2(shares[:3])
+	computeSK, _ := ec2.Combine2(secp256k1.S256(), shares[:3])
 
 	assert.Equal(t, 0, sk.Cmp(computeSK), "wrong sk ", computeSK, " is not ", sk)
 }
+
+func TestVss2InitZero(t *testing.T) {
+	poly, polyG, err := ec2.Vss2InitZero(secp256k1.S256(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, poly.Poly[0].Sign())
+	assert.Equal(t, 2, len(polyG.PolyG))
+
+	var ids smpclib.SortableIDSSlice
+	for i := 0; i < 5; i++ {
+		ids = append(ids, big.NewInt(int64(i+1)))
+	}
+
+	shares, err := poly.Vss2(secp256k1.S256(), ids)
+	assert.NoError(t, err)
+	for _, share := range shares {
+		assert.True(t, share.VerifyZero2(secp256k1.S256(), polyG))
+	}
+
+	zero, err := ec2.Combine2(secp256k1.S256(), shares[:3])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, zero.Sign())
+}
+
+
+func TestLagrangeCoefficient(t *testing.T) {
+	u1 := random.GetRandomIntFromZn(secp256k1.S256().N)
+	u1Poly, _, _ := ec2.Vss2Init(secp256k1.S256(), u1, 3)
+
+	var ids smpclib.SortableIDSSlice
+	for i := 0; i < 5; i++ {
+		ids = append(ids, big.NewInt(int64(i+1)))
+	}
+
+	shares, err := u1Poly.Vss2(secp256k1.S256(), ids)
+	assert.NoError(t, err)
+
+	// recreate the share of ids[4] by the shares of ids[0..2]
+	lost := big.NewInt(0)
+	for i := 0; i < 3; i++ {
+		lambda, err := ec2.LagrangeCoefficient(secp256k1.S256(), ids[:3], i, ids[4])
+		assert.NoError(t, err)
+		lost = new(big.Int).Add(lost, new(big.Int).Mul(lambda, shares[i].Share))
+	}
+	lost = new(big.Int).Mod(lost, secp256k1.S256().N)
+	assert.Equal(t, 0, lost.Cmp(shares[4].Share))
+
+	// x = 0 is the secret
+	secret := big.NewInt(0)
+	for i := 0; i < 3; i++ {
+		lambda, err := ec2.LagrangeCoefficient(secp256k1.S256(), ids[:3], i, big.NewInt(0))
+		assert.NoError(t, err)
+		secret = new(big.Int).Add(secret, new(big.Int).Mul(lambda, shares[i].Share))
+	}
+	secret = new(big.Int).Mod(secret, secp256k1.S256().N)
+	assert.Equal(t, 0, secret.Cmp(u1))
+}
+
+func TestPubShare(t *testing.T) {
+	curve := secp256k1.S256()
+	u1 := random.GetRandomIntFromZn(curve.N)
+	poly, polyG, err := ec2.Vss2Init(curve, u1, 3)
+	assert.NoError(t, err)
+	zpoly, zpolyG, err := ec2.Vss2InitZero(curve, 3)
+	assert.NoError(t, err)
+
+	var ids smpclib.SortableIDSSlice
+	for i := 0; i < 5; i++ {
+		ids = append(ids, big.NewInt(int64(i+1)))
+	}
+
+	shares, err := poly.Vss2(curve, ids)
+	assert.NoError(t, err)
+	zshares, err := zpoly.Vss2(curve, ids)
+	assert.NoError(t, err)
+
+	for k, id := range ids {
+		x, y := polyG.PubShare(curve, id)
+		sx, sy := curve.ScalarBaseMult(shares[k].Share.Bytes())
+		assert.True(t, x.Cmp(sx) == 0 && y.Cmp(sy) == 0, "public share of %v", id)
+
+		x, y = zpolyG.PubShareZero(curve, id)
+		sx, sy = curve.ScalarBaseMult(zshares[k].Share.Bytes())
+		assert.True(t, x.Cmp(sx) == 0 && y.Cmp(sy) == 0, "zero public share of %v", id)
+	}
+}
+
+func TestVerifyPubShares(t *testing.T) {
+	curve := secp256k1.S256()
+	u1 := random.GetRandomIntFromZn(curve.N)
+	_, polyG, err := ec2.Vss2Init(curve, u1, 3)
+	assert.NoError(t, err)
+	pkx, pky := curve.ScalarBaseMult(u1.Bytes())
+
+	var ids smpclib.SortableIDSSlice
+	pubShares := make([][]*big.Int, 0)
+	for i := 0; i < 5; i++ {
+		ids = append(ids, big.NewInt(int64(i+1)))
+		x, y := polyG.PubShare(curve, ids[i])
+		pubShares = append(pubShares, []*big.Int{x, y})
+	}
+
+	assert.True(t, ec2.VerifyPubShares(curve, ids, pubShares, 3, pkx, pky))
+
+	// another pubkey
+	ox, oy := curve.ScalarBaseMult(big.NewInt(7).Bytes())
+	assert.False(t, ec2.VerifyPubShares(curve, ids, pubShares, 3, ox, oy))
+
+	// a public share that is not on the polynomial
+	for k := range pubShares {
+		bad := append([][]*big.Int{}, pubShares...)
+		bad[k] = []*big.Int{ox, oy}
+		assert.False(t, ec2.VerifyPubShares(curve, ids, bad, 3, pkx, pky), "bad public share %v", k)
+	}
+
+	// the count of public shares must match the ids
+	assert.False(t, ec2.VerifyPubShares(curve, ids, pubShares[:4], 3, pkx, pky))
+}
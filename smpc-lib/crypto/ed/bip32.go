@@ -0,0 +1,107 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ed
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// non-hardened child key derivation of BIP32-Ed25519(Khovratovich and Law),only the public derivation is supported,
+// because the private key is shared by the group. the child private key is kL + 8*ZL,the tweak 8*ZL is added to every share.
+
+// Bip32HardenedIndex the first hardened index
+const Bip32HardenedIndex = uint32(0x80000000)
+
+// Bip32DeriveChild derive the child pubkey and chain code of index from pk and chain code c
+// tweak is the scalar that child private key = parent private key + tweak
+func Bip32DeriveChild(pk [32]byte, c [32]byte, index uint32) (childPk [32]byte, childC [32]byte, tweak [32]byte, err error) {
+	if index >= Bip32HardenedIndex {
+		err = errors.New("hardened index is not supported")
+		return
+	}
+
+	var A ExtendedGroupElement
+	if !A.FromBytes(&pk) {
+		err = errors.New("invalid pubkey")
+		return
+	}
+
+	var idx [4]byte
+	binary.LittleEndian.PutUint32(idx[:], index)
+
+	h := hmac.New(sha512.New, c[:])
+	h.Write([]byte{0x02})
+	h.Write(pk[:])
+	h.Write(idx[:])
+	z := h.Sum(nil)
+
+	// tweak = 8 * ZL,ZL is the first 28 bytes of Z
+	var carry uint16
+	for i := 0; i < 28; i++ {
+		v := uint16(z[i])<<3 | carry
+		tweak[i] = byte(v)
+		carry = v >> 8
+	}
+	tweak[28] = byte(carry)
+
+	var T, C ExtendedGroupElement
+	GeScalarMultBase(&T, &tweak)
+	GeAdd(&C, &A, &T)
+	C.ToBytes(&childPk)
+
+	h = hmac.New(sha512.New, c[:])
+	h.Write([]byte{0x03})
+	h.Write(pk[:])
+	h.Write(idx[:])
+	copy(childC[:], h.Sum(nil)[32:])
+	return
+}
+
+// Bip32DerivePath derive the child pubkey along the path "m/x1/x2/.../xn"
+// tweak is the sum of the tweaks of every level
+func Bip32DerivePath(pk [32]byte, c [32]byte, path string) (childPk [32]byte, tweak [32]byte, err error) {
+	indexs := strings.Split(path, "/")
+	if len(indexs) < 2 || indexs[0] != "m" {
+		err = errors.New("invalid derivation path")
+		return
+	}
+
+	childPk = pk
+	childC := c
+	for _, v := range indexs[1:] {
+		index, e := strconv.ParseUint(v, 10, 32)
+		if e != nil {
+			err = errors.New("invalid derivation path")
+			return
+		}
+
+		var t [32]byte
+		childPk, childC, t, err = Bip32DeriveChild(childPk, childC, uint32(index))
+		if err != nil {
+			return
+		}
+
+		ScAdd(&tweak, &tweak, &t)
+	}
+
+	return
+}
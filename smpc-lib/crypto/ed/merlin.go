@@ -0,0 +1,175 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ed
+
+import (
+	"encoding/binary"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/sha3"
+)
+
+// Merlin transcript (https://merlin.cool) over STROBE-128,it is used by schnorrkel(sr25519) to derive the challenges
+
+const (
+	strobeR = 166 // 200 - 128/4 - 2
+
+	flagI = 1
+	flagA = 1 << 1
+	flagC = 1 << 2
+	flagT = 1 << 3
+	flagM = 1 << 4
+	flagK = 1 << 5
+)
+
+// strobe128 the part of STROBE-128 that merlin needs
+type strobe128 struct {
+	state    [200]byte
+	pos      byte
+	posBegin byte
+	curFlags byte
+}
+
+func newStrobe128(protocol []byte) *strobe128 {
+	s := &strobe128{}
+	copy(s.state[:], []byte{1, strobeR + 2, 1, 0, 1, 96})
+	copy(s.state[6:], []byte("STROBEv1.0.2"))
+	s.keccak()
+	s.metaAd(protocol, false)
+	return s
+}
+
+func (s *strobe128) keccak() {
+	var a [25]uint64
+	for i := range a {
+		a[i] = binary.LittleEndian.Uint64(s.state[i*8:])
+	}
+
+	sha3.KeccakF1600(&a)
+	for i := range a {
+		binary.LittleEndian.PutUint64(s.state[i*8:], a[i])
+	}
+}
+
+func (s *strobe128) runF() {
+	s.state[s.pos] ^= s.posBegin
+	s.state[s.pos+1] ^= 0x04
+	s.state[strobeR+1] ^= 0x80
+	s.keccak()
+	s.pos = 0
+	s.posBegin = 0
+}
+
+func (s *strobe128) absorb(data []byte) {
+	for _, b := range data {
+		s.state[s.pos] ^= b
+		s.pos++
+		if s.pos == strobeR {
+			s.runF()
+		}
+	}
+}
+
+func (s *strobe128) squeeze(data []byte) {
+	for i := range data {
+		data[i] = s.state[s.pos]
+		s.state[s.pos] = 0
+		s.pos++
+		if s.pos == strobeR {
+			s.runF()
+		}
+	}
+}
+
+func (s *strobe128) beginOp(flags byte, more bool) {
+	if more {
+		// continue the operation,flags must be the same as s.curFlags
+		return
+	}
+
+	oldBegin := s.posBegin
+	s.posBegin = s.pos + 1
+	s.curFlags = flags
+	s.absorb([]byte{oldBegin, flags})
+
+	if flags&(flagC|flagK) != 0 && s.pos != 0 {
+		s.runF()
+	}
+}
+
+func (s *strobe128) metaAd(data []byte, more bool) {
+	s.beginOp(flagM|flagA, more)
+	s.absorb(data)
+}
+
+func (s *strobe128) ad(data []byte, more bool) {
+	s.beginOp(flagA, more)
+	s.absorb(data)
+}
+
+func (s *strobe128) prf(data []byte, more bool) {
+	s.beginOp(flagI|flagA|flagC, more)
+	s.squeeze(data)
+}
+
+func (s *strobe128) clone() *strobe128 {
+	c := *s
+	return &c
+}
+
+// Transcript merlin transcript
+type Transcript struct {
+	s *strobe128
+}
+
+// NewTranscript new a merlin transcript with the domain separator label
+func NewTranscript(label string) *Transcript {
+	t := &Transcript{s: newStrobe128([]byte("Merlin v1.0"))}
+	t.AppendMessage([]byte("dom-sep"), []byte(label))
+	return t
+}
+
+// Clone copy the transcript
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{s: t.s.clone()}
+}
+
+// AppendMessage append the message with label to the transcript
+func (t *Transcript) AppendMessage(label []byte, message []byte) {
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(message)))
+	t.s.metaAd(label, false)
+	t.s.metaAd(size[:], true)
+	t.s.ad(message, false)
+}
+
+// ChallengeBytes fill dest with the challenge bytes of label
+func (t *Transcript) ChallengeBytes(label []byte, dest []byte) {
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(dest)))
+	t.s.metaAd(label, false)
+	t.s.metaAd(size[:], true)
+	t.s.prf(dest, false)
+}
+
+// ChallengeScalar get the challenge scalar of label,64 bytes challenge reduced by the group order
+func (t *Transcript) ChallengeScalar(label []byte) [32]byte {
+	var wide [64]byte
+	var sc [32]byte
+	t.ChallengeBytes(label, wide[:])
+	ScReduce(&sc, &wide)
+	return sc
+}
@@ -0,0 +1,182 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ed
+
+import (
+	"bytes"
+	"math/big"
+)
+
+// ristretto255 is the prime order group built on the edwards25519 points,see https://ristretto.group
+// the points used here are ExtendedGroupElement,only the encoding and decoding are different from ed25519
+
+var (
+	// invSqrtAMinusD 1/sqrt(a-d)
+	invSqrtAMinusD = feFromDecimal("54469307008909316920995813868745141605393597292927456921205312896311721017578")
+	feOne          = feFromDecimal("1")
+)
+
+// feFromDecimal get the field element from decimal string
+func feFromDecimal(s string) FieldElement {
+	n, _ := new(big.Int).SetString(s, 10)
+	var b [32]byte
+	nb := n.Bytes()
+	for i := range nb {
+		b[i] = nb[len(nb)-1-i]
+	}
+
+	var fe FieldElement
+	FeFromBytes(&fe, &b)
+	return fe
+}
+
+// feEqual f == g
+func feEqual(f, g *FieldElement) bool {
+	var fb, gb [32]byte
+	FeToBytes(&fb, f)
+	FeToBytes(&gb, g)
+	return bytes.Equal(fb[:], gb[:])
+}
+
+// feAbs set h = |f|,the non negative one of f and -f
+func feAbs(h, f *FieldElement) {
+	var neg FieldElement
+	FeNeg(&neg, f)
+	FeCopy(h, f)
+	FeCMove(h, &neg, int32(FeIsNegative(f)))
+}
+
+// feSqrtRatioM1 set r = sqrt(u/v) if u/v is square,otherwise r = sqrt(i*u/v),r is non negative
+// return true if u/v is square
+func feSqrtRatioM1(r, u, v *FieldElement) bool {
+	var v3, v7, uv3, uv7, check, negU, negUI, rPrime FieldElement
+
+	FeSquare(&v3, v)
+	FeMul(&v3, &v3, v) // v^3
+	FeSquare(&v7, &v3)
+	FeMul(&v7, &v7, v) // v^7
+
+	FeMul(&uv3, u, &v3)
+	FeMul(&uv7, u, &v7)
+	fePow22523(r, &uv7)
+	FeMul(r, r, &uv3) // r = u*v^3*(u*v^7)^((p-5)/8)
+
+	FeSquare(&check, r)
+	FeMul(&check, &check, v) // check = v*r^2
+
+	FeNeg(&negU, u)
+	FeMul(&negUI, &negU, &SqrtM1)
+
+	correctSign := feEqual(&check, u)
+	flippedSign := feEqual(&check, &negU)
+	flippedSignI := feEqual(&check, &negUI)
+
+	FeMul(&rPrime, r, &SqrtM1)
+	if flippedSign || flippedSignI {
+		FeCopy(r, &rPrime)
+	}
+
+	feAbs(r, r)
+	return correctSign || flippedSign
+}
+
+// RistrettoEncode encode the point p to the 32 bytes ristretto255 encoding
+func RistrettoEncode(s *[32]byte, p *ExtendedGroupElement) {
+	var u1, u2, tmp, invSqrt, den1, den2, zInv, ix0, iy0, enDen FieldElement
+	var x, y, denInv, negY FieldElement
+
+	FeAdd(&u1, &p.Z, &p.Y)
+	FeSub(&tmp, &p.Z, &p.Y)
+	FeMul(&u1, &u1, &tmp)  // u1 = (Z+Y)*(Z-Y)
+	FeMul(&u2, &p.X, &p.Y) // u2 = X*Y
+
+	FeSquare(&tmp, &u2)
+	FeMul(&tmp, &tmp, &u1)
+	feSqrtRatioM1(&invSqrt, &feOne, &tmp) // invsqrt = 1/sqrt(u1*u2^2)
+
+	FeMul(&den1, &invSqrt, &u1)
+	FeMul(&den2, &invSqrt, &u2)
+	FeMul(&zInv, &den1, &den2)
+	FeMul(&zInv, &zInv, &p.T)
+
+	FeMul(&ix0, &p.X, &SqrtM1)
+	FeMul(&iy0, &p.Y, &SqrtM1)
+	FeMul(&enDen, &den1, &invSqrtAMinusD)
+
+	FeMul(&tmp, &p.T, &zInv)
+	rotate := int32(FeIsNegative(&tmp))
+
+	FeCopy(&x, &p.X)
+	FeCopy(&y, &p.Y)
+	FeCopy(&denInv, &den2)
+	FeCMove(&x, &iy0, rotate)
+	FeCMove(&y, &ix0, rotate)
+	FeCMove(&denInv, &enDen, rotate)
+
+	FeMul(&tmp, &x, &zInv)
+	FeNeg(&negY, &y)
+	FeCMove(&y, &negY, int32(FeIsNegative(&tmp)))
+
+	FeSub(&tmp, &p.Z, &y)
+	FeMul(&tmp, &denInv, &tmp)
+	feAbs(&tmp, &tmp)
+	FeToBytes(s, &tmp)
+}
+
+// RistrettoDecode decode the 32 bytes ristretto255 encoding to point p
+// return false if s is not a valid encoding
+func RistrettoDecode(p *ExtendedGroupElement, s *[32]byte) bool {
+	var fs, ss, u1, u2, u2Sqr, v, tmp, invSqrt, denX, denY FieldElement
+	var check [32]byte
+
+	// s must be canonical and non negative
+	FeFromBytes(&fs, s)
+	FeToBytes(&check, &fs)
+	if !bytes.Equal(check[:], s[:]) || FeIsNegative(&fs) == 1 {
+		return false
+	}
+
+	FeSquare(&ss, &fs)
+	FeSub(&u1, &feOne, &ss) // u1 = 1 - s^2
+	FeAdd(&u2, &feOne, &ss) // u2 = 1 + s^2
+	FeSquare(&u2Sqr, &u2)
+
+	FeSquare(&tmp, &u1)
+	FeMul(&tmp, &tmp, &d)
+	FeNeg(&tmp, &tmp)
+	FeSub(&v, &tmp, &u2Sqr) // v = -(d*u1^2) - u2^2
+
+	FeMul(&tmp, &v, &u2Sqr)
+	wasSquare := feSqrtRatioM1(&invSqrt, &feOne, &tmp)
+
+	FeMul(&denX, &invSqrt, &u2)
+	FeMul(&denY, &invSqrt, &denX)
+	FeMul(&denY, &denY, &v)
+
+	FeAdd(&tmp, &fs, &fs)
+	FeMul(&p.X, &tmp, &denX)
+	feAbs(&p.X, &p.X) // x = |2*s*den_x|
+	FeMul(&p.Y, &u1, &denY)
+	FeOne(&p.Z)
+	FeMul(&p.T, &p.X, &p.Y)
+
+	if !wasSquare || FeIsNegative(&p.T) == 1 || FeIsNonZero(&p.Y) == 0 {
+		return false
+	}
+
+	return true
+}
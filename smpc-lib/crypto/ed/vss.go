@@ -19,6 +19,7 @@ package ed
 import (
 	"bytes"
 	cryptorand "crypto/rand"
+	"crypto/sha512"
 	"fmt"
 	"io"
 	"errors"
@@ -198,6 +199,22 @@ func Combine(shares [][32]byte, ids [][32]byte) [32]byte {
 	return secret
 }
 
+// SecretFromSeed get the secret scalar of the ed25519 private key seed (RFC 8032 section 5.1.5),reduced modulo the order of the curve.
+// It is the secret shared by Vss when an existing ed25519 key is imported.
+func SecretFromSeed(seed [32]byte) [32]byte {
+	digest := sha512.Sum512(seed[:])
+	digest[0] &= 248
+	digest[31] &= 127
+	digest[31] |= 64
+
+	var tmp [64]byte
+	copy(tmp[:], digest[:32])
+
+	var secret [32]byte
+	ScReduce(&secret, &tmp)
+	return secret
+}
+
 func calculatePolynomial(cfs [][32]byte, id [32]byte) ([32]byte,error) {
     // the shares are generated by evaluating the polynomial with the other nodes’ID’s. It is critical that these node ID’s are non-zero, or zero modulo the order of the curve
     zero,_ := new(big.Int).SetString("0",10)
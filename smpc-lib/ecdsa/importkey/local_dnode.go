@@ -0,0 +1,264 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package importkey MPC implementation of importing an existing private key
+// A trusted dealer splits the key with feldman vss,every member verifies its share against the commitments of the dealer,
+// then the members generate fresh paillier keys and ntilde and prove them to each other just like keygen does.
+package importkey
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp  localTempData
+	data  *keygen.LocalDNodeSaveData
+	polyG *ec2.PolyGStruct2
+	out   chan<- smpc.Message
+	end   chan<- keygen.LocalDNodeSaveData
+	curve elliptic.Curve
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	impRound1Messages,
+	impRound2Messages []smpc.Message
+
+	// temp data (thrown away after import)
+
+	//round 1
+	preParams *ec2.PreParams
+	p         *big.Int // paillier primes
+	q         *big.Int
+	p1        *big.Int // ntilde primes
+	p2        *big.Int
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// sd.IDs,sd.CurDNodeID,sd.Pkx,sd.Pky,sd.C and sd.SkU1 must be set,sd.SkU1 is the share dealt to current node
+// polyG is the feldman commitments of the dealer,polyG.PolyG[0] is the pubkey
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- keygen.LocalDNodeSaveData,
+	DNodeCountInGroup int,
+	threshold int,
+	paillierkeylength int,
+	sd *keygen.LocalDNodeSaveData,
+	polyG *ec2.PolyGStruct2,
+	keytype string,
+) smpc.DNode {
+
+	id := ""
+	if sd != nil && sd.CurDNodeID != nil {
+		id = fmt.Sprintf("%v", sd.CurDNodeID)
+		sd.U1PaillierPk = make([]*ec2.PublicKey, DNodeCountInGroup)
+		sd.U1NtildeH1H2 = make([]*ec2.NtildeH1H2, DNodeCountInGroup)
+	}
+
+	p := &LocalDNode{
+		BaseDNode: new(smpc.BaseDNode),
+		temp:      localTempData{},
+		data:      sd,
+		polyG:     polyG,
+		out:       out,
+		end:       end,
+		curve:     ec2.GetCurve(keytype),
+	}
+
+	p.ID = hex.EncodeToString([]byte(id))
+	p.DNodeCountInGroup = DNodeCountInGroup
+	p.ThresHold = threshold
+	p.PaillierKeyLength = paillierkeylength
+
+	p.temp.impRound1Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.impRound2Messages = make([]smpc.Message, DNodeCountInGroup)
+	return p
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(p.data, p.polyG, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.PaillierKeyLength, p.curve, p.SessionKey)
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// Finalize weather gg20 round
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// Start import key start
+func (p *LocalDNode) Start() error {
+	if p.data == nil || p.data.CurDNodeID == nil || p.data.Pkx == nil || p.data.Pky == nil || p.data.C == nil || p.data.SkU1 == nil || len(p.data.IDs) != p.DNodeCountInGroup {
+		return errors.New("import key save data error")
+	}
+
+	if p.polyG == nil || len(p.polyG.PolyG) != p.ThresHold {
+		return errors.New("import key commitments error")
+	}
+
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *ImpRound1Message:
+		return find(p.temp.impRound1Messages, msg)
+	case *ImpRound2Message:
+		return find(p.temp.impRound2Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// putMessage put msg to l,return false if it is a duplicate
+func putMessage(l []smpc.Message, msg smpc.Message) (bool, error) {
+	if find(l, msg) {
+		return false, nil
+	}
+
+	index := msg.GetFromIndex()
+	if index < 0 || index >= len(l) {
+		return false, errors.New("msg index error")
+	}
+
+	l[index] = msg
+	return true, nil
+}
+
+// checkNtilde check h1,h2 and the proofs that h1 and h2 generate the same group of Ntilde
+func checkNtilde(m *ImpRound1Message) error {
+	if m.U1PaillierPk == nil || m.U1PaillierPk.N == nil || m.U1NtildeH1H2 == nil || m.U1NtildeH1H2.Ntilde == nil || m.U1NtildeH1H2.H1 == nil || m.U1NtildeH1H2.H2 == nil || m.NtildeProof1 == nil || m.NtildeProof2 == nil {
+		return errors.New("error import key round1 message")
+	}
+
+	H1 := m.U1NtildeH1H2.H1
+	H2 := m.U1NtildeH1H2.H2
+	Ntilde := m.U1NtildeH1H2.Ntilde
+	h1modn := new(big.Int).Mod(H1, Ntilde)
+	h2modn := new(big.Int).Mod(H2, Ntilde)
+	if h1modn.Sign() == 0 || h2modn.Sign() == 0 {
+		return errors.New("h1 or h2 is equal 0 mod Ntilde")
+	}
+
+	if h1modn.Cmp(big.NewInt(1)) == 0 || h2modn.Cmp(big.NewInt(1)) == 0 {
+		return errors.New("h1 or h2 is equal 1 mod Ntilde")
+	}
+
+	if h1modn.Cmp(h2modn) == 0 {
+		return errors.New("h1 and h2 were equal mod Ntilde")
+	}
+
+	// the two proofs are independent,verify them at the same time
+	return smpc.VerifyParallel(2, func(k int) error {
+		if (k == 0 && !m.NtildeProof1.Verify(H1, H2, Ntilde)) || (k == 1 && !m.NtildeProof2.Verify(H2, H1, Ntilde)) {
+			return errors.New("ntilde zk proof check fail")
+		}
+		return nil
+	})
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *ImpRound1Message:
+		m := msg.(*ImpRound1Message)
+		if err := checkNtilde(m); err != nil {
+			return false, smpc.NewBlameError(m.GetFromID(), 1, "NtildeProof", err)
+		}
+
+		if ok, err := putMessage(p.temp.impRound1Messages, msg); !ok {
+			return false, err
+		}
+
+		p.data.U1PaillierPk[m.GetFromIndex()] = m.U1PaillierPk
+		p.data.U1NtildeH1H2[m.GetFromIndex()] = m.U1NtildeH1H2
+		return CheckFull(p.temp.impRound1Messages), nil
+	case *ImpRound2Message:
+		if ok, err := putMessage(p.temp.impRound2Messages, msg); !ok {
+			return false, err
+		}
+
+		return CheckFull(p.temp.impRound2Messages), nil
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+}
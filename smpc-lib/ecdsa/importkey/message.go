@@ -0,0 +1,138 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package importkey
+
+import (
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&ImpRound1Message{},
+		&ImpRound2Message{},
+	)
+}
+
+// ImpRoundMessage base type of import key round message
+type ImpRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (im *ImpRoundMessage) SetFromID(id string) {
+	im.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (im *ImpRoundMessage) SetFromIndex(index int) {
+	im.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (im *ImpRoundMessage) AppendToID(toid string) {
+	im.ToID = append(im.ToID, toid)
+}
+
+// ImpRound1Message  Round 1 sending message,the fresh paillier pubkey and ntilde of the member
+type ImpRound1Message struct {
+	*ImpRoundMessage
+
+	U1PaillierPk *ec2.PublicKey
+	U1NtildeH1H2 *ec2.NtildeH1H2
+
+	NtildeProof1 *ec2.NtildeProof
+	NtildeProof2 *ec2.NtildeProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (im *ImpRound1Message) GetFromID() string {
+	return im.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (im *ImpRound1Message) GetFromIndex() int {
+	return im.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (im *ImpRound1Message) GetToID() []string {
+	return im.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (im *ImpRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (im *ImpRound1Message) GetMsgType() string {
+	return "ImpRound1Message"
+}
+
+// ImpRound2Message  Round 2 sending message,the zk proofs of the paillier key and ntilde,and the proof of knowing the imported share
+type ImpRound2Message struct {
+	*ImpRoundMessage
+
+	// paillier N is square-free
+	SfNum *big.Int
+	SfPf  *ec2.SquareFreeProof
+
+	// ntilde is square-free
+	NtildeSfNum *big.Int
+	NtildeSfPf  *ec2.SquareFreeProof
+
+	// ntilde is a product of two primes
+	HvNum *big.Int
+	HvPf  *ec2.HvProof
+
+	// paillier N is a paillier-blum modulus and has no small factor
+	ModPf  *ec2.PaillierBlumProof
+	FacPf  []*ec2.NoSmallFactorProof // FacPf[k] is checked with the ntilde of the node k
+
+	U1zkXiProof *ec2.ZkXiProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (im *ImpRound2Message) GetFromID() string {
+	return im.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (im *ImpRound2Message) GetFromIndex() int {
+	return im.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (im *ImpRound2Message) GetToID() []string {
+	return im.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (im *ImpRound2Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (im *ImpRound2Message) GetMsgType() string {
+	return "ImpRound2Message"
+}
@@ -0,0 +1,135 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package importkey
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(save *keygen.LocalDNodeSaveData, polyG *ec2.PolyGStruct2, temp *localTempData, out chan<- smpc.Message, end chan<- keygen.LocalDNodeSaveData, dnodeid string, dnodecount int, threshold int, paillierkeylength int, curve elliptic.Curve, sessionkey string) smpc.Round {
+	return &round1{
+		&base{save, polyG, temp, out, end, make([]bool, dnodecount), false, 0, dnodeid, dnodecount, threshold, paillierkeylength, curve, sessionkey}}
+}
+
+// Start verify the dealt share against the commitments of the dealer,generate paillier key and ntilde
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= import key round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	if round.threshold <= 1 || round.threshold > round.dnodecount {
+		return errors.New("threshold value error")
+	}
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		fmt.Printf("============import key round1 start,get dnode id index fail,uid = %v,err = %v ===========\n", round.dnodeid, err)
+		return err
+	}
+
+	// the constant term of the polynomial is the imported private key
+	pk := round.polyG.PolyG[0]
+	if len(pk) != 2 || pk[0].Cmp(round.Save.Pkx) != 0 || pk[1].Cmp(round.Save.Pky) != 0 {
+		return errors.New("the commitments do not match the pubkey")
+	}
+
+	share := &ec2.ShareStruct2{ID: round.Save.CurDNodeID, Share: round.Save.SkU1}
+	if !share.Verify2(round.curve, round.polyG) {
+		return errors.New("verify the dealt share fail")
+	}
+
+	if round.paillierkeylength < ec2.MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	}
+
+	// use the pre-generated data if there is,so that import does not wait for the safe primes
+	var u1PaillierPk *ec2.PublicKey
+	var u1PaillierSk *ec2.PrivateKey
+	var u1NtildeH1H2 *ec2.NtildeH1H2
+	var ntildeProof1, ntildeProof2 *ec2.NtildeProof
+	if pre := ec2.TakePreParams(round.dnodeid, round.paillierkeylength); pre != nil {
+		u1PaillierPk, u1PaillierSk = pre.PaillierPk(), pre.PaillierSk
+		round.temp.p, round.temp.q = u1PaillierSk.Primes()
+		u1NtildeH1H2 = pre.NtildeH1H2
+		round.Save.U1NtildePrivData = pre.NtildePriv
+		round.temp.p1, round.temp.p2 = pre.NtildePrimes()
+		ntildeProof1, ntildeProof2 = pre.NtildeProof1, pre.NtildeProof2
+		round.temp.preParams = pre
+	} else {
+		u1PaillierPk, u1PaillierSk, round.temp.p, round.temp.q = ec2.GenerateKeyPair(round.paillierkeylength)
+		if u1PaillierPk == nil || u1PaillierSk == nil {
+			return errors.New(" Error generating Paillier pubkey/private data ")
+		}
+
+		var alpha, beta, p, q *big.Int
+		u1NtildeH1H2, alpha, beta, p, q, round.temp.p1, round.temp.p2 = ec2.GenerateNtildeH1H2(round.paillierkeylength)
+		if u1NtildeH1H2 == nil {
+			return errors.New("gen ntilde h1 h2 fail")
+		}
+
+		round.Save.U1NtildePrivData = &ec2.NtildePrivData{Alpha: alpha, Beta: beta, Q1: p, Q2: q}
+		ntildeProof1 = ec2.NewNtildeProof(u1NtildeH1H2.H1, u1NtildeH1H2.H2, alpha, p, q, u1NtildeH1H2.Ntilde)
+		ntildeProof2 = ec2.NewNtildeProof(u1NtildeH1H2.H2, u1NtildeH1H2.H1, beta, p, q, u1NtildeH1H2.Ntilde)
+	}
+
+	im := &ImpRound1Message{
+		ImpRoundMessage: new(ImpRoundMessage),
+		U1PaillierPk:    u1PaillierPk,
+		U1NtildeH1H2:    u1NtildeH1H2,
+		NtildeProof1:    ntildeProof1,
+		NtildeProof2:    ntildeProof2,
+	}
+	im.SetFromID(round.dnodeid)
+	im.SetFromIndex(index)
+
+	round.Save.U1PaillierSk = u1PaillierSk
+	round.Save.U1PaillierPk[index] = u1PaillierPk
+	round.Save.U1NtildeH1H2[index] = u1NtildeH1H2
+	round.temp.impRound1Messages[index] = im
+	round.out <- im
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*ImpRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.impRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,165 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package importkey
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start check the bitlen of paillier N and ntilde,prove the paillier key,the ntilde and the knowledge of the dealt share
+func (round *round2) Start() error {
+	if round.started {
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	for k, msg := range round.temp.impRound1Messages {
+		m, ok := msg.(*ImpRound1Message)
+		if !ok {
+			return errors.New("error import key round1 message")
+		}
+
+		if m.U1PaillierPk.N.BitLen() < ec2.MinPaillierKeyLength || m.U1PaillierPk.N.BitLen() != round.paillierkeylength {
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(round.Save.IDs[k]), round.number, "PaillierKeyLength", errors.New("got paillier N with not enough bits"))
+		}
+
+		if m.U1NtildeH1H2.Ntilde.BitLen() < ec2.MinNtildeLength || m.U1NtildeH1H2.Ntilde.BitLen() != round.paillierkeylength {
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(round.Save.IDs[k]), round.number, "NtildeLength", errors.New("got ntilde with not enough bits"))
+		}
+	}
+
+	im := &ImpRound2Message{
+		ImpRoundMessage: new(ImpRoundMessage),
+	}
+	im.SetFromID(round.dnodeid)
+	im.SetFromIndex(curIndex)
+
+	// the proofs made with the pre params are used if there are
+	if pre := round.temp.preParams; pre != nil {
+		im.SfNum, im.SfPf = pre.SquareFreeProof()
+		im.NtildeSfNum, im.NtildeSfPf = pre.NtildeSquareFreeProof()
+		im.HvNum, im.HvPf = pre.NtildeHvProof()
+	}
+
+	// paillier N is square-free
+	paiN := round.Save.U1PaillierSk.N
+	if im.SfPf == nil {
+		im.SfNum = ec2.MustGetRandomInt(paiN.BitLen())
+		if im.SfNum == nil {
+			return errors.New("get random int fail")
+		}
+
+		im.SfPf = ec2.SquareFreeProve(paiN, im.SfNum, round.Save.U1PaillierSk.L)
+	}
+
+	if im.SfPf == nil {
+		return errors.New("get square free proof fail")
+	}
+
+	// ntilde is square-free and a product of two primes,the same as keygen round 5
+	ntilde := round.Save.U1NtildeH1H2[curIndex].Ntilde
+	if im.NtildeSfPf == nil {
+		im.NtildeSfNum = ec2.MustGetRandomInt(ntilde.BitLen())
+		if im.NtildeSfNum == nil {
+			return errors.New("get random int fail")
+		}
+
+		pMinus1 := new(big.Int).Sub(round.temp.p1, big.NewInt(1))
+		qMinus1 := new(big.Int).Sub(round.temp.p2, big.NewInt(1))
+		l := new(big.Int).Mul(pMinus1, qMinus1)
+		im.NtildeSfPf = ec2.SquareFreeProve(ntilde, im.NtildeSfNum, l)
+	}
+
+	if im.NtildeSfPf == nil {
+		return errors.New("get square free proof fail")
+	}
+
+	if im.HvPf == nil {
+		im.HvNum = ec2.MustGetRandomInt(ntilde.BitLen())
+		if im.HvNum == nil {
+			return errors.New("get random int fail")
+		}
+
+		im.HvPf = ec2.HvProve(ntilde, im.HvNum, round.temp.p1, round.temp.p2)
+	}
+
+	if im.HvPf == nil {
+		return errors.New("get hvzk proof fail")
+	}
+
+	// paillier N is a paillier-blum modulus and has no small factor, the no small factor proof use the ntilde of every receiver
+	// the challenges are bound to the session,the prover and the round,so the prover can not choose them
+	ctx := round.proofContext(round.Save.CurDNodeID, round.number)
+	im.ModPf = ec2.PaillierBlumProve(ctx, paiN, round.temp.p, round.temp.q)
+	if im.ModPf == nil {
+		return errors.New("get paillier-blum modulus proof fail")
+	}
+
+	im.FacPf = make([]*ec2.NoSmallFactorProof, round.dnodecount)
+	for k := range im.FacPf {
+		im.FacPf[k] = ec2.NoSmallFactorProve(ctx, paiN, round.temp.p, round.temp.q, round.Save.U1NtildeH1H2[k])
+		if im.FacPf[k] == nil {
+			return errors.New("get no small factor proof fail")
+		}
+	}
+
+	round.temp.p = nil
+	round.temp.q = nil
+	round.temp.p1 = nil
+	round.temp.p2 = nil
+	round.temp.preParams = nil
+
+	// the other members check the proof with the xi*G derived from the commitments
+	im.U1zkXiProof = ec2.ZkXiProve(round.curve, round.proofContext(round.Save.CurDNodeID, round.number), round.Save.SkU1)
+	if im.U1zkXiProof == nil {
+		return errors.New("zkx prove fail")
+	}
+
+	round.temp.impRound2Messages[curIndex] = im
+	round.out <- im
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*ImpRound2Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	return round.update(round.temp.impRound2Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
@@ -0,0 +1,126 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package importkey
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the proofs of all members and return save data
+func (round *round3) Start() error {
+	if round.started {
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return err
+	}
+
+	ownNtilde := round.Save.U1NtildeH1H2[curIndex]
+	pubShares := make([][]*big.Int, len(ids))
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
+		msg1, ok := round.temp.impRound1Messages[k].(*ImpRound1Message)
+		if !ok {
+			return errors.New("round.Start get round1 msg fail")
+		}
+
+		msg2, ok := round.temp.impRound2Messages[k].(*ImpRound2Message)
+		if !ok {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		dnodeid := smpc.GetDNodeIDByUID(ids[k])
+		paiN := msg1.U1PaillierPk.N
+		ntilde := msg1.U1NtildeH1H2.Ntilde
+
+		if !ec2.SquareFreeVerify(paiN, msg2.SfNum, msg2.SfPf) {
+			fmt.Printf("========= import key round3,check that paillier N is a square-free integer fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "SquareFreeProof", errors.New("check that a zero-knowledge proof that paillier.N is a square-free integer fail"))
+		}
+
+		if !ec2.SquareFreeVerify(ntilde, msg2.NtildeSfNum, msg2.NtildeSfPf) {
+			fmt.Printf("========= import key round3,check that ntilde is a square-free integer fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "SquareFreeProof", errors.New("check that a zero-knowledge proof that ntilde is a square-free integer fail"))
+		}
+
+		if !ec2.HvVerify(ntilde, msg2.HvNum, msg2.HvPf) {
+			fmt.Printf("========= import key round3,check that ntilde is a valid RSA modulus from two safe primes fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "HvProof", errors.New("check that a zero-knowledge proof that ntilde is a valid RSA modulus from two safe primes fail"))
+		}
+
+		ctx := round.proofContext(ids[k], 2)
+		if !ec2.PaillierBlumVerify(ctx, paiN, msg2.ModPf) {
+			fmt.Printf("========= import key round3,check that paillier N is a paillier-blum modulus fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "PaillierBlumProof", errors.New("check that a zero-knowledge proof that paillier N is a paillier-blum modulus fail"))
+		}
+
+		if len(msg2.FacPf) != len(ids) || !ec2.NoSmallFactorVerify(ctx, paiN, ownNtilde, msg2.FacPf[curIndex]) {
+			fmt.Printf("========= import key round3,check that paillier N has no small factor fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "NoSmallFactorProof", errors.New("check that a zero-knowledge proof that paillier N has no small factor fail"))
+		}
+
+		// a member that can not prove the knowledge of its share did not get a valid share from the dealer
+		xiGx, xiGy, err := round.shareG(ids[k])
+		if err != nil {
+			return err
+		}
+
+		if !ec2.ZkXiVerify(round.curve, round.proofContext(ids[k], 2), []*big.Int{xiGx, xiGy}, msg2.U1zkXiProof) {
+			fmt.Printf("========= import key round3,verify zkx fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(dnodeid, round.number, "ZkXiProof", errors.New("verify zkx fail"))
+		}
+
+		pubShares[k] = []*big.Int{xiGx, xiGy}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	round.Save.PubShares = pubShares
+	round.end <- *round.Save
+	return nil
+}
+
+// CanAccept end import key
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update end import key
+func (round *round3) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound end import key
+func (round *round3) NextRound() smpc.Round {
+	return nil
+}
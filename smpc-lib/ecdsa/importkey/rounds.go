@@ -0,0 +1,150 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package importkey
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		Save              *keygen.LocalDNodeSaveData
+		polyG             *ec2.PolyGStruct2
+		temp              *localTempData
+		out               chan<- smpc.Message
+		end               chan<- keygen.LocalDNodeSaveData
+		ok                []bool
+		started           bool
+		number            int
+		dnodeid           string
+		dnodecount        int
+		threshold         int
+		paillierkeylength int
+		curve             elliptic.Curve
+		sessionkey        string
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetIDs get the uids of all nodes in the group
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	if round.Save == nil || len(round.Save.IDs) != round.dnodecount {
+		return nil, errors.New("get ids fail")
+	}
+
+	return round.Save.IDs, nil
+}
+
+// GetDNodeIDIndex get the node index in the group by dnode id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" || round.Save == nil {
+		return -1, errors.New("no found current node's uid")
+	}
+
+	idtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+
+	uid, ok := new(big.Int).SetString(string(idtmp), 10)
+	if !ok {
+		return -1, errors.New("get uid fail")
+	}
+
+	for k, v := range round.Save.IDs {
+		if v.Cmp(uid) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in ids")
+}
+
+// shareG calc f(id)*G from the feldman commitments of the dealer,it is the xi*G of the node id
+func (round *base) shareG(id *big.Int) (*big.Int, *big.Int, error) {
+	if id == nil || round.polyG == nil || len(round.polyG.PolyG) == 0 {
+		return nil, nil, errors.New("param error")
+	}
+
+	x, y := round.polyG.PubShare(round.curve, id)
+	if x == nil {
+		return nil, nil, errors.New("invalid commitment")
+	}
+
+	return x, y, nil
+}
+
+// proofContext the context that the zk proofs of party id in round number are bound to
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// update is the messages of current round received?
+func (round *base) update(l []smpc.Message, canAccept func(smpc.Message) bool) (bool, error) {
+	for j, msg := range l {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !canAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
@@ -0,0 +1,333 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package keygen
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// The complaint sub-protocol of round 3:
+// every node verifies the vss share it got from each dealer against the dealer's polyG and broadcasts the dealers whose share is invalid (complaintRound),
+// each accused dealer reveals the shares of its accusers publicly (justifyRound),
+// then in round 4 everyone checks the revealed shares: if a revealed share is invalid the dealer is faulty and its polynomial is excluded from the key,
+// otherwise the accuser made a false complaint,it is marked faulty and takes the revealed share.
+// An accused dealer that reveals nothing is faulty too: the owner of the dnode calls LocalDNode.Timeout when it has waited long enough,
+// so the justification must reach all nodes in time or none of them,otherwise the nodes disagree on the dealers and the keygen fails.
+
+// Start verify the vss shares from all dealers and broadcast the complaint
+func (round *complaintRound) Start() error {
+	if round.started {
+		return errors.New("round already started")
+	}
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return err
+	}
+
+	accused := make([]int, 0)
+	for k := range ids {
+		msg2, ok := round.temp.kgRound2Messages[k].(*KGRound2Message)
+		if !ok {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		msg3, ok := round.temp.kgRound3Messages[k].(*KGRound3Message)
+		if !ok {
+			return errors.New("round.Start get round3 msg fail")
+		}
+
+		if !verifyShare(round.curve, ids[curIndex], msg2.Share, msg3.U1PolyGG) {
+			fmt.Printf("========= complaint round, verify share fail, k = %v ==========\n", k)
+			accused = append(accused, k)
+		}
+	}
+
+	kg := &KGRound3Message2{
+		KGRoundMessage: new(KGRoundMessage),
+		Accused:        accused,
+	}
+	kg.SetFromID(round.dnodeid)
+	kg.SetFromIndex(curIndex)
+	round.temp.kgRound3Messages2[curIndex] = kg
+	round.out <- kg
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *complaintRound) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*KGRound3Message2); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *complaintRound) Update() (bool, error) {
+	for j, msg := range round.temp.kgRound3Messages2 {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *complaintRound) NextRound() smpc.Round {
+	round.started = false
+	return &justifyRound{complaintRound: round}
+}
+
+//----------------------------------------------------------------
+
+// Start reveal the shares of the accusers if current node is accused
+func (round *justifyRound) Start() error {
+	if round.started {
+		return errors.New("round already started")
+	}
+	round.started = true
+	round.start = time.Now()
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return err
+	}
+
+	accusers := getComplaints(round.temp.kgRound3Messages2, len(ids))[curIndex]
+	if len(accusers) == 0 {
+		return nil
+	}
+
+	shares := make([]*big.Int, len(accusers))
+	for i, j := range accusers {
+		for _, v := range round.temp.u1Shares {
+			if vv := ec2.GetSharesID(v); vv != nil && vv.Cmp(ids[j]) == 0 {
+				shares[i] = v.Share
+				break
+			}
+		}
+
+		if shares[i] == nil {
+			return errors.New("get the share of the accuser fail")
+		}
+	}
+
+	kg := &KGRound3Message3{
+		KGRoundMessage: new(KGRoundMessage),
+		Accuser:        accusers,
+		Share:          shares,
+	}
+	kg.SetFromID(round.dnodeid)
+	kg.SetFromIndex(curIndex)
+	round.temp.kgRound3Messages3[curIndex] = kg
+	round.out <- kg
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *justifyRound) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*KGRound3Message3); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *justifyRound) Update() (bool, error) {
+	complaints := getComplaints(round.temp.kgRound3Messages2, len(round.temp.kgRound3Messages2))
+	for j, msg := range round.temp.kgRound3Messages3 {
+		if round.ok[j] {
+			continue
+		}
+		// the dealers that no one accuses send nothing,the accused ones that do not reveal the shares in time are faulty
+		if len(complaints[j]) != 0 && (msg == nil || !round.CanAccept(msg)) && !round.timedout {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+	return true, nil
+}
+
+// Timeout give up waiting for the accused dealers that have not revealed the shares if the round started at least wait ago,
+// round 4 excludes their polynomials from the key
+func (round *justifyRound) Timeout(wait time.Duration) bool {
+	if !round.started || round.timedout || time.Since(round.start) < wait {
+		return false
+	}
+
+	if justifyFull(round.temp.kgRound3Messages2, round.temp.kgRound3Messages3) {
+		return false
+	}
+
+	fmt.Printf("========= justify round timeout,the accused dealers that do not reveal the shares are faulty ==========\n")
+	round.timedout = true
+	return true
+}
+
+// NextRound enter next round
+func (round *justifyRound) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round.round3}
+}
+
+//----------------------------------------------------------------
+
+// verifyShare verify the share of the node id against the polyG of the dealer
+func verifyShare(curve elliptic.Curve, id *big.Int, share *big.Int, polyG [][]*big.Int) bool {
+	if id == nil || share == nil || len(polyG) == 0 {
+		return false
+	}
+
+	for _, v := range polyG {
+		if len(v) != 2 || v[0] == nil || v[1] == nil {
+			return false
+		}
+	}
+
+	ushare := &ec2.ShareStruct2{ID: id, Share: share}
+	return ushare.Verify2(curve, &ec2.PolyGStruct2{PolyG: polyG})
+}
+
+// getComplaints get the indexes of the accusers of every dealer from the complaint messages, the result is the same on every node.
+// Indexes out of range,self accusations and repeated accusations are ignored.
+func getComplaints(msgs []smpc.Message, count int) [][]int {
+	complaints := make([][]int, count)
+	for j, msg := range msgs {
+		m, ok := msg.(*KGRound3Message2)
+		if !ok {
+			continue
+		}
+
+		accused := make(map[int]bool)
+		for _, k := range m.Accused {
+			if k < 0 || k >= count || k == j || accused[k] {
+				continue
+			}
+
+			accused[k] = true
+			complaints[k] = append(complaints[k], j)
+		}
+	}
+
+	return complaints
+}
+
+// justifyFull weather all accused dealers have sent the justification
+func justifyFull(complaints []smpc.Message, justifies []smpc.Message) bool {
+	for k, accusers := range getComplaints(complaints, len(justifies)) {
+		if len(accusers) != 0 && justifies[k] == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveComplaints check the shares revealed by the accused dealers,every node gets the same result.
+// It returns weather the polynomial of each dealer is taken into the key,the share of current node from each dealer and the blames of the faulty nodes.
+// The keygen aborts blaming the faulty dealers if less than threshold dealers are left.
+func (round *round4) resolveComplaints(curIndex int, ids smpc.SortableIDSSlice) ([]bool, []*big.Int, []*smpc.Blame, error) {
+	complaints := getComplaints(round.temp.kgRound3Messages2, len(ids))
+	qual := make([]bool, len(ids))
+	shares := make([]*big.Int, len(ids))
+	dealers := make([]*smpc.Blame, 0)
+	accusers := make([]*smpc.Blame, 0)
+	count := 0
+	for k := range ids {
+		msg2, ok := round.temp.kgRound2Messages[k].(*KGRound2Message)
+		if !ok {
+			return nil, nil, nil, errors.New("round.Start get round2 msg fail")
+		}
+
+		msg3, ok := round.temp.kgRound3Messages[k].(*KGRound3Message)
+		if !ok {
+			return nil, nil, nil, errors.New("round.Start get round3 msg fail")
+		}
+
+		qual[k] = true
+		shares[k] = msg2.Share
+		for _, j := range complaints[k] {
+			if !verifyShare(round.curve, ids[j], getRevealedShare(round.temp.kgRound3Messages3[k], j), msg3.U1PolyGG) {
+				qual[k] = false
+				break
+			}
+		}
+
+		if !qual[k] {
+			fmt.Printf("========= round4 the dealer fail to justify the share, k = %v ==========\n", k)
+			dealers = append(dealers, &smpc.Blame{Culprit: smpc.GetDNodeIDByUID(ids[k]), Round: round.number, Proof: "VssShare"})
+			continue
+		}
+
+		count++
+		for _, j := range complaints[k] {
+			fmt.Printf("========= round4 false complaint, accuser = %v, dealer = %v ==========\n", j, k)
+			accusers = append(accusers, &smpc.Blame{Culprit: smpc.GetDNodeIDByUID(ids[j]), Round: round.number, Proof: "FalseComplaint"})
+			if j == curIndex {
+				shares[k] = getRevealedShare(round.temp.kgRound3Messages3[k], j)
+			}
+		}
+	}
+
+	if count < round.threshold {
+		return nil, nil, nil, &smpc.BlameError{Blames: dealers, Err: errors.New("verify share data fail")}
+	}
+
+	return qual, shares, append(dealers, accusers...), nil
+}
+
+// getRevealedShare get the share of the accuser j revealed by the dealer,nil if the dealer does not reveal it
+func getRevealedShare(justify smpc.Message, j int) *big.Int {
+	m, ok := justify.(*KGRound3Message3)
+	if !ok || len(m.Accuser) != len(m.Share) {
+		return nil
+	}
+
+	for i, v := range m.Accuser {
+		if v == j {
+			return m.Share[i]
+		}
+	}
+
+	return nil
+}
@@ -18,6 +18,7 @@
 package keygen
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
 	"time"
@@ -37,6 +38,7 @@ type LocalDNode struct {
 	data LocalDNodeSaveData
 	out  chan<- smpc.Message
 	end  chan<- LocalDNodeSaveData
+	curve elliptic.Curve
 }
 
 // localTempData  Store some data of MPC calculation process 
@@ -48,10 +50,13 @@ type localTempData struct {
 	kgRound2Messages2,
 	kgRound3Messages,
 	kgRound3Messages1,
+	kgRound3Messages2,
+	kgRound3Messages3,
 	kgRound4Messages,
 	kgRound5Messages,
 	kgRound5Messages1,
 	kgRound5Messages2,
+	kgRound5Messages3,
 	kgRound6Messages,
 	kgRound7Messages []smpc.Message
 
@@ -69,6 +74,8 @@ type localTempData struct {
 	// paillier.N = p*q
 	p *big.Int 
 	q *big.Int
+	// taken from the pre params pool,the ntilde data of round 4 is also from it
+	preParams *ec2.PreParams
 
 	//round 2
 	u1Shares []*ec2.ShareStruct2
@@ -95,6 +102,7 @@ func NewLocalDNode(
 	DNodeCountInGroup int,
 	threshold int,
 	paillierkeylength int,
+	keytype string,
 ) smpc.DNode {
 
 	data := NewLocalDNodeSaveData(DNodeCountInGroup)
@@ -104,6 +112,7 @@ func NewLocalDNode(
 		data:      data,
 		out:       out,
 		end:       end,
+		curve:     ec2.GetCurve(keytype),
 	}
 
 	uid := random.GetRandomIntFromZn(secp256k1.S256().N)
@@ -121,17 +130,20 @@ func NewLocalDNode(
 	p.temp.kgRound2Messages2 = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound3Messages = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound3Messages1 = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.kgRound3Messages2 = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.kgRound3Messages3 = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound4Messages = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound5Messages = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound5Messages1 = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound5Messages2 = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.kgRound5Messages3 = make([]smpc.Message, DNodeCountInGroup)
 	p.temp.kgRound6Messages = make([]smpc.Message, DNodeCountInGroup)
 	return p
 }
 
 // FirstRound first round
 func (p *LocalDNode) FirstRound() smpc.Round {
-	return newRound0(&p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.PaillierKeyLength)
+	return newRound0(&p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.PaillierKeyLength, p.curve, p.SessionKey)
 }
 
 // FinalizeRound get finalize round
@@ -154,6 +166,12 @@ func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
 	return smpc.BaseUpdate(p, msg)
 }
 
+// Timeout go on without the accused dealers that have not revealed the shares wait after the justify round started,
+// it returns weather the keygen gave up waiting for them
+func (p *LocalDNode) Timeout(wait time.Duration) (bool, error) {
+	return smpc.BaseTimeout(p, wait)
+}
+
 // DNodeID get the ID of current DNode
 func (p *LocalDNode) DNodeID() string {
 	return p.ID
@@ -250,6 +268,14 @@ func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
 	    	if find(p.temp.kgRound3Messages1,msg) {
 		    return true
 		}
+	case *KGRound3Message2:
+	    	if find(p.temp.kgRound3Messages2,msg) {
+		    return true
+		}
+	case *KGRound3Message3:
+	    	if find(p.temp.kgRound3Messages3,msg) {
+		    return true
+		}
 	case *KGRound4Message:
 	    	if find(p.temp.kgRound4Messages,msg) {
 		    return true
@@ -266,6 +292,10 @@ func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
 	    	if find(p.temp.kgRound5Messages2,msg) {
 		    return true
 		}
+	case *KGRound5Message3:
+	    	if find(p.temp.kgRound5Messages3,msg) {
+		    return true
+		}
 	case *KGRound6Message:
 	    	if find(p.temp.kgRound6Messages,msg) {
 		    return true
@@ -369,6 +399,29 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 			time.Sleep(time.Duration(1000000)) //tmp code
 			return true, nil
 		}
+	case *KGRound3Message2:
+	    	if find(p.temp.kgRound3Messages2,msg) {
+			return false,nil
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.kgRound3Messages2[index] = msg
+		if len(p.temp.kgRound3Messages2) == p.DNodeCountInGroup && CheckFull(p.temp.kgRound3Messages2) {
+			log.Info("================ StoreMessage,get all ec keygen 3-2 messages ==============")
+			return true, nil
+		}
+	case *KGRound3Message3:
+	    	if find(p.temp.kgRound3Messages3,msg) {
+			return false,nil
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.kgRound3Messages3[index] = msg
+		// only the accused dealers send 3-3 messages,the round can proceed when all of them arrive
+		if CheckFull(p.temp.kgRound3Messages2) && justifyFull(p.temp.kgRound3Messages2, p.temp.kgRound3Messages3) {
+			log.Info("================ StoreMessage,get all ec keygen 3-3 messages ==============")
+			return true, nil
+		}
 	case *KGRound4Message:
 	    	if find(p.temp.kgRound4Messages,msg) {
 			//if len(p.temp.kgRound4Messages) == p.DNodeCountInGroup && CheckFull(p.temp.kgRound4Messages) {
@@ -405,7 +458,14 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 			return false, errors.New("h1 and h2 were equal mod Ntilde")
 		}
 		
-		if !pf1.Verify(H1, H2, Ntilde) || !pf2.Verify(H2, H1, Ntilde) {
+		// the two proofs are independent,verify them at the same time
+		err := smpc.VerifyParallel(2, func(k int) error {
+		    if (k == 0 && !pf1.Verify(H1, H2, Ntilde)) || (k == 1 && !pf2.Verify(H2, H1, Ntilde)) {
+			return errors.New("ntilde zk proof check fail")
+		    }
+		    return nil
+		})
+		if err != nil {
 		    log.Error("=========================keygen StoreMessage, message 4, ntilde zk proof check fail. ===========================")
 			return false, errors.New("ntilde zk proof check fail")
 		}
@@ -466,6 +526,19 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 			time.Sleep(time.Duration(1000000))
 			return true, nil
 		}
+	case *KGRound5Message3:
+	    	if find(p.temp.kgRound5Messages3,msg) {
+			return false,nil
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.kgRound5Messages3[index] = msg
+		log.Info("=========================keygen StoreMessage, message 5-3========================","received msg counts", getFullCount(p.temp.kgRound5Messages3))
+		if len(p.temp.kgRound5Messages3) == p.DNodeCountInGroup && CheckFull(p.temp.kgRound5Messages3) {
+			log.Info("================ StoreMessage,get all ec keygen 5-3 messages ==============")
+			time.Sleep(time.Duration(1000000))
+			return true, nil
+		}
 	case *KGRound6Message:
 	    	if find(p.temp.kgRound6Messages,msg) {
 			//if len(p.temp.kgRound6Messages) == p.DNodeCountInGroup && CheckFull(p.temp.kgRound6Messages) {
@@ -17,13 +17,32 @@
 package keygen
 
 import (
-	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
-	"strconv"
-	"strings"
 )
 
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&KGRound0Message{},
+		&KGRound1Message{},
+		&KGRound2Message{},
+		&KGRound2Message1{},
+		&KGRound2Message2{},
+		&KGRound3Message{},
+		&KGRound3Message1{},
+		&KGRound3Message2{},
+		&KGRound3Message3{},
+		&KGRound4Message{},
+		&KGRound5Message{},
+		&KGRound5Message1{},
+		&KGRound5Message2{},
+		&KGRound5Message3{},
+		&KGRound6Message{},
+	)
+}
+
 // KGRoundMessage base type of kg round message
 type KGRoundMessage struct {
 	FromID    string   `json:"FromID"` //DNodeID
@@ -72,16 +91,6 @@ func (kg *KGRound0Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound0Message to map
-func (kg *KGRound0Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "KGRound0Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound0Message) GetMsgType() string {
 	return "KGRound0Message"
@@ -117,25 +126,6 @@ func (kg *KGRound1Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound1Message to map
-func (kg *KGRound1Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	m["ComC"] = fmt.Sprintf("%v", kg.ComC)
-	m["ComC_bip32"] = fmt.Sprintf("%v", kg.ComCBip32)
-
-	pk, err := kg.U1PaillierPk.MarshalJSON()
-	if err != nil {
-	    return nil 
-	}
-
-	m["U1PaillierPk"] = string(pk)
-	m["Type"] = "KGRound1Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound1Message) GetMsgType() string {
 	return "KGRound1Message"
@@ -169,18 +159,6 @@ func (kg *KGRound2Message) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *KGRound2Message to map
-func (kg *KGRound2Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = strings.Join(kg.ToID, ":")
-	m["ID"] = fmt.Sprintf("%v", kg.ID)
-	m["Share"] = fmt.Sprintf("%v", kg.Share)
-	m["Type"] = "KGRound2Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound2Message) GetMsgType() string {
 	return "KGRound2Message"
@@ -215,17 +193,6 @@ func (kg *KGRound2Message1) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound2Message1 to map
-func (kg *KGRound2Message1) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	m["C1"] = fmt.Sprintf("%v", kg.C1)
-	m["Type"] = "KGRound2Message1"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound2Message1) GetMsgType() string {
 	return "KGRound2Message1"
@@ -260,28 +227,6 @@ func (kg *KGRound2Message2) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound2Message1 to map
-func (kg *KGRound2Message2) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	
-	if kg.SfPf == nil {
-	    return nil
-	}
-	sf,err := kg.SfPf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["SfPf"] = string(sf)
-
-	m["Num"] = fmt.Sprintf("%v",kg.Num)
-	m["Type"] = "KGRound2Message2"
-	
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound2Message2) GetMsgType() string {
 	return "KGRound2Message2"
@@ -317,44 +262,6 @@ func (kg *KGRound3Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound3Message to map
-func (kg *KGRound3Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	tmp := make([]string, len(kg.ComU1GD))
-	for k, v := range kg.ComU1GD {
-		tmp[k] = fmt.Sprintf("%v", v)
-	}
-	m["ComU1GD"] = strings.Join(tmp, ":")
-
-	tmp2 := make([]string, len(kg.ComC1GD))
-	for k, v := range kg.ComC1GD {
-		tmp2[k] = fmt.Sprintf("%v", v)
-	}
-	m["ComC1GD"] = strings.Join(tmp2, ":")
-
-	tmp3 := make([][]string, len(kg.U1PolyGG))
-	for k, v := range kg.U1PolyGG {
-		tmp4 := make([]string, len(v))
-		for kk, vv := range v {
-			tmp4[kk] = fmt.Sprintf("%v", vv)
-		}
-
-		tmp3[k] = tmp4
-	}
-	tmp5 := make([]string, len(tmp3))
-	for k, v := range tmp3 {
-		vv := strings.Join(v, ":")
-		tmp5[k] = vv
-	}
-	m["U1PolyGG"] = strings.Join(tmp5, "|")
-	m["Type"] = "KGRound3Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound3Message) GetMsgType() string {
 	return "KGRound3Message"
@@ -388,22 +295,82 @@ func (kg *KGRound3Message1) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *KGRound3Message1 to map
-func (kg *KGRound3Message1) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = strings.Join(kg.ToID, ":")
-	m["Y"] = fmt.Sprintf("%v", kg.Y)
-	m["Type"] = "KGRound3Message1"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound3Message1) GetMsgType() string {
 	return "KGRound3Message1"
 }
 
+//--------------------------------------------------------------
+
+// KGRound3Message2  Round 3 complaint message,every node broadcasts it even if it accuses no one
+type KGRound3Message2 struct {
+	*KGRoundMessage
+
+	// the indexes of the dealers whose vss share sent to this node fail to verify
+	Accused []int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (kg *KGRound3Message2) GetFromID() string {
+	return kg.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group 
+func (kg *KGRound3Message2) GetFromIndex() int {
+	return kg.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (kg *KGRound3Message2) GetToID() []string {
+	return kg.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (kg *KGRound3Message2) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (kg *KGRound3Message2) GetMsgType() string {
+	return "KGRound3Message2"
+}
+
+//--------------------------------------------------------------
+
+// KGRound3Message3  Round 3 justification message,the accused dealer reveals the shares of its accusers publicly
+type KGRound3Message3 struct {
+	*KGRoundMessage
+
+	// Share[i] is the share of the node with index Accuser[i]
+	Accuser []int
+	Share   []*big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (kg *KGRound3Message3) GetFromID() string {
+	return kg.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group 
+func (kg *KGRound3Message3) GetFromIndex() int {
+	return kg.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (kg *KGRound3Message3) GetToID() []string {
+	return kg.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (kg *KGRound3Message3) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (kg *KGRound3Message3) GetMsgType() string {
+	return "KGRound3Message3"
+}
+
 //----------------------------------------------------------------
 
 // KGRound4Message  Round 4 sending message 
@@ -439,36 +406,6 @@ func (kg *KGRound4Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound4Message to map
-func (kg *KGRound4Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	nt, err := kg.U1NtildeH1H2.MarshalJSON()
-	if err != nil {
-	    return nil 
-	}
-	m["U1NtildeH1H2"] = string(nt)
-
-	pf1, err := kg.NtildeProof1.MarshalJSON()
-	if err != nil {
-	    return nil 
-	}
-	m["NtildeProof1"] = string(pf1)
-
-	pf2, err := kg.NtildeProof2.MarshalJSON()
-	if err != nil {
-	    return nil 
-	}
-	m["NtildeProof2"] = string(pf2)
-
-	m["ComXiC"] = fmt.Sprintf("%v",kg.ComXiC)
-	m["Type"] = "KGRound4Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound4Message) GetMsgType() string {
 	return "KGRound4Message"
@@ -500,23 +437,6 @@ func (kg *KGRound5Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound5Message to map
-func (kg *KGRound5Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	tmp := make([]string, len(kg.ComXiGD))
-	for k, v := range kg.ComXiGD {
-		tmp[k] = fmt.Sprintf("%v", v)
-	}
-	m["ComXiGD"] = strings.Join(tmp, ":")
-
-	m["Type"] = "KGRound5Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound5Message) GetMsgType() string {
 	return "KGRound5Message"
@@ -552,28 +472,6 @@ func (kg *KGRound5Message1) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound5Message1 to map
-func (kg *KGRound5Message1) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	
-	if kg.HvPf == nil {
-	    return nil
-	}
-	hv,err := kg.HvPf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["HvPf"] = string(hv)
-
-	m["Num"] = fmt.Sprintf("%v",kg.Num)
-	m["Type"] = "KGRound5Message1"
-	
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound5Message1) GetMsgType() string {
 	return "KGRound5Message1"
@@ -608,28 +506,6 @@ func (kg *KGRound5Message2) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound5Message2 to map
-func (kg *KGRound5Message2) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	
-	if kg.SfPf == nil {
-	    return nil
-	}
-	sf,err := kg.SfPf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["SfPf"] = string(sf)
-
-	m["Num"] = fmt.Sprintf("%v",kg.Num)
-	m["Type"] = "KGRound5Message2"
-	
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound5Message2) GetMsgType() string {
 	return "KGRound5Message2"
@@ -637,6 +513,40 @@ func (kg *KGRound5Message2) GetMsgType() string {
 
 //-----------------------------------------------------------------
 
+// KGRound5Message3  Round 5 sending message3
+type KGRound5Message3 struct {
+	*KGRoundMessage
+	ModPf *ec2.PaillierBlumProof
+	FacPf []*ec2.NoSmallFactorProof // FacPf[k] is checked with the ntilde of the node k
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (kg *KGRound5Message3) GetFromID() string {
+	return kg.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group 
+func (kg *KGRound5Message3) GetFromIndex() int {
+	return kg.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (kg *KGRound5Message3) GetToID() []string {
+	return kg.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (kg *KGRound5Message3) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (kg *KGRound5Message3) GetMsgType() string {
+	return "KGRound5Message3"
+}
+
+//-----------------------------------------------------------------
+
 // KGRound6Message  Round 6 sending message 
 type KGRound6Message struct {
 	*KGRoundMessage
@@ -664,29 +574,6 @@ func (kg *KGRound6Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound6Message to map
-func (kg *KGRound6Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	zk, err := kg.U1zkXiProof.MarshalJSON()
-	if err != nil {
-	    return nil 
-	}
-	m["U1zkXiProof"] = string(zk)
-
-	if kg.CheckPubkeyStatus {
-		m["CheckPubkeyStatus"] = "true"
-	} else {
-		m["CheckPubkeyStatus"] = "false"
-	}
-
-	m["Type"] = "KGRound6Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound6Message) GetMsgType() string {
 	return "KGRound6Message"
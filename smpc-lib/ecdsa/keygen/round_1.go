@@ -19,7 +19,6 @@ package keygen
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
@@ -36,17 +35,17 @@ func (round *round1) Start() error {
 	round.started = true
 	round.ResetOK()
 
-	u1 := random.GetRandomIntFromZn(secp256k1.S256().N)
-	c1 := random.GetRandomIntFromZn(secp256k1.S256().N)
+	u1 := random.GetRandomIntFromZn(round.curve.Params().N)
+	c1 := random.GetRandomIntFromZn(round.curve.Params().N)
 
 	if u1 == nil || c1 == nil || round.threshold <= 1 || round.threshold > round.dnodecount {
 	    return errors.New("round one fail")
 	}
 
-	u1Poly, u1PolyG, _ := ec2.Vss2Init(u1, round.threshold)
-	_, c1PolyG, _ := ec2.Vss2Init(c1, round.threshold)
+	u1Poly, u1PolyG, _ := ec2.Vss2Init(round.curve, u1, round.threshold)
+	_, c1PolyG, _ := ec2.Vss2Init(round.curve, c1, round.threshold)
 
-	u1Gx, u1Gy := secp256k1.S256().ScalarBaseMult(u1.Bytes())
+	u1Gx, u1Gy := round.curve.ScalarBaseMult(u1.Bytes())
 	u1Secrets := make([]*big.Int, 0)
 	u1Secrets = append(u1Secrets, u1Gx)
 	u1Secrets = append(u1Secrets, u1Gy)
@@ -57,7 +56,7 @@ func (round *round1) Start() error {
 	commitU1G := new(ec2.Commitment).Commit(u1Secrets...)
 
 	//bip32
-	c1Gx, c1Gy := secp256k1.S256().ScalarBaseMult(c1.Bytes())
+	c1Gx, c1Gy := round.curve.ScalarBaseMult(c1.Bytes())
 	c1Secrets := make([]*big.Int, 0)
 	c1Secrets = append(c1Secrets, c1Gx)
 	c1Secrets = append(c1Secrets, c1Gy)
@@ -68,7 +67,21 @@ func (round *round1) Start() error {
 	commitC1G := new(ec2.Commitment).Commit(c1Secrets...)
 
 	// 3. generate their own paillier public key and private key
-	u1PaillierPk, u1PaillierSk,p,q := ec2.GenerateKeyPair(round.paillierkeylength)
+	if round.paillierkeylength < ec2.MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	}
+	var u1PaillierPk *ec2.PublicKey
+	var u1PaillierSk *ec2.PrivateKey
+	var p,q *big.Int
+	// use the pre-generated data if there is,so that keygen does not wait for the safe primes
+	pre := ec2.TakePreParams(round.dnodeid,round.paillierkeylength)
+	if pre != nil {
+	    u1PaillierPk,u1PaillierSk = pre.PaillierPk(),pre.PaillierSk
+	    p,q = u1PaillierSk.Primes()
+	    round.temp.preParams = pre
+	} else {
+	    u1PaillierPk, u1PaillierSk,p,q = ec2.GenerateKeyPair(round.paillierkeylength)
+	}
 
 	if u1PaillierPk == nil || u1PaillierSk == nil {
 		return errors.New(" Error generating Paillier pubkey/private data ")
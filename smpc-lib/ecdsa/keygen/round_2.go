@@ -25,10 +25,6 @@ import (
 	"encoding/hex"
 )
 
-const (
-	paillierBitsLen = 2048
-)
-
 // Start send vss data to corresponding peer
 func (round *round2) Start() error {
 	if round.started {
@@ -48,7 +44,12 @@ func (round *round2) Start() error {
 		return err
 	}
 	round.Save.IDs = ids
-	round.Save.CurDNodeID, _ = new(big.Int).SetString(round.dnodeid, 10)
+	// dnodeid is the hex of the uid string
+	curuid, err := hex.DecodeString(round.dnodeid)
+	if err != nil {
+		return err
+	}
+	round.Save.CurDNodeID, _ = new(big.Int).SetString(string(curuid), 10)
 
 	//check paillier.N bitlen
 	for _,msg := range round.temp.kgRound1Messages {
@@ -62,7 +63,7 @@ func (round *round2) Start() error {
 			return errors.New("error kg round1 message")
 		}
 
-		if paiPk.N.BitLen() != paillierBitsLen {
+		if paiPk.N.BitLen() < ec2.MinPaillierKeyLength || paiPk.N.BitLen() != round.paillierkeylength {
 			return errors.New("got paillier N with not enough bits")
 		}
 	}
@@ -70,12 +71,22 @@ func (round *round2) Start() error {
 
 	// add for GG20: keygen phase 3. Each player Pi proves in ZK that Ni is square-free using the proof of Gennaro, Micciancio, and Rabin [30]
 	// An Efficient Non-Interactive Statistical Zero-Knowledge Proof System for Quasi-Safe Prime Products, section 3.1
-	num := ec2.MustGetRandomInt(round.Save.U1PaillierSk.N.BitLen())
-	if num == nil {
-	    return errors.New("get random int fail")
+	// the proof made with the pre params is used if there is
+	var num *big.Int
+	var sfProof *ec2.SquareFreeProof
+	if pre := round.temp.preParams; pre != nil {
+	    num,sfProof = pre.SquareFreeProof()
+	}
+
+	if sfProof == nil {
+	    num = ec2.MustGetRandomInt(round.Save.U1PaillierSk.N.BitLen())
+	    if num == nil {
+		return errors.New("get random int fail")
+	    }
+
+	    sfProof = ec2.SquareFreeProve(round.Save.U1PaillierSk.N,num,round.Save.U1PaillierSk.L)
 	}
 
-	sfProof := ec2.SquareFreeProve(round.Save.U1PaillierSk.N,num,round.Save.U1PaillierSk.L)
 	if sfProof == nil {
 	    return errors.New("get square free proof fail")
 	}
@@ -96,7 +107,7 @@ func (round *round2) Start() error {
 	    return errors.New("node id error")
 	}
 
-	u1Shares, err := round.temp.u1Poly.Vss2(ids)
+	u1Shares, err := round.temp.u1Poly.Vss2(round.curve, ids)
 	if err != nil {
 		return err
 	}
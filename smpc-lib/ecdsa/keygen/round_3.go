@@ -44,7 +44,7 @@ func (round *round3) Start() error {
 	
 	// add for GG20: keygen phase 3. Each player Pi proves in ZK that Ni is square-free using the proof of Gennaro, Micciancio, and Rabin [30]
 	// An Efficient Non-Interactive Statistical Zero-Knowledge Proof System for Quasi-Safe Prime Products, section 3.1
-	for k := range ids {
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
 	    msg1, ok := round.temp.kgRound1Messages[k].(*KGRound1Message)
 	    if !ok {
 		return errors.New("round.Start get round1 msg fail")
@@ -62,8 +62,13 @@ func (round *round3) Start() error {
 
 	    if !ec2.SquareFreeVerify(paiPk.N,msg22.Num,msg22.SfPf) {
 		fmt.Printf("==========================keygen round3,check that a zero-knowledge proof that paillier.N is a square-free integer fail, k = %v,id = %v============================\n",k,ids[k])
-		return errors.New("check that a zero-knowledge proof that paillier.N is a square-free integer fail")
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "SquareFreeProof", errors.New("check that a zero-knowledge proof that paillier.N is a square-free integer fail"))
 	    }
+
+	    return nil
+	})
+	if err != nil {
+	    return err
 	}
 
 	kg := &KGRound3Message{
@@ -113,5 +118,5 @@ func (round *round3) Update() (bool, error) {
 // NextRound enter next round
 func (round *round3) NextRound() smpc.Round {
 	round.started = false
-	return &round4{round}
+	return &complaintRound{round}
 }
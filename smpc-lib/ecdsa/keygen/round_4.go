@@ -21,7 +21,6 @@ import (
 	"fmt"
 	//"time"
 	"sync"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -53,24 +52,19 @@ func (round *round4) Start() error {
 		return err
 	}
 
-	for k := range ids {
-		msg2, ok := round.temp.kgRound2Messages[k].(*KGRound2Message)
-		if !ok {
-			return errors.New("round.Start get round2 msg fail")
-		}
+	// the shares are verified in the complaint round,the faulty dealers are excluded here
+	qual, shares, faulty, err := round.resolveComplaints(curIndex, ids)
+	if err != nil {
+		return err
+	}
+	round.Save.Faulty = faulty
 
-		ushare := &ec2.ShareStruct2{ID: msg2.ID, Share: msg2.Share}
+	for k := range ids {
 		msg3, ok := round.temp.kgRound3Messages[k].(*KGRound3Message)
 		if !ok {
 			return errors.New("round.Start get round3 msg fail")
 		}
 
-		ps := &ec2.PolyGStruct2{PolyG: msg3.U1PolyGG}
-		if !ushare.Verify2(ps) {
-			fmt.Printf("========= round4 verify share fail, k = %v ==========\n", k)
-			return errors.New("verify share data fail")
-		}
-
 		//verify commitment
 		msg1, ok := round.temp.kgRound1Messages[k].(*KGRound1Message)
 		if !ok {
@@ -78,25 +72,25 @@ func (round *round4) Start() error {
 		}
 
 		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg3.ComU1GD}
-		if !deCommit.Verify() {
+		if !deCommit.Verify(round.curve) {
 			fmt.Printf("========= round4 verify commitment fail, k = %v ==========\n", k)
 			return errors.New("verify commitment fail")
 		}
 
 		//verify bip32 commitment
 		deCommitBip32 := &ec2.Commitment{C: msg1.ComCBip32, D: msg3.ComC1GD}
-		if !deCommitBip32.Verify() {
+		if !deCommitBip32.Verify(round.curve) {
 			fmt.Printf("========= round4 verify commitment for bip32 fail, k = %v ==========\n", k)
 			return errors.New("verify commitment fail")
 		}
 
-		_, c1G := deCommitBip32.DeCommit()
+		_, c1G := deCommitBip32.DeCommit(round.curve)
 		msg21, ok := round.temp.kgRound2Messages1[k].(*KGRound2Message1)
 		if !ok {
 			return errors.New("round.Start get round2.1 msg fail")
 		}
 
-		cGVerifyx, cGVerifyy := secp256k1.S256().ScalarBaseMult(msg21.C1.Bytes())
+		cGVerifyx, cGVerifyy := round.curve.ScalarBaseMult(msg21.C1.Bytes())
 		if c1G[0].Cmp(cGVerifyx) == 0 && c1G[1].Cmp(cGVerifyy) == 0 {
 			//.....
 		} else {
@@ -110,55 +104,66 @@ func (round *round4) Start() error {
 	var c *big.Int
 	var skU1 *big.Int
 
+	// the pubkey is the sum of the secrets of the qualified dealers
 	for k := range ids {
-		msg3, _ := round.temp.kgRound3Messages[k].(*KGRound3Message)
-		msg1, _ := round.temp.kgRound1Messages[k].(*KGRound1Message)
-		msg2, _ := round.temp.kgRound2Messages[k].(*KGRound2Message)
-		ushare := &ec2.ShareStruct2{ID: msg2.ID, Share: msg2.Share}
-
-		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg3.ComU1GD}
-		_, u1G := deCommit.DeCommit()
-		pkx = u1G[0]
-		pky = u1G[1]
-
-		msg21, _ := round.temp.kgRound2Messages1[k].(*KGRound2Message1)
-
-		c = msg21.C1
-		skU1 = ushare.Share
-		break
-	}
-
-	for k := range ids {
-		if k == 0 {
+		if !qual[k] {
 			continue
 		}
 
-		msg2, _ := round.temp.kgRound2Messages[k].(*KGRound2Message)
-		ushare := &ec2.ShareStruct2{ID: msg2.ID, Share: msg2.Share}
 		msg3, _ := round.temp.kgRound3Messages[k].(*KGRound3Message)
 		msg1, _ := round.temp.kgRound1Messages[k].(*KGRound1Message)
+		msg21, _ := round.temp.kgRound2Messages1[k].(*KGRound2Message1)
 
 		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg3.ComU1GD}
+		_, u1G := deCommit.DeCommit(round.curve)
 
-		_, u1G := deCommit.DeCommit()
-		pkx, pky = secp256k1.S256().Add(pkx, pky, u1G[0], u1G[1])
-
-		msg21, _ := round.temp.kgRound2Messages1[k].(*KGRound2Message1)
+		if pkx == nil {
+			pkx, pky = u1G[0], u1G[1]
+			c = msg21.C1
+			skU1 = shares[k]
+			continue
+		}
 
+		pkx, pky = round.curve.Add(pkx, pky, u1G[0], u1G[1])
 		c = new(big.Int).Add(c, msg21.C1)
-		skU1 = new(big.Int).Add(skU1, ushare.Share)
+		skU1 = new(big.Int).Add(skU1, shares[k])
 	}
 
-	c = new(big.Int).Mod(c, secp256k1.S256().N)
-	skU1 = new(big.Int).Mod(skU1, secp256k1.S256().N)
+	c = new(big.Int).Mod(c, round.curve.Params().N)
+	skU1 = new(big.Int).Mod(skU1, round.curve.Params().N)
 
 	round.Save.SkU1 = skU1
 	round.Save.Pkx = pkx
 	round.Save.Pky = pky
 	round.Save.C = c
 
+	// the public share of each node is the sum of the public shares of it in the polyG of the qualified dealers
+	pubShares := make([][]*big.Int, len(ids))
+	for j := range ids {
+		var x, y *big.Int
+		for k := range ids {
+			if !qual[k] {
+				continue
+			}
+
+			msg3, _ := round.temp.kgRound3Messages[k].(*KGRound3Message)
+			px, py := (&ec2.PolyGStruct2{PolyG: msg3.U1PolyGG}).PubShare(round.curve, ids[j])
+			if px == nil {
+				return errors.New("calc public share fail")
+			}
+
+			if x == nil {
+				x, y = px, py
+				continue
+			}
+			x, y = round.curve.Add(x, y, px, py)
+		}
+		pubShares[j] = []*big.Int{x, y}
+	}
+	round.Save.PubShares = pubShares
+
 	// add commitment for sku1
-	xiGx, xiGy := secp256k1.S256().ScalarBaseMult(skU1.Bytes())
+	xiGx, xiGy := round.curve.ScalarBaseMult(skU1.Bytes())
 	u1Secrets := make([]*big.Int, 0)
 	u1Secrets = append(u1Secrets, xiGx)
 	u1Secrets = append(u1Secrets, xiGy)
@@ -171,21 +176,31 @@ func (round *round4) Start() error {
 	//
 
 	// zk of paillier key
-	NtildeLength := 2048
-	u1NtildeH1H2, alpha, beta, p, q,p1,p2 := ec2.GenerateNtildeH1H2(NtildeLength)
-	if u1NtildeH1H2 == nil {
-		return errors.New("gen ntilde h1 h2 fail")
+	// the length of ntilde is the same as paillier N
+	var u1NtildeH1H2 *ec2.NtildeH1H2
+	var ntildeProof1,ntildeProof2 *ec2.NtildeProof
+	if pre := round.temp.preParams; pre != nil {
+	    u1NtildeH1H2 = pre.NtildeH1H2
+	    round.Save.U1NtildePrivData = pre.NtildePriv
+	    round.temp.p1,round.temp.p2 = pre.NtildePrimes()
+	    ntildeProof1,ntildeProof2 = pre.NtildeProof1,pre.NtildeProof2
+	} else {
+	    var alpha,beta,p,q,p1,p2 *big.Int
+	    u1NtildeH1H2, alpha, beta, p, q,p1,p2 = ec2.GenerateNtildeH1H2(round.paillierkeylength)
+	    if u1NtildeH1H2 == nil {
+		    return errors.New("gen ntilde h1 h2 fail")
+	    }
+
+	    priv := &ec2.NtildePrivData{Alpha:alpha,Beta:beta,Q1:p,Q2:q}
+	    round.Save.U1NtildePrivData = priv
+
+	    round.temp.p1 = p1
+	    round.temp.p2 = p2
+
+	    ntildeProof1 = ec2.NewNtildeProof(u1NtildeH1H2.H1, u1NtildeH1H2.H2, alpha, p, q, u1NtildeH1H2.Ntilde)
+	    ntildeProof2 = ec2.NewNtildeProof(u1NtildeH1H2.H2, u1NtildeH1H2.H1, beta, p, q, u1NtildeH1H2.Ntilde)
 	}
 
-	priv := &ec2.NtildePrivData{Alpha:alpha,Beta:beta,Q1:p,Q2:q}
-	round.Save.U1NtildePrivData = priv
-
-	round.temp.p1 = p1
-	round.temp.p2 = p2
-
-	ntildeProof1 := ec2.NewNtildeProof(u1NtildeH1H2.H1, u1NtildeH1H2.H2, alpha, p, q, u1NtildeH1H2.Ntilde)
-	ntildeProof2 := ec2.NewNtildeProof(u1NtildeH1H2.H2, u1NtildeH1H2.H1, beta, p, q, u1NtildeH1H2.Ntilde)
-
 	kg := &KGRound4Message{
 		KGRoundMessage: new(KGRoundMessage),
 		U1NtildeH1H2:   u1NtildeH1H2,
@@ -24,10 +24,6 @@ import (
 	"fmt"
 )
 
-const (
-	ntildeBitsLen = 2048
-)
-
 // Start check ntilde bitlen/add HVZK Proof for a Product of Two Primes ...
 func (round *round5) Start() error {
 	if round.started {
@@ -54,7 +50,7 @@ func (round *round5) Start() error {
 			return errors.New("error kg round4 message")
 		}
 
-		if ntilde.Ntilde.BitLen() != ntildeBitsLen {
+		if ntilde.Ntilde.BitLen() < ec2.MinNtildeLength || ntilde.Ntilde.BitLen() != round.paillierkeylength {
 			return errors.New("got ntilde with not enough bits")
 		}
 	}
@@ -62,16 +58,26 @@ func (round *round5) Start() error {
 
 	// add for GG20: In keygen phase 3, each player Pi need to proves in ZK that Ni is square-free using the proof of Gennaro, Micciancio, and Rabin [30].Similarly, it needs to prove it for ntilde.
 	// An Efficient Non-Interactive Statistical Zero-Knowledge Proof System for Quasi-Safe Prime Products, section 3.1
+	// the proofs made with the pre params are used if there are
 	ntilde := round.temp.kgRound4Messages[curIndex].(*KGRound4Message).U1NtildeH1H2.Ntilde
-	num := ec2.MustGetRandomInt(ntilde.BitLen())
-	if num == nil {
-	    return errors.New("get random int fail")
+	var num *big.Int
+	var sfProof *ec2.SquareFreeProof
+	if pre := round.temp.preParams; pre != nil {
+	    num,sfProof = pre.NtildeSquareFreeProof()
+	}
+
+	if sfProof == nil {
+	    num = ec2.MustGetRandomInt(ntilde.BitLen())
+	    if num == nil {
+		return errors.New("get random int fail")
+	    }
+
+	    pMinus1 := new(big.Int).Sub(round.temp.p1, big.NewInt(1))
+	    qMinus1 := new(big.Int).Sub(round.temp.p2, big.NewInt(1))
+	    l := new(big.Int).Mul(pMinus1, qMinus1)
+	    sfProof = ec2.SquareFreeProve(ntilde,num,l)
 	}
 
-	pMinus1 := new(big.Int).Sub(round.temp.p1, big.NewInt(1))
-	qMinus1 := new(big.Int).Sub(round.temp.p2, big.NewInt(1))
-	l := new(big.Int).Mul(pMinus1, qMinus1)
-	sfProof := ec2.SquareFreeProve(ntilde,num,l)
 	if sfProof == nil {
 	    return errors.New("get square free proof fail")
 	}
@@ -90,13 +96,21 @@ func (round *round5) Start() error {
 	// see Paper:   Attacking Threshold Wallets*   JP Aumasson and Omer Shlomovits   Taurus Group, Switzerland   ZenGo X, Israel   section 5  The Golden Shoe Attack
 	// Mitigation: The fix is simple: Ntilde,h1,h2 must be validated on the receiving end.For Ntilde,the sender must attach a proof that Ntilde is a valid RSA modulus from two safe primes.For h1,h2, there is a nice trick in [FO97]: pick h1 at random and h2 = h1^alpha and prove to the receiver the knowledge of alpha with respect to h1, h2.
 	// see Paper : Efficient Noninteractive Certification of RSA Moduli and Beyond   Sharon Goldberg*, Leonid Reyzin*, Omar Sagga*, and Foteini Baldimtsi      Boston University, Boston, MA, USA  George Mason University, Fairfax, VA, USA foteini@gmu.edu   October 3, 2019     section 3.4  HVZK Proof for a Product of Two Primes
-	num = ec2.MustGetRandomInt(ntilde.BitLen())
-	if num == nil {
-	    return errors.New("get random int fail")
+	var hvProof *ec2.HvProof
+	if pre := round.temp.preParams; pre != nil {
+	    num,hvProof = pre.NtildeHvProof()
+	}
+
+	if hvProof == nil {
+	    num = ec2.MustGetRandomInt(ntilde.BitLen())
+	    if num == nil {
+		return errors.New("get random int fail")
+	    }
+
+	    //fmt.Printf("===========================keygen round 5, get num = %v for ntilde = %v==========================\n",num,ntilde)
+	    hvProof = ec2.HvProve(ntilde,num,round.temp.p1,round.temp.p2)
 	}
 
-	//fmt.Printf("===========================keygen round 5, get num = %v for ntilde = %v==========================\n",num,ntilde)
-	hvProof := ec2.HvProve(ntilde,num,round.temp.p1,round.temp.p2)
 	if hvProof == nil {
 		fmt.Printf("===========================keygen round 5, get hvzk proof fail==========================\n")
 	    return errors.New("get hvzk proof fail")
@@ -113,6 +127,41 @@ func (round *round5) Start() error {
 	round.temp.kgRound5Messages1[curIndex] = srm2
 	round.out <- srm2
 
+	// add for CGGMP21: prove that paillier N is a paillier-blum modulus and has no small factor, the no small factor proof use the ntilde of every receiver
+	// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts, section 6.3 and appendix C.5
+	// the challenges are bound to the session,the prover and the round,so the prover can not choose them
+	ids, err := round.GetIDs()
+	if err != nil {
+	    return err
+	}
+
+	ctx := round.proofContext(ids[curIndex], round.number)
+	paiN := round.Save.U1PaillierSk.N
+	modProof := ec2.PaillierBlumProve(ctx,paiN,round.temp.p,round.temp.q)
+	if modProof == nil {
+	    return errors.New("get paillier-blum modulus proof fail")
+	}
+
+	facProof := make([]*ec2.NoSmallFactorProof,len(round.temp.kgRound4Messages))
+	for k,msg := range round.temp.kgRound4Messages {
+	    m := msg.(*KGRound4Message)
+	    facProof[k] = ec2.NoSmallFactorProve(ctx,paiN,round.temp.p,round.temp.q,m.U1NtildeH1H2)
+	    if facProof[k] == nil {
+		return errors.New("get no small factor proof fail")
+	    }
+	}
+
+	srm3 := &KGRound5Message3{
+		KGRoundMessage: new(KGRoundMessage),
+		ModPf:		modProof,
+		FacPf:		facProof,
+	}
+	srm3.SetFromID(round.dnodeid)
+	srm3.SetFromIndex(curIndex)
+
+	round.temp.kgRound5Messages3[curIndex] = srm3
+	round.out <- srm3
+
 	kg := &KGRound5Message{
 		KGRoundMessage: new(KGRoundMessage),
 		ComXiGD:	round.temp.commitXiG.D,
@@ -142,6 +191,10 @@ func (round *round5) CanAccept(msg smpc.Message) bool {
 		return msg.IsBroadcast()
 	}
 	
+	if _, ok := msg.(*KGRound5Message3); ok {
+		return msg.IsBroadcast()
+	}
+	
 	return false
 }
 
@@ -164,6 +217,11 @@ func (round *round5) Update() (bool, error) {
 		if msg52 == nil || !round.CanAccept(msg52) {
 			return false, nil
 		}
+		
+		msg53 := round.temp.kgRound5Messages3[j]
+		if msg53 == nil || !round.CanAccept(msg53) {
+			return false, nil
+		}
 		round.ok[j] = true
 	}
 	
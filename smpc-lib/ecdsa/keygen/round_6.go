@@ -42,7 +42,7 @@ func (round *round6) Start() error {
 		return err
 	}
 
-	for k := range ids {
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
 		msg5, ok := round.temp.kgRound5Messages[k].(*KGRound5Message)
 		if !ok {
 			return errors.New("round.Start get round5 msg fail")
@@ -54,15 +54,20 @@ func (round *round6) Start() error {
 		}
 
 		deCommit := &ec2.Commitment{C: msg4.ComXiC, D: msg5.ComXiGD}
-		if !deCommit.Verify() {
+		if !deCommit.Verify(round.curve) {
 			fmt.Printf("========= round6 verify commitment fail, k = %v ==========\n", k)
-			return errors.New("verify commitment fail")
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "XiGCommitment", errors.New("verify commitment fail"))
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// add for GG20: In keygen phase 3, each player Pi need to proves in ZK that Ni is square-free using the proof of Gennaro, Micciancio, and Rabin [30].Similarly, it needs to prove it for ntilde.
 	// An Efficient Non-Interactive Statistical Zero-Knowledge Proof System for Quasi-Safe Prime Products, section 3.1
-	for k := range ids {
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
 	    msg4, ok := round.temp.kgRound4Messages[k].(*KGRound4Message)
 	    if !ok {
 		return errors.New("round.Start get round4 msg fail")
@@ -80,14 +85,19 @@ func (round *round6) Start() error {
 
 	    if !ec2.SquareFreeVerify(ntilde,msg52.Num,msg52.SfPf) {
 		fmt.Printf("==========================keygen round6,check that a zero-knowledge proof that ntilde is a square-free integer fail, k = %v,id = %v============================\n",k,ids[k])
-		return errors.New("check that a zero-knowledge proof that ntilde is a square-free integer fail")
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "SquareFreeProof", errors.New("check that a zero-knowledge proof that ntilde is a square-free integer fail"))
 	    }
+
+	    return nil
+	})
+	if err != nil {
+	    return err
 	}
 
 	// see Paper:   Attacking Threshold Wallets*   JP Aumasson and Omer Shlomovits   Taurus Group, Switzerland   ZenGo X, Israel   section 5  The Golden Shoe Attack
 	// Mitigation: The fix is simple: Ntilde,h1,h2 must be validated on the receiving end.For Ntilde,the sender must attach a proof that Ntilde is a valid RSA modulus from two safe primes.For h1,h2, there is a nice trick in [FO97]: pick h1 at random and h2 = h1^alpha and prove to the receiver the knowledge of alpha with respect to h1, h2.
 	// see Paper : Efficient Noninteractive Certification of RSA Moduli and Beyond   Sharon Goldberg*, Leonid Reyzin*, Omar Sagga*, and Foteini Baldimtsi      Boston University, Boston, MA, USA  George Mason University, Fairfax, VA, USA foteini@gmu.edu   October 3, 2019     section 3.4  HVZK Proof for a Product of Two Primes
-	for k,_ := range ids {
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
 	    msg4,ok := round.temp.kgRound4Messages[k].(*KGRound4Message)
 	    if !ok {
 		return errors.New("round.Start get round 4 msg fail")
@@ -104,16 +114,59 @@ func (round *round6) Start() error {
 	    
 	    if !ec2.HvVerify(ntilde,msg51.Num,msg51.HvPf) {
 		fmt.Printf("==========================keygen round6,check that a zero-knowledge proof that ntilde is a valid RSA modulus from two safe primes fail, k = %v,id = %v============================\n",k,ids[k])
-		return errors.New("check that a zero-knowledge proof that ntilde is a valid RSA modulus from two safe primes fail")
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "HvProof", errors.New("check that a zero-knowledge proof that ntilde is a valid RSA modulus from two safe primes fail"))
 	    }
+
+	    return nil
+	})
+	if err != nil {
+	    return err
+	}
+
+	// add for CGGMP21: check the paillier-blum modulus proof and the no small factor proof of every paillier N, the no small factor proof is checked with our own ntilde
+	// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts, section 6.3 and appendix C.5
+	msg4, ok := round.temp.kgRound4Messages[curIndex].(*KGRound4Message)
+	if !ok {
+	    return errors.New("round.Start get round 4 msg fail")
+	}
+
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
+	    msg1, ok := round.temp.kgRound1Messages[k].(*KGRound1Message)
+	    if !ok || msg1.U1PaillierPk == nil {
+		return errors.New("round.Start get round 1 msg fail")
+	    }
+
+	    msg53, ok := round.temp.kgRound5Messages3[k].(*KGRound5Message3)
+	    if !ok {
+		return errors.New("round.Start get round 5-3 msg fail")
+	    }
+
+	    ctx := round.proofContext(ids[k], 5)
+	    if !ec2.PaillierBlumVerify(ctx,msg1.U1PaillierPk.N,msg53.ModPf) {
+		fmt.Printf("==========================keygen round6,check that a zero-knowledge proof that paillier N is a paillier-blum modulus fail, k = %v,id = %v============================\n",k,ids[k])
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "PaillierBlumProof", errors.New("check that a zero-knowledge proof that paillier N is a paillier-blum modulus fail"))
+	    }
+
+	    if len(msg53.FacPf) != len(ids) || !ec2.NoSmallFactorVerify(ctx,msg1.U1PaillierPk.N,msg4.U1NtildeH1H2,msg53.FacPf[curIndex]) {
+		fmt.Printf("==========================keygen round6,check that a zero-knowledge proof that paillier N has no small factor fail, k = %v,id = %v============================\n",k,ids[k])
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "NoSmallFactorProof", errors.New("check that a zero-knowledge proof that paillier N has no small factor fail"))
+	    }
+
+	    return nil
+	})
+	if err != nil {
+	    return err
 	}
 	///////////
 
 	round.temp.p1 = nil
 	round.temp.p2 = nil 
+	round.temp.p = nil
+	round.temp.q = nil
+	round.temp.preParams = nil
 
 	// add prove for xi 
-	u1zkXiProof := ec2.ZkXiProve(round.Save.SkU1)
+	u1zkXiProof := ec2.ZkXiProve(round.curve, round.proofContext(ids[curIndex], round.number), round.Save.SkU1)
 	if u1zkXiProof == nil {
 		return errors.New("zkx prove fail")
 	}
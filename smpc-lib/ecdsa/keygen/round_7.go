@@ -49,16 +49,17 @@ func (round *round7) Start() error {
 		}
 
 		deCommit := &ec2.Commitment{C: msg4.ComXiC, D: msg5.ComXiGD}
-		_, xiG := deCommit.DeCommit()
+		_, xiG := deCommit.DeCommit(round.curve)
 
 		msg6, ok := round.temp.kgRound6Messages[k].(*KGRound6Message)
 		if !ok {
 			return errors.New("round.Start get round6 msg fail")
 		}
 
-		if !ec2.ZkXiVerify(xiG, msg6.U1zkXiProof) {
+		// the proof is made in round 6 by party k
+		if !ec2.ZkXiVerify(round.curve, round.proofContext(ids[k], 6), xiG, msg6.U1zkXiProof) {
 			fmt.Printf("========= round7 verify zkx fail, k = %v ==========\n", k)
-			return errors.New("verify zkx fail")
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(ids[k]), round.number, "ZkXiProof", errors.New("verify zkx fail"))
 		}
 	}
 
@@ -17,12 +17,15 @@
 package keygen
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
 	"sort"
 	"encoding/hex"
+	"time"
 )
 
 type (
@@ -38,6 +41,8 @@ type (
 		dnodecount        int
 		threshold         int
 		paillierkeylength int
+		curve             elliptic.Curve
+		sessionkey        string
 	}
 	round0 struct {
 		*base
@@ -51,6 +56,15 @@ type (
 	round3 struct {
 		*round2
 	}
+	// complaintRound and justifyRound are the sub-rounds of round 3 that resolve the invalid vss shares
+	complaintRound struct {
+		*round3
+	}
+	justifyRound struct {
+		*complaintRound
+		start    time.Time // when the round started,Timeout measures the wait from it
+		timedout bool      // the round gave up waiting,the dealers that have not revealed the shares are faulty
+	}
 	round4 struct {
 		*round3
 	}
@@ -85,6 +99,11 @@ func (round *base) CanProceed() bool {
 	return true
 }
 
+// proofContext get the context of the zk proof made by the party id in round number
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
 // GetIDs get uid with *big.Int format
 func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
 	var ids smpc.SortableIDSSlice
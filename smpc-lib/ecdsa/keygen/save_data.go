@@ -39,6 +39,12 @@ type LocalDNodeSaveData struct {
 
 	IDs        smpc.SortableIDSSlice
 	CurDNodeID *big.Int
+
+	// the public shares sku1*G of the nodes in the order of IDs,nil if unknown
+	PubShares [][]*big.Int
+
+	// the nodes marked faulty by the complaint round,they are reported as blames and not saved to local db
+	Faulty []*smpc.Blame
 }
 
 // NewLocalDNodeSaveData init a LocalDNodeSaveData data struct
@@ -110,6 +116,17 @@ func (sd *LocalDNodeSaveData) OutMap() map[string]string {
 
 	sdout["CurDNodeID"] = fmt.Sprintf("%v", sd.CurDNodeID)
 
+	if len(sd.PubShares) != 0 {
+		pubshares := make([]string, len(sd.PubShares))
+		for k, v := range sd.PubShares {
+			if len(v) != 2 {
+				return nil
+			}
+			pubshares[k] = fmt.Sprintf("%v:%v", v[0], v[1])
+		}
+		sdout["PubShares"] = strings.Join(pubshares, "|")
+	}
+
 	return sdout
 }
 
@@ -164,7 +181,27 @@ func GetLocalDNodeSaveData(data map[string]string) *LocalDNodeSaveData {
 
 	curdnodeid, _ := new(big.Int).SetString(data["CurDNodeID"], 10)
 
-	sd := &LocalDNodeSaveData{Pkx: pkx, Pky: pky, C: c, SkU1: sku1, U1PaillierSk: usk, U1PaillierPk: pk, U1NtildePrivData:ntildepriv, U1NtildeH1H2: nt, IDs: ids, CurDNodeID: curdnodeid}
+	// the data saved before the public shares were added has none
+	var pubshares [][]*big.Int
+	if data["PubShares"] != "" {
+		pstmp := strings.Split(data["PubShares"], "|")
+		pubshares = make([][]*big.Int, len(pstmp))
+		for k, v := range pstmp {
+			xy := strings.Split(v, ":")
+			if len(xy) != 2 {
+				return nil
+			}
+
+			x, ok1 := new(big.Int).SetString(xy[0], 10)
+			y, ok2 := new(big.Int).SetString(xy[1], 10)
+			if !ok1 || !ok2 {
+				return nil
+			}
+			pubshares[k] = []*big.Int{x, y}
+		}
+	}
+
+	sd := &LocalDNodeSaveData{Pkx: pkx, Pky: pky, C: c, SkU1: sku1, U1PaillierSk: usk, U1PaillierPk: pk, U1NtildePrivData:ntildepriv, U1NtildeH1H2: nt, IDs: ids, CurDNodeID: curdnodeid, PubShares: pubshares}
 	return sd
 }
 
@@ -0,0 +1,231 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package presign MPC implementation of the ecdsa presign of the three-round CGGMP21 protocol.
+// It is not the full CGGMP21 protocol:
+// there is no key refresh/aux-info phase,the paillier key and ntilde (ring-pedersen parameters) of every party and their proofs are made once by ecdsa keygen or reshare;
+// the presign data has the same format as GG20,so the online sign is done by the finalize rounds of ecdsa signing instead of the one-round sign of CGGMP21;
+// a failed proof aborts the presign and blames the sender,but a wrong delta_i only aborts it: the paillier decryption proofs that identify the sender are not implemented.
+package presign
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/log"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp   localTempData
+	save   *keygen.LocalDNodeSaveData
+	idsign smpc.SortableIDSSlice
+	out    chan<- smpc.Message
+	end    chan<- signing.PrePubData
+	curve  elliptic.Curve
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	preRound1Messages,
+	preRound1Messages1,
+	preRound2Messages,
+	preRound3Messages []smpc.Message
+
+	// temp data (thrown away after presign)
+
+	//round 1
+	w     *big.Int
+	k     *big.Int
+	gamma *big.Int
+	rho   *big.Int // K = enc(k,rho)
+	nu    *big.Int // G = enc(gamma,nu)
+	bigK  *big.Int
+	bigG  *big.Int
+
+	//round 2
+	gammaX  *big.Int
+	gammaY  *big.Int
+	beta    []*big.Int
+	betaHat []*big.Int
+
+	//round 3
+	bigGammaX *big.Int
+	bigGammaY *big.Int
+	delta     *big.Int
+	chi       *big.Int
+}
+
+// NewLocalDNode new a DNode data struct for current node
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- signing.PrePubData,
+	save *keygen.LocalDNodeSaveData,
+	idsign smpc.SortableIDSSlice,
+	kgid *big.Int,
+	threshold int,
+	paillierkeylength int,
+	keytype string,
+) smpc.DNode {
+
+	p := &LocalDNode{
+		BaseDNode: new(smpc.BaseDNode),
+		save:      save,
+		idsign:    idsign,
+		temp:      localTempData{},
+		out:       out,
+		end:       end,
+		curve:     ec2.GetCurve(keytype),
+	}
+
+	p.ID = fmt.Sprintf("%v", kgid)
+
+	p.ThresHold = threshold
+	p.PaillierKeyLength = paillierkeylength
+
+	p.temp.preRound1Messages = make([]smpc.Message, threshold)
+	p.temp.preRound1Messages1 = make([]smpc.Message, threshold)
+	p.temp.preRound2Messages = make([]smpc.Message, threshold)
+	p.temp.preRound3Messages = make([]smpc.Message, threshold)
+	return p
+}
+
+// FinalizeRound the online sign is done by ecdsa signing
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.PaillierKeyLength, p.curve, p.SessionKey)
+}
+
+// Start presign start
+func (p *LocalDNode) Start() error {
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// Finalize presign only
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *PreSignRound1Message:
+		return find(p.temp.preRound1Messages, msg)
+	case *PreSignRound1Message1:
+		return find(p.temp.preRound1Messages1, msg)
+	case *PreSignRound2Message:
+		return find(p.temp.preRound2Messages, msg)
+	case *PreSignRound3Message:
+		return find(p.temp.preRound3Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	var l []smpc.Message
+	switch msg.(type) {
+	case *PreSignRound1Message:
+		l = p.temp.preRound1Messages
+	case *PreSignRound1Message1:
+		l = p.temp.preRound1Messages1
+	case *PreSignRound2Message:
+		l = p.temp.preRound2Messages
+	case *PreSignRound3Message:
+		l = p.temp.preRound3Messages
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+
+	if find(l, msg) {
+		return false, nil
+	}
+
+	index := msg.GetFromIndex()
+	if index < 0 || index >= len(l) {
+		return false, fmt.Errorf("error msg from index %v", index)
+	}
+
+	l[index] = msg
+	if len(l) == p.ThresHold && CheckFull(l) {
+		log.Debug("================ StoreMessage,get all presign messages ==============", "msg type", msg.GetMsgType())
+		return true, nil
+	}
+
+	return false, nil
+}
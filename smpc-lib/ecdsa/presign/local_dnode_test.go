@@ -0,0 +1,48 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package presign_test test MPC implementation of the three-round ecdsa presign
+package presign_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/presign"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	msgs := make([]smpc.Message, 0)
+	assert.False(t, presign.CheckFull(msgs), "fail")
+
+	for i := 0; i < 3; i++ {
+		prm := &presign.PreSignRound1Message{
+			PreSignRoundMessage: new(presign.PreSignRoundMessage),
+			K:                   big.NewInt(int64(i + 1)),
+			G:                   big.NewInt(int64(i + 2)),
+		}
+		prm.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		prm.SetFromIndex(i)
+		msgs = append(msgs, prm)
+	}
+
+	assert.True(t, presign.CheckFull(msgs), "success")
+
+	msgs[1] = nil
+	assert.False(t, presign.CheckFull(msgs), "fail")
+}
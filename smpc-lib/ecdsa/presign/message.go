@@ -0,0 +1,208 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&PreSignRound1Message{},
+		&PreSignRound1Message1{},
+		&PreSignRound2Message{},
+		&PreSignRound3Message{},
+	)
+}
+
+// PreSignRoundMessage base type of presign round message
+type PreSignRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (prm *PreSignRoundMessage) SetFromID(id string) {
+	prm.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (prm *PreSignRoundMessage) SetFromIndex(index int) {
+	prm.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (prm *PreSignRoundMessage) AppendToID(toid string) {
+	prm.ToID = append(prm.ToID, toid)
+}
+
+//-----------------------------------------------------------------------
+
+// PreSignRound1Message  Round 1 sending message,K = enc(k),G = enc(gamma)
+type PreSignRound1Message struct {
+	*PreSignRoundMessage
+
+	K *big.Int
+	G *big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (prm *PreSignRound1Message) GetFromID() string {
+	return prm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (prm *PreSignRound1Message) GetFromIndex() int {
+	return prm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (prm *PreSignRound1Message) GetToID() []string {
+	return prm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (prm *PreSignRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (prm *PreSignRound1Message) GetMsgType() string {
+	return "PreSignRound1Message"
+}
+
+//-----------------------------------------------------------------------
+
+// PreSignRound1Message1  Round 1 sending p2p message,proof that K encrypt a value in range
+type PreSignRound1Message1 struct {
+	*PreSignRoundMessage
+
+	EncPf *ec2.EncRangeProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (prm *PreSignRound1Message1) GetFromID() string {
+	return prm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (prm *PreSignRound1Message1) GetFromIndex() int {
+	return prm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (prm *PreSignRound1Message1) GetToID() []string {
+	return prm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (prm *PreSignRound1Message1) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (prm *PreSignRound1Message1) GetMsgType() string {
+	return "PreSignRound1Message1"
+}
+
+//-----------------------------------------------------------------------
+
+// PreSignRound2Message  Round 2 sending p2p message
+// Gamma = gamma*G, W = w*G, D = gamma*K + enc(beta), F = enc(beta), DHat = w*K + enc(betaHat), FHat = enc(betaHat)
+type PreSignRound2Message struct {
+	*PreSignRoundMessage
+
+	GammaX *big.Int
+	GammaY *big.Int
+	WX     *big.Int
+	WY     *big.Int
+
+	D    *big.Int
+	F    *big.Int
+	DHat *big.Int
+	FHat *big.Int
+
+	AffgPf    *ec2.AffGProof
+	AffgHatPf *ec2.AffGProof
+	LogStarPf *ec2.LogStarProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (prm *PreSignRound2Message) GetFromID() string {
+	return prm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (prm *PreSignRound2Message) GetFromIndex() int {
+	return prm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (prm *PreSignRound2Message) GetToID() []string {
+	return prm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (prm *PreSignRound2Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (prm *PreSignRound2Message) GetMsgType() string {
+	return "PreSignRound2Message"
+}
+
+//-----------------------------------------------------------------------
+
+// PreSignRound3Message  Round 3 sending p2p message,Delta = delta_i,BigDelta = k*Gamma
+type PreSignRound3Message struct {
+	*PreSignRoundMessage
+
+	Delta     *big.Int
+	BigDeltaX *big.Int
+	BigDeltaY *big.Int
+	LogStarPf *ec2.LogStarProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (prm *PreSignRound3Message) GetFromID() string {
+	return prm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (prm *PreSignRound3Message) GetFromIndex() int {
+	return prm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (prm *PreSignRound3Message) GetToID() []string {
+	return prm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (prm *PreSignRound3Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (prm *PreSignRound3Message) GetMsgType() string {
+	return "PreSignRound3Message"
+}
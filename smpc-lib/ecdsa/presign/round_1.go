@@ -0,0 +1,183 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- signing.PrePubData, kgid string, threshold int, paillierkeylength int, curve elliptic.Curve, sessionkey string) smpc.Round {
+	return &round1{
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, paillierkeylength, curve, sessionkey}}
+}
+
+// Start calc w = lambda*sku1,sample k and gamma,broadcast K = enc(k) and G = enc(gamma) and send the proof that K is in range to every signer
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= presign round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	if err := round.CheckPaillierKeyLength(); err != nil {
+		return err
+	}
+
+	if round.save.U1PaillierSk == nil {
+		return errors.New("error paillier sk for current node")
+	}
+
+	order := round.curve.Params().N
+	self := round.idsign[curIndex]
+	lambda1 := big.NewInt(1)
+	for k, v := range round.idsign {
+		if k == curIndex {
+			continue
+		}
+
+		sub := new(big.Int).Sub(v, self)
+		subInverse := new(big.Int).ModInverse(sub, order)
+		if subInverse == nil {
+			return errors.New("calc times fail")
+		}
+
+		times := new(big.Int).Mul(subInverse, v)
+		lambda1 = new(big.Int).Mul(lambda1, times)
+		lambda1 = new(big.Int).Mod(lambda1, order)
+	}
+	w := new(big.Int).Mul(lambda1, round.save.SkU1)
+	round.temp.w = new(big.Int).Mod(w, order)
+
+	paiPk, err := round.GetPaillierPk(curIndex)
+	if err != nil {
+		return err
+	}
+
+	k := random.GetRandomIntFromZn(order)
+	gamma := random.GetRandomIntFromZn(order)
+	rho := ec2.GetRandomPositiveRelativelyPrimeInt(paiPk.N)
+	nu := ec2.GetRandomPositiveRelativelyPrimeInt(paiPk.N)
+	if k == nil || gamma == nil || rho == nil || nu == nil {
+		return errors.New("get random int fail")
+	}
+
+	bigK := ec2.PaillierEncrypt(paiPk, k, rho)
+	bigG := ec2.PaillierEncrypt(paiPk, gamma, nu)
+	if bigK == nil || bigG == nil {
+		return errors.New("paillier encrypt fail")
+	}
+
+	round.temp.k = k
+	round.temp.gamma = gamma
+	round.temp.rho = rho
+	round.temp.nu = nu
+	round.temp.bigK = bigK
+	round.temp.bigG = bigG
+
+	prm := &PreSignRound1Message{
+		PreSignRoundMessage: new(PreSignRoundMessage),
+		K:                   bigK,
+		G:                   bigG,
+	}
+	prm.SetFromID(round.kgid)
+	prm.SetFromIndex(curIndex)
+
+	round.temp.preRound1Messages[curIndex] = prm
+	round.out <- prm
+
+	for j := range round.idsign {
+		prm1 := &PreSignRound1Message1{
+			PreSignRoundMessage: new(PreSignRoundMessage),
+		}
+		prm1.SetFromID(round.kgid)
+		prm1.SetFromIndex(curIndex)
+
+		if j == curIndex {
+			round.temp.preRound1Messages1[curIndex] = prm1
+			continue
+		}
+
+		nt, err := round.GetNtilde(j)
+		if err != nil {
+			return err
+		}
+
+		prm1.EncPf = ec2.EncRangeProve(round.curve, round.proofContext(round.idsign[curIndex], round.number), paiPk, nt, bigK, k, rho)
+		if prm1.EncPf == nil {
+			return errors.New("get paillier encryption in range proof fail")
+		}
+
+		prm1.AppendToID(round.toDNodeID(j))
+		round.out <- prm1
+	}
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*PreSignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	if _, ok := msg.(*PreSignRound1Message1); ok {
+		return !msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	for j, msg := range round.temp.preRound1Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+
+		msg1 := round.temp.preRound1Messages1[j]
+		if msg1 == nil || !round.CanAccept(msg1) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,181 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+// Start verify the range proof of K,send gamma*K + enc(beta),w*K + enc(betaHat) with the affine operation proofs to every signer
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= presign round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	paiPk, err := round.GetPaillierPk(curIndex)
+	if err != nil {
+		return err
+	}
+
+	nt, err := round.GetNtilde(curIndex)
+	if err != nil {
+		return err
+	}
+
+	// check that K_j encrypt a value in range
+	for j := range round.idsign {
+		if j == curIndex {
+			continue
+		}
+
+		msg1, ok := round.temp.preRound1Messages[j].(*PreSignRound1Message)
+		if !ok {
+			return errors.New("get presign round 1 msg fail")
+		}
+
+		msg11, ok := round.temp.preRound1Messages1[j].(*PreSignRound1Message1)
+		if !ok {
+			return errors.New("get presign round 1-1 msg fail")
+		}
+
+		pk, err := round.GetPaillierPk(j)
+		if err != nil {
+			return err
+		}
+
+		if !ec2.EncRangeVerify(round.curve, round.proofContext(round.idsign[j], 1), pk, nt, msg1.K, msg11.EncPf) {
+			return smpc.NewBlameError(msg11.GetFromID(), round.number, "EncRangeProof", errors.New("verify paillier encryption in range proof fail"))
+		}
+	}
+
+	order := round.curve.Params().N
+	gammaX, gammaY := round.curve.ScalarBaseMult(round.temp.gamma.Bytes())
+	wX, wY := round.curve.ScalarBaseMult(round.temp.w.Bytes())
+	round.temp.gammaX = gammaX
+	round.temp.gammaY = gammaY
+
+	betaBound := new(big.Int).Lsh(big.NewInt(1), ec2.CGGMPLPrime)
+	round.temp.beta = make([]*big.Int, len(round.idsign))
+	round.temp.betaHat = make([]*big.Int, len(round.idsign))
+
+	for j := range round.idsign {
+		prm := &PreSignRound2Message{
+			PreSignRoundMessage: new(PreSignRoundMessage),
+			GammaX:              gammaX,
+			GammaY:              gammaY,
+			WX:                  wX,
+			WY:                  wY,
+		}
+		prm.SetFromID(round.kgid)
+		prm.SetFromIndex(curIndex)
+
+		if j == curIndex {
+			round.temp.preRound2Messages[curIndex] = prm
+			continue
+		}
+
+		msg1, _ := round.temp.preRound1Messages[j].(*PreSignRound1Message)
+		pkj, err := round.GetPaillierPk(j)
+		if err != nil {
+			return err
+		}
+
+		ntj, err := round.GetNtilde(j)
+		if err != nil {
+			return err
+		}
+
+		beta := ec2.GetRandomPositiveInt(betaBound)
+		betaHat := ec2.GetRandomPositiveInt(betaBound)
+		s := ec2.GetRandomPositiveRelativelyPrimeInt(pkj.N)
+		r := ec2.GetRandomPositiveRelativelyPrimeInt(paiPk.N)
+		sHat := ec2.GetRandomPositiveRelativelyPrimeInt(pkj.N)
+		rHat := ec2.GetRandomPositiveRelativelyPrimeInt(paiPk.N)
+		if beta == nil || betaHat == nil || s == nil || r == nil || sHat == nil || rHat == nil {
+			return errors.New("get random int fail")
+		}
+
+		// D = K^gamma * enc_j(beta), F = enc_i(beta)
+		prm.D = ec2.PaillierAffine(pkj, msg1.K, round.temp.gamma, beta, s)
+		prm.F = ec2.PaillierEncrypt(paiPk, beta, r)
+		prm.DHat = ec2.PaillierAffine(pkj, msg1.K, round.temp.w, betaHat, sHat)
+		prm.FHat = ec2.PaillierEncrypt(paiPk, betaHat, rHat)
+		if prm.D == nil || prm.F == nil || prm.DHat == nil || prm.FHat == nil {
+			return errors.New("paillier affine operation fail")
+		}
+
+		ctx := round.proofContext(round.idsign[curIndex], round.number)
+		prm.AffgPf = ec2.AffGProve(round.curve, ctx, pkj, paiPk, ntj, msg1.K, prm.D, prm.F, gammaX, gammaY, round.temp.gamma, beta, s, r)
+		prm.AffgHatPf = ec2.AffGProve(round.curve, ctx, pkj, paiPk, ntj, msg1.K, prm.DHat, prm.FHat, wX, wY, round.temp.w, betaHat, sHat, rHat)
+		prm.LogStarPf = ec2.LogStarProve(round.curve, ctx, paiPk, ntj, round.temp.bigG, round.curve.Params().Gx, round.curve.Params().Gy, gammaX, gammaY, round.temp.gamma, round.temp.nu)
+		if prm.AffgPf == nil || prm.AffgHatPf == nil || prm.LogStarPf == nil {
+			return errors.New("get presign round 2 proof fail")
+		}
+
+		// the signer j get alpha = k_j*gamma + beta,so the share of current node is -beta
+		round.temp.beta[j] = new(big.Int).Mod(new(big.Int).Neg(beta), order)
+		round.temp.betaHat[j] = new(big.Int).Mod(new(big.Int).Neg(betaHat), order)
+
+		prm.AppendToID(round.toDNodeID(j))
+		round.out <- prm
+	}
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*PreSignRound2Message); ok {
+		return !msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	for j, msg := range round.temp.preRound2Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
@@ -0,0 +1,192 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+// Start verify the affine operation proofs,calc delta = k*gamma + sum(alpha - beta),chi = k*w + sum(alphaHat - betaHat),Delta = k*Gamma and send them to every signer
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= presign round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	paiPk, err := round.GetPaillierPk(curIndex)
+	if err != nil {
+		return err
+	}
+
+	nt, err := round.GetNtilde(curIndex)
+	if err != nil {
+		return err
+	}
+
+	order := round.curve.Params().N
+	delta := new(big.Int).Mul(round.temp.k, round.temp.gamma)
+	chi := new(big.Int).Mul(round.temp.k, round.temp.w)
+
+	var gammaX, gammaY, wX, wY *big.Int
+	for j := range round.idsign {
+		msg2, ok := round.temp.preRound2Messages[j].(*PreSignRound2Message)
+		if !ok {
+			return errors.New("get presign round 2 msg fail")
+		}
+
+		if msg2.GammaX == nil || msg2.GammaY == nil || msg2.WX == nil || msg2.WY == nil || !round.curve.IsOnCurve(msg2.GammaX, msg2.GammaY) || !round.curve.IsOnCurve(msg2.WX, msg2.WY) {
+			return smpc.NewBlameError(msg2.GetFromID(), round.number, "Gamma", errors.New("error Gamma or W"))
+		}
+
+		if j == 0 {
+			gammaX, gammaY = msg2.GammaX, msg2.GammaY
+			wX, wY = msg2.WX, msg2.WY
+		} else {
+			gammaX, gammaY = round.curve.Add(gammaX, gammaY, msg2.GammaX, msg2.GammaY)
+			wX, wY = round.curve.Add(wX, wY, msg2.WX, msg2.WY)
+		}
+
+		if j == curIndex {
+			continue
+		}
+
+		msg1, ok := round.temp.preRound1Messages[j].(*PreSignRound1Message)
+		if !ok {
+			return errors.New("get presign round 1 msg fail")
+		}
+
+		pkj, err := round.GetPaillierPk(j)
+		if err != nil {
+			return err
+		}
+
+		ctx := round.proofContext(round.idsign[j], 2)
+		if !ec2.AffGVerify(round.curve, ctx, paiPk, pkj, nt, round.temp.bigK, msg2.D, msg2.F, msg2.GammaX, msg2.GammaY, msg2.AffgPf) {
+			return smpc.NewBlameError(msg2.GetFromID(), round.number, "AffGProof", errors.New("verify paillier affine operation with group commitment proof fail"))
+		}
+
+		if !ec2.AffGVerify(round.curve, ctx, paiPk, pkj, nt, round.temp.bigK, msg2.DHat, msg2.FHat, msg2.WX, msg2.WY, msg2.AffgHatPf) {
+			return smpc.NewBlameError(msg2.GetFromID(), round.number, "AffGProof", errors.New("verify paillier affine operation with group commitment proof fail"))
+		}
+
+		if !ec2.LogStarVerify(round.curve, ctx, pkj, nt, msg1.G, round.curve.Params().Gx, round.curve.Params().Gy, msg2.GammaX, msg2.GammaY, msg2.LogStarPf) {
+			return smpc.NewBlameError(msg2.GetFromID(), round.number, "LogStarProof", errors.New("verify knowledge of exponent vs paillier encryption proof fail"))
+		}
+
+		alpha, err := round.save.U1PaillierSk.Decrypt(msg2.D)
+		if err != nil {
+			return err
+		}
+
+		alphaHat, err := round.save.U1PaillierSk.Decrypt(msg2.DHat)
+		if err != nil {
+			return err
+		}
+
+		delta.Add(delta, alpha)
+		delta.Add(delta, round.temp.beta[j])
+		chi.Add(chi, alphaHat)
+		chi.Add(chi, round.temp.betaHat[j])
+	}
+
+	// sum(w_j*G) must be the pubkey,otherwise some signer use the wrong share
+	if wX.Cmp(round.save.Pkx) != 0 || wY.Cmp(round.save.Pky) != 0 {
+		return errors.New("the sum of W is not equal to the pubkey")
+	}
+
+	round.temp.bigGammaX = gammaX
+	round.temp.bigGammaY = gammaY
+	round.temp.delta = delta.Mod(delta, order)
+	round.temp.chi = chi.Mod(chi, order)
+	round.temp.beta = nil
+	round.temp.betaHat = nil
+
+	deltaX, deltaY := round.curve.ScalarMult(gammaX, gammaY, round.temp.k.Bytes())
+
+	for j := range round.idsign {
+		prm := &PreSignRound3Message{
+			PreSignRoundMessage: new(PreSignRoundMessage),
+			Delta:               round.temp.delta,
+			BigDeltaX:           deltaX,
+			BigDeltaY:           deltaY,
+		}
+		prm.SetFromID(round.kgid)
+		prm.SetFromIndex(curIndex)
+
+		if j == curIndex {
+			round.temp.preRound3Messages[curIndex] = prm
+			continue
+		}
+
+		ntj, err := round.GetNtilde(j)
+		if err != nil {
+			return err
+		}
+
+		prm.LogStarPf = ec2.LogStarProve(round.curve, round.proofContext(round.idsign[curIndex], round.number), paiPk, ntj, round.temp.bigK, gammaX, gammaY, deltaX, deltaY, round.temp.k, round.temp.rho)
+		if prm.LogStarPf == nil {
+			return errors.New("get knowledge of exponent vs paillier encryption proof fail")
+		}
+
+		prm.AppendToID(round.toDNodeID(j))
+		round.out <- prm
+	}
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*PreSignRound3Message); ok {
+		return !msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	for j, msg := range round.temp.preRound3Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
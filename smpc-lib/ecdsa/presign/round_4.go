@@ -0,0 +1,120 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+// Start verify Delta_j = k_j*Gamma,calc delta = sum(delta_j),check delta*G = sum(Delta_j) and output the presign data R = delta^-1*Gamma
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= presign round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	nt, err := round.GetNtilde(curIndex)
+	if err != nil {
+		return err
+	}
+
+	order := round.curve.Params().N
+	delta := big.NewInt(0)
+	var deltaX, deltaY *big.Int
+	for j := range round.idsign {
+		msg3, ok := round.temp.preRound3Messages[j].(*PreSignRound3Message)
+		if !ok {
+			return errors.New("get presign round 3 msg fail")
+		}
+
+		if msg3.Delta == nil || msg3.BigDeltaX == nil || msg3.BigDeltaY == nil || !round.curve.IsOnCurve(msg3.BigDeltaX, msg3.BigDeltaY) {
+			return smpc.NewBlameError(msg3.GetFromID(), round.number, "Delta", errors.New("error delta"))
+		}
+
+		if j != curIndex {
+			msg1, ok := round.temp.preRound1Messages[j].(*PreSignRound1Message)
+			if !ok {
+				return errors.New("get presign round 1 msg fail")
+			}
+
+			pkj, err := round.GetPaillierPk(j)
+			if err != nil {
+				return err
+			}
+
+			if !ec2.LogStarVerify(round.curve, round.proofContext(round.idsign[j], 3), pkj, nt, msg1.K, round.temp.bigGammaX, round.temp.bigGammaY, msg3.BigDeltaX, msg3.BigDeltaY, msg3.LogStarPf) {
+				return smpc.NewBlameError(msg3.GetFromID(), round.number, "LogStarProof", errors.New("verify knowledge of exponent vs paillier encryption proof fail"))
+			}
+		}
+
+		delta.Add(delta, msg3.Delta)
+		if j == 0 {
+			deltaX, deltaY = msg3.BigDeltaX, msg3.BigDeltaY
+		} else {
+			deltaX, deltaY = round.curve.Add(deltaX, deltaY, msg3.BigDeltaX, msg3.BigDeltaY)
+		}
+	}
+	delta.Mod(delta, order)
+
+	gx, gy := round.curve.ScalarBaseMult(delta.Bytes())
+	if gx.Cmp(deltaX) != 0 || gy.Cmp(deltaY) != 0 {
+		return errors.New("check delta*G = sum(Delta) fail")
+	}
+
+	deltaInverse := new(big.Int).ModInverse(delta, order)
+	if deltaInverse == nil {
+		return errors.New("calc delta inverse fail")
+	}
+
+	rx, ry := round.curve.ScalarMult(round.temp.bigGammaX, round.temp.bigGammaY, deltaInverse.Bytes())
+
+	round.end <- signing.PrePubData{K1: round.temp.k, R: rx, Ry: ry, Sigma1: round.temp.chi}
+
+	round.temp.w = nil
+	round.temp.gamma = nil
+	round.temp.rho = nil
+	round.temp.nu = nil
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
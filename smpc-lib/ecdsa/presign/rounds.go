@@ -0,0 +1,198 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package presign
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"math/big"
+)
+
+type (
+	base struct {
+		temp              *localTempData
+		save              *keygen.LocalDNodeSaveData
+		idsign            smpc.SortableIDSSlice
+		out               chan<- smpc.Message
+		end               chan<- signing.PrePubData
+		ok                []bool
+		started           bool
+		number            int
+		kgid              string
+		threshold         int
+		paillierkeylength int
+		curve             elliptic.Curve
+		sessionkey        string
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// proofContext get the context of the zk proof made by the party id in round number
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
+// GetIDs get from all nodes
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	return round.idsign, nil
+}
+
+// GetDNodeIDIndex get from threshold group
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" {
+		return -1, nil
+	}
+
+	uidtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+	idtmp, _ := new(big.Int).SetString(string(uidtmp[:]), 10)
+
+	for k, v := range round.idsign {
+		if v.Cmp(idtmp) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in idsign")
+}
+
+// GetSaveIndex get the index in save.IDs of the signer k
+func (round *base) GetSaveIndex(k int) (int, error) {
+	if k < 0 || k >= len(round.idsign) {
+		return -1, errors.New("signer index out of range")
+	}
+
+	for kk, vv := range round.save.IDs {
+		if round.idsign[k].Cmp(vv) == 0 {
+			if kk >= len(round.save.U1PaillierPk) || kk >= len(round.save.U1NtildeH1H2) {
+				return -1, errors.New("get signer index fail")
+			}
+
+			return kk, nil
+		}
+	}
+
+	return -1, errors.New("get signer index fail")
+}
+
+// GetPaillierPk get the paillier pubkey of the signer k
+func (round *base) GetPaillierPk(k int) (*ec2.PublicKey, error) {
+	index, err := round.GetSaveIndex(k)
+	if err != nil {
+		return nil, err
+	}
+
+	pk := round.save.U1PaillierPk[index]
+	if pk == nil || pk.N == nil {
+		return nil, errors.New("get paillier pubkey fail")
+	}
+
+	return pk, nil
+}
+
+// GetNtilde get the ring-pedersen parameters (ntilde,h1,h2) of the signer k
+func (round *base) GetNtilde(k int) (*ec2.NtildeH1H2, error) {
+	index, err := round.GetSaveIndex(k)
+	if err != nil {
+		return nil, err
+	}
+
+	nt := round.save.U1NtildeH1H2[index]
+	if nt == nil || nt.Ntilde == nil || nt.H1 == nil || nt.H2 == nil {
+		return nil, errors.New("get ntilde fail")
+	}
+
+	return nt, nil
+}
+
+// CheckPaillierKeyLength check the paillier N and Ntilde of all signers have the length stored with the key
+func (round *base) CheckPaillierKeyLength() error {
+	if round.paillierkeylength < ec2.MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	}
+
+	for k := range round.idsign {
+		paiPk, err := round.GetPaillierPk(k)
+		if err != nil {
+			return err
+		}
+
+		if paiPk.N.BitLen() < ec2.MinPaillierKeyLength || paiPk.N.BitLen() != round.paillierkeylength {
+			return errors.New("got paillier N with not enough bits")
+		}
+
+		nt, err := round.GetNtilde(k)
+		if err != nil {
+			return err
+		}
+
+		if nt.Ntilde.BitLen() < ec2.MinNtildeLength || nt.Ntilde.BitLen() != round.paillierkeylength {
+			return errors.New("got ntilde with not enough bits")
+		}
+	}
+
+	return nil
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// toDNodeID get the dnode id of the signer k
+func (round *base) toDNodeID(k int) string {
+	return hex.EncodeToString([]byte(fmt.Sprintf("%v", round.idsign[k])))
+}
@@ -0,0 +1,310 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package recovery MPC implementation of share recovery
+// At least threshold surviving holders of a pubkey jointly re-create the lost sku1 of one node of the keygen group,
+// every holder only sends random additive pieces of its lagrange term,so no party learns the secret or the other shares.
+package recovery
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp         localTempData
+	data         *keygen.LocalDNodeSaveData
+	out          chan<- smpc.Message
+	end          chan<- keygen.LocalDNodeSaveData
+	curve        elliptic.Curve
+	recoverID    *big.Int
+	recoverIndex int
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	recRound1Messages,
+	recRound2Messages,
+	recRound2Messages1,
+	recRound3Messages,
+	recRound4Messages []smpc.Message
+
+	// temp data (thrown away after recovery)
+
+	//round 4
+	sku1 *big.Int
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// sd is the keygen save data,sd.IDs,sd.CurDNodeID,sd.Pkx and sd.Pky must be set,sd.SkU1 is nil on the node that lost its share
+// recoverid is the uid of the node that lost its share
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- keygen.LocalDNodeSaveData,
+	DNodeCountInGroup int,
+	threshold int,
+	sd *keygen.LocalDNodeSaveData,
+	recoverid *big.Int,
+	keytype string,
+) smpc.DNode {
+
+	id := ""
+	if sd != nil && sd.CurDNodeID != nil {
+		id = fmt.Sprintf("%v", sd.CurDNodeID)
+	}
+
+	p := &LocalDNode{
+		BaseDNode:    new(smpc.BaseDNode),
+		temp:         localTempData{},
+		data:         sd,
+		out:          out,
+		end:          end,
+		curve:        ec2.GetCurve(keytype),
+		recoverID:    recoverid,
+		recoverIndex: -1,
+	}
+
+	if sd != nil && recoverid != nil {
+		for k, v := range sd.IDs {
+			if v.Cmp(recoverid) == 0 {
+				p.recoverIndex = k
+				break
+			}
+		}
+	}
+
+	p.ID = hex.EncodeToString([]byte(id))
+	p.DNodeCountInGroup = DNodeCountInGroup
+	p.ThresHold = threshold
+
+	p.temp.recRound1Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.recRound2Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.recRound2Messages1 = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.recRound3Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.recRound4Messages = make([]smpc.Message, DNodeCountInGroup)
+	return p
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.curve, p.SessionKey, p.recoverID, p.recoverIndex)
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// Finalize weather gg20 round
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// Start recovery start
+func (p *LocalDNode) Start() error {
+	if p.data == nil || p.data.CurDNodeID == nil || p.data.Pkx == nil || p.data.Pky == nil || len(p.data.IDs) != p.DNodeCountInGroup {
+		return errors.New("recovery save data error")
+	}
+
+	if p.recoverIndex < 0 {
+		return errors.New("the node to recover is not in the keygen group")
+	}
+
+	// every holder must still have its sku1
+	if p.data.CurDNodeID.Cmp(p.recoverID) != 0 && p.data.SkU1 == nil {
+		return errors.New("recovery get sku1 fail")
+	}
+
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkFullExcept Check for empty messages except the one of index,the node to recover does not send it
+func checkFullExcept(msg []smpc.Message, index int) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for k, v := range msg {
+		if k != index && v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *RecRound1Message:
+		return find(p.temp.recRound1Messages, msg)
+	case *RecRound2Message:
+		return find(p.temp.recRound2Messages, msg)
+	case *RecRound2Message1:
+		return find(p.temp.recRound2Messages1, msg)
+	case *RecRound3Message:
+		return find(p.temp.recRound3Messages, msg)
+	case *RecRound4Message:
+		return find(p.temp.recRound4Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// putMessage put msg to l,return false if it is a duplicate
+func putMessage(l []smpc.Message, msg smpc.Message) (bool, error) {
+	if find(l, msg) {
+		return false, nil
+	}
+
+	index := msg.GetFromIndex()
+	if index < 0 || index >= len(l) {
+		return false, errors.New("msg index error")
+	}
+
+	l[index] = msg
+	return true, nil
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *RecRound1Message:
+		if ok, err := putMessage(p.temp.recRound1Messages, msg); !ok {
+			return false, err
+		}
+
+		return CheckFull(p.temp.recRound1Messages), nil
+	case *RecRound2Message:
+		// only the holders send the pieces
+		if msg.GetFromIndex() == p.recoverIndex {
+			return false, errors.New("msg index error")
+		}
+
+		if ok, err := putMessage(p.temp.recRound2Messages, msg); !ok {
+			return false, err
+		}
+
+		return checkFullExcept(p.temp.recRound2Messages, p.recoverIndex), nil
+	case *RecRound2Message1:
+		if ok, err := putMessage(p.temp.recRound2Messages1, msg); !ok {
+			return false, err
+		}
+
+		if !CheckFull(p.temp.recRound2Messages1) {
+			return false, nil
+		}
+
+		///check pubkey ok
+		for _, v := range p.temp.recRound2Messages1 {
+			m := v.(*RecRound2Message1)
+			if m.PubKeyOk != "TRUE" {
+				return false, smpc.NewBlameError(m.GetFromID(), 2, "PubKeyOk", errors.New("check pubkey ok fail"))
+			}
+		}
+		////
+
+		return true, nil
+	case *RecRound3Message:
+		if msg.GetFromIndex() == p.recoverIndex {
+			return false, errors.New("msg index error")
+		}
+
+		if ok, err := putMessage(p.temp.recRound3Messages, msg); !ok {
+			return false, err
+		}
+
+		return checkFullExcept(p.temp.recRound3Messages, p.recoverIndex), nil
+	case *RecRound4Message:
+		// only the node to recover sends the result
+		if msg.GetFromIndex() != p.recoverIndex {
+			return false, errors.New("msg index error")
+		}
+
+		if ok, err := putMessage(p.temp.recRound4Messages, msg); !ok {
+			return false, err
+		}
+
+		m := msg.(*RecRound4Message)
+		if m.ShareOk != "TRUE" {
+			return false, smpc.NewBlameError(m.GetFromID(), 4, "ShareOk", errors.New("check share ok fail"))
+		}
+
+		return true, nil
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package recovery_test test MPC implementation of share recovery
+package recovery_test
+
+import (
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/recovery"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	recRoundiMessages := make([]smpc.Message, 0)
+	succ := recovery.CheckFull(recRoundiMessages)
+	assert.False(t, succ, "fail")
+
+	count := 3
+	for i := 0; i < count; i++ {
+		re := &recovery.RecRound2Message1{
+			RecRoundMessage: new(recovery.RecRoundMessage),
+			PubKeyOk:        "TRUE",
+		}
+		re.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		re.SetFromIndex(i)
+
+		recRoundiMessages = append(recRoundiMessages, re)
+	}
+
+	succ = recovery.CheckFull(recRoundiMessages)
+	assert.True(t, succ, "success")
+}
@@ -0,0 +1,218 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&RecRound1Message{},
+		&RecRound2Message{},
+		&RecRound2Message1{},
+		&RecRound3Message{},
+		&RecRound4Message{},
+	)
+}
+
+// RecRoundMessage base type of recovery round message
+type RecRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (re *RecRoundMessage) SetFromID(id string) {
+	re.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (re *RecRoundMessage) SetFromIndex(index int) {
+	re.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (re *RecRoundMessage) AppendToID(toid string) {
+	re.ToID = append(re.ToID, toid)
+}
+
+// RecRound1Message  Round 1 sending message,the xi*G of the holder and the proof of knowing xi,it is empty for the node to recover
+type RecRound1Message struct {
+	*RecRoundMessage
+
+	XiG       []*big.Int
+	ZkXiProof *ec2.ZkXiProof
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RecRound1Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RecRound1Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RecRound1Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RecRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RecRound1Message) GetMsgType() string {
+	return "RecRound1Message"
+}
+
+// RecRound2Message  Round 2 sending message,one random additive piece of the lagrange term of the holder
+type RecRound2Message struct {
+	*RecRoundMessage
+
+	Piece *big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RecRound2Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RecRound2Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RecRound2Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RecRound2Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (re *RecRound2Message) GetMsgType() string {
+	return "RecRound2Message"
+}
+
+// RecRound2Message1  Round 2 sending message,the xi*G of the holders match the pubkey
+type RecRound2Message1 struct {
+	*RecRoundMessage
+
+	PubKeyOk string
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RecRound2Message1) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RecRound2Message1) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RecRound2Message1) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RecRound2Message1) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RecRound2Message1) GetMsgType() string {
+	return "RecRound2Message1"
+}
+
+// RecRound3Message  Round 3 sending message,the sum of the pieces received by the holder,sent to the node to recover
+type RecRound3Message struct {
+	*RecRoundMessage
+
+	Sum *big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RecRound3Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RecRound3Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RecRound3Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RecRound3Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (re *RecRound3Message) GetMsgType() string {
+	return "RecRound3Message"
+}
+
+// RecRound4Message  Round 4 sending message,the node to recover has verified the recovered sku1
+type RecRound4Message struct {
+	*RecRoundMessage
+
+	ShareOk string
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RecRound4Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RecRound4Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RecRound4Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RecRound4Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RecRound4Message) GetMsgType() string {
+	return "RecRound4Message"
+}
@@ -0,0 +1,99 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(save *keygen.LocalDNodeSaveData, temp *localTempData, out chan<- smpc.Message, end chan<- keygen.LocalDNodeSaveData, dnodeid string, dnodecount int, threshold int, curve elliptic.Curve, sessionkey string, recoverid *big.Int, recoverindex int) smpc.Round {
+	return &round1{
+		&base{save, temp, out, end, make([]bool, dnodecount), false, 0, dnodeid, dnodecount, threshold, curve, sessionkey, recoverid, recoverindex}}
+}
+
+// Start the holders broadcast xi*G and the proof of knowing xi,the node to recover joins with an empty message
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= recovery round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	if round.threshold <= 1 || round.threshold > round.dnodecount {
+		return errors.New("threshold value error")
+	}
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		fmt.Printf("============recovery round1 start,get dnode id index fail,uid = %v,err = %v ===========\n", round.dnodeid, err)
+		return err
+	}
+
+	holders, _ := round.getHolders()
+	if len(holders) < round.threshold {
+		return fmt.Errorf("recover share need at least %v holders,but there are %v", round.threshold, len(holders))
+	}
+
+	re := &RecRound1Message{
+		RecRoundMessage: new(RecRoundMessage),
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+
+	if !round.isRecovering() {
+		xiGx, xiGy := round.curve.ScalarBaseMult(round.Save.SkU1.Bytes())
+		zkXiProof := ec2.ZkXiProve(round.curve, round.proofContext(round.Save.CurDNodeID, round.number), round.Save.SkU1)
+		if zkXiProof == nil {
+			return errors.New("zkx prove fail")
+		}
+
+		re.XiG = []*big.Int{xiGx, xiGy}
+		re.ZkXiProof = zkXiProof
+	}
+
+	round.temp.recRound1Messages[index] = re
+	round.out <- re
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RecRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.recRound1Messages, round.CanAccept, -1)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,177 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify xi*G of the holders against the pubkey,
+// every holder splits its lagrange term lambda_i(x_r)*xi into random additive pieces and sends one piece to each holder
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= recovery round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return err
+	}
+
+	// the proofs are made in round 1 by the holders
+	err = smpc.VerifyParallel(len(ids), func(k int) error {
+		if k == round.recoverindex {
+			return nil
+		}
+
+		msg1, ok := round.temp.recRound1Messages[k].(*RecRound1Message)
+		if !ok {
+			return errors.New("round.Start get round1 msg fail")
+		}
+
+		if !ec2.ZkXiVerify(round.curve, round.proofContext(ids[k], 1), msg1.XiG, msg1.ZkXiProof) {
+			fmt.Printf("========= recovery round2 verify zkx fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), round.number, "ZkXiProof", errors.New("verify zkx fail"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the shares of the holders are on the polynomial of the pubkey: sum(lambda_i(0) * xi*G) == pk
+	pkx, pky, err := round.lagrangeSum(big.NewInt(0))
+	if err != nil {
+		return err
+	}
+
+	if pkx.Cmp(round.Save.Pkx) != 0 || pky.Cmp(round.Save.Pky) != 0 {
+		fmt.Printf("========= recovery round2 verify pubkey fail ==========\n")
+		return errors.New("the shares of the holders do not match the pubkey")
+	}
+
+	if !round.isRecovering() {
+		holders, cur := round.getHolders()
+		lambda, err := ec2.LagrangeCoefficient(round.curve, holders, cur, round.recoverid)
+		if err != nil {
+			return err
+		}
+
+		term := new(big.Int).Mul(lambda, round.Save.SkU1)
+		term = new(big.Int).Mod(term, round.curve.Params().N)
+
+		// the last piece makes the sum of pieces equal to the term
+		last := new(big.Int).Set(term)
+		h := 0
+		for k, id := range ids {
+			if k == round.recoverindex {
+				continue
+			}
+
+			var piece *big.Int
+			if h == len(holders)-1 {
+				piece = new(big.Int).Mod(last, round.curve.Params().N)
+			} else {
+				piece = random.GetRandomIntFromZn(round.curve.Params().N)
+				last = new(big.Int).Sub(last, piece)
+			}
+			h++
+
+			re := &RecRound2Message{
+				RecRoundMessage: new(RecRoundMessage),
+				Piece:           piece,
+			}
+			re.SetFromID(round.dnodeid)
+			re.SetFromIndex(index)
+
+			if k == index {
+				round.temp.recRound2Messages[index] = re
+			} else {
+				tmp := fmt.Sprintf("%v", id)
+				re.AppendToID(hex.EncodeToString([]byte(tmp))) //id-->dnodeid
+				round.out <- re
+			}
+		}
+	}
+
+	re := &RecRound2Message1{
+		RecRoundMessage: new(RecRoundMessage),
+		PubKeyOk:        "TRUE",
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+	round.temp.recRound2Messages1[index] = re
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RecRound2Message); ok {
+		return !msg.IsBroadcast()
+	}
+	if _, ok := msg.(*RecRound2Message1); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+// the node to recover does not get the pieces
+func (round *round2) Update() (bool, error) {
+	for j, msg := range round.temp.recRound2Messages1 {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		if !round.isRecovering() && j != round.recoverindex {
+			msg2 := round.temp.recRound2Messages[j]
+			if msg2 == nil || !round.CanAccept(msg2) {
+				return false, nil
+			}
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
@@ -0,0 +1,107 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start every holder sends the sum of the pieces it received to the node to recover
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= recovery round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	if round.isRecovering() {
+		return nil
+	}
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	sum := big.NewInt(0)
+	for k := range round.temp.recRound2Messages {
+		if k == round.recoverindex {
+			continue
+		}
+
+		msg2, ok := round.temp.recRound2Messages[k].(*RecRound2Message)
+		if !ok || msg2.Piece == nil {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		sum = new(big.Int).Add(sum, msg2.Piece)
+	}
+	sum = new(big.Int).Mod(sum, round.curve.Params().N)
+
+	re := &RecRound3Message{
+		RecRoundMessage: new(RecRoundMessage),
+		Sum:             sum,
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+	re.AppendToID(round.getRecoverDNodeID())
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RecRound3Message); ok {
+		return !msg.IsBroadcast()
+	}
+	if _, ok := msg.(*RecRound4Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+// the node to recover waits for the sums of the holders,the holders wait for the result of the node to recover
+func (round *round3) Update() (bool, error) {
+	if round.isRecovering() {
+		return round.update(round.temp.recRound3Messages, round.CanAccept, round.recoverindex)
+	}
+
+	msg := round.temp.recRound4Messages[round.recoverindex]
+	if msg == nil || !round.CanAccept(msg) {
+		return false, nil
+	}
+
+	for j := range round.ok {
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
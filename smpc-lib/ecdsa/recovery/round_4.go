@@ -0,0 +1,102 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start the node to recover adds up the sums and verifies sku1*G == sum(lambda_i(x_r) * xi*G),then broadcasts the result
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= recovery round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	if !round.isRecovering() {
+		round.end <- *round.Save
+		fmt.Printf("========= recovery round4 finish, dnode id = %v ==========\n", round.dnodeid)
+		return nil
+	}
+
+	sku1 := big.NewInt(0)
+	for k := range round.temp.recRound3Messages {
+		if k == round.recoverindex {
+			continue
+		}
+
+		msg3, ok := round.temp.recRound3Messages[k].(*RecRound3Message)
+		if !ok || msg3.Sum == nil {
+			return errors.New("round.Start get round3 msg fail")
+		}
+
+		sku1 = new(big.Int).Add(sku1, msg3.Sum)
+	}
+	sku1 = new(big.Int).Mod(sku1, round.curve.Params().N)
+	if sku1.Sign() == 0 {
+		return errors.New("recovered sku1 is zero")
+	}
+
+	xiGx, xiGy, err := round.lagrangeSum(round.recoverid)
+	if err != nil {
+		return err
+	}
+
+	sku1Gx, sku1Gy := round.curve.ScalarBaseMult(sku1.Bytes())
+	if sku1Gx.Cmp(xiGx) != 0 || sku1Gy.Cmp(xiGy) != 0 {
+		fmt.Printf("========= recovery round4 verify recovered sku1 fail ==========\n")
+		return errors.New("verify recovered sku1 fail")
+	}
+
+	round.temp.sku1 = sku1
+
+	re := &RecRound4Message{
+		RecRoundMessage: new(RecRoundMessage),
+		ShareOk:         "TRUE",
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(round.recoverindex)
+	round.temp.recRound4Messages[round.recoverindex] = re
+	round.out <- re
+
+	round.Save.SkU1 = round.temp.sku1
+	round.end <- *round.Save
+	fmt.Printf("========= recovery round4 finish, dnode id = %v ==========\n", round.dnodeid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
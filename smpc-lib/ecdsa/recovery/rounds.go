@@ -0,0 +1,211 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package recovery
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		Save         *keygen.LocalDNodeSaveData
+		temp         *localTempData
+		out          chan<- smpc.Message
+		end          chan<- keygen.LocalDNodeSaveData
+		ok           []bool
+		started      bool
+		number       int
+		dnodeid      string
+		dnodecount   int
+		threshold    int
+		curve        elliptic.Curve
+		sessionkey   string
+		recoverid    *big.Int
+		recoverindex int
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetIDs get the uids of all nodes in the keygen group
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	if round.Save == nil || len(round.Save.IDs) != round.dnodecount {
+		return nil, errors.New("get ids fail")
+	}
+
+	return round.Save.IDs, nil
+}
+
+// GetDNodeIDIndex get the node index in the keygen group by dnode id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" || round.Save == nil {
+		return -1, errors.New("no found current node's uid")
+	}
+
+	idtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+
+	uid, ok := new(big.Int).SetString(string(idtmp), 10)
+	if !ok {
+		return -1, errors.New("get uid fail")
+	}
+
+	for k, v := range round.Save.IDs {
+		if v.Cmp(uid) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in keygen ids")
+}
+
+// isRecovering weather current node is the node to recover
+func (round *base) isRecovering() bool {
+	return round.Save.CurDNodeID.Cmp(round.recoverid) == 0
+}
+
+// getHolders get the uids of the holders,that is all nodes in the keygen group except the node to recover
+// the index of current node in the holders is -1 if it is the node to recover
+func (round *base) getHolders() ([]*big.Int, int) {
+	var holders []*big.Int
+	cur := -1
+	for k, v := range round.Save.IDs {
+		if k == round.recoverindex {
+			continue
+		}
+
+		if v.Cmp(round.Save.CurDNodeID) == 0 {
+			cur = len(holders)
+		}
+		holders = append(holders, v)
+	}
+
+	return holders, cur
+}
+
+// getRecoverDNodeID get the dnode id of the node to recover
+func (round *base) getRecoverDNodeID() string {
+	tmp := fmt.Sprintf("%v", round.recoverid)
+	return hex.EncodeToString([]byte(tmp)) //id-->dnodeid
+}
+
+// lagrangeSum calc sum(lambda_k(x) * xiG_k) over the holders,xiG_k is broadcast in round 1
+func (round *base) lagrangeSum(x *big.Int) (*big.Int, *big.Int, error) {
+	holders, _ := round.getHolders()
+	var sumx, sumy *big.Int
+	h := 0
+	for k := range round.temp.recRound1Messages {
+		if k == round.recoverindex {
+			continue
+		}
+
+		msg1, ok := round.temp.recRound1Messages[k].(*RecRound1Message)
+		if !ok || len(msg1.XiG) != 2 {
+			return nil, nil, errors.New("round.Start get round1 msg fail")
+		}
+
+		lambda, err := ec2.LagrangeCoefficient(round.curve, holders, h, x)
+		if err != nil {
+			return nil, nil, err
+		}
+		h++
+
+		px, py := round.curve.ScalarMult(msg1.XiG[0], msg1.XiG[1], lambda.Bytes())
+		if sumx == nil {
+			sumx, sumy = px, py
+			continue
+		}
+
+		sumx, sumy = round.curve.Add(sumx, sumy, px, py)
+	}
+
+	if sumx == nil {
+		return nil, nil, errors.New("no holder")
+	}
+
+	return sumx, sumy, nil
+}
+
+// proofContext the context that the zk proofs of party id in round number are bound to
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// update is the messages of current round received?
+// the message of the node with index skip is not waited for,skip < 0 means all nodes
+func (round *base) update(l []smpc.Message, canAccept func(smpc.Message) bool, skip int) (bool, error) {
+	for j, msg := range l {
+		if round.ok[j] {
+			continue
+		}
+		if j == skip {
+			round.ok[j] = true
+			continue
+		}
+		if msg == nil || !canAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
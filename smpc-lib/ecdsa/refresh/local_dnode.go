@@ -0,0 +1,238 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package refresh MPC implementation of proactive share refresh
+// All nodes of the keygen group add the shares of a zero secret polynomial to their sku1,
+// so the pubkey and the group are unchanged but the old shares are useless.
+package refresh
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp  localTempData
+	data  *keygen.LocalDNodeSaveData
+	out   chan<- smpc.Message
+	end   chan<- keygen.LocalDNodeSaveData
+	curve elliptic.Curve
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	refreshRound1Messages,
+	refreshRound2Messages,
+	refreshRound2Messages1,
+	refreshRound3Messages []smpc.Message
+
+	// temp data (thrown away after refresh)
+
+	//round 1
+	poly  []*big.Int
+	polyG [][]*big.Int
+	comd  []*big.Int
+
+	//round 3
+	newskU1   *big.Int
+	pubShares [][]*big.Int
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// sd is the keygen save data,sd.IDs and sd.CurDNodeID must be set
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- keygen.LocalDNodeSaveData,
+	DNodeCountInGroup int,
+	threshold int,
+	sd *keygen.LocalDNodeSaveData,
+	keytype string,
+) smpc.DNode {
+
+	id := ""
+	if sd != nil && sd.CurDNodeID != nil {
+		id = fmt.Sprintf("%v", sd.CurDNodeID)
+	}
+
+	p := &LocalDNode{
+		BaseDNode: new(smpc.BaseDNode),
+		temp:      localTempData{},
+		data:      sd,
+		out:       out,
+		end:       end,
+		curve:     ec2.GetCurve(keytype),
+	}
+
+	p.ID = hex.EncodeToString([]byte(id))
+	p.DNodeCountInGroup = DNodeCountInGroup
+	p.ThresHold = threshold
+
+	p.temp.refreshRound1Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.refreshRound2Messages = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.refreshRound2Messages1 = make([]smpc.Message, DNodeCountInGroup)
+	p.temp.refreshRound3Messages = make([]smpc.Message, DNodeCountInGroup)
+	return p
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.curve)
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// Finalize weather gg20 round
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// Start refresh start
+func (p *LocalDNode) Start() error {
+	if p.data == nil || p.data.SkU1 == nil || p.data.CurDNodeID == nil || len(p.data.IDs) != p.DNodeCountInGroup {
+		return errors.New("refresh save data error")
+	}
+
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *RefRound1Message:
+		return find(p.temp.refreshRound1Messages, msg)
+	case *RefRound2Message:
+		return find(p.temp.refreshRound2Messages, msg)
+	case *RefRound2Message1:
+		return find(p.temp.refreshRound2Messages1, msg)
+	case *RefRound3Message:
+		return find(p.temp.refreshRound3Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// checkIndex check whether the index of msg is out of range
+func checkIndex(l []smpc.Message, msg smpc.Message) bool {
+	index := msg.GetFromIndex()
+	return index >= 0 && index < len(l)
+}
+
+// storeMessage put msg to l and return true if l is full
+func storeMessage(l []smpc.Message, msg smpc.Message) (bool, error) {
+	if find(l, msg) {
+		return false, nil
+	}
+
+	if !checkIndex(l, msg) {
+		return false, errors.New("msg index error")
+	}
+
+	l[msg.GetFromIndex()] = msg
+	return CheckFull(l), nil
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *RefRound1Message:
+		return storeMessage(p.temp.refreshRound1Messages, msg)
+	case *RefRound2Message:
+		return storeMessage(p.temp.refreshRound2Messages, msg)
+	case *RefRound2Message1:
+		return storeMessage(p.temp.refreshRound2Messages1, msg)
+	case *RefRound3Message:
+		full, err := storeMessage(p.temp.refreshRound3Messages, msg)
+		if err != nil || !full {
+			return full, err
+		}
+
+		///check newskok
+		for _, v := range p.temp.refreshRound3Messages {
+			m := v.(*RefRound3Message)
+			if m.NewSkOk != "TRUE" {
+				return false, smpc.NewBlameError(m.GetFromID(), 3, "NewSkOk", errors.New("check newsk ok fail"))
+			}
+		}
+		////
+
+		return true, nil
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"math/big"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&RefRound1Message{},
+		&RefRound2Message{},
+		&RefRound2Message1{},
+		&RefRound3Message{},
+	)
+}
+
+// RefRoundMessage base type of refresh round message
+type RefRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (re *RefRoundMessage) SetFromID(id string) {
+	re.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (re *RefRoundMessage) SetFromIndex(index int) {
+	re.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (re *RefRoundMessage) AppendToID(toid string) {
+	re.ToID = append(re.ToID, toid)
+}
+
+// RefRound1Message  Round 1 sending message
+type RefRound1Message struct {
+	*RefRoundMessage
+	ComC *big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RefRound1Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RefRound1Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RefRound1Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RefRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RefRound1Message) GetMsgType() string {
+	return "RefRound1Message"
+}
+
+// RefRound2Message  Round 2 sending message,the share of zero secret polynomial
+type RefRound2Message struct {
+	*RefRoundMessage
+
+	ID    *big.Int
+	Share *big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RefRound2Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RefRound2Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RefRound2Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RefRound2Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (re *RefRound2Message) GetMsgType() string {
+	return "RefRound2Message"
+}
+
+// RefRound2Message1  Round 2 sending message,the decommitment of the coefficients of zero secret polynomial
+type RefRound2Message1 struct {
+	*RefRoundMessage
+
+	ComD []*big.Int
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RefRound2Message1) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RefRound2Message1) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RefRound2Message1) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RefRound2Message1) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RefRound2Message1) GetMsgType() string {
+	return "RefRound2Message1"
+}
+
+// RefRound3Message  Round 3 sending message
+type RefRound3Message struct {
+	*RefRoundMessage
+	NewSkOk string
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *RefRound3Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *RefRound3Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *RefRound3Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *RefRound3Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *RefRound3Message) GetMsgType() string {
+	return "RefRound3Message"
+}
@@ -0,0 +1,103 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(save *keygen.LocalDNodeSaveData, temp *localTempData, out chan<- smpc.Message, end chan<- keygen.LocalDNodeSaveData, dnodeid string, dnodecount int, threshold int, curve elliptic.Curve) smpc.Round {
+	return &round1{
+		&base{save, temp, out, end, make([]bool, dnodecount), false, 0, dnodeid, dnodecount, threshold, curve}}
+}
+
+// Start generate zero secret polynomial and broadcast the commitment of its coefficients
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= refresh round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	if round.threshold <= 1 || round.threshold > round.dnodecount {
+		return errors.New("threshold value error")
+	}
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		fmt.Printf("============refresh round1 start,get dnode id index fail,uid = %v,err = %v ===========\n", round.dnodeid, err)
+		return err
+	}
+
+	poly, polyG, err := ec2.Vss2InitZero(round.curve, round.threshold)
+	if err != nil {
+		return err
+	}
+
+	commitValues := make([]*big.Int, 0)
+	for _, v := range polyG.PolyG {
+		commitValues = append(commitValues, v[0])
+		commitValues = append(commitValues, v[1])
+	}
+	commit := new(ec2.Commitment).Commit(commitValues...)
+	if commit == nil {
+		return errors.New(" Error generating commitment data in refresh round 1")
+	}
+
+	round.temp.poly = poly.Poly
+	round.temp.polyG = polyG.PolyG
+	round.temp.comd = commit.D
+
+	re := &RefRound1Message{
+		RefRoundMessage: new(RefRoundMessage),
+		ComC:            commit.C,
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+
+	round.temp.refreshRound1Messages[index] = re
+	round.out <- re
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RefRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.refreshRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,113 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start send the shares of zero secret polynomial to corresponding peer and broadcast the decommitment
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= refresh round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	poly := &ec2.PolyStruct2{Poly: round.temp.poly}
+	shares, err := poly.Vss2(round.curve, round.Save.IDs)
+	if err != nil {
+		return err
+	}
+
+	for k, id := range round.Save.IDs {
+		re := &RefRound2Message{
+			RefRoundMessage: new(RefRoundMessage),
+			ID:              shares[k].ID,
+			Share:           shares[k].Share,
+		}
+		re.SetFromID(round.dnodeid)
+		re.SetFromIndex(index)
+
+		if k == index {
+			round.temp.refreshRound2Messages[index] = re
+		} else {
+			tmp := fmt.Sprintf("%v", id)
+			re.AppendToID(hex.EncodeToString([]byte(tmp))) //id-->dnodeid
+			round.out <- re
+		}
+	}
+
+	re := &RefRound2Message1{
+		RefRoundMessage: new(RefRoundMessage),
+		ComD:            round.temp.comd,
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+	round.temp.refreshRound2Messages1[index] = re
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RefRound2Message); ok {
+		return !msg.IsBroadcast()
+	}
+	if _, ok := msg.(*RefRound2Message1); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	for j, msg := range round.temp.refreshRound2Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		msg2 := round.temp.refreshRound2Messages1[j]
+		if msg2 == nil || !round.CanAccept(msg2) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
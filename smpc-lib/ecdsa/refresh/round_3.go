@@ -0,0 +1,143 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify commitment and the shares of zero secret polynomial,calc new sku1
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= refresh round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	index, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	newskU1 := new(big.Int).Set(round.Save.SkU1)
+
+	// update the public shares only if they are known,the data saved before they were added has none
+	var pubShares [][]*big.Int
+	if len(round.Save.PubShares) != 0 && len(round.Save.PubShares) == len(round.Save.IDs) {
+		pubShares = make([][]*big.Int, len(round.Save.PubShares))
+		for j, v := range round.Save.PubShares {
+			if len(v) != 2 {
+				return errors.New("invalid public share")
+			}
+			pubShares[j] = []*big.Int{v[0], v[1]}
+		}
+	}
+
+	for k := range round.temp.refreshRound1Messages {
+		msg1, ok := round.temp.refreshRound1Messages[k].(*RefRound1Message)
+		if !ok {
+			return errors.New("round.Start get round1 msg fail")
+		}
+
+		msg2, ok := round.temp.refreshRound2Messages[k].(*RefRound2Message)
+		if !ok {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		msg21, ok := round.temp.refreshRound2Messages1[k].(*RefRound2Message1)
+		if !ok {
+			return errors.New("round.Start get round2-1 msg fail")
+		}
+
+		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg21.ComD}
+		succ, values := deCommit.DeCommit(round.curve)
+		if !succ || len(values) != 2*(round.threshold-1) {
+			fmt.Printf("========= refresh round3 verify commitment fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "PolyCommitment", errors.New("verify commitment fail"))
+		}
+
+		polyG := make([][]*big.Int, 0)
+		for i := 0; i < len(values); i += 2 {
+			polyG = append(polyG, []*big.Int{values[i], values[i+1]})
+		}
+
+		if msg2.ID == nil || msg2.ID.Cmp(round.Save.CurDNodeID) != 0 {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZeroShare", errors.New("share id error"))
+		}
+
+		ushare := &ec2.ShareStruct2{ID: msg2.ID, Share: msg2.Share}
+		if !ushare.VerifyZero2(round.curve, &ec2.PolyGStruct2{PolyG: polyG}) {
+			fmt.Printf("========= refresh round3 verify share fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZeroShare", errors.New("verify share data fail"))
+		}
+
+		newskU1 = new(big.Int).Add(newskU1, msg2.Share)
+
+		for j := range pubShares {
+			px, py := (&ec2.PolyGStruct2{PolyG: polyG}).PubShareZero(round.curve, round.Save.IDs[j])
+			if px == nil {
+				return smpc.NewBlameError(msg1.GetFromID(), 3, "PolyCommitment", errors.New("calc public share fail"))
+			}
+			pubShares[j][0], pubShares[j][1] = round.curve.Add(pubShares[j][0], pubShares[j][1], px, py)
+		}
+	}
+
+	newskU1 = new(big.Int).Mod(newskU1, round.curve.Params().N)
+	if newskU1.Sign() == 0 {
+		return errors.New("new sku1 is zero")
+	}
+
+	round.temp.newskU1 = newskU1
+	round.temp.pubShares = pubShares
+
+	re := &RefRound3Message{
+		RefRoundMessage: new(RefRoundMessage),
+		NewSkOk:         "TRUE",
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+	round.temp.refreshRound3Messages[index] = re
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*RefRound3Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	return round.update(round.temp.refreshRound3Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
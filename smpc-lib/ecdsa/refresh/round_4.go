@@ -0,0 +1,56 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start all nodes have got the new sku1,replace the old one
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= refresh round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	round.Save.SkU1 = round.temp.newskU1
+	round.Save.PubShares = round.temp.pubShares
+	round.end <- *round.Save
+	fmt.Printf("========= refresh round4 finish, dnode id = %v ==========\n", round.dnodeid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
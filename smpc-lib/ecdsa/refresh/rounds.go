@@ -0,0 +1,130 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package refresh
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		Save       *keygen.LocalDNodeSaveData
+		temp       *localTempData
+		out        chan<- smpc.Message
+		end        chan<- keygen.LocalDNodeSaveData
+		ok         []bool
+		started    bool
+		number     int
+		dnodeid    string
+		dnodecount int
+		threshold  int
+		curve      elliptic.Curve
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetIDs get the uids of all nodes in the keygen group
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	if round.Save == nil || len(round.Save.IDs) != round.dnodecount {
+		return nil, errors.New("get ids fail")
+	}
+
+	return round.Save.IDs, nil
+}
+
+// GetDNodeIDIndex get the node index in the keygen group by dnode id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" || round.Save == nil {
+		return -1, errors.New("no found current node's uid")
+	}
+
+	idtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+
+	uid, ok := new(big.Int).SetString(string(idtmp), 10)
+	if !ok {
+		return -1, errors.New("get uid fail")
+	}
+
+	for k, v := range round.Save.IDs {
+		if v.Cmp(uid) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in keygen ids")
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// update is the messages of current round received?
+func (round *base) update(l []smpc.Message, canAccept func(smpc.Message) bool) (bool, error) {
+	for j, msg := range l {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !canAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
@@ -18,6 +18,7 @@
 package reshare
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
 	"time"
@@ -41,6 +42,7 @@ type LocalDNode struct {
 	oldindex int //the node join the keygen and join the reshare, return it's index in group
 	//save for check msg0
 	firstround smpc.Round
+	curve      elliptic.Curve
 }
 
 // localTempData  Store some data of MPC calculation process 
@@ -70,6 +72,8 @@ type localTempData struct {
 	newskU1      *big.Int
 	u1PaillierSk *ec2.PrivateKey
 	u1PaillierPk *ec2.PublicKey
+	// taken from the pre params pool by new node,the ntilde data of round 4 is also from it
+	preParams *ec2.PreParams
 
 	//round 4
 	u1NtildeH1H2 *ec2.NtildeH1H2
@@ -83,6 +87,7 @@ type localTempData struct {
 }
 
 // NewLocalDNode new a DNode data struct for current node
+// paillierkeylength is the one stored with the key on the old nodes,the new nodes pass 0 and take it from the round 1 messages of the old nodes
 func NewLocalDNode(
 	out chan<- smpc.Message,
 	end chan<- keygen.LocalDNodeSaveData,
@@ -92,6 +97,7 @@ func NewLocalDNode(
 	sd *keygen.LocalDNodeSaveData,
 	oldnode bool,
 	oldindex int,
+	keytype string,
 ) smpc.DNode {
 
 	var id string
@@ -115,6 +121,7 @@ func NewLocalDNode(
 		end:       end,
 		oldnode:   oldnode,
 		oldindex:   oldindex,
+		curve:     ec2.GetCurve(keytype),
 	}
 
 	p.ID = id
@@ -136,7 +143,7 @@ func NewLocalDNode(
 
 // FirstRound first round
 func (p *LocalDNode) FirstRound() smpc.Round {
-	fr := newRound0(p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.PaillierKeyLength, p.oldnode,p.oldindex)
+	fr := newRound0(p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.PaillierKeyLength, p.oldnode,p.oldindex, p.curve, p.SessionKey)
 	p.firstround = fr
 	return fr
 }
@@ -266,6 +273,17 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 		    return false,nil
 		}
 		
+		// the paillier key length of the key is only known by the old nodes,all of them must send the same one
+		m := msg.(*ReRound1Message)
+		if err := ec2.CheckPaillierKeyLength(m.PaillierKeyLength); err != nil {
+			return false, err
+		}
+		if p.PaillierKeyLength == 0 {
+			p.PaillierKeyLength = m.PaillierKeyLength
+		} else if p.PaillierKeyLength != m.PaillierKeyLength {
+			return false, fmt.Errorf("paillier key length %v is not the same as %v", m.PaillierKeyLength, p.PaillierKeyLength)
+		}
+
 		index := msg.GetFromIndex()
 		p.temp.reshareRound1Messages[index] = msg
 		if len(p.temp.reshareRound1Messages) == p.ThresHold && CheckFull(p.temp.reshareRound1Messages) {
@@ -303,8 +321,12 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 		}
 		
 		index := msg.GetFromIndex()
-		p.temp.reshareRound3Messages[index] = msg
 		m := msg.(*ReRound3Message)
+		if m.U1PaillierPk == nil || m.U1PaillierPk.N == nil || m.U1PaillierPk.N.BitLen() < ec2.MinPaillierKeyLength || m.U1PaillierPk.N.BitLen() != p.PaillierKeyLength {
+			return false, errors.New("got paillier N with not enough bits")
+		}
+
+		p.temp.reshareRound3Messages[index] = msg
 		p.data.U1PaillierPk[index] = m.U1PaillierPk
 		if len(p.temp.reshareRound3Messages) == p.DNodeCountInGroup && CheckFull(p.temp.reshareRound3Messages) {
 			fmt.Printf("================ StoreMessage,get all ec reshare 3 messages ==============\n")
@@ -319,6 +341,10 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 		index := msg.GetFromIndex()
 		m := msg.(*ReRound4Message)
 
+		if m.U1NtildeH1H2 == nil || m.U1NtildeH1H2.Ntilde == nil || m.U1NtildeH1H2.Ntilde.BitLen() < ec2.MinNtildeLength || m.U1NtildeH1H2.Ntilde.BitLen() != p.PaillierKeyLength {
+			return false, errors.New("got ntilde with not enough bits")
+		}
+
 		////////add for ntilde zk proof check
 		H1 := m.U1NtildeH1H2.H1
 		H2 := m.U1NtildeH1H2.H2
@@ -17,13 +17,24 @@
 package reshare
 
 import (
-	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
-	"strconv"
-	"strings"
 )
 
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&ReRound0Message{},
+		&ReRound1Message{},
+		&ReRound2Message{},
+		&ReRound2Message1{},
+		&ReRound3Message{},
+		&ReRound4Message{},
+		&ReRound5Message{},
+	)
+}
+
 // ReRoundMessage base type of sign round message
 type ReRoundMessage struct {
 	FromID    string   `json:"FromID"` //DNodeID
@@ -71,16 +82,6 @@ func (re *ReRound0Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound0Message to map
-func (re *ReRound0Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "ReRound0Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound0Message) GetMsgType() string {
 	return "ReRound0Message"
@@ -90,6 +91,7 @@ func (re *ReRound0Message) GetMsgType() string {
 type ReRound1Message struct {
 	*ReRoundMessage
 	ComC *big.Int
+	PaillierKeyLength int // bit length of paillier N and Ntilde of the key,the new nodes take it from the old nodes
 }
 
 // GetFromID get the ID of sending nodes in the group
@@ -112,17 +114,6 @@ func (re *ReRound1Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound1Message to map
-func (re *ReRound1Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-	m["ComC"] = fmt.Sprintf("%v", re.ComC)
-	m["Type"] = "ReRound1Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound1Message) GetMsgType() string {
 	return "ReRound1Message"
@@ -156,19 +147,6 @@ func (re *ReRound2Message) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *ReRound2Message to map
-func (re *ReRound2Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = strings.Join(re.ToID, ":")
-	m["ID"] = fmt.Sprintf("%v", re.ID)
-	m["Share"] = fmt.Sprintf("%v", re.Share)
-	m["Type"] = "ReRound2Message"
-	fmt.Printf("\n===========ReRound2Message.OutMap, re.ID = %v,re.Share = %v, FromID = %v ==========\n", m["ID"], m["Share"], m["FromID"])
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound2Message) GetMsgType() string {
 	return "ReRound2Message"
@@ -202,39 +180,6 @@ func (re *ReRound2Message1) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound2Message1 to map
-func (re *ReRound2Message1) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-
-	tmp := make([]string, len(re.ComD))
-	for k, v := range re.ComD {
-		tmp[k] = fmt.Sprintf("%v", v)
-	}
-	m["ComD"] = strings.Join(tmp, ":")
-
-	tmp3 := make([][]string, len(re.SkP1PolyG))
-	for k, v := range re.SkP1PolyG {
-		tmp4 := make([]string, len(v))
-		for kk, vv := range v {
-			tmp4[kk] = fmt.Sprintf("%v", vv)
-		}
-
-		tmp3[k] = tmp4
-	}
-	tmp5 := make([]string, len(tmp3))
-	for k, v := range tmp3 {
-		vv := strings.Join(v, ":")
-		tmp5[k] = vv
-	}
-	m["SkP1PolyG"] = strings.Join(tmp5, "|")
-
-	m["Type"] = "ReRound2Message1"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound2Message1) GetMsgType() string {
 	return "ReRound2Message1"
@@ -266,23 +211,6 @@ func (re *ReRound3Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound3Message to map
-func (re *ReRound3Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-
-	pk, err := re.U1PaillierPk.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	
-	m["U1PaillierPk"] = string(pk)
-	m["Type"] = "ReRound3Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound3Message) GetMsgType() string {
 	return "ReRound3Message"
@@ -318,35 +246,6 @@ func (re *ReRound4Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound4Message to map
-func (re *ReRound4Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-
-	nt, err := re.U1NtildeH1H2.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["U1NtildeH1H2"] = string(nt)
-
-	pf1, err := re.NtildeProof1.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["NtildeProof1"] = string(pf1)
-
-	pf2, err := re.NtildeProof2.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["NtildeProof2"] = string(pf2)
-
-	m["Type"] = "ReRound4Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound4Message) GetMsgType() string {
 	return "ReRound4Message"
@@ -356,6 +255,10 @@ func (re *ReRound4Message) GetMsgType() string {
 type ReRound5Message struct {
 	*ReRoundMessage
 	NewSkOk string
+
+	//add for paillier N zk
+	ModPf *ec2.PaillierBlumProof
+	FacPf []*ec2.NoSmallFactorProof // FacPf[k] is checked with the ntilde of the node k
 }
 
 // GetFromID get the ID of sending nodes in the group
@@ -378,17 +281,6 @@ func (re *ReRound5Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *ReRound5Message to map
-func (re *ReRound5Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = re.FromID
-	m["FromIndex"] = strconv.Itoa(re.FromIndex)
-	m["ToID"] = ""
-	m["NewSkOk"] = re.NewSkOk
-	m["Type"] = "ReRound4Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (re *ReRound5Message) GetMsgType() string {
 	return "ReRound5Message"
@@ -17,6 +17,7 @@
 package reshare
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
@@ -28,9 +29,9 @@ var (
 	zero = big.NewInt(0)
 )
 
-func newRound0(save *keygen.LocalDNodeSaveData, temp *localTempData, out chan<- smpc.Message, end chan<- keygen.LocalDNodeSaveData, dnodeid string, dnodecount int, threshold int, paillierkeylength int, oldnode bool,oldindex int) smpc.Round {
+func newRound0(save *keygen.LocalDNodeSaveData, temp *localTempData, out chan<- smpc.Message, end chan<- keygen.LocalDNodeSaveData, dnodeid string, dnodecount int, threshold int, paillierkeylength int, oldnode bool,oldindex int, curve elliptic.Curve, sessionkey string) smpc.Round {
 	return &round0{
-		&base{save, temp, out, end, make([]bool, dnodecount), false, 0, dnodeid, dnodecount, threshold, paillierkeylength, oldnode,oldindex, nil}}
+		&base{save, temp, out, end, make([]bool, dnodecount), false, 0, dnodeid, dnodecount, threshold, paillierkeylength, oldnode,oldindex, nil, curve, sessionkey}}
 }
 
 // Start  Broadcast current dnode ID to other nodes 
@@ -19,7 +19,6 @@ package reshare
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -68,22 +67,22 @@ func (round *round1) Start() error {
 		}
 
 		sub := new(big.Int).Sub(v, self)
-		subInverse := new(big.Int).ModInverse(sub, secp256k1.S256().N)
+		subInverse := new(big.Int).ModInverse(sub, round.curve.Params().N)
 		if subInverse == nil {
 		    return errors.New("calc times fail")
 		}
 
 		times := new(big.Int).Mul(subInverse, v)
 		lambda1 = new(big.Int).Mul(lambda1, times)
-		lambda1 = new(big.Int).Mod(lambda1, secp256k1.S256().N)
+		lambda1 = new(big.Int).Mod(lambda1, round.curve.Params().N)
 	}
 	w1 := new(big.Int).Mul(lambda1, round.Save.SkU1)
-	w1 = new(big.Int).Mod(w1, secp256k1.S256().N)
+	w1 = new(big.Int).Mod(w1, round.curve.Params().N)
 
 	round.temp.w1 = w1
 
-	skP1Poly, skP1PolyG, _ := ec2.Vss2Init(w1, round.threshold)
-	skP1Gx, skP1Gy := secp256k1.S256().ScalarBaseMult(w1.Bytes())
+	skP1Poly, skP1PolyG, _ := ec2.Vss2Init(round.curve, w1, round.threshold)
+	skP1Gx, skP1Gy := round.curve.ScalarBaseMult(w1.Bytes())
 	u1CommitValues := make([]*big.Int, 0)
 	u1CommitValues = append(u1CommitValues, skP1Gx)
 	u1CommitValues = append(u1CommitValues, skP1Gy)
@@ -103,6 +102,7 @@ func (round *round1) Start() error {
 	re := &ReRound1Message{
 		ReRoundMessage: new(ReRoundMessage),
 		ComC:                commitSkP1G.C,
+		PaillierKeyLength:   round.paillierkeylength,
 	}
 	re.SetFromID(round.dnodeid)
 	re.SetFromIndex(index)
@@ -142,6 +142,13 @@ func (round *round1) Update() (bool, error) {
 		//
 	}
 
+	// the new nodes do not have the key,they use the paillier key length of the old nodes,StoreMessage has checked that all of them are the same
+	if !round.oldnode {
+		if m, ok := round.temp.reshareRound1Messages[0].(*ReRound1Message); ok {
+			round.paillierkeylength = m.PaillierKeyLength
+		}
+	}
+
 	return true, nil
 }
 
@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
-	"math/big"
 	"encoding/hex"
 )
 
@@ -34,13 +33,18 @@ func (round *round2) Start() error {
 	round.started = true
 	round.ResetOK()
 
+	curDNodeID, err := round.GetCurDNodeID()
+	if err != nil {
+		return err
+	}
+
 	if !round.oldnode {
 		ids, err := round.GetIDs()
 		if err != nil {
 			return err
 		}
 		round.Save.IDs = ids
-		round.Save.CurDNodeID, _ = new(big.Int).SetString(round.dnodeid, 10)
+		round.Save.CurDNodeID = curDNodeID
 
 		return nil
 	}
@@ -50,14 +54,14 @@ func (round *round2) Start() error {
 		return err
 	}
 	round.Save.IDs = ids
-	round.Save.CurDNodeID, _ = new(big.Int).SetString(round.dnodeid, 10)
+	round.Save.CurDNodeID = curDNodeID
 
 	dul,err := ec2.ContainsDuplicate(ids)
 	if err != nil || dul || len(ids) > round.dnodecount {
 	    return errors.New("node id error")
 	}
 
-	skP1Shares, err := round.temp.skP1Poly.Vss2(ids)
+	skP1Shares, err := round.temp.skP1Poly.Vss2(round.curve, ids)
 	if err != nil {
 		return err
 	}
@@ -19,7 +19,6 @@ package reshare
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -48,7 +47,7 @@ func (round *round3) Start() error {
 		}
 
 		ps := &ec2.PolyGStruct2{PolyG: msg21.SkP1PolyG}
-		if !ushare.Verify2(ps) {
+		if !ushare.Verify2(round.curve, ps) {
 			fmt.Printf("========= round3 verify share fail, k = %v ==========\n", k)
 			return errors.New("verify share data fail")
 		}
@@ -60,7 +59,7 @@ func (round *round3) Start() error {
 		}
 
 		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg21.ComD}
-		if !deCommit.Verify() {
+		if !deCommit.Verify(round.curve) {
 			fmt.Printf("========= round3 verify commitment fail, k = %v ==========\n", k)
 			return errors.New("verify commitment fail")
 		}
@@ -77,7 +76,7 @@ func (round *round3) Start() error {
 		ushare := &ec2.ShareStruct2{ID: msg2.ID, Share: msg2.Share}
 
 		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg21.ComD}
-		_, u1G := deCommit.DeCommit()
+		_, u1G := deCommit.DeCommit(round.curve)
 		pkx = u1G[0]
 		pky = u1G[1]
 
@@ -97,13 +96,13 @@ func (round *round3) Start() error {
 
 		deCommit := &ec2.Commitment{C: msg1.ComC, D: msg21.ComD}
 
-		_, u1G := deCommit.DeCommit()
-		pkx, pky = secp256k1.S256().Add(pkx, pky, u1G[0], u1G[1])
+		_, u1G := deCommit.DeCommit(round.curve)
+		pkx, pky = round.curve.Add(pkx, pky, u1G[0], u1G[1])
 
 		newskU1 = new(big.Int).Add(newskU1, ushare.Share)
 	}
 
-	newskU1 = new(big.Int).Mod(newskU1, secp256k1.S256().N)
+	newskU1 = new(big.Int).Mod(newskU1, round.curve.Params().N)
 
 	round.Save.SkU1 = newskU1
 	round.Save.Pkx = pkx
@@ -112,9 +111,30 @@ func (round *round3) Start() error {
 	round.temp.pky = pky
 	round.temp.newskU1 = newskU1
 
-	idtmp, ok := new(big.Int).SetString(round.dnodeid, 10)
-	if !ok {
-		return errors.New("get id big number fail")
+	// the new public share of each node is the sum of the public shares of it in the polyG of the old nodes
+	pubShares := make([][]*big.Int, len(round.Save.IDs))
+	for j, id := range round.Save.IDs {
+		var x, y *big.Int
+		for k := range round.temp.reshareRound1Messages {
+			msg21, _ := round.temp.reshareRound2Messages1[k].(*ReRound2Message1)
+			px, py := (&ec2.PolyGStruct2{PolyG: msg21.SkP1PolyG}).PubShare(round.curve, id)
+			if px == nil {
+				return errors.New("calc public share fail")
+			}
+
+			if x == nil {
+				x, y = px, py
+				continue
+			}
+			x, y = round.curve.Add(x, y, px, py)
+		}
+		pubShares[j] = []*big.Int{x, y}
+	}
+	round.Save.PubShares = pubShares
+
+	idtmp, err := round.GetCurDNodeID()
+	if err != nil {
+		return err
 	}
 
 	curIndex := -1
@@ -138,7 +158,21 @@ func (round *round3) Start() error {
 	    u1PaillierSk = round.Save.U1PaillierSk
 	    u1PaillierPk = round.Save.U1PaillierPk[round.oldindex]
 	} else {
-	    u1PaillierPk, u1PaillierSk,_,_ = ec2.GenerateKeyPair(round.paillierkeylength)
+	    if round.paillierkeylength < ec2.MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	    }
+
+	    // use the pre-generated data if there is,so that reshare does not wait for the safe primes
+	    pre := ec2.TakePreParams(round.dnodeid,round.paillierkeylength)
+	    if pre != nil {
+		u1PaillierPk,u1PaillierSk = pre.PaillierPk(),pre.PaillierSk
+		round.temp.preParams = pre
+	    } else {
+		u1PaillierPk, u1PaillierSk,_,_ = ec2.GenerateKeyPair(round.paillierkeylength)
+	    }
+	    if u1PaillierPk == nil || u1PaillierSk == nil {
+		return errors.New("error generating paillier pubkey/private data")
+	    }
 	}
 
 	//round.Save.U1PaillierSk = u1PaillierSk
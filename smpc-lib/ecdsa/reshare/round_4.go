@@ -33,9 +33,9 @@ func (round *round4) Start() error {
 	round.started = true
 	round.ResetOK()
 
-	idtmp, ok := new(big.Int).SetString(round.dnodeid, 10)
-	if !ok {
-		return errors.New("get id big number fail")
+	idtmp, err := round.GetCurDNodeID()
+	if err != nil {
+		return err
 	}
 
 	curIndex := -1
@@ -56,6 +56,8 @@ func (round *round4) Start() error {
 	var beta *big.Int
 	var p *big.Int
 	var q *big.Int
+	var ntildeProof1 *ec2.NtildeProof
+	var ntildeProof2 *ec2.NtildeProof
 
 	if round.oldnode && round.oldindex != -1 {
 	    u1NtildeH1H2 = round.Save.U1NtildeH1H2[round.oldindex]
@@ -63,17 +65,27 @@ func (round *round4) Start() error {
 	    beta = round.Save.U1NtildePrivData.Beta
 	    p = round.Save.U1NtildePrivData.Q1
 	    q = round.Save.U1NtildePrivData.Q2
+	} else if pre := round.temp.preParams; pre != nil {
+	    u1NtildeH1H2 = pre.NtildeH1H2
+	    alpha = pre.NtildePriv.Alpha
+	    beta = pre.NtildePriv.Beta
+	    p = pre.NtildePriv.Q1
+	    q = pre.NtildePriv.Q2
+	    ntildeProof1 = pre.NtildeProof1
+	    ntildeProof2 = pre.NtildeProof2
 	} else {
-	    NtildeLength := 2048
-	    u1NtildeH1H2, alpha, beta, p, q,_,_ = ec2.GenerateNtildeH1H2(NtildeLength)
+	    // the length of ntilde is the same as paillier N
+	    u1NtildeH1H2, alpha, beta, p, q,_,_ = ec2.GenerateNtildeH1H2(round.paillierkeylength)
 	    if u1NtildeH1H2 == nil {
 		    return errors.New("gen ntilde h1 h2 fail")
 	    }
 
 	}
 
-	ntildeProof1 := ec2.NewNtildeProof(u1NtildeH1H2.H1, u1NtildeH1H2.H2, alpha, p, q, u1NtildeH1H2.Ntilde)
-	ntildeProof2 := ec2.NewNtildeProof(u1NtildeH1H2.H2, u1NtildeH1H2.H1, beta, p, q, u1NtildeH1H2.Ntilde)
+	if ntildeProof1 == nil || ntildeProof2 == nil {
+	    ntildeProof1 = ec2.NewNtildeProof(u1NtildeH1H2.H1, u1NtildeH1H2.H2, alpha, p, q, u1NtildeH1H2.Ntilde)
+	    ntildeProof2 = ec2.NewNtildeProof(u1NtildeH1H2.H2, u1NtildeH1H2.H1, beta, p, q, u1NtildeH1H2.Ntilde)
+	}
 
 	re := &ReRound4Message{
 		ReRoundMessage: new(ReRoundMessage),
@@ -18,7 +18,7 @@ package reshare
 
 import (
 	"errors"
-	"math/big"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
 
@@ -31,9 +31,9 @@ func (round *round5) Start() error {
 	round.started = true
 	round.ResetOK()
 
-	idtmp, ok := new(big.Int).SetString(round.dnodeid, 10)
-	if !ok {
-		return errors.New("get id big number fail")
+	idtmp, err := round.GetCurDNodeID()
+	if err != nil {
+		return err
 	}
 
 	curIndex := -1
@@ -48,9 +48,39 @@ func (round *round5) Start() error {
 		return errors.New("get cur index fail")
 	}
 
+	// add for CGGMP21: prove that paillier N is a paillier-blum modulus and has no small factor, the no small factor proof use the ntilde of every receiver
+	// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts, section 6.3 and appendix C.5
+	paiN := round.temp.u1PaillierSk.N
+	p,q := round.temp.u1PaillierSk.Primes()
+	if p == nil || q == nil {
+	    return errors.New("get paillier primes fail")
+	}
+
+	// the challenges are bound to the session,the prover and the round,so the prover can not choose them
+	ctx := round.proofContext(round.Save.IDs[curIndex], round.number)
+	modProof := ec2.PaillierBlumProve(ctx,paiN,p,q)
+	if modProof == nil {
+	    return errors.New("get paillier-blum modulus proof fail")
+	}
+
+	facProof := make([]*ec2.NoSmallFactorProof,len(round.temp.reshareRound4Messages))
+	for k,msg := range round.temp.reshareRound4Messages {
+	    msg4,ok := msg.(*ReRound4Message)
+	    if !ok {
+		return errors.New("get reshare round 4 msg fail")
+	    }
+
+	    facProof[k] = ec2.NoSmallFactorProve(ctx,paiN,p,q,msg4.U1NtildeH1H2)
+	    if facProof[k] == nil {
+		return errors.New("get no small factor proof fail")
+	    }
+	}
+
 	re := &ReRound5Message{
 		ReRoundMessage: new(ReRoundMessage),
 		NewSkOk:             "TRUE",
+		ModPf:		modProof,
+		FacPf:		facProof,
 	}
 	re.SetFromID(round.dnodeid)
 	re.SetFromIndex(curIndex)
@@ -18,8 +18,9 @@ package reshare
 
 import (
 	"errors"
+	"fmt"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
-	"math/big"
 )
 
 // Start return save data
@@ -31,9 +32,9 @@ func (round *round6) Start() error {
 	round.started = true
 	round.ResetOK()
 
-	idtmp, ok := new(big.Int).SetString(round.dnodeid, 10)
-	if !ok {
-		return errors.New("get id big number fail")
+	idtmp, err := round.GetCurDNodeID()
+	if err != nil {
+		return err
 	}
 
 	curIndex := -1
@@ -48,6 +49,31 @@ func (round *round6) Start() error {
 		return errors.New("get cur index fail")
 	}
 
+	// add for CGGMP21: check the paillier-blum modulus proof and the no small factor proof of every paillier N, the no small factor proof is checked with our own ntilde
+	// see Paper: UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable Aborts, section 6.3 and appendix C.5
+	for k,msg := range round.temp.reshareRound5Messages {
+	    msg5,ok := msg.(*ReRound5Message)
+	    if !ok {
+		return errors.New("get reshare round 5 msg fail")
+	    }
+
+	    msg3,ok := round.temp.reshareRound3Messages[k].(*ReRound3Message)
+	    if !ok || msg3.U1PaillierPk == nil {
+		return errors.New("get reshare round 3 msg fail")
+	    }
+
+	    ctx := round.proofContext(round.Save.IDs[k], 5)
+	    if !ec2.PaillierBlumVerify(ctx,msg3.U1PaillierPk.N,msg5.ModPf) {
+		fmt.Printf("==========================reshare round6,check that a zero-knowledge proof that paillier N is a paillier-blum modulus fail, k = %v============================\n",k)
+		return errors.New("check that a zero-knowledge proof that paillier N is a paillier-blum modulus fail")
+	    }
+
+	    if len(msg5.FacPf) != len(round.temp.reshareRound5Messages) || !ec2.NoSmallFactorVerify(ctx,msg3.U1PaillierPk.N,round.temp.u1NtildeH1H2,msg5.FacPf[curIndex]) {
+		fmt.Printf("==========================reshare round6,check that a zero-knowledge proof that paillier N has no small factor fail, k = %v============================\n",k)
+		return errors.New("check that a zero-knowledge proof that paillier N has no small factor fail")
+	    }
+	}
+
 	round.Save.SkU1 = round.temp.newskU1
 	round.Save.Pkx = round.temp.pkx
 	round.Save.Pky = round.temp.pky
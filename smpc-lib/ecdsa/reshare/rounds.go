@@ -17,9 +17,11 @@
 package reshare
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
 	"sort"
@@ -43,6 +45,8 @@ type (
 		oldindex           int
 		//add for check msg0
 		idreshare smpc.SortableIDSSlice
+		curve     elliptic.Curve
+		sessionkey string
 	}
 	round0 struct {
 		*base
@@ -73,6 +77,11 @@ func (round *base) RoundNumber() int {
 	return round.number
 }
 
+// proofContext get the context of the zk proof made by the party id in round number
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
 func (round *base) CanProceed() bool {
 	if !round.started {
 		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
@@ -104,6 +113,21 @@ func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
 	return ids, nil
 }
 
+// GetCurDNodeID get the uid of current node, dnodeid is the hex of the uid string
+func (round *base) GetCurDNodeID() (*big.Int, error) {
+	uidtmp, err := hex.DecodeString(round.dnodeid)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, ok := new(big.Int).SetString(string(uidtmp[:]), 10)
+	if !ok {
+		return nil, errors.New("get id big number fail")
+	}
+
+	return uid, nil
+}
+
 // GetDNodeIDIndex get from threshold group
 func (round *base) GetDNodeIDIndex(id string) (int, error) {
 	if id == "" {
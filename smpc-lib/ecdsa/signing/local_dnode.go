@@ -18,6 +18,7 @@
 package signing
 
 import (
+	"crypto/elliptic"
 	"fmt"
 	"time"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
@@ -40,6 +41,7 @@ type LocalDNode struct {
 	predata      *PrePubData
 	txhash       *big.Int
 	finalizeend chan<- *big.Int
+	curve        elliptic.Curve
 }
 
 // localTempData  Store some data of MPC calculation process 
@@ -114,6 +116,7 @@ func NewLocalDNode(
 	predata *PrePubData,
 	txhash *big.Int,
 	finalizeend chan<- *big.Int,
+	keytype string,
 ) smpc.DNode {
 
 	p := &LocalDNode{
@@ -126,6 +129,7 @@ func NewLocalDNode(
 		predata:      predata,
 		txhash:       txhash,
 		finalizeend: finalizeend,
+		curve:        ec2.GetCurve(keytype),
 	}
 
 	p.ID = fmt.Sprintf("%v", kgid)
@@ -150,12 +154,12 @@ func NewLocalDNode(
 
 // FinalizeRound get finalize round
 func (p *LocalDNode) FinalizeRound() smpc.Round {
-	return newRound10(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.PaillierKeyLength, p.predata, p.txhash, p.finalizeend)
+	return newRound10(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.PaillierKeyLength, p.predata, p.txhash, p.finalizeend, p.curve, p.SessionKey)
 }
 
 // FirstRound first round
 func (p *LocalDNode) FirstRound() smpc.Round {
-	return newRound1(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.PaillierKeyLength)
+	return newRound1(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.PaillierKeyLength, p.curve, p.SessionKey)
 }
 
 // Start signing start 
@@ -342,14 +346,16 @@ func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
 		m := msg.(*SignRound5Message)
 
 		// check tproof
-		hx,hy,err := ec2.CalcHPoint()
+		hx,hy,err := ec2.CalcHPoint(p.curve)
 		if err != nil {
 		    fmt.Printf("calc h point fail, err = %v",err)
 		    return false,err 
 		}
 
-		if !ec2.TVerify(m.T1X,m.T1Y,hx,hy,m.Tpf) {
-		    return false,fmt.Errorf("verify tproof fail")
+		// the proof is made in round 5 by the sender
+		ctx := ec2.NewProofContext(p.SessionKey, smpc.GetUIDByDNodeID(msg.GetFromID()), 5)
+		if !ec2.TVerify(p.curve, ctx, m.T1X,m.T1Y,hx,hy,m.Tpf) {
+		    return false,smpc.NewBlameError(msg.GetFromID(), 5, "TProof", fmt.Errorf("verify tproof fail"))
 		}
 		//
 
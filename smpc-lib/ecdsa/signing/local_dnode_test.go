@@ -18,6 +18,7 @@
 package signing_test
 
 import (
+	"math/big"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
@@ -25,6 +26,7 @@ import (
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
 )
 
 func TestCheckFull(t *testing.T) {
@@ -57,3 +59,132 @@ func TestCheckFull(t *testing.T) {
 }
 
 
+
+func TestEC256R1Proofs(t *testing.T) {
+	curve := ec2.GetCurve("EC256R1")
+	assert.False(t, ec2.IsSecp256k1(curve), "fail")
+
+	u1 := random.GetRandomIntFromZn(curve.Params().N)
+	u1Gx, u1Gy := curve.ScalarBaseMult(u1.Bytes())
+	ctx := ec2.NewProofContext("EC256R1", big.NewInt(1), 6)
+	zku := ec2.ZkUProve(curve, ctx, u1)
+	assert.True(t, ec2.ZkUVerify(curve, ctx, []*big.Int{u1Gx, u1Gy}, zku), "fail")
+	assert.False(t, ec2.ZkUVerify(secp256k1.S256(), ctx, []*big.Int{u1Gx, u1Gy}, zku), "success")
+
+	commit := new(ec2.Commitment).Commit(u1Gx, u1Gy)
+	succ, secrets := commit.DeCommit(curve)
+	assert.True(t, succ, "fail")
+	assert.Equal(t, 0, secrets[0].Cmp(u1Gx))
+
+	hx, hy, err := ec2.CalcHPoint(curve)
+	assert.Nil(t, err)
+	assert.True(t, curve.IsOnCurve(hx, hy), "fail")
+
+	sigma1 := random.GetRandomIntFromZn(curve.Params().N)
+	l1 := random.GetRandomIntFromZn(curve.Params().N)
+	sigmaGx, sigmaGy := curve.ScalarBaseMult(sigma1.Bytes())
+	l1Hx, l1Hy := curve.ScalarMult(hx, hy, l1.Bytes())
+	t1X, t1Y := curve.Add(sigmaGx, sigmaGy, l1Hx, l1Hy)
+	tpf := ec2.TProve(curve, ctx, t1X, t1Y, hx, hy, sigma1, l1)
+	assert.True(t, ec2.TVerify(curve, ctx, t1X, t1Y, hx, hy, tpf), "fail")
+
+	poly, polyG, err := ec2.Vss2Init(curve, u1, 2)
+	assert.Nil(t, err)
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	shares, err := poly.Vss2(curve, ids)
+	assert.Nil(t, err)
+	for _, share := range shares {
+		assert.True(t, share.Verify2(curve, polyG), "fail")
+	}
+	secret, err := ec2.Combine2(curve, shares[:2])
+	assert.Nil(t, err)
+	assert.Equal(t, 0, secret.Cmp(u1))
+}
+
+// replayContexts the contexts that differ from ctx in exactly one of session key, prover and round
+func replayContexts(ctx *ec2.ProofContext) map[string]*ec2.ProofContext {
+	return map[string]*ec2.ProofContext{
+		"session": ec2.NewProofContext(ctx.SessionKey+"x", ctx.PartyID, ctx.Round),
+		"party":   ec2.NewProofContext(ctx.SessionKey, new(big.Int).Add(ctx.PartyID, big.NewInt(1)), ctx.Round),
+		"round":   ec2.NewProofContext(ctx.SessionKey, ctx.PartyID, ctx.Round+1),
+		"empty":   nil,
+	}
+}
+
+// TestProofContextReplay every proof verifies in the context it is made in, and a replay in any other context is rejected
+func TestProofContextReplay(t *testing.T) {
+	curve := secp256k1.S256()
+	ctx := ec2.NewProofContext("0xsession", big.NewInt(7), 2)
+	pre, err := simulate.TestPreParams(0)
+	assert.Nil(t, err)
+	pk := pre.PaillierPk()
+	nt := pre.NtildeH1H2
+
+	// ZkU and ZkXi
+	u1 := random.GetRandomIntFromZn(curve.N)
+	u1Gx, u1Gy := curve.ScalarBaseMult(u1.Bytes())
+	zku := ec2.ZkUProve(curve, ctx, u1)
+	zkxi := ec2.ZkXiProve(curve, ctx, u1)
+	assert.True(t, ec2.ZkUVerify(curve, ctx, []*big.Int{u1Gx, u1Gy}, zku), "ZkU")
+	assert.True(t, ec2.ZkXiVerify(curve, ctx, []*big.Int{u1Gx, u1Gy}, zkxi), "ZkXi")
+
+	// T and ST
+	hx, hy, err := ec2.CalcHPoint(curve)
+	assert.Nil(t, err)
+	sigma1 := random.GetRandomIntFromZn(curve.N)
+	l1 := random.GetRandomIntFromZn(curve.N)
+	sigmaGx, sigmaGy := curve.ScalarBaseMult(sigma1.Bytes())
+	l1Hx, l1Hy := curve.ScalarMult(hx, hy, l1.Bytes())
+	t1X, t1Y := curve.Add(sigmaGx, sigmaGy, l1Hx, l1Hy)
+	s1X, s1Y := curve.ScalarMult(u1Gx, u1Gy, sigma1.Bytes())
+	tpf := ec2.TProve(curve, ctx, t1X, t1Y, hx, hy, sigma1, l1)
+	stpf := ec2.NewSTProof(curve, ctx, t1X, t1Y, s1X, s1Y, u1Gx, u1Gy, hx, hy, sigma1, l1)
+	assert.True(t, ec2.TVerify(curve, ctx, t1X, t1Y, hx, hy, tpf), "T")
+	assert.True(t, ec2.STVerify(curve, ctx, s1X, s1Y, t1X, t1Y, u1Gx, u1Gy, hx, hy, stpf), "ST")
+
+	// MtA range proof
+	k := random.GetRandomIntFromZn(curve.N)
+	kc, kr, err := pk.Encrypt(k)
+	assert.Nil(t, err)
+	rangepf := ec2.MtARangeProofProve(curve, ctx, kc, k, kr, pk, nt)
+	assert.True(t, rangepf.MtARangeProofVerify(curve, ctx, kc, pk, nt), "MtARange")
+
+	// MtA and MtAwc response proofs
+	beta := random.GetRandomIntFromZn(curve.N)
+	c2 := pk.HomoMul(kc, u1)
+	betac, betar, err := pk.Encrypt(beta)
+	assert.Nil(t, err)
+	c2 = pk.HomoAdd(c2, betac)
+	resppf := ec2.MtARespZKProofProve(curve, ctx, u1, beta, betar, kc, c2, pk, nt)
+	wcpf := ec2.MtAwcRespZKProofProve(curve, ctx, u1, beta, betar, kc, c2, pk, nt)
+	assert.True(t, resppf.MtARespZKProofVerify(curve, ctx, kc, c2, pk, nt), "MtAResp")
+	assert.True(t, wcpf.MtAwcRespZKProofVefify(curve, ctx, []*big.Int{u1Gx, u1Gy}, kc, c2, pk, nt), "MtAwcResp")
+
+	// PDL with slack
+	k1Rx, k1Ry := curve.ScalarMult(u1Gx, u1Gy, k.Bytes())
+	st := &ec2.PDLwSlackStatement{
+		PK:         pk,
+		CipherText: kc,
+		K1RX:       k1Rx,
+		K1RY:       k1Ry,
+		Rx:         u1Gx,
+		Ry:         u1Gy,
+		H1:         nt.H1,
+		H2:         nt.H2,
+		NTilde:     nt.Ntilde,
+	}
+	wit := &ec2.PDLwSlackWitness{SK: pre.PaillierSk, K1: k, K1Ra: kr}
+	pdlpf := ec2.NewPDLwSlackProof(curve, ctx, wit, st)
+	assert.True(t, ec2.PDLwSlackVerify(curve, ctx, st, pdlpf), "PDLwSlack")
+
+	for name, other := range replayContexts(ctx) {
+		assert.False(t, ec2.ZkUVerify(curve, other, []*big.Int{u1Gx, u1Gy}, zku), "ZkU replayed, "+name)
+		assert.False(t, ec2.ZkXiVerify(curve, other, []*big.Int{u1Gx, u1Gy}, zkxi), "ZkXi replayed, "+name)
+		assert.False(t, ec2.TVerify(curve, other, t1X, t1Y, hx, hy, tpf), "T replayed, "+name)
+		assert.False(t, ec2.STVerify(curve, other, s1X, s1Y, t1X, t1Y, u1Gx, u1Gy, hx, hy, stpf), "ST replayed, "+name)
+		assert.False(t, rangepf.MtARangeProofVerify(curve, other, kc, pk, nt), "MtARange replayed, "+name)
+		assert.False(t, resppf.MtARespZKProofVerify(curve, other, kc, c2, pk, nt), "MtAResp replayed, "+name)
+		assert.False(t, wcpf.MtAwcRespZKProofVefify(curve, other, []*big.Int{u1Gx, u1Gy}, kc, c2, pk, nt), "MtAwcResp replayed, "+name)
+		assert.False(t, ec2.PDLwSlackVerify(curve, other, st, pdlpf), "PDLwSlack replayed, "+name)
+	}
+}
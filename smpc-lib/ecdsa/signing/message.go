@@ -17,13 +17,27 @@
 package signing
 
 import (
-	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
-	"strconv"
-	"strings"
 )
 
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&SignRound1Message{},
+		&SignRound2Message{},
+		&SignRound3Message{},
+		&SignRound4Message{},
+		&SignRound4Message1{},
+		&SignRound5Message{},
+		&SignRound6Message{},
+		&SignRound7Message{},
+		&SignRound8Message{},
+		&SignRound9Message{},
+	)
+}
+
 // SignRoundMessage base type of sign round message
 type SignRoundMessage struct {
 	FromID    string   `json:"FromID"` //DNodeID
@@ -76,19 +90,6 @@ func (srm *SignRound1Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound5Message to map
-func (srm *SignRound1Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound1Message"
-	m["C11"] = fmt.Sprintf("%v", srm.C11)
-	m["ComWiC"] = fmt.Sprintf("%v", srm.ComWiC)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound1Message) GetMsgType() string {
 	return "SignRound1Message"
@@ -121,24 +122,6 @@ func (srm *SignRound2Message) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *SignRound2Message to map
-func (srm *SignRound2Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = strings.Join(srm.ToID, ":")
-	m["Type"] = "SignRound2Message"
-
-	proof, err := srm.U1u1MtAZK1Proof.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-
-	m["U1u1MtAZK1Proof"] = string(proof)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound2Message) GetMsgType() string {
 	return "SignRound2Message"
@@ -171,25 +154,6 @@ func (srm *SignRound3Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound3Message to map
-func (srm *SignRound3Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound3Message"
-	m["Kc"] = fmt.Sprintf("%v", srm.Kc)
-
-	dtmp := make([]string, len(srm.ComWiD))
-	for k, v := range srm.ComWiD {
-		dtmp[k] = fmt.Sprintf("%v", v)
-	}
-
-	m["ComWiD"] = strings.Join(dtmp, ":")
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound3Message) GetMsgType() string {
 	return "SignRound3Message"
@@ -222,25 +186,6 @@ func (srm *SignRound4Message) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *SignRound4Message to map
-func (srm *SignRound4Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = strings.Join(srm.ToID, ":")
-	m["Type"] = "SignRound4Message"
-
-	proof, err := srm.U1u1MtAZK2Proof.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-
-	m["U1u1MtAZK2Proof"] = string(proof)
-	m["U1KGamma1Cipher"] = fmt.Sprintf("%v", srm.U1KGamma1Cipher)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound4Message) GetMsgType() string {
 	return "SignRound4Message"
@@ -273,25 +218,6 @@ func (srm *SignRound4Message1) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *SignRound4Message1 to map
-func (srm *SignRound4Message1) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = strings.Join(srm.ToID, ":")
-	m["Type"] = "SignRound4Message1"
-
-	proof, err := srm.U1u1MtAZK3Proof.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-
-	m["U1u1MtAZK3Proof"] = string(proof)
-	m["U1Kw1Cipher"] = fmt.Sprintf("%v", srm.U1Kw1Cipher)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound4Message1) GetMsgType() string {
 	return "SignRound4Message1"
@@ -326,27 +252,6 @@ func (srm *SignRound5Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound5Message to map
-func (srm *SignRound5Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound5Message"
-
-	m["Delta1"] = fmt.Sprintf("%v", srm.Delta1)
-	m["T1X"] = fmt.Sprintf("%v", srm.T1X)
-	m["T1Y"] = fmt.Sprintf("%v", srm.T1Y)
-
-	proof, err := srm.Tpf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-
-	m["Tpf"] = string(proof) 
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound5Message) GetMsgType() string {
 	return "SignRound5Message"
@@ -379,30 +284,6 @@ func (srm *SignRound6Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound6Message to map
-func (srm *SignRound6Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound6Message"
-
-	proof, err := srm.U1GammaZKProof.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["U1GammaZKProof"] = string(proof)
-
-	dtmp := make([]string, len(srm.CommU1D))
-	for k, v := range srm.CommU1D {
-		dtmp[k] = fmt.Sprintf("%v", v)
-	}
-
-	m["CommU1D"] = strings.Join(dtmp, ":")
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound6Message) GetMsgType() string {
 	return "SignRound6Message"
@@ -436,26 +317,6 @@ func (srm *SignRound7Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound7Message to map
-func (srm *SignRound7Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound7Message"
-
-	m["K1RX"] = fmt.Sprintf("%v", srm.K1RX)
-	m["K1RY"] = fmt.Sprintf("%v", srm.K1RY)
-
-	proof, err := srm.PdlwSlackPf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["PdlwSlackPf"] = string(proof)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound7Message) GetMsgType() string {
 	return "SignRound7Message"
@@ -489,26 +350,6 @@ func (srm *SignRound8Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound8Message to map
-func (srm *SignRound8Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound8Message"
-
-	m["S1X"] = fmt.Sprintf("%v", srm.S1X)
-	m["S1Y"] = fmt.Sprintf("%v", srm.S1Y)
-
-	proof, err := srm.STpf.MarshalJSON()
-	if err != nil {
-	    return nil
-	}
-	m["STpf"] = string(proof)
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound8Message) GetMsgType() string {
 	return "SignRound8Message"
@@ -540,18 +381,6 @@ func (srm *SignRound9Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound9Message to map
-func (srm *SignRound9Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound9Message"
-
-	m["Us1"] = fmt.Sprintf("%v", srm.Us1)
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound9Message) GetMsgType() string {
 	return "SignRound9Message"
@@ -17,9 +17,9 @@
 package signing
 
 import (
+	"crypto/elliptic"
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
@@ -31,10 +31,10 @@ var (
 	zero = big.NewInt(0)
 )
 
-func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- PrePubData, kgid string, threshold int, paillierkeylength int) smpc.Round {
+func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- PrePubData, kgid string, threshold int, paillierkeylength int, curve elliptic.Curve, sessionkey string) smpc.Round {
 	finalizeendCh := make(chan *big.Int, threshold)
 	return &round1{
-		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, paillierkeylength, nil, nil, finalizeendCh}}
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, paillierkeylength, nil, nil, finalizeendCh, curve, sessionkey}}
 }
 
 // Start calc w1 and u1Gamma k1
@@ -52,6 +52,10 @@ func (round *round1) Start() error {
 		return err
 	}
 
+	if err := round.CheckPaillierKeyLength(); err != nil {
+		return err
+	}
+
 	var self *big.Int
 	lambda1 := big.NewInt(1)
 	for k, v := range round.idsign {
@@ -71,31 +75,31 @@ func (round *round1) Start() error {
 		}
 
 		sub := new(big.Int).Sub(v, self)
-		subInverse := new(big.Int).ModInverse(sub, secp256k1.S256().N)
+		subInverse := new(big.Int).ModInverse(sub, round.curve.Params().N)
 		if subInverse == nil {
 		    return errors.New("calc times fail")
 		}
 
 		times := new(big.Int).Mul(subInverse, v)
 		lambda1 = new(big.Int).Mul(lambda1, times)
-		lambda1 = new(big.Int).Mod(lambda1, secp256k1.S256().N)
+		lambda1 = new(big.Int).Mod(lambda1, round.curve.Params().N)
 	}
 	w1 := new(big.Int).Mul(lambda1, round.save.SkU1)
-	w1 = new(big.Int).Mod(w1, secp256k1.S256().N)
+	w1 = new(big.Int).Mod(w1, round.curve.Params().N)
 
 	round.temp.w1 = w1
 
-	u1K := random.GetRandomIntFromZn(secp256k1.S256().N)
-	u1Gamma := random.GetRandomIntFromZn(secp256k1.S256().N)
+	u1K := random.GetRandomIntFromZn(round.curve.Params().N)
+	u1Gamma := random.GetRandomIntFromZn(round.curve.Params().N)
 
-	u1GammaGx, u1GammaGy := secp256k1.S256().ScalarBaseMult(u1Gamma.Bytes())
+	u1GammaGx, u1GammaGy := round.curve.ScalarBaseMult(u1Gamma.Bytes())
 	commitU1GammaG := new(ec2.Commitment).Commit(u1GammaGx, u1GammaGy)
 	if commitU1GammaG == nil {
 		return errors.New(" Error generating commitment data in signing round 1")
 	}
 
 	// add for GG18 A.2 Respondent ZK Proof for MtAwc
-	wiGx, wiGy := secp256k1.S256().ScalarBaseMult(round.temp.w1.Bytes())
+	wiGx, wiGy := round.curve.ScalarBaseMult(round.temp.w1.Bytes())
 	commitwiG := new(ec2.Commitment).Commit(wiGx, wiGy)
 	if commitwiG == nil {
 	    return errors.New(" Error generating commitment data for wi")
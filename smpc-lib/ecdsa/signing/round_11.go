@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
 )
@@ -46,7 +45,7 @@ func (round *round11) Start() error {
 		msg9, _ := round.temp.signRound9Messages[k].(*SignRound9Message)
 		s = new(big.Int).Add(s, msg9.Us1)
 	}
-	s = new(big.Int).Mod(s, secp256k1.S256().N)
+	s = new(big.Int).Mod(s, round.curve.Params().N)
 
 	round.finalizeend <- s
 	//fmt.Printf("============= round9.start success, current node id = %v =======\n", round.kgid)
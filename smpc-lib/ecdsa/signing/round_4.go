@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
@@ -51,7 +50,8 @@ func (round *round4) Start() error {
 		}
 	}
 
-	for k, v := range round.idsign {
+	err = smpc.VerifyParallel(len(round.idsign), func(k int) error {
+		v := round.idsign[k]
 		index := -1
 		for kk, vv := range round.save.IDs {
 			if v.Cmp(vv) == 0 {
@@ -66,18 +66,18 @@ func (round *round4) Start() error {
 		if k == curIndex {
 			u1PaillierPk := round.save.U1PaillierPk[index]
 			u1nt := round.save.U1NtildeH1H2[index]
-			u1rlt1 := msg2.U1u1MtAZK1Proof.MtARangeProofVerify(msg3.Kc, u1PaillierPk, u1nt)
+			u1rlt1 := msg2.U1u1MtAZK1Proof.MtARangeProofVerify(round.curve, round.proofContext(v, 2), msg3.Kc, u1PaillierPk, u1nt)
 			if !u1rlt1 {
 				log.Error("=====================round4.start,verify mtazk1 proof fail===================","msg2",*msg2,"msg3",*msg3,"index",index,"oldindex",oldindex,"idsign",round.idsign,"save.IDs",round.save.IDs,"curIndex",curIndex)
-				return errors.New("verify mtazk1 proof fail")
+				return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "MtARangeProof", errors.New("verify mtazk1 proof fail"))
 			}
 		} else {
 			u1PaillierPk := round.save.U1PaillierPk[index]
 			u1nt := round.save.U1NtildeH1H2[oldindex]
-			u1rlt1 := msg2.U1u1MtAZK1Proof.MtARangeProofVerify(msg3.Kc, u1PaillierPk, u1nt)
+			u1rlt1 := msg2.U1u1MtAZK1Proof.MtARangeProofVerify(round.curve, round.proofContext(v, 2), msg3.Kc, u1PaillierPk, u1nt)
 			if !u1rlt1 {
 				log.Error("=====================round4.start,verify mtazk1 proof fail===================","msg2",*msg2,"msg3",*msg3,"index",index,"oldindex",oldindex,"idsign",round.idsign,"save.IDs",round.save.IDs,"curIndex",curIndex,"k",k)
-				return errors.New("verify mtazk1 proof fail")
+				return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "MtARangeProof", errors.New("verify mtazk1 proof fail"))
 			}
 		}
 
@@ -85,14 +85,19 @@ func (round *round4) Start() error {
 		// check commitment
 		msg1, _ := round.temp.signRound1Messages[k].(*SignRound1Message)
 		deCommit := &ec2.Commitment{C: msg1.ComWiC, D: msg3.ComWiD}
-		if !deCommit.Verify() {
+		if !deCommit.Verify(round.curve) {
 			log.Error("=====================round4.start,verify commit for wi fail================","msg1",*msg1,"msg3",*msg3,"index",index,"oldindex",oldindex,"idsign",round.idsign,"save.IDs",round.save.IDs,"curIndex",curIndex,"k",k)
-		    return errors.New("verify commit for wi fail")
+		    return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "ComWiCommitment", errors.New("verify commit for wi fail"))
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	NSalt := new(big.Int).Lsh(big.NewInt(1), uint(round.paillierkeylength-round.paillierkeylength/10))
-	NSubN2 := new(big.Int).Mul(secp256k1.S256().N, secp256k1.S256().N)
+	NSubN2 := new(big.Int).Mul(round.curve.Params().N, round.curve.Params().N)
 	NSubN2 = new(big.Int).Sub(NSalt, NSubN2)
 	// 2. MinusOne
 	MinusOne := big.NewInt(-1)
@@ -135,7 +140,7 @@ func (round *round4) Start() error {
 			u1KGamma1Cipher := u1PaillierPk.HomoMul(msg3.Kc, round.temp.u1Gamma)
 			beta1U1StarCipher, u1BetaR1, _ := u1PaillierPk.Encrypt(betaU1Star[k])
 			u1KGamma1Cipher = u1PaillierPk.HomoAdd(u1KGamma1Cipher, beta1U1StarCipher)
-			u1u1MtAZK2Proof := ec2.MtARespZKProofProve(round.temp.u1Gamma, betaU1Star[k], u1BetaR1, round.temp.ukc, u1KGamma1Cipher,round.save.U1PaillierPk[oldindex], round.save.U1NtildeH1H2[oldindex])
+			u1u1MtAZK2Proof := ec2.MtARespZKProofProve(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.u1Gamma, betaU1Star[k], u1BetaR1, round.temp.ukc, u1KGamma1Cipher,round.save.U1PaillierPk[oldindex], round.save.U1NtildeH1H2[oldindex])
 
 			srm := &SignRound4Message{
 				SignRoundMessage: new(SignRoundMessage),
@@ -152,7 +157,7 @@ func (round *round4) Start() error {
 			u1KGamma1Cipher := u1PaillierPk.HomoMul(msg3.Kc, round.temp.u1Gamma)
 			beta1U1StarCipher, u1BetaR1, _ := u1PaillierPk.Encrypt(betaU1Star[k])
 			u1KGamma1Cipher = u1PaillierPk.HomoAdd(u1KGamma1Cipher, beta1U1StarCipher)
-			u1u1MtAZK2Proof := ec2.MtARespZKProofProve(round.temp.u1Gamma, betaU1Star[k], u1BetaR1, msg3.Kc, u1KGamma1Cipher,u1PaillierPk, round.save.U1NtildeH1H2[oldindex])
+			u1u1MtAZK2Proof := ec2.MtARespZKProofProve(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.u1Gamma, betaU1Star[k], u1BetaR1, msg3.Kc, u1KGamma1Cipher,u1PaillierPk, round.save.U1NtildeH1H2[oldindex])
 
 			srm := &SignRound4Message{
 				SignRoundMessage: new(SignRoundMessage),
@@ -185,7 +190,7 @@ func (round *round4) Start() error {
 			u1Kw1Cipher := u1PaillierPk.HomoMul(msg3.Kc, round.temp.w1)
 			v1U1StarCipher, u1VR1, _ := u1PaillierPk.Encrypt(vU1Star[k])
 			u1Kw1Cipher = u1PaillierPk.HomoAdd(u1Kw1Cipher, v1U1StarCipher) // send to u1
-			u1u1MtAZK3Proof := ec2.MtAwcRespZKProofProve(round.temp.w1, vU1Star[k], u1VR1, round.temp.ukc,u1Kw1Cipher,round.save.U1PaillierPk[oldindex], round.save.U1NtildeH1H2[oldindex])
+			u1u1MtAZK3Proof := ec2.MtAwcRespZKProofProve(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.w1, vU1Star[k], u1VR1, round.temp.ukc,u1Kw1Cipher,round.save.U1PaillierPk[oldindex], round.save.U1NtildeH1H2[oldindex])
 
 			srm := &SignRound4Message1{
 				SignRoundMessage: new(SignRoundMessage),
@@ -202,7 +207,7 @@ func (round *round4) Start() error {
 			u1Kw1Cipher := u1PaillierPk.HomoMul(msg3.Kc, round.temp.w1)
 			v1U1StarCipher, u1VR1, _ := u1PaillierPk.Encrypt(vU1Star[k])
 			u1Kw1Cipher = u1PaillierPk.HomoAdd(u1Kw1Cipher, v1U1StarCipher) // send to u1
-			u1u1MtAZK3Proof := ec2.MtAwcRespZKProofProve(round.temp.w1, vU1Star[k], u1VR1, msg3.Kc, u1Kw1Cipher,u1PaillierPk, round.save.U1NtildeH1H2[oldindex])
+			u1u1MtAZK3Proof := ec2.MtAwcRespZKProofProve(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.w1, vU1Star[k], u1VR1, msg3.Kc, u1Kw1Cipher,u1PaillierPk, round.save.U1NtildeH1H2[oldindex])
 
 			srm := &SignRound4Message1{
 				SignRoundMessage: new(SignRoundMessage),
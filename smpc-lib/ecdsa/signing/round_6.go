@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -51,10 +50,10 @@ func (round *round6) Start() error {
 		msg5, _ := round.temp.signRound5Messages[k].(*SignRound5Message)
 		deltaSum = new(big.Int).Add(deltaSum, msg5.Delta1)
 	}
-	deltaSum = new(big.Int).Mod(deltaSum, secp256k1.S256().N)
+	deltaSum = new(big.Int).Mod(deltaSum, round.curve.Params().N)
 	round.temp.deltaSum = deltaSum
 
-	u1GammaZKProof := ec2.ZkUProve(round.temp.u1Gamma)
+	u1GammaZKProof := ec2.ZkUProve(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.u1Gamma)
 
 	srm := &SignRound6Message{
 		SignRoundMessage: new(SignRoundMessage),
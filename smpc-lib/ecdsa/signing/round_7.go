@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -42,23 +41,29 @@ func (round *round7) Start() error {
 
 	var GammaGSumx *big.Int
 	var GammaGSumy *big.Int
-	for k := range round.idsign {
+	err = smpc.VerifyParallel(len(round.idsign), func(k int) error {
+		v := round.idsign[k]
 		msg1, _ := round.temp.signRound1Messages[k].(*SignRound1Message)
 		msg6, _ := round.temp.signRound6Messages[k].(*SignRound6Message)
 		deCommit := &ec2.Commitment{C: msg1.C11, D: msg6.CommU1D}
-		if !deCommit.Verify() {
-			return errors.New("verify commit fail")
+		if !deCommit.Verify(round.curve) {
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "GammaGCommitment", errors.New("verify commit fail"))
 		}
 
-		_, u1GammaG := deCommit.DeCommit()
-		if !ec2.ZkUVerify(u1GammaG, msg6.U1GammaZKProof) {
-			return errors.New("verify zkuproof fail")
+		_, u1GammaG := deCommit.DeCommit(round.curve)
+		if !ec2.ZkUVerify(round.curve, round.proofContext(v, 6), u1GammaG, msg6.U1GammaZKProof) {
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "ZkUProof", errors.New("verify zkuproof fail"))
 		}
 
 		if k == 0 {
 			GammaGSumx = u1GammaG[0]
 			GammaGSumy = u1GammaG[1]
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	for k := range round.idsign {
@@ -69,16 +74,16 @@ func (round *round7) Start() error {
 		msg1, _ := round.temp.signRound1Messages[k].(*SignRound1Message)
 		msg6, _ := round.temp.signRound6Messages[k].(*SignRound6Message)
 		deCommit := &ec2.Commitment{C: msg1.C11, D: msg6.CommU1D}
-		_, u1GammaG := deCommit.DeCommit()
-		GammaGSumx, GammaGSumy = secp256k1.S256().Add(GammaGSumx, GammaGSumy, u1GammaG[0], u1GammaG[1])
+		_, u1GammaG := deCommit.DeCommit(round.curve)
+		GammaGSumx, GammaGSumy = round.curve.Add(GammaGSumx, GammaGSumy, u1GammaG[0], u1GammaG[1])
 	}
 	
-	deltaSumInverse := new(big.Int).ModInverse(round.temp.deltaSum, secp256k1.S256().N)
+	deltaSumInverse := new(big.Int).ModInverse(round.temp.deltaSum, round.curve.Params().N)
 	if deltaSumInverse == nil {
 	    return errors.New("calc deltaSum Inverse fail")
 	}
 
-	deltaGammaGx, deltaGammaGy := secp256k1.S256().ScalarMult(GammaGSumx, GammaGSumy, deltaSumInverse.Bytes())
+	deltaGammaGx, deltaGammaGy := round.curve.ScalarMult(GammaGSumx, GammaGSumy, deltaSumInverse.Bytes())
 
 	// 4. get r = deltaGammaGx
 	r := deltaGammaGx
@@ -95,7 +100,7 @@ func (round *round7) Start() error {
 	round.temp.deltaGammaGy = deltaGammaGy
 
 	// gg20: compute ZK proof of consistency between R_i and E_i(k_i) 
-	bigRK1Gx,bigRK1Gy := secp256k1.S256().ScalarMult(deltaGammaGx,deltaGammaGy,round.temp.u1K.Bytes())
+	bigRK1Gx,bigRK1Gy := round.curve.ScalarMult(deltaGammaGx,deltaGammaGy,round.temp.u1K.Bytes())
 
 	oldindex := -1
 	for k, v := range round.save.IDs {
@@ -135,7 +140,7 @@ func (round *round7) Start() error {
 		K1:  round.temp.u1K,
 		K1Ra:  round.temp.ukc2,
 	}
-	pdlWSlackPf := ec2.NewPDLwSlackProof(pdlWSlackWitness, pdlWSlackStatement)
+	pdlWSlackPf := ec2.NewPDLwSlackProof(round.curve, round.proofContext(round.save.CurDNodeID, round.number), pdlWSlackWitness, pdlWSlackStatement)
 	if pdlWSlackPf == nil {
 	    return errors.New("compute ZK proof of consistency between R_i and E_i(k_i) fail")
 	}
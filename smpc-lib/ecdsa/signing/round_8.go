@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"math/big"
@@ -44,7 +43,8 @@ func (round *round8) Start() error {
 	var K1Rx *big.Int
 	var K1Ry *big.Int
 
-	for k, v := range round.idsign {
+	err = smpc.VerifyParallel(len(round.idsign), func(k int) error {
+	    v := round.idsign[k]
 	    index := -1
 	    for kk, vv := range round.save.IDs {
 		    if v.Cmp(vv) == 0 {
@@ -80,33 +80,41 @@ func (round *round8) Start() error {
 		    NTilde:     nt.Ntilde,
 	    }
 
-	    if !ec2.PDLwSlackVerify(pdlWSlackStatement,msg7.PdlwSlackPf) {
+	    if !ec2.PDLwSlackVerify(round.curve, round.proofContext(v, 7), pdlWSlackStatement,msg7.PdlwSlackPf) {
 		log.Error("=======================signing round 8,failed to verify ZK proof of consistency between R_i and E_i(k_i) for Uid=========================","Uid",v)
-		return fmt.Errorf("failed to verify ZK proof of consistency between R_i and E_i(k_i) for Uid %v,k = %v", v,k)
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "PDLwSlackProof", fmt.Errorf("failed to verify ZK proof of consistency between R_i and E_i(k_i) for Uid %v,k = %v", v,k))
 	    }
 
+	    return nil
+	})
+	if err != nil {
+	    return err
+	}
+
+	for k := range round.idsign {
+	    msg7, _ := round.temp.signRound7Messages[k].(*SignRound7Message)
 	    if k == 0 {
 		K1Rx = msg7.K1RX
 		K1Ry = msg7.K1RY
 		continue
 	    }
 
-	    K1Rx,K1Ry = secp256k1.S256().Add(K1Rx,K1Ry,msg7.K1RX,msg7.K1RY)
+	    K1Rx,K1Ry = round.curve.Add(K1Rx,K1Ry,msg7.K1RX,msg7.K1RY)
 	}
 
-	if K1Rx.Cmp(secp256k1.S256().Gx) != 0 || K1Ry.Cmp(secp256k1.S256().Gy) != 0 {
+	if K1Rx.Cmp(round.curve.Params().Gx) != 0 || K1Ry.Cmp(round.curve.Params().Gy) != 0 {
 	    log.Error("==============================signing round 8,consistency check failed: g != R products==================================")
 	    return fmt.Errorf("consistency check failed: g != R products")
 	}
 
-	S1X,S1Y := secp256k1.S256().ScalarMult(round.temp.deltaGammaGx,round.temp.deltaGammaGy,round.temp.sigma1.Bytes())
-	hx,hy,err := ec2.CalcHPoint()
+	S1X,S1Y := round.curve.ScalarMult(round.temp.deltaGammaGx,round.temp.deltaGammaGy,round.temp.sigma1.Bytes())
+	hx,hy,err := ec2.CalcHPoint(round.curve)
 	if err != nil {
 	    log.Error("=====================calc h point fail===================","err",err)
 	    return err 
 	}
 
-	stProof := ec2.NewSTProof(round.temp.t1X,round.temp.t1Y,S1X,S1Y,round.temp.deltaGammaGx,round.temp.deltaGammaGy,hx,hy,round.temp.sigma1,round.temp.l1)
+	stProof := ec2.NewSTProof(round.curve, round.proofContext(round.save.CurDNodeID, round.number), round.temp.t1X,round.temp.t1Y,S1X,S1Y,round.temp.deltaGammaGx,round.temp.deltaGammaGy,hx,hy,round.temp.sigma1,round.temp.l1)
 	if stProof == nil {
 	    return fmt.Errorf("new stproof fail")
 	}
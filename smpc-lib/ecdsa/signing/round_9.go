@@ -19,7 +19,6 @@ package signing
 import (
 	"errors"
 	"fmt"
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"math/big"
@@ -37,7 +36,7 @@ func (round *round9) Start() error {
 	round.started = true
 	round.ResetOK()
 	
-	hx,hy,err := ec2.CalcHPoint()
+	hx,hy,err := ec2.CalcHPoint(round.curve)
 	if err != nil {
 	    fmt.Printf("calc h point fail, err = %v",err)
 	    return err 
@@ -46,11 +45,11 @@ func (round *round9) Start() error {
 	var s1x *big.Int
 	var s1y *big.Int
 	
-	for k := range round.idsign {
+	for k, v := range round.idsign {
 	    msg8, _ := round.temp.signRound8Messages[k].(*SignRound8Message)
 	    msg5, _ := round.temp.signRound5Messages[k].(*SignRound5Message)
-	    if ok := ec2.STVerify(msg8.S1X,msg8.S1Y,msg5.T1X,msg5.T1Y,round.temp.deltaGammaGx,round.temp.deltaGammaGy,hx,hy,msg8.STpf); !ok {
-		return fmt.Errorf("STProof verify fail")
+	    if ok := ec2.STVerify(round.curve, round.proofContext(v, 8), msg8.S1X,msg8.S1Y,msg5.T1X,msg5.T1Y,round.temp.deltaGammaGx,round.temp.deltaGammaGy,hx,hy,msg8.STpf); !ok {
+		return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "STProof", fmt.Errorf("STProof verify fail"))
 	    }
 
 	    if k == 0 {
@@ -59,7 +58,7 @@ func (round *round9) Start() error {
 		continue
 	    }
 
-	    s1x,s1y = secp256k1.S256().Add(s1x,s1y,msg8.S1X,msg8.S1Y)
+	    s1x,s1y = round.curve.Add(s1x,s1y,msg8.S1X,msg8.S1Y)
 	}
 
 	if s1x.Cmp(round.save.Pkx) != 0 || s1y.Cmp(round.save.Pky) != 0 {
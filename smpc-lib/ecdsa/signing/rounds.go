@@ -17,7 +17,9 @@
 package signing
 
 import (
+	"crypto/elliptic"
 	"errors"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"math/big"
@@ -41,6 +43,8 @@ type (
 		predata           *PrePubData
 		txhash            *big.Int
 		finalizeend      chan<- *big.Int
+		curve             elliptic.Curve
+		sessionkey        string
 	}
 	round1 struct {
 		*base
@@ -99,6 +103,11 @@ func (round *base) CanProceed() bool {
 	return true
 }
 
+// proofContext get the context of the zk proof made by the party id in round number
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
 // GetIDs get from all nodes
 func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
 	return round.idsign, nil
@@ -125,6 +134,39 @@ func (round *base) GetDNodeIDIndex(id string) (int, error) {
 	return -1, errors.New("get dnode index fail,no found in kgRound0Messages")
 }
 
+// CheckPaillierKeyLength check the paillier N and Ntilde of all signers have the length stored with the key
+func (round *base) CheckPaillierKeyLength() error {
+	if round.paillierkeylength < ec2.MinPaillierKeyLength {
+		return errors.New("paillier key length is too small")
+	}
+
+	for _, v := range round.idsign {
+		index := -1
+		for kk, vv := range round.save.IDs {
+			if v.Cmp(vv) == 0 {
+				index = kk
+				break
+			}
+		}
+
+		if index < 0 || index >= len(round.save.U1PaillierPk) || index >= len(round.save.U1NtildeH1H2) {
+			return errors.New("get signer index fail")
+		}
+
+		paiPk := round.save.U1PaillierPk[index]
+		if paiPk == nil || paiPk.N == nil || paiPk.N.BitLen() < ec2.MinPaillierKeyLength || paiPk.N.BitLen() != round.paillierkeylength {
+			return errors.New("got paillier N with not enough bits")
+		}
+
+		nt := round.save.U1NtildeH1H2[index]
+		if nt == nil || nt.Ntilde == nil || nt.Ntilde.BitLen() < ec2.MinNtildeLength || nt.Ntilde.BitLen() != round.paillierkeylength {
+			return errors.New("got ntilde with not enough bits")
+		}
+	}
+
+	return nil
+}
+
 func (round *base) ResetOK() {
 	for j := range round.ok {
 		round.ok[j] = false
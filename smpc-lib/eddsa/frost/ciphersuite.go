@@ -0,0 +1,234 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// ContextString the context string of FROST(Ed25519, SHA-512) ciphersuite,see RFC 9591 section 6.1
+const ContextString = "FROST-ED25519-SHA512-v1"
+
+// hashToScalar SHA-512(ContextString || tag || m) mod L,tag "" is H2 of RFC 9591 which has no prefix
+func hashToScalar(tag string, m ...[]byte) [32]byte {
+	h := sha512.New()
+	if tag != "" {
+		h.Write([]byte(ContextString))
+		h.Write([]byte(tag))
+	}
+	for _, v := range m {
+		h.Write(v)
+	}
+
+	var digest [64]byte
+	h.Sum(digest[:0])
+
+	var s [32]byte
+	ed.ScReduce(&s, &digest)
+	return s
+}
+
+// hash SHA-512(ContextString || tag || m)
+func hash(tag string, m ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(ContextString))
+	h.Write([]byte(tag))
+	for _, v := range m {
+		h.Write(v)
+	}
+
+	return h.Sum(nil)
+}
+
+// H1 the hash used to derive the binding factors
+func H1(m ...[]byte) [32]byte {
+	return hashToScalar("rho", m...)
+}
+
+// H2 the hash used to derive the challenge,it is the same as the challenge of ed25519 signature
+func H2(m ...[]byte) [32]byte {
+	return hashToScalar("", m...)
+}
+
+// H3 the hash used to derive the nonces
+func H3(m ...[]byte) [32]byte {
+	return hashToScalar("nonce", m...)
+}
+
+// H4 the hash of the message
+func H4(m ...[]byte) []byte {
+	return hash("msg", m...)
+}
+
+// H5 the hash of the commitment list
+func H5(m ...[]byte) []byte {
+	return hash("com", m...)
+}
+
+// NonceGenerate nonce = H3(random_bytes(32) || secret)
+func NonceGenerate(secret [32]byte) ([32]byte, error) {
+	var r [32]byte
+	if _, err := io.ReadFull(cryptorand.Reader, r[:]); err != nil {
+		return r, err
+	}
+
+	return H3(r[:], secret[:]), nil
+}
+
+// Identifier the scalar of the uid,the bytes of uid are put in the scalar the same as ed keygen and ed sign do
+func Identifier(uid *big.Int) [32]byte {
+	var id [32]byte
+	copy(id[:], uid.Bytes())
+
+	var tmp [64]byte
+	copy(tmp[:], id[:])
+	ed.ScReduce(&id, &tmp)
+	return id
+}
+
+// Lambda calc the lagrange coefficient of cur in ids
+func Lambda(ids smpc.SortableIDSSlice, cur *big.Int) ([32]byte, error) {
+	var lambda [32]byte
+	lambda[0] = 1
+	order := ed.GetBytesOrder()
+
+	curByte := Identifier(cur)
+	for _, v := range ids {
+		if v.Cmp(cur) == 0 {
+			continue
+		}
+
+		indexByte := Identifier(v)
+
+		var times, zero [32]byte
+		ed.ScSub(&times, &indexByte, &curByte)
+		if times == zero {
+			return lambda, errors.New("calc lambda fail,same id")
+		}
+
+		times = ed.ScModInverse(times, order)
+		ed.ScMul(&times, &times, &indexByte)
+		ed.ScMul(&lambda, &lambda, &times)
+	}
+
+	return lambda, nil
+}
+
+// identity the encoding of the identity element
+var identity = [32]byte{1}
+
+// decodePoint decode the point,the identity element is rejected
+func decodePoint(p [32]byte) (*ed.ExtendedGroupElement, error) {
+	if p == identity {
+		return nil, errors.New("point is the identity element")
+	}
+
+	var P ed.ExtendedGroupElement
+	if !P.FromBytes(&p) {
+		return nil, errors.New("invalid point encoding")
+	}
+
+	var enc [32]byte
+	P.ToBytes(&enc)
+	if !bytes.Equal(enc[:], p[:]) {
+		return nil, errors.New("non-canonical point encoding")
+	}
+
+	return &P, nil
+}
+
+// negPoint -P
+func negPoint(P *ed.ExtendedGroupElement) *ed.ExtendedGroupElement {
+	N := *P
+	ed.FeNeg(&N.X, &N.X)
+	ed.FeNeg(&N.T, &N.T)
+	return &N
+}
+
+// EncodeCommitmentList the encoding of the commitment list: id || D || E of every signer in the order of the list
+func EncodeCommitmentList(commitments []*NonceCommitment) []byte {
+	var buf []byte
+	for _, c := range commitments {
+		id := Identifier(c.ID)
+		buf = append(buf, id[:]...)
+		buf = append(buf, c.D[:]...)
+		buf = append(buf, c.E[:]...)
+	}
+
+	return buf
+}
+
+// BindingFactors rho_j = H1(pk || H4(msg) || H5(encoded commitment list) || id_j)
+func BindingFactors(pk [32]byte, commitments []*NonceCommitment, message []byte) [][32]byte {
+	prefix := append([]byte{}, pk[:]...)
+	prefix = append(prefix, H4(message)...)
+	prefix = append(prefix, H5(EncodeCommitmentList(commitments))...)
+
+	rhos := make([][32]byte, len(commitments))
+	for k, c := range commitments {
+		id := Identifier(c.ID)
+		rhos[k] = H1(prefix, id[:])
+	}
+
+	return rhos
+}
+
+// GroupCommitment R = sum(D_j + rho_j*E_j)
+func GroupCommitment(commitments []*NonceCommitment, rhos [][32]byte) ([32]byte, error) {
+	var RBytes [32]byte
+	if len(commitments) == 0 || len(commitments) != len(rhos) {
+		return RBytes, errors.New("commitment list error")
+	}
+
+	var R ed.ExtendedGroupElement
+	for k, c := range commitments {
+		D, err := decodePoint(c.D)
+		if err != nil {
+			return RBytes, err
+		}
+
+		E, err := decodePoint(c.E)
+		if err != nil {
+			return RBytes, err
+		}
+
+		var rhoE, tmp ed.ExtendedGroupElement
+		ed.GeScalarMult(&rhoE, &rhos[k], E)
+		ed.GeAdd(&tmp, D, &rhoE)
+		if k == 0 {
+			R = tmp
+		} else {
+			ed.GeAdd(&R, &R, &tmp)
+		}
+	}
+
+	R.ToBytes(&RBytes)
+	return RBytes, nil
+}
+
+// Challenge c = H2(R || pk || msg)
+func Challenge(R [32]byte, pk [32]byte, message []byte) [32]byte {
+	return H2(R[:], pk[:], message)
+}
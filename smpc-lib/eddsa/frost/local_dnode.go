@@ -0,0 +1,215 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package frost MPC implementation of FROST(Ed25519, SHA-512) signing with the ED25519 keygen shares,see RFC 9591
+package frost
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp        localTempData
+	save        *keygen.LocalDNodeSaveData
+	idsign      smpc.SortableIDSSlice
+	out         chan<- smpc.Message
+	end         chan<- PrePubData
+	finalize    bool
+	predata     *PrePubData
+	message     []byte
+	finalizeend chan<- signing.EdSignData
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	preRound1Messages,
+	signRound1Messages []smpc.Message
+
+	// temp data (thrown away after sign)
+
+	//round 1
+	d [32]byte
+	e [32]byte
+
+	//round 3
+	rhos [][32]byte // binding factors
+	R    [32]byte   // group commitment
+	c    [32]byte   // the challenge
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// finalize == false: the preprocessing,output the nonces and the commitment list to end
+// finalize == true: sign the message with predata in a single round,output the signature to finalizeend
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- PrePubData,
+	save *keygen.LocalDNodeSaveData,
+	idsign smpc.SortableIDSSlice,
+	kgid *big.Int,
+	threshold int,
+	finalize bool,
+	predata *PrePubData,
+	message []byte,
+	finalizeend chan<- signing.EdSignData,
+) smpc.DNode {
+
+	p := &LocalDNode{
+		BaseDNode:   new(smpc.BaseDNode),
+		save:        save,
+		idsign:      idsign,
+		temp:        localTempData{},
+		out:         out,
+		end:         end,
+		finalize:    finalize,
+		predata:     predata,
+		message:     message,
+		finalizeend: finalizeend,
+	}
+
+	p.ID = hex.EncodeToString([]byte(fmt.Sprintf("%v", kgid)))
+	p.ThresHold = threshold
+
+	p.temp.preRound1Messages = make([]smpc.Message, threshold)
+	p.temp.signRound1Messages = make([]smpc.Message, threshold)
+	return p
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return newRound3(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.predata, p.message, p.finalizeend)
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold)
+}
+
+// Start frost preprocessing or signing start
+func (p *LocalDNode) Start() error {
+	if p.save == nil || p.save.CurDNodeID == nil || len(p.idsign) != p.ThresHold {
+		return errors.New("frost sign save data error")
+	}
+
+	if p.finalize && (p.predata == nil || p.message == nil) {
+		return errors.New("frost sign pre data or message error")
+	}
+
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// Finalize weather the online signing round
+func (p *LocalDNode) Finalize() bool {
+	return p.finalize
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *PreSignRound1Message:
+		return find(p.temp.preRound1Messages, msg)
+	case *SignRound1Message:
+		return find(p.temp.signRound1Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// storeMessage put msg to l and return true if l is full
+func storeMessage(l []smpc.Message, msg smpc.Message) (bool, error) {
+	if find(l, msg) {
+		return false, nil
+	}
+
+	index := msg.GetFromIndex()
+	if index < 0 || index >= len(l) {
+		return false, errors.New("msg index error")
+	}
+
+	l[index] = msg
+	return CheckFull(l), nil
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *PreSignRound1Message:
+		return storeMessage(p.temp.preRound1Messages, msg)
+	case *SignRound1Message:
+		return storeMessage(p.temp.signRound1Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package frost_test test MPC implementation of FROST signing
+package frost_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	signSigniMessages := make([]smpc.Message, 0)
+	succ := frost.CheckFull(signSigniMessages)
+	assert.False(t, succ, "fail")
+
+	threshold := 3
+	for i := 0; i < threshold; i++ {
+		srm := &frost.SignRound1Message{
+			SignRoundMessage: new(frost.SignRoundMessage),
+		}
+		srm.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		srm.SetFromIndex(i)
+
+		signSigniMessages = append(signSigniMessages, srm)
+	}
+
+	succ = frost.CheckFull(signSigniMessages)
+	assert.True(t, succ, "success")
+}
+
+func TestPrePubDataJSON(t *testing.T) {
+	pre := &frost.PrePubData{
+		D:  [32]byte{1, 2, 3},
+		E:  [32]byte{4, 5, 6},
+		Pk: [32]byte{7, 8, 9},
+		Commitments: []*frost.NonceCommitment{
+			{ID: big.NewInt(1), D: [32]byte{10}, E: [32]byte{11}, Y: [32]byte{12}},
+			{ID: big.NewInt(3), D: [32]byte{13}, E: [32]byte{14}, Y: [32]byte{15}},
+		},
+	}
+
+	b, err := json.Marshal(pre)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pre2 := &frost.PrePubData{}
+	if !assert.NoError(t, json.Unmarshal(b, pre2)) {
+		return
+	}
+
+	assert.Equal(t, pre, pre2)
+}
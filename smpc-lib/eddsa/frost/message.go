@@ -0,0 +1,115 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&PreSignRound1Message{},
+		&SignRound1Message{},
+	)
+}
+
+// SignRoundMessage base type of FROST round message
+type SignRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (srm *SignRoundMessage) SetFromID(id string) {
+	srm.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (srm *SignRoundMessage) SetFromIndex(index int) {
+	srm.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (srm *SignRoundMessage) AppendToID(toid string) {
+	srm.ToID = append(srm.ToID, toid)
+}
+
+// PreSignRound1Message  preprocessing sending message,the nonce commitments D = d*B,E = e*B and the public verification share Y = tsk*B
+type PreSignRound1Message struct {
+	*SignRoundMessage
+	D [32]byte
+	E [32]byte
+	Y [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (srm *PreSignRound1Message) GetFromID() string {
+	return srm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (srm *PreSignRound1Message) GetFromIndex() int {
+	return srm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (srm *PreSignRound1Message) GetToID() []string {
+	return srm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (srm *PreSignRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (srm *PreSignRound1Message) GetMsgType() string {
+	return "FrostPreSignRound1Message"
+}
+
+// SignRound1Message  online sign sending message,the signature share z
+type SignRound1Message struct {
+	*SignRoundMessage
+	Z [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (srm *SignRound1Message) GetFromID() string {
+	return srm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (srm *SignRound1Message) GetFromIndex() int {
+	return srm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (srm *SignRound1Message) GetToID() []string {
+	return srm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (srm *SignRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (srm *SignRound1Message) GetMsgType() string {
+	return "FrostSignRound1Message"
+}
@@ -0,0 +1,102 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- PrePubData, kgid string, threshold int) smpc.Round {
+	return &round1{
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, nil, nil, nil}}
+}
+
+// Start choose the nonces (d_i,e_i) and broadcast the commitments D_i = d_i*B,E_i = e_i*B and Y_i = tsk_i*B
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= frost presign round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	d, err := NonceGenerate(round.save.TSk)
+	if err != nil {
+		return err
+	}
+
+	e, err := NonceGenerate(round.save.TSk)
+	if err != nil {
+		return err
+	}
+
+	var D, E, Y ed.ExtendedGroupElement
+	var DBytes, EBytes, YBytes [32]byte
+	ed.GeScalarMultBase(&D, &d)
+	ed.GeScalarMultBase(&E, &e)
+	ed.GeScalarMultBase(&Y, &round.save.TSk)
+	D.ToBytes(&DBytes)
+	E.ToBytes(&EBytes)
+	Y.ToBytes(&YBytes)
+
+	round.temp.d = d
+	round.temp.e = e
+
+	srm := &PreSignRound1Message{
+		SignRoundMessage: new(SignRoundMessage),
+		D:                DBytes,
+		E:                EBytes,
+		Y:                YBytes,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.preRound1Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*PreSignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.preRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
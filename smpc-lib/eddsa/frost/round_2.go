@@ -0,0 +1,100 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start check the commitments,check sum(lambda_j*Y_j) == pk and output the nonces and the commitment list
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= frost presign round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	var pk ed.ExtendedGroupElement
+	commitments := make([]*NonceCommitment, len(round.idsign))
+	for k, id := range round.idsign {
+		msg1, ok := round.temp.preRound1Messages[k].(*PreSignRound1Message)
+		if !ok {
+			return errors.New("round.Start get frost presign round1 msg fail")
+		}
+
+		_, err := decodePoint(msg1.D)
+		if err != nil {
+			return smpc.NewBlameError(msg1.GetFromID(), round.number, "D", err)
+		}
+
+		_, err = decodePoint(msg1.E)
+		if err != nil {
+			return smpc.NewBlameError(msg1.GetFromID(), round.number, "E", err)
+		}
+
+		Y, err := decodePoint(msg1.Y)
+		if err != nil {
+			return smpc.NewBlameError(msg1.GetFromID(), round.number, "Y", err)
+		}
+
+		lambda, err := Lambda(round.idsign, id)
+		if err != nil {
+			return err
+		}
+
+		var lambdaY ed.ExtendedGroupElement
+		ed.GeScalarMult(&lambdaY, &lambda, Y)
+		if k == 0 {
+			pk = lambdaY
+		} else {
+			ed.GeAdd(&pk, &pk, &lambdaY)
+		}
+
+		commitments[k] = &NonceCommitment{ID: id, D: msg1.D, E: msg1.E, Y: msg1.Y}
+	}
+
+	var pkBytes [32]byte
+	pk.ToBytes(&pkBytes)
+	if pkBytes != round.save.FinalPkBytes {
+		return errors.New("check the public verification shares fail")
+	}
+
+	round.end <- PrePubData{D: round.temp.d, E: round.temp.e, Pk: pkBytes, Commitments: commitments}
+	fmt.Printf("========= frost presign round2 finish, dnode id = %v ==========\n", round.kgid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	return nil
+}
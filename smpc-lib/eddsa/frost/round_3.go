@@ -0,0 +1,114 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound3(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- PrePubData, kgid string, threshold int, predata *PrePubData, message []byte, finalizeend chan<- signing.EdSignData) smpc.Round {
+	return &round3{
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, predata, message, finalizeend}}
+}
+
+// Start calc the binding factors,the group commitment R and the challenge c,broadcast z_i = d_i + e_i*rho_i + lambda_i*tsk_i*c
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= frost sign round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	commitments := round.predata.Commitments
+	if len(commitments) != len(round.idsign) {
+		return errors.New("frost pre data does not match the signing group")
+	}
+
+	for k, id := range round.idsign {
+		if commitments[k] == nil || commitments[k].ID == nil || commitments[k].ID.Cmp(id) != 0 {
+			return errors.New("frost pre data does not match the signing group")
+		}
+	}
+
+	pk := round.save.FinalPkBytes
+	rhos := BindingFactors(pk, commitments, round.message)
+	R, err := GroupCommitment(commitments, rhos)
+	if err != nil {
+		return err
+	}
+
+	c := Challenge(R, pk, round.message)
+
+	lambda, err := Lambda(round.idsign, round.idsign[curIndex])
+	if err != nil {
+		return err
+	}
+
+	// z_i = d_i + e_i*rho_i + lambda_i*tsk_i*c
+	var z, lc [32]byte
+	ed.ScMul(&lc, &lambda, &c)
+	ed.ScMulAdd(&z, &lc, &round.save.TSk, &round.predata.D)
+	ed.ScMulAdd(&z, &round.predata.E, &rhos[curIndex], &z)
+
+	round.temp.rhos = rhos
+	round.temp.R = R
+	round.temp.c = c
+
+	srm := &SignRound1Message{
+		SignRoundMessage: new(SignRoundMessage),
+		Z:                z,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound1Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	return round.update(round.temp.signRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
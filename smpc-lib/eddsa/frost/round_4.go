@@ -0,0 +1,136 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the signature shares,calc z = sum(z_j) and check the ed25519 signature (R,z)
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= frost sign round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	// the public verification shares in pre data are of the pubkey when the preprocessing is done,
+	// if the signing key is a bip32 child key,Y_j' = Y_j + (pk - pk_pre)
+	pk := round.save.FinalPkBytes
+	var tweak *ed.ExtendedGroupElement
+	if pk != round.predata.Pk {
+		P, err := decodePoint(pk)
+		if err != nil {
+			return err
+		}
+
+		P0, err := decodePoint(round.predata.Pk)
+		if err != nil {
+			return err
+		}
+
+		tweak = new(ed.ExtendedGroupElement)
+		ed.GeAdd(tweak, P, negPoint(P0))
+	}
+
+	var z [32]byte
+	for k, id := range round.idsign {
+		msg, ok := round.temp.signRound1Messages[k].(*SignRound1Message)
+		if !ok {
+			return errors.New("round.Start get frost sign round1 msg fail")
+		}
+
+		zj := msg.Z
+		if !ed.ScMinimal(&zj) {
+			return smpc.NewBlameError(msg.GetFromID(), round.number, "SignatureShare", errors.New("verify signature share fail"))
+		}
+
+		c := round.predata.Commitments[k]
+		D, err := decodePoint(c.D)
+		if err != nil {
+			return err
+		}
+
+		E, err := decodePoint(c.E)
+		if err != nil {
+			return err
+		}
+
+		Y, err := decodePoint(c.Y)
+		if err != nil {
+			return err
+		}
+
+		if tweak != nil {
+			ed.GeAdd(Y, Y, tweak)
+		}
+
+		lambda, err := Lambda(round.idsign, id)
+		if err != nil {
+			return err
+		}
+
+		// z_j*B == D_j + rho_j*E_j + (c*lambda_j)*Y_j
+		var lc [32]byte
+		ed.ScMul(&lc, &round.temp.c, &lambda)
+
+		var zB, rhoE, lcY, check ed.ExtendedGroupElement
+		var zBBytes, checkBytes [32]byte
+		ed.GeScalarMultBase(&zB, &zj)
+		ed.GeScalarMult(&rhoE, &round.temp.rhos[k], E)
+		ed.GeScalarMult(&lcY, &lc, Y)
+		ed.GeAdd(&check, D, &rhoE)
+		ed.GeAdd(&check, &check, &lcY)
+		zB.ToBytes(&zBBytes)
+		check.ToBytes(&checkBytes)
+		if zBBytes != checkBytes {
+			return smpc.NewBlameError(msg.GetFromID(), round.number, "SignatureShare", errors.New("verify signature share fail"))
+		}
+
+		ed.ScAdd(&z, &z, &zj)
+	}
+
+	if !signing.EdVerify(signing.InputVerify{FinalR: round.temp.R, FinalS: z, Message: round.message, FinalPk: pk}) {
+		return errors.New("verify frost signature fail")
+	}
+
+	round.finalizeend <- signing.EdSignData{Rx: round.temp.R, Sx: z}
+	fmt.Printf("========= frost sign round4 finish, dnode id = %v ==========\n", round.kgid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
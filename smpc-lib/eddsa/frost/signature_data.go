@@ -0,0 +1,161 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package frost
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// NonceCommitment the nonce commitments (D,E) and the public verification share Y = tsk*B of the signer ID
+type NonceCommitment struct {
+	ID *big.Int
+	D  [32]byte
+	E  [32]byte
+	Y  [32]byte
+}
+
+// PrePubData the output of FROST preprocessing: the nonces (d,e) of current signer and the commitment list of all signers
+// it can be used to sign only one message
+type PrePubData struct {
+	D           [32]byte
+	E           [32]byte
+	Pk          [32]byte // the pubkey when the preprocessing is done
+	Commitments []*NonceCommitment
+}
+
+// decodeHex32 decode the hex string to 32 bytes
+func decodeHex32(s string) ([32]byte, error) {
+	var out [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return out, errors.New("decode 32 bytes hex fail")
+	}
+
+	copy(out[:], b)
+	return out, nil
+}
+
+// MarshalJSON marshal *NonceCommitment to json bytes
+func (nc *NonceCommitment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID string `json:"ID"`
+		D  string `json:"D"`
+		E  string `json:"E"`
+		Y  string `json:"Y"`
+	}{
+		ID: fmt.Sprintf("%v", nc.ID),
+		D:  hex.EncodeToString(nc.D[:]),
+		E:  hex.EncodeToString(nc.E[:]),
+		Y:  hex.EncodeToString(nc.Y[:]),
+	})
+}
+
+// UnmarshalJSON unmarshal json bytes to *NonceCommitment
+func (nc *NonceCommitment) UnmarshalJSON(raw []byte) error {
+	var c struct {
+		ID string `json:"ID"`
+		D  string `json:"D"`
+		E  string `json:"E"`
+		Y  string `json:"Y"`
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return err
+	}
+
+	id, ok := new(big.Int).SetString(c.ID, 10)
+	if !ok {
+		return errors.New("unmarshal nonce commitment id fail")
+	}
+
+	d, err := decodeHex32(c.D)
+	if err != nil {
+		return err
+	}
+
+	e, err := decodeHex32(c.E)
+	if err != nil {
+		return err
+	}
+
+	y, err := decodeHex32(c.Y)
+	if err != nil {
+		return err
+	}
+
+	nc.ID = id
+	nc.D = d
+	nc.E = e
+	nc.Y = y
+	return nil
+}
+
+// MarshalJSON marshal *PrePubData to json bytes
+func (pd *PrePubData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		D           string             `json:"D"`
+		E           string             `json:"E"`
+		Pk          string             `json:"Pk"`
+		Commitments []*NonceCommitment `json:"Commitments"`
+	}{
+		D:           hex.EncodeToString(pd.D[:]),
+		E:           hex.EncodeToString(pd.E[:]),
+		Pk:          hex.EncodeToString(pd.Pk[:]),
+		Commitments: pd.Commitments,
+	})
+}
+
+// UnmarshalJSON unmarshal json bytes to *PrePubData
+func (pd *PrePubData) UnmarshalJSON(raw []byte) error {
+	var pre struct {
+		D           string             `json:"D"`
+		E           string             `json:"E"`
+		Pk          string             `json:"Pk"`
+		Commitments []*NonceCommitment `json:"Commitments"`
+	}
+	if err := json.Unmarshal(raw, &pre); err != nil {
+		return err
+	}
+
+	d, err := decodeHex32(pre.D)
+	if err != nil {
+		return err
+	}
+
+	e, err := decodeHex32(pre.E)
+	if err != nil {
+		return err
+	}
+
+	pk, err := decodeHex32(pre.Pk)
+	if err != nil {
+		return err
+	}
+
+	if len(pre.Commitments) == 0 {
+		return errors.New("no nonce commitments")
+	}
+
+	pd.D = d
+	pd.E = e
+	pd.Pk = pk
+	pd.Commitments = pre.Commitments
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package keygen
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// ImportSaveData get the save data of current node from the share dealt by a trusted dealer when an existing ed25519 key is imported.
+// cfsBBytes is the feldman commitments of the dealer,cfsBBytes[0] is the pubkey.
+func ImportSaveData(share [32]byte, cfsBBytes [][32]byte, c [32]byte, ids smpc.SortableIDSSlice, curid *big.Int) (*LocalDNodeSaveData, error) {
+	if len(cfsBBytes) < 2 || len(ids) < len(cfsBBytes) || curid == nil {
+		return nil, errors.New("param error")
+	}
+
+	found := false
+	for _, v := range ids {
+		if v.Cmp(curid) == 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("current node is not in the group")
+	}
+
+	// the same uid encoding as keygen round 4
+	var uid [32]byte
+	copy(uid[:], curid.Bytes())
+	if !ed.VerifyVss(share, uid, cfsBBytes) {
+		return nil, errors.New("verify the dealt share fail")
+	}
+
+	sd := &LocalDNodeSaveData{}
+	sd.Sk = share
+	sd.TSk = share
+	var pk ed.ExtendedGroupElement
+	ed.GeScalarMultBase(&pk, &share)
+	pk.ToBytes(&sd.Pk)
+	sd.FinalPkBytes = cfsBBytes[0]
+	sd.C = c
+	sd.IDs = ids
+	sd.CurDNodeID = curid
+	return sd, nil
+}
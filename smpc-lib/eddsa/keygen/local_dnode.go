@@ -51,6 +51,7 @@ type localTempData struct {
 	pk   [32]byte
 	DPk  [64]byte
 	zkPk [64]byte
+	DC   [64]byte
 
 	//round 2
 
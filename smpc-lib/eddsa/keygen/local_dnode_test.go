@@ -19,7 +19,9 @@ package keygen_test
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
@@ -45,3 +47,66 @@ func TestCheckFull(t *testing.T) {
 }
 
 
+
+func TestBip32Derive(t *testing.T) {
+    var sk, c [32]byte
+    sk[0], sk[9], sk[30] = 5, 99, 7
+    c[0], c[31] = 1, 2
+
+    var A ed.ExtendedGroupElement
+    var pk [32]byte
+    ed.GeScalarMultBase(&A, &sk)
+    A.ToBytes(&pk)
+
+    childpk, tweak, err := ed.Bip32DerivePath(pk, c, "m/0/5")
+    assert.NoError(t, err)
+
+    // (sk + tweak) * B == child pubkey
+    var childsk, pk2 [32]byte
+    ed.ScAdd(&childsk, &sk, &tweak)
+    ed.GeScalarMultBase(&A, &childsk)
+    A.ToBytes(&pk2)
+    assert.Equal(t, childpk, pk2)
+
+    other, _, err := ed.Bip32DerivePath(pk, c, "m/0/6")
+    assert.NoError(t, err)
+    assert.NotEqual(t, childpk, other)
+
+    _, _, err = ed.Bip32DerivePath(pk, c, "m/2147483648")
+    assert.Error(t, err, "hardened index")
+    _, _, err = ed.Bip32DerivePath(pk, c, "0/5")
+    assert.Error(t, err, "invalid path")
+
+    sd := &keygen.LocalDNodeSaveData{C: c, IDs: smpc.SortableIDSSlice{}}
+    sd2 := keygen.GetLocalDNodeSaveData(sd.OutMap())
+    assert.NotNil(t, sd2)
+    assert.Equal(t, c, sd2.C)
+}
+
+func TestImportSaveData(t *testing.T) {
+    var seed [32]byte
+    seed[0] = 9
+    secret := ed.SecretFromSeed(seed)
+
+    ids := smpc.SortableIDSSlice{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+    uids := make([][32]byte, len(ids))
+    for k, v := range ids {
+	copy(uids[k][:], v.Bytes())
+    }
+
+    _, cfsBBytes, shares, err := ed.Vss(secret, uids, 2, 3)
+    assert.NoError(t, err)
+
+    var c [32]byte
+    sd, err := keygen.ImportSaveData(shares[1], cfsBBytes, c, ids, ids[1])
+    assert.NoError(t, err)
+    assert.Equal(t, cfsBBytes[0], sd.FinalPkBytes)
+    assert.Equal(t, shares[1], sd.TSk)
+
+    // the share of another node
+    _, err = keygen.ImportSaveData(shares[0], cfsBBytes, c, ids, ids[1])
+    assert.Error(t, err, "wrong share")
+
+    _, err = keygen.ImportSaveData(shares[1], cfsBBytes, c, ids, big.NewInt(4))
+    assert.Error(t, err, "not in group")
+}
@@ -17,11 +17,21 @@
 package keygen
 
 import (
-	"strings"
-	"encoding/hex"
-	"strconv"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
 
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&KGRound0Message{},
+		&KGRound1Message{},
+		&KGRound2Message{},
+		&KGRound3Message{},
+		&KGRound4Message{},
+		&KGRound5Message{},
+	)
+}
+
 // KGRoundMessage base type of sign round message
 type KGRoundMessage struct {
 	FromID    string   `json:"FromID"` //DNodeID
@@ -69,16 +79,6 @@ func (kg *KGRound0Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound0Message to map
-func (kg *KGRound0Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "KGRound0Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound0Message) GetMsgType() string {
 	return "KGRound0Message"
@@ -89,6 +89,7 @@ type KGRound1Message struct {
 	*KGRoundMessage
 
 	CPk [32]byte
+	CC  [32]byte // commitment of the chain code contribution
 }
 
 // GetFromID get the ID of sending nodes in the group
@@ -111,20 +112,6 @@ func (kg *KGRound1Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound1Message to map
-func (kg *KGRound1Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	cpk := hex.EncodeToString(kg.CPk[:])
-	m["CPk"] = cpk
-
-	m["Type"] = "KGRound1Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound1Message) GetMsgType() string {
 	return "KGRound1Message"
@@ -157,20 +144,6 @@ func (kg *KGRound2Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound2Message to map
-func (kg *KGRound2Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	zkpk := hex.EncodeToString(kg.ZkPk[:])
-	m["ZkPk"] = zkpk
-
-	m["Type"] = "KGRound2Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound2Message) GetMsgType() string {
 	return "KGRound2Message"
@@ -181,6 +154,7 @@ type KGRound3Message struct {
 	*KGRoundMessage
 
 	DPk [64]byte
+	DC  [64]byte // decommitment of the chain code contribution
 }
 
 // GetFromID get the ID of sending nodes in the group
@@ -203,20 +177,6 @@ func (kg *KGRound3Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound3Message to map
-func (kg *KGRound3Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	dpk := hex.EncodeToString(kg.DPk[:])
-	m["DPk"] = dpk
-
-	m["Type"] = "KGRound3Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound3Message) GetMsgType() string {
 	return "KGRound3Message"
@@ -249,19 +209,6 @@ func (kg *KGRound4Message) IsBroadcast() bool {
 	return false
 }
 
-// OutMap transfer *KGRound4Message to map
-func (kg *KGRound4Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = strings.Join(kg.ToID, ":")
-
-	shares := hex.EncodeToString(kg.Share[:])
-	m["Share"] = shares
-	m["Type"] = "KGRound4Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound4Message) GetMsgType() string {
 	return "KGRound4Message"
@@ -294,25 +241,6 @@ func (kg *KGRound5Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *KGRound5Message to map
-func (kg *KGRound5Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = kg.FromID
-	m["FromIndex"] = strconv.Itoa(kg.FromIndex)
-	m["ToID"] = ""
-
-	tmp := make([]string, len(kg.CfsBBytes))
-	for k, v := range kg.CfsBBytes {
-		vv := hex.EncodeToString(v[:])
-		tmp[k] = vv
-	}
-	s := strings.Join(tmp, ":")
-	m["CfsBBytes"] = s
-
-	m["Type"] = "KGRound5Message"
-	return m
-}
-
 // GetMsgType get msg type
 func (kg *KGRound5Message) GetMsgType() string {
 	return "KGRound5Message"
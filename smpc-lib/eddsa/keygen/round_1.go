@@ -89,10 +89,22 @@ func (round *round1) Start() error {
 	    return err
 	}
 
+	// 1.4 the contribution of bip32 chain code
+	var c [32]byte
+	if _, err := io.ReadFull(rand, c[:]); err != nil {
+		return err
+	}
+
+	CC, DC, err := ed.Commit(c)
+	if err != nil {
+	    return err
+	}
+
 	round.temp.sk = sk
 	round.temp.pk = pk
 	round.temp.DPk = DPk
 	round.temp.zkPk = zkPk
+	round.temp.DC = DC
 
 	index, err := round.GetDNodeIDIndex(round.dnodeid)
 	if err != nil {
@@ -103,6 +115,7 @@ func (round *round1) Start() error {
 	kg := &KGRound1Message{
 		KGRoundMessage: new(KGRoundMessage),
 		CPk:            CPk,
+		CC:             CC,
 	}
 	kg.SetFromID(round.dnodeid)
 	kg.SetFromIndex(index)
@@ -38,6 +38,7 @@ func (round *round3) Start() error {
 	kg := &KGRound3Message{
 		KGRoundMessage: new(KGRoundMessage),
 		DPk:            round.temp.DPk,
+		DC:             round.temp.DC,
 	}
 	kg.SetFromID(round.dnodeid)
 	kg.SetFromIndex(curIndex)
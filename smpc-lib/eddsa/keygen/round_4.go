@@ -45,6 +45,7 @@ func (round *round4) Start() error {
 	}
 
 	var PkSet []byte
+	var CSet []byte
 
 	for k, id := range ids {
 		msg1, ok := round.temp.kgRound1Messages[k].(*KGRound1Message)
@@ -76,9 +77,19 @@ func (round *round4) Start() error {
 			return errors.New("smpc back-end internal error:zeroknowledge check fail")
 		}
 
+		if !ed.Verify(msg1.CC, msg3.DC) {
+			fmt.Printf("Error: Commitment(chain code) Not Pass at User: %v, k = %v \n", id, k)
+			return errors.New("smpc back-end internal error:chain code commitment check fail in req ed pubkey")
+		}
+
 		PkSet = append(PkSet[:], (msg3.DPk[32:])...)
+		CSet = append(CSet[:], (msg3.DC[32:])...)
 	}
 
+	// 2.4 bip32 chain code = SHA512(c1, c2, ..., cn)[:32]
+	cDigest := sha512.Sum512(CSet)
+	copy(round.Save.C[:], cDigest[:32])
+
 	// 2.5 calculate a = SHA256(PkU1, {PkU2, PkU3})
 	var a [32]byte
 	var aDigest [64]byte
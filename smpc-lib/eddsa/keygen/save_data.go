@@ -31,6 +31,7 @@ type LocalDNodeSaveData struct {
 	Pk           [32]byte
 	TSk          [32]byte
 	FinalPkBytes [32]byte
+	C            [32]byte // bip32 chain code
 	//
 
 	IDs        smpc.SortableIDSSlice
@@ -60,6 +61,8 @@ func (sd *LocalDNodeSaveData) OutMap() map[string]string {
 	finalpk := hex.EncodeToString(sd.FinalPkBytes[:])
 	sdout["FinalPkBytes"] = finalpk
 
+	sdout["C"] = hex.EncodeToString(sd.C[:])
+
 	ids := make([]string, len(sd.IDs))
 	for k, v := range sd.IDs {
 		ids[k] = fmt.Sprintf("%v", v)
@@ -106,6 +109,15 @@ func GetLocalDNodeSaveData(data map[string]string) *LocalDNodeSaveData {
 
 	copy(FinalPkBytes[:], finalpk[:])
 
+	// the data generated before bip32 supported has no chain code
+	var C [32]byte
+	c, err := hex.DecodeString(data["C"])
+	if err != nil {
+	    return nil
+	}
+
+	copy(C[:], c[:])
+
 	idstmp := strings.Split(data["IDs"], "|")
 	ids := make(smpc.SortableIDSSlice, len(idstmp))
 	for k, v := range idstmp {
@@ -114,6 +126,6 @@ func GetLocalDNodeSaveData(data map[string]string) *LocalDNodeSaveData {
 
 	curdnodeid, _ := new(big.Int).SetString(data["CurDNodeID"], 10)
 
-	sd := &LocalDNodeSaveData{Sk: Sk, TSk: TSk, Pk: Pk, FinalPkBytes: FinalPkBytes, IDs: ids, CurDNodeID: curdnodeid}
+	sd := &LocalDNodeSaveData{Sk: Sk, TSk: TSk, Pk: Pk, FinalPkBytes: FinalPkBytes, C: C, IDs: ids, CurDNodeID: curdnodeid}
 	return sd
 }
@@ -0,0 +1,317 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package reshare ED MPC implementation of reshare
+package reshare
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp    localTempData
+	data    *keygen.LocalDNodeSaveData
+	out     chan<- smpc.Message
+	end     chan<- keygen.LocalDNodeSaveData
+	oldnode bool //the node join the keygen and join the reshare
+
+	// the uids (in keygen group) of the old nodes that take part in the reshare
+	idreshare smpc.SortableIDSSlice
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	reshareRound0Messages,
+	reshareRound1Messages,
+	reshareRound2Messages,
+	reshareRound2Messages1,
+	reshareRound3Messages []smpc.Message
+
+	// temp data (thrown away after reshare)
+
+	//round 1
+	oldindex int
+	w1       [32]byte
+	pkw1     [32]byte
+	DPk      [64]byte
+	zkPk     [64]byte
+
+	//round 2
+	uids      [][32]byte
+	cfsBBytes [][32]byte
+
+	//round 3
+	tSk          [32]byte
+	finalPkBytes [32]byte
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// sd is nil and idreshare is nil if current node is new node
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- keygen.LocalDNodeSaveData,
+	DNodeCountInGroup int,
+	threshold int,
+	sd *keygen.LocalDNodeSaveData,
+	oldnode bool,
+	idreshare smpc.SortableIDSSlice,
+) smpc.DNode {
+
+	var id string
+	if sd != nil && sd.CurDNodeID != nil {
+		id = fmt.Sprintf("%v", sd.CurDNodeID)
+	} else {
+		uid := random.GetRandomIntFromZn(ed.GetBigIntOrder())
+		id = fmt.Sprintf("%v", uid)
+	}
+
+	if sd == nil {
+		sdtmp := keygen.NewLocalDNodeSaveData(DNodeCountInGroup)
+		sd = &sdtmp
+	}
+
+	p := &LocalDNode{
+		BaseDNode: new(smpc.BaseDNode),
+		temp:      localTempData{},
+		data:      sd,
+		out:       out,
+		end:       end,
+		oldnode:   oldnode,
+		idreshare: idreshare,
+	}
+
+	p.ID = hex.EncodeToString([]byte(id))
+	fmt.Printf("=========== ed reshare.NewLocalDNode,p.ID = %v,threshold = %v,DNodeCountInGroup = %v =============\n", p.ID, threshold, DNodeCountInGroup)
+
+	p.DNodeCountInGroup = DNodeCountInGroup
+	p.ThresHold = threshold
+
+	p.temp.reshareRound0Messages = make([]smpc.Message, 0)
+	p.temp.reshareRound1Messages = make([]smpc.Message, threshold)
+	p.temp.reshareRound2Messages = make([]smpc.Message, threshold)
+	p.temp.reshareRound2Messages1 = make([]smpc.Message, threshold)
+	p.temp.reshareRound3Messages = make([]smpc.Message, DNodeCountInGroup)
+	return p
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound0(p.data, &p.temp, p.out, p.end, p.ID, p.DNodeCountInGroup, p.ThresHold, p.oldnode, p.idreshare)
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// Finalize weather gg20 round
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// Start reshare start
+func (p *LocalDNode) Start() error {
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+// *big.Int format: index+1
+// string format: EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *ReRound0Message:
+		if find(p.temp.reshareRound0Messages, msg) {
+			return true
+		}
+	case *ReRound1Message:
+		if find(p.temp.reshareRound1Messages, msg) {
+			return true
+		}
+	case *ReRound2Message:
+		if find(p.temp.reshareRound2Messages, msg) {
+			return true
+		}
+	case *ReRound2Message1:
+		if find(p.temp.reshareRound2Messages1, msg) {
+			return true
+		}
+	case *ReRound3Message:
+		if find(p.temp.reshareRound3Messages, msg) {
+			return true
+		}
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+
+	return false
+}
+
+// checkIndex check whether the index of msg is out of range
+func checkIndex(l []smpc.Message, msg smpc.Message) bool {
+	index := msg.GetFromIndex()
+	return index >= 0 && index < len(l)
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *ReRound0Message:
+		if !find(p.temp.reshareRound0Messages, msg) {
+			if len(p.temp.reshareRound0Messages) < p.DNodeCountInGroup {
+				p.temp.reshareRound0Messages = append(p.temp.reshareRound0Messages, msg)
+			}
+
+			if len(p.temp.reshareRound0Messages) == p.DNodeCountInGroup {
+				fmt.Printf("================ StoreMessage,get all ed reshare 0 messages ==============\n")
+				return true, nil
+			}
+		}
+	case *ReRound1Message:
+		if find(p.temp.reshareRound1Messages, msg) {
+			return false, nil
+		}
+
+		if !checkIndex(p.temp.reshareRound1Messages, msg) {
+			return false, errors.New("msg index error")
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.reshareRound1Messages[index] = msg
+		if len(p.temp.reshareRound1Messages) == p.ThresHold && CheckFull(p.temp.reshareRound1Messages) {
+			fmt.Printf("================ StoreMessage,get all ed reshare 1 messages ==============\n")
+			return true, nil
+		}
+	case *ReRound2Message:
+		if find(p.temp.reshareRound2Messages, msg) {
+			return false, nil
+		}
+
+		if !checkIndex(p.temp.reshareRound2Messages, msg) {
+			return false, errors.New("msg index error")
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.reshareRound2Messages[index] = msg
+		if len(p.temp.reshareRound2Messages) == p.ThresHold && CheckFull(p.temp.reshareRound2Messages) {
+			fmt.Printf("================ StoreMessage,get all ed reshare 2 messages ==============\n")
+			return true, nil
+		}
+	case *ReRound2Message1:
+		if find(p.temp.reshareRound2Messages1, msg) {
+			return false, nil
+		}
+
+		if !checkIndex(p.temp.reshareRound2Messages1, msg) {
+			return false, errors.New("msg index error")
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.reshareRound2Messages1[index] = msg
+		if len(p.temp.reshareRound2Messages1) == p.ThresHold && CheckFull(p.temp.reshareRound2Messages1) {
+			fmt.Printf("================ StoreMessage,get all ed reshare 2-1 messages ==============\n")
+			return true, nil
+		}
+	case *ReRound3Message:
+		if find(p.temp.reshareRound3Messages, msg) {
+			return false, nil
+		}
+
+		if !checkIndex(p.temp.reshareRound3Messages, msg) {
+			return false, errors.New("msg index error")
+		}
+
+		index := msg.GetFromIndex()
+		p.temp.reshareRound3Messages[index] = msg
+		if len(p.temp.reshareRound3Messages) == p.DNodeCountInGroup && CheckFull(p.temp.reshareRound3Messages) {
+			fmt.Printf("================ StoreMessage,get all ed reshare 3 messages ==============\n")
+
+			///check newskok
+			for _, v := range p.temp.reshareRound3Messages {
+				m := v.(*ReRound3Message)
+				if m.NewSkOk != "TRUE" {
+					return false, errors.New("check newsk ok fail")
+				}
+			}
+			////
+
+			return true, nil
+		}
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+
+	return false, nil
+}
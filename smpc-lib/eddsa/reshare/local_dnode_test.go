@@ -0,0 +1,46 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package reshare_test test ED MPC implementation of reshare
+package reshare_test
+
+import (
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/reshare"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	reshareRoundiMessages := make([]smpc.Message, 0)
+	succ := reshare.CheckFull(reshareRoundiMessages)
+	assert.False(t, succ, "fail")
+
+	threshold := 3
+	for i := 0; i < threshold; i++ {
+		re := &reshare.ReRound0Message{
+			ReRoundMessage: new(reshare.ReRoundMessage),
+		}
+		re.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		re.SetFromIndex(-1)
+
+		reshareRoundiMessages = append(reshareRoundiMessages, re)
+	}
+
+	succ = reshare.CheckFull(reshareRoundiMessages)
+	assert.True(t, succ, "success")
+}
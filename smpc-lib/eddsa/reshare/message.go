@@ -0,0 +1,213 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&ReRound0Message{},
+		&ReRound1Message{},
+		&ReRound2Message{},
+		&ReRound2Message1{},
+		&ReRound3Message{},
+	)
+}
+
+// ReRoundMessage base type of reshare round message
+type ReRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (re *ReRoundMessage) SetFromID(id string) {
+	re.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (re *ReRoundMessage) SetFromIndex(index int) {
+	re.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (re *ReRoundMessage) AppendToID(toid string) {
+	re.ToID = append(re.ToID, toid)
+}
+
+// ReRound0Message  Round 0 sending message
+type ReRound0Message struct {
+	*ReRoundMessage
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *ReRound0Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *ReRound0Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *ReRound0Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *ReRound0Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *ReRound0Message) GetMsgType() string {
+	return "ReRound0Message"
+}
+
+// ReRound1Message  Round 1 sending message
+type ReRound1Message struct {
+	*ReRoundMessage
+
+	CPk [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *ReRound1Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *ReRound1Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *ReRound1Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *ReRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *ReRound1Message) GetMsgType() string {
+	return "ReRound1Message"
+}
+
+// ReRound2Message  Round 2 sending message
+type ReRound2Message struct {
+	*ReRoundMessage
+
+	Share [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *ReRound2Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *ReRound2Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *ReRound2Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *ReRound2Message) IsBroadcast() bool {
+	return false
+}
+
+// GetMsgType get msg type
+func (re *ReRound2Message) GetMsgType() string {
+	return "ReRound2Message"
+}
+
+// ReRound2Message1  Round 2 sending message
+type ReRound2Message1 struct {
+	*ReRoundMessage
+
+	DPk       [64]byte
+	ZkPk      [64]byte
+	CfsBBytes [][32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *ReRound2Message1) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *ReRound2Message1) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *ReRound2Message1) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *ReRound2Message1) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *ReRound2Message1) GetMsgType() string {
+	return "ReRound2Message1"
+}
+
+// ReRound3Message  Round 3 sending message
+type ReRound3Message struct {
+	*ReRoundMessage
+	NewSkOk string
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (re *ReRound3Message) GetFromID() string {
+	return re.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (re *ReRound3Message) GetFromIndex() int {
+	return re.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (re *ReRound3Message) GetToID() []string {
+	return re.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (re *ReRound3Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (re *ReRound3Message) GetMsgType() string {
+	return "ReRound3Message"
+}
@@ -0,0 +1,175 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start calc w1 = lambda1 * tSk and get commitment data of w1*G
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============ ed reshare round1 start error,already started============\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	if !round.oldnode {
+		// new node generate its own sk/pk,they are useless for sign but are saved as keygen does
+		var sk [32]byte
+		var skTem [64]byte
+		if _, err := io.ReadFull(cryptorand.Reader, sk[:]); err != nil {
+			return err
+		}
+
+		sk[0] &= 248
+		sk[31] &= 127
+		sk[31] |= 64
+
+		copy(skTem[:], sk[:])
+		ed.ScReduce(&sk, &skTem)
+
+		var A ed.ExtendedGroupElement
+		var pk [32]byte
+		ed.GeScalarMultBase(&A, &sk)
+		A.ToBytes(&pk)
+
+		round.Save.Sk = sk
+		round.Save.Pk = pk
+		return nil
+	}
+
+	if round.threshold <= 1 || round.threshold > round.dnodecount || len(round.idreshare) != round.threshold {
+		return errors.New("threshold value error")
+	}
+
+	index, err := round.getOldIndex()
+	if err != nil {
+		fmt.Printf("============ed reshare round1 start,get old index fail,uid = %v,err = %v ===========\n", round.dnodeid, err)
+		return err
+	}
+	round.temp.oldindex = index
+
+	// lambda1 = prod(x_j/(x_j - x_i)),j != i
+	var lambda [32]byte
+	lambda[0] = 1
+	order := ed.GetBytesOrder()
+
+	curByte := getUIDBytes(round.Save.CurDNodeID)
+	for k, v := range round.idreshare {
+		if k == index {
+			continue
+		}
+
+		indexByte := getUIDBytes(v)
+
+		var time [32]byte
+		ed.ScSub(&time, &indexByte, &curByte)
+		time = ed.ScModInverse(time, order)
+		count := 0
+		for i := 0; i < 32; i++ {
+			if time[i] == byte('0') {
+				count++
+			}
+		}
+		if count == 32 {
+			return errors.New("calc time mod inverse fail")
+		}
+
+		ed.ScMul(&time, &time, &indexByte)
+		ed.ScMul(&lambda, &lambda, &time)
+	}
+
+	var w1 [32]byte
+	ed.ScMul(&w1, &lambda, &round.Save.TSk)
+
+	var W1 ed.ExtendedGroupElement
+	var pkw1 [32]byte
+	ed.GeScalarMultBase(&W1, &w1)
+	W1.ToBytes(&pkw1)
+
+	CPk, DPk, err := ed.Commit(pkw1)
+	if err != nil {
+		return err
+	}
+
+	zkPk, err := ed.Prove2(w1, pkw1)
+	if err != nil {
+		return err
+	}
+
+	round.temp.w1 = w1
+	round.temp.pkw1 = pkw1
+	round.temp.DPk = DPk
+	round.temp.zkPk = zkPk
+
+	re := &ReRound1Message{
+		ReRoundMessage: new(ReRoundMessage),
+		CPk:            CPk,
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(index)
+
+	round.temp.reshareRound1Messages[index] = re
+	round.out <- re
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*ReRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	for j, msg := range round.temp.reshareRound1Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+
+		//add for reshare only
+		if j == (len(round.temp.reshareRound1Messages) - 1) {
+			for jj := range round.ok {
+				round.ok[jj] = true
+			}
+		}
+		//
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,155 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start get vss data of w1 and send to corresponding peer
+func (round *round2) Start() error {
+	if round.started {
+		return errors.New("ed,round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return err
+	}
+
+	dul, err := ec2.ContainsDuplicate(ids)
+	if err != nil || dul || len(ids) != round.dnodecount {
+		return errors.New("node id error")
+	}
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	if curIndex < 0 {
+		return errors.New("get current node index fail")
+	}
+
+	var uids [][32]byte
+	for _, v := range ids {
+		uids = append(uids, getUIDBytes(v))
+	}
+	round.temp.uids = uids
+
+	// the keygen uid of old node is useless from now on
+	round.Save.IDs = ids
+	round.Save.CurDNodeID = ids[curIndex]
+
+	if !round.oldnode {
+		return nil
+	}
+
+	_, cfsBBytes, shares, err := ed.Vss(round.temp.w1, uids, round.threshold, len(uids))
+	if cfsBBytes == nil || shares == nil || err != nil {
+		if err != nil {
+			return err
+		}
+
+		return errors.New("calc shares error")
+	}
+
+	round.temp.cfsBBytes = cfsBBytes
+
+	for k, id := range ids {
+		re := &ReRound2Message{
+			ReRoundMessage: new(ReRoundMessage),
+			Share:          shares[k],
+		}
+		re.SetFromID(round.dnodeid)
+		re.SetFromIndex(round.temp.oldindex)
+
+		if k == curIndex {
+			round.temp.reshareRound2Messages[round.temp.oldindex] = re
+		} else {
+			tmp := fmt.Sprintf("%v", id)
+			idtmp := hex.EncodeToString([]byte(tmp))
+			re.AppendToID(idtmp) //id-->dnodeid
+			round.out <- re
+		}
+	}
+
+	re := &ReRound2Message1{
+		ReRoundMessage: new(ReRoundMessage),
+		DPk:            round.temp.DPk,
+		ZkPk:           round.temp.zkPk,
+		CfsBBytes:      cfsBBytes,
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(round.temp.oldindex)
+	round.temp.reshareRound2Messages1[round.temp.oldindex] = re
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*ReRound2Message); ok {
+		return !msg.IsBroadcast()
+	}
+	if _, ok := msg.(*ReRound2Message1); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	for j, msg := range round.temp.reshareRound2Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		msg2 := round.temp.reshareRound2Messages1[j]
+		if msg2 == nil || !round.CanAccept(msg2) {
+			return false, nil
+		}
+		round.ok[j] = true
+
+		//add for reshare only
+		if j == (len(round.temp.reshareRound2Messages) - 1) {
+			for jj := range round.ok {
+				round.ok[jj] = true
+			}
+		}
+		//
+	}
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
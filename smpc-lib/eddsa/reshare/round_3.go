@@ -0,0 +1,153 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify commitment,zk and vss data,calc pubkey and new tSk
+func (round *round3) Start() error {
+	if round.started {
+		return errors.New("ed,round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.dnodeid)
+	if err != nil {
+		return err
+	}
+
+	if curIndex < 0 {
+		return errors.New("get cur index fail")
+	}
+
+	var tSk [32]byte
+	var finalPk ed.ExtendedGroupElement
+
+	// use round.temp.reshareRound1Messages replace round.idreshare,because round.idreshare == nil when oldnode == false
+	for k := range round.temp.reshareRound1Messages {
+		msg1, ok := round.temp.reshareRound1Messages[k].(*ReRound1Message)
+		if !ok {
+			return errors.New("ed,round.Start get round1 msg fail")
+		}
+
+		msg2, ok := round.temp.reshareRound2Messages[k].(*ReRound2Message)
+		if !ok {
+			return errors.New("ed,round.Start get round2 msg fail")
+		}
+
+		msg21, ok := round.temp.reshareRound2Messages1[k].(*ReRound2Message1)
+		if !ok {
+			return errors.New("ed,round.Start get round2-1 msg fail")
+		}
+
+		if !ed.Verify(msg1.CPk, msg21.DPk) {
+			fmt.Printf("========= ed reshare round3 verify commitment fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "PkCommitment", errors.New("verify commitment fail"))
+		}
+
+		var pkw1 [32]byte
+		copy(pkw1[:], msg21.DPk[32:])
+
+		if !ed.VerifyZk2(msg21.ZkPk, pkw1) {
+			fmt.Printf("========= ed reshare round3 verify zk fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZkPk", errors.New("verify zeroknowledge proof fail"))
+		}
+
+		if len(msg21.CfsBBytes) != round.threshold || !bytes.Equal(msg21.CfsBBytes[0][:], pkw1[:]) {
+			fmt.Printf("========= ed reshare round3 verify vss coefficient fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "VssCoefficient", errors.New("verify vss coefficient fail"))
+		}
+
+		if !ed.VerifyVss(msg2.Share, round.temp.uids[curIndex], msg21.CfsBBytes) {
+			fmt.Printf("========= ed reshare round3 verify share fail, k = %v ==========\n", k)
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "VssShare", errors.New("verify share data fail"))
+		}
+
+		ed.ScAdd(&tSk, &tSk, &msg2.Share)
+
+		var W1 ed.ExtendedGroupElement
+		if !W1.FromBytes(&pkw1) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "PkCommitment", errors.New("invalid point"))
+		}
+
+		if k == 0 {
+			finalPk = W1
+		} else {
+			ed.GeAdd(&finalPk, &finalPk, &W1)
+		}
+	}
+
+	var finalPkBytes [32]byte
+	finalPk.ToBytes(&finalPkBytes)
+
+	// the pubkey can not be changed by reshare
+	if round.oldnode && !bytes.Equal(finalPkBytes[:], round.Save.FinalPkBytes[:]) {
+		return errors.New("reshare fail,new pubkey != old pubkey")
+	}
+
+	round.temp.tSk = tSk
+	round.temp.finalPkBytes = finalPkBytes
+
+	re := &ReRound3Message{
+		ReRoundMessage: new(ReRoundMessage),
+		NewSkOk:        "TRUE",
+	}
+	re.SetFromID(round.dnodeid)
+	re.SetFromIndex(curIndex)
+	round.temp.reshareRound3Messages[curIndex] = re
+	round.out <- re
+
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*ReRound3Message); ok {
+		return msg.IsBroadcast()
+	}
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	for j, msg := range round.temp.reshareRound3Messages {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !round.CanAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
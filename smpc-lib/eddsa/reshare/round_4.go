@@ -0,0 +1,59 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start return save data
+func (round *round4) Start() error {
+	if round.started {
+		return errors.New("ed,round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	round.Save.TSk = round.temp.tSk
+	round.Save.FinalPkBytes = round.temp.finalPkBytes
+
+	round.end <- *round.Save
+
+	pub := hex.EncodeToString(round.temp.finalPkBytes[:])
+	fmt.Printf("========= ed reshare round4 start success, pubkey = %v ==========\n", pub)
+	return nil
+}
+
+// CanAccept end reshare
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update end reshare
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound end reshare
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
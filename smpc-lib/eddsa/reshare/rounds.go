@@ -0,0 +1,164 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package reshare
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		Save       *keygen.LocalDNodeSaveData
+		temp       *localTempData
+		out        chan<- smpc.Message
+		end        chan<- keygen.LocalDNodeSaveData
+		ok         []bool
+		started    bool
+		number     int
+		dnodeid    string
+		dnodecount int
+		threshold  int
+		oldnode    bool
+		idreshare  smpc.SortableIDSSlice
+	}
+	round0 struct {
+		*base
+	}
+	round1 struct {
+		*round0
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		fmt.Printf("=========== round.CanProceed,not start, round.number = %v ============\n", round.number)
+		return false
+	}
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// getUID dnodeid --> DecodeString --> *big.Int
+func getUID(id string) (*big.Int, error) {
+	uidtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, ok := new(big.Int).SetString(string(uidtmp[:]), 10)
+	if !ok {
+		return nil, errors.New("get uid fail")
+	}
+
+	return uid, nil
+}
+
+// GetIDs get from all nodes
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	var ids smpc.SortableIDSSlice
+	for _, v := range round.temp.reshareRound0Messages {
+		uid, err := getUID(v.GetFromID())
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, uid)
+	}
+
+	sort.Sort(ids)
+	return ids, nil
+}
+
+// GetDNodeIDIndex get current node index in the new group by id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" || len(round.temp.reshareRound0Messages) != round.dnodecount {
+		return -1, nil
+	}
+
+	ids, err := round.GetIDs()
+	if err != nil {
+		return -1, err
+	}
+
+	uid, err := getUID(id)
+	if err != nil {
+		return -1, err
+	}
+
+	for k, v := range ids {
+		if v.Cmp(uid) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in reshareRound0Messages")
+}
+
+// getOldIndex get current node index in the old nodes that take part in the reshare
+func (round *base) getOldIndex() (int, error) {
+	if round.Save == nil || round.Save.CurDNodeID == nil {
+		return -1, errors.New("no found current node's uid in keygen group")
+	}
+
+	for k, v := range round.idreshare {
+		if v.Cmp(round.Save.CurDNodeID) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get old index fail,no found in idreshare")
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// getUIDBytes big.Int --> [32]byte,the same as keygen
+func getUIDBytes(v *big.Int) [32]byte {
+	var tem [32]byte
+	tmp := v.Bytes()
+	copy(tem[:], tmp[:])
+	return tem
+}
@@ -17,11 +17,21 @@
 package signing
 
 import (
-	"strings"
-	"encoding/hex"
-	"strconv"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 )
 
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&SignRound1Message{},
+		&SignRound2Message{},
+		&SignRound3Message{},
+		&SignRound4Message{},
+		&SignRound5Message{},
+		&SignRound6Message{},
+	)
+}
+
 // SignRoundMessage base type of sign round message
 type SignRoundMessage struct {
 	FromID    string   `json:"FromID"` //DNodeID
@@ -70,20 +80,6 @@ func (srm *SignRound1Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound1Message to map
-func (srm *SignRound1Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound1Message"
-
-	cr := hex.EncodeToString(srm.CR[:])
-	m["CR"] = cr
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound1Message) GetMsgType() string {
 	return "SignRound1Message"
@@ -115,20 +111,6 @@ func (srm *SignRound2Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound2Message to map
-func (srm *SignRound2Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = strings.Join(srm.ToID, ":")
-	m["Type"] = "SignRound2Message"
-
-	zkr := hex.EncodeToString(srm.ZkR[:])
-	m["ZkR"] = zkr
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound2Message) GetMsgType() string {
 	return "SignRound2Message"
@@ -160,20 +142,6 @@ func (srm *SignRound3Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound3Message to map
-func (srm *SignRound3Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound3Message"
-
-	dr := hex.EncodeToString(srm.DR[:])
-	m["DR"] = dr
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound3Message) GetMsgType() string {
 	return "SignRound3Message"
@@ -205,20 +173,6 @@ func (srm *SignRound4Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound4Message to map
-func (srm *SignRound4Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = strings.Join(srm.ToID, ":")
-	m["Type"] = "SignRound4Message"
-
-	csb := hex.EncodeToString(srm.CSB[:])
-	m["CSB"] = csb
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound4Message) GetMsgType() string {
 	return "SignRound4Message"
@@ -250,20 +204,6 @@ func (srm *SignRound5Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound5Message to map
-func (srm *SignRound5Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound5Message"
-
-	dsb := hex.EncodeToString(srm.DSB[:])
-	m["DSB"] = dsb
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound5Message) GetMsgType() string {
 	return "SignRound5Message"
@@ -295,20 +235,6 @@ func (srm *SignRound6Message) IsBroadcast() bool {
 	return true
 }
 
-// OutMap transfer *SignRound6Message to map
-func (srm *SignRound6Message) OutMap() map[string]string {
-	m := make(map[string]string)
-	m["FromID"] = srm.FromID
-	m["FromIndex"] = strconv.Itoa(srm.FromIndex)
-	m["ToID"] = ""
-	m["Type"] = "SignRound6Message"
-
-	s := hex.EncodeToString(srm.S[:])
-	m["S"] = s
-
-	return m
-}
-
 // GetMsgType get msg type
 func (srm *SignRound6Message) GetMsgType() string {
 	return "SignRound6Message"
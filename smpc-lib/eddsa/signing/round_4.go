@@ -42,7 +42,7 @@ func (round *round4) Start() error {
 	var FinalR, temR2 ed.ExtendedGroupElement
 	var FinalRBytes [32]byte
 
-	for k := range round.idsign {
+	for k, v := range round.idsign {
 		msg1, ok := round.temp.signRound1Messages[k].(*SignRound1Message)
 		if !ok {
 			return errors.New("get cr fail")
@@ -56,7 +56,7 @@ func (round *round4) Start() error {
 		CRFlag := ed.Verify(msg1.CR, msg3.DR)
 		if !CRFlag {
 			fmt.Printf("error: commitment(r) not pass at user: %v\n", round.save.CurDNodeID)
-			return errors.New("smpc back-end internal error:commitment verification fail in ed sign")
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "RCommitment", errors.New("smpc back-end internal error:commitment verification fail in ed sign"))
 		}
 
 		msg2, ok := round.temp.signRound2Messages[k].(*SignRound2Message)
@@ -70,7 +70,7 @@ func (round *round4) Start() error {
 		zkRFlag := ed.VerifyZk2(msg2.ZkR, temR)
 		if !zkRFlag {
 			fmt.Printf("Error: ZeroKnowledge Proof (R) Not Pass at User: %v\n", round.save.CurDNodeID)
-			return errors.New("smpc back-end internal error:zeroknowledge verification fail in ed sign")
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "ZkR", errors.New("smpc back-end internal error:zeroknowledge verification fail in ed sign"))
 		}
 
 		var temRBytes [32]byte
@@ -43,7 +43,7 @@ func (round *round6) Start() error {
 
 	var sB2, temSB ed.ExtendedGroupElement
 
-	for k := range round.idsign {
+	for k, v := range round.idsign {
 		msg4, ok := round.temp.signRound4Messages[k].(*SignRound4Message)
 		if !ok {
 			return errors.New("get csb fail")
@@ -57,7 +57,7 @@ func (round *round6) Start() error {
 		CSBFlag := ed.Verify(msg4.CSB, msg5.DSB)
 		if !CSBFlag {
 			fmt.Printf("Error: Commitment(SB) Not Pass at User: %v", round.kgid)
-			return errors.New("smpc back-end internal error:commitment(CSB) not pass")
+			return smpc.NewBlameError(smpc.GetDNodeIDByUID(v), round.number, "SBCommitment", errors.New("smpc back-end internal error:commitment(CSB) not pass"))
 		}
 
 		var temSBBytes [32]byte
@@ -0,0 +1,77 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+
+// Package signing_test test MPC implementation of schnorr signing
+package signing_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/schnorr/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	signSigniMessages := make([]smpc.Message, 0)
+	succ := signing.CheckFull(signSigniMessages)
+	assert.False(t, succ, "fail")
+
+	threshold := 3
+	for i := 0; i < threshold; i++ {
+		srm := &signing.SignRound1Message{
+			SignRoundMessage: new(signing.SignRoundMessage),
+			C:                big.NewInt(int64(i + 1)),
+		}
+		srm.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		srm.SetFromIndex(i)
+
+		signSigniMessages = append(signSigniMessages, srm)
+	}
+
+	succ = signing.CheckFull(signSigniMessages)
+	assert.True(t, succ, "success")
+}
+
+// TestBip340Verify BIP-340 test vectors 0 and 1
+func TestBip340Verify(t *testing.T) {
+	pk, _ := hex.DecodeString("F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9")
+	msg := make([]byte, 32)
+	sig, _ := hex.DecodeString("E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0")
+	assert.True(t, signing.Bip340Verify(pk, msg, sig), "vector 0")
+
+	pk, _ = hex.DecodeString("DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	msg, _ = hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	sig, _ = hex.DecodeString("6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A")
+	assert.True(t, signing.Bip340Verify(pk, msg, sig), "vector 1")
+
+	sig[63] ^= 0x01
+	assert.False(t, signing.Bip340Verify(pk, msg, sig), "wrong sig")
+}
+
+// TestTaprootTweak BIP-86 test vector,key path only output key
+func TestTaprootTweak(t *testing.T) {
+	x, _ := new(big.Int).SetString("cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", 16)
+	px, py, err := signing.LiftX(x)
+	assert.NoError(t, err)
+
+	q, err := signing.XOnlyPubKey(px, py, []byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", hex.EncodeToString(q))
+}
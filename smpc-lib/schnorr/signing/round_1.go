@@ -0,0 +1,117 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- SchnorrSignData, kgid string, threshold int, txhash *big.Int, tweak []byte, sessionkey string) smpc.Round {
+	return &round1{
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, txhash, tweak, sessionkey}}
+}
+
+// Start calc w_i = lambda_i * sku1,choose nonce k_i and broadcast the commitment of R_i = k_i*G and W_i = w_i*G
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= schnorr sign round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	self := round.idsign[curIndex]
+	lambda1 := big.NewInt(1)
+	for k, v := range round.idsign {
+		if k == curIndex {
+			continue
+		}
+
+		sub := new(big.Int).Sub(v, self)
+		subInverse := new(big.Int).ModInverse(sub, secp256k1.S256().N)
+		if subInverse == nil {
+			return errors.New("calc times fail")
+		}
+
+		times := new(big.Int).Mul(subInverse, v)
+		lambda1 = new(big.Int).Mul(lambda1, times)
+		lambda1 = new(big.Int).Mod(lambda1, secp256k1.S256().N)
+	}
+	w1 := new(big.Int).Mul(lambda1, round.save.SkU1)
+	w1 = new(big.Int).Mod(w1, secp256k1.S256().N)
+
+	k1 := random.GetRandomIntFromZn(secp256k1.S256().N)
+	rx, ry := secp256k1.S256().ScalarBaseMult(k1.Bytes())
+	wx, wy := secp256k1.S256().ScalarBaseMult(w1.Bytes())
+	if rx == nil || wx == nil {
+		return errors.New("calc R_i or W_i fail")
+	}
+
+	commit := new(ec2.Commitment).Commit(rx, ry, wx, wy)
+	if commit == nil {
+		return errors.New(" Error generating commitment data in schnorr signing round 1")
+	}
+
+	round.temp.k = k1
+	round.temp.w = w1
+	round.temp.cd = commit.D
+
+	srm := &SignRound1Message{
+		SignRoundMessage: new(SignRoundMessage),
+		C:                commit.C,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound1Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.signRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
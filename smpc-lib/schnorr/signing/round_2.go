@@ -0,0 +1,82 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start broadcast the decommitment of R_i,W_i and the zk proof of k_i,w_i
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= schnorr sign round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	ctx := round.proofContext(round.idsign[curIndex], round.number)
+	zkr := ec2.ZkUProve(secp256k1.S256(), ctx, round.temp.k)
+	zkw := ec2.ZkUProve(secp256k1.S256(), ctx, round.temp.w)
+	if zkr == nil || zkw == nil {
+		return errors.New("schnorr sign generate zk proof fail")
+	}
+
+	srm := &SignRound2Message{
+		SignRoundMessage: new(SignRoundMessage),
+		D:                round.temp.cd,
+		ZkR:              zkr,
+		ZkW:              zkw,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound2Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound2Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	return round.update(round.temp.signRound2Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
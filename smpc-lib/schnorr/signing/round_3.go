@@ -0,0 +1,162 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the decommitment and zk proof,calc R = sum(R_i) and the challenge e,broadcast the partial signature s_i
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= schnorr sign round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	round.temp.rs = make([][]*big.Int, round.threshold)
+	round.temp.ws = make([][]*big.Int, round.threshold)
+	var rx, ry, wx, wy *big.Int
+	for k := range round.idsign {
+		msg1, ok := round.temp.signRound1Messages[k].(*SignRound1Message)
+		if !ok {
+			return errors.New("round.Start get round1 msg fail")
+		}
+
+		msg2, ok := round.temp.signRound2Messages[k].(*SignRound2Message)
+		if !ok {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		deCommit := &ec2.Commitment{C: msg1.C, D: msg2.D}
+		succ, values := deCommit.DeCommit(secp256k1.S256())
+		if !succ || len(values) != 4 {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "Commitment", errors.New("verify commitment fail"))
+		}
+
+		// the proofs are made in round 2 by party k
+		ctx := round.proofContext(round.idsign[k], 2)
+		if !ec2.ZkUVerify(secp256k1.S256(), ctx, values[0:2], msg2.ZkR) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZkR", errors.New("verify zk proof of k fail"))
+		}
+
+		if !ec2.ZkUVerify(secp256k1.S256(), ctx, values[2:4], msg2.ZkW) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZkW", errors.New("verify zk proof of w fail"))
+		}
+
+		round.temp.rs[k] = values[0:2]
+		round.temp.ws[k] = values[2:4]
+		if rx == nil {
+			rx, ry = values[0], values[1]
+			wx, wy = values[2], values[3]
+			continue
+		}
+
+		rx, ry = secp256k1.S256().Add(rx, ry, values[0], values[1])
+		wx, wy = secp256k1.S256().Add(wx, wy, values[2], values[3])
+	}
+
+	// sum(W_i) must be the pubkey,so the partial signature of every node can be verified with W_i
+	if wx.Cmp(round.save.Pkx) != 0 || wy.Cmp(round.save.Pky) != 0 {
+		return errors.New("the sum of public shares is not the pubkey")
+	}
+
+	// d = gQ * (gP * x + t)
+	qx, qy, t := round.save.Pkx, round.save.Pky, big.NewInt(0)
+	if !hasEvenY(qy) {
+		qy = negY(qy)
+	}
+	if round.tweak != nil {
+		qx, qy, t, err = TaprootTweak(round.save.Pkx, round.tweak)
+		if err != nil {
+			return err
+		}
+	}
+
+	c := big.NewInt(1)
+	if !hasEvenY(round.save.Pky) {
+		c = new(big.Int).Sub(secp256k1.S256().N, c)
+	}
+	if !hasEvenY(qy) {
+		c = new(big.Int).Sub(secp256k1.S256().N, c)
+	}
+
+	e := Challenge(rx, qx, bytes32(round.txhash))
+	c = new(big.Int).Mul(c, e)
+	c = new(big.Int).Mod(c, secp256k1.S256().N)
+
+	// s_i = gR * k_i + e * gQ * gP * w_i
+	k1 := round.temp.k
+	if !hasEvenY(ry) {
+		k1 = new(big.Int).Sub(secp256k1.S256().N, k1)
+	}
+	s1 := new(big.Int).Mul(c, round.temp.w)
+	s1 = new(big.Int).Add(s1, k1)
+	s1 = new(big.Int).Mod(s1, secp256k1.S256().N)
+
+	round.temp.rx = rx
+	round.temp.ry = ry
+	round.temp.qx = qx
+	round.temp.e = e
+	round.temp.c = c
+	round.temp.t = t
+	round.temp.gq = !hasEvenY(qy)
+
+	srm := &SignRound3Message{
+		SignRoundMessage: new(SignRoundMessage),
+		S:                s1,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound3Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound3Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	return round.update(round.temp.signRound3Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
@@ -0,0 +1,100 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the partial signatures,calc s = sum(s_i) + e * gQ * t and check the BIP-340 signature (R.x,s)
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= schnorr sign round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	s := big.NewInt(0)
+	for k := range round.idsign {
+		msg3, ok := round.temp.signRound3Messages[k].(*SignRound3Message)
+		if !ok || msg3.S == nil {
+			return errors.New("round.Start get round3 msg fail")
+		}
+
+		// s_i*G == gR * R_i + e * gQ * gP * W_i
+		sGx, sGy := secp256k1.S256().ScalarBaseMult(msg3.S.Bytes())
+		cWx, cWy := secp256k1.S256().ScalarMult(round.temp.ws[k][0], round.temp.ws[k][1], round.temp.c.Bytes())
+		rx, ry := round.temp.rs[k][0], round.temp.rs[k][1]
+		if !hasEvenY(round.temp.ry) {
+			ry = negY(ry)
+		}
+		if sGx == nil || cWx == nil {
+			return smpc.NewBlameError(msg3.GetFromID(), 4, "PartialSig", errors.New("verify partial signature fail"))
+		}
+
+		vx, vy := secp256k1.S256().Add(rx, ry, cWx, cWy)
+		if sGx.Cmp(vx) != 0 || sGy.Cmp(vy) != 0 {
+			return smpc.NewBlameError(msg3.GetFromID(), 4, "PartialSig", errors.New("verify partial signature fail"))
+		}
+
+		s = new(big.Int).Add(s, msg3.S)
+	}
+
+	et := new(big.Int).Mul(round.temp.e, round.temp.t)
+	if round.temp.gq {
+		et = new(big.Int).Neg(et)
+	}
+	s = new(big.Int).Add(s, et)
+	s = new(big.Int).Mod(s, secp256k1.S256().N)
+
+	var data SchnorrSignData
+	copy(data.Rx[:], bytes32(round.temp.rx))
+	copy(data.S[:], bytes32(s))
+	copy(data.XOnlyPk[:], bytes32(round.temp.qx))
+	copy(data.Message[:], bytes32(round.txhash))
+
+	sig := append(data.Rx[:], data.S[:]...)
+	if !Bip340Verify(data.XOnlyPk[:], data.Message[:], sig) {
+		return errors.New("verify schnorr signature fail")
+	}
+
+	round.end <- data
+	fmt.Printf("========= schnorr sign round4 finish, dnode id = %v ==========\n", round.kgid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		temp      *localTempData
+		save      *keygen.LocalDNodeSaveData
+		idsign    smpc.SortableIDSSlice
+		out       chan<- smpc.Message
+		end       chan<- SchnorrSignData
+		ok        []bool
+		started   bool
+		number    int
+		kgid      string
+		threshold int
+		txhash    *big.Int
+		tweak     []byte
+		sessionkey string
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		return false
+	}
+
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// proofContext get the context of the zk proof made by the party id in round number
+func (round *base) proofContext(id *big.Int, number int) *ec2.ProofContext {
+	return ec2.NewProofContext(round.sessionkey, id, number)
+}
+
+// GetIDs get from all nodes
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	return round.idsign, nil
+}
+
+// GetDNodeIDIndex get current dnode index by id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" {
+		return -1, errors.New("no found current node's uid")
+	}
+
+	uidtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+
+	idtmp, ok := new(big.Int).SetString(string(uidtmp[:]), 10)
+	if !ok {
+		return -1, errors.New("get uid fail")
+	}
+
+	for k, v := range round.idsign {
+		if v.Cmp(idtmp) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in idsign")
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// update is the messages of current round received?
+func (round *base) update(l []smpc.Message, canAccept func(smpc.Message) bool) (bool, error) {
+	for j, msg := range l {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !canAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
@@ -0,0 +1,176 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+)
+
+// SchnorrSignData the BIP-340 signature (R.x,s) and the x-only pubkey that verifies it
+type SchnorrSignData struct {
+	Rx      [32]byte
+	S       [32]byte
+	XOnlyPk [32]byte
+	Message [32]byte
+}
+
+// TaggedHash BIP-340 tagged hash: sha256(sha256(tag)||sha256(tag)||msg)
+func TaggedHash(tag string, msg ...[]byte) []byte {
+	th := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(th[:])
+	h.Write(th[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+// bytes32 big-endian 32 bytes of x
+func bytes32(x *big.Int) []byte {
+	b := make([]byte, 32)
+	xb := x.Bytes()
+	copy(b[32-len(xb):], xb)
+	return b
+}
+
+// hasEvenY weather the y coordinate is even
+func hasEvenY(y *big.Int) bool {
+	return y.Bit(0) == 0
+}
+
+// negY -y mod p
+func negY(y *big.Int) *big.Int {
+	return new(big.Int).Sub(secp256k1.S256().P, y)
+}
+
+// LiftX get the point with even y whose x coordinate is x
+func LiftX(x *big.Int) (*big.Int, *big.Int, error) {
+	p := secp256k1.S256().P
+	if x == nil || x.Sign() <= 0 || x.Cmp(p) >= 0 {
+		return nil, nil, errors.New("x is out of range")
+	}
+
+	c := new(big.Int).Exp(x, big.NewInt(3), p)
+	c.Add(c, big.NewInt(7))
+	c.Mod(c, p)
+
+	e := new(big.Int).Add(p, big.NewInt(1))
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(c, e, p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, nil, errors.New("x is not on the curve")
+	}
+
+	if !hasEvenY(y) {
+		y = negY(y)
+	}
+
+	return new(big.Int).Set(x), y, nil
+}
+
+// TaprootTweak BIP-341 taproot_tweak_pubkey,return the output key Q = lift_x(P.x) + t*G and t
+// merkleRoot is empty for the key path only output (BIP-86)
+func TaprootTweak(pkx *big.Int, merkleRoot []byte) (*big.Int, *big.Int, *big.Int, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return nil, nil, nil, errors.New("merkle root length error")
+	}
+
+	px, py, err := LiftX(pkx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	t := new(big.Int).SetBytes(TaggedHash("TapTweak", bytes32(px), merkleRoot))
+	if t.Cmp(secp256k1.S256().N) >= 0 {
+		return nil, nil, nil, errors.New("tweak is out of range")
+	}
+
+	tx, ty := secp256k1.S256().ScalarBaseMult(t.Bytes())
+	if tx == nil || ty == nil {
+		return nil, nil, nil, errors.New("calc tweak point fail")
+	}
+
+	qx, qy := secp256k1.S256().Add(px, py, tx, ty)
+	if qx == nil || qy == nil || !secp256k1.S256().IsOnCurve(qx, qy) {
+		return nil, nil, nil, errors.New("calc output key fail")
+	}
+
+	return qx, qy, t, nil
+}
+
+// XOnlyPubKey get the x-only pubkey that the signatures of (pkx,pky) are verified with
+// tweak is nil for plain BIP-340,otherwise the BIP-341 merkle root(may be empty)
+func XOnlyPubKey(pkx *big.Int, pky *big.Int, tweak []byte) ([]byte, error) {
+	if pkx == nil || pky == nil || !secp256k1.S256().IsOnCurve(pkx, pky) {
+		return nil, errors.New("pubkey error")
+	}
+
+	if tweak == nil {
+		return bytes32(pkx), nil
+	}
+
+	qx, _, _, err := TaprootTweak(pkx, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes32(qx), nil
+}
+
+// Challenge BIP-340 challenge e = hash_BIP0340/challenge(R.x||P.x||m) mod n
+func Challenge(rx *big.Int, px *big.Int, msg []byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", bytes32(rx), bytes32(px), msg))
+	return e.Mod(e, secp256k1.S256().N)
+}
+
+// Bip340Verify verify the 64 bytes signature of msg with the 32 bytes x-only pubkey
+func Bip340Verify(pk []byte, msg []byte, sig []byte) bool {
+	if len(pk) != 32 || len(sig) != 64 {
+		return false
+	}
+
+	px, py, err := LiftX(new(big.Int).SetBytes(pk))
+	if err != nil {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(secp256k1.S256().P) >= 0 || s.Cmp(secp256k1.S256().N) >= 0 {
+		return false
+	}
+
+	// R = s*G - e*P
+	e := Challenge(r, px, msg)
+	sGx, sGy := secp256k1.S256().ScalarBaseMult(s.Bytes())
+	ePx, ePy := secp256k1.S256().ScalarMult(px, py, e.Bytes())
+	if sGx == nil || ePx == nil {
+		return false
+	}
+
+	rx, ry := secp256k1.S256().Add(sGx, sGy, ePx, negY(ePy))
+	if rx == nil || ry == nil || (rx.Sign() == 0 && ry.Sign() == 0) {
+		return false
+	}
+
+	return hasEvenY(ry) && rx.Cmp(r) == 0
+}
@@ -0,0 +1,94 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package simulate_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+var benchGroupSizes = []int{3, 5, 7}
+
+// benchWorkers compare verifying the proofs one by one with verifying them concurrently
+func benchWorkers() []int {
+	if runtime.NumCPU() == 1 {
+		return []int{1}
+	}
+	return []int{1, runtime.NumCPU()}
+}
+
+func runWithWorkers(b *testing.B, name string, f func(b *testing.B)) {
+	for _, w := range benchWorkers() {
+		b.Run(fmt.Sprintf("%v/workers=%v", name, w), func(b *testing.B) {
+			old := smpc.MaxVerifyWorkers
+			smpc.MaxVerifyWorkers = w
+			defer func() { smpc.MaxVerifyWorkers = old }()
+			f(b)
+		})
+	}
+}
+
+func BenchmarkECKeyGen(b *testing.B) {
+	for _, n := range benchGroupSizes {
+		n := n
+		runWithWorkers(b, fmt.Sprintf("n=%v", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := simulate.ECKeyGen(n, n, "EC256K1", nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkECPreSignSign(b *testing.B) {
+	hash := sha256.Sum256([]byte("benchmark"))
+	for _, n := range benchGroupSizes {
+		saves, err := simulate.ECKeyGen(n, n, "EC256K1", nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		signers := allSigners(saves)
+		runWithWorkers(b, fmt.Sprintf("n=%v", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pres, err := simulate.ECPreSign(saves, signers, "EC256K1", nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, _, err := simulate.ECSign(saves, signers, pres, hash[:], "EC256K1", nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func allSigners(saves []*keygen.LocalDNodeSaveData) []int {
+	signers := make([]int, len(saves))
+	for i := range signers {
+		signers[i] = i
+	}
+	return signers
+}
@@ -0,0 +1,482 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/importkey"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/presign"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/recovery"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/refresh"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/reshare"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// ECKeyGen run ecdsa keygen among n parties.
+// Party i gets the uid i+1, the same as the smpc layer does.
+// The save data of dropped parties is nil.
+func ECKeyGen(n int, threshold int, keytype string, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	usePreParams(cfg)
+
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	for i := 0; i < n; i++ {
+		out := NewOut()
+		ends[i] = make(chan keygen.LocalDNodeSaveData, 1)
+		node := keygen.NewLocalDNode(out, ends[i], n, threshold, paillierKeyLength(cfg), keytype)
+		node.SetDNodeID(fmt.Sprintf("%v", i+1))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	saves := make([]*keygen.LocalDNodeSaveData, n)
+	for i := range ends {
+		if net.Dropped(i) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[i]:
+			saves[i] = &sd
+		default:
+			return nil, fmt.Errorf("party %v keygen not finish", i)
+		}
+	}
+
+	return saves, nil
+}
+
+// ECPreSign run ecdsa presign among the parties in signers (indexes of saves).
+// The indexes in cfg.Drop are indexes of signers.
+func ECPreSign(saves []*keygen.LocalDNodeSaveData, signers []int, keytype string, cfg *Config) ([]*signing.PrePubData, error) {
+	return runPreSign(saves, signers, cfg, func(out chan<- smpc.Message, end chan<- signing.PrePubData, sd *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice) smpc.DNode {
+		return signing.NewLocalDNode(out, end, sd, idsign, sd.CurDNodeID, len(signers), paillierKeyLength(cfg), false, nil, nil, nil, keytype)
+	})
+}
+
+// ECPreSignCGGMP run the three-round CGGMP21 presign of package presign among the parties in signers (indexes of saves).
+// The presign data can be used by ECSign the same as the data returned by ECPreSign.
+func ECPreSignCGGMP(saves []*keygen.LocalDNodeSaveData, signers []int, keytype string, cfg *Config) ([]*signing.PrePubData, error) {
+	return runPreSign(saves, signers, cfg, func(out chan<- smpc.Message, end chan<- signing.PrePubData, sd *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice) smpc.DNode {
+		return presign.NewLocalDNode(out, end, sd, idsign, sd.CurDNodeID, len(signers), paillierKeyLength(cfg), keytype)
+	})
+}
+
+// runPreSign run the presign dnodes created by newNode and collect the presign data
+func runPreSign(saves []*keygen.LocalDNodeSaveData, signers []int, cfg *Config, newNode func(chan<- smpc.Message, chan<- signing.PrePubData, *keygen.LocalDNodeSaveData, smpc.SortableIDSSlice) smpc.DNode) ([]*signing.PrePubData, error) {
+	idsign, err := getIDSign(saves, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	net := NewNetwork(cfg)
+	ends := make([]chan signing.PrePubData, len(signers))
+	for k, i := range signers {
+		out := NewOut()
+		ends[k] = make(chan signing.PrePubData, 1)
+		node := newNode(out, ends[k], saves[i], idsign)
+		node.SetDNodeID(fmt.Sprintf("%v", saves[i].CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	pres := make([]*signing.PrePubData, len(signers))
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case pre := <-ends[k]:
+			pres[k] = &pre
+		default:
+			return nil, fmt.Errorf("party %v presign not finish", signers[k])
+		}
+	}
+
+	return pres, nil
+}
+
+// ECSign finish the signature of hash with the presign data pres, which is returned by ECPreSign with the same signers.
+// It returns (r,s), r = R mod N.
+func ECSign(saves []*keygen.LocalDNodeSaveData, signers []int, pres []*signing.PrePubData, hash []byte, keytype string, cfg *Config) (*big.Int, *big.Int, error) {
+	if len(pres) != len(signers) {
+		return nil, nil, errors.New("presign data count error")
+	}
+
+	idsign, err := getIDSign(saves, signers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txhash := new(big.Int).SetBytes(hash)
+	net := NewNetwork(cfg)
+	ends := make([]chan *big.Int, len(signers))
+	for k, i := range signers {
+		if pres[k] == nil {
+			return nil, nil, fmt.Errorf("party %v has no presign data", i)
+		}
+
+		out := NewOut()
+		ends[k] = make(chan *big.Int, 1)
+		node := signing.NewLocalDNode(out, nil, saves[i], idsign, saves[i].CurDNodeID, len(signers), paillierKeyLength(cfg), true, pres[k], txhash, ends[k], keytype)
+		node.SetDNodeID(fmt.Sprintf("%v", saves[i].CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, nil, err
+	}
+
+	var s *big.Int
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case v := <-ends[k]:
+			if s != nil && s.Cmp(v) != 0 {
+				return nil, nil, fmt.Errorf("party %v get different s", signers[k])
+			}
+			s = v
+		default:
+			return nil, nil, fmt.Errorf("party %v sign not finish", signers[k])
+		}
+	}
+
+	if s == nil {
+		return nil, nil, errors.New("no party finish sign")
+	}
+
+	r := new(big.Int).Mod(pres[0].R, ec2.GetCurve(keytype).Params().N)
+	return r, s, nil
+}
+
+// ECVerify verify the ecdsa signature (r,s) of hash by pubkey (pkx,pky)
+func ECVerify(keytype string, pkx *big.Int, pky *big.Int, hash []byte, r *big.Int, s *big.Int) bool {
+	curve := ec2.GetCurve(keytype)
+	if curve == nil || pkx == nil || pky == nil || r == nil || s == nil {
+		return false
+	}
+
+	n := curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(hash)
+	w := new(big.Int).ModInverse(s, n)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(pkx, pky, u2.Bytes())
+	x, _ := curve.Add(x1, y1, x2, y2)
+	if x == nil {
+		return false
+	}
+
+	return new(big.Int).Mod(x, n).Cmp(r) == 0
+}
+
+// ECReshare reshare the key held by saves[oldSigners] to a new group of n parties with threshold.
+// The first len(oldSigners) parties of the new group are the old signers and keep their uids,
+// the others are new parties with uids following the largest old uid.
+func ECReshare(saves []*keygen.LocalDNodeSaveData, oldSigners []int, n int, threshold int, keytype string, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	idreshare, err := getIDSign(saves, oldSigners)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < len(oldSigners) {
+		return nil, errors.New("new group is too small")
+	}
+
+	usePreParams(cfg)
+
+	net := NewNetwork(cfg)
+	net.IDReshare = idreshare
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	uids := make([]*big.Int, n)
+	next := new(big.Int).Set(idreshare[len(idreshare)-1])
+	for k := 0; k < n; k++ {
+		out := NewOut()
+		ends[k] = make(chan keygen.LocalDNodeSaveData, 1)
+
+		var node smpc.DNode
+		if k < len(oldSigners) {
+			sd := copySaveData(saves[oldSigners[k]])
+			oldindex := -1
+			for j, id := range sd.IDs {
+				if id.Cmp(sd.CurDNodeID) == 0 {
+					oldindex = j
+					break
+				}
+			}
+
+			uids[k] = sd.CurDNodeID
+			node = reshare.NewLocalDNode(out, ends[k], n, threshold, paillierKeyLength(cfg), sd, true, oldindex, keytype)
+		} else {
+			next = new(big.Int).Add(next, big.NewInt(1))
+			uids[k] = next
+			// the new parties do not know the paillier key length of the key,they take it from the old parties
+			node = reshare.NewLocalDNode(out, ends[k], n, threshold, 0, nil, false, -1, keytype)
+		}
+
+		node.SetDNodeID(fmt.Sprintf("%v", uids[k]))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	news := make([]*keygen.LocalDNodeSaveData, n)
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[k]:
+			news[k] = &sd
+		default:
+			return nil, fmt.Errorf("party %v reshare not finish", k)
+		}
+	}
+
+	return news, nil
+}
+
+// ECRecoverShare re-create the sku1 of saves[lost] with all other parties of the keygen group.
+// The sku1 of saves[lost] is not used,the returned save data of saves[lost] has the recovered sku1.
+func ECRecoverShare(saves []*keygen.LocalDNodeSaveData, lost int, threshold int, keytype string, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	if lost < 0 || lost >= len(saves) || saves[lost] == nil {
+		return nil, fmt.Errorf("party %v has no save data", lost)
+	}
+
+	n := len(saves)
+	recoverid := saves[lost].CurDNodeID
+
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	for k := 0; k < n; k++ {
+		if saves[k] == nil {
+			return nil, fmt.Errorf("party %v has no save data", k)
+		}
+
+		out := NewOut()
+		ends[k] = make(chan keygen.LocalDNodeSaveData, 1)
+
+		sd := copySaveData(saves[k])
+		if k == lost {
+			sd.SkU1 = nil
+		}
+
+		node := recovery.NewLocalDNode(out, ends[k], n, threshold, sd, recoverid, keytype)
+		node.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	news := make([]*keygen.LocalDNodeSaveData, n)
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[k]:
+			news[k] = &sd
+		default:
+			return nil, fmt.Errorf("party %v recover share not finish", k)
+		}
+	}
+
+	return news, nil
+}
+
+// ECRefresh run ecdsa refresh among all parties of saves,the pubkey does not change and every party gets a new sku1
+func ECRefresh(saves []*keygen.LocalDNodeSaveData, threshold int, keytype string, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	n := len(saves)
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	for k := 0; k < n; k++ {
+		if saves[k] == nil {
+			return nil, fmt.Errorf("party %v has no save data", k)
+		}
+
+		out := NewOut()
+		ends[k] = make(chan keygen.LocalDNodeSaveData, 1)
+
+		sd := copySaveData(saves[k])
+		node := refresh.NewLocalDNode(out, ends[k], n, threshold, sd, keytype)
+		node.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	news := make([]*keygen.LocalDNodeSaveData, n)
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[k]:
+			news[k] = &sd
+		default:
+			return nil, fmt.Errorf("party %v refresh not finish", k)
+		}
+	}
+
+	return news, nil
+}
+
+// ECImportKey split the private key sk among n parties as a trusted dealer and run the import among them.
+// Party i gets the uid i+1, the same as the smpc layer does.
+func ECImportKey(sk *big.Int, n int, threshold int, keytype string, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	usePreParams(cfg)
+
+	curve := ec2.GetCurve(keytype)
+	ids := make(smpc.SortableIDSSlice, n)
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+	}
+
+	poly, polyG, err := ec2.Vss2Init(curve, sk, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := poly.Vss2(curve, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	c := random.GetRandomIntFromZn(curve.Params().N)
+
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	for i := 0; i < n; i++ {
+		out := NewOut()
+		ends[i] = make(chan keygen.LocalDNodeSaveData, 1)
+
+		sd := &keygen.LocalDNodeSaveData{Pkx: polyG.PolyG[0][0], Pky: polyG.PolyG[0][1], C: c, SkU1: shares[i].Share, IDs: ids, CurDNodeID: ids[i]}
+		node := importkey.NewLocalDNode(out, ends[i], n, threshold, paillierKeyLength(cfg), sd, polyG, keytype)
+		node.SetDNodeID(fmt.Sprintf("%v", ids[i]))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	saves := make([]*keygen.LocalDNodeSaveData, n)
+	for i := range ends {
+		if net.Dropped(i) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[i]:
+			saves[i] = &sd
+		default:
+			return nil, fmt.Errorf("party %v import key not finish", i)
+		}
+	}
+
+	return saves, nil
+}
+
+// getIDSign get the sorted uids of the signers
+func getIDSign(saves []*keygen.LocalDNodeSaveData, signers []int) (smpc.SortableIDSSlice, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("no signers")
+	}
+
+	var ids smpc.SortableIDSSlice
+	for _, i := range signers {
+		if i < 0 || i >= len(saves) || saves[i] == nil || saves[i].CurDNodeID == nil {
+			return nil, fmt.Errorf("party %v has no save data", i)
+		}
+
+		ids = append(ids, saves[i].CurDNodeID)
+	}
+
+	sort.Sort(ids)
+	return ids, nil
+}
+
+// copySaveData copy the save data so that reshare will not change the input
+func copySaveData(sd *keygen.LocalDNodeSaveData) *keygen.LocalDNodeSaveData {
+	c := *sd
+	c.U1PaillierPk = append([]*ec2.PublicKey(nil), sd.U1PaillierPk...)
+	c.U1NtildeH1H2 = append([]*ec2.NtildeH1H2(nil), sd.U1NtildeH1H2...)
+	c.IDs = append(smpc.SortableIDSSlice(nil), sd.IDs...)
+	return &c
+}
+
+// paillierKeyLength get the paillier key length of cfg
+func paillierKeyLength(cfg *Config) int {
+	if cfg == nil || cfg.PaillierKeyLength == 0 {
+		return ec2.DefaultPaillierKeyLength
+	}
+
+	return cfg.PaillierKeyLength
+}
+
+// dropParties mark the parties in cfg.Drop offline
+func dropParties(net *Network, cfg *Config) {
+	if cfg == nil {
+		return
+	}
+
+	for _, i := range cfg.Drop {
+		net.Drop(i)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package simulate
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/reshare"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// EDKeyGen run ed25519 keygen among n parties.
+// Party i gets the uid i+1, the same as the smpc layer does.
+// The save data of dropped parties is nil.
+func EDKeyGen(n int, threshold int, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	for i := 0; i < n; i++ {
+		out := NewOut()
+		ends[i] = make(chan keygen.LocalDNodeSaveData, 1)
+		node := keygen.NewLocalDNode(out, ends[i], n, threshold)
+		node.SetDNodeID(fmt.Sprintf("%v", i+1))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	saves := make([]*keygen.LocalDNodeSaveData, n)
+	for i := range ends {
+		if net.Dropped(i) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[i]:
+			saves[i] = &sd
+		default:
+			return nil, fmt.Errorf("party %v keygen not finish", i)
+		}
+	}
+
+	return saves, nil
+}
+
+// EDImportKey split the ed25519 private key seed among n parties as a trusted dealer,every party verifies its share and gets its save data.
+// Party i gets the uid i+1, the same as the smpc layer does.
+func EDImportKey(seed [32]byte, n int, threshold int) ([]*keygen.LocalDNodeSaveData, error) {
+	ids := make(smpc.SortableIDSSlice, n)
+	uids := make([][32]byte, n)
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+		copy(uids[i][:], ids[i].Bytes())
+	}
+
+	_, cfsBBytes, shares, err := ed.Vss(ed.SecretFromSeed(seed), uids, threshold, n)
+	if err != nil {
+		return nil, err
+	}
+
+	var c [32]byte
+	if _, err := io.ReadFull(cryptorand.Reader, c[:]); err != nil {
+		return nil, err
+	}
+
+	saves := make([]*keygen.LocalDNodeSaveData, n)
+	for i := range saves {
+		saves[i], err = keygen.ImportSaveData(shares[i], cfsBBytes, c, ids, ids[i])
+		if err != nil {
+			return nil, fmt.Errorf("party %v import key fail: %v", i, err)
+		}
+	}
+
+	return saves, nil
+}
+
+// EDReshare reshare the ed25519 key held by saves[oldSigners] to a new group of n parties with threshold,
+// the count of oldSigners must be threshold.
+// The first len(oldSigners) parties of the new group are the old signers and keep their uids,
+// the others are new parties with uids following the largest old uid.
+func EDReshare(saves []*keygen.LocalDNodeSaveData, oldSigners []int, n int, threshold int, cfg *Config) ([]*keygen.LocalDNodeSaveData, error) {
+	idreshare, err := edIDSign(saves, oldSigners)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < len(oldSigners) {
+		return nil, errors.New("new group is too small")
+	}
+
+	net := NewNetwork(cfg)
+	ends := make([]chan keygen.LocalDNodeSaveData, n)
+	uids := make([]*big.Int, n)
+	next := new(big.Int).Set(idreshare[len(idreshare)-1])
+	for k := 0; k < n; k++ {
+		out := NewOut()
+		ends[k] = make(chan keygen.LocalDNodeSaveData, 1)
+
+		var node smpc.DNode
+		if k < len(oldSigners) {
+			sd := *saves[oldSigners[k]]
+			sd.IDs = append(smpc.SortableIDSSlice(nil), sd.IDs...)
+			uids[k] = sd.CurDNodeID
+			node = reshare.NewLocalDNode(out, ends[k], n, threshold, &sd, true, idreshare)
+		} else {
+			next = new(big.Int).Add(next, big.NewInt(1))
+			uids[k] = next
+			node = reshare.NewLocalDNode(out, ends[k], n, threshold, nil, false, nil)
+		}
+
+		node.SetDNodeID(fmt.Sprintf("%v", uids[k]))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	news := make([]*keygen.LocalDNodeSaveData, n)
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case sd := <-ends[k]:
+			news[k] = &sd
+		default:
+			return nil, fmt.Errorf("party %v reshare not finish", k)
+		}
+	}
+
+	return news, nil
+}
+
+// EDFrostPreSign run the FROST preprocessing among the parties in signers (indexes of saves).
+// The indexes in cfg.Drop are indexes of signers.
+func EDFrostPreSign(saves []*keygen.LocalDNodeSaveData, signers []int, cfg *Config) ([]*frost.PrePubData, error) {
+	idsign, err := edIDSign(saves, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	net := NewNetwork(cfg)
+	ends := make([]chan frost.PrePubData, len(signers))
+	for k, i := range signers {
+		out := NewOut()
+		ends[k] = make(chan frost.PrePubData, 1)
+		node := frost.NewLocalDNode(out, ends[k], saves[i], idsign, saves[i].CurDNodeID, len(signers), false, nil, nil, nil)
+		node.SetDNodeID(fmt.Sprintf("%v", saves[i].CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	pres := make([]*frost.PrePubData, len(signers))
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case pre := <-ends[k]:
+			pres[k] = &pre
+		default:
+			return nil, fmt.Errorf("party %v frost presign not finish", signers[k])
+		}
+	}
+
+	return pres, nil
+}
+
+// EDFrostSign sign message in a single round with the FROST pre data pres, which is returned by EDFrostPreSign with the same signers.
+func EDFrostSign(saves []*keygen.LocalDNodeSaveData, signers []int, pres []*frost.PrePubData, message []byte, cfg *Config) (*signing.EdSignData, error) {
+	if len(pres) != len(signers) {
+		return nil, errors.New("frost pre data count error")
+	}
+
+	idsign, err := edIDSign(saves, signers)
+	if err != nil {
+		return nil, err
+	}
+
+	net := NewNetwork(cfg)
+	ends := make([]chan signing.EdSignData, len(signers))
+	for k, i := range signers {
+		if pres[k] == nil {
+			return nil, fmt.Errorf("party %v has no frost pre data", i)
+		}
+
+		out := NewOut()
+		ends[k] = make(chan signing.EdSignData, 1)
+		node := frost.NewLocalDNode(out, nil, saves[i], idsign, saves[i].CurDNodeID, len(signers), true, pres[k], message, ends[k])
+		node.SetDNodeID(fmt.Sprintf("%v", saves[i].CurDNodeID))
+		net.Add(node, out)
+	}
+	dropParties(net, cfg)
+
+	if err := net.Run(); err != nil {
+		return nil, err
+	}
+
+	var sig *signing.EdSignData
+	for k := range ends {
+		if net.Dropped(k) {
+			continue
+		}
+
+		select {
+		case v := <-ends[k]:
+			if sig != nil && *sig != v {
+				return nil, fmt.Errorf("party %v get different signature", signers[k])
+			}
+			sig = &v
+		default:
+			return nil, fmt.Errorf("party %v frost sign not finish", signers[k])
+		}
+	}
+
+	if sig == nil {
+		return nil, errors.New("no party finish sign")
+	}
+
+	return sig, nil
+}
+
+// edIDSign get the sorted uids of the signers
+func edIDSign(saves []*keygen.LocalDNodeSaveData, signers []int) (smpc.SortableIDSSlice, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("no signers")
+	}
+
+	var ids smpc.SortableIDSSlice
+	for _, i := range signers {
+		if i < 0 || i >= len(saves) || saves[i] == nil || saves[i].CurDNodeID == nil {
+			return nil, fmt.Errorf("party %v has no save data", i)
+		}
+
+		ids = append(ids, saves[i].CurDNodeID)
+	}
+
+	sort.Sort(ids)
+	return ids, nil
+}
@@ -0,0 +1,214 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package simulate_test
+
+import (
+	"crypto/ed25519"
+	"sync"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/reshare"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	edOnce  sync.Once
+	edSaves []*keygen.LocalDNodeSaveData
+	edErr   error
+)
+
+// getEDSaves run 2/3 ed25519 keygen once and share the result between tests
+func getEDSaves() ([]*keygen.LocalDNodeSaveData, error) {
+	edOnce.Do(func() {
+		edSaves, edErr = simulate.EDKeyGen(3, 2, nil)
+	})
+	return edSaves, edErr
+}
+
+func TestEDFrostSign(t *testing.T) {
+	saves, err := getEDSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, sd := range saves {
+		assert.Equal(t, saves[0].FinalPkBytes, sd.FinalPkBytes, "pubkey")
+	}
+
+	for _, signers := range [][]int{{0, 1}, {0, 2}, {1, 2}} {
+		pres, err := simulate.EDFrostPreSign(saves, signers, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		msg := []byte("frost")
+		sig, err := simulate.EDFrostSign(saves, signers, pres, msg, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		raw := append(sig.Rx[:], sig.Sx[:]...)
+		pk := saves[0].FinalPkBytes
+		assert.True(t, ed25519.Verify(ed25519.PublicKey(pk[:]), msg, raw), "verify")
+		assert.False(t, ed25519.Verify(ed25519.PublicKey(pk[:]), []byte("other"), raw), "verify")
+	}
+}
+
+func TestEDFrostSignTamper(t *testing.T) {
+	saves, err := getEDSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	signers := []int{0, 2}
+	pres, err := simulate.EDFrostPreSign(saves, signers, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := &simulate.Config{
+		Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+			m, ok := msg.(*frost.SignRound1Message)
+			if !ok || from != 1 {
+				return msg
+			}
+
+			bad := *m
+			bad.Z[0] ^= 1
+			return &bad
+		},
+	}
+
+	_, err = simulate.EDFrostSign(saves, signers, pres, []byte("frost"), cfg)
+	assert.Error(t, err, "tampered signature share must be rejected")
+}
+
+func TestEDFrostPreSignTamper(t *testing.T) {
+	saves, err := getEDSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := &simulate.Config{
+		Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+			m, ok := msg.(*frost.PreSignRound1Message)
+			if !ok || from != 1 {
+				return msg
+			}
+
+			bad := *m
+			bad.Y = m.D
+			return &bad
+		},
+	}
+
+	_, err = simulate.EDFrostPreSign(saves, []int{0, 1}, cfg)
+	assert.Error(t, err, "wrong public verification share must be rejected")
+}
+
+func TestEDReshare(t *testing.T) {
+	saves, err := getEDSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 2/3 --> 2/4,party 1 of the old group does not take part in
+	news, err := simulate.EDReshare(saves, []int{0, 2}, 4, 2, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pk := saves[0].FinalPkBytes
+	for _, sd := range news {
+		assert.Equal(t, pk, sd.FinalPkBytes, "the pubkey does not change")
+		assert.Equal(t, 4, len(sd.IDs), "ids")
+	}
+
+	// sign with the new shares,including the new parties only
+	for _, signers := range [][]int{{0, 1}, {1, 3}, {2, 3}} {
+		pres, err := simulate.EDFrostPreSign(news, signers, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		msg := []byte("ed reshare")
+		sig, err := simulate.EDFrostSign(news, signers, pres, msg, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		raw := append(sig.Rx[:], sig.Sx[:]...)
+		assert.True(t, ed25519.Verify(ed25519.PublicKey(pk[:]), msg, raw), "verify")
+	}
+}
+
+func TestEDReshareTamper(t *testing.T) {
+	saves, err := getEDSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := &simulate.Config{
+		Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+			m, ok := msg.(*reshare.ReRound2Message)
+			if !ok || from != 1 {
+				return msg
+			}
+
+			bad := *m
+			bad.Share[0] ^= 1
+			return &bad
+		},
+	}
+
+	_, err = simulate.EDReshare(saves, []int{0, 2}, 3, 2, cfg)
+	assert.Error(t, err, "tampered reshare share must be rejected")
+}
+
+func TestEDImportKey(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], []byte("import an existing ed25519 key!!"))
+	saves, err := simulate.EDImportKey(seed, 3, 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the address of the imported key does not change
+	pub := ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey)
+	for _, sd := range saves {
+		assert.Equal(t, []byte(pub), sd.FinalPkBytes[:], "pubkey")
+	}
+
+	signers := []int{1, 2}
+	pres, err := simulate.EDFrostPreSign(saves, signers, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := []byte("import key")
+	sig, err := simulate.EDFrostSign(saves, signers, pres, msg, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	raw := append(sig.Rx[:], sig.Sx[:]...)
+	assert.True(t, ed25519.Verify(pub, msg, raw), "verify")
+}
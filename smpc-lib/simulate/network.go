@@ -0,0 +1,309 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package simulate run several local dnodes in one process, routing the smpc messages between them by in-memory channels.
+//
+// Every ecdsa flow takes the paillier key and ntilde of party i from TestPreParams(i) (or Config.PreParams),
+// no safe prime is generated at runtime and the modulus proofs of the fixtures are computed once per process.
+// The tests of the package keep to this contract: `go test ./smpc-lib/simulate/` runs every test,
+// including the ones that run their own 2048-bit keygen or import key, within the default timeout on one cpu.
+package simulate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+const (
+	// OutBufferSize the cap of the out channel of every dnode.
+	// Round.Start sends to out while holding the dnode lock, so it must never block.
+	OutBufferSize = 1024
+)
+
+// TamperFunc is called before every delivery of msg from party `from` to party `to`.
+// It returns the message that will really be delivered, nil means the message is dropped.
+type TamperFunc func(from int, to int, msg smpc.Message) smpc.Message
+
+// Config the options of one simulation run
+type Config struct {
+	// Tamper inject malicious/corrupted messages
+	Tamper TamperFunc
+
+	// Drop the indexes of the parties that are offline: they never start, never send and never receive
+	Drop []int
+
+	// PaillierKeyLength the bit length of paillier N and Ntilde, 0 means ec2.DefaultPaillierKeyLength
+	PaillierKeyLength int
+
+	// SessionKey the session key the zk proofs are bound to, "" means DefaultSessionKey
+	SessionKey string
+
+	// PreParams the pool the parties take the paillier key and ntilde from, nil means every party takes TestPreParams
+	PreParams ec2.PreParamsFunc
+}
+
+// DefaultSessionKey the session key of the simulated sessions
+const DefaultSessionKey = "simulate"
+
+// timeoutDNode the dnode that can go on without the messages that never arrive
+type timeoutDNode interface {
+	Timeout(wait time.Duration) (bool, error)
+}
+
+// reshareDNode the reshare dnode need the ids of the old nodes taking part in reshare
+type reshareDNode interface {
+	SetIDReshare(ids smpc.SortableIDSSlice)
+}
+
+// Network in-memory network connecting several local dnodes
+type Network struct {
+	nodes   []smpc.DNode
+	outs    []chan smpc.Message
+	dropped []bool
+
+	// Tamper see TamperFunc
+	Tamper TamperFunc
+
+	// IDReshare the sorted uids of the old nodes taking part in reshare
+	IDReshare smpc.SortableIDSSlice
+
+	// SessionKey set to every dnode added to the network
+	SessionKey string
+}
+
+// NewNetwork new an empty network
+func NewNetwork(cfg *Config) *Network {
+	n := &Network{SessionKey: DefaultSessionKey}
+	if cfg != nil {
+		n.Tamper = cfg.Tamper
+		if cfg.SessionKey != "" {
+			n.SessionKey = cfg.SessionKey
+		}
+	}
+	return n
+}
+
+// NewOut new an out channel for a dnode that will be added to the network
+func NewOut() chan smpc.Message {
+	return make(chan smpc.Message, OutBufferSize)
+}
+
+// Add add a dnode and its out channel to the network, return the party index
+func (n *Network) Add(node smpc.DNode, out chan smpc.Message) int {
+	node.SetSessionKey(n.SessionKey)
+	n.nodes = append(n.nodes, node)
+	n.outs = append(n.outs, out)
+	n.dropped = append(n.dropped, false)
+	return len(n.nodes) - 1
+}
+
+// Drop mark the party offline
+func (n *Network) Drop(index int) {
+	if index >= 0 && index < len(n.dropped) {
+		n.dropped[index] = true
+	}
+}
+
+// Dropped weather the party is offline
+func (n *Network) Dropped(index int) bool {
+	return n.dropped[index]
+}
+
+// Len the count of parties
+func (n *Network) Len() int {
+	return len(n.nodes)
+}
+
+// Run start all online parties and deliver messages until no message is pending.
+// Messages are delivered in waves: every wave drains all out channels, then each party
+// handles its own messages in order while different parties run in parallel.
+// When no message is pending the network times out the parties waiting for messages that never come,
+// it stops once none of them can go on.
+// It returns the first error reported by a dnode.
+func (n *Network) Run() error {
+	errs := make([]error, len(n.nodes))
+	var wg sync.WaitGroup
+	for i, node := range n.nodes {
+		if n.dropped[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, node smpc.DNode) {
+			defer wg.Done()
+			if err := node.Start(); err != nil {
+				errs[i] = fmt.Errorf("party %v start fail: %v", i, err)
+				return
+			}
+
+			// the first round is created by Start, set the ids to it before any message arrives
+			if rn, ok := node.(reshareDNode); ok {
+				rn.SetIDReshare(n.IDReshare)
+			}
+		}(i, node)
+	}
+	wg.Wait()
+	if err := firstError(errs); err != nil {
+		return err
+	}
+
+	for {
+		inbox, pending := n.collect()
+		if !pending {
+			timedout, err := n.timeout()
+			if err != nil || !timedout {
+				return err
+			}
+
+			continue
+		}
+
+		for to, msgs := range inbox {
+			if len(msgs) == 0 {
+				continue
+			}
+
+			wg.Add(1)
+			go func(to int, msgs []delivery) {
+				defer wg.Done()
+				for _, d := range msgs {
+					if err := n.deliver(to, d); err != nil {
+						errs[to] = err
+						return
+					}
+				}
+			}(to, msgs)
+		}
+		wg.Wait()
+		if err := firstError(errs); err != nil {
+			return err
+		}
+	}
+}
+
+// timeout let the online parties waiting for the missing messages go on,
+// it returns weather any of them did
+func (n *Network) timeout() (bool, error) {
+	timedout := false
+	for i, node := range n.nodes {
+		tn, ok := node.(timeoutDNode)
+		if n.dropped[i] || !ok {
+			continue
+		}
+
+		ok, err := tn.Timeout(0)
+		if err != nil {
+			return false, fmt.Errorf("party %v timeout fail: %w", i, err)
+		}
+		timedout = timedout || ok
+	}
+
+	return timedout, nil
+}
+
+// delivery one message waiting to be delivered
+type delivery struct {
+	from int
+	msg  smpc.Message
+}
+
+// collect drain all out channels and route every message to the inbox of its recipients,
+// broadcast to all other parties or p2p by msg.GetToID()
+func (n *Network) collect() ([][]delivery, bool) {
+	inbox := make([][]delivery, len(n.nodes))
+	pending := false
+	for from := range n.nodes {
+		for {
+			var msg smpc.Message
+			select {
+			case msg = <-n.outs[from]:
+			default:
+			}
+
+			if msg == nil {
+				break
+			}
+
+			pending = true
+			for to, node := range n.nodes {
+				if to == from || n.dropped[to] {
+					continue
+				}
+
+				if !msg.IsBroadcast() && !isRecipient(node, msg) {
+					continue
+				}
+
+				m := msg
+				if n.Tamper != nil {
+					m = n.Tamper(from, to, msg)
+					if m == nil {
+						continue
+					}
+				}
+
+				inbox[to] = append(inbox[to], delivery{from: from, msg: m})
+			}
+		}
+	}
+
+	return inbox, pending
+}
+
+// deliver hand one message to party to,the message goes through the binary wire format as it does between the real nodes
+func (n *Network) deliver(to int, d delivery) error {
+	b, err := smpc.EncodeMessage(d.msg)
+	if err != nil {
+		return fmt.Errorf("party %v encode msg fail, msg type = %v, err = %v", d.from, d.msg.GetMsgType(), err)
+	}
+
+	msg, err := smpc.DecodeMessage(b)
+	if err != nil {
+		return fmt.Errorf("party %v decode msg fail, from = %v, msg type = %v, err = %v", to, d.from, d.msg.GetMsgType(), err)
+	}
+
+	if _, err := n.nodes[to].Update(msg); err != nil {
+		return fmt.Errorf("party %v update fail, from = %v, msg type = %v, err = %w", to, d.from, d.msg.GetMsgType(), err)
+	}
+
+	return nil
+}
+
+// firstError return the error of the party with the smallest index
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isRecipient weather the dnode is in the ToID list of msg
+func isRecipient(node smpc.DNode, msg smpc.Message) bool {
+	for _, id := range msg.GetToID() {
+		if id == node.DNodeID() {
+			return true
+		}
+	}
+
+	return false
+}
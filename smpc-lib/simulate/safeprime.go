@@ -0,0 +1,153 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// TestParties the number of the parties that have fixed pre params,the party with uid i+1 takes TestPreParams(i)
+const TestParties = 5
+
+// testSafePrimes 1024-bit safe primes p = 2q+1, only for simulation.
+// Generating them at runtime takes minutes, so the paillier key and ntilde of the party i are built from the 4 primes starting at 4*i:
+// paillier N = p[4i]*p[4i+1], Ntilde = p[4i+2]*p[4i+3]. No two parties share a modulus.
+var testSafePrimes = []string{
+	"E442550E0410BE5CFB6D64904484463087DF77832F9201D4FDBF21FB6A58D7FBEDF7A00EB8ADB3A003D9012AF10EAB3631F54B0EAF1EC2E8EDE98FF56D111279BE325D022B30126A9E06C15FF935FAEB4362FCE5DA241D5B9D1DDC21280795F38E1A6444F6AEFF73E24231095D46A37754E097453F3690395C0988C8FA832E43",
+	"FD8856BECA1B785906FF8855269DD347EC21DEF7B942269959A3D248225EFD4DA63D1800E72D6833510040C4C5A023FBAF55BC0081E5B29B464759A35DDDD4947731FB687303B57D4350AEE5D009BE8FEBDE126AEC83F4677A2269655EA9B79C4448D7D083FC6E56381FE868906393CA60B64EADA3A99353CB3B36758757D213",
+	"F3030209904C2E66CF3423F91C0C15CA570669AB91E7F371487D64A6A5029AC9596FA9612F4A2690D0C195D3A572B9CB0A872A3EAACB58E73EB33A36D4FA54940D0422E5A6CBC3000EC395D4AD0832D8A875DD12E458B1CA2C491007641233E95E78302DFB544E9218C16DC1066EF2BC652E6C8EDB614CFBFF4BD163F1B196A7",
+	"FE5CC9A7EB0CC274E0B039BF1ABB71E2F7B2C876A85CCF147CDB26E6D3C7CB38C7D207D2036B9DFBD1700CFF9718C97F2120955C46A16733BFD8B750852BE4A941CB977C6328A7FEC12A54D0E726B82409D29EE536FE741429F9BA744E41E03DA9F90B8D270F2C86294A1FBC4715A30C81C6CF391EDBD80B278BD360CD07A1AB",
+	"CCDCB9D0D02DD8EDA390F598320916B6F56356A10776277D3BC5C554AA0AAC440025353CF3545E8B9BCE7C37CA9E87BE97826F76DA56A3951678794D0BE44DF96C9C676E0C172A75AB01F85FDDAC0783CB3F4C5E50A004EA7EB3D9CE866ED213C8D63B799597733954B5FAA0BBDAFB3386F7E38B98B01BBDAC6DF1202AB0C6AF",
+	"DAD9542C15D799A0F1B4DE7508A6DF7F3582DCCE100BC050BF71AC456A819BC974193625B085CF311701D781C41812D9BA27A6CDF8E8CEEDD319BBF1347486625E4C3934B3B633FFF5B5EDCB2E84EE115025BE3250488DEEFD8C288E524E51A97AC4CC1906643026FC3A89562F3DF498E81628C5CCDAF682E1EB5D9CE4827FF7",
+	"C3AA304604E375A172921502B14F66A82656911FAF55F2633A24FB86FF9360BD73C574DEDE0781899FD96EF2A3B7B2B7525426FF2751E3AC468B0A241225E00DB3014A91DA85DFBE1114B68879E97630798E449DDD02B456C4BF524263AAED9F5397955A51CC38513448CE8558C98C6B451963DBE4CD4CD7F43E384F5604526B",
+	"C1A3BB24CF439970FFEDBEC96768573E28DCBD5C363D0C08B94F7E5342435E48FEF997FAD5FAD0DC2E266862AF951A468D451960C74254C48D9709B96ACF370641F68D8997431B4BDE8B7E9DB25E07B8A2EFCF950154959599CD394ABA567DFDFCB519F0DAC42E7BAA3572BE78B2CDCC10D55335EB4A4154DC9ADECD2389934F",
+	"C3B33923B4BC62C351E1067CD29F966C163003BBE67F1E157CC96022304C3335ACE2BE2A73C2DE3A1B66BD4A93865A3D1C97FEAD726AEDD3824C3EA16F17F1DE81EB37B4CD6AE64BF6D969685384065586314D7F6D3DE308540FAEC005D9B08730AB9D49AE81E713757806C9157D6B79B64BC01E1C0F0A0D6128B9848030140F",
+	"EE7C7E8B91BFCAC62E401F3DAE1D1083144868C8DF6990267413860A25BF1B7CAB246F36BA73BD7145B0B3BDE413BE597B26B48BE8B19D880F005BBD6AEA281D37B19182AD6108092D225406078883A6642A5E3DAD0CF5830F807D2306D2D39C7BCB53D66D654C82E37B683145FAD81BE41337B54B8767B45A5BD70F0AEC784B",
+	"C1E635EEF62D2A716DFD39183D78FD129640F4FFE8EF093ABBCD0D3166904E7B5C45D9AA7EA8F0E38176419B49B981B7204442B40B56D469B24758E587D3E6CA57373806F9E4EC838C61A9AC817959CD29BA26016B5FF434C4841B9EBD80E17828DE96E0B471A4E36109ED437681EB1051264FFC197B4427E405B81F135A4CA7",
+	"C05E33DCE64476235BC023845B04818EA81FCA3D2DC2957F3994F2C322F508A64B605AE9B06F00A7870A5E3E839480C2317A329D644B4E4AFBFE4A582438A8567AAA95CC7156B919082E1B5BBFA0587CF68F5134A374CC1A9A34C67E976D182BC75AAEF50FC1C7FAAFD2678E02EEF6D78C1CBF81A17BC3008FDE0563DC0EC63B",
+	"DC2739238C2797F4C2599CEB85B936C5A1982EEF2B38E5C67AFB51A5170093941D6558DA5D3A1AEC333C17AD9068ED7E03E4624A54E18E760E4FAF61B0ACD5F82F2625144F936C029691389DC70607DE3EA4ED24EA282D19B6B6E9DD3D3C07E90BB5BF0D75B76E97A7552B13A936B412255C9ADB59BDA62535D77D6CD4C4ABB3",
+	"D4F4007AF543B8D918970A39A8351D3E48F824D7BD12139D7FCD7BE6000FF63BB956BE83082E82C642DEA7990219881FAB0120F51A482DC8D39820D5F59A315CB76767BFBFC885B186BF5F1C9B91BC82A6FC3E4BD4B0F522F22D73996DD873C49811A0BC4047C308255DA876D62736843E0F8EBA5BEF4E87276B69951AD02A3F",
+	"E2EFAFC78502653DABA915FCDFF853A56C3761E2850D751CD05A40CAC680B7EDE99726E8F1D8835DAEF97207D2CC147A38930097CC2AA6AD819A752BABF849B4DC804D2D62942469EFA112566F44306F63F0613638906223AD9D8C144A9DE5ED262C283D54705918DC3FDA0C357A6050ACBF283EF92F3F95EE5841B91BCF614B",
+	"C16468982A6119E34B38A7709AB3FD6404361C3255BFFBD3592E33F98977BA82650737ED84057D55678C1C7734C18C74B477698EFBC8719CA8482656FDED850700A6DD758CA413B39A28C0D67255E6ABAE1B534C2686BD97755196C611DD05F63CDA7BE6C20569A491B9BA31A93D9B7BE48787474F95BA99E1542F2C16D0C33F",
+	"D78C81AE0072B7B364D4EBBA497BC598C7F1850C55A6ECE7A963A9F378F276CA241946B7835AA516BAC4A357839B67916360F9143C7AF845A568ADAFA00CED9056EB0F5F7B5EBFC77F7E57F7A557419388BC71623226BABA5CC1CCABB52DD9ABEB52B7A081A29C09CC1B77F19939F0F766581E01A79A5034136BC5FE63597F6F",
+	"F8D19B91D29CF541EDBD0C93A1B803C676F87943763E9C44F67BACAD8BEE5C5B48080031D0CF063623F50130C3C71EF6DD8ED7CA8C0790D34D60082EDE351DC07E071F65784F4BED35C40807383283F92185DE43EE2E509CBD4B1BE4848A875EB5A94DB2CA5281292FF751BB1E14AEBC12FB4838DCB582AC04FDE3A9A04D59CB",
+	"D03572F0026C83CF9467E8BB277FF0ECFF2CBF6054AE81CCE89B567D8432D2363782F4DF6236D4B858F14A289ECCD85400D8F1C1C2F2E76DCB212534B073BFC0679EB7B6CE4211955BCCA7C29ADF533EECD61617C3E3F3F9998B3C2DE7A6D9906726FEF011858DBBA7A316CDF35DAC4B4571E52CF54A844ECA9B47E0F159741B",
+	"DD033544E8AA3F4268A7850BB98D7383B1BB2CDC4D31623A89DB6922686ACF0AB9B07D0394267ABF9925CD54850911125958735076A52FFD8A7CD5174986C805E759AAA0FBB26077CD780DA3F9353AA714C888029DEA68A235EB5C07773C98D21C6C9CFF2D03826D119185C72580B4F510E1E4498FA5FB6A679BC792CD4B1D33",
+}
+
+var loadOnce sync.Once
+
+// LoadTestSafePrimes fill ec2.SafePrimeCh with the first 4 test safe primes,they are used by the party that has no fixed pre params.
+// It must not be used outside of tests: all such parties will share the same paillier and ntilde modulus.
+func LoadTestSafePrimes() {
+	loadOnce.Do(func() {
+		for _, sp := range testSafePrimeList(0) {
+			select {
+			case ec2.SafePrimeCh <- sp:
+			default:
+				return
+			}
+		}
+	})
+}
+
+// testSafePrimeList get the 4 test safe primes of the party i
+func testSafePrimeList(i int) []ec2.SafePrime {
+	sps := make([]ec2.SafePrime, 4)
+	for k, v := range testSafePrimes[4*i : 4*i+4] {
+		p, _ := new(big.Int).SetString(v, 16)
+		sps[k].SetQ(new(big.Int).Rsh(p, 1))
+		sps[k].SetP(p)
+	}
+
+	return sps
+}
+
+var (
+	testPreOnce [TestParties]sync.Once
+	testPre     [TestParties][]byte
+	testPreErr  [TestParties]error
+)
+
+// TestPreParams create the pre params of paillier key length 2048 of the party i (uid i+1) from its fixed test safe primes.
+// The ntilde proofs and the modulus proofs are made once for every party,every call returns a new copy.
+// It must not be used outside of tests.
+func TestPreParams(i int) (*ec2.PreParams, error) {
+	if i < 0 || i >= TestParties {
+		return nil, fmt.Errorf("no test pre params for party %v", i)
+	}
+
+	testPreOnce[i].Do(func() {
+		var pre *ec2.PreParams
+		pre, testPreErr[i] = ec2.NewPreParams(ec2.DefaultPaillierKeyLength, testSafePrimeList(i))
+		if testPreErr[i] == nil {
+			testPre[i], testPreErr[i] = json.Marshal(pre)
+		}
+	})
+
+	if testPreErr[i] != nil {
+		return nil, testPreErr[i]
+	}
+
+	pre := &ec2.PreParams{}
+	if err := json.Unmarshal(testPre[i], pre); err != nil {
+		return nil, err
+	}
+
+	return pre, nil
+}
+
+// takeTestPreParams the pre params pool of the simulated sessions,the party with uid i+1 takes a copy of TestPreParams(i)
+func takeTestPreParams(id string, length int) *ec2.PreParams {
+	if length != ec2.DefaultPaillierKeyLength {
+		return nil
+	}
+
+	uid := smpc.GetUIDByDNodeID(id)
+	if uid == nil || uid.Sign() <= 0 || uid.Cmp(big.NewInt(TestParties)) > 0 {
+		return nil
+	}
+
+	pre, err := TestPreParams(int(uid.Int64()) - 1)
+	if err != nil {
+		return nil
+	}
+
+	return pre
+}
+
+// usePreParams register the pool that keygen/reshare/import key take the paillier key and ntilde from,
+// it is cfg.PreParams if set,otherwise TestPreParams of every party.
+// The verify cache of ec2 is enabled too,so the modulus proofs of the fixed pre params are checked once in the process.
+// The pool is global,so the simulated sessions must not run in parallel with a real one.
+func usePreParams(cfg *Config) {
+	LoadTestSafePrimes()
+	ec2.EnableVerifyCache(true)
+
+	if cfg != nil && cfg.PreParams != nil {
+		ec2.RegPreParamsCallBack(cfg.PreParams)
+		return
+	}
+
+	ec2.RegPreParamsCallBack(takeTestPreParams)
+}
@@ -0,0 +1,668 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package simulate_test run keygen, presign, sign and reshare in one process
+package simulate_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/importkey"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/presign"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/recovery"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	kgOnce  sync.Once
+	kgSaves []*keygen.LocalDNodeSaveData
+	kgErr   error
+	kgTaken int
+	kgLock  sync.Mutex
+)
+
+// getSaves run 2/3 keygen once and share the result between tests,
+// the party with uid i+1 takes TestPreParams(i) from the pool and the takes are counted
+func getSaves() ([]*keygen.LocalDNodeSaveData, error) {
+	kgOnce.Do(func() {
+		cfg := &simulate.Config{PreParams: func(id string, length int) *ec2.PreParams {
+			kgLock.Lock()
+			defer kgLock.Unlock()
+			kgTaken++
+			uid := smpc.GetUIDByDNodeID(id)
+			if uid == nil {
+				return nil
+			}
+			pre, err := simulate.TestPreParams(int(uid.Int64()) - 1)
+			if err != nil {
+				return nil
+			}
+			return pre
+		}}
+		kgSaves, kgErr = simulate.ECKeyGen(3, 2, "EC256K1", cfg)
+	})
+	return kgSaves, kgErr
+}
+
+func TestECKeyGenSign(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, sd := range saves {
+		assert.Equal(t, 0, sd.Pkx.Cmp(saves[0].Pkx), "pubkey")
+		assert.Equal(t, 0, sd.Pky.Cmp(saves[0].Pky), "pubkey")
+	}
+	checkPubShares(t, saves, 2)
+
+	signers := []int{0, 2}
+	pres, err := simulate.ECPreSign(saves, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("simulate"))
+	r, s, err := simulate.ECSign(saves, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+
+	hash2 := sha256.Sum256([]byte("other"))
+	assert.False(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash2[:], r, s), "verify")
+}
+
+// tamperShare corrupt the vss share sent by party `from` to party `to`, to < 0 means to all parties.
+// If reveal is true,the share revealed in the justification is corrupted too,so the dealer is faulty.
+func tamperShare(from int, to int, reveal bool) simulate.TamperFunc {
+	return func(f int, tt int, msg smpc.Message) smpc.Message {
+		if f != from {
+			return msg
+		}
+
+		switch m := msg.(type) {
+		case *keygen.KGRound2Message:
+			if to >= 0 && tt != to {
+				return msg
+			}
+
+			bad := *m
+			bad.Share = new(big.Int).Add(m.Share, big.NewInt(1))
+			return &bad
+		case *keygen.KGRound3Message3:
+			if !reveal {
+				return msg
+			}
+
+			bad := *m
+			bad.Share = make([]*big.Int, len(m.Share))
+			for i, v := range m.Share {
+				bad.Share[i] = new(big.Int).Add(v, big.NewInt(1))
+			}
+			return &bad
+		}
+
+		return msg
+	}
+}
+
+// hasBlame weather the blames contain the party with uid
+func hasBlame(blames []*smpc.Blame, uid int64, proof string) bool {
+	for _, b := range blames {
+		if b.Culprit == smpc.GetDNodeIDByUID(big.NewInt(uid)) && b.Proof == proof {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPubShares check every party saved the same public shares,its own one is sku1*G and they lie on the polynomial of the pubkey
+func checkPubShares(t *testing.T, saves []*keygen.LocalDNodeSaveData, threshold int) {
+	curve := ec2.GetCurve("EC256K1")
+	for _, sd := range saves {
+		if sd == nil || !assert.Equal(t, len(sd.IDs), len(sd.PubShares), "public shares") {
+			continue
+		}
+
+		assert.True(t, ec2.VerifyPubShares(curve, sd.IDs, sd.PubShares, threshold, sd.Pkx, sd.Pky), "verify public shares")
+		for k, id := range sd.IDs {
+			assert.Equal(t, 0, sd.PubShares[k][0].Cmp(saves[0].PubShares[k][0]), "same public shares")
+			if id.Cmp(sd.CurDNodeID) == 0 {
+				x, y := curve.ScalarBaseMult(sd.SkU1.Bytes())
+				assert.True(t, x.Cmp(sd.PubShares[k][0]) == 0 && y.Cmp(sd.PubShares[k][1]) == 0, "own public share")
+			}
+		}
+	}
+}
+
+func TestECKeyGenTamper(t *testing.T) {
+	// the dealer reveals the valid shares,so the accusers made false complaints and take the revealed shares
+	cfg := &simulate.Config{Tamper: tamperShare(1, -1, false)}
+	saves, err := simulate.ECKeyGen(3, 2, "EC256K1", cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, sd := range saves {
+		assert.Equal(t, 0, sd.Pkx.Cmp(saves[0].Pkx), "pubkey")
+		assert.Equal(t, 0, sd.Pky.Cmp(saves[0].Pky), "pubkey")
+		assert.Equal(t, 2, len(sd.Faulty), "faulty")
+		assert.True(t, hasBlame(sd.Faulty, 1, "FalseComplaint"), "accuser 1")
+		assert.True(t, hasBlame(sd.Faulty, 3, "FalseComplaint"), "accuser 3")
+	}
+}
+
+func TestECKeyGenComplaintDealer(t *testing.T) {
+	// the dealer can not justify the share,the honest parties complete the keygen without it
+	cfg := &simulate.Config{Tamper: tamperShare(1, 0, true)}
+	saves, err := simulate.ECKeyGen(3, 2, "EC256K1", cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, k := range []int{0, 2} {
+		assert.Equal(t, 0, saves[k].Pkx.Cmp(saves[0].Pkx), "pubkey")
+		assert.Equal(t, 0, saves[k].Pky.Cmp(saves[0].Pky), "pubkey")
+		assert.Equal(t, 1, len(saves[k].Faulty), "faulty")
+		assert.True(t, hasBlame(saves[k].Faulty, 2, "VssShare"), "dealer 2")
+	}
+	checkPubShares(t, []*keygen.LocalDNodeSaveData{saves[0], saves[2]}, 2)
+
+	signers := []int{0, 2}
+	pres, err := simulate.ECPreSign(saves, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("complaint"))
+	r, s, err := simulate.ECSign(saves, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+}
+
+func TestECKeyGenComplaintSilentDealer(t *testing.T) {
+	// the accused dealer never reveals the shares,the honest parties time out and complete the keygen without it
+	bad := tamperShare(1, 0, false)
+	tamper := func(from int, to int, msg smpc.Message) smpc.Message {
+		if _, ok := msg.(*keygen.KGRound3Message3); ok && from == 1 {
+			return nil
+		}
+		return bad(from, to, msg)
+	}
+
+	saves, err := simulate.ECKeyGen(3, 2, "EC256K1", &simulate.Config{Tamper: tamper})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, k := range []int{0, 2} {
+		assert.Equal(t, 0, saves[k].Pkx.Cmp(saves[0].Pkx), "pubkey")
+		assert.Equal(t, 0, saves[k].Pky.Cmp(saves[0].Pky), "pubkey")
+		assert.Equal(t, 1, len(saves[k].Faulty), "faulty")
+		assert.True(t, hasBlame(saves[k].Faulty, 2, "VssShare"), "dealer 2")
+	}
+}
+
+func TestECKeyGenComplaintAbort(t *testing.T) {
+	// 3/3 keygen can not complete without the faulty dealer,it aborts naming the dealer
+	cfg := &simulate.Config{Tamper: tamperShare(1, 0, true)}
+	_, err := simulate.ECKeyGen(3, 3, "EC256K1", cfg)
+	if !assert.Error(t, err, "keygen must abort") {
+		return
+	}
+
+	assert.Contains(t, err.Error(), smpc.GetDNodeIDByUID(big.NewInt(2))+"(round 4,VssShare)")
+}
+
+func TestECKeyGenTamperPaillierProof(t *testing.T) {
+	cfg := &simulate.Config{
+		Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+			m, ok := msg.(*keygen.KGRound5Message3)
+			if !ok || from != 1 {
+				return msg
+			}
+
+			pf := *m.ModPf
+			pf.X = append([]*big.Int{}, m.ModPf.X...)
+			pf.X[0] = new(big.Int).Add(pf.X[0], big.NewInt(1))
+
+			bad := *m
+			bad.ModPf = &pf
+			return &bad
+		},
+	}
+
+	_, err := simulate.ECKeyGen(3, 2, "EC256K1", cfg)
+	assert.Error(t, err, "tampered paillier-blum modulus proof must be rejected")
+}
+
+func TestECKeyGenDrop(t *testing.T) {
+	cfg := &simulate.Config{Drop: []int{1}}
+	_, err := simulate.ECKeyGen(3, 2, "EC256K1", cfg)
+	assert.Error(t, err, "keygen can not finish without all parties")
+}
+
+func TestECPreSignDrop(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := &simulate.Config{Drop: []int{0}}
+	_, err = simulate.ECPreSign(saves, []int{1, 2}, "EC256K1", cfg)
+	assert.Error(t, err, "presign can not finish without all signers")
+}
+
+func TestECPaillierKeyLength(t *testing.T) {
+	_, err := simulate.ECKeyGen(3, 2, "EC256K1", &simulate.Config{PaillierKeyLength: 1024})
+	assert.Error(t, err, "paillier key length less than the min length must be rejected")
+
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = simulate.ECPreSign(saves, []int{0, 1}, "EC256K1", &simulate.Config{PaillierKeyLength: 3072})
+	assert.Error(t, err, "presign must use the paillier key length stored with the key")
+}
+
+func TestECKeyGenPreParams(t *testing.T) {
+	pres := make([]*ec2.PreParams, 3)
+	for k := range pres {
+		pre, err := simulate.TestPreParams(k)
+		if !assert.NoError(t, err) {
+			return
+		}
+		pres[k] = pre
+	}
+
+	// every party has its own paillier key and ntilde
+	for k := range pres {
+		for j := 0; j < k; j++ {
+			assert.NotEqual(t, 0, pres[k].PaillierSk.N.Cmp(pres[j].PaillierSk.N), "paillier N")
+			assert.NotEqual(t, 0, pres[k].NtildeH1H2.Ntilde.Cmp(pres[j].NtildeH1H2.Ntilde), "ntilde")
+		}
+	}
+
+	pre := pres[0]
+
+	b, err := json.Marshal(pre)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pre2 := &ec2.PreParams{}
+	if !assert.NoError(t, json.Unmarshal(b, pre2)) {
+		return
+	}
+	assert.NoError(t, pre2.Validate(ec2.DefaultPaillierKeyLength))
+	assert.Error(t, pre2.Validate(3072), "length mismatch")
+
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	kgLock.Lock()
+	taken := kgTaken
+	kgLock.Unlock()
+	assert.Equal(t, 3, taken, "every party takes its paillier key and ntilde from the pool")
+	for i, sd := range saves {
+		assert.Equal(t, 0, sd.U1PaillierSk.N.Cmp(pres[i].PaillierSk.N), "paillier N")
+		assert.Equal(t, 0, sd.U1NtildePrivData.Alpha.Cmp(pres[i].NtildePriv.Alpha), "ntilde")
+
+		// the keys of the peers are saved at their indexes
+		for k := range sd.IDs {
+			assert.Equal(t, 0, sd.U1PaillierPk[k].N.Cmp(pres[k].PaillierSk.N), "paillier N of party %v", k)
+			assert.Equal(t, 0, sd.U1NtildeH1H2[k].Ntilde.Cmp(pres[k].NtildeH1H2.Ntilde), "ntilde of party %v", k)
+		}
+	}
+}
+
+func TestECPreSignCGGMP(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	signers := []int{0, 1}
+	pres, err := simulate.ECPreSignCGGMP(saves, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("cggmp"))
+	r, s, err := simulate.ECSign(saves, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+}
+
+func TestECPreSignCGGMPTamper(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	one := big.NewInt(1)
+	// every corrupted message of the party 1 of the signers {0,2} (the save 2,uid 3) aborts the presign and blames it
+	tests := []struct {
+		name   string
+		proof  string
+		tamper func(msg smpc.Message) smpc.Message
+	}{
+		{"round 1 enc proof", "EncRangeProof", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound1Message1)
+			if !ok {
+				return msg
+			}
+
+			pf := *m.EncPf
+			pf.Z1 = new(big.Int).Add(pf.Z1, one)
+			bad := *m
+			bad.EncPf = &pf
+			return &bad
+		}},
+		{"round 2 affine ciphertext", "AffGProof", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound2Message)
+			if !ok {
+				return msg
+			}
+
+			bad := *m
+			bad.D = new(big.Int).Add(m.D, one)
+			return &bad
+		}},
+		{"round 2 affine proof of w", "AffGProof", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound2Message)
+			if !ok {
+				return msg
+			}
+
+			pf := *m.AffgHatPf
+			pf.Z2 = new(big.Int).Add(pf.Z2, one)
+			bad := *m
+			bad.AffgHatPf = &pf
+			return &bad
+		}},
+		{"round 2 log proof of gamma", "LogStarProof", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound2Message)
+			if !ok {
+				return msg
+			}
+
+			pf := *m.LogStarPf
+			pf.Z1 = new(big.Int).Add(pf.Z1, one)
+			bad := *m
+			bad.LogStarPf = &pf
+			return &bad
+		}},
+		{"round 2 Gamma not on curve", "Gamma", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound2Message)
+			if !ok {
+				return msg
+			}
+
+			bad := *m
+			bad.GammaX = new(big.Int).Add(m.GammaX, one)
+			return &bad
+		}},
+		{"round 3 Delta", "LogStarProof", func(msg smpc.Message) smpc.Message {
+			m, ok := msg.(*presign.PreSignRound3Message)
+			if !ok {
+				return msg
+			}
+
+			bad := *m
+			bad.BigDeltaX, bad.BigDeltaY = ec2.GetCurve("EC256K1").Double(m.BigDeltaX, m.BigDeltaY)
+			return &bad
+		}},
+	}
+
+	for _, tt := range tests {
+		tamper := tt.tamper
+		cfg := &simulate.Config{
+			Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+				if from != 1 {
+					return msg
+				}
+
+				return tamper(msg)
+			},
+		}
+
+		_, err = simulate.ECPreSignCGGMP(saves, []int{0, 2}, "EC256K1", cfg)
+		if !assert.Error(t, err, tt.name) {
+			continue
+		}
+
+		assert.True(t, hasBlame(smpc.GetBlames(err), 3, tt.proof), "%v: %v", tt.name, err)
+	}
+}
+
+func TestECReshare(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	news, err := simulate.ECReshare(saves, []int{0, 1}, 3, 2, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, sd := range news {
+		assert.Equal(t, 0, sd.Pkx.Cmp(saves[0].Pkx), "pubkey")
+	}
+	checkPubShares(t, news, 2)
+
+	signers := []int{1, 2}
+	pres, err := simulate.ECPreSign(news, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("reshare"))
+	r, s, err := simulate.ECSign(news, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+}
+
+func TestECRecoverShare(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	news, err := simulate.ECRecoverShare(saves, 1, 2, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for k, sd := range news {
+		assert.Equal(t, 0, sd.SkU1.Cmp(saves[k].SkU1), "sku1")
+	}
+	checkPubShares(t, news, 2)
+
+	signers := []int{1, 2}
+	pres, err := simulate.ECPreSign(news, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("recover share"))
+	r, s, err := simulate.ECSign(news, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+}
+
+func TestECRefresh(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	news, err := simulate.ECRefresh(saves, 2, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the public shares follow the new sku1
+	for k, sd := range news {
+		assert.Equal(t, 0, sd.Pkx.Cmp(saves[0].Pkx), "pubkey")
+		assert.NotEqual(t, 0, sd.SkU1.Cmp(saves[k].SkU1), "new sku1")
+	}
+	checkPubShares(t, news, 2)
+
+	signers := []int{0, 1}
+	pres, err := simulate.ECPreSign(news, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("refresh"))
+	r, s, err := simulate.ECSign(news, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", saves[0].Pkx, saves[0].Pky, hash[:], r, s), "verify")
+}
+
+func TestECRecoverShareTooFewHolders(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = simulate.ECRecoverShare(saves, 1, 3, "EC256K1", nil)
+	assert.Error(t, err)
+}
+
+func TestECRecoverShareTamper(t *testing.T) {
+	saves, err := getSaves()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// a holder lies about its xi*G
+	cfg := &simulate.Config{Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+		if m, ok := msg.(*recovery.RecRound1Message); ok && from == 0 {
+			c := *m
+			c.XiG = []*big.Int{saves[2].Pkx, saves[2].Pky}
+			return &c
+		}
+		return msg
+	}}
+	_, err = simulate.ECRecoverShare(saves, 1, 2, "EC256K1", cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ZkXiProof")
+	}
+
+	// a holder sends a wrong sum to the node to recover
+	cfg = &simulate.Config{Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+		if m, ok := msg.(*recovery.RecRound3Message); ok && from == 2 {
+			c := *m
+			c.Sum = new(big.Int).Add(m.Sum, big.NewInt(1))
+			return &c
+		}
+		return msg
+	}}
+	_, err = simulate.ECRecoverShare(saves, 1, 2, "EC256K1", cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "verify recovered sku1 fail")
+	}
+}
+
+func TestECImportKey(t *testing.T) {
+	sk := big.NewInt(0)
+	sk.SetString("8a1f5e4c2b0d97a3e6f14c58b2d0e9a7c3f51b6d8e2a4c09f7b1d3e5a6c8f012", 16)
+	saves, err := simulate.ECImportKey(sk, 3, 2, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the address of the imported key does not change,the paillier key and ntilde are taken from the pre params of every party
+	pkx, pky := ec2.GetCurve("EC256K1").ScalarBaseMult(sk.Bytes())
+	for i, sd := range saves {
+		pre, err := simulate.TestPreParams(i)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, 0, sd.Pkx.Cmp(pkx), "pubkey")
+		assert.Equal(t, 0, sd.Pky.Cmp(pky), "pubkey")
+		assert.Equal(t, 3, len(sd.U1PaillierPk), "paillier pubkey")
+		assert.Equal(t, 3, len(sd.U1NtildeH1H2), "ntilde")
+		assert.Equal(t, 0, sd.U1PaillierSk.N.Cmp(pre.PaillierSk.N), "paillier key from pre params")
+		assert.Equal(t, 0, sd.U1NtildePrivData.Q1.Cmp(pre.NtildePriv.Q1), "ntilde from pre params")
+	}
+	checkPubShares(t, saves, 2)
+
+	signers := []int{0, 2}
+	pres, err := simulate.ECPreSign(saves, signers, "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte("import key"))
+	r, s, err := simulate.ECSign(saves, signers, pres, hash[:], "EC256K1", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, simulate.ECVerify("EC256K1", pkx, pky, hash[:], r, s), "verify")
+}
+
+func TestECImportKeyTamper(t *testing.T) {
+	sk := big.NewInt(0)
+	sk.SetString("5c7e21a9d4b38f06e1a2c4d6b8f0e3a5c7d9b1f2a4c6e8d0b3f5a7c9e1d2b4f6", 16)
+
+	// a member proves the knowledge of a share that is not dealt to it
+	cfg := &simulate.Config{Tamper: func(from int, to int, msg smpc.Message) smpc.Message {
+		if m, ok := msg.(*importkey.ImpRound2Message); ok && from == 1 {
+			c := *m
+			c.U1zkXiProof = ec2.ZkXiProve(ec2.GetCurve("EC256K1"), ec2.NewProofContext("", big.NewInt(2), 2), big.NewInt(7))
+			return &c
+		}
+		return msg
+	}}
+	_, err := simulate.ECImportKey(sk, 3, 2, "EC256K1", cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ZkXiProof")
+	}
+}
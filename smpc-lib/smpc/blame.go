@@ -0,0 +1,90 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Blame the party that failed a check in some round of the MPC calculation
+type Blame struct {
+	Culprit string // DNodeID of the misbehaving node
+	Round   int
+	Proof   string // the name of the failed proof or check
+}
+
+// BlameError the error returned by a round when some parties' data fail to verify,it carries the blames of the culprits
+type BlameError struct {
+	Blames []*Blame
+	Err    error
+}
+
+// Error get the error info
+func (e *BlameError) Error() string {
+	culprits := make([]string, 0)
+	for _, b := range e.Blames {
+		culprits = append(culprits, fmt.Sprintf("%v(round %v,%v)", b.Culprit, b.Round, b.Proof))
+	}
+
+	return fmt.Sprintf("%v,culprits: %v", e.Err, strings.Join(culprits, ","))
+}
+
+// NewBlameError new a BlameError that blames one party
+func NewBlameError(culprit string, round int, proof string, err error) *BlameError {
+	return &BlameError{
+		Blames: []*Blame{&Blame{Culprit: culprit, Round: round, Proof: proof}},
+		Err:    err,
+	}
+}
+
+// GetBlames get the blames from the error returned by the dnode or from an error wrapping it,nil if the error does not blame anyone
+func GetBlames(err error) []*Blame {
+	var be *BlameError
+	if !errors.As(err, &be) {
+		return nil
+	}
+
+	return be.Blames
+}
+
+// GetDNodeIDByUID uid --> Sprintf(uid) --> []byte( Sprintf(uid) ) --> EncodeToString
+func GetDNodeIDByUID(uid *big.Int) string {
+	if uid == nil {
+		return ""
+	}
+
+	return hex.EncodeToString([]byte(fmt.Sprintf("%v", uid)))
+}
+
+// GetUIDByDNodeID EncodeToString --> []byte --> uid,nil if id is not a dnode id
+func GetUIDByDNodeID(id string) *big.Int {
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return nil
+	}
+
+	uid, ok := new(big.Int).SetString(string(b), 10)
+	if !ok {
+		return nil
+	}
+
+	return uid
+}
@@ -0,0 +1,43 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBlames(t *testing.T) {
+	uid := big.NewInt(12345)
+	id := smpc.GetDNodeIDByUID(uid)
+	assert.Equal(t, "3132333435", id)
+
+	var err error = smpc.NewBlameError(id, 4, "MtARangeProof", errors.New("verify mta range proof fail"))
+	blames := smpc.GetBlames(err)
+	assert.Equal(t, 1, len(blames))
+	assert.Equal(t, id, blames[0].Culprit)
+	assert.Equal(t, 4, blames[0].Round)
+	assert.Equal(t, "MtARangeProof", blames[0].Proof)
+
+	assert.Nil(t, smpc.GetBlames(nil))
+	assert.Nil(t, smpc.GetBlames(fmt.Errorf("verify fail")))
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // DNode base interface of local dnode
@@ -31,6 +32,7 @@ type DNode interface {
 	StoreMessage(msg Message) (bool, error)
 	DulMessage(msg Message) bool
 	SetDNodeID(id string)
+	SetSessionKey(key string)
 	Finalize() bool
 	FinalizeRound() Round
 
@@ -50,6 +52,12 @@ type BaseDNode struct {
 	DNodeCountInGroup int
 	ThresHold         int
 	PaillierKeyLength int
+	SessionKey        string // the key of the session (msgprex),the challenges of the zk proofs are bound to it
+}
+
+// SetSessionKey set the key of the session that the dnode runs in
+func (p *BaseDNode) SetSessionKey(key string) {
+	p.SessionKey = key
 }
 
 // -----
@@ -126,7 +134,7 @@ func BaseUpdate(p DNode, msg Message) (ok bool, err error) {
 		if p.Round().CanProceed() {
 			if p.advance(); p.Round() != nil {
 				if err := p.Round().Start(); err != nil {
-					fmt.Printf("==========================BaseUpdate,round start fail,msg,err = %v=====================\n",msg,err)
+					fmt.Printf("==========================BaseUpdate,round start fail,msg = %v,err = %v=====================\n",msg,err)
 					p.unlock() // recursive so can't defer after return
 					return false, err
 				}
@@ -157,3 +165,40 @@ func BaseUpdate(p DNode, msg Message) (ok bool, err error) {
 	return true, nil
 }
 
+// TimeoutRound a round that can go on without the messages that have not arrived
+type TimeoutRound interface {
+	// Timeout give up waiting for the missing messages if the round started at least wait ago,
+	// false if the round is not waiting for any message or it has not waited long enough
+	Timeout(wait time.Duration) bool
+}
+
+// BaseTimeout let the current round go on without the missing messages if it is a TimeoutRound that started at least wait ago,
+// then run the next rounds as far as the stored messages allow.
+// It returns weather the current round gave up waiting.
+func BaseTimeout(p DNode, wait time.Duration) (bool, error) {
+	p.lock()
+	defer p.unlock()
+
+	tr, ok := p.Round().(TimeoutRound)
+	if !ok || !tr.Timeout(wait) {
+		return false, nil
+	}
+
+	for p.Round() != nil {
+		if _, err := p.Round().Update(); err != nil {
+			return true, err
+		}
+
+		if !p.Round().CanProceed() {
+			break
+		}
+
+		if p.advance(); p.Round() != nil {
+			if err := p.Round().Start(); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	return true, nil
+}
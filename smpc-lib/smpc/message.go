@@ -25,6 +25,5 @@ type Message interface {
 	GetFromIndex() int
 	GetToID() []string
 	IsBroadcast() bool
-	OutMap() map[string]string
 	GetMsgType() string
 }
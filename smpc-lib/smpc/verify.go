@@ -0,0 +1,75 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxVerifyWorkers the max count of goroutines verifying the data of different parties in one round,default is the count of CPUs
+var MaxVerifyWorkers = runtime.NumCPU()
+
+// VerifyParallel run verify(k) for k = 0,1,...,n-1 concurrently by at most MaxVerifyWorkers goroutines.
+// The indexes are taken in ascending order and no new index is taken after a failure,
+// so the returned error is the one of the smallest failed k,the same as verifying them one by one.
+func VerifyParallel(n int, verify func(k int) error) error {
+	if n <= 0 {
+		return nil
+	}
+
+	workers := MaxVerifyWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	errs := make([]error, n)
+	next := int32(-1)
+	failed := int32(0)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				k := int(atomic.AddInt32(&next, 1))
+				if k >= n {
+					return
+				}
+
+				if err := verify(k); err != nil {
+					errs[k] = err
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
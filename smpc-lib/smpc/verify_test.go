@@ -0,0 +1,55 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyParallel(t *testing.T) {
+	var count int32
+	err := smpc.VerifyParallel(16, func(k int) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(16), count)
+
+	assert.NoError(t, smpc.VerifyParallel(0, func(k int) error { return errors.New("never called") }))
+}
+
+func TestVerifyParallelLowestError(t *testing.T) {
+	old := smpc.MaxVerifyWorkers
+	defer func() { smpc.MaxVerifyWorkers = old }()
+
+	for _, workers := range []int{1, 2, 4, 16} {
+		smpc.MaxVerifyWorkers = workers
+		err := smpc.VerifyParallel(10, func(k int) error {
+			if k == 3 || k == 7 {
+				return fmt.Errorf("party %v fail", k)
+			}
+			return nil
+		})
+		assert.EqualError(t, err, "party 3 fail")
+	}
+}
@@ -0,0 +1,468 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/p2p/rlp"
+)
+
+// WireVersion the version of the binary encoding of the p2p messages
+//
+// A message is encoded as the rlp list [version, name, body]:
+// name is "<package>.<type>" such as "ecdsa/keygen.KGRound1Message",
+// body is the rlp list of the exported fields of the message in declaration order:
+// *big.Int is its sign byte (0 or 1) followed by the big-endian absolute value and is never nil,
+// struct is the list of its exported fields, array is the list of its elements ([N]byte is a string),
+// nil pointer is the empty list and non-nil pointer is the list of its element,the pointer elements of slices and arrays are never nil,
+// nil slice is the empty string and non-nil slice is the list of its elements ([]byte is the opposite),
+// string is the string,bool is 0 or 1,signed int is encoded as *big.Int and unsigned int as big-endian bytes.
+// The nil/empty information is kept so that the decoded message is marshaled to exactly the same json that the p2p signature is made on.
+// A message with a nil *big.Int or a nil slice element is rejected by both the encoder and the decoder,
+// so the rounds can use every number and every element of a decoded message without checking it for nil.
+const WireVersion = 1
+
+// MinWireVersion the lowest version of the binary encoding that can still be encoded and decoded,
+// the nodes of a group run a session on the highest version that all of them support
+const MinWireVersion = 1
+
+var (
+	wireTypes    = make(map[string]reflect.Type)
+	wireTypeLock sync.RWMutex
+
+	bigIntType = reflect.TypeOf(big.Int{})
+
+	errWireFormat = errors.New("invalid wire message format")
+	errWireNil    = errors.New("nil big int or nil list element in wire message")
+)
+
+// RegisterMessage register the p2p message types of a protocol so that they can be decoded from the wire format.
+// msgs are pointers to the message structs,such as &KGRound1Message{}
+func RegisterMessage(msgs ...Message) {
+	wireTypeLock.Lock()
+	defer wireTypeLock.Unlock()
+
+	for _, msg := range msgs {
+		t := reflect.TypeOf(msg)
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			panic(fmt.Sprintf("register message %v: must be a pointer to struct", t))
+		}
+
+		name := wireName(t.Elem())
+		if _, ok := wireTypes[name]; ok {
+			panic(fmt.Sprintf("register message %v: duplicate", name))
+		}
+
+		wireTypes[name] = t.Elem()
+	}
+}
+
+// RegisteredMessages get the names of all registered p2p message types
+func RegisteredMessages() []string {
+	wireTypeLock.RLock()
+	defer wireTypeLock.RUnlock()
+
+	names := make([]string, 0, len(wireTypes))
+	for name := range wireTypes {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// NewRegisteredMessage create an empty message of the registered type name
+func NewRegisteredMessage(name string) (Message, error) {
+	wireTypeLock.RLock()
+	t, ok := wireTypes[name]
+	wireTypeLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown wire message type %v", name)
+	}
+
+	return reflect.New(t).Interface().(Message), nil
+}
+
+// wireName "ecdsa/keygen.KGRound1Message" for the type KGRound1Message in package smpc-lib/ecdsa/keygen
+func wireName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "smpc-lib/"); i >= 0 {
+		pkg = pkg[i+len("smpc-lib/"):]
+	}
+
+	return pkg + "." + t.Name()
+}
+
+// WireName get the wire type name of msg
+func WireName(msg Message) string {
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return wireName(t)
+}
+
+// SupportedWireVersions get all versions of the binary encoding supported by this node,in ascending order
+func SupportedWireVersions() []uint {
+	vers := make([]uint, 0, WireVersion-MinWireVersion+1)
+	for v := uint(MinWireVersion); v <= WireVersion; v++ {
+		vers = append(vers, v)
+	}
+
+	return vers
+}
+
+// IsSupportedWireVersion is version a supported version of the binary encoding
+func IsSupportedWireVersion(version uint) bool {
+	return version >= MinWireVersion && version <= WireVersion
+}
+
+// EncodeMessage encode msg to the binary wire format of the current version
+func EncodeMessage(msg Message) ([]byte, error) {
+	return EncodeMessageVersion(msg, WireVersion)
+}
+
+// EncodeMessageVersion encode msg to the binary wire format of version
+func EncodeMessageVersion(msg Message, version uint) ([]byte, error) {
+	if !IsSupportedWireVersion(version) {
+		return nil, fmt.Errorf("unsupported wire version %v", version)
+	}
+
+	v := reflect.ValueOf(msg)
+	if msg == nil || v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, errors.New("encode nil message")
+	}
+
+	name := wireName(v.Elem().Type())
+	wireTypeLock.RLock()
+	_, ok := wireTypes[name]
+	wireTypeLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown wire message type %v", name)
+	}
+
+	body, err := encodeWireValue(v.Elem())
+	if err != nil {
+		return nil, fmt.Errorf("encode %v fail: %v", name, err)
+	}
+
+	return rlp.EncodeToBytes([]interface{}{version, name, body})
+}
+
+// DecodeMessage decode the binary wire format to message
+func DecodeMessage(b []byte) (Message, error) {
+	var list []interface{}
+	if err := rlp.DecodeBytes(b, &list); err != nil {
+		return nil, err
+	}
+
+	if len(list) != 3 {
+		return nil, errWireFormat
+	}
+
+	ver, ok := list[0].([]byte)
+	if !ok || len(ver) == 0 || len(ver) > 4 || ver[0] == 0 {
+		return nil, errWireFormat
+	}
+
+	version := uint(new(big.Int).SetBytes(ver).Uint64())
+	if !IsSupportedWireVersion(version) {
+		return nil, fmt.Errorf("unsupported wire version %v", version)
+	}
+
+	name, ok := list[1].([]byte)
+	if !ok {
+		return nil, errWireFormat
+	}
+
+	msg, err := NewRegisteredMessage(string(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decodeWireValue(list[2], reflect.ValueOf(msg).Elem()); err != nil {
+		return nil, fmt.Errorf("decode %v fail: %v", string(name), err)
+	}
+
+	return msg, nil
+}
+
+//------------------------------------------------------------------------------
+
+func encodeBigInt(x *big.Int) []byte {
+	sign := byte(0)
+	if x.Sign() < 0 {
+		sign = 1
+	}
+
+	return append([]byte{sign}, x.Bytes()...)
+}
+
+func decodeBigInt(b []byte) (*big.Int, error) {
+	if len(b) == 0 || b[0] > 1 || (len(b) > 1 && b[1] == 0) {
+		return nil, errWireFormat
+	}
+
+	x := new(big.Int).SetBytes(b[1:])
+	if b[0] == 1 {
+		if x.Sign() == 0 {
+			return nil, errWireFormat
+		}
+		x.Neg(x)
+	}
+
+	return x, nil
+}
+
+func encodeWireValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem() == bigIntType {
+			if v.IsNil() {
+				return nil, errWireNil
+			}
+			return encodeBigInt(v.Interface().(*big.Int)), nil
+		}
+		if v.IsNil() {
+			return []interface{}{}, nil
+		}
+
+		e, err := encodeWireValue(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{e}, nil
+	case reflect.Struct:
+		list := make([]interface{}, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+
+			e, err := encodeWireValue(v.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", v.Type().Field(i).Name, err)
+			}
+			list = append(list, e)
+		}
+		return list, nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.IsNil() {
+				return []interface{}{}, nil
+			}
+			return v.Bytes(), nil
+		}
+		if v.IsNil() {
+			return []byte{}, nil
+		}
+		return encodeWireList(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return b, nil
+		}
+		return encodeWireList(v)
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeBigInt(big.NewInt(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()).Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func encodeWireList(v reflect.Value) (interface{}, error) {
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		if v.Index(i).Kind() == reflect.Ptr && v.Index(i).IsNil() {
+			return nil, errWireNil
+		}
+
+		e, err := encodeWireValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = e
+	}
+
+	return list, nil
+}
+
+func decodeWireValue(node interface{}, v reflect.Value) error {
+	b, isBytes := node.([]byte)
+	list, isList := node.([]interface{})
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem() == bigIntType {
+			if isList && len(list) == 0 {
+				return errWireNil
+			}
+			if !isBytes {
+				return errWireFormat
+			}
+			x, err := decodeBigInt(b)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(x))
+			return nil
+		}
+		if isList && len(list) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if !isList || len(list) != 1 {
+			return errWireFormat
+		}
+
+		e := reflect.New(v.Type().Elem())
+		if err := decodeWireValue(list[0], e.Elem()); err != nil {
+			return err
+		}
+		v.Set(e)
+		return nil
+	case reflect.Struct:
+		if !isList {
+			return errWireFormat
+		}
+
+		k := 0
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+
+			if k >= len(list) {
+				return errWireFormat
+			}
+			if err := decodeWireValue(list[k], v.Field(i)); err != nil {
+				return fmt.Errorf("%v: %v", v.Type().Field(i).Name, err)
+			}
+			k++
+		}
+		if k != len(list) {
+			return errWireFormat
+		}
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if isList && len(list) == 0 {
+				v.Set(reflect.Zero(v.Type()))
+				return nil
+			}
+			if !isBytes {
+				return errWireFormat
+			}
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		if isBytes && len(b) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if !isList {
+			return errWireFormat
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(list), len(list))
+		if err := decodeWireList(list, s); err != nil {
+			return err
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if !isBytes || len(b) != v.Len() {
+				return errWireFormat
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		if !isList || len(list) != v.Len() {
+			return errWireFormat
+		}
+		return decodeWireList(list, v)
+	case reflect.String:
+		if !isBytes {
+			return errWireFormat
+		}
+		v.SetString(string(b))
+		return nil
+	case reflect.Bool:
+		if !isBytes || len(b) != 1 || b[0] > 1 {
+			return errWireFormat
+		}
+		v.SetBool(b[0] == 1)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !isBytes {
+			return errWireFormat
+		}
+		x, err := decodeBigInt(b)
+		if err != nil {
+			return err
+		}
+		if !x.IsInt64() || v.OverflowInt(x.Int64()) {
+			return errWireFormat
+		}
+		v.SetInt(x.Int64())
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !isBytes || (len(b) > 0 && b[0] == 0) {
+			return errWireFormat
+		}
+		x := new(big.Int).SetBytes(b)
+		if !x.IsUint64() || v.OverflowUint(x.Uint64()) {
+			return errWireFormat
+		}
+		v.SetUint(x.Uint64())
+		return nil
+	}
+
+	return fmt.Errorf("unsupported type %v", v.Type())
+}
+
+// decodeWireList decode the elements of a slice or array,none of them can be a nil pointer
+func decodeWireList(list []interface{}, v reflect.Value) error {
+	for i := range list {
+		if err := decodeWireValue(list[i], v.Index(i)); err != nil {
+			return err
+		}
+		if v.Index(i).Kind() == reflect.Ptr && v.Index(i).IsNil() {
+			return errWireNil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,395 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/presign"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/refresh"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/reshare"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/reshare"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/schnorr/signing"
+	_ "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/sr25519/signing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/p2p/rlp"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// fillRandom fill v with random values,nil pointers and nil/empty slices are generated too when sparse is true,
+// but never a nil *big.Int or a nil list element since they can not be sent
+func fillRandom(t testing.TB, r *rand.Rand, v reflect.Value, sparse bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if sparse && v.Type().Elem() != bigIntType && r.Intn(4) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+
+		if v.Type().Elem() == bigIntType {
+			x := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(r.Intn(2048)+1)))
+			if r.Intn(4) == 0 {
+				x.Neg(x)
+			}
+			v.Set(reflect.ValueOf(x))
+			return
+		}
+
+		v.Set(reflect.New(v.Type().Elem()))
+		fillRandom(t, r, v.Elem(), sparse)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				fillRandom(t, r, v.Field(i), sparse)
+			}
+		}
+	case reflect.Slice:
+		if sparse && r.Intn(4) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+
+		n := r.Intn(4)
+		if sparse && r.Intn(4) == 0 {
+			n = 0
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			fillElem(t, r, v.Index(i), sparse)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillElem(t, r, v.Index(i), sparse)
+		}
+	case reflect.String:
+		b := make([]byte, r.Intn(64))
+		r.Read(b)
+		v.SetString(string(b))
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63() >> uint(r.Intn(63)) * int64(1-2*r.Intn(2)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(r.Uint64() >> uint(r.Intn(64)))
+	default:
+		t.Fatalf("unsupported kind %v", v.Kind())
+	}
+}
+
+// fillElem fill the list element v with random values,it is never a nil pointer
+func fillElem(t testing.TB, r *rand.Rand, v reflect.Value, sparse bool) {
+	fillRandom(t, r, v, sparse)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+		fillRandom(t, r, v.Elem(), sparse)
+	}
+}
+
+// wireEqual compare the decoded value with the original one,big.Int is compared by value since its internal slice may differ
+func wireEqual(a, b reflect.Value) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Type().Elem() == bigIntType {
+			return a.Interface().(*big.Int).Cmp(b.Interface().(*big.Int)) == 0
+		}
+		return wireEqual(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if a.Type().Field(i).PkgPath == "" && !wireEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.IsNil() != b.IsNil() {
+			return false
+		}
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !wireEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func TestWireRoundTrip(t *testing.T) {
+	names := smpc.RegisteredMessages()
+	assert.True(t, len(names) > 50, "all protocol messages are registered")
+
+	r := rand.New(rand.NewSource(1))
+	for _, name := range names {
+		for i := 0; i < 8; i++ {
+			msg, err := smpc.NewRegisteredMessage(name)
+			if !assert.NoError(t, err, name) {
+				return
+			}
+
+			// full and sparse ones in turn
+			fillRandom(t, r, reflect.ValueOf(msg).Elem(), i%2 == 0)
+			assert.Equal(t, name, smpc.WireName(msg))
+
+			b, err := smpc.EncodeMessage(msg)
+			if !assert.NoError(t, err, name) {
+				return
+			}
+
+			msg2, err := smpc.DecodeMessage(b)
+			if !assert.NoError(t, err, name) {
+				return
+			}
+
+			if !assert.True(t, wireEqual(reflect.ValueOf(msg), reflect.ValueOf(msg2)), "%v round trip", name) {
+				return
+			}
+
+			j1, err := json.Marshal(msg)
+			if !assert.NoError(t, err, name) {
+				return
+			}
+			j2, err := json.Marshal(msg2)
+			if !assert.NoError(t, err, name) {
+				return
+			}
+			assert.Equal(t, string(j1), string(j2), "%v json", name)
+
+			if i%2 == 1 {
+				assert.True(t, len(b) < len(j1), "%v wire size %v, json size %v", name, len(b), len(j1))
+			}
+		}
+	}
+}
+
+func TestWireDecodeFail(t *testing.T) {
+	msg, err := smpc.NewRegisteredMessage("ecdsa/keygen.KGRound0Message")
+	if !assert.NoError(t, err) {
+		return
+	}
+	fillRandom(t, rand.New(rand.NewSource(2)), reflect.ValueOf(msg).Elem(), false)
+
+	b, err := smpc.EncodeMessage(msg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var list []rlp.RawValue
+	if !assert.NoError(t, rlp.DecodeBytes(b, &list)) {
+		return
+	}
+
+	ver, _ := rlp.EncodeToBytes(uint(smpc.WireVersion + 1))
+	bad, _ := rlp.EncodeToBytes([]rlp.RawValue{ver, list[1], list[2]})
+	_, err = smpc.DecodeMessage(bad)
+	assert.Error(t, err, "unsupported version")
+
+	name, _ := rlp.EncodeToBytes("ecdsa/keygen.NoSuchMessage")
+	bad, _ = rlp.EncodeToBytes([]rlp.RawValue{list[0], name, list[2]})
+	_, err = smpc.DecodeMessage(bad)
+	assert.Error(t, err, "unknown type")
+
+	name, _ = rlp.EncodeToBytes("ecdsa/signing.SignRound1Message")
+	bad, _ = rlp.EncodeToBytes([]rlp.RawValue{list[0], name, list[2]})
+	_, err = smpc.DecodeMessage(bad)
+	assert.Error(t, err, "body of another type")
+
+	bad, _ = rlp.EncodeToBytes([]rlp.RawValue{list[0], list[1]})
+	_, err = smpc.DecodeMessage(bad)
+	assert.Error(t, err, "missing body")
+
+	_, err = smpc.DecodeMessage(b[:len(b)-1])
+	assert.Error(t, err, "truncated")
+
+	_, err = smpc.EncodeMessage(nil)
+	assert.Error(t, err)
+}
+
+// replaceWireField encode msg and replace the body field k (counted in exported fields) by field,
+// or its first element by field if elem is true
+func replaceWireField(t *testing.T, msg smpc.Message, k int, elem bool, field rlp.RawValue) []byte {
+	b, err := smpc.EncodeMessage(msg)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	var list, body []rlp.RawValue
+	if !assert.NoError(t, rlp.DecodeBytes(b, &list)) || !assert.NoError(t, rlp.DecodeBytes(list[2], &body)) {
+		return nil
+	}
+
+	if elem {
+		var elems []rlp.RawValue
+		if !assert.NoError(t, rlp.DecodeBytes(body[k], &elems)) {
+			return nil
+		}
+		elems[0] = field
+		body[k], _ = rlp.EncodeToBytes(elems)
+	} else {
+		body[k] = field
+	}
+
+	list[2], _ = rlp.EncodeToBytes(body)
+	bad, _ := rlp.EncodeToBytes(list)
+	return bad
+}
+
+func TestWireNil(t *testing.T) {
+	bigSliceType := reflect.TypeOf([]*big.Int{})
+	emptyList, _ := rlp.EncodeToBytes([]interface{}{})
+
+	r := rand.New(rand.NewSource(4))
+	checked := 0
+	for _, name := range smpc.RegisteredMessages() {
+		msg, err := smpc.NewRegisteredMessage(name)
+		if !assert.NoError(t, err, name) {
+			return
+		}
+		fillRandom(t, r, reflect.ValueOf(msg).Elem(), false)
+
+		v := reflect.ValueOf(msg).Elem()
+		k := 0
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+
+			field := name + "." + v.Type().Field(i).Name
+			switch {
+			case f.Type() == reflect.PtrTo(bigIntType):
+				// a nil number is neither sent nor accepted
+				bad := replaceWireField(t, msg, k, false, emptyList)
+				_, err = smpc.DecodeMessage(bad)
+				assert.Error(t, err, "decode nil %v", field)
+
+				old := f.Interface()
+				f.Set(reflect.Zero(f.Type()))
+				_, err = smpc.EncodeMessage(msg)
+				assert.Error(t, err, "encode nil %v", field)
+				f.Set(reflect.ValueOf(old))
+				checked++
+			case f.Type() == bigSliceType:
+				// so is a nil element of a list such as the commitment data D
+				if f.Len() == 0 {
+					f.Set(reflect.ValueOf([]*big.Int{big.NewInt(1)}))
+				}
+				bad := replaceWireField(t, msg, k, true, emptyList)
+				_, err = smpc.DecodeMessage(bad)
+				assert.Error(t, err, "decode nil element of %v", field)
+
+				old := f.Index(0).Interface()
+				f.Index(0).Set(reflect.Zero(f.Type().Elem()))
+				_, err = smpc.EncodeMessage(msg)
+				assert.Error(t, err, "encode nil element of %v", field)
+				f.Index(0).Set(reflect.ValueOf(old))
+				checked++
+			}
+			k++
+		}
+
+		// the message is still valid after the fields are restored
+		_, err = smpc.EncodeMessage(msg)
+		assert.NoError(t, err, name)
+	}
+
+	assert.True(t, checked > 50, "checked %v fields", checked)
+}
+
+// FuzzWireDecode every message that is decoded can be used without nil checks,so it is encoded again to the same bytes
+func FuzzWireDecode(f *testing.F) {
+	r := rand.New(rand.NewSource(5))
+	for _, name := range smpc.RegisteredMessages() {
+		msg, err := smpc.NewRegisteredMessage(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		fillRandom(f, r, reflect.ValueOf(msg).Elem(), true)
+
+		b, err := smpc.EncodeMessage(msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		msg, err := smpc.DecodeMessage(b)
+		if err != nil {
+			return
+		}
+
+		b2, err := smpc.EncodeMessage(msg)
+		if assert.NoError(t, err) {
+			assert.Equal(t, b, b2)
+		}
+	})
+}
+
+func TestWireVersion(t *testing.T) {
+	vers := smpc.SupportedWireVersions()
+	if !assert.NotEmpty(t, vers) {
+		return
+	}
+	assert.Equal(t, uint(smpc.MinWireVersion), vers[0])
+	assert.Equal(t, uint(smpc.WireVersion), vers[len(vers)-1])
+
+	msg, err := smpc.NewRegisteredMessage("ecdsa/keygen.KGRound0Message")
+	if !assert.NoError(t, err) {
+		return
+	}
+	fillRandom(t, rand.New(rand.NewSource(3)), reflect.ValueOf(msg).Elem(), false)
+
+	for _, v := range vers {
+		b, err := smpc.EncodeMessageVersion(msg, v)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		msg2, err := smpc.DecodeMessage(b)
+		if assert.NoError(t, err) {
+			assert.True(t, wireEqual(reflect.ValueOf(msg), reflect.ValueOf(msg2)))
+		}
+	}
+
+	_, err = smpc.EncodeMessageVersion(msg, smpc.MinWireVersion-1)
+	assert.Error(t, err)
+	_, err = smpc.EncodeMessageVersion(msg, smpc.WireVersion+1)
+	assert.Error(t, err)
+}
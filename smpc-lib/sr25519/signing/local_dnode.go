@@ -0,0 +1,217 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package signing MPC implementation of sr25519(schnorrkel) signing with the ED25519 keygen shares
+package signing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// LocalDNode current local node
+type LocalDNode struct {
+	*smpc.BaseDNode
+	temp    localTempData
+	save    *keygen.LocalDNodeSaveData
+	idsign  smpc.SortableIDSSlice
+	out     chan<- smpc.Message
+	end     chan<- SrSignData
+	context []byte
+	message []byte
+}
+
+// localTempData  Store some data of MPC calculation process
+type localTempData struct {
+	signRound1Messages,
+	signRound2Messages,
+	signRound3Messages []smpc.Message
+
+	// temp data (thrown away after sign)
+
+	//round 1
+	r  [32]byte
+	w  [32]byte
+	DR [64]byte
+	DW [64]byte
+
+	//round 3
+	rs [][32]byte // R_i
+	ws [][32]byte // W_i = w_i*B
+	R  [32]byte   // ristretto encoding of R
+	pk [32]byte   // ristretto encoding of pubkey
+	k  [32]byte   // the challenge
+}
+
+// NewLocalDNode new a DNode data struct for current node
+// context is the schnorrkel signing context,"substrate" for the substrate chains
+func NewLocalDNode(
+	out chan<- smpc.Message,
+	end chan<- SrSignData,
+	save *keygen.LocalDNodeSaveData,
+	idsign smpc.SortableIDSSlice,
+	kgid *big.Int,
+	threshold int,
+	context []byte,
+	message []byte,
+) smpc.DNode {
+
+	p := &LocalDNode{
+		BaseDNode: new(smpc.BaseDNode),
+		save:      save,
+		idsign:    idsign,
+		temp:      localTempData{},
+		out:       out,
+		end:       end,
+		context:   context,
+		message:   message,
+	}
+
+	p.ID = hex.EncodeToString([]byte(fmt.Sprintf("%v", kgid)))
+	p.ThresHold = threshold
+
+	p.temp.signRound1Messages = make([]smpc.Message, threshold)
+	p.temp.signRound2Messages = make([]smpc.Message, threshold)
+	p.temp.signRound3Messages = make([]smpc.Message, threshold)
+	return p
+}
+
+// FinalizeRound get finalize round
+func (p *LocalDNode) FinalizeRound() smpc.Round {
+	return nil
+}
+
+// FirstRound first round
+func (p *LocalDNode) FirstRound() smpc.Round {
+	return newRound1(&p.temp, p.save, p.idsign, p.out, p.end, p.ID, p.ThresHold, p.context, p.message)
+}
+
+// Start sr25519 signing start
+func (p *LocalDNode) Start() error {
+	if p.save == nil || p.save.CurDNodeID == nil || len(p.idsign) != p.ThresHold {
+		return errors.New("sr25519 sign save data error")
+	}
+
+	if p.message == nil {
+		return errors.New("sr25519 sign message error")
+	}
+
+	return smpc.BaseStart(p)
+}
+
+// Update Collect data from other nodes and enter the next round
+func (p *LocalDNode) Update(msg smpc.Message) (ok bool, err error) {
+	return smpc.BaseUpdate(p, msg)
+}
+
+// DNodeID get the ID of current DNode
+func (p *LocalDNode) DNodeID() string {
+	return p.ID
+}
+
+// SetDNodeID set the ID of current DNode
+// p.ID : enode --> DoubleHash --> index+1 --> Sprintf(index+1) --> []byte( Sprintf(index+1) ) --> EncodeToString
+func (p *LocalDNode) SetDNodeID(id string) {
+	p.ID = hex.EncodeToString([]byte(id))
+}
+
+// Finalize weather gg20 round
+func (p *LocalDNode) Finalize() bool {
+	return false
+}
+
+// CheckFull  Check for empty messages
+func CheckFull(msg []smpc.Message) bool {
+	if len(msg) == 0 {
+		return false
+	}
+
+	for _, v := range msg {
+		if v == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func find(l []smpc.Message, msg smpc.Message) bool {
+	if msg == nil || l == nil {
+		return true
+	}
+
+	for _, v := range l {
+		if v == nil {
+			continue
+		}
+
+		if v.GetMsgType() == msg.GetMsgType() && v.GetFromID() == msg.GetFromID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DulMessage check whether the msg already exists in the list.
+func (p *LocalDNode) DulMessage(msg smpc.Message) bool {
+	switch msg.(type) {
+	case *SignRound1Message:
+		return find(p.temp.signRound1Messages, msg)
+	case *SignRound2Message:
+		return find(p.temp.signRound2Messages, msg)
+	case *SignRound3Message:
+		return find(p.temp.signRound3Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return true
+	}
+}
+
+// storeMessage put msg to l and return true if l is full
+func storeMessage(l []smpc.Message, msg smpc.Message) (bool, error) {
+	if find(l, msg) {
+		return false, nil
+	}
+
+	index := msg.GetFromIndex()
+	if index < 0 || index >= len(l) {
+		return false, errors.New("msg index error")
+	}
+
+	l[index] = msg
+	return CheckFull(l), nil
+}
+
+// StoreMessage Collect data from other nodes
+func (p *LocalDNode) StoreMessage(msg smpc.Message) (bool, error) {
+	switch msg.(type) {
+	case *SignRound1Message:
+		return storeMessage(p.temp.signRound1Messages, msg)
+	case *SignRound2Message:
+		return storeMessage(p.temp.signRound2Messages, msg)
+	case *SignRound3Message:
+		return storeMessage(p.temp.signRound3Messages, msg)
+	default: // unrecognised message, just ignore!
+		fmt.Printf("storemessage,unrecognised message ignored: %v\n", msg)
+		return false, nil
+	}
+}
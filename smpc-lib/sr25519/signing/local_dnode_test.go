@@ -0,0 +1,155 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package signing_test test MPC implementation of sr25519 signing
+package signing_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/sr25519/signing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFull(t *testing.T) {
+	signSigniMessages := make([]smpc.Message, 0)
+	succ := signing.CheckFull(signSigniMessages)
+	assert.False(t, succ, "fail")
+
+	threshold := 3
+	for i := 0; i < threshold; i++ {
+		srm := &signing.SignRound1Message{
+			SignRoundMessage: new(signing.SignRoundMessage),
+		}
+		srm.SetFromID("62472382178168225119626719865491481459304781844424379027070392269894567214882")
+		srm.SetFromIndex(i)
+
+		signSigniMessages = append(signSigniMessages, srm)
+	}
+
+	succ = signing.CheckFull(signSigniMessages)
+	assert.True(t, succ, "success")
+}
+
+// TestRistretto ristretto255 encodings of the small multiples of the base point
+func TestRistretto(t *testing.T) {
+	vectors := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+		"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+		"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	}
+
+	for i, v := range vectors {
+		var sc, enc [32]byte
+		sc[0] = byte(i)
+		var P ed.ExtendedGroupElement
+		ed.GeScalarMultBase(&P, &sc)
+		ed.RistrettoEncode(&enc, &P)
+		assert.Equal(t, v, hex.EncodeToString(enc[:]))
+
+		var Q ed.ExtendedGroupElement
+		assert.True(t, ed.RistrettoDecode(&Q, &enc))
+		var enc2 [32]byte
+		ed.RistrettoEncode(&enc2, &Q)
+		assert.Equal(t, enc, enc2)
+	}
+
+	// non canonical encoding
+	var bad [32]byte
+	bad[0] = 1
+	var P ed.ExtendedGroupElement
+	assert.False(t, ed.RistrettoDecode(&P, &bad))
+}
+
+// TestTranscript merlin transcript test vector
+func TestTranscript(t *testing.T) {
+	tr := ed.NewTranscript("test protocol")
+	tr.AppendMessage([]byte("some label"), []byte("some data"))
+	var cb [32]byte
+	tr.ChallengeBytes([]byte("challenge"), cb[:])
+	assert.Equal(t, "d5a21972d0d5fe320c0d263fac7fffb8145aa640af6e9bca177c03c7efcf0615", hex.EncodeToString(cb[:]))
+}
+
+// TestSign 2/3 threshold sr25519 sign with the shares of a dealer
+func TestSign(t *testing.T) {
+	var x [32]byte
+	x[0], x[7], x[19] = 9, 77, 123
+
+	ids := smpc.SortableIDSSlice{big.NewInt(11), big.NewInt(22), big.NewInt(33)}
+	uids := make([][32]byte, len(ids))
+	for k, v := range ids {
+		copy(uids[k][:], v.Bytes())
+	}
+
+	_, _, shares, err := ed.Vss(x, uids, 2, 3)
+	assert.NoError(t, err)
+
+	var X ed.ExtendedGroupElement
+	var pk [32]byte
+	ed.GeScalarMultBase(&X, &x)
+	X.ToBytes(&pk)
+
+	message := []byte("sr25519 threshold sign test")
+	idsign := smpc.SortableIDSSlice{ids[0], ids[2]}
+	signers := []int{0, 2}
+
+	outs := make([]chan smpc.Message, len(signers))
+	ends := make([]chan signing.SrSignData, len(signers))
+	nodes := make([]smpc.DNode, len(signers))
+	for k, i := range signers {
+		save := &keygen.LocalDNodeSaveData{TSk: shares[i], FinalPkBytes: pk, IDs: ids, CurDNodeID: ids[i]}
+		outs[k] = make(chan smpc.Message, 10)
+		ends[k] = make(chan signing.SrSignData, 1)
+		nodes[k] = signing.NewLocalDNode(outs[k], ends[k], save, idsign, ids[i], 2, signing.SubstrateContext, message)
+		assert.NoError(t, nodes[k].Start())
+	}
+
+	for done := false; !done; {
+		done = true
+		for k := range nodes {
+			select {
+			case msg := <-outs[k]:
+				done = false
+				for j := range nodes {
+					if j != k {
+						_, err := nodes[j].Update(msg)
+						assert.NoError(t, err)
+					}
+				}
+			default:
+			}
+		}
+	}
+
+	for k := range nodes {
+		data := <-ends[k]
+		expect, err := signing.PubKeyFromEd(pk)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, data.Pk)
+
+		sig := data.Signature()
+		assert.True(t, signing.SrVerify(data.Pk, signing.SubstrateContext, message, sig[:]))
+		assert.False(t, signing.SrVerify(data.Pk, []byte("other"), message, sig[:]))
+		sig[63] &= 127
+		assert.False(t, signing.SrVerify(data.Pk, signing.SubstrateContext, message, sig[:]))
+	}
+}
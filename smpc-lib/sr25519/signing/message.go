@@ -0,0 +1,149 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func init() {
+	// register the p2p messages so that they can be decoded from the wire format
+	smpc.RegisterMessage(
+		&SignRound1Message{},
+		&SignRound2Message{},
+		&SignRound3Message{},
+	)
+}
+
+// SignRoundMessage base type of sign round message
+type SignRoundMessage struct {
+	FromID    string   `json:"FromID"` //DNodeID
+	FromIndex int      `json:"FromIndex"`
+	ToID      []string `json:"ToID"`
+}
+
+// SetFromID set sending nodes's ID
+func (srm *SignRoundMessage) SetFromID(id string) {
+	srm.FromID = id
+}
+
+// SetFromIndex set sending nodes's serial number in group
+func (srm *SignRoundMessage) SetFromIndex(index int) {
+	srm.FromIndex = index
+}
+
+// AppendToID get the ID of nodes that the message will broacast to
+func (srm *SignRoundMessage) AppendToID(toid string) {
+	srm.ToID = append(srm.ToID, toid)
+}
+
+// SignRound1Message  Round 1 sending message,the commitment of R_i and W_i
+type SignRound1Message struct {
+	*SignRoundMessage
+	CR [32]byte
+	CW [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (srm *SignRound1Message) GetFromID() string {
+	return srm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (srm *SignRound1Message) GetFromIndex() int {
+	return srm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (srm *SignRound1Message) GetToID() []string {
+	return srm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (srm *SignRound1Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (srm *SignRound1Message) GetMsgType() string {
+	return "SrSignRound1Message"
+}
+
+// SignRound2Message  Round 2 sending message,the decommitment and the zk proof of r_i and w_i
+type SignRound2Message struct {
+	*SignRoundMessage
+	DR  [64]byte
+	DW  [64]byte
+	ZkR [64]byte
+	ZkW [64]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (srm *SignRound2Message) GetFromID() string {
+	return srm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (srm *SignRound2Message) GetFromIndex() int {
+	return srm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (srm *SignRound2Message) GetToID() []string {
+	return srm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (srm *SignRound2Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (srm *SignRound2Message) GetMsgType() string {
+	return "SrSignRound2Message"
+}
+
+// SignRound3Message  Round 3 sending message,the partial signature s_i
+type SignRound3Message struct {
+	*SignRoundMessage
+	S [32]byte
+}
+
+// GetFromID get the ID of sending nodes in the group
+func (srm *SignRound3Message) GetFromID() string {
+	return srm.FromID
+}
+
+// GetFromIndex get the Serial number of sending nodes in the group
+func (srm *SignRound3Message) GetFromIndex() int {
+	return srm.FromIndex
+}
+
+// GetToID get the ID of the node that broacasting message to
+func (srm *SignRound3Message) GetToID() []string {
+	return srm.ToID
+}
+
+// IsBroadcast weather broacast the message
+func (srm *SignRound3Message) IsBroadcast() bool {
+	return true
+}
+
+// GetMsgType get msg type
+func (srm *SignRound3Message) GetMsgType() string {
+	return "SrSignRound3Message"
+}
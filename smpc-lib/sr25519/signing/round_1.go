@@ -0,0 +1,160 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+func newRound1(temp *localTempData, save *keygen.LocalDNodeSaveData, idsign smpc.SortableIDSSlice, out chan<- smpc.Message, end chan<- SrSignData, kgid string, threshold int, context []byte, message []byte) smpc.Round {
+	return &round1{
+		&base{temp, save, idsign, out, end, make([]bool, threshold), false, 0, kgid, threshold, context, message}}
+}
+
+// calcLambda calc the lagrange coefficient of cur in ids,the same as ed sign
+func calcLambda(ids smpc.SortableIDSSlice, cur *big.Int) ([32]byte, error) {
+	var lambda [32]byte
+	lambda[0] = 1
+	order := ed.GetBytesOrder()
+
+	var curByte [32]byte
+	copy(curByte[:], cur.Bytes())
+
+	for _, v := range ids {
+		if v.Cmp(cur) == 0 {
+			continue
+		}
+
+		var indexByte [32]byte
+		copy(indexByte[:], v.Bytes())
+
+		var times, zero [32]byte
+		ed.ScSub(&times, &indexByte, &curByte)
+		if times == zero {
+			return lambda, errors.New("calc lambda fail,same id")
+		}
+
+		times = ed.ScModInverse(times, order)
+		ed.ScMul(&times, &times, &indexByte)
+		ed.ScMul(&lambda, &lambda, &times)
+	}
+
+	return lambda, nil
+}
+
+// randScalar get a random scalar
+func randScalar() ([32]byte, error) {
+	var r [32]byte
+	var rTem [64]byte
+	if _, err := io.ReadFull(cryptorand.Reader, rTem[:]); err != nil {
+		return r, err
+	}
+
+	ed.ScReduce(&r, &rTem)
+	return r, nil
+}
+
+// Start calc w_i = lambda_i * tsk,choose nonce r_i and broadcast the commitment of R_i = r_i*B and W_i = w_i*B
+func (round *round1) Start() error {
+	if round.started {
+		fmt.Printf("============= sr25519 sign round1.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 1
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	lambda, err := calcLambda(round.idsign, round.idsign[curIndex])
+	if err != nil {
+		return err
+	}
+
+	var w [32]byte
+	ed.ScMul(&w, &lambda, &round.save.TSk)
+
+	r, err := randScalar()
+	if err != nil {
+		return err
+	}
+
+	var R, W ed.ExtendedGroupElement
+	var RBytes, WBytes [32]byte
+	ed.GeScalarMultBase(&R, &r)
+	ed.GeScalarMultBase(&W, &w)
+	R.ToBytes(&RBytes)
+	W.ToBytes(&WBytes)
+
+	CR, DR, err := ed.Commit(RBytes)
+	if err != nil {
+		return err
+	}
+
+	CW, DW, err := ed.Commit(WBytes)
+	if err != nil {
+		return err
+	}
+
+	round.temp.r = r
+	round.temp.w = w
+	round.temp.DR = DR
+	round.temp.DW = DW
+
+	srm := &SignRound1Message{
+		SignRoundMessage: new(SignRoundMessage),
+		CR:               CR,
+		CW:               CW,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound1Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round1) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound1Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round1) Update() (bool, error) {
+	return round.update(round.temp.signRound1Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round1) NextRound() smpc.Round {
+	round.started = false
+	return &round2{round}
+}
@@ -0,0 +1,89 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start broadcast the decommitment of R_i,W_i and the zk proof of r_i,w_i
+func (round *round2) Start() error {
+	if round.started {
+		fmt.Printf("============= sr25519 sign round2.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 2
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	var RBytes, WBytes [32]byte
+	copy(RBytes[:], round.temp.DR[32:])
+	copy(WBytes[:], round.temp.DW[32:])
+
+	zkR, err := ed.Prove2(round.temp.r, RBytes)
+	if err != nil {
+		return err
+	}
+
+	zkW, err := ed.Prove2(round.temp.w, WBytes)
+	if err != nil {
+		return err
+	}
+
+	srm := &SignRound2Message{
+		SignRoundMessage: new(SignRoundMessage),
+		DR:               round.temp.DR,
+		DW:               round.temp.DW,
+		ZkR:              zkR,
+		ZkW:              zkW,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound2Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round2) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound2Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round2) Update() (bool, error) {
+	return round.update(round.temp.signRound2Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round2) NextRound() smpc.Round {
+	round.started = false
+	return &round3{round}
+}
@@ -0,0 +1,133 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the decommitment and zk proof,calc R = sum(R_i) and the schnorrkel challenge k,broadcast the partial signature s_i
+func (round *round3) Start() error {
+	if round.started {
+		fmt.Printf("============= sr25519 sign round3.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 3
+	round.started = true
+	round.ResetOK()
+
+	curIndex, err := round.GetDNodeIDIndex(round.kgid)
+	if err != nil {
+		return err
+	}
+
+	round.temp.rs = make([][32]byte, round.threshold)
+	round.temp.ws = make([][32]byte, round.threshold)
+	var R, W, temR, temW ed.ExtendedGroupElement
+	for k := range round.idsign {
+		msg1, ok := round.temp.signRound1Messages[k].(*SignRound1Message)
+		if !ok {
+			return errors.New("round.Start get round1 msg fail")
+		}
+
+		msg2, ok := round.temp.signRound2Messages[k].(*SignRound2Message)
+		if !ok {
+			return errors.New("round.Start get round2 msg fail")
+		}
+
+		if !ed.Verify(msg1.CR, msg2.DR) || !ed.Verify(msg1.CW, msg2.DW) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "Commitment", errors.New("verify commitment fail"))
+		}
+
+		var RBytes, WBytes [32]byte
+		copy(RBytes[:], msg2.DR[32:])
+		copy(WBytes[:], msg2.DW[32:])
+
+		if !ed.VerifyZk2(msg2.ZkR, RBytes) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZkR", errors.New("verify zk proof of r fail"))
+		}
+
+		if !ed.VerifyZk2(msg2.ZkW, WBytes) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "ZkW", errors.New("verify zk proof of w fail"))
+		}
+
+		if !temR.FromBytes(&RBytes) || !temW.FromBytes(&WBytes) {
+			return smpc.NewBlameError(msg1.GetFromID(), 3, "Point", errors.New("R_i or W_i format error"))
+		}
+
+		round.temp.rs[k] = RBytes
+		round.temp.ws[k] = WBytes
+		if k == 0 {
+			R = temR
+			W = temW
+			continue
+		}
+
+		ed.GeAdd(&R, &R, &temR)
+		ed.GeAdd(&W, &W, &temW)
+	}
+
+	// sum(W_i) must be the pubkey,so the partial signature of every node can be verified with W_i
+	var WBytes [32]byte
+	W.ToBytes(&WBytes)
+	if WBytes != round.save.FinalPkBytes {
+		return errors.New("the sum of public shares is not the pubkey")
+	}
+
+	ed.RistrettoEncode(&round.temp.pk, &W)
+	ed.RistrettoEncode(&round.temp.R, &R)
+	round.temp.k = Challenge(round.context, round.message, round.temp.pk, round.temp.R)
+
+	// s_i = k * w_i + r_i
+	var s [32]byte
+	ed.ScMulAdd(&s, &round.temp.k, &round.temp.w, &round.temp.r)
+
+	srm := &SignRound3Message{
+		SignRoundMessage: new(SignRoundMessage),
+		S:                s,
+	}
+	srm.SetFromID(round.kgid)
+	srm.SetFromIndex(curIndex)
+
+	round.temp.signRound3Messages[curIndex] = srm
+	round.out <- srm
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round3) CanAccept(msg smpc.Message) bool {
+	if _, ok := msg.(*SignRound3Message); ok {
+		return msg.IsBroadcast()
+	}
+
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round3) Update() (bool, error) {
+	return round.update(round.temp.signRound3Messages, round.CanAccept)
+}
+
+// NextRound enter next round
+func (round *round3) NextRound() smpc.Round {
+	round.started = false
+	return &round4{round}
+}
@@ -0,0 +1,88 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// Start verify the partial signatures,calc s = sum(s_i) and check the schnorrkel signature (R,s)
+func (round *round4) Start() error {
+	if round.started {
+		fmt.Printf("============= sr25519 sign round4.start fail =======\n")
+		return errors.New("round already started")
+	}
+	round.number = 4
+	round.started = true
+	round.ResetOK()
+
+	var s [32]byte
+	for k := range round.idsign {
+		msg3, ok := round.temp.signRound3Messages[k].(*SignRound3Message)
+		if !ok {
+			return errors.New("round.Start get round3 msg fail")
+		}
+
+		// s_i*B == R_i + k * W_i
+		var sB, Ri, Wi, kW, check ed.ExtendedGroupElement
+		var sBBytes, checkBytes [32]byte
+		si := msg3.S
+		if !ed.ScMinimal(&si) || !Ri.FromBytes(&round.temp.rs[k]) || !Wi.FromBytes(&round.temp.ws[k]) {
+			return smpc.NewBlameError(msg3.GetFromID(), 4, "PartialSig", errors.New("verify partial signature fail"))
+		}
+
+		ed.GeScalarMultBase(&sB, &si)
+		ed.GeScalarMult(&kW, &round.temp.k, &Wi)
+		ed.GeAdd(&check, &Ri, &kW)
+		sB.ToBytes(&sBBytes)
+		check.ToBytes(&checkBytes)
+		if sBBytes != checkBytes {
+			return smpc.NewBlameError(msg3.GetFromID(), 4, "PartialSig", errors.New("verify partial signature fail"))
+		}
+
+		ed.ScAdd(&s, &s, &si)
+	}
+
+	data := SrSignData{R: round.temp.R, S: s, Pk: round.temp.pk}
+	sig := data.Signature()
+	if !SrVerify(data.Pk, round.context, round.message, sig[:]) {
+		return errors.New("verify sr25519 signature fail")
+	}
+
+	round.end <- data
+	fmt.Printf("========= sr25519 sign round4 finish, dnode id = %v ==========\n", round.kgid)
+	return nil
+}
+
+// CanAccept is it legal to receive this message
+func (round *round4) CanAccept(msg smpc.Message) bool {
+	return false
+}
+
+// Update  is the message received and ready for the next round?
+func (round *round4) Update() (bool, error) {
+	return false, nil
+}
+
+// NextRound enter next round
+func (round *round4) NextRound() smpc.Round {
+	return nil
+}
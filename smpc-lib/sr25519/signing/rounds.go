@@ -0,0 +1,126 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+type (
+	base struct {
+		temp      *localTempData
+		save      *keygen.LocalDNodeSaveData
+		idsign    smpc.SortableIDSSlice
+		out       chan<- smpc.Message
+		end       chan<- SrSignData
+		ok        []bool
+		started   bool
+		number    int
+		kgid      string
+		threshold int
+		context   []byte
+		message   []byte
+	}
+	round1 struct {
+		*base
+	}
+	round2 struct {
+		*round1
+	}
+	round3 struct {
+		*round2
+	}
+	round4 struct {
+		*round3
+	}
+)
+
+// ----- //
+
+func (round *base) RoundNumber() int {
+	return round.number
+}
+
+func (round *base) CanProceed() bool {
+	if !round.started {
+		return false
+	}
+
+	for _, ok := range round.ok {
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetIDs get from all nodes
+func (round *base) GetIDs() (smpc.SortableIDSSlice, error) {
+	return round.idsign, nil
+}
+
+// GetDNodeIDIndex get current dnode index by id
+func (round *base) GetDNodeIDIndex(id string) (int, error) {
+	if id == "" {
+		return -1, errors.New("no found current node's uid")
+	}
+
+	uidtmp, err := hex.DecodeString(id)
+	if err != nil {
+		return -1, err
+	}
+
+	idtmp, ok := new(big.Int).SetString(string(uidtmp[:]), 10)
+	if !ok {
+		return -1, errors.New("get uid fail")
+	}
+
+	for k, v := range round.idsign {
+		if v.Cmp(idtmp) == 0 {
+			return k, nil
+		}
+	}
+
+	return -1, errors.New("get dnode index fail,no found in idsign")
+}
+
+func (round *base) ResetOK() {
+	for j := range round.ok {
+		round.ok[j] = false
+	}
+}
+
+// update is the messages of current round received?
+func (round *base) update(l []smpc.Message, canAccept func(smpc.Message) bool) (bool, error) {
+	for j, msg := range l {
+		if round.ok[j] {
+			continue
+		}
+		if msg == nil || !canAccept(msg) {
+			return false, nil
+		}
+		round.ok[j] = true
+	}
+
+	return true, nil
+}
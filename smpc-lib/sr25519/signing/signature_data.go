@@ -0,0 +1,100 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package signing
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+)
+
+// SubstrateContext the schnorrkel signing context used by the substrate chains
+var SubstrateContext = []byte("substrate")
+
+// SrSignData the sr25519 signature (R,s) and the ristretto255 pubkey that verifies it
+type SrSignData struct {
+	R  [32]byte
+	S  [32]byte
+	Pk [32]byte
+}
+
+// Signature the 64 bytes schnorrkel signature,the highest bit of s is set to mark it as schnorrkel signature
+func (sd *SrSignData) Signature() [64]byte {
+	var sig [64]byte
+	copy(sig[:32], sd.R[:])
+	copy(sig[32:], sd.S[:])
+	sig[63] |= 128
+	return sig
+}
+
+// PubKeyFromEd get the ristretto255 encoding of the ed25519 pubkey of ED25519 keygen
+func PubKeyFromEd(edpk [32]byte) ([32]byte, error) {
+	var pk [32]byte
+	var A ed.ExtendedGroupElement
+	if !A.FromBytes(&edpk) {
+		return pk, errors.New("ed25519 pubkey format error")
+	}
+
+	ed.RistrettoEncode(&pk, &A)
+	return pk, nil
+}
+
+// Challenge the schnorrkel challenge k of signing message under context with pubkey pk and commitment R
+func Challenge(context []byte, message []byte, pk [32]byte, R [32]byte) [32]byte {
+	t := ed.NewTranscript("SigningContext")
+	t.AppendMessage([]byte(""), context)
+	t.AppendMessage([]byte("sign-bytes"), message)
+	t.AppendMessage([]byte("proto-name"), []byte("Schnorr-sig"))
+	t.AppendMessage([]byte("sign:pk"), pk[:])
+	t.AppendMessage([]byte("sign:R"), R[:])
+	return t.ChallengeScalar([]byte("sign:c"))
+}
+
+// SrVerify verify the 64 bytes schnorrkel signature of message under context with the ristretto255 pubkey pk
+func SrVerify(pk [32]byte, context []byte, message []byte, sig []byte) bool {
+	if len(sig) != 64 || sig[63]&128 == 0 {
+		return false
+	}
+
+	var R, s [32]byte
+	copy(R[:], sig[:32])
+	copy(s[:], sig[32:])
+	s[31] &= 127
+	if !ed.ScMinimal(&s) {
+		return false
+	}
+
+	var A, RP ed.ExtendedGroupElement
+	if !ed.RistrettoDecode(&A, &pk) || !ed.RistrettoDecode(&RP, &R) {
+		return false
+	}
+
+	// R == s*B - k*A
+	k := Challenge(context, message, pk, R)
+	ed.FeNeg(&A.X, &A.X)
+	ed.FeNeg(&A.T, &A.T)
+
+	var sB, kA, RCal ed.ExtendedGroupElement
+	ed.GeScalarMultBase(&sB, &s)
+	ed.GeScalarMult(&kA, &k, &A)
+	ed.GeAdd(&RCal, &sB, &kA)
+
+	var RCalBytes [32]byte
+	ed.RistrettoEncode(&RCalBytes, &RCal)
+	return bytes.Equal(RCalBytes[:], R[:])
+}
@@ -48,6 +48,9 @@ const (
 	
 	// RPCRESHARE reshare
 	RPCRESHARE RPCType = 3
+
+	// RPCRECOVERSHARE recover the lost share of a node
+	RPCRECOVERSHARE RPCType = 4
 )
 
 // GetAllReplyFromGroup get all accept reply from group node 
@@ -88,6 +91,8 @@ func GetAllReplyFromGroup(wid int, gid string, rt RPCType, initiator string) []N
 		req = &ReqSmpcSign{}
 	case RPCRESHARE:
 		req = &ReqSmpcReshare{}
+	case RPCRECOVERSHARE:
+		req = &ReqSmpcRecoverShare{}
 	case RPCREQADDR:
 		req = &ReqSmpcAddr{}
 	default:
@@ -165,7 +170,7 @@ func CheckAccept(pubkey string, mode string, account string) bool {
 							return true
 						}
 
-						if mode == "0" && CheckAcc(curEnode, account, ac.Sigs) {
+						if isGroupApprovalMode(mode) && CheckAcc(curEnode, account, ac.Sigs) {
 							return true
 						}
 					}
@@ -229,6 +234,10 @@ func SaveAcceptReqAddrData(ac *AcceptReqAddrData) error {
 		return err
 	}
 
+	if ac.Status == "Pending" {
+		notifyPendingApproval("REQSMPCADDR", key, ac.Account, "", ac.GroupID)
+	}
+
 	return nil
 }
 
@@ -343,6 +352,8 @@ func AcceptReqAddr(initiator string, account string, cointype string, groupid st
 			common.Error("===================================AcceptReqAddr,put reqaddr accept data to pubkey data db fail===========================", "err", err, "key", key)
 			return err.Error(), err
 		}
+
+		notifyFinished("REQSMPCADDR", key, ac2.Account, ac2.PubKey, ac2.GroupID, ac2.Status, ac2.PubKey, ac2.Tip, ac2.Error)
 	} else {
 		err = PutReqAddrInfoData([]byte(key), []byte(es))
 		if err != nil {
@@ -410,6 +421,10 @@ func SaveAcceptSignData(ac *AcceptSignData) error {
 		return err
 	}
 
+	if ac.Status == "Pending" {
+		notifyPendingApproval("SIGN", key, ac.Account, ac.PubKey, ac.GroupID)
+	}
+
 	return nil
 }
 
@@ -521,6 +536,8 @@ func AcceptSign(initiator string, account string, pubkey string, msghash []strin
 			common.Error("========================AcceptSign,put sign accept data to pubkey data db fail.=======================", "key", key, "err", err)
 			return err.Error(), err
 		}
+
+		notifyFinished("SIGN", key, ac2.Account, ac2.PubKey, ac2.GroupID, ac2.Status, ac2.Rsv, ac2.Tip, ac2.Error)
 	} else {
 		err = PutSignInfoData([]byte(key), []byte(es))
 		if err != nil {
@@ -585,6 +602,10 @@ func SaveAcceptReShareData(ac *AcceptReShareData) error {
 		return err
 	}
 
+	if ac.Status == "Pending" {
+		notifyPendingApproval("RESHARE", key, ac.Account, ac.PubKey, ac.GroupID)
+	}
+
 	return nil
 }
 
@@ -694,6 +715,9 @@ func AcceptReShare(initiator string, account string, groupid string, tsgroupid s
 			common.Error("=====================AcceptReShare, put reshare accept data to pubkey data db fail======================", "err", err, "key", key)
 			return err.Error(), err
 		}
+
+		// the new share is private,only the pubkey is reported
+		notifyFinished("RESHARE", key, ac2.Account, ac2.PubKey, ac2.GroupID, ac2.Status, ac2.PubKey, ac2.Tip, ac2.Error)
 	} else {
 		err = PutReShareInfoData([]byte(key), []byte(es))
 		if err != nil {
@@ -705,6 +729,185 @@ func AcceptReShare(initiator string, account string, groupid string, tsgroupid s
 	return "", nil
 }
 
+//----------------------------------------------------------------------------------
+
+// AcceptRecoverShareData the data of recover share cmd,include:weather initiator,from accout,gid,pubkey,the enode that lost its share,accept or reject the recover share .. and so on. 
+type AcceptRecoverShareData struct {
+	TxType    string // RECOVERSHARE || REFRESH,"" is RECOVERSHARE
+	Initiator string //enode id
+	Account   string
+	GroupID   string
+	PubKey    string
+	Enode     string // the enode id of the node whose share is recovered
+	TimeStamp string
+
+	Deal   string
+	Accept string
+
+	Status string
+	Tip    string
+	Error  string
+
+	AllReply []NodeReply
+	WorkID   int
+}
+
+// getRecoverShareTxType get the command type of the recover share data,the old data has no TxType,it is RECOVERSHARE
+func getRecoverShareTxType(ac *AcceptRecoverShareData) string {
+	if ac == nil || ac.TxType == "" {
+		return "RECOVERSHARE"
+	}
+
+	return ac.TxType
+}
+
+// SaveAcceptRecoverShareData save the recover share command data to local db
+func SaveAcceptRecoverShareData(ac *AcceptRecoverShareData) error {
+	if ac == nil {
+		return fmt.Errorf("Accept data was not found")
+	}
+
+	key := Keccak256Hash([]byte(strings.ToLower(ac.Account + ":" + ac.GroupID + ":" + ac.PubKey + ":" + ac.Enode + ":" + ac.TimeStamp))).Hex()
+
+	alos, err := Encode2(ac)
+	if err != nil {
+		common.Error("========================SaveAcceptRecoverShareData======================", "enode err", err, "key", key)
+		return err
+	}
+
+	ss, err := Compress([]byte(alos))
+	if err != nil {
+		common.Error("========================SaveAcceptRecoverShareData======================", "compress err", err, "key", key)
+		return err
+	}
+
+	err = PutRecoverShareInfoData([]byte(key), []byte(ss))
+	if err != nil {
+		common.Error("========================SaveAcceptRecoverShareData======================", "put recover share accept data to local db err", err, "key", key)
+		return err
+	}
+
+	if ac.Status == "Pending" {
+		notifyPendingApproval(getRecoverShareTxType(ac), key, ac.Account, ac.PubKey, ac.GroupID)
+	}
+
+	return nil
+}
+
+//--------------------------------------------------------------------------------------
+
+// TxDataAcceptRecoverShare the data of the special tx of accepting recover share
+type TxDataAcceptRecoverShare struct {
+	TxType    string
+	Key       string
+	Accept    string
+	TimeStamp string
+}
+
+// AcceptRecoverShare  set the status of recover share request 
+// if the status is not "Pending",move the Corresponding data to  General database,otherwise stay the database for saving data related to recover share command 
+func AcceptRecoverShare(initiator string, account string, groupid string, pubkey string, enode string, timestamp string, deal string, accept string, status string, tip string, errinfo string, allreply []NodeReply, workid int) (string, error) {
+	key := Keccak256Hash([]byte(strings.ToLower(account + ":" + groupid + ":" + pubkey + ":" + enode + ":" + timestamp))).Hex()
+	exsit, da := GetPubKeyData([]byte(key))
+	if exsit {
+		ac, ok := da.(*AcceptRecoverShareData)
+		if ok {
+			if ac.Status != "Pending" {
+				common.Info("=====================AcceptRecoverShare,the recover share has been processed=======================", "key", key)
+				return "", nil
+			}
+		}
+	}
+
+	exsit, da = GetRecoverShareInfoData([]byte(key))
+	if !exsit {
+		common.Error("=====================AcceptRecoverShare, key does not exist======================", "key", key)
+		return "smpc back-end internal error:get accept data fail from db", fmt.Errorf("get recover share accept data fail from db")
+	}
+
+	ac, ok := da.(*AcceptRecoverShareData)
+	if !ok {
+		common.Error("=====================AcceptRecoverShare, get recover share accept data fail from db======================", "key", key)
+		return "smpc back-end internal error:get accept data fail from db", fmt.Errorf("get recover share accept data fail from db")
+	}
+
+	in := ac.Initiator
+	if initiator != "" {
+		in = initiator
+	}
+
+	de := ac.Deal
+	if deal != "" {
+		de = deal
+	}
+
+	acp := ac.Accept
+	if accept != "" {
+		acp = accept
+	}
+
+	ttip := ac.Tip
+	if tip != "" {
+		ttip = tip
+	}
+
+	eif := ac.Error
+	if errinfo != "" {
+		eif = errinfo
+	}
+
+	sts := ac.Status
+	if status != "" {
+		sts = status
+	}
+
+	arl := ac.AllReply
+	if allreply != nil {
+		arl = allreply
+	}
+
+	wid := ac.WorkID
+	if workid >= 0 {
+		wid = workid
+	}
+
+	ac2 := &AcceptRecoverShareData{TxType: ac.TxType, Initiator: in, Account: ac.Account, GroupID: ac.GroupID, PubKey: ac.PubKey, Enode: ac.Enode, TimeStamp: ac.TimeStamp, Deal: de, Accept: acp, Status: sts, Tip: ttip, Error: eif, AllReply: arl, WorkID: wid}
+
+	e, err := Encode2(ac2)
+	if err != nil {
+		common.Error("=====================AcceptRecoverShare, encode fail======================", "err", err, "key", key)
+		return "smpc back-end internal error:encode accept data fail", err
+	}
+
+	es, err := Compress([]byte(e))
+	if err != nil {
+		common.Error("=====================AcceptRecoverShare, compress fail======================", "err", err, "key", key)
+		return "smpc back-end internal error:compress accept data fail", err
+	}
+
+	if ac2.Status != "Pending" {
+		err = DeleteRecoverShareInfoData([]byte(key))
+		if err != nil {
+			return err.Error(), err
+		}
+		err = PutPubKeyData([]byte(key), []byte(es))
+		if err != nil {
+			common.Error("=====================AcceptRecoverShare, put recover share accept data to pubkey data db fail======================", "err", err, "key", key)
+			return err.Error(), err
+		}
+
+		notifyFinished(getRecoverShareTxType(ac2), key, ac2.Account, ac2.PubKey, ac2.GroupID, ac2.Status, ac2.Enode, ac2.Tip, ac2.Error)
+	} else {
+		err = PutRecoverShareInfoData([]byte(key), []byte(es))
+		if err != nil {
+			common.Error("=====================AcceptRecoverShare, put recover share accept data to local db fail======================", "err", err, "key", key)
+			return err.Error(), err
+		}
+	}
+
+	return "", nil
+}
+
 //---------------------------------------------------------------------
 
 // ApprovReply the reply of node,including enode,accept or reject the keygen/sign/reshare cmd request
@@ -783,6 +986,15 @@ func GetRawReply(l *list.List) *common.SafeMap {
 			continue
 		}
 
+		rs, ok := txdata.(*TxDataRecoverShare)
+		if ok {
+			reply := &RawReply{From: from, Accept: "true", TimeStamp: rs.TimeStamp}
+			req2 = &ReqSmpcRecoverShare{}
+			req2.GetRawReply(ret, reply)
+
+			continue
+		}
+
 		acceptreq, ok := txdata.(*TxDataAcceptReqAddr)
 		if ok {
 			accept := "false"
@@ -818,6 +1030,18 @@ func GetRawReply(l *list.List) *common.SafeMap {
 			req2 = &ReqSmpcReshare{}
 			req2.GetRawReply(ret, reply)
 		}
+
+		acceptrs, ok := txdata.(*TxDataAcceptRecoverShare)
+		if ok {
+			accept := "false"
+			if acceptrs.Accept == "AGREE" {
+				accept = "true"
+			}
+
+			reply := &RawReply{From: from, Accept: accept, TimeStamp: acceptrs.TimeStamp}
+			req2 = &ReqSmpcRecoverShare{}
+			req2.GetRawReply(ret, reply)
+		}
 	}
 
 	return ret
@@ -835,6 +1059,11 @@ func CheckReply(l *list.List, rt RPCType, key string) bool {
 		return req.CheckReply(nil, l, key)
 	}
 
+	if rt == RPCRECOVERSHARE {
+		req = &ReqSmpcRecoverShare{}
+		return req.CheckReply(nil, l, key)
+	}
+
 	k := ""
 	if rt == RPCREQADDR {
 		k = key
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
 	"github.com/anyswap/FastMulThreshold-DSA/p2p/discover"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/fsn-dev/cryptoCoins/coins/types"
 	"github.com/fsn-dev/cryptoCoins/tools/rlp"
 	"math/big"
@@ -207,6 +208,17 @@ func (req *ReqSmpcAddr) DoReq(raw string, workid int, sender string, ch chan int
 		    return false
 		}
 
+		// the shares of the imported key are dealt in the command data
+		var deal *ImportKeyDeal
+		if req2.TxType == "IMPORTKEY" {
+		    deal, err = getImportKeyDeal(raw)
+		    if err != nil {
+			res := RPCSmpcRes{Ret: "", Tip: err.Error(), Err: err}
+			ch <- res
+			return false
+		    }
+		}
+
 		_, err := SetReqAddrNonce(from, nonce)
 		if err != nil {
 		    common.Error("===============DoReq,set nonce fail===================", "key", key,"from",from,"nonce",nonce,"err",err)
@@ -238,6 +250,9 @@ func (req *ReqSmpcAddr) DoReq(raw string, workid int, sender string, ch chan int
 		w.sid = key
 		w.groupid = req2.GroupID
 		w.limitnum = req2.ThresHold
+		w.paillierkeylength, _ = parsePaillierKeyLength(req2.PaillierKeyLength)
+		w.signprotocol, _ = parseSignProtocol(req2.Keytype, req2.SignProtocol)
+		smpclibec2.PrepareSafePrime(w.paillierkeylength)
 		gcnt, _ := GetGroup(w.groupid)
 		w.NodeCnt = gcnt
 		w.ThresHold = w.NodeCnt
@@ -265,7 +280,7 @@ func (req *ReqSmpcAddr) DoReq(raw string, workid int, sender string, ch chan int
 		}
 		w.ThresHold = th
 
-		if req2.Mode == "0" { // self-group
+		if isGroupApprovalMode(req2.Mode) { // self-group
 			////
 			var reply bool
 			var tip string
@@ -400,7 +415,7 @@ func (req *ReqSmpcAddr) DoReq(raw string, workid int, sender string, ch chan int
 			}
 		}
 
-		smpcGenPubKey(w.sid, from, req2.Keytype, rch, req2.Mode, nonce)
+		smpcGenPubKey(w.sid, from, req2.Keytype, rch, req2.Mode, nonce, deal)
 		chret, tip, cherr := GetChannelValue(waitall, rch)
 		if cherr != nil {
 			ars := GetAllReplyFromGroup(w.id, req2.GroupID, RPCREQADDR, sender)
@@ -496,7 +511,7 @@ func (req *ReqSmpcAddr) GetGroupSigs(txdata []byte) (string, string, string, str
 
 	req2 := TxDataReqAddr{}
 	err := json.Unmarshal(txdata, &req2)
-	if err == nil && req2.TxType == "REQSMPCADDR" {
+	if err == nil && (req2.TxType == "REQSMPCADDR" || req2.TxType == "IMPORTKEY") {
 		return req2.ThresHold, req2.Mode, req2.Sigs, req2.GroupID
 	}
 
@@ -513,11 +528,23 @@ func (req *ReqSmpcAddr) CheckTxData(txdata []byte, from string, nonce uint64) (s
 
 	req2 := TxDataReqAddr{}
 	err := json.Unmarshal(txdata, &req2)
-	if err == nil && req2.TxType == "REQSMPCADDR" {
+	if err == nil && (req2.TxType == "REQSMPCADDR" || req2.TxType == "IMPORTKEY") {
 		keytype := req2.Keytype 
-		if keytype != "EC256K1" && keytype != "ED25519" {
+		if keytype != "EC256K1" && keytype != "EC256R1" && keytype != "ED25519" && keytype != "SR25519" {
 			return "","","",nil,fmt.Errorf("invalid keytype")
 		}
+
+		if req2.PaillierKeyLength != "" && keytype != "EC256K1" && keytype != "EC256R1" {
+			return "", "", "", nil, fmt.Errorf("paillier key length is only for EC256K1 and EC256R1")
+		}
+
+		if _, err := parsePaillierKeyLength(req2.PaillierKeyLength); err != nil {
+			return "", "", "", nil, err
+		}
+
+		if _, err := parseSignProtocol(keytype, req2.SignProtocol); err != nil {
+			return "", "", "", nil, err
+		}
 		
 		groupid := req2.GroupID
 		if groupid == "" {
@@ -534,6 +561,10 @@ func (req *ReqSmpcAddr) CheckTxData(txdata []byte, from string, nonce uint64) (s
 			return "", "", "", nil, fmt.Errorf("get mode fail")
 		}
 
+		if err := checkMode(mode); err != nil {
+			return "", "", "", nil, err
+		}
+
 		ato,err := strconv.Atoi(req2.AcceptTimeOut)
 		if err != nil || req2.AcceptTimeOut == "" {
 			ato = 600
@@ -579,6 +610,17 @@ func (req *ReqSmpcAddr) CheckTxData(txdata []byte, from string, nonce uint64) (s
 			return "", "", "", nil, fmt.Errorf("there is same enodeID in group")
 		}
 
+		if req2.TxType == "IMPORTKEY" {
+			imp := TxDataImportKey{}
+			if err := json.Unmarshal(txdata, &imp); err != nil {
+				return "", "", "", nil, err
+			}
+
+			if err := checkImportKeyDeal(&imp, ts); err != nil {
+				return "", "", "", nil, err
+			}
+		}
+
 		key := Keccak256Hash([]byte(strings.ToLower(from + ":" + req2.Keytype + ":" + groupid + ":" + fmt.Sprintf("%v", nonce) + ":" + threshold + ":" + mode))).Hex()
 
 		return key, from, fmt.Sprintf("%v", nonce), &req2, nil
@@ -641,8 +683,8 @@ func GetReqAddrRawValue(raw string) (string, string, string) {
 
 	req := TxDataReqAddr{}
 	err = json.Unmarshal(tx.Data(), &req)
-	if err == nil && req.TxType == "REQSMPCADDR" {
-		txtype = "REQSMPCADDR"
+	if err == nil && (req.TxType == "REQSMPCADDR" || req.TxType == "IMPORTKEY") {
+		txtype = req.TxType
 		timestamp = req.TimeStamp
 	} else {
 		acceptreq := TxDataAcceptReqAddr{}
@@ -736,6 +778,7 @@ func (req *ReqSmpcAddr) DisAcceptMsg(raw string, workid int, key string) {
 	}
 
 	w.msgacceptreqaddrres.PushBack(raw)
+	notifyApprovalRaw(key, raw)
 	
 	/////fix bug: miss accept msg for 7-11 test
 	SendMsgToSmpcGroup(raw, ac.GroupID)
@@ -0,0 +1,594 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/p2p/discover"
+	"github.com/fsn-dev/cryptoCoins/coins"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+)
+
+// ReqSmpcRecoverShare recover share cmd request
+type ReqSmpcRecoverShare struct {
+}
+
+//---------------------------------------------------------------------------------------------------
+
+// GetReplyFromGroup  Get the current reply status of the nodes in the group. About this command request
+func (req *ReqSmpcRecoverShare) GetReplyFromGroup(wid int, gid string, initiator string) []NodeReply {
+	if wid < 0 || wid >= len(workers) {
+		return nil
+	}
+
+	w := workers[wid]
+	if w == nil {
+		return nil
+	}
+
+	h := coins.NewCryptocoinHandler("FSN")
+	if h == nil {
+		return nil
+	}
+
+	var ars []NodeReply
+	_, enodes := GetGroup(gid)
+	nodes := strings.Split(enodes, common.Sep2)
+	for _, node := range nodes {
+		node2 := ParseNode(node)
+		sta := "Pending"
+		ts := ""
+		in := "0"
+		if strings.EqualFold(initiator, node2) {
+			in = "1"
+		}
+
+		pk := "04" + node2
+		fr, err := h.PublicKeyToAddress(pk)
+		if err != nil {
+			continue
+		}
+
+		iter := w.msgacceptrecovershareres.Front()
+		for iter != nil {
+			mdss := iter.Value.(string)
+			_, from, _, txdata, err := CheckRaw(mdss)
+			if err != nil || !strings.EqualFold(from, fr) {
+				iter = iter.Next()
+				continue
+			}
+
+			rs, ok := txdata.(*TxDataRecoverShare)
+			if ok {
+				sta = "Agree"
+				ts = rs.TimeStamp
+				break
+			}
+
+			acceptrs, ok := txdata.(*TxDataAcceptRecoverShare)
+			if ok {
+				sta = "Agree"
+				if acceptrs.Accept != "AGREE" {
+					sta = "DisAgree"
+				}
+				ts = acceptrs.TimeStamp
+				break
+			}
+
+			iter = iter.Next()
+		}
+
+		nr := NodeReply{Enode: node2, Status: sta, TimeStamp: ts, Initiator: in}
+		ars = append(ars, nr)
+	}
+
+	return ars
+}
+
+//---------------------------------------------------------------------------------------
+
+// GetReqAddrKeyByKey No need for recover share
+func (req *ReqSmpcRecoverShare) GetReqAddrKeyByKey(key string) string {
+	return ""
+}
+
+//-----------------------------------------------------------------------------------------
+
+// GetRawReply put the reply to map, select the reply sent at the latest time
+// reply.From ---> reply
+func (req *ReqSmpcRecoverShare) GetRawReply(ret *common.SafeMap, reply *RawReply) {
+	if reply == nil || ret == nil {
+		return
+	}
+
+	tmp, ok := ret.ReadMap(reply.From)
+	if !ok {
+		ret.WriteMap(reply.From, reply)
+	} else {
+		tmp2, ok := tmp.(*RawReply)
+		if ok {
+			t1, _ := new(big.Int).SetString(reply.TimeStamp, 10)
+			t2, _ := new(big.Int).SetString(tmp2.TimeStamp, 10)
+			if t1.Cmp(t2) > 0 {
+				ret.WriteMap(reply.From, reply)
+			}
+		}
+
+	}
+}
+
+//--------------------------------------------------------------------------------------------
+
+// CheckReply  Detect whether all nodes in the group have sent accept data
+func (req *ReqSmpcRecoverShare) CheckReply(ac *AcceptReqAddrData, l *list.List, key string) bool {
+	if l == nil || key == "" {
+		return false
+	}
+
+	exsit, da := GetRecoverShareInfoData([]byte(key))
+	if !exsit {
+		return false
+	}
+
+	ac2, ok := da.(*AcceptRecoverShareData)
+	if !ok || ac2 == nil {
+		return false
+	}
+
+	h := coins.NewCryptocoinHandler("FSN")
+	if h == nil {
+		return false
+	}
+
+	ret := GetRawReply(l)
+	_, enodes := GetGroup(ac2.GroupID)
+	nodes := strings.Split(enodes, common.Sep2)
+	for _, node := range nodes {
+		node2 := ParseNode(node)
+		pk := "04" + node2
+		fr, err := h.PublicKeyToAddress(pk)
+		if err != nil {
+			return false
+		}
+
+		found := false
+		_, value := ret.ListMap()
+		for _, v := range value {
+			if v != nil && strings.EqualFold((v.(*RawReply)).From, fr) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+//------------------------------------------------------------------------------------------------
+
+// DoReq   1.Parse the recover share command and implement the process 2.analyze the accept data
+func (req *ReqSmpcRecoverShare) DoReq(raw string, workid int, sender string, ch chan interface{}) bool {
+	if raw == "" || workid < 0 || sender == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "do req fail.", Err: fmt.Errorf("do req fail")}
+		ch <- res
+		return false
+	}
+
+	key, from, _, txdata, err := CheckRaw(raw)
+	if err != nil {
+		common.Error("===============DoReq,check raw data error===================", "raw", raw, "err ", err)
+		res := RPCSmpcRes{Ret: "", Tip: err.Error(), Err: err}
+		ch <- res
+		return false
+	}
+
+	rs, ok := txdata.(*TxDataRecoverShare)
+	if ok {
+		pubs, err := getRecoverSharePubKeyData(rs.PubKey, rs.GroupID)
+		if err != nil {
+			res := RPCSmpcRes{Ret: "", Tip: err.Error(), Err: err}
+			ch <- res
+			return false
+		}
+
+		ars := GetAllReplyFromGroup(workid, rs.GroupID, RPCRECOVERSHARE, sender)
+		ac := &AcceptRecoverShareData{TxType: rs.TxType, Initiator: sender, Account: from, GroupID: rs.GroupID, PubKey: rs.PubKey, Enode: rs.Enode, TimeStamp: rs.TimeStamp, Deal: "false", Accept: "false", Status: "Pending", Tip: "", Error: "", AllReply: ars, WorkID: workid}
+		err = SaveAcceptRecoverShareData(ac)
+		common.Info("===================DoReq,finish call SaveAcceptRecoverShareData======================", "err ", err, "workid ", workid, "account ", from, "group id ", rs.GroupID, "pubkey ", rs.PubKey, "enode ", rs.Enode, "key ", key)
+		if err != nil {
+			res := RPCSmpcRes{Ret: "", Tip: err.Error(), Err: err}
+			ch <- res
+			return false
+		}
+
+		w := workers[workid]
+		w.sid = key
+		w.blamekey = key
+		w.groupid = rs.GroupID
+		w.limitnum = pubs.LimitNum
+		w.SmpcFrom = rs.PubKey
+		gcnt, _ := GetGroup(w.groupid)
+		w.NodeCnt = gcnt
+		w.ThresHold = gcnt
+
+		var reply bool
+		var tip string
+		timeout := make(chan bool, 1)
+		go func(wid int) {
+			curEnode = discover.GetLocalID().String() //GetSelfEnode()
+			ato, err := strconv.Atoi(rs.AcceptTimeOut)
+			if err != nil || rs.AcceptTimeOut == "" {
+				ato = 600
+			}
+
+			agreeWaitTime := time.Duration(ato) * time.Second
+			agreeWaitTimeOut := time.NewTicker(agreeWaitTime)
+
+			wtmp2 := workers[wid]
+
+			for {
+				select {
+				case account := <-wtmp2.acceptRecoverShareChan:
+					common.Debug("(self *RecvMsg) Run(),", "account= ", account, "key = ", key)
+					ars := GetAllReplyFromGroup(w.id, rs.GroupID, RPCRECOVERSHARE, sender)
+					common.Info("================== DoReq, get all AcceptRecoverShareRes================", "raw ", raw, "result ", ars, "key ", key)
+
+					reply = true
+					for _, nr := range ars {
+						if !strings.EqualFold(nr.Status, "Agree") {
+							reply = false
+							break
+						}
+					}
+
+					if !reply {
+						tip = "don't accept recover share"
+						_, err = AcceptRecoverShare(sender, from, rs.GroupID, rs.PubKey, rs.Enode, rs.TimeStamp, "false", "false", "Failure", "don't accept recover share", "don't accept recover share", ars, wid)
+					} else {
+						tip = ""
+						_, err = AcceptRecoverShare(sender, from, rs.GroupID, rs.PubKey, rs.Enode, rs.TimeStamp, "true", "true", "Pending", "", "", ars, wid)
+					}
+
+					if err != nil {
+						tip = tip + " and accept recover share data fail"
+					}
+
+					timeout <- true
+					return
+				case <-agreeWaitTimeOut.C:
+					common.Info("================== DoReq, agree wait timeout===================", "raw ", raw, "key ", key)
+					ars := GetAllReplyFromGroup(w.id, rs.GroupID, RPCRECOVERSHARE, sender)
+					_, err = AcceptRecoverShare(sender, from, rs.GroupID, rs.PubKey, rs.Enode, rs.TimeStamp, "false", "false", "Timeout", "get other node accept recover share result timeout", "get other node accept recover share result timeout", ars, wid)
+					reply = false
+					tip = "get other node accept recover share result timeout"
+					if err != nil {
+						tip = tip + " and accept recover share data fail"
+					}
+
+					timeout <- true
+					return
+				}
+			}
+		}(workid)
+
+		if len(workers[workid].acceptWaitRecoverShareChan) == 0 {
+			workers[workid].acceptWaitRecoverShareChan <- "go on"
+		}
+
+		DisAcceptMsg(raw, workid)
+		HandleC1Data(nil, key)
+
+		<-timeout
+
+		if !reply {
+			res2 := RPCSmpcRes{Ret: "", Tip: tip, Err: fmt.Errorf("don't accept recover share")}
+			ch <- res2
+			return false
+		}
+
+		// RecoverShareEC2/RefreshEC2 and the inbound message goroutine both may report the error
+		rch := make(chan interface{}, 2)
+		tip = "recover share fail"
+		if rs.TxType == "REFRESH" {
+			tip = "refresh fail"
+			_, err = RefreshEC2(w.sid, pubs, rch, workid)
+		} else {
+			_, err = RecoverShareEC2(w.sid, pubs, rs.Enode, rch, workid)
+		}
+		if err != nil {
+			_, _ = AcceptRecoverShare(sender, from, rs.GroupID, rs.PubKey, rs.Enode, rs.TimeStamp, "true", "", "Failure", tip, err.Error(), nil, workid)
+			res2 := RPCSmpcRes{Ret: "", Tip: tip, Err: err}
+			ch <- res2
+			return false
+		}
+
+		tip, err = AcceptRecoverShare(sender, from, rs.GroupID, rs.PubKey, rs.Enode, rs.TimeStamp, "true", "", "Success", "", "", nil, workid)
+		if err != nil {
+			res2 := RPCSmpcRes{Ret: "", Tip: tip, Err: fmt.Errorf("update recover share status error")}
+			ch <- res2
+			return false
+		}
+
+		common.Info("================recover share,the terminal res is success=================", "key", key, "tx type", rs.TxType)
+		res2 := RPCSmpcRes{Ret: "Success", Tip: "", Err: nil}
+		ch <- res2
+		return true
+	}
+
+	acceptrs, ok := txdata.(*TxDataAcceptRecoverShare)
+	if ok {
+		w, err := FindWorker(acceptrs.Key)
+		if err != nil || w == nil {
+			c1data := strings.ToLower(acceptrs.Key + "-" + from)
+			C1Data.WriteMap(c1data, raw)
+			res := RPCSmpcRes{Ret: "Failure", Tip: "get recover share accept data fail from db when no find worker", Err: fmt.Errorf("get recover share accept data fail from db when no find worker")}
+			ch <- res
+			return false
+		}
+
+		exsit, da := GetRecoverShareInfoData([]byte(acceptrs.Key))
+		if !exsit {
+			res := RPCSmpcRes{Ret: "Failure", Tip: "smpc back-end internal error:get recover share accept data fail from db in init accept data", Err: fmt.Errorf("get recover share accept data fail from db in init accept data")}
+			ch <- res
+			return false
+		}
+
+		ac, ok := da.(*AcceptRecoverShareData)
+		if !ok || ac == nil {
+			res := RPCSmpcRes{Ret: "Failure", Tip: "smpc back-end internal error:decode accept data fail", Err: fmt.Errorf("decode accept data fail")}
+			ch <- res
+			return false
+		}
+
+		id, _ := GetWorkerID(w)
+		DisAcceptMsg(raw, id)
+		HandleC1Data(nil, acceptrs.Key)
+
+		res := RPCSmpcRes{Ret: "Success", Tip: "", Err: nil}
+		ch <- res
+		return true
+	}
+
+	return false
+}
+
+//---------------------------------------------------------------------------------------------
+
+// GetGroupSigs No need for recover share
+func (req *ReqSmpcRecoverShare) GetGroupSigs(txdata []byte) (string, string, string, string) {
+	return "", "", "", ""
+}
+
+//-------------------------------------------------------------------------------------------------------
+
+// CheckTxData check recover share/refresh command data and accept data
+func (req *ReqSmpcRecoverShare) CheckTxData(txdata []byte, from string, nonce uint64) (string, string, string, interface{}, error) {
+	if txdata == nil {
+		return "", "", "", nil, fmt.Errorf("tx data is nil")
+	}
+
+	rs := TxDataRecoverShare{}
+	err := json.Unmarshal(txdata, &rs)
+	if err == nil && (rs.TxType == "RECOVERSHARE" || rs.TxType == "REFRESH") {
+		if !IsValidReShareAccept(from, rs.GroupID) {
+			return "", "", "", nil, fmt.Errorf("check current enode account fail from raw data")
+		}
+
+		if from == "" || rs.PubKey == "" || rs.GroupID == "" || rs.TimeStamp == "" {
+			return "", "", "", nil, fmt.Errorf("param error")
+		}
+
+		pubs, err := getRecoverSharePubKeyData(rs.PubKey, rs.GroupID)
+		if err != nil {
+			return "", "", "", nil, err
+		}
+
+		if rs.TxType == "REFRESH" {
+			if rs.Enode != "" {
+				return "", "", "", nil, fmt.Errorf("refresh command must not have enode")
+			}
+		} else if index, _ := GetNodeUID(rs.Enode, getPubKeyType(pubs), rs.GroupID); rs.Enode == "" || index < 0 {
+			return "", "", "", nil, fmt.Errorf("the node to recover is not in the group")
+		}
+
+		ato, err := strconv.Atoi(rs.AcceptTimeOut)
+		if err != nil || rs.AcceptTimeOut == "" {
+			ato = 600
+		}
+		if ato <= 0 {
+			return "", "", "", nil, fmt.Errorf("illegal agreed timeout")
+		}
+
+		if ato > MaxAcceptTime {
+			return "", "", "", nil, fmt.Errorf("greater than the agreed maximum timeout")
+		}
+
+		key := Keccak256Hash([]byte(strings.ToLower(from + ":" + rs.GroupID + ":" + rs.PubKey + ":" + rs.Enode + ":" + rs.TimeStamp))).Hex()
+
+		return key, from, fmt.Sprintf("%v", nonce), &rs, nil
+	}
+
+	acceptrs := TxDataAcceptRecoverShare{}
+	err = json.Unmarshal(txdata, &acceptrs)
+	if err == nil && acceptrs.TxType == "ACCEPTRECOVERSHARE" {
+		if acceptrs.Accept != "AGREE" && acceptrs.Accept != "DISAGREE" {
+			return "", "", "", nil, fmt.Errorf("transaction data format error,the lastest segment is not AGREE or DISAGREE")
+		}
+
+		exsit, da := GetRecoverShareInfoData([]byte(acceptrs.Key))
+		if !exsit {
+			return "", "", "", nil, fmt.Errorf("get accept result from db fail")
+		}
+
+		ac, ok := da.(*AcceptRecoverShareData)
+		if !ok || ac == nil {
+			return "", "", "", nil, fmt.Errorf("get accept result from db fail")
+		}
+
+		if !IsValidReShareAccept(from, ac.GroupID) {
+			return "", "", "", nil, fmt.Errorf("check current enode account fail from raw data")
+		}
+
+		return acceptrs.Key, from, "", &acceptrs, nil
+	}
+
+	return "", "", "", nil, fmt.Errorf("check tx data fail")
+}
+
+//---------------------------------------------------------------------------------------------
+
+// GetRecoverShareRawValue get from/special tx data type/timestamp from recover share/refresh command data
+func GetRecoverShareRawValue(raw string) (string, string, string) {
+	if raw == "" {
+		return "", "", ""
+	}
+
+	tx := new(types.Transaction)
+	raws := common.FromHex(raw)
+	if err := rlp.DecodeBytes(raws, tx); err != nil {
+		return "", "", ""
+	}
+
+	signer := types.NewEIP155Signer(big.NewInt(30400))
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return "", "", ""
+	}
+
+	var txtype string
+	var timestamp string
+
+	rs := TxDataRecoverShare{}
+	err = json.Unmarshal(tx.Data(), &rs)
+	if err == nil && (rs.TxType == "RECOVERSHARE" || rs.TxType == "REFRESH") {
+		txtype = rs.TxType
+		timestamp = rs.TimeStamp
+	} else {
+		acceptrs := TxDataAcceptRecoverShare{}
+		err = json.Unmarshal(tx.Data(), &acceptrs)
+		if err == nil && acceptrs.TxType == "ACCEPTRECOVERSHARE" {
+			txtype = "ACCEPTRECOVERSHARE"
+			timestamp = acceptrs.TimeStamp
+		}
+	}
+
+	return from.Hex(), txtype, timestamp
+}
+
+// CheckRecoverShareDulpRawReply Filter duplicate accept data (command data is also a kind of accept data),
+// Take the latest accept data as the final data
+func CheckRecoverShareDulpRawReply(raw string, l *list.List) bool {
+	if l == nil || raw == "" {
+		return false
+	}
+
+	from, txtype, timestamp := GetRecoverShareRawValue(raw)
+
+	if from == "" || txtype == "" || timestamp == "" {
+		return false
+	}
+
+	var next *list.Element
+	for e := l.Front(); e != nil; e = next {
+		next = e.Next()
+
+		if e.Value == nil {
+			continue
+		}
+
+		s := e.Value.(string)
+
+		if s == "" {
+			continue
+		}
+
+		if strings.EqualFold(raw, s) {
+			return false
+		}
+
+		from2, txtype2, timestamp2 := GetRecoverShareRawValue(s)
+		if strings.EqualFold(from, from2) && strings.EqualFold(txtype, txtype2) {
+			t1, _ := new(big.Int).SetString(timestamp, 10)
+			t2, _ := new(big.Int).SetString(timestamp2, 10)
+			if t1.Cmp(t2) > 0 {
+				l.Remove(e)
+			} else {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// DisAcceptMsg  Collect accept data of nodes in the group, after collection, continue the MPC process
+func (req *ReqSmpcRecoverShare) DisAcceptMsg(raw string, workid int, key string) {
+	if raw == "" || workid < 0 || workid >= len(workers) || key == "" {
+		return
+	}
+
+	w := workers[workid]
+	if w == nil {
+		return
+	}
+
+	if Find(w.msgacceptrecovershareres, raw) {
+		common.Debug("======================ReqSmpcRecoverShare.DisAcceptMsg,receive one msg and already in list.===========================", "raw", raw, "key", key)
+		return
+	}
+
+	if !CheckRecoverShareDulpRawReply(raw, w.msgacceptrecovershareres) {
+		return
+	}
+
+	w.msgacceptrecovershareres.PushBack(raw)
+	notifyApprovalRaw(key, raw)
+	if w.msgacceptrecovershareres.Len() >= w.NodeCnt {
+		if !CheckReply(w.msgacceptrecovershareres, RPCRECOVERSHARE, key) {
+			common.Debug("=====================ReqSmpcRecoverShare.DisAcceptMsg,receive one msg, but Not all accept data has been received ===================", "raw", raw, "key", key)
+			return
+		}
+
+		common.Debug("=====================ReqSmpcRecoverShare.DisAcceptMsg,receive one msg,all accept data has been received===================", "raw", raw, "key", key)
+		w.bacceptrecovershareres <- true
+		exsit, da := GetRecoverShareInfoData([]byte(key))
+		if !exsit {
+			return
+		}
+
+		ac, ok := da.(*AcceptRecoverShareData)
+		if !ok || ac == nil {
+			return
+		}
+
+		common.Debug("=====================ReqSmpcRecoverShare.DisAcceptMsg,receive one msg,all accept data has been received,set acceptRecoverShareChan ===================", "raw", raw, "key", key)
+		workers[ac.WorkID].acceptRecoverShareChan <- "go on"
+	}
+}
@@ -17,6 +17,7 @@
 package smpc
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -26,6 +27,7 @@ import (
 	"container/list"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
 	"github.com/anyswap/FastMulThreshold-DSA/p2p/discover"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/fsn-dev/cryptoCoins/coins"
 	"github.com/fsn-dev/cryptoCoins/coins/types"
 	"github.com/fsn-dev/cryptoCoins/tools/rlp"
@@ -249,6 +251,17 @@ func (req *ReqSmpcReshare) DoReq(raw string, workid int, sender string, ch chan
 		w.sid = key
 		w.groupid = rh.TSGroupID
 		w.limitnum = rh.ThresHold
+		// the old nodes use the paillier key length stored with the key,the new nodes get it from the old nodes during reshare
+		w.paillierkeylength = 0
+		if smpcpks, err := hex.DecodeString(rh.PubKey); err == nil {
+			if exsit, da := GetPubKeyData(smpcpks[:]); exsit {
+				if pubs, ok := da.(*PubKeyData); ok {
+					w.paillierkeylength = getPaillierKeyLength(pubs)
+				}
+			}
+		}
+		w.signprotocol, _ = parseSignProtocol(rh.Keytype, rh.SignProtocol)
+		smpclibec2.PrepareSafePrime(w.paillierkeylength)
 		gcnt, _ := GetGroup(w.groupid)
 		w.NodeCnt = gcnt
 		w.ThresHold = w.NodeCnt
@@ -362,7 +375,7 @@ func (req *ReqSmpcReshare) DoReq(raw string, workid int, sender string, ch chan
 		}
 
 		rch := make(chan interface{}, 1)
-		_reshare(w.sid, from, rh.GroupID, rh.PubKey, rh.Account, rh.Mode, sigs, rch)
+		_reshare(w.sid, from, rh.GroupID, rh.PubKey, rh.Account, rh.Mode, sigs, rh.Keytype, rch)
 		chret, tip, cherr := GetChannelValue(cht, rch)
 		if chret != "" {
 			res2 := RPCSmpcRes{Ret: chret, Tip: "", Err: nil}
@@ -477,6 +490,17 @@ func (req *ReqSmpcReshare) CheckTxData(txdata []byte, from string, nonce uint64)
 			return "", "", "", nil, fmt.Errorf("param error")
 		}
 
+		if err := checkMode(rh.Mode); err != nil {
+			return "", "", "", nil, err
+		}
+
+		if rh.Keytype == "" {
+			rh.Keytype = "EC256K1"
+		}
+		if rh.Keytype != "EC256K1" && rh.Keytype != "EC256R1" && rh.Keytype != "ED25519" {
+			return "", "", "", nil, fmt.Errorf("invalid keytype")
+		}
+
 		nums := strings.Split(rh.ThresHold, "/")
 		if len(nums) != 2 {
 			return "", "", "", nil, fmt.Errorf("transacion data format error,threshold is not right")
@@ -498,6 +522,28 @@ func (req *ReqSmpcReshare) CheckTxData(txdata []byte, from string, nonce uint64)
 			return "", "", "", nil, fmt.Errorf("check group node count error")
 		}
 
+		signprotocol, err := parseSignProtocol(rh.Keytype, rh.SignProtocol)
+		if err != nil {
+			return "", "", "", nil, err
+		}
+
+		// the shares of SR25519 pubkey are not supported by ed reshare yet
+		if smpcpks, err := hex.DecodeString(rh.PubKey); err == nil {
+			if exsit, da := GetPubKeyData(smpcpks[:]); exsit {
+				if pubs, ok := da.(*PubKeyData); ok && getPubKeyType(pubs) == "SR25519" {
+					return "", "", "", nil, fmt.Errorf("reshare is not supported by SR25519 pubkey")
+				}
+
+				if pubs, ok := da.(*PubKeyData); ok && rh.Keytype != "ED25519" && getSignProtocol(pubs) != signprotocol {
+					return "", "", "", nil, fmt.Errorf("sign protocol %v is not the same as the one of pubkey %v", signprotocol, getSignProtocol(pubs))
+				}
+
+				if pubs, ok := da.(*PubKeyData); ok && rh.Keytype == "ED25519" && isFROST(pubs) != (signprotocol == SignProtocolFROST) {
+					return "", "", "", nil, fmt.Errorf("sign protocol %v is not the same as the one of pubkey %v", rh.SignProtocol, pubs.SignProtocol)
+				}
+			}
+		}
+
 		ato,err := strconv.Atoi(rh.AcceptTimeOut)
 		if err != nil || rh.AcceptTimeOut == "" {
 			ato = 600
@@ -649,6 +695,7 @@ func (req *ReqSmpcReshare) DisAcceptMsg(raw string, workid int, key string) {
 	}
 
 	w.msgacceptreshareres.PushBack(raw)
+	notifyApprovalRaw(key, raw)
 	if w.msgacceptreshareres.Len() >= w.NodeCnt {
 		if !CheckReply(w.msgacceptreshareres, RPCRESHARE, key) {
 			common.Debug("=====================ReqSmpcReshare.DisAcceptMsg,receive one msg, but Not all accept data has been received ===================", "raw", raw, "key", key)
@@ -380,6 +380,7 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 
 			w := workers[workid]
 			w.sid = sd.Key
+			w.blamekey = sd.MsgPrex
 			w.groupid = sd.GroupID
 
 			w.NodeCnt = sd.NodeCnt
@@ -512,6 +513,7 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 			
 			w := workers[workid]
 			w.sid = ps.Nonce
+			w.blamekey = getPreSignBlameKey(ps.Pub, ps.InputCode, ps.Gid)
 			w.groupid = ps.Gid
 			w.SmpcFrom = ps.Pub
 			gcnt, _ := GetGroup(w.groupid)
@@ -560,6 +562,20 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 				return false
 			}
 
+			keytype := getPubKeyType(pd)
+			if keytype == "EC256R1" && ps.InputCode != "" {
+				res := RPCSmpcRes{Ret: "", Tip: "bip32 is not supported by EC256R1", Err: fmt.Errorf("bip32 is not supported by EC256R1")}
+				ch <- res
+				return false
+			}
+
+			// the FROST nonces are independent of the key,the bip32 child keys use the pre-sign data of the root key
+			if keytype == "ED25519" && (!isFROST(pd) || ps.InputCode != "") {
+				res := RPCSmpcRes{Ret: "", Tip: "presign is only supported by the ED25519 root pubkey signed by FROST", Err: fmt.Errorf("presign is only supported by the ED25519 root pubkey signed by FROST")}
+				ch <- res
+				return false
+			}
+
 			childSKU1 := sku1
 			smpcpub := (da.(*PubKeyData)).Pub
 			smpcpkx, smpcpky := secp256k1.S256().Unmarshal(([]byte(smpcpub))[:])
@@ -621,7 +637,12 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 
 			var ch1 = make(chan interface{}, 1)
 			//pre := PreSignEC3(w.sid,save,sku1,"ECDSA",ch1,workid)
-			pre := PreSignEC3(w.sid, save, childSKU1, childPKx,childPKy,"EC256K1", ch1, workid)
+			var pre *PreSignData
+			if keytype == "ED25519" {
+				pre = PreSignFROST(w.sid, save, ps.Pub, ch1, workid)
+			} else {
+				pre = PreSignEC3(w.sid, save, childSKU1, childPKx,childPKy,keytype, ch1, workid)
+			}
 			if pre == nil {
 				common.Info("============================PreSign at RecvMsg.Run, failed to generate the presign data this time ==========================", "pubkey", ps.Pub, "gid", ps.Gid, "presign data key", w.sid, "err", "return result is nil")
 				if syncpresign && !SynchronizePreSignData(w.sid, w.id, false) {
@@ -700,8 +721,9 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 			}
 			
 			pickdata := make([]*PickHashData, 0)
+			preinputcode := getPreSignInputCode(sig.PubKey, sig.InputCode)
 			for _, vv := range signbrocast.PickHash {
-				pre := GetPreSignData(sig.PubKey, sig.InputCode, sig.GroupID, vv.PickKey)
+				pre := GetPreSignData(sig.PubKey, preinputcode, sig.GroupID, vv.PickKey)
 				if pre == nil {
 				    fmt.Printf("============================PreSign at RecvMsg.Run,get pre-sign data fail============================\n")
 				    res := RPCSmpcRes{Ret: "", Tip: "", Err: fmt.Errorf("get pre-sign data fail")}
@@ -711,7 +733,7 @@ func (req *ReqSmpcSign) DoReq(raw string, workid int, sender string, ch chan int
 
 				pd := &PickHashData{Hash: vv.Hash, Pre: pre}
 				pickdata = append(pickdata, pd)
-				err = DeletePreSignData(sig.PubKey, sig.InputCode, sig.GroupID, vv.PickKey)
+				err = DeletePreSignData(sig.PubKey, preinputcode, sig.GroupID, vv.PickKey)
 				if err != nil {
 				    fmt.Printf("============================PreSign at RecvMsg.Run,delete pre-sign data fail,err = %v============================\n",err)
 				    res := RPCSmpcRes{Ret: "", Tip: "", Err: err}
@@ -884,6 +906,10 @@ func (req *ReqSmpcSign) CheckTxData(txdata []byte, from string, nonce uint64) (s
 			return "", "", "", nil, fmt.Errorf("param error from raw data")
 		}
 
+		if err := checkMode(mode); err != nil {
+			return "", "", "", nil, err
+		}
+
 		//check input code
 		if inputcode != "" {
 			indexs := strings.Split(inputcode, "/")
@@ -893,10 +919,30 @@ func (req *ReqSmpcSign) CheckTxData(txdata []byte, from string, nonce uint64) (s
 		}
 		//
 
-		if keytype != "EC256K1" && keytype != "ED25519" {
+		if keytype != "EC256K1" && keytype != "EC256R1" && keytype != "ED25519" && keytype != "SR25519" && keytype != "SCHNORR256K1" {
 		    return "","","",nil,fmt.Errorf("invalid keytype")
 		}
 
+		if keytype == "EC256R1" && inputcode != "" {
+			return "", "", "", nil, fmt.Errorf("bip32 is not supported by EC256R1 sign")
+		}
+
+		if keytype == "SR25519" && inputcode != "" {
+			return "", "", "", nil, fmt.Errorf("bip32 is not supported by SR25519 sign")
+		}
+
+		if keytype == "SCHNORR256K1" {
+			if inputcode != "" {
+				return "", "", "", nil, fmt.Errorf("bip32 is not supported by schnorr sign")
+			}
+
+			if _, err := getTapTweak(sig.TapTweak); err != nil {
+				return "", "", "", nil, err
+			}
+		} else if sig.TapTweak != "" {
+			return "", "", "", nil, fmt.Errorf("taptweak is only supported by schnorr sign")
+		}
+
 		nums := strings.Split(threshold, "/")
 		if len(nums) != 2 {
 			return "", "", "", nil, fmt.Errorf("threshold is not right")
@@ -943,6 +989,28 @@ func (req *ReqSmpcSign) CheckTxData(txdata []byte, from string, nonce uint64) (s
 			return "", "", "", nil, fmt.Errorf("can not sign with different mode in pubkey")
 		}
 
+		if keytype == "SCHNORR256K1" && getPubKeyType(pubs) != "EC256K1" {
+			return "", "", "", nil, fmt.Errorf("schnorr sign need EC256K1 pubkey")
+		}
+
+		if keytype == "EC256K1" && getPubKeyType(pubs) != "EC256K1" {
+			return "", "", "", nil, fmt.Errorf("keytype is not match the pubkey")
+		}
+
+		if keytype == "EC256R1" && getPubKeyType(pubs) != "EC256R1" {
+			return "", "", "", nil, fmt.Errorf("EC256R1 sign need EC256R1 pubkey")
+		}
+
+		if (keytype == "SR25519") != (getPubKeyType(pubs) == "SR25519") {
+			return "", "", "", nil, fmt.Errorf("keytype is not match the pubkey")
+		}
+
+		if keytype == "ED25519" && inputcode != "" {
+			if _, _, err := getEdBip32ChildKey(smpcpks[:], inputcode); err != nil {
+				return "", "", "", nil, err
+			}
+		}
+
 		if len(sig.MsgContext) > 16 {
 			return "", "", "", nil, fmt.Errorf("msgcontext counts must <= 16")
 		}
@@ -1164,6 +1232,7 @@ func (req *ReqSmpcSign) DisAcceptMsg(raw string, workid int, key string) {
 	}
 
 	w.msgacceptsignres.PushBack(raw)
+	notifyApprovalRaw(key, raw)
 	/////fix bug: miss accept msg for 7-11 test
 	SendMsgToSmpcGroup(raw, ac.GroupID)
 	/////
@@ -0,0 +1,432 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package backup the passphrase-encrypted file format of the key material backup of a node
+// The backup data is encrypted by a random data key with AES-256-GCM,the data key is encrypted by the key derived from the passphrase with scrypt.
+// The data key can be shamir-split among custodians,every custodian gets a file with the same encrypted backup data and one share of the data key.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common/math/random"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version the version of the backup file format
+	Version = 1
+
+	scryptR     = 8
+	scryptP     = 1
+	scryptDKLen = 32
+
+	// the bounds of the scrypt parameters read from a backup file,a file out of them is rejected before deriving the key
+	scryptMinN = 1 << 15
+	scryptMaxN = 1 << 20
+	scryptMaxR = 32
+	scryptMaxP = 16
+)
+
+var (
+	// ScryptN the scrypt N parameter used for new backup files,a power of 2 in [1<<15,1<<20]
+	ScryptN = 1 << 18
+)
+
+// KDFParams the parameters of deriving the key from the passphrase
+type KDFParams struct {
+	Name string // scrypt
+	N    int
+	R    int
+	P    int
+	Salt string
+}
+
+// check the kdf is scrypt and its cost parameters are within the fixed bounds,
+// so that a crafted file can not make Open use unbounded cpu or memory
+func (k *KDFParams) check() error {
+	if k.Name != "scrypt" {
+		return fmt.Errorf("unsupported kdf %v", k.Name)
+	}
+
+	if k.N < scryptMinN || k.N > scryptMaxN || k.N&(k.N-1) != 0 {
+		return fmt.Errorf("invalid scrypt N %v,it must be a power of 2 in [%v,%v]", k.N, scryptMinN, scryptMaxN)
+	}
+
+	if k.R < 1 || k.R > scryptMaxR {
+		return fmt.Errorf("invalid scrypt r %v,it must be in [1,%v]", k.R, scryptMaxR)
+	}
+
+	if k.P < 1 || k.P > scryptMaxP {
+		return fmt.Errorf("invalid scrypt p %v,it must be in [1,%v]", k.P, scryptMaxP)
+	}
+
+	return nil
+}
+
+// File one backup file
+// all the parts of a split backup have the same ID,metadata and Data,but different Index and Key
+type File struct {
+	Version    int
+	ID         string // random id shared by all the parts of one backup
+	Enode      string // the enode id of the node that the key material belongs to
+	Count      int    // the count of pubkeys in the backup
+	CreateTime string
+	Checksum   string // hex of the sha256 of the backup data
+
+	Threshold int // the count of parts needed to restore the backup,0 if the backup is not split
+	Total     int // the count of parts,0 if the backup is not split
+	Index     int // the x coordinate of the shamir share of the data key,1...Total
+
+	KDF      KDFParams
+	KeyNonce string // hex of the nonce of encrypting the data key
+	Key      string // hex of the data key(or its shamir share) encrypted with the passphrase
+
+	Nonce string // hex of the nonce of encrypting the backup data
+	Data  string // hex of the backup data encrypted with the data key
+}
+
+// header the metadata bound to the encrypted backup data,changing any of them makes the decryption fail
+func (f *File) header() []byte {
+	return []byte(fmt.Sprintf("%v:%v:%v:%v:%v:%v:%v:%v", f.Version, f.ID, f.Enode, f.Count, f.CreateTime, f.Checksum, f.Threshold, f.Total))
+}
+
+// keyHeader the metadata bound to the encrypted data key
+func (f *File) keyHeader() []byte {
+	return append(f.header(), []byte(":"+strconv.Itoa(f.Index))...)
+}
+
+// Seal encrypt the backup data of the node enode,count is the count of pubkeys in data
+// if total is 0,one file is returned and passphrases[0] is used;
+// otherwise the data key is split into total parts,threshold of them can restore the backup,
+// part i is encrypted with passphrases[i],or passphrases[0] if only one passphrase is given.
+func Seal(data []byte, enode string, count int, passphrases []string, threshold int, total int) ([][]byte, error) {
+	if len(data) == 0 || len(passphrases) == 0 {
+		return nil, errors.New("param error")
+	}
+
+	if total != 0 && (threshold < 2 || threshold > total) {
+		return nil, errors.New("threshold must > 1 and <= total")
+	}
+
+	parts := total
+	if parts == 0 {
+		parts = 1
+	}
+
+	if len(passphrases) != 1 && len(passphrases) != parts {
+		return nil, errors.New("the count of passphrases must be 1 or equal to the count of parts")
+	}
+
+	for _, v := range passphrases {
+		if v == "" {
+			return nil, errors.New("passphrase is empty")
+		}
+	}
+
+	kdf := KDFParams{Name: "scrypt", N: ScryptN, R: scryptR, P: scryptP}
+	if err := kdf.check(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(data)
+	tmpl := File{
+		Version:    Version,
+		ID:         hex.EncodeToString(id),
+		Enode:      enode,
+		Count:      count,
+		CreateTime: fmt.Sprintf("%v", time.Now().Unix()),
+		Checksum:   hex.EncodeToString(checksum[:]),
+		Threshold:  threshold,
+		Total:      total,
+	}
+
+	curve := secp256k1.S256()
+	k := random.GetRandomIntFromZn(curve.Params().N)
+	nonce, enc, err := encrypt(toKey(k), data, tmpl.header())
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Nonce = hex.EncodeToString(nonce)
+	tmpl.Data = hex.EncodeToString(enc)
+
+	keys := []*big.Int{k}
+	if total != 0 {
+		poly, _, err := ec2.Vss2Init(curve, k, threshold)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]*big.Int, total)
+		for i := range ids {
+			ids[i] = big.NewInt(int64(i + 1))
+		}
+
+		shares, err := poly.Vss2(curve, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = keys[:0]
+		for _, v := range shares {
+			keys = append(keys, v.Share)
+		}
+	}
+
+	out := make([][]byte, 0, parts)
+	for i, key := range keys {
+		f := tmpl
+		if total != 0 {
+			f.Index = i + 1
+		}
+
+		passphrase := passphrases[0]
+		if len(passphrases) != 1 {
+			passphrase = passphrases[i]
+		}
+
+		salt := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+		f.KDF = kdf
+		f.KDF.Salt = hex.EncodeToString(salt)
+
+		pk, err := scrypt.Key([]byte(passphrase), salt, f.KDF.N, f.KDF.R, f.KDF.P, scryptDKLen)
+		if err != nil {
+			return nil, err
+		}
+
+		keyk := toKey(key)
+		knonce, kenc, err := encrypt(pk, keyk, f.keyHeader())
+		if err != nil {
+			return nil, err
+		}
+		f.KeyNonce = hex.EncodeToString(knonce)
+		f.Key = hex.EncodeToString(kenc)
+
+		b, err := json.MarshalIndent(&f, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+
+	return out, nil
+}
+
+// Open decrypt the backup,files[i] is decrypted with passphrases[i],or passphrases[0] if only one passphrase is given
+// the files must be the same backup,and at least Threshold parts are needed if the backup is split
+// return the backup data and the metadata of the first file
+func Open(files [][]byte, passphrases []string) ([]byte, *File, error) {
+	if len(files) == 0 || len(passphrases) == 0 {
+		return nil, nil, errors.New("param error")
+	}
+
+	if len(passphrases) != 1 && len(passphrases) != len(files) {
+		return nil, nil, errors.New("the count of passphrases must be 1 or equal to the count of files")
+	}
+
+	fs := make([]*File, 0, len(files))
+	for _, b := range files {
+		f := &File{}
+		if err := json.Unmarshal(b, f); err != nil {
+			return nil, nil, errors.New("invalid backup file")
+		}
+
+		if f.Version != Version {
+			return nil, nil, fmt.Errorf("unsupported backup file version %v", f.Version)
+		}
+
+		if err := f.KDF.check(); err != nil {
+			return nil, nil, err
+		}
+
+		if len(fs) != 0 && (f.ID != fs[0].ID || f.Data != fs[0].Data || f.Nonce != fs[0].Nonce) {
+			return nil, nil, errors.New("the files are not the parts of the same backup")
+		}
+
+		fs = append(fs, f)
+	}
+
+	first := fs[0]
+	if first.Total == 0 && len(fs) != 1 {
+		return nil, nil, errors.New("the backup is not split,only one file is needed")
+	}
+
+	if first.Total != 0 && len(fs) < first.Threshold {
+		return nil, nil, fmt.Errorf("at least %v parts are needed to restore the backup", first.Threshold)
+	}
+
+	curve := secp256k1.S256()
+	shares := make([]*ec2.ShareStruct2, 0, len(fs))
+	var k *big.Int
+	for i, f := range fs {
+		passphrase := passphrases[0]
+		if len(passphrases) != 1 {
+			passphrase = passphrases[i]
+		}
+
+		key, err := openKey(f, passphrase)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decrypt the data key of file %v fail: %v", i, err)
+		}
+
+		if first.Total == 0 {
+			k = key
+			break
+		}
+
+		if f.Index < 1 || f.Index > f.Total {
+			return nil, nil, errors.New("invalid part index")
+		}
+
+		for _, v := range shares {
+			if v.ID.Int64() == int64(f.Index) {
+				return nil, nil, errors.New("duplicate part")
+			}
+		}
+
+		shares = append(shares, &ec2.ShareStruct2{ID: big.NewInt(int64(f.Index)), Share: key})
+	}
+
+	if first.Total != 0 {
+		var err error
+		k, err = ec2.Combine2(curve, shares)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	nonce, err := hex.DecodeString(first.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enc, err := hex.DecodeString(first.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := decrypt(toKey(k), nonce, enc, first.header())
+	if err != nil {
+		return nil, nil, errors.New("decrypt the backup data fail,the parts do not match or the file is damaged")
+	}
+
+	checksum := sha256.Sum256(data)
+	if hex.EncodeToString(checksum[:]) != first.Checksum {
+		return nil, nil, errors.New("checksum mismatch")
+	}
+
+	return data, first, nil
+}
+
+// openKey decrypt the data key(or its shamir share) of the file with passphrase
+func openKey(f *File, passphrase string) (*big.Int, error) {
+	if err := f.KDF.check(); err != nil {
+		return nil, err
+	}
+
+	salt, err := hex.DecodeString(f.KDF.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := scrypt.Key([]byte(passphrase), salt, f.KDF.N, f.KDF.R, f.KDF.P, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+
+	knonce, err := hex.DecodeString(f.KeyNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	kenc, err := hex.DecodeString(f.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := decrypt(pk, knonce, kenc, f.keyHeader())
+	if err != nil {
+		return nil, errors.New("wrong passphrase or the file is damaged")
+	}
+
+	return new(big.Int).SetBytes(key), nil
+}
+
+// toKey get the 32 bytes AES-256 key from k
+func toKey(k *big.Int) []byte {
+	key := make([]byte, 32)
+	b := k.Bytes()
+	copy(key[32-len(b):], b)
+	return key
+}
+
+func encrypt(key []byte, plaintext []byte, ad []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+
+	return nonce, gcm.Seal(nil, nonce, plaintext, ad), nil
+}
+
+func decrypt(key []byte, nonce []byte, ciphertext []byte, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+
+	return gcm.Open(nil, nonce, ciphertext, ad)
+}
@@ -0,0 +1,115 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package backup_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc/backup"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// the smallest N that Open accepts,keep the tests fast
+	backup.ScryptN = 1 << 15
+}
+
+func TestSealOpen(t *testing.T) {
+	data := []byte("key material")
+	files, err := backup.Seal(data, "enode", 1, []string{"pass"}, 0, 0)
+	if !assert.NoError(t, err) || !assert.Equal(t, 1, len(files)) {
+		return
+	}
+
+	out, f, err := backup.Open(files, []string{"pass"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, data, out)
+		assert.Equal(t, "enode", f.Enode)
+		assert.Equal(t, 1, f.Count)
+	}
+
+	_, _, err = backup.Open(files, []string{"wrong"})
+	assert.Error(t, err, "wrong passphrase")
+
+	// the metadata is bound to the encrypted data
+	tampered := &backup.File{}
+	assert.NoError(t, json.Unmarshal(files[0], tampered))
+	tampered.Enode = "other"
+	b, _ := json.Marshal(tampered)
+	_, _, err = backup.Open([][]byte{b}, []string{"pass"})
+	assert.Error(t, err, "tampered metadata")
+}
+
+func TestSealOpenSplit(t *testing.T) {
+	data := []byte("key material")
+	passes := []string{"p1", "p2", "p3"}
+	files, err := backup.Seal(data, "enode", 2, passes, 2, 3)
+	if !assert.NoError(t, err) || !assert.Equal(t, 3, len(files)) {
+		return
+	}
+
+	out, _, err := backup.Open([][]byte{files[2], files[0]}, []string{passes[2], passes[0]})
+	if assert.NoError(t, err) {
+		assert.Equal(t, data, out)
+	}
+
+	_, _, err = backup.Open([][]byte{files[1]}, []string{passes[1]})
+	assert.Error(t, err, "less than threshold parts")
+
+	_, _, err = backup.Open([][]byte{files[1], files[1]}, []string{passes[1]})
+	assert.Error(t, err, "duplicate parts")
+
+	other, err := backup.Seal(data, "enode", 2, passes, 2, 3)
+	if assert.NoError(t, err) {
+		_, _, err = backup.Open([][]byte{files[0], other[1]}, []string{passes[0], passes[1]})
+		assert.Error(t, err, "parts of different backups")
+	}
+}
+
+func TestOpenKDFBounds(t *testing.T) {
+	files, err := backup.Seal([]byte("key material"), "enode", 1, []string{"pass"}, 0, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the scrypt parameters are read from the file,out of bounds values are rejected before deriving the key
+	for _, kdf := range []backup.KDFParams{
+		{Name: "pbkdf2", N: 1 << 15, R: 8, P: 1},
+		{Name: "scrypt", N: 1 << 14, R: 8, P: 1},
+		{Name: "scrypt", N: 1 << 21, R: 8, P: 1},
+		{Name: "scrypt", N: 1<<15 + 1, R: 8, P: 1},
+		{Name: "scrypt", N: 1 << 15, R: 0, P: 1},
+		{Name: "scrypt", N: 1 << 15, R: 33, P: 1},
+		{Name: "scrypt", N: 1 << 15, R: 8, P: 0},
+		{Name: "scrypt", N: 1 << 15, R: 8, P: 17},
+	} {
+		f := &backup.File{}
+		assert.NoError(t, json.Unmarshal(files[0], f))
+		kdf.Salt = f.KDF.Salt
+		f.KDF = kdf
+		b, _ := json.Marshal(f)
+		_, _, err = backup.Open([][]byte{b}, []string{"pass"})
+		assert.Error(t, err, "kdf %+v", kdf)
+	}
+
+	old := backup.ScryptN
+	backup.ScryptN = 1 << 21
+	_, err = backup.Seal([]byte("key material"), "enode", 1, []string{"pass"}, 0, 0)
+	backup.ScryptN = old
+	assert.Error(t, err, "ScryptN out of bounds")
+}
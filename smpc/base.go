@@ -163,6 +163,15 @@ func Encode2(obj interface{}) (string, error) {
 		var buff bytes.Buffer
 		enc := gob.NewEncoder(&buff)
 
+		err1 := enc.Encode(ch)
+		if err1 != nil {
+			return "", err1
+		}
+		return buff.String(), nil
+	case *AcceptRecoverShareData:
+		var buff bytes.Buffer
+		enc := gob.NewEncoder(&buff)
+
 		err1 := enc.Encode(ch)
 		if err1 != nil {
 			return "", err1
@@ -234,6 +243,21 @@ func Decode2(s string, datatype string) (interface{}, error) {
 		return &res, nil
 	}
 
+	if datatype == "AcceptRecoverShareData" {
+		var data bytes.Buffer
+		data.Write([]byte(s))
+
+		dec := gob.NewDecoder(&data)
+
+		var res AcceptRecoverShareData
+		err := dec.Decode(&res)
+		if err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+
 	return nil, fmt.Errorf("decode fail")
 }
 
@@ -341,7 +365,7 @@ func DoubleHash(id string, keytype string) *big.Int {
 		return nil
 	}
 
-	if keytype == "ED25519" {
+	if keytype == "ED25519" || keytype == "SR25519" {
 		var digest [32]byte
 		copy(digest[:], sha3256.Sum(nil))
 
@@ -451,6 +475,10 @@ func GetTxTypeFromData(txdata []byte) string {
 		return "REQSMPCADDR"
 	}
 
+	if err == nil && req.TxType == "IMPORTKEY" {
+		return "IMPORTKEY"
+	}
+
 	sig := TxDataSign{}
 	err = json.Unmarshal(txdata, &sig)
 	if err == nil && sig.TxType == "SIGN" {
@@ -469,6 +497,12 @@ func GetTxTypeFromData(txdata []byte) string {
 		return "RESHARE"
 	}
 
+	rs := TxDataRecoverShare{}
+	err = json.Unmarshal(txdata, &rs)
+	if err == nil && (rs.TxType == "RECOVERSHARE" || rs.TxType == "REFRESH") {
+		return rs.TxType
+	}
+
 	acceptreq := TxDataAcceptReqAddr{}
 	err = json.Unmarshal(txdata, &acceptreq)
 	if err == nil && acceptreq.TxType == "ACCEPTREQADDR" {
@@ -487,6 +521,12 @@ func GetTxTypeFromData(txdata []byte) string {
 		return "ACCEPTRESHARE"
 	}
 
+	acceptrs := TxDataAcceptRecoverShare{}
+	err = json.Unmarshal(txdata, &acceptrs)
+	if err == nil && acceptrs.TxType == "ACCEPTRECOVERSHARE" {
+		return "ACCEPTRECOVERSHARE"
+	}
+
 	return ""
 }
 
@@ -513,6 +553,8 @@ func CheckRaw(raw string) (string, string, string, interface{}, error) {
 	switch txtype {
 	case "REQSMPCADDR":
 		smpcreq = &ReqSmpcAddr{}
+	case "IMPORTKEY":
+		smpcreq = &ReqSmpcAddr{}
 	case "SIGN":
 		smpcreq = &ReqSmpcSign{}
 	case "PRESIGNDATA":
@@ -525,6 +567,12 @@ func CheckRaw(raw string) (string, string, string, interface{}, error) {
 		smpcreq = &ReqSmpcSign{}
 	case "ACCEPTRESHARE":
 		smpcreq = &ReqSmpcReshare{}
+	case "RECOVERSHARE":
+		smpcreq = &ReqSmpcRecoverShare{}
+	case "REFRESH":
+		smpcreq = &ReqSmpcRecoverShare{}
+	case "ACCEPTRECOVERSHARE":
+		smpcreq = &ReqSmpcRecoverShare{}
 	default:
 		return "", "", "", nil, fmt.Errorf("Unsupported request type")
 	}
@@ -0,0 +1,238 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+var blameLock sync.Mutex
+
+// BlameRecord the record of a node that failed a check during the sign calculation,it is signed by the reporting node
+type BlameRecord struct {
+	Key          string // the key of the sign command,or the pre-sign blame key of (pubkey,inputcode,gid) for pre-sign
+	Culprit      string // DNodeID of the misbehaving node
+	CulpritENode string
+	Round        int
+	Proof        string
+	Reporter     string // enodeID of the reporting node
+	TimeStamp    string
+	Sig          string
+}
+
+// getBlameRecordHash get the hash of the blame record without the sig
+func getBlameRecordHash(br *BlameRecord) ([]byte, error) {
+	if br == nil {
+		return nil, errors.New("param error")
+	}
+
+	tmp := *br
+	tmp.Sig = ""
+	s, err := json.Marshal(&tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256(s), nil
+}
+
+// signBlameRecord sign the blame record with the private key of current node
+func signBlameRecord(br *BlameRecord) error {
+	priv, err := getNodePrivate(KeyFile)
+	if err != nil {
+		return err
+	}
+
+	hash, err := getBlameRecordHash(br)
+	if err != nil {
+		return err
+	}
+
+	sig, err := crypto.Sign(hash, priv)
+	if err != nil {
+		return err
+	}
+
+	br.Sig = hex.EncodeToString(sig)
+	return nil
+}
+
+// VerifyBlameRecord check whether the blame record is signed by the reporting node
+func VerifyBlameRecord(br *BlameRecord) bool {
+	if br == nil || br.Sig == "" || br.Reporter == "" {
+		return false
+	}
+
+	sig, err := hex.DecodeString(br.Sig)
+	if err != nil {
+		return false
+	}
+
+	hash, err := getBlameRecordHash(br)
+	if err != nil {
+		return false
+	}
+
+	public, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return false
+	}
+
+	pub := hex.EncodeToString(secp256k1.S256().Marshal(public.X, public.Y))
+	return strings.EqualFold(pub[2:], br.Reporter)
+}
+
+// newBlameRecords create the signed blame records from the blames returned by the dnode
+// gid is the `keygen gid`,groupid is the group taking part in the calculation
+func newBlameRecords(key string, keytype string, gid string, groupid string, blames []*smpclib.Blame) []*BlameRecord {
+	if key == "" || len(blames) == 0 {
+		return nil
+	}
+
+	msgtoenode := GetMsgToEnode(keytype, gid, groupid)
+	records := make([]*BlameRecord, 0)
+	for _, b := range blames {
+		if b == nil {
+			continue
+		}
+
+		br := &BlameRecord{
+			Key:          key,
+			Culprit:      b.Culprit,
+			CulpritENode: msgtoenode[b.Culprit],
+			Round:        b.Round,
+			Proof:        b.Proof,
+			Reporter:     curEnode,
+			TimeStamp:    common.CurrentTime(),
+		}
+
+		if err := signBlameRecord(br); err != nil {
+			common.Error("=====================newBlameRecords,sign blame record fail=====================", "key", key, "culprit", b.Culprit, "err", err)
+			continue
+		}
+
+		records = append(records, br)
+	}
+
+	return records
+}
+
+// getBlameKey the key of blame records in general database
+func getBlameKey(key string) []byte {
+	return []byte(Keccak256Hash([]byte(strings.ToLower("BLAME:" + key))).Hex())
+}
+
+// GetBlameRecords get all the blame records of the key from general database
+func GetBlameRecords(key string) []*BlameRecord {
+	if key == "" || db == nil {
+		return nil
+	}
+
+	da, err := db.Get(getBlameKey(key))
+	if da == nil || err != nil {
+		return nil
+	}
+
+	ss, err := UnCompress(string(da))
+	if err != nil {
+		return nil
+	}
+
+	var records []*BlameRecord
+	if err := json.Unmarshal([]byte(ss), &records); err != nil {
+		return nil
+	}
+
+	return records
+}
+
+// PutBlameRecords append the blame records of the key to general database
+func PutBlameRecords(key string, records []*BlameRecord) error {
+	if key == "" || len(records) == 0 {
+		return fmt.Errorf("param error")
+	}
+
+	blameLock.Lock()
+	defer blameLock.Unlock()
+
+	all := append(GetBlameRecords(key), records...)
+	s, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	ss, err := Compress(s)
+	if err != nil {
+		return err
+	}
+
+	return PutPubKeyData(getBlameKey(key), []byte(ss))
+}
+
+// getPreSignBlameKey the key that the blame records of the pre-sign of (pubkey,inputcode,gid) are saved under
+func getPreSignBlameKey(pubkey string, inputcode string, gid string) string {
+	if inputcode != "" {
+		return Keccak256Hash([]byte(strings.ToLower(pubkey + ":" + inputcode + ":" + gid))).Hex()
+	}
+
+	return Keccak256Hash([]byte(strings.ToLower(pubkey + ":" + gid))).Hex()
+}
+
+// attachPreSignBlames save the blame records of the pre-sign of (pubkey,inputcode,gid) under the key of the sign command,
+// so that GetSignStatus shows the nodes that make the pre-sign fail when there is no pre-sign data for the sign command
+func attachPreSignBlames(key string, pubkey string, inputcode string, gid string) {
+	records := GetBlameRecords(getPreSignBlameKey(pubkey, inputcode, gid))
+	if len(records) == 0 {
+		return
+	}
+
+	if err := PutBlameRecords(key, records); err != nil {
+		common.Error("=====================attachPreSignBlames,put blame records to db fail=====================", "key", key, "pubkey", pubkey, "gid", gid, "err", err)
+	}
+}
+
+// saveBlame save the blame records if the error returned by the dnode blames some nodes
+func saveBlame(key string, keytype string, gid string, groupid string, err error) {
+	blames := smpclib.GetBlames(err)
+	if len(blames) == 0 {
+		return
+	}
+
+	records := newBlameRecords(key, keytype, gid, groupid, blames)
+	if len(records) == 0 {
+		return
+	}
+
+	if err := PutBlameRecords(key, records); err != nil {
+		common.Error("=====================saveBlame,put blame records to db fail=====================", "key", key, "err", err)
+		return
+	}
+
+	for _, br := range records {
+		common.Info("=====================saveBlame,node blamed=====================", "key", br.Key, "culprit", br.Culprit, "culprit enode", br.CulpritENode, "round", br.Round, "proof", br.Proof)
+	}
+}
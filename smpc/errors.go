@@ -16,72 +16,87 @@
 
 package smpc
 
+import (
+	"errors"
+	"fmt"
+)
+
+// retErrs the errors of the error info below by the error info
+var retErrs = make(map[string]*RetError)
+
+// retErrInfo register the error with code and msg,return its error info
+func retErrInfo(code int, msg string) string {
+	info := fmt.Sprintf(`{Code:%v,Error:"%v"}`, code, msg)
+	retErrs[info] = &RetError{Code: code, Message: msg, Info: info}
+	return info
+}
+
 // smpc erros
 var (
 	//err code 1
-	ErrEncodeSendMsgFail      = `{Code:1,Error:"encode send msg fail."}`
-	ErrParamError             = `{Code:2,Error:"parameters error."}`
-	ErrGetOtherNodesDataFail  = `{Code:3,Error:"NetWork Error,Get Data From Other Node Fail."}`
-	ErrUnknownChType          = `{Code:4,Error:"unknown channel type."}`
-	ErrGetChValueFail         = `{Code:5,Error:"get channel value fail."}`
-	ErrNoFindWorker           = `{Code:7,Error:"can not find worker."}`
-	ErrGetWorkerIDError       = `{Code:10,Error:"get worker id error."}`
-	ErrGetPrexDataError       = `{Code:11,Error:"get msg prefix data error."}`
-	ErrSendDataToGroupFail    = `{Code:15,Error:"send data to group fail."}`
-	ErrInternalMsgFormatError = `{Code:16,Error:"msg data format error."}`
-	ErrGetNoResFromGroupMem   = `{Code:17,Error:"no get any result from other group node."}`
-	ErrCoinTypeNotSupported   = `{Code:18,Error:"coin type is not supported."}`
-	ErrGroupNotReady          = `{Code:23,Error:"the group is not ready.please try again."}`
-	ErrGetGenPubkeyFail       = `{Code:24,Error:"get generate pubkey fail."}`
-	ErrGetGenSaveDataFail     = `{Code:25,Error:"get generate save data fail."}`
-	ErrCreateDbFail           = `{Code:26,Error:"create db fail."}`
-	ErrSmpcSigWrongSize       = `{Code:28,Error:"wrong size for smpc sig."}`
-	ErrSmpcSigFail            = `{Code:29,Error:"smpc sign fail."}`
-	ErrInvalidSmpcAddr        = `{Code:30,Error:"invalid smpc address."}`
-	ErrGetRealEosUserFail     = `{Code:27,Error:"cannot get real eos account."}`
-	ErrSendTxToNetFail        = `{Code:14,Error:"send tx to outside net fail."}`
-	ErrGetC1Timeout           = `{Code:31,Error:"get C1 timeout."}`
-	ErrGetEnodeByUIDFail      = `{Code:32,Error:"can not find proper enodes by uid."}`
-	ErrGetD1Timeout           = `{Code:33,Error:"get D1 timeout."}`
-	ErrGetSHARE1Timeout       = `{Code:34,Error:"get SHARE1 timeout."}`
-	ErrGetAllSHARE1Fail       = `{Code:35,Error:"get all SHARE1 msg fail."}`
-	ErrGetAllD1Fail           = `{Code:36,Error:"get all D1 msg fail."}`
-	ErrVerifySHARE1Fail       = `{Code:37,Error:"verify SHARE1 fail."}`
-	ErrGetAllC1Fail           = `{Code:38,Error:"get all C1 msg fail."}`
-	ErrKeyGenVerifyCommitFail = `{Code:39,Error:"verify commit in keygenerate fail."}`
-	ErrGetZKFACTPROOFTimeout  = `{Code:40,Error:""get ZKFACTPROOF timeout."}`
-	ErrGetZKUPROOFTimeout     = `{Code:41,Error:""get ZKUPROOF timeout."}`
-	ErrGetAllZKFACTPROOFFail  = `{Code:42,Error:"get all ZKFACTPROOF msg fail."}`
-	ErrVerifyZKFACTPROOFFail  = `{Code:43,Error:"verify ZKFACTPROOF fail."}`
-	ErrGetAllZKUPROOFFail     = `{Code:44,Error:"get all ZKUPROOF msg fail."}`
-	ErrVerifyZKUPROOFFail     = `{Code:45,Error:"verify ZKUPROOF fail."}`
-	ErrGetC11Timeout          = `{Code:46,Error:"get C11 timeout."}`
-	ErrGetMTAZK1PROOFTimeout  = `{Code:47,Error:"get MTAZK1PROOF timeout."}`
-	ErrGetKCTimeout           = `{Code:48,Error:"get KC timeout."}`
-	ErrGetAllKCFail           = `{Code:49,Error:"get all KC msg fail."}`
-	ErrGetAllMTAZK1PROOFFail  = `{Code:50,Error:"get all MTAZK1PROOF msg fail."}`
-	ErrVerifyMTAZK1PROOFFail  = `{Code:51,Error:"verify MTAZK1PROOF fail.""}`
-	ErrGetMKGTimeout          = `{Code:52,Error:"get MKG timeout."}`
-	ErrGetAllMKGFail          = `{Code:53,Error:"get all MKG msg fail."}`
-	ErrGetMKWTimeout          = `{Code:54,Error:"get MKW timeout."}`
-	ErrGetAllMKWFail          = `{Code:55,Error:"get all MKW msg fail."}`
-	ErrVerifyMKGFail          = `{Code:56,Error:"verify MKG fail.""}`
-	ErrVerifyMKWFail          = `{Code:57,Error:"verify MKW fail.""}`
-	ErrGetPaillierPrivKeyFail = `{Code:58,Error:"get paillier privkey fail.""}`
-	ErrGetDELTA1Timeout       = `{Code:59,Error:"get DELTA1 timeout."}`
-	ErrGetAllDELTA1Fail       = `{Code:60,Error:"get all DELTA1 msg fail."}`
-	ErrGetD11Timeout          = `{Code:61,Error:"get D11 timeout."}`
-	ErrGetAllD11Fail          = `{Code:62,Error:"get all D11 msg fail."}`
-	ErrGetAllC11Fail          = `{Code:63,Error:"get all C11 msg fail."}`
-	ErrSignVerifyCommitFail   = `{Code:64,Error:"verify commit in smpc sign fail."}`
-	ErrREqualZero             = `{Code:65,Error:"sign error: r equal zero."}`
-	ErrGetS1Timeout           = `{Code:66,Error:"get S1 timeout."}`
-	ErrGetAllS1Fail           = `{Code:67,Error:"get all S1 msg fail."}`
-	ErrVerifySAllFail         = `{Code:68,Error:"verify SAll != m*G + r*PK in smpc sign ec2."}`
-	ErrGetSS1Timeout          = `{Code:69,Error:"get SS1 timeout."}`
-	ErrGetAllSS1Fail          = `{Code:70,Error:"get all SS1 msg fail."}`
-	ErrSEqualZero             = `{Code:71,Error:"sign error: s equal zero."}`
-	ErrSmpcSignVerifyFail     = `{Code:72,Error:"smpc sign verify fail."}`
+	ErrEncodeSendMsgFail      = retErrInfo(1, "encode send msg fail.")
+	ErrParamError             = retErrInfo(2, "parameters error.")
+	ErrGetOtherNodesDataFail  = retErrInfo(3, "NetWork Error,Get Data From Other Node Fail.")
+	ErrUnknownChType          = retErrInfo(4, "unknown channel type.")
+	ErrGetChValueFail         = retErrInfo(5, "get channel value fail.")
+	ErrNoFindWorker           = retErrInfo(7, "can not find worker.")
+	ErrGetWorkerIDError       = retErrInfo(10, "get worker id error.")
+	ErrGetPrexDataError       = retErrInfo(11, "get msg prefix data error.")
+	ErrSendDataToGroupFail    = retErrInfo(15, "send data to group fail.")
+	ErrInternalMsgFormatError = retErrInfo(16, "msg data format error.")
+	ErrGetNoResFromGroupMem   = retErrInfo(17, "no get any result from other group node.")
+	ErrCoinTypeNotSupported   = retErrInfo(18, "coin type is not supported.")
+	ErrGroupNotReady          = retErrInfo(23, "the group is not ready.please try again.")
+	ErrGetGenPubkeyFail       = retErrInfo(24, "get generate pubkey fail.")
+	ErrGetGenSaveDataFail     = retErrInfo(25, "get generate save data fail.")
+	ErrCreateDbFail           = retErrInfo(26, "create db fail.")
+	ErrSmpcSigWrongSize       = retErrInfo(28, "wrong size for smpc sig.")
+	ErrSmpcSigFail            = retErrInfo(29, "smpc sign fail.")
+	ErrInvalidSmpcAddr        = retErrInfo(30, "invalid smpc address.")
+	ErrGetRealEosUserFail     = retErrInfo(27, "cannot get real eos account.")
+	ErrSendTxToNetFail        = retErrInfo(14, "send tx to outside net fail.")
+	ErrGetC1Timeout           = retErrInfo(31, "get C1 timeout.")
+	ErrGetEnodeByUIDFail      = retErrInfo(32, "can not find proper enodes by uid.")
+	ErrGetD1Timeout           = retErrInfo(33, "get D1 timeout.")
+	ErrGetSHARE1Timeout       = retErrInfo(34, "get SHARE1 timeout.")
+	ErrGetAllSHARE1Fail       = retErrInfo(35, "get all SHARE1 msg fail.")
+	ErrGetAllD1Fail           = retErrInfo(36, "get all D1 msg fail.")
+	ErrVerifySHARE1Fail       = retErrInfo(37, "verify SHARE1 fail.")
+	ErrGetAllC1Fail           = retErrInfo(38, "get all C1 msg fail.")
+	ErrKeyGenVerifyCommitFail = retErrInfo(39, "verify commit in keygenerate fail.")
+	ErrGetZKFACTPROOFTimeout  = retErrInfo(40, "get ZKFACTPROOF timeout.")
+	ErrGetZKUPROOFTimeout     = retErrInfo(41, "get ZKUPROOF timeout.")
+	ErrGetAllZKFACTPROOFFail  = retErrInfo(42, "get all ZKFACTPROOF msg fail.")
+	ErrVerifyZKFACTPROOFFail  = retErrInfo(43, "verify ZKFACTPROOF fail.")
+	ErrGetAllZKUPROOFFail     = retErrInfo(44, "get all ZKUPROOF msg fail.")
+	ErrVerifyZKUPROOFFail     = retErrInfo(45, "verify ZKUPROOF fail.")
+	ErrGetC11Timeout          = retErrInfo(46, "get C11 timeout.")
+	ErrGetMTAZK1PROOFTimeout  = retErrInfo(47, "get MTAZK1PROOF timeout.")
+	ErrGetKCTimeout           = retErrInfo(48, "get KC timeout.")
+	ErrGetAllKCFail           = retErrInfo(49, "get all KC msg fail.")
+	ErrGetAllMTAZK1PROOFFail  = retErrInfo(50, "get all MTAZK1PROOF msg fail.")
+	ErrVerifyMTAZK1PROOFFail  = retErrInfo(51, "verify MTAZK1PROOF fail.")
+	ErrGetMKGTimeout          = retErrInfo(52, "get MKG timeout.")
+	ErrGetAllMKGFail          = retErrInfo(53, "get all MKG msg fail.")
+	ErrGetMKWTimeout          = retErrInfo(54, "get MKW timeout.")
+	ErrGetAllMKWFail          = retErrInfo(55, "get all MKW msg fail.")
+	ErrVerifyMKGFail          = retErrInfo(56, "verify MKG fail.")
+	ErrVerifyMKWFail          = retErrInfo(57, "verify MKW fail.")
+	ErrGetPaillierPrivKeyFail = retErrInfo(58, "get paillier privkey fail.")
+	ErrGetDELTA1Timeout       = retErrInfo(59, "get DELTA1 timeout.")
+	ErrGetAllDELTA1Fail       = retErrInfo(60, "get all DELTA1 msg fail.")
+	ErrGetD11Timeout          = retErrInfo(61, "get D11 timeout.")
+	ErrGetAllD11Fail          = retErrInfo(62, "get all D11 msg fail.")
+	ErrGetAllC11Fail          = retErrInfo(63, "get all C11 msg fail.")
+	ErrSignVerifyCommitFail   = retErrInfo(64, "verify commit in smpc sign fail.")
+	ErrREqualZero             = retErrInfo(65, "sign error: r equal zero.")
+	ErrGetS1Timeout           = retErrInfo(66, "get S1 timeout.")
+	ErrGetAllS1Fail           = retErrInfo(67, "get all S1 msg fail.")
+	ErrVerifySAllFail         = retErrInfo(68, "verify SAll != m*G + r*PK in smpc sign ec2.")
+	ErrGetSS1Timeout          = retErrInfo(69, "get SS1 timeout.")
+	ErrGetAllSS1Fail          = retErrInfo(70, "get all SS1 msg fail.")
+	ErrSEqualZero             = retErrInfo(71, "sign error: s equal zero.")
+	ErrSmpcSignVerifyFail     = retErrInfo(72, "smpc sign verify fail.")
 )
 
 // ErrorRet error return code
@@ -90,9 +105,48 @@ type ErrorRet struct {
 	Error string
 }
 
+// RetError the error created by GetRetErr from the error info above
+type RetError struct {
+	Code    int
+	Message string
+	Info    string
+}
+
+// Error the error info,it is returned by the smpc namespace as before
+func (e *RetError) Error() string {
+	return e.Info
+}
+
+// Is the errors with the same code are the same,so errors.Is(err, GetRetErr(ErrGroupNotReady)) works
+func (e *RetError) Is(target error) bool {
+	t, ok := target.(*RetError)
+	return ok && t.Code == e.Code
+}
+
 // GetRetErr get error form error info
+// it is a *RetError if err is one of the error info above
 func GetRetErr(err string) error {
+	if e, ok := retErrs[err]; ok {
+		ret := *e
+		return &ret
+	}
+
 	var ret2 Err
 	ret2.Info = err
 	return ret2
 }
+
+// GetRetErrCode get the code and message of the error created by GetRetErr from the error info above,err may be wrapped
+// 0 and err.Error() are returned if err is not one of them
+func GetRetErrCode(err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+
+	var e *RetError
+	if !errors.As(err, &e) {
+		return 0, err.Error()
+	}
+
+	return e.Code, e.Message
+}
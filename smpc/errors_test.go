@@ -0,0 +1,85 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRetErrCode(t *testing.T) {
+	tests := []struct {
+		info string
+		code int
+		msg  string
+	}{
+		{ErrEncodeSendMsgFail, 1, "encode send msg fail."},
+		{ErrParamError, 2, "parameters error."},
+		{ErrNoFindWorker, 7, "can not find worker."},
+		{ErrGetWorkerIDError, 10, "get worker id error."},
+		{ErrSendTxToNetFail, 14, "send tx to outside net fail."},
+		{ErrGroupNotReady, 23, "the group is not ready.please try again."},
+		{ErrGetGenPubkeyFail, 24, "get generate pubkey fail."},
+		{ErrGetGenSaveDataFail, 25, "get generate save data fail."},
+		{ErrGetRealEosUserFail, 27, "cannot get real eos account."},
+		{ErrSmpcSigWrongSize, 28, "wrong size for smpc sig."},
+		{ErrGetZKFACTPROOFTimeout, 40, "get ZKFACTPROOF timeout."},
+		{ErrVerifyMTAZK1PROOFFail, 51, "verify MTAZK1PROOF fail."},
+		{ErrVerifySAllFail, 68, "verify SAll != m*G + r*PK in smpc sign ec2."},
+		{ErrSmpcSignVerifyFail, 72, "smpc sign verify fail."},
+	}
+
+	for _, tt := range tests {
+		err := GetRetErr(tt.info)
+		assert.Equal(t, tt.info, err.Error(), "error info")
+
+		code, msg := GetRetErrCode(err)
+		assert.Equal(t, tt.code, code, tt.info)
+		assert.Equal(t, tt.msg, msg, tt.info)
+
+		code, msg = GetRetErrCode(fmt.Errorf("wrapped: %w", err))
+		assert.Equal(t, tt.code, code, "wrapped %v", tt.info)
+		assert.Equal(t, tt.msg, msg, "wrapped %v", tt.info)
+
+		assert.True(t, errors.Is(err, GetRetErr(tt.info)), tt.info)
+	}
+
+	// every error info has its own code
+	codes := make(map[int]string)
+	for info, e := range retErrs {
+		assert.Equal(t, fmt.Sprintf(`{Code:%v,Error:"%v"}`, e.Code, e.Message), info)
+		assert.Equal(t, "", codes[e.Code], "code %v is used by %v", e.Code, codes[e.Code])
+		codes[e.Code] = info
+	}
+
+	assert.False(t, errors.Is(GetRetErr(ErrGroupNotReady), GetRetErr(ErrNoFindWorker)))
+
+	code, msg := GetRetErrCode(nil)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", msg)
+
+	code, msg = GetRetErrCode(errors.New("other error"))
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "other error", msg)
+
+	code, msg = GetRetErrCode(GetRetErr("not an error info"))
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "not an error info", msg)
+}
@@ -0,0 +1,270 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+)
+
+const (
+	// EventPendingApproval a new request is waiting for the approval of this node
+	EventPendingApproval = "PendingApproval"
+
+	// EventApproval an approval (agree or disagree) of a node has been received
+	EventApproval = "Approval"
+
+	// EventRound the mpc process of a request has sent the message of a new round
+	EventRound = "Round"
+
+	// EventFinished a request has finished with its result or error
+	EventFinished = "Finished"
+
+	// EventLagged the subscriber was too slow,Dropped events were dropped before this one
+	EventLagged = "Lagged"
+)
+
+// eventKeyTTL the request that does not finish within it is forgotten,
+// its approval and round events are sent without the account/pubkey/group after that
+var eventKeyTTL = 24 * time.Hour
+
+// Event the lifecycle event of a keygen/sign/reshare/recover share request
+type Event struct {
+	Type      string
+	CmdType   string
+	Key       string
+	Account   string
+	PubKey    string
+	GroupID   string
+	Round     string `json:",omitempty"`
+	Approver  string `json:",omitempty"`
+	Accept    string `json:",omitempty"`
+	Status    string `json:",omitempty"`
+	Result    string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+	Tip       string `json:",omitempty"`
+	Dropped   int    `json:",omitempty"`
+	TimeStamp string
+}
+
+// EventFilter the filter of the event subscriptions,the empty fields match any event
+type EventFilter struct {
+	Account string
+	PubKey  string
+	Key     string
+}
+
+// Match return true if the event pass the filter,the lagged event always passes
+func (f *EventFilter) Match(ev *Event) bool {
+	if f == nil || ev.Type == EventLagged {
+		return true
+	}
+
+	if f.Account != "" && !strings.EqualFold(f.Account, ev.Account) {
+		return false
+	}
+
+	if f.PubKey != "" && !strings.EqualFold(f.PubKey, ev.PubKey) {
+		return false
+	}
+
+	if f.Key != "" && !strings.EqualFold(f.Key, ev.Key) {
+		return false
+	}
+
+	return true
+}
+
+// eventKeyInfo the account/pubkey/group of a request,used to fill the approval and round events
+type eventKeyInfo struct {
+	CmdType string
+	Account string
+	PubKey  string
+	GroupID string
+	Time    time.Time
+}
+
+// eventSub a subscriber and the count of the events dropped since the last event it got
+type eventSub struct {
+	ch      chan *Event
+	dropped int
+}
+
+var (
+	eventsLock   sync.Mutex
+	eventSubs    = make(map[int]*eventSub)
+	eventSubID   = 0
+	eventKeyLock sync.RWMutex
+	eventKeys    = make(map[string]*eventKeyInfo)
+)
+
+// SubscribeEvents subscribe all the request lifecycle events,size is the buffer size of the channel
+// the events are dropped if the buffer of the subscriber is full,the subscriber gets an EventLagged with the count of
+// the dropped events before the next event once there is room again,so it knows to query the status of the requests
+func SubscribeEvents(size int) (int, <-chan *Event) {
+	if size <= 0 {
+		size = 64
+	}
+
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	eventSubID++
+	ch := make(chan *Event, size)
+	eventSubs[eventSubID] = &eventSub{ch: ch}
+	return eventSubID, ch
+}
+
+// UnsubscribeEvents cancel the subscription and close its channel
+func UnsubscribeEvents(id int) {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	sub, ok := eventSubs[id]
+	if !ok {
+		return
+	}
+
+	delete(eventSubs, id)
+	close(sub.ch)
+}
+
+// notifyEvent send the event to all subscribers without blocking the caller,
+// the event is dropped for the subscriber whose buffer is full and counted for its EventLagged
+func notifyEvent(ev *Event) {
+	if ev == nil {
+		return
+	}
+
+	eventKeyLock.RLock()
+	info, ok := eventKeys[ev.Key]
+	eventKeyLock.RUnlock()
+	if ok {
+		if ev.CmdType == "" {
+			ev.CmdType = info.CmdType
+		}
+		if ev.Account == "" {
+			ev.Account = info.Account
+		}
+		if ev.PubKey == "" {
+			ev.PubKey = info.PubKey
+		}
+		if ev.GroupID == "" {
+			ev.GroupID = info.GroupID
+		}
+	}
+
+	if ev.TimeStamp == "" {
+		ev.TimeStamp = strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	}
+
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	for id, sub := range eventSubs {
+		if sub.dropped != 0 {
+			select {
+			case sub.ch <- &Event{Type: EventLagged, Dropped: sub.dropped, TimeStamp: ev.TimeStamp}:
+				sub.dropped = 0
+			default:
+			}
+		}
+
+		if sub.dropped == 0 {
+			select {
+			case sub.ch <- ev:
+				continue
+			default:
+			}
+		}
+
+		sub.dropped++
+		common.Debug("=====================notifyEvent,the subscriber is too slow,drop the event=====================", "id", id, "key", ev.Key, "type", ev.Type, "dropped", sub.dropped)
+	}
+}
+
+// notifyPendingApproval remember the request and tell the subscribers and the webhooks it is waiting for approval,
+// the requests that are older than eventKeyTTL are forgotten
+func notifyPendingApproval(cmdtype string, key string, account string, pubkey string, groupid string) {
+	now := time.Now()
+	eventKeyLock.Lock()
+	for k, v := range eventKeys {
+		if now.Sub(v.Time) > eventKeyTTL {
+			delete(eventKeys, k)
+		}
+	}
+	eventKeys[key] = &eventKeyInfo{CmdType: cmdtype, Account: account, PubKey: pubkey, GroupID: groupid, Time: now}
+	eventKeyLock.Unlock()
+
+	ev := &Event{Type: EventPendingApproval, CmdType: cmdtype, Key: key, Account: account, PubKey: pubkey, GroupID: groupid, Status: "Pending"}
+	notifyEvent(ev)
+	putWebhookOutbox(ev)
+	queueApprovalPolicy(cmdtype, key)
+}
+
+// notifyApproval tell the subscribers the accept data of the node from has been received
+func notifyApproval(key string, from string, accept string) {
+	notifyEvent(&Event{Type: EventApproval, Key: key, Approver: from, Accept: accept})
+}
+
+// notifyApprovalRaw tell the subscribers the accept raw data has been received,
+// the raw data has been checked by the caller,only the sender and the accept are got from it,the command raw data is ignored
+func notifyApprovalRaw(key string, raw string) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(raw), tx); err != nil {
+		return
+	}
+
+	var txdata struct {
+		TxType string
+		Accept string
+	}
+	if err := json.Unmarshal(tx.Data(), &txdata); err != nil || !strings.HasPrefix(txdata.TxType, "ACCEPT") {
+		return
+	}
+
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(30400)), tx)
+	if err != nil {
+		return
+	}
+
+	notifyApproval(key, from.Hex(), txdata.Accept)
+}
+
+// notifyRound tell the subscribers the message of the round has been sent
+func notifyRound(key string, round string) {
+	notifyEvent(&Event{Type: EventRound, Key: key, Round: round})
+}
+
+// notifyFinished tell the subscribers and the webhooks the request has finished and forget it
+func notifyFinished(cmdtype string, key string, account string, pubkey string, groupid string, status string, result string, tip string, errinfo string) {
+	ev := &Event{Type: EventFinished, CmdType: cmdtype, Key: key, Account: account, PubKey: pubkey, GroupID: groupid, Status: status, Result: result, Tip: tip, Error: errinfo}
+	notifyEvent(ev)
+	putWebhookOutbox(ev)
+
+	eventKeyLock.Lock()
+	delete(eventKeys, key)
+	eventKeyLock.Unlock()
+}
@@ -0,0 +1,208 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFilter(t *testing.T) {
+	ev := &Event{Type: EventFinished, Key: "0xKey", Account: "0xAccount", PubKey: "04abcd"}
+	tests := []struct {
+		name   string
+		filter *EventFilter
+		ev     *Event
+		match  bool
+	}{
+		{"nil", nil, ev, true},
+		{"empty", &EventFilter{}, ev, true},
+		{"account", &EventFilter{Account: "0xaccount"}, ev, true},
+		{"other account", &EventFilter{Account: "0xother"}, ev, false},
+		{"pubkey", &EventFilter{PubKey: "04ABCD"}, ev, true},
+		{"other pubkey", &EventFilter{PubKey: "04ef"}, ev, false},
+		{"key", &EventFilter{Key: "0xkey"}, ev, true},
+		{"other key", &EventFilter{Key: "0xother"}, ev, false},
+		{"all fields", &EventFilter{Account: "0xAccount", PubKey: "04abcd", Key: "0xKey"}, ev, true},
+		{"one field mismatch", &EventFilter{Account: "0xAccount", PubKey: "04abcd", Key: "0xother"}, ev, false},
+		{"lagged", &EventFilter{Account: "0xother"}, &Event{Type: EventLagged, Dropped: 1}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, tt.filter.Match(tt.ev), tt.name)
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	id, ch := SubscribeEvents(4)
+	id2, ch2 := SubscribeEvents(4)
+	assert.NotEqual(t, id, id2)
+
+	notifyEvent(&Event{Type: EventRound, Key: "0xsub", Round: "1"})
+	for _, c := range []<-chan *Event{ch, ch2} {
+		select {
+		case ev := <-c:
+			assert.Equal(t, EventRound, ev.Type)
+			assert.Equal(t, "1", ev.Round)
+			assert.NotEqual(t, "", ev.TimeStamp, "timestamp")
+		default:
+			t.Fatal("no event")
+		}
+	}
+
+	// the channel is closed and gets no more events after unsubscribe
+	UnsubscribeEvents(id)
+	UnsubscribeEvents(id)
+	_, ok := <-ch
+	assert.False(t, ok, "closed")
+
+	notifyEvent(&Event{Type: EventRound, Key: "0xsub", Round: "2"})
+	ev := <-ch2
+	assert.Equal(t, "2", ev.Round)
+
+	UnsubscribeEvents(id2)
+}
+
+func TestSubscribeEventsLagged(t *testing.T) {
+	id, ch := SubscribeEvents(2)
+	defer UnsubscribeEvents(id)
+
+	for i := 1; i <= 4; i++ {
+		notifyEvent(&Event{Type: EventRound, Key: "0xlag", Round: strconv.Itoa(i)})
+	}
+
+	assert.Equal(t, "1", (<-ch).Round)
+	assert.Equal(t, "2", (<-ch).Round)
+
+	// the subscriber is told how many events it missed before the next event
+	notifyEvent(&Event{Type: EventRound, Key: "0xlag", Round: "5"})
+	ev := <-ch
+	assert.Equal(t, EventLagged, ev.Type)
+	assert.Equal(t, 2, ev.Dropped)
+	assert.Equal(t, "5", (<-ch).Round)
+
+	notifyEvent(&Event{Type: EventRound, Key: "0xlag", Round: "6"})
+	assert.Equal(t, "6", (<-ch).Round)
+}
+
+func TestEventKeys(t *testing.T) {
+	id, ch := SubscribeEvents(8)
+	defer UnsubscribeEvents(id)
+
+	notifyPendingApproval("SIGN", "0xkey1", "0xaccount", "04abcd", "0xgroup")
+	ev := <-ch
+	assert.Equal(t, EventPendingApproval, ev.Type)
+
+	// the round and approval events get the account/pubkey/group of the request
+	notifyRound("0xkey1", "2")
+	ev = <-ch
+	assert.Equal(t, "SIGN", ev.CmdType)
+	assert.Equal(t, "0xaccount", ev.Account)
+	assert.Equal(t, "04abcd", ev.PubKey)
+	assert.Equal(t, "0xgroup", ev.GroupID)
+
+	notifyApproval("0xkey1", "0xfrom", "AGREE")
+	ev = <-ch
+	assert.Equal(t, EventApproval, ev.Type)
+	assert.Equal(t, "0xaccount", ev.Account)
+	assert.Equal(t, "0xfrom", ev.Approver)
+	assert.Equal(t, "AGREE", ev.Accept)
+
+	// the finished request is forgotten
+	notifyFinished("SIGN", "0xkey1", "0xaccount", "04abcd", "0xgroup", "Success", "rsv", "", "")
+	<-ch
+	eventKeyLock.RLock()
+	_, ok := eventKeys["0xkey1"]
+	eventKeyLock.RUnlock()
+	assert.False(t, ok, "finished")
+
+	// the request that never finishes is forgotten after eventKeyTTL
+	notifyPendingApproval("SIGN", "0xkey2", "0xaccount", "04abcd", "0xgroup")
+	<-ch
+	eventKeyLock.Lock()
+	eventKeys["0xkey2"].Time = time.Now().Add(-eventKeyTTL - time.Minute)
+	eventKeyLock.Unlock()
+
+	notifyPendingApproval("SIGN", "0xkey3", "0xaccount", "04abcd", "0xgroup")
+	<-ch
+	eventKeyLock.RLock()
+	_, ok = eventKeys["0xkey2"]
+	_, ok3 := eventKeys["0xkey3"]
+	eventKeyLock.RUnlock()
+	assert.False(t, ok, "expired")
+	assert.True(t, ok3, "not expired")
+
+	notifyFinished("SIGN", "0xkey3", "", "", "", "Timeout", "", "", "")
+}
+
+func TestNotifyApprovalRaw(t *testing.T) {
+	id, ch := SubscribeEvents(8)
+	defer UnsubscribeEvents(id)
+
+	prv, err := crypto.GenerateKey()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rawOf := func(txdata interface{}) string {
+		data, err := json.Marshal(txdata)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+
+		tx := types.NewTransaction(1, [20]byte{}, big.NewInt(0), 100000, big.NewInt(80000), data)
+		tx, err = types.SignTx(tx, types.NewEIP155Signer(big.NewInt(30400)), prv)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+
+		b, err := rlp.EncodeToBytes(tx)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		return common.ToHex(b)
+	}
+
+	notifyApprovalRaw("0xkey", rawOf(&TxDataAcceptSign{TxType: "ACCEPTSIGN", Key: "0xkey", Accept: "DISAGREE"}))
+	select {
+	case ev := <-ch:
+		assert.Equal(t, EventApproval, ev.Type)
+		assert.Equal(t, "0xkey", ev.Key)
+		assert.Equal(t, crypto.PubkeyToAddress(prv.PublicKey).Hex(), ev.Approver)
+		assert.Equal(t, "DISAGREE", ev.Accept)
+	default:
+		t.Fatal("no approval event")
+	}
+
+	// the command raw data and the invalid raw data are not approvals
+	notifyApprovalRaw("0xkey", rawOf(&TxDataSign{TxType: "SIGN"}))
+	notifyApprovalRaw("0xkey", "0x1234")
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %v", ev.Type)
+	default:
+	}
+}
@@ -17,16 +17,12 @@
 package smpc
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
-	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/anyswap/FastMulThreshold-DSA/log"
-	"math/big"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,8 +31,13 @@ import (
 
 //---------------------------------------ECDSA start-----------------------------------------------------------------------
 
-// ProcessInboundMessages Analyze the obtained P2P messages and enter next round
-func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+// keygenTimeout the keygen dnode that can go on without the dealers that never justify
+type keygenTimeout interface {
+	Timeout(wait time.Duration) (bool, error)
+}
+
+// ProcessInboundMessages Analyze the obtained P2P messages and enter next round,keytype is the type of the key that is generated
+func ProcessInboundMessages(msgprex string, keytype string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
     	if msgprex == "" {
 	    return
 	}
@@ -52,10 +53,26 @@ func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.W
 	}
 
 	defer log.Info("stop processing inbound messages","key",msgprex)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-finishChan:
 			return
+		case <-ticker.C:
+			// the accused dealers that never justify are excluded after EcKeygenJustifyTimeout
+			tn, ok := w.DNode.(keygenTimeout)
+			if !ok {
+				break
+			}
+
+			if _, err := tn.Timeout(time.Duration(EcKeygenJustifyTimeout) * time.Second); err != nil {
+				common.Error("====================ProcessInboundMessages,dnode timeout fail=======================","key",msgprex,"err",err)
+				saveBlame(msgprex, keytype, w.groupid, w.groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
 		case m := <-w.SmpcMsg:
 
 			if w.DNode == nil {
@@ -73,8 +90,7 @@ func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.W
 			}
 			///
 
-			msgmap := make(map[string]string)
-			err := json.Unmarshal([]byte(m), &msgmap)
+			msgmap, err := decodeP2pMsg(m)
 			if err != nil {
 				log.Error("======================ProcessInboundMessages,unmarshal msg error===============","key",msgprex,"msg",m,"err",err)
 				res := RPCSmpcRes{Ret: "", Err: err}
@@ -133,7 +149,7 @@ func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.W
 			}
 
 			// check fromID
-			_,UID := GetNodeUID(msgmap["ENode"], "EC256K1",w.groupid)
+			_,UID := GetNodeUID(msgmap["ENode"], keytype,w.groupid)
 			id := fmt.Sprintf("%v", UID)
 			uid := hex.EncodeToString([]byte(id))
 			if !strings.EqualFold(uid,mm.GetFromID()) {
@@ -165,6 +181,7 @@ func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.W
 			_, err = w.DNode.Update(mm)
 			if err != nil {
 				common.Error("====================ProcessInboundMessages,dnode update fail=======================", "receiv msg", m, "err", err)
+				saveBlame(msgprex, keytype, w.groupid, w.groupid, err)
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
 				return
@@ -194,352 +211,11 @@ func ProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sync.W
 
 // GetRealMessage get the message data struct by map. (p2p msg ---> map)
 func GetRealMessage(msg map[string]string) smpclib.Message {
-    	if msg == nil {
-	    return nil
-	}
-
-	from := msg["FromID"]
-	if from == "" {
-	    return nil
-	}
-
-	var to []string
-	v, ok := msg["ToID"]
-	if ok && v != "" {
-		to = strings.Split(v, ":")
-	}
-
-	index, indexerr := strconv.Atoi(msg["FromIndex"])
-	if indexerr != nil {
-		return nil
-	}
-
-	//1 message
-	if msg["Type"] == "KGRound1Message" {
-		pub := &ec2.PublicKey{}
-		if msg["U1PaillierPk"] == "" {
-		    return nil
-		}
-
-		err := pub.UnmarshalJSON([]byte(msg["U1PaillierPk"]))
-		if err == nil {
-			comc, _ := new(big.Int).SetString(msg["ComC"], 10)
-			ComCBip32, _ := new(big.Int).SetString(msg["ComC_bip32"], 10)
-			if comc == nil || ComCBip32 == nil {
-			    return nil
-			}
-
-			kg := &keygen.KGRound1Message{
-				KGRoundMessage: new(keygen.KGRoundMessage),
-				ComC:           comc,
-				ComCBip32:     ComCBip32,
-				U1PaillierPk:   pub,
-			}
-			kg.SetFromID(from)
-			kg.SetFromIndex(index)
-			kg.ToID = to
-			return kg
-		}
-	}
-
-	//2 message
-	if msg["Type"] == "KGRound2Message" {
-		id, _ := new(big.Int).SetString(msg["ID"], 10)
-		sh, _ := new(big.Int).SetString(msg["Share"], 10)
-		if id == nil || sh == nil {
-		    return nil
-		}
-
-		kg := &keygen.KGRound2Message{
-			KGRoundMessage: new(keygen.KGRoundMessage),
-			ID:             id,
-			Share:          sh,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//2-1 message
-	if msg["Type"] == "KGRound2Message1" {
-		c1, _ := new(big.Int).SetString(msg["C1"], 10)
-		if c1 == nil {
-		    return nil
-		}
-
-		kg := &keygen.KGRound2Message1{
-			KGRoundMessage: new(keygen.KGRoundMessage),
-			C1:             c1,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//2-2 message
-	if msg["Type"] == "KGRound2Message2" {
-	    if msg["SfPf"] == "" {
-		return nil
-	    }
-
-	    if msg["Num"] == "" {
-		return nil
-	    }
-
-	    pf := &ec2.SquareFreeProof{}
-	    err := pf.UnmarshalJSON([]byte(msg["SfPf"]))
-	    if err == nil {
-		num, ok := new(big.Int).SetString(msg["Num"], 10)
-		if !ok {
-		    return nil
-		}
-
-		kg := &keygen.KGRound2Message2{
-		    KGRoundMessage: new(keygen.KGRoundMessage),
-		    Num:		num,
-		    SfPf:             pf,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	    }
-	}
-
-	//3 message
-	if msg["Type"] == "KGRound3Message" {
-	    if msg["ComU1GD"] == "" || msg["ComC1GD"] == "" || msg["U1PolyGG"] == "" {
-		return nil
-	    }
-
-		ugd := strings.Split(msg["ComU1GD"], ":")
-		u1gd := make([]*big.Int, len(ugd))
-		for k, v := range ugd {
-			u1gd[k], _ = new(big.Int).SetString(v, 10)
-			if u1gd[k] == nil {
-			    return nil
-			}
-		}
-
-		ucd := strings.Split(msg["ComC1GD"], ":")
-		u1cd := make([]*big.Int, len(ucd))
-		for k, v := range ucd {
-			u1cd[k], _ = new(big.Int).SetString(v, 10)
-			if u1cd[k] == nil {
-			    return nil
-			}
-		}
-
-		uggtmp := strings.Split(msg["U1PolyGG"], "|")
-		ugg := make([][]*big.Int, len(uggtmp))
-		for k, v := range uggtmp {
-			uggtmp2 := strings.Split(v, ":")
-			tmp := make([]*big.Int, len(uggtmp2))
-			for kk, vv := range uggtmp2 {
-				tmp[kk], _ = new(big.Int).SetString(vv, 10)
-				if tmp[kk] == nil {
-				    return nil
-				}
-			}
-			ugg[k] = tmp
-		}
-
-		kg := &keygen.KGRound3Message{
-			KGRoundMessage: new(keygen.KGRoundMessage),
-			ComU1GD:        u1gd,
-			ComC1GD:        u1cd,
-			U1PolyGG:       ugg,
-		}
-
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//3-1 message
-	if msg["Type"] == "KGRound3Message1" {
-	    if msg["Y"] == "" {
-		return nil
-	    }
-
-		y, _ := new(big.Int).SetString(msg["Y"], 10)
-		if y == nil {
-		    return nil
-		}
-
-		kg := &keygen.KGRound3Message1{
-		    KGRoundMessage: new(keygen.KGRoundMessage),
-		    Y:             y,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//4 message
-	if msg["Type"] == "KGRound4Message" {
-		nti := &ec2.NtildeH1H2{}
-		if msg["U1NtildeH1H2"] == "" {
-		    return nil
-		}
-
-		if err := nti.UnmarshalJSON([]byte(msg["U1NtildeH1H2"])); err == nil {
-			pf1 := &ec2.NtildeProof{}
-			if msg["NtildeProof1"] == "" {
-			    return nil
-			}
-
-			if err := pf1.UnmarshalJSON([]byte(msg["NtildeProof1"])); err == nil {
-				pf2 := &ec2.NtildeProof{}
-				if msg["NtildeProof2"] == "" {
-				    return nil
-				}
-
-				if err := pf2.UnmarshalJSON([]byte(msg["NtildeProof2"])); err == nil {
-				    if msg["ComXiC"] == "" {
-					return nil
-				    }
-
-				    comxic,_ := new(big.Int).SetString(msg["ComXiC"],10)
-				    
-					kg := &keygen.KGRound4Message{
-						KGRoundMessage: new(keygen.KGRoundMessage),
-						U1NtildeH1H2:   nti,
-						NtildeProof1:   pf1,
-						NtildeProof2:   pf2,
-						ComXiC:		comxic,
-					}
-					kg.SetFromID(from)
-					kg.SetFromIndex(index)
-					kg.ToID = to
-					return kg
-				}
-			}
-		}
-	}
-
-	//5 message
-	if msg["Type"] == "KGRound5Message" {
-	    if msg["ComXiGD"] == "" {
-		return nil
-	    }
-
-	    xgd := strings.Split(msg["ComXiGD"], ":")
-	    xigd := make([]*big.Int, len(xgd))
-	    for k, v := range xgd {
-		xigd[k], _ = new(big.Int).SetString(v, 10)
-		if xigd[k] == nil {
-		    return nil
-		}
-	    }
-
-	    kg := &keygen.KGRound5Message{
-		    KGRoundMessage: new(keygen.KGRoundMessage),
-		    ComXiGD:		xigd,
-	    }
-	    kg.SetFromID(from)
-	    kg.SetFromIndex(index)
-	    kg.ToID = to
-	    return kg
-	}
-
-	//5-1 message
-	if msg["Type"] == "KGRound5Message1" {
-	    if msg["HvPf"] == "" || msg["Num"] == "" {
-		return nil
-	    }
-
-	    pf := &ec2.HvProof{}
-	    err := pf.UnmarshalJSON([]byte(msg["HvPf"]))
-	    if err == nil {
-		num, ok := new(big.Int).SetString(msg["Num"], 10)
-		if !ok {
-		    return nil
-		}
-
-		kg := &keygen.KGRound5Message1{
-		    KGRoundMessage: new(keygen.KGRoundMessage),
-		    Num:		num,
-		    HvPf:             pf,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	    }
-	}
-
-	//5-2 message
-	if msg["Type"] == "KGRound5Message2" {
-	    if msg["SfPf"] == "" {
-		return nil
-	    }
-
-	    if msg["Num"] == "" {
-		return nil
-	    }
-
-	    pf := &ec2.SquareFreeProof{}
-	    err := pf.UnmarshalJSON([]byte(msg["SfPf"]))
-	    if err == nil {
-		num, ok := new(big.Int).SetString(msg["Num"], 10)
-		if !ok {
-		    return nil
-		}
-
-		kg := &keygen.KGRound5Message2{
-		    KGRoundMessage: new(keygen.KGRoundMessage),
-		    Num:		num,
-		    SfPf:             pf,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	    }
-	}
-
-	//6 message
-	if msg["Type"] == "KGRound6Message" {
-	    b := false
-	    if msg["CheckPubkeyStatus"] == "true" {
-		    b = true
-	    }
-
-	    if msg["U1zkXiProof"] == "" {
-		return nil
-	    }
-	    
-	    zk := &ec2.ZkXiProof{}
-	    if err := zk.UnmarshalJSON([]byte(msg["U1zkXiProof"])); err == nil {
-		kg := &keygen.KGRound6Message{
-			KGRoundMessage:      new(keygen.KGRoundMessage),
-			U1zkXiProof:     zk,
-			CheckPubkeyStatus: b,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	    }
-	}
-
-	kg := &keygen.KGRound0Message{
-		KGRoundMessage: new(keygen.KGRoundMessage),
-	}
-	kg.SetFromID(from)
-	kg.SetFromIndex(-1)
-	kg.ToID = to
-
-	return kg
+	return getRealMessage(msg, "ecdsa/keygen")
 }
 
-// processKeyGen  Obtain the data to be sent in each round and send it to other nodes until the end of the request command 
-func processKeyGen(msgprex string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) error {
+// processKeyGen  Obtain the data to be sent in each round and send it to other nodes until the end of the request command,keytype is the type of the key that is generated
+func processKeyGen(msgprex string, keytype string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) error {
     	if msgprex == "" {
 	    return errors.New("param error")
 	}
@@ -565,10 +241,16 @@ func processKeyGen(msgprex string, errChan chan struct{}, outCh <-chan smpclib.M
 				return fmt.Errorf("get worker fail")
 			}
 
+			// the keygen finished without the faulty dealers,still record who cheated
+			if len(msg.Faulty) != 0 {
+				saveBlame(msgprex, keytype, w.groupid, w.groupid, &smpclib.BlameError{Blames: msg.Faulty, Err: errors.New("keygen complaint")})
+			}
+
 			w.pkx.PushBack(fmt.Sprintf("%v", msg.Pkx))
 			w.pky.PushBack(fmt.Sprintf("%v", msg.Pky))
 			w.bip32c.PushBack(string(msg.C.Bytes()))
 			w.sku1.PushBack(string(msg.SkU1.Bytes()))
+			w.pubshares.PushBack(encodePubShares(msg.IDs, msg.PubShares))
 
 			ss := "XXX"
 			ss = ss + common.SepSave
@@ -652,23 +334,29 @@ func ProcessOutCh(msgprex string, msg smpclib.Message) error {
 		return fmt.Errorf("get worker fail")
 	}
 
+	notifyRound(msgprex, msg.GetMsgType())
+
 	sig,err := sigP2pMsg(msg,curEnode)
 	if err != nil {
 	    return err
 	}
 
-	msgmap := msg.OutMap()
+	msgmap, err := getWireMsgMap(msg, w.groupid)
+	if err != nil {
+		return err
+	}
+
 	msgmap["Key"] = msgprex
 	msgmap["ENode"] = curEnode
 	msgmap["Sig"] = hex.EncodeToString(sig)
-	s, err := json.Marshal(msgmap)
+	s, err := encodeP2pMsg(msgmap)
 	if err != nil {
 		log.Error("====================ProcessOutCh, marshal fail=================","err",err,"key",msgprex)
 		return err
 	}
 
 	if msg.IsBroadcast() {
-		SendMsgToSmpcGroup(string(s), w.groupid)
+		SendMsgToSmpcGroup(s, w.groupid)
 	} else {
 		for _, v := range msg.GetToID() {
 			enode := w.MsgToEnode[v]
@@ -678,7 +366,7 @@ func ProcessOutCh(msgprex string, msg smpclib.Message) error {
 				node2 := ParseNode(node)
 				if strings.EqualFold(enode, node2) {
 					//SendMsgToPeer(node, string(s))
-					SendMsgToPeerWithBrodcast(msgprex,node,string(s),w.groupid)
+					SendMsgToPeerWithBrodcast(msgprex,node,s,w.groupid)
 					break
 				}
 			}
@@ -18,13 +18,11 @@ package smpc
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
 	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -54,8 +52,7 @@ func ProcessInboundMessagesEDDSA(msgprex string, finishChan chan struct{}, wg *s
 			return
 		case m := <-w.SmpcMsg:
 
-			msgmap := make(map[string]string)
-			err := json.Unmarshal([]byte(m), &msgmap)
+			msgmap, err := decodeP2pMsg(m)
 			if err != nil {
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
@@ -147,155 +144,7 @@ func ProcessInboundMessagesEDDSA(msgprex string, finishChan chan struct{}, wg *s
 
 // GetRealMessageEDDSA get the message data struct by map. (p2p msg ---> map)
 func GetRealMessageEDDSA(msg map[string]string) smpclib.Message {
-    	if msg == nil {
-	    return nil
-	}
-
-	from := msg["FromID"]
-	if from == "" {
-	    return nil
-	}
-
-	var to []string
-	v, ok := msg["ToID"]
-	if ok && v != "" {
-		to = strings.Split(v, ":")
-	}
-
-	index, indexerr := strconv.Atoi(msg["FromIndex"])
-	if indexerr != nil {
-		return nil
-	}
-
-	//1 message
-	if msg["Type"] == "KGRound1Message" {
-	    if msg["CPk"] == "" {
-		return nil
-	    }
-
-		cpks, err := hex.DecodeString(msg["CPk"])
-		if cpks == nil || err != nil {
-		    return nil
-		}
-
-		var temCpk [32]byte
-		copy(temCpk[:], cpks[:])
-		kg := &edkeygen.KGRound1Message{
-			KGRoundMessage: new(edkeygen.KGRoundMessage),
-			CPk:            temCpk,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//2 message
-	if msg["Type"] == "KGRound2Message" {
-	    if msg["ZkPk"] == "" {
-		return nil
-	    }
-
-		zkpks, err := hex.DecodeString(msg["ZkPk"])
-		if zkpks == nil || err != nil {
-		    return nil
-		}
-
-		var temzkpk [64]byte
-		copy(temzkpk[:], zkpks[:])
-		kg := &edkeygen.KGRound2Message{
-			KGRoundMessage: new(edkeygen.KGRoundMessage),
-			ZkPk:           temzkpk,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//3 message
-	if msg["Type"] == "KGRound3Message" {
-	    if msg["DPk"] == "" {
-		return nil
-	    }
-
-		dpks, err := hex.DecodeString(msg["DPk"])
-		if dpks == nil || err != nil {
-		    return nil
-		}
-
-		var temdpk [64]byte
-		copy(temdpk[:], dpks[:])
-		kg := &edkeygen.KGRound3Message{
-			KGRoundMessage: new(edkeygen.KGRoundMessage),
-			DPk:            temdpk,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//4 message
-	if msg["Type"] == "KGRound4Message" {
-	    if msg["Share"] == "" {
-		return nil
-	    }
-
-		shares, err := hex.DecodeString(msg["Share"])
-		if shares == nil || err != nil {
-		    return nil
-		}
-
-		var temsh [32]byte
-		copy(temsh[:], shares[:])
-		kg := &edkeygen.KGRound4Message{
-			KGRoundMessage: new(edkeygen.KGRoundMessage),
-			Share:          temsh,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	//5 message
-	if msg["Type"] == "KGRound5Message" {
-	    if msg["CfsBBytes"] == "" {
-		return nil
-	    }
-
-		tmp := strings.Split(msg["CfsBBytes"], ":")
-		tmp2 := make([][32]byte, len(tmp))
-		for k, v := range tmp {
-			vv, err := hex.DecodeString(v)
-			if vv == nil || err != nil {
-			    return nil
-			}
-
-			var tem [32]byte
-			copy(tem[:], vv[:])
-			tmp2[k] = tem
-		}
-
-		kg := &edkeygen.KGRound5Message{
-			KGRoundMessage: new(edkeygen.KGRoundMessage),
-			CfsBBytes:      tmp2,
-		}
-		kg.SetFromID(from)
-		kg.SetFromIndex(index)
-		kg.ToID = to
-		return kg
-	}
-
-	kg := &edkeygen.KGRound0Message{
-		KGRoundMessage: new(edkeygen.KGRoundMessage),
-	}
-	kg.SetFromID(from)
-	kg.SetFromIndex(-1)
-	kg.ToID = to
-
-	return kg
+	return getRealMessage(msg, "eddsa/keygen")
 }
 
 // processKeyGenEDDSA  Obtain the data to be sent in each round and send it to other nodes until the end of the request command 
@@ -327,6 +176,7 @@ func processKeyGenEDDSA(msgprex string, errChan chan struct{}, outCh <-chan smpc
 
 			w.edsku1.PushBack(string(msg.Sk[:]))
 			w.edpk.PushBack(string(msg.FinalPkBytes[:]))
+			w.bip32c.PushBack(string(msg.C[:]))
 
 			s := "XXX" + common.Sep11 + string(msg.Pk[:]) + common.Sep11 + string(msg.TSk[:]) + common.Sep11 + string(msg.FinalPkBytes[:])
 			w.edsave.PushBack(string(s))
@@ -0,0 +1,487 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/p2p/discover"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	srsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/sr25519/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc/backup"
+	"github.com/fsn-dev/cryptoCoins/coins"
+	cryptocoinsconfig "github.com/fsn-dev/cryptoCoins/coins/config"
+)
+
+// BackupKey the key material of one pubkey of the node
+type BackupKey struct {
+	PubKey     string // hex of the pubkey
+	KeyType    string
+	PubKeyData string // hex of the compressed PubKeyData in the general database,it includes the paillier/ntilde save data
+	SkU1       string // hex of sku1
+	Bip32C     string // hex of bip32 c value
+}
+
+// BackupData the key material of the node in the backup file
+type BackupData struct {
+	Enode string
+	Keys  []*BackupKey
+}
+
+// OpenLocalDbForBackup open the local databases of the node with the node key keyfile,the gsmpc node must not be running
+// the datadir must be set by common.InitDir before
+func OpenLocalDbForBackup(keyfile string) error {
+	nodeKey, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		return err
+	}
+
+	KeyFile = keyfile
+	curEnode = discover.PubkeyID(&nodeKey.PublicKey).String()
+
+	db = GetSmpcDb()
+	if db == nil {
+		return errors.New("open db fail")
+	}
+
+	dbsk = GetSmpcSkDb()
+	if dbsk == nil {
+		CloseLocalDbForBackup()
+		return errors.New("open dbsk fail")
+	}
+
+	dbbip32 = GetSmpcBip32Db()
+	if dbbip32 == nil {
+		CloseLocalDbForBackup()
+		return errors.New("open dbbip32 fail")
+	}
+
+	accountsdb = GetSmpcAccountsDirDb()
+	if accountsdb == nil {
+		CloseLocalDbForBackup()
+		return errors.New("open accountsdb fail")
+	}
+
+	cryptocoinsconfig.Init()
+	coins.Init()
+	return nil
+}
+
+// CloseLocalDbForBackup close the local databases opened by OpenLocalDbForBackup
+func CloseLocalDbForBackup() {
+	if db != nil {
+		db.Close()
+		db = nil
+	}
+
+	if dbsk != nil {
+		dbsk.Close()
+		dbsk = nil
+	}
+
+	if dbbip32 != nil {
+		dbbip32.Close()
+		dbbip32 = nil
+	}
+
+	if accountsdb != nil {
+		accountsdb.Close()
+		accountsdb = nil
+	}
+}
+
+// getBackupKey get the key material of the pubkey pk from local db
+func getBackupKey(pk []byte, value []byte) (*BackupKey, error) {
+	pd, err := decodeBackupPubKeyData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkey := hex.EncodeToString(pk)
+	sku1 := getSkU1FromLocalDb(pk)
+	if sku1 == nil {
+		return nil, fmt.Errorf("get sku1 of pubkey %v fail", pubkey)
+	}
+
+	c := getBip32cFromLocalDb(pk)
+	if c == nil {
+		return nil, fmt.Errorf("get bip32c of pubkey %v fail", pubkey)
+	}
+
+	return &BackupKey{PubKey: pubkey, KeyType: getPubKeyType(pd), PubKeyData: hex.EncodeToString(value), SkU1: hex.EncodeToString(sku1), Bip32C: hex.EncodeToString(c)}, nil
+}
+
+// ExportBackup export the key material of pubkey from local db,"" is all the pubkeys of the node
+// see backup.Seal for passphrases,threshold and total
+func ExportBackup(pubkey string, passphrases []string, threshold int, total int) ([][]byte, error) {
+	if db == nil {
+		return nil, errors.New("local db is not opened")
+	}
+
+	data := &BackupData{Enode: curEnode}
+	if pubkey != "" {
+		pk, err := hex.DecodeString(pubkey)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := db.Get(pk)
+		if err != nil || value == nil {
+			return nil, fmt.Errorf("pubkey %v is not found", pubkey)
+		}
+
+		key, err := getBackupKey(pk, value)
+		if err != nil {
+			return nil, err
+		}
+
+		data.Keys = append(data.Keys, key)
+	} else {
+		iter := db.NewIterator()
+		for iter.Next() {
+			pd, err := decodeBackupPubKeyData(iter.Value())
+			if err != nil {
+				continue
+			}
+
+			// the same PubKeyData is also saved under the coin address keys
+			if pd.Pub != string(iter.Key()) {
+				continue
+			}
+
+			pk := append([]byte{}, iter.Key()...)
+			value := append([]byte{}, iter.Value()...)
+			key, err := getBackupKey(pk, value)
+			if err != nil {
+				iter.Release()
+				return nil, err
+			}
+
+			data.Keys = append(data.Keys, key)
+		}
+		iter.Release()
+	}
+
+	if len(data.Keys) == 0 {
+		return nil, errors.New("no pubkey to back up")
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.Seal(b, curEnode, len(data.Keys), passphrases, threshold, total)
+}
+
+// ImportBackup verify the key material in the backup files and write it into local db,return the imported pubkeys
+// the backup must belong to the current node,because the uid of the node in the group is derived from its enode id
+// nothing is written if any key fails to verify or already exists.
+// The EC data saved before the public shares were added can not be verified against the pubkey,
+// the sku1 already saved for such a pubkey is only overwritten if force is set
+func ImportBackup(files [][]byte, passphrases []string, force bool) ([]string, error) {
+	if db == nil {
+		return nil, errors.New("local db is not opened")
+	}
+
+	b, _, err := backup.Open(files, passphrases)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &BackupData{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, errors.New("invalid backup data")
+	}
+
+	if !strings.EqualFold(data.Enode, curEnode) {
+		return nil, fmt.Errorf("the backup belongs to node %v,not the current node %v", data.Enode, curEnode)
+	}
+
+	for _, key := range data.Keys {
+		verified, err := verifyBackupKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("verify pubkey %v fail: %v", key.PubKey, err)
+		}
+
+		pk, _ := hex.DecodeString(key.PubKey)
+		if has, _ := db.Has(pk); has {
+			return nil, fmt.Errorf("pubkey %v already exists", key.PubKey)
+		}
+
+		if err := checkSkU1Overwrite(key, verified, force); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]string, 0, len(data.Keys))
+	for _, key := range data.Keys {
+		if err := putBackupKey(key); err != nil {
+			return ret, fmt.Errorf("write pubkey %v fail: %v", key.PubKey, err)
+		}
+
+		ret = append(ret, key.PubKey)
+	}
+
+	return ret, nil
+}
+
+// decodeBackupPubKeyData decode the compressed PubKeyData in the general database
+func decodeBackupPubKeyData(value []byte) (*PubKeyData, error) {
+	ss, err := UnCompress(string(value))
+	if err != nil {
+		return nil, err
+	}
+
+	pubs, err := Decode2(ss, "PubKeyData")
+	if err != nil {
+		return nil, err
+	}
+
+	pd, ok := pubs.(*PubKeyData)
+	if !ok || pd.Pub == "" || pd.Save == "" {
+		return nil, errors.New("invalid pubkey data")
+	}
+
+	return pd, nil
+}
+
+// verifyBackupKey verify the key material against the pubkey,return whether sku1 is verified to be the share of the pubkey
+// ED25519/SR25519: sku1 must be the secret of the node's Pk in the save data,and the FinalPk must be the pubkey
+// EC256K1/EC256R1: sku1*G must be one of the public shares in PubKeyData,the public shares must interpolate to the pubkey,
+// and the paillier private key must match one of the paillier public keys in the save data.
+// The data saved before the public shares were added has none,only sku1 is checked to be in [1,N) for it and false is returned
+func verifyBackupKey(key *BackupKey) (bool, error) {
+	pk, err := hex.DecodeString(key.PubKey)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := hex.DecodeString(key.PubKeyData)
+	if err != nil {
+		return false, err
+	}
+
+	pd, err := decodeBackupPubKeyData(value)
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal([]byte(pd.Pub), pk) {
+		return false, errors.New("pubkey mismatch")
+	}
+
+	keytype := getPubKeyType(pd)
+	if keytype != key.KeyType {
+		return false, errors.New("keytype mismatch")
+	}
+
+	sku1, err := hex.DecodeString(key.SkU1)
+	if err != nil || len(sku1) == 0 {
+		return false, errors.New("invalid sku1")
+	}
+
+	c, err := hex.DecodeString(key.Bip32C)
+	if err != nil || len(c) == 0 {
+		return false, errors.New("invalid bip32c")
+	}
+
+	if keytype == "ED25519" || keytype == "SR25519" {
+		mm := strings.Split(pd.Save, common.Sep11)
+		if len(mm) < 4 || len(mm[1]) != 32 || len(mm[3]) != 32 || len(sku1) != 32 {
+			return false, errors.New("invalid save data")
+		}
+
+		var sk [32]byte
+		var nodepk [32]byte
+		copy(sk[:], sku1)
+		var A ed.ExtendedGroupElement
+		ed.GeScalarMultBase(&A, &sk)
+		A.ToBytes(&nodepk)
+		if !bytes.Equal(nodepk[:], []byte(mm[1])) {
+			return false, errors.New("sku1 does not match the save data")
+		}
+
+		var finalpk [32]byte
+		copy(finalpk[:], mm[3])
+		if keytype == "SR25519" {
+			finalpk, err = srsigning.PubKeyFromEd(finalpk)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if !bytes.Equal(finalpk[:], pk) {
+			return false, errors.New("the save data does not match the pubkey")
+		}
+
+		return true, nil
+	}
+
+	sk := new(big.Int).SetBytes(sku1)
+	if sk.Sign() <= 0 || sk.Cmp(ec2.GetCurve(keytype).Params().N) >= 0 {
+		return false, errors.New("invalid sku1")
+	}
+
+	verified := false
+	if pd.PubShares != "" {
+		if err := verifyBackupPubShares(pd, keytype, pk, sk); err != nil {
+			return false, err
+		}
+		verified = true
+	}
+
+	mm := strings.Split(pd.Save, common.SepSave)
+	if len(mm) < 4 {
+		return false, errors.New("invalid save data")
+	}
+
+	m := big.NewInt(0x5a5a)
+	for i := 0; ; i++ {
+		publicKey := GetPaillierPkByIndexFromSaveData(pd.Save, i)
+		if publicKey == nil || publicKey.N.Sign() == 0 {
+			break
+		}
+
+		privateKey := &ec2.PrivateKey{Length: mm[1], PublicKey: *publicKey, L: new(big.Int).SetBytes([]byte(mm[2])), U: new(big.Int).SetBytes([]byte(mm[3]))}
+		cipher, _, err := publicKey.Encrypt(m)
+		if err != nil {
+			continue
+		}
+
+		plain, err := privateKey.Decrypt(cipher)
+		if err == nil && plain.Cmp(m) == 0 {
+			return verified, nil
+		}
+	}
+
+	return false, errors.New("the paillier private key does not match the save data")
+}
+
+// verifyBackupPubShares check sku1*G is one of the public shares in pd and the public shares lie on the polynomial of the pubkey pk
+func verifyBackupPubShares(pd *PubKeyData, keytype string, pk []byte, sk *big.Int) error {
+	ids, pubShares, err := decodePubShares(pd.PubShares)
+	if err != nil {
+		return err
+	}
+
+	threshold, err := getRefreshThreshold(pd.LimitNum)
+	if err != nil {
+		return err
+	}
+
+	curve := ec2.GetCurve(keytype)
+	pkx, pky := elliptic.Unmarshal(curve, pk)
+	if pkx == nil {
+		return errors.New("invalid pubkey")
+	}
+
+	xiGx, xiGy := curve.ScalarBaseMult(sk.Bytes())
+	found := false
+	for _, v := range pubShares {
+		if v[0].Cmp(xiGx) == 0 && v[1].Cmp(xiGy) == 0 {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return errors.New("sku1 does not match the public shares")
+	}
+
+	if !ec2.VerifyPubShares(curve, ids, pubShares, threshold, pkx, pky) {
+		return errors.New("the public shares do not match the pubkey")
+	}
+
+	return nil
+}
+
+// checkSkU1Overwrite refuse to overwrite the sku1 already saved for the pubkey by a sku1 that is not verified,unless force is set
+func checkSkU1Overwrite(key *BackupKey, verified bool, force bool) error {
+	if verified || force || dbsk == nil {
+		return nil
+	}
+
+	for _, k := range backupKeys(key) {
+		if has, _ := dbsk.Has(k); has {
+			return fmt.Errorf("the sku1 of pubkey %v already exists and the backup has no public shares to verify it,use force to overwrite it", key.PubKey)
+		}
+	}
+
+	return nil
+}
+
+// backupKeys the keys that the key material of the pubkey is saved under,the pubkey and its coin address hashes
+func backupKeys(key *BackupKey) [][]byte {
+	pk, _ := hex.DecodeString(key.PubKey)
+	keys := [][]byte{pk}
+	for _, ct := range getCoinTypes(key.KeyType) {
+		if strings.EqualFold(ct, "ALL") {
+			continue
+		}
+
+		h := coins.NewCryptocoinHandler(ct)
+		if h == nil {
+			continue
+		}
+		ctaddr, err := h.PublicKeyToAddress(key.PubKey)
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, []byte(Keccak256Hash([]byte(strings.ToLower(ctaddr))).Hex()))
+	}
+
+	return keys
+}
+
+// putBackupKey write the key material into local db the same way as smpcGenPubKey
+func putBackupKey(key *BackupKey) error {
+	value, _ := hex.DecodeString(key.PubKeyData)
+	sku1, _ := hex.DecodeString(key.SkU1)
+	c, _ := hex.DecodeString(key.Bip32C)
+
+	for _, k := range backupKeys(key) {
+		if err := PutPubKeyData(k, value); err != nil {
+			return err
+		}
+
+		if err := PutAccountDataToDb(k, []byte(key.PubKey)); err != nil {
+			return err
+		}
+
+		if err := putSkU1ToLocalDb(k, sku1); err != nil {
+			return err
+		}
+
+		if err := putBip32cToLocalDb(k, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSharesEncode(t *testing.T) {
+	ids := smpclib.SortableIDSSlice{big.NewInt(3), big.NewInt(11)}
+	pubShares := [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}}
+
+	s := encodePubShares(ids, pubShares)
+	ids2, pubShares2, err := decodePubShares(s)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ids, ids2)
+	assert.Equal(t, pubShares, pubShares2)
+
+	assert.Equal(t, "", encodePubShares(ids, pubShares[:1]), "count mismatch")
+	_, _, err = decodePubShares("")
+	assert.Error(t, err)
+	_, _, err = decodePubShares("1:2")
+	assert.Error(t, err)
+	_, _, err = decodePubShares("1:2:x")
+	assert.Error(t, err)
+}
+
+func TestVerifyBackupPubShares(t *testing.T) {
+	curve := ec2.GetCurve("EC256K1")
+	sk := big.NewInt(0x5eed)
+	poly, polyG, err := ec2.Vss2Init(curve, sk, 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ids := smpclib.SortableIDSSlice{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	shares, err := poly.Vss2(curve, ids)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pubShares := make([][]*big.Int, len(ids))
+	for k, id := range ids {
+		x, y := polyG.PubShare(curve, id)
+		pubShares[k] = []*big.Int{x, y}
+	}
+
+	pkx, pky := curve.ScalarBaseMult(sk.Bytes())
+	pk := elliptic.Marshal(curve, pkx, pky)
+	pd := &PubKeyData{LimitNum: "2/3", PubShares: encodePubShares(ids, pubShares)}
+
+	for _, v := range shares {
+		assert.NoError(t, verifyBackupPubShares(pd, "EC256K1", pk, v.Share))
+	}
+
+	// a share that is not dealt to any node
+	assert.Error(t, verifyBackupPubShares(pd, "EC256K1", pk, big.NewInt(7)))
+
+	// the public shares of another pubkey
+	ox, oy := curve.ScalarBaseMult(big.NewInt(7).Bytes())
+	assert.Error(t, verifyBackupPubShares(pd, "EC256K1", elliptic.Marshal(curve, ox, oy), shares[0].Share))
+
+	// a public share that is not on the polynomial
+	bad := &PubKeyData{LimitNum: "2/3", PubShares: encodePubShares(ids, [][]*big.Int{pubShares[0], pubShares[1], {ox, oy}})}
+	assert.Error(t, verifyBackupPubShares(bad, "EC256K1", pk, shares[0].Share))
+}
+
+func TestCheckSkU1Overwrite(t *testing.T) {
+	db, err := ethdb.NewLDBDatabase(t.TempDir(), cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	old := dbsk
+	dbsk = db
+	defer func() {
+		db.Close()
+		dbsk = old
+	}()
+
+	curve := ec2.GetCurve("EC256K1")
+	x, y := curve.ScalarBaseMult(big.NewInt(5).Bytes())
+	pk := elliptic.Marshal(curve, x, y)
+	key := &BackupKey{PubKey: hex.EncodeToString(pk), KeyType: "EC256K1"}
+
+	assert.NoError(t, checkSkU1Overwrite(key, false, false), "no sku1 saved yet")
+
+	assert.NoError(t, dbsk.Put(pk, []byte("sku1")))
+	assert.Error(t, checkSkU1Overwrite(key, false, false), "the unverified sku1 must not overwrite the saved one")
+	assert.NoError(t, checkSkU1Overwrite(key, true, false), "the verified sku1 can overwrite the saved one")
+	assert.NoError(t, checkSkU1Overwrite(key, false, true), "force")
+}
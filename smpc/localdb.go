@@ -35,10 +35,12 @@ var (
 	dbbip32 *ethdb.LDBDatabase
 	predb   *ethdb.LDBDatabase
 	prekey  *ethdb.LDBDatabase
+	preparamsdb *ethdb.LDBDatabase
 
 	reqaddrinfodb *ethdb.LDBDatabase
 	signinfodb    *ethdb.LDBDatabase
 	reshareinfodb *ethdb.LDBDatabase
+	recovershareinfodb *ethdb.LDBDatabase
 	accountsdb    *ethdb.LDBDatabase
 )
 
@@ -105,6 +107,14 @@ func GetPubKeyData(key []byte) (bool, interface{}) {
 		}
 	}
 
+	pubs6, err := Decode2(ss, "AcceptRecoverShareData")
+	if err == nil {
+		pd, ok := pubs6.(*AcceptRecoverShareData)
+		if ok && pd.Enode != "" {
+			return true, pd
+		}
+	}
+
 	pubs, err := Decode2(ss, "AcceptReqAddrData")
 	if err == nil {
 		pd, ok := pubs.(*AcceptReqAddrData)
@@ -224,6 +234,43 @@ func putSkU1ToLocalDb(key []byte, value []byte) error {
 
 //-------------------------------------------------------------------------------------------------------------
 
+// putSkU1BatchToLocalDb put the same Sk under all keys to local db in one batch,either all keys are updated or none
+func putSkU1BatchToLocalDb(keys [][]byte, value []byte) error {
+	if dbsk == nil || len(keys) == 0 || value == nil {
+		return fmt.Errorf("put sku1 data to db fail")
+	}
+
+	cm, err := EncryptMsg(string(value), curEnode)
+	if err != nil {
+		common.Error("===============putSkU1BatchToLocalDb, encrypt sku1 data fail.=================", "err", err)
+		return err
+	}
+
+	batch := dbsk.NewBatch()
+	for _, key := range keys {
+		if key == nil {
+			return fmt.Errorf("put sku1 data to db fail")
+		}
+
+		err = batch.Put(key, []byte(cm))
+		if err != nil {
+			common.Error("===============putSkU1BatchToLocalDb, put sku1 data to batch fail.=================", "err", err)
+			return err
+		}
+	}
+
+	err = batch.Write()
+	if err == nil {
+		common.Debug("===============putSkU1BatchToLocalDb, put sku1 data into db success.=================", "keys", len(keys))
+		return nil
+	}
+
+	common.Error("===============putSkU1BatchToLocalDb, put sku1 data to db fail.=================", "err", err)
+	return err
+}
+
+//-------------------------------------------------------------------------------------------------------------
+
 // putBip32cToLocalDb put bip32 c value to local db
 func putBip32cToLocalDb(key []byte, value []byte) error {
 	if dbbip32 == nil || key == nil || value == nil {
@@ -486,6 +533,74 @@ func DeleteReShareInfoData(key []byte) error {
 	return err
 }
 
+//------------------------------------------------------
+
+// GetRecoverShareInfoData get value by key from database for saving data related to recover share command 
+func GetRecoverShareInfoData(key []byte) (bool, interface{}) {
+	if key == nil || recovershareinfodb == nil {
+		common.Error("========================GetRecoverShareInfoData, param err=======================", "key", string(key))
+		return false, nil
+	}
+
+	da, err := recovershareinfodb.Get(key)
+	if da == nil || err != nil {
+		common.Debug("========================GetRecoverShareInfoData, get recover share info from local db fail =======================", "key", string(key))
+		return false, nil
+	}
+
+	ss, err := UnCompress(string(da))
+	if err != nil {
+		common.Debug("========================GetRecoverShareInfoData, uncompress err=======================", "err", err, "key", string(key))
+		return true, da
+	}
+
+	pubs, err := Decode2(ss, "AcceptRecoverShareData")
+	if err == nil {
+		pd, ok := pubs.(*AcceptRecoverShareData)
+		if ok && pd.Enode != "" {
+			return true, pd
+		}
+	}
+
+	return false, nil
+}
+
+//-------------------------------------------------------
+
+// PutRecoverShareInfoData put value to database for saving data related to recover share command 
+func PutRecoverShareInfoData(key []byte, value []byte) error {
+	if recovershareinfodb == nil || key == nil || value == nil {
+		return fmt.Errorf("put recover share info to db fail")
+	}
+
+	err := recovershareinfodb.Put(key, value)
+	if err == nil {
+		common.Debug("===============PutRecoverShareInfoData, put recover share info into db success.=================", "key", string(key))
+		return nil
+	}
+
+	common.Error("===============PutRecoverShareInfoData, put recover share info into db fail.=================", "key", string(key), "err", err)
+	return err
+}
+
+//-------------------------------------------------------
+
+// DeleteRecoverShareInfoData delete value from database for saving data related to recover share command 
+func DeleteRecoverShareInfoData(key []byte) error {
+	if key == nil || recovershareinfodb == nil {
+		return fmt.Errorf("delete recover share info from db fail")
+	}
+
+	err := recovershareinfodb.Delete(key)
+	if err == nil {
+		common.Debug("===============DeleteRecoverShareInfoData, del recover share info from db success.=================", "key", string(key))
+		return nil
+	}
+
+	common.Error("===============DeleteRecoverShareInfoData, delete recover share info from db fail.=================", "key", string(key), "err", err)
+	return err
+}
+
 //-------------------------------------------------------
 
 // GetGroupDir get P2P group info database dir 
@@ -617,6 +732,27 @@ func GetSmpcPreKeyDb() *ethdb.LDBDatabase {
 
 //---------------------------------------------------------------
 
+// GetPreParamsDir get the dir of database for saving the pre-generated paillier key and ntilde data
+func GetPreParamsDir() string {
+	dir := common.DefaultDataDir()
+	dir += "/smpcdata/smpcpreparams" + curEnode
+	return dir
+}
+
+// GetSmpcPreParamsDb open database for saving the pre-generated paillier key and ntilde data
+func GetSmpcPreParamsDb() *ethdb.LDBDatabase {
+	dir := GetPreParamsDir()
+	preparamsdb, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if err != nil {
+		common.Error("======================smpc.Start,open preparamsdb fail======================", "err", err, "dir", dir)
+		return nil
+	}
+
+	return preparamsdb
+}
+
+//---------------------------------------------------------------
+
 // GetReqAddrInfoDir get dir of database for saving data related to generate pubkey command
 func GetReqAddrInfoDir() string {
 	dir := common.DefaultDataDir()
@@ -680,6 +816,27 @@ func GetSmpcReShareInfoDb() *ethdb.LDBDatabase {
 
 //--------------------------------------------------------------
 
+// GetRecoverShareInfoDir get dir of database for saving data related to recover share command
+func GetRecoverShareInfoDir() string {
+	dir := common.DefaultDataDir()
+	dir += "/smpcdata/smpcrecovershareinfo" + curEnode
+	return dir
+}
+
+// GetSmpcRecoverShareInfoDb open database for saving data related to recover share command
+func GetSmpcRecoverShareInfoDb() *ethdb.LDBDatabase {
+	dir := GetRecoverShareInfoDir()
+	recovershareinfodb, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if err != nil {
+		common.Error("======================smpc.Start,open recovershareinfodb fail======================", "err", err, "dir", dir)
+		return nil
+	}
+
+	return recovershareinfodb
+}
+
+//--------------------------------------------------------------
+
 // StartSmpcLocalDb open all database
 func StartSmpcLocalDb() error {
 	db = GetSmpcDb()
@@ -712,6 +869,12 @@ func StartSmpcLocalDb() error {
 		return errors.New("open prekey fail")
 	}
 
+	preparamsdb = GetSmpcPreParamsDb()
+	if preparamsdb == nil {
+		common.Error("======================StartSmpcLocalDb,open preparamsdb fail=====================")
+		return errors.New("open preparamsdb fail")
+	}
+
 	reqaddrinfodb = GetCmdReqAddrInfoDb()
 	if reqaddrinfodb == nil {
 		common.Error("======================StartSmpcLocalDb,open reqaddrinfodb fail=====================")
@@ -730,6 +893,12 @@ func StartSmpcLocalDb() error {
 		return errors.New("open reshareinfodb fail")
 	}
 
+	recovershareinfodb = GetSmpcRecoverShareInfoDb()
+	if recovershareinfodb == nil {
+		common.Error("======================StartSmpcLocalDb,open recovershareinfodb fail=====================")
+		return errors.New("open recovershareinfodb fail")
+	}
+
 	accountsdb = GetSmpcAccountsDirDb()
 	if accountsdb == nil {
 		common.Error("======================StartSmpcLocalDb,open accountsdb fail=====================")
@@ -784,6 +953,8 @@ func CleanUpAllReqAddrInfo() {
 		if err != nil {
 		    continue
 		}
+
+		notifyFinished("REQSMPCADDR", string(key), vv.Account, vv.PubKey, vv.GroupID, vv.Status, vv.PubKey, vv.Tip, vv.Error)
 	}
 	iter.Release()
 }
@@ -833,6 +1004,8 @@ func CleanUpAllSignInfo() {
 		if err != nil {
 			continue
 		}
+
+		notifyFinished("SIGN", string(key), vv.Account, vv.PubKey, vv.GroupID, vv.Status, vv.Rsv, vv.Tip, vv.Error)
 	}
 	iter.Release()
 }
@@ -882,6 +1055,59 @@ func CleanUpAllReshareInfo() {
 		if err != nil {
 			continue
 		}
+
+		notifyFinished("RESHARE", string(key), vv.Account, vv.PubKey, vv.GroupID, vv.Status, vv.PubKey, vv.Tip, vv.Error)
+	}
+	iter.Release()
+}
+
+//------------------------------------------------------------------------------------------------
+
+// CleanUpAllRecoverShareInfo Delete the data related to recover share command from the corresponding sub database, and correspondingly change the status of the command data to timeout in the general database.
+func CleanUpAllRecoverShareInfo() {
+	if recovershareinfodb == nil {
+		return
+	}
+
+	iter := recovershareinfodb.NewIterator()
+	for iter.Next() {
+		key := []byte(string(iter.Key())) //must be deep copy, Otherwise, an error will be reported: "panic: JSON decoder out of sync - data changing underfoot?"
+		if len(key) == 0 {
+			continue
+		}
+
+		exsit, da := GetRecoverShareInfoData(key)
+		if !exsit || da == nil {
+			continue
+		}
+
+		vv, ok := da.(*AcceptRecoverShareData)
+		if vv == nil || !ok {
+			continue
+		}
+
+		vv.Status = "Timeout"
+
+		e, err := Encode2(vv)
+		if err != nil {
+			continue
+		}
+
+		es, err := Compress([]byte(e))
+		if err != nil {
+			continue
+		}
+
+		err = DeleteRecoverShareInfoData(key)
+		if err != nil {
+			continue
+		}
+		err = PutPubKeyData(key, []byte(es))
+		if err != nil {
+			continue
+		}
+
+		notifyFinished(getRecoverShareTxType(vv), string(key), vv.Account, vv.PubKey, vv.GroupID, vv.Status, vv.Enode, vv.Tip, vv.Error)
 	}
 	iter.Release()
 }
@@ -48,6 +48,9 @@ var (
 	//ec keygen timeout
 	EcKeygenTimeout = 1200
 
+	// EcKeygenJustifyTimeout the seconds the ec keygen waits for the accused dealers to justify
+	EcKeygenJustifyTimeout = 60
+
 	//ed keygen timeout
 	EdKeygenTimeout = 1200
 
@@ -89,6 +92,9 @@ var (
 	// ParseNode p2p callback
 	ParseNode              func(string) string
 
+	// NegotiateGroupVersion p2p callback
+	NegotiateGroupVersion  func(string) (uint, error)
+
 	// GetEosAccount p2p callback
 	GetEosAccount          func() (string, string, string)
 	
@@ -130,6 +136,11 @@ func RegP2pParseNodeCallBack(f func(string) string) {
 	ParseNode = f
 }
 
+// RegP2pNegotiateGroupVersionCallBack set p2p callback func NegotiateGroupVersion
+func RegP2pNegotiateGroupVersionCallBack(f func(string) (uint, error)) {
+	NegotiateGroupVersion = f
+}
+
 // RegSmpcGetEosAccountCallBack set p2p callback func GetEosAccount
 func RegSmpcGetEosAccountCallBack(f func() (string, string, string)) {
 	GetEosAccount = f
@@ -408,7 +419,7 @@ func IsEDSignCmd(raw string) (string, bool) {
 
 	sig, ok := txdata.(*TxDataSign)
 	if ok {
-		if sig.Keytype == "ED25519" {
+		if sig.Keytype == "ED25519" || sig.Keytype == "SR25519" {
 			return key, true
 		}
 
@@ -536,8 +547,7 @@ func Call(msg interface{}, enode string) {
 	//	s = msgdata
 	//}
 
-	msgmap := make(map[string]string)
-	err = json.Unmarshal([]byte(s), &msgmap)
+	msgmap, err := decodeP2pMsg(s)
 	if err == nil {
 	    ok,keytmp,gidtmp,ss := IsMsg2Peer(msgmap)
 	    if ok {
@@ -564,8 +574,7 @@ func Call(msg interface{}, enode string) {
 		}
 
 		s = ss
-		msgmap = make(map[string]string)
-		err = json.Unmarshal([]byte(s), &msgmap)
+		msgmap, err = decodeP2pMsg(s)
 		if err != nil {
 		    return
 		}
@@ -759,6 +768,10 @@ func HandleKG(key string, uid *big.Int) {
 	Handle(key, c1data)
 	c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound3Message1")
 	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound3Message2")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound3Message3")
+	Handle(key, c1data)
 	c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound4Message")
 	Handle(key, c1data)
 	c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound5Message")
@@ -767,6 +780,8 @@ func HandleKG(key string, uid *big.Int) {
         Handle(key, c1data)
         c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound5Message2")
         Handle(key, c1data)
+        c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound5Message3")
+        Handle(key, c1data)
         c1data = strings.ToLower(key + "-" + tmp + "-" + "KGRound6Message")
         Handle(key, c1data)
 }
@@ -795,10 +810,18 @@ func HandleSign(key string, uid *big.Int) {
 	Handle(key, c1data)
 	c1data = strings.ToLower(key + "-" + tmp + "-" + "SignRound9Message")
 	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "PreSignRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "PreSignRound1Message1")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "PreSignRound2Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "PreSignRound3Message")
+	Handle(key, c1data)
 }
 
 //HandleC1Data C1Data Key, Three formats are included:
-// 1.  key-enodefrom, for reshare only, enodefrom get from enodeID
+// 1.  key-enodefrom, for reshare and recover share only, enodefrom get from enodeID
 // 2.  key-uid-msgtype, for example: key-uid-"KGRound0Message"
 // 3.  key-accout,for accept reply
 func HandleC1Data(ac *AcceptReqAddrData, key string) {
@@ -807,19 +830,24 @@ func HandleC1Data(ac *AcceptReqAddrData, key string) {
 		return
 	}
 
-	//reshare only
+	//reshare and recover share only
 	if ac == nil {
-		exsit, da := GetReShareInfoData([]byte(key))
-		if !exsit {
-			return
+		groupid := ""
+		if exsit, da := GetReShareInfoData([]byte(key)); exsit {
+			if ac, ok := da.(*AcceptReShareData); ok && ac != nil {
+				groupid = ac.GroupID
+			}
+		} else if exsit, da := GetRecoverShareInfoData([]byte(key)); exsit {
+			if ac, ok := da.(*AcceptRecoverShareData); ok && ac != nil {
+				groupid = ac.GroupID
+			}
 		}
 
-		ac, ok := da.(*AcceptReShareData)
-		if !ok || ac == nil {
+		if groupid == "" {
 			return
 		}
 
-		_, enodes := GetGroup(ac.GroupID)
+		_, enodes := GetGroup(groupid)
 		nodes := strings.Split(enodes, common.Sep2)
 		for _, node := range nodes {
 			node2 := ParseNode(node)
@@ -844,7 +872,7 @@ func HandleC1Data(ac *AcceptReqAddrData, key string) {
 
 		return
 	}
-	//reshare only
+	//reshare and recover share only
 
 	if key == "" {
 		return
@@ -853,14 +881,22 @@ func HandleC1Data(ac *AcceptReqAddrData, key string) {
 	_, enodes := GetGroup(ac.GroupID)
 	nodes := strings.Split(enodes, common.Sep2)
 
+	// the uids of the ecdsa keygen are made with the keytype of the key
+	eckeytype := "EC256K1"
+	if ac.Cointype == "EC256R1" {
+		eckeytype = ac.Cointype
+	}
+
 	for _, node := range nodes {
 		node2 := ParseNode(node)
-		_,uid := GetNodeUID(node2, "EC256K1",ac.GroupID)
+		_,uid := GetNodeUID(node2, eckeytype,ac.GroupID)
 		HandleKG(key, uid)
 		HandleSign(key, uid)
+		HandleSchnorrSign(key, uid)
 		_,uid = GetNodeUID(node2, "ED25519",ac.GroupID)
 		HandleKG(key, uid)
 		HandleSign(key, uid)
+		HandleSrSign(key, uid)
 	}
 
 	// ED
@@ -962,6 +998,11 @@ func GetRawType(raw string) (string, string) {
 		return "RESHARE", key
 	}
 
+	_, ok = txdata.(*TxDataRecoverShare)
+	if ok {
+		return "RECOVERSHARE", key
+	}
+
 	acceptreq, ok := txdata.(*TxDataAcceptReqAddr)
 	if ok {
 		return "ACCEPTREQADDR", acceptreq.Key
@@ -977,6 +1018,11 @@ func GetRawType(raw string) (string, string) {
 		return "ACCEPTRESHARE", acceptreshare.Key
 	}
 
+	acceptrecovershare, ok := txdata.(*TxDataAcceptRecoverShare)
+	if ok {
+		return "ACCEPTRECOVERSHARE", acceptrecovershare.Key
+	}
+
 	return "", ""
 }
 
@@ -1010,6 +1056,10 @@ func DisAcceptMsg(raw string, workid int) {
 		req = &ReqSmpcSign{}
 	case "ACCEPTRESHARE":
 		req = &ReqSmpcReshare{}
+	case "RECOVERSHARE":
+		req = &ReqSmpcRecoverShare{}
+	case "ACCEPTRECOVERSHARE":
+		req = &ReqSmpcRecoverShare{}
 	}
 
 	req.DisAcceptMsg(raw, workid, key)
@@ -1040,6 +1090,10 @@ func MsgRun(raw string, workid int, sender string, ch chan interface{}) error {
 		req = &ReqSmpcSign{}
 	case "ACCEPTRESHARE":
 		req = &ReqSmpcReshare{}
+	case "RECOVERSHARE":
+		req = &ReqSmpcRecoverShare{}
+	case "ACCEPTRECOVERSHARE":
+		req = &ReqSmpcRecoverShare{}
 	default:
 		return fmt.Errorf("Unsupported request type")
 	}
@@ -1081,7 +1135,7 @@ func GetGroupSigsDataByRaw(raw string) (string, error) {
 	var req2 CmdReq
 	req := TxDataReqAddr{}
 	err = json.Unmarshal(tx.Data(), &req)
-	if err == nil && req.TxType == "REQSMPCADDR" {
+	if err == nil && (req.TxType == "REQSMPCADDR" || req.TxType == "IMPORTKEY") {
 		req2 = &ReqSmpcAddr{}
 	} else {
 		rh := TxDataReShare{}
@@ -1103,8 +1157,8 @@ func GetGroupSigsDataByRaw(raw string) (string, error) {
 		return "", nil
 	}
 
-	if mode == "0" && groupsigs == "" {
-		return "", fmt.Errorf("raw data error,must have sigs data when mode = 0")
+	if isGroupApprovalMode(mode) && groupsigs == "" {
+		return "", fmt.Errorf("raw data error,must have sigs data when mode = %v", mode)
 	}
 
 	nums := strings.Split(threshold, "/")
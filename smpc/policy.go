@@ -0,0 +1,612 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	coincommon "github.com/fsn-dev/cryptoCoins/tools/common"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+)
+
+const (
+	// PolicyDefaultRule the name of the rule recorded when no rule matches the request
+	PolicyDefaultRule = "default"
+
+	// ModeGroupApproval the mode of the keygen/sign request that each node of the group approves manually with its own account (self-group)
+	// by AcceptReqAddr/AcceptSign,the approval policy never decides these requests
+	ModeGroupApproval = "0"
+
+	// ModeAgreed the mode of the keygen/sign request that is agreed by default and never waits for the approval of the nodes
+	ModeAgreed = "1"
+
+	// ModePolicyApproval the mode of the keygen/sign request that is approved by the nodes of the group the same way as ModeGroupApproval,
+	// but the node started with an approval policy decides it by the local policy instead of waiting for AcceptReqAddr/AcceptSign
+	ModePolicyApproval = "2"
+
+	// policyWorkers the number of the goroutines deciding the queued requests
+	policyWorkers = 4
+
+	// policyToAddr the to address of the tx built and signed by the node (the accept tx and the scheduled refresh command),the same as the one used by gsmpc-client
+	policyToAddr = "0x00000000000000000000000000000000000000dc"
+)
+
+// ApprovalRule one rule of the approval policy,the empty fields match any request.
+// The rule decides the request if all its fields match,the rules are checked in order.
+type ApprovalRule struct {
+	Name        string
+	Action      string   // AGREE or DISAGREE
+	Cmds        []string // REQSMPCADDR,SIGN
+	Accounts    []string // the initiator accounts
+	PubKeys     []string
+	GroupIDs    []string
+	MsgContexts []string // regexps,every MsgContext of the sign request must match one of them,the pattern is anchored so it must match the whole MsgContext
+	RateLimit   int      // the max requests agreed by the rule in Window seconds,0 means no limit
+	Window      uint64
+
+	msgContexts []*regexp.Regexp
+	agreed      []int64 // unix time of the requests agreed by the rule in the current window,saved in policydb next to the decisions
+}
+
+// ApprovalPolicy the local policy that agrees or disagrees the keygen/sign requests
+// waiting for the approval of this node automatically
+type ApprovalPolicy struct {
+	ApproverKey string // the private key file of the approval account of this node in the group
+	Default     string // the action if no rule matches,DISAGREE if it is empty
+	Rules       []*ApprovalRule
+
+	approver *ecdsa.PrivateKey
+	lock     sync.Mutex
+}
+
+// ApprovalDecision the decision made by the approval policy for one request
+type ApprovalDecision struct {
+	Key       string
+	CmdType   string
+	Account   string
+	PubKey    string
+	GroupID   string
+	Accept    string
+	Rule      string
+	Reason    string
+	TimeStamp string
+}
+
+// policyRequest the fields of the keygen/sign request checked by the rules
+type policyRequest struct {
+	CmdType    string
+	Key        string
+	Account    string
+	PubKey     string
+	GroupID    string
+	MsgContext []string
+}
+
+var (
+	approvalPolicy *ApprovalPolicy
+	policydb       *ethdb.LDBDatabase
+
+	policyQueue     []*policyRequest
+	policyQueueLock sync.Mutex
+	policyQueueCond = sync.NewCond(&policyQueueLock)
+)
+
+// LoadApprovalPolicy load the approval policy from the toml or json file
+func LoadApprovalPolicy(path string) (*ApprovalPolicy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ApprovalPolicy{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, p)
+	} else {
+		_, err = toml.Decode(string(b), p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode approval policy %v fail:%v", path, err)
+	}
+
+	if err := p.init(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// init check the policy,compile the msg context patterns and load the approver key
+func (p *ApprovalPolicy) init(dir string) error {
+	if p.Default == "" {
+		p.Default = "DISAGREE"
+	}
+
+	if p.Default != "AGREE" && p.Default != "DISAGREE" {
+		return fmt.Errorf("invalid default action %v,it must be AGREE or DISAGREE", p.Default)
+	}
+
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			r.Name = "rule" + strconv.Itoa(i)
+		}
+
+		if r.Action != "AGREE" && r.Action != "DISAGREE" {
+			return fmt.Errorf("invalid action %v of rule %v,it must be AGREE or DISAGREE", r.Action, r.Name)
+		}
+
+		for _, c := range r.Cmds {
+			if c != "REQSMPCADDR" && c != "SIGN" {
+				return fmt.Errorf("invalid cmd %v of rule %v,it must be REQSMPCADDR or SIGN", c, r.Name)
+			}
+		}
+
+		if r.RateLimit > 0 && r.Window == 0 {
+			return fmt.Errorf("the window of rule %v must be set if the rate limit is set", r.Name)
+		}
+
+		r.msgContexts = make([]*regexp.Regexp, 0, len(r.MsgContexts))
+		for _, s := range r.MsgContexts {
+			re, err := regexp.Compile("^(?:" + s + ")$")
+			if err != nil {
+				return fmt.Errorf("invalid msg context pattern %v of rule %v:%v", s, r.Name, err)
+			}
+
+			r.msgContexts = append(r.msgContexts, re)
+		}
+	}
+
+	if p.ApproverKey == "" {
+		return errors.New("the approver key of the approval policy is required")
+	}
+
+	keyfile := p.ApproverKey
+	if !filepath.IsAbs(keyfile) {
+		keyfile = filepath.Join(dir, keyfile)
+	}
+
+	approver, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		return fmt.Errorf("load approver key fail:%v", err)
+	}
+
+	p.approver = approver
+	return nil
+}
+
+// Approver the approval account of this node
+func (p *ApprovalPolicy) Approver() string {
+	return crypto.PubkeyToAddress(p.approver.PublicKey).Hex()
+}
+
+// match return true if all the fields of the rule match the request
+func (r *ApprovalRule) match(req *policyRequest) bool {
+	if len(r.Cmds) != 0 && !containsFold(r.Cmds, req.CmdType) {
+		return false
+	}
+
+	if len(r.Accounts) != 0 && !containsFold(r.Accounts, req.Account) {
+		return false
+	}
+
+	if len(r.PubKeys) != 0 && !containsFold(r.PubKeys, req.PubKey) {
+		return false
+	}
+
+	if len(r.GroupIDs) != 0 && !containsFold(r.GroupIDs, req.GroupID) {
+		return false
+	}
+
+	if len(r.msgContexts) != 0 {
+		if len(req.MsgContext) == 0 {
+			return false
+		}
+
+		for _, mc := range req.MsgContext {
+			ok := false
+			for _, re := range r.msgContexts {
+				if re.MatchString(mc) {
+					ok = true
+					break
+				}
+			}
+
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// allow count the request in the rate limit of the rule,return false if the limit is reached
+func (r *ApprovalRule) allow(now int64) bool {
+	if r.RateLimit <= 0 {
+		return true
+	}
+
+	start := now - int64(r.Window)
+	agreed := r.agreed[:0]
+	for _, t := range r.agreed {
+		if t > start {
+			agreed = append(agreed, t)
+		}
+	}
+	r.agreed = agreed
+
+	if len(r.agreed) >= r.RateLimit {
+		return false
+	}
+
+	r.agreed = append(r.agreed, now)
+	return true
+}
+
+// decide check the rules in order,the first matched rule decides the request
+func (p *ApprovalPolicy) decide(req *policyRequest) *ApprovalDecision {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	d := &ApprovalDecision{Key: req.Key, CmdType: req.CmdType, Account: req.Account, PubKey: req.PubKey, GroupID: req.GroupID, TimeStamp: strconv.FormatInt(time.Now().UnixNano()/1e6, 10)}
+	for _, r := range p.Rules {
+		if !r.match(req) {
+			continue
+		}
+
+		d.Rule = r.Name
+		d.Accept = r.Action
+		d.Reason = "matched"
+		if r.Action == "AGREE" && !r.allow(time.Now().Unix()) {
+			d.Accept = "DISAGREE"
+			d.Reason = "rate limit exceeded"
+		}
+
+		if r.Action == "AGREE" && r.RateLimit > 0 && policydb != nil {
+			if err := putRuleAgreed(r); err != nil {
+				common.Error("=====================decide,save the agreed time of the rule fail=====================", "rule", r.Name, "err", err)
+			}
+		}
+
+		return d
+	}
+
+	d.Rule = PolicyDefaultRule
+	d.Accept = p.Default
+	d.Reason = "no rule matched"
+	return d
+}
+
+// checkMode check the mode of the keygen/sign/reshare request is one of ModeGroupApproval,ModeAgreed and ModePolicyApproval
+func checkMode(mode string) error {
+	if mode != ModeGroupApproval && mode != ModeAgreed && mode != ModePolicyApproval {
+		return fmt.Errorf("invalid mode %v,it must be %v,%v or %v", mode, ModeGroupApproval, ModeAgreed, ModePolicyApproval)
+	}
+
+	return nil
+}
+
+// isGroupApprovalMode return true if the request of the mode waits for the approval of the nodes of the group
+func isGroupApprovalMode(mode string) bool {
+	return mode == ModeGroupApproval || mode == ModePolicyApproval
+}
+
+func containsFold(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//--------------------------------------------------------------------------------
+
+// GetPolicyDir get the dir of database for saving the decisions of the approval policy
+func GetPolicyDir() string {
+	dir := common.DefaultDataDir()
+	dir += "/smpcdata/smpcpolicy" + curEnode
+	return dir
+}
+
+// GetSmpcPolicyDb open database for saving the decisions of the approval policy
+func GetSmpcPolicyDb() *ethdb.LDBDatabase {
+	dir := GetPolicyDir()
+	policydb, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if err != nil {
+		common.Error("======================smpc.Start,open policydb fail======================", "err", err, "dir", dir)
+		return nil
+	}
+
+	return policydb
+}
+
+// StartApprovalPolicy load the policy and start the workers deciding the keygen/sign requests waiting for the approval of this node.
+// The policy only applies to the requests of ModePolicyApproval and agrees or disagrees them with the approver account of this node,
+// the requests of ModeGroupApproval still wait for the manual approval.
+func StartApprovalPolicy(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	p, err := LoadApprovalPolicy(path)
+	if err != nil {
+		return err
+	}
+
+	policydb = GetSmpcPolicyDb()
+	if policydb == nil {
+		return errors.New("open policydb fail")
+	}
+
+	p.loadAgreed()
+	approvalPolicy = p
+
+	for i := 0; i < policyWorkers; i++ {
+		go runApprovalPolicy()
+	}
+
+	common.Info("=====================StartApprovalPolicy,approval policy loaded=====================", "path", path, "approver", p.Approver(), "rules", len(p.Rules), "default", p.Default)
+	return nil
+}
+
+// queueApprovalPolicy queue the keygen/sign request waiting for the approval of this node,
+// it is called where the pending approval event fires so that no request is missed and never blocks the caller
+func queueApprovalPolicy(cmdtype string, key string) {
+	if approvalPolicy == nil || (cmdtype != "REQSMPCADDR" && cmdtype != "SIGN") {
+		return
+	}
+
+	policyQueueLock.Lock()
+	policyQueue = append(policyQueue, &policyRequest{CmdType: cmdtype, Key: key})
+	policyQueueLock.Unlock()
+	policyQueueCond.Signal()
+}
+
+// runApprovalPolicy take the queued requests one by one and decide them
+func runApprovalPolicy() {
+	for {
+		policyQueueLock.Lock()
+		for len(policyQueue) == 0 {
+			policyQueueCond.Wait()
+		}
+
+		req := policyQueue[0]
+		policyQueue[0] = nil
+		policyQueue = policyQueue[1:]
+		policyQueueLock.Unlock()
+
+		applyApprovalPolicy(req.CmdType, req.Key)
+	}
+}
+
+// applyApprovalPolicy decide the request and send the AGREE/DISAGREE tx signed by the approver
+// through the same path as the AcceptReqAddr/AcceptSign rpc
+func applyApprovalPolicy(cmdtype string, key string) {
+	p := approvalPolicy
+	if p == nil {
+		return
+	}
+
+	var req *policyRequest
+	var reqaddr *AcceptReqAddrData
+	switch cmdtype {
+	case "REQSMPCADDR":
+		exsit, da := GetReqAddrInfoData([]byte(key))
+		if !exsit {
+			return
+		}
+
+		ac, ok := da.(*AcceptReqAddrData)
+		if !ok || ac == nil || ac.Mode != ModePolicyApproval {
+			return
+		}
+
+		reqaddr = ac
+		req = &policyRequest{CmdType: cmdtype, Key: key, Account: ac.Account, GroupID: ac.GroupID}
+	case "SIGN":
+		exsit, da := GetSignInfoData([]byte(key))
+		if !exsit {
+			return
+		}
+
+		ac, ok := da.(*AcceptSignData)
+		if !ok || ac == nil || ac.Mode != ModePolicyApproval {
+			return
+		}
+
+		exsit, da = GetPubKeyData([]byte(GetReqAddrKeyByOtherKey(key, RPCSIGN)))
+		if !exsit {
+			return
+		}
+
+		reqaddr, ok = da.(*AcceptReqAddrData)
+		if !ok || reqaddr == nil {
+			return
+		}
+
+		req = &policyRequest{CmdType: cmdtype, Key: key, Account: ac.Account, PubKey: ac.PubKey, GroupID: ac.GroupID, MsgContext: ac.MsgContext}
+	default:
+		return
+	}
+
+	approver := p.Approver()
+	if !IsValidAccept(req.GroupID, approver, reqaddr) {
+		common.Info("=====================applyApprovalPolicy,the approver is not the approval account of this node,skip it=====================", "key", key, "approver", approver)
+		return
+	}
+
+	d := p.decide(req)
+	common.Info("=====================applyApprovalPolicy,request decided by approval policy=====================", "key", key, "cmd", cmdtype, "accept", d.Accept, "rule", d.Rule, "reason", d.Reason)
+
+	if err := putApprovalDecision(d); err != nil {
+		common.Error("=====================applyApprovalPolicy,save decision fail=====================", "key", key, "err", err)
+	}
+
+	raw, err := p.buildAcceptRaw(req, d.Accept)
+	if err != nil {
+		common.Error("=====================applyApprovalPolicy,build accept raw fail=====================", "key", key, "err", err)
+		return
+	}
+
+	if cmdtype == "SIGN" {
+		_, _, err = RPCAcceptSign(raw)
+	} else {
+		_, _, err = RPCAcceptReqAddr(raw)
+	}
+
+	if err != nil {
+		common.Error("=====================applyApprovalPolicy,accept fail=====================", "key", key, "err", err)
+	}
+}
+
+// buildAcceptRaw build the ACCEPTREQADDR/ACCEPTSIGN tx and sign it by the approver key
+func (p *ApprovalPolicy) buildAcceptRaw(req *policyRequest, accept string) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+
+	var data interface{}
+	if req.CmdType == "SIGN" {
+		exsit, da := GetSignInfoData([]byte(req.Key))
+		if !exsit {
+			return "", errors.New("get sign accept data fail")
+		}
+
+		ac, ok := da.(*AcceptSignData)
+		if !ok || ac == nil {
+			return "", errors.New("get sign accept data fail")
+		}
+
+		data = &TxDataAcceptSign{TxType: "ACCEPTSIGN", Key: req.Key, MsgHash: ac.MsgHash, MsgContext: ac.MsgContext, Accept: accept, TimeStamp: timestamp}
+	} else {
+		data = &TxDataAcceptReqAddr{TxType: "ACCEPTREQADDR", Key: req.Key, Accept: accept, TimeStamp: timestamp}
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	tx := types.NewTransaction(0, coincommon.HexToAddress(policyToAddr), big.NewInt(0), 100000, big.NewInt(80000), payload)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(30400)), p.approver)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return "", err
+	}
+
+	return common.ToHex(b), nil
+}
+
+func putApprovalDecision(d *ApprovalDecision) error {
+	if policydb == nil {
+		return errors.New("policydb is not opened")
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return policydb.Put([]byte(strings.ToLower(d.Key)), b)
+}
+
+// policyAgreedKey the key of the agreed time of the rule in policydb,the prefix never collides with the request keys of the decisions
+func policyAgreedKey(rule string) []byte {
+	return []byte("ratelimit:" + rule)
+}
+
+// putRuleAgreed save the unix time of the requests agreed by the rule in the current window,so that the rate limit survives a restart
+func putRuleAgreed(r *ApprovalRule) error {
+	if policydb == nil {
+		return errors.New("policydb is not opened")
+	}
+
+	b, err := json.Marshal(r.agreed)
+	if err != nil {
+		return err
+	}
+
+	return policydb.Put(policyAgreedKey(r.Name), b)
+}
+
+// loadAgreed reload the agreed time of the rate limited rules from policydb,the time out of the window is dropped by the next allow
+func (p *ApprovalPolicy) loadAgreed() {
+	if policydb == nil {
+		return
+	}
+
+	for _, r := range p.Rules {
+		if r.RateLimit <= 0 {
+			continue
+		}
+
+		b, err := policydb.Get(policyAgreedKey(r.Name))
+		if err != nil || len(b) == 0 {
+			continue
+		}
+
+		var agreed []int64
+		if err := json.Unmarshal(b, &agreed); err != nil {
+			common.Error("=====================loadAgreed,decode the agreed time of the rule fail=====================", "rule", r.Name, "err", err)
+			continue
+		}
+
+		r.agreed = agreed
+	}
+}
+
+// GetApprovalDecision get the decision made by the approval policy for the request
+func GetApprovalDecision(key string) (*ApprovalDecision, error) {
+	if policydb == nil {
+		return nil, errors.New("approval policy is not enabled")
+	}
+
+	b, err := policydb.Get([]byte(strings.ToLower(key)))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("no approval decision for key %v", key)
+	}
+
+	d := &ApprovalDecision{}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,281 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestPolicy(t *testing.T, policy string) (*ApprovalPolicy, error) {
+	prv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := crypto.SaveECDSA(filepath.Join(dir, "approver.key"), prv); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "policy.toml")
+	if err := ioutil.WriteFile(path, []byte("ApproverKey=\"approver.key\"\n"+policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return LoadApprovalPolicy(path)
+}
+
+func TestLoadApprovalPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		ok     bool
+	}{
+		{"empty", "", true},
+		{"rule", "[[Rules]]\nAction=\"AGREE\"\nCmds=[\"SIGN\"]\nMsgContexts=[\"payment:.*\"]\n", true},
+		{"bad default", "Default=\"MAYBE\"\n", false},
+		{"bad action", "[[Rules]]\nAction=\"YES\"\n", false},
+		{"bad cmd", "[[Rules]]\nAction=\"AGREE\"\nCmds=[\"RESHARE\"]\n", false},
+		{"rate limit without window", "[[Rules]]\nAction=\"AGREE\"\nRateLimit=1\n", false},
+		{"bad pattern", "[[Rules]]\nAction=\"AGREE\"\nMsgContexts=[\"(\"]\n", false},
+	}
+
+	for _, tt := range tests {
+		p, err := loadTestPolicy(t, tt.policy)
+		if !tt.ok {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+
+		if assert.NoError(t, err, tt.name) {
+			assert.Equal(t, "DISAGREE", p.Default, tt.name)
+			assert.NotEmpty(t, p.Approver(), tt.name)
+		}
+	}
+}
+
+func TestApprovalRuleMatch(t *testing.T) {
+	p, err := loadTestPolicy(t, `
+[[Rules]]
+Name="any"
+Action="AGREE"
+
+[[Rules]]
+Name="sign"
+Action="AGREE"
+Cmds=["SIGN"]
+Accounts=["0xAbc"]
+PubKeys=["04abcd"]
+GroupIDs=["g1","g2"]
+MsgContexts=["payment:.*","fee"]
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	anyRule, sign := p.Rules[0], p.Rules[1]
+	req := func(f func(r *policyRequest)) *policyRequest {
+		r := &policyRequest{CmdType: "SIGN", Account: "0xabc", PubKey: "04ABCD", GroupID: "g2", MsgContext: []string{"payment:1", "fee"}}
+		if f != nil {
+			f(r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		rule  *ApprovalRule
+		req   *policyRequest
+		match bool
+	}{
+		{"empty rule", anyRule, &policyRequest{CmdType: "REQSMPCADDR"}, true},
+		{"all fields", sign, req(nil), true},
+		{"cmd", sign, req(func(r *policyRequest) { r.CmdType = "REQSMPCADDR" }), false},
+		{"account", sign, req(func(r *policyRequest) { r.Account = "0xdef" }), false},
+		{"no account", sign, req(func(r *policyRequest) { r.Account = "" }), false},
+		{"pubkey", sign, req(func(r *policyRequest) { r.PubKey = "04ef" }), false},
+		{"groupid", sign, req(func(r *policyRequest) { r.GroupID = "g3" }), false},
+		{"no msgcontext", sign, req(func(r *policyRequest) { r.MsgContext = nil }), false},
+		{"one msgcontext not matched", sign, req(func(r *policyRequest) { r.MsgContext = []string{"payment:1", "refund:1"} }), false},
+		{"msgcontext prefix", sign, req(func(r *policyRequest) { r.MsgContext = []string{"xpayment:1"} }), false},
+		{"msgcontext suffix", sign, req(func(r *policyRequest) { r.MsgContext = []string{"feex"} }), false},
+		{"msgcontext substring", sign, req(func(r *policyRequest) { r.MsgContext = []string{"xfeey"} }), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, tt.rule.match(tt.req), tt.name)
+	}
+}
+
+func TestApprovalRuleAllow(t *testing.T) {
+	r := &ApprovalRule{RateLimit: 2, Window: 10}
+	tests := []struct {
+		now   int64
+		allow bool
+	}{
+		{100, true},
+		{105, true},
+		{109, false},
+		{110, true}, // the request at 100 is out of the window
+		{114, false},
+		{115, true},
+		{126, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allow, r.allow(tt.now), "now %v", tt.now)
+	}
+
+	r = &ApprovalRule{}
+	for i := 0; i < 10; i++ {
+		assert.True(t, r.allow(100))
+	}
+}
+
+func TestApprovalPolicyDecide(t *testing.T) {
+	p, err := loadTestPolicy(t, `
+Default="AGREE"
+
+[[Rules]]
+Name="blocked"
+Action="DISAGREE"
+Accounts=["0xbad"]
+
+[[Rules]]
+Name="sign"
+Action="AGREE"
+Cmds=["SIGN"]
+RateLimit=1
+Window=3600
+
+[[Rules]]
+Name="sign all"
+Action="DISAGREE"
+Cmds=["SIGN"]
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name   string
+		req    *policyRequest
+		rule   string
+		accept string
+	}{
+		{"first rule wins", &policyRequest{CmdType: "SIGN", Key: "k1", Account: "0xBAD"}, "blocked", "DISAGREE"},
+		{"matched", &policyRequest{CmdType: "SIGN", Key: "k2", Account: "0x1"}, "sign", "AGREE"},
+		{"rate limit exceeded", &policyRequest{CmdType: "SIGN", Key: "k3", Account: "0x1"}, "sign", "DISAGREE"},
+		{"default", &policyRequest{CmdType: "REQSMPCADDR", Key: "k4", Account: "0x1"}, PolicyDefaultRule, "AGREE"},
+	}
+
+	for _, tt := range tests {
+		d := p.decide(tt.req)
+		assert.Equal(t, tt.req.Key, d.Key, tt.name)
+		assert.Equal(t, tt.rule, d.Rule, tt.name)
+		assert.Equal(t, tt.accept, d.Accept, tt.name)
+	}
+}
+
+func TestApprovalRuleAgreedPersist(t *testing.T) {
+	policy := `
+[[Rules]]
+Name="sign"
+Action="AGREE"
+Cmds=["SIGN"]
+RateLimit=1
+Window=3600
+`
+	dir := t.TempDir()
+	db, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	old := policydb
+	policydb = db
+	defer func() {
+		policydb.Close()
+		policydb = old
+	}()
+
+	p, err := loadTestPolicy(t, policy)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	p.loadAgreed()
+	assert.Equal(t, "AGREE", p.decide(&policyRequest{CmdType: "SIGN", Key: "k1"}).Accept)
+
+	// restart the node,the request agreed before the restart is still in the window
+	policydb.Close()
+	policydb, err = ethdb.NewLDBDatabase(dir, cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	p, err = loadTestPolicy(t, policy)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	p.loadAgreed()
+	d := p.decide(&policyRequest{CmdType: "SIGN", Key: "k2"})
+	assert.Equal(t, "DISAGREE", d.Accept)
+	assert.Equal(t, "rate limit exceeded", d.Reason)
+}
+
+func TestQueueApprovalPolicy(t *testing.T) {
+	defer func() {
+		approvalPolicy = nil
+		policyQueue = nil
+	}()
+
+	queueApprovalPolicy("SIGN", "k0")
+	assert.Empty(t, policyQueue)
+
+	approvalPolicy = &ApprovalPolicy{}
+	queueApprovalPolicy("SIGN", "k1")
+	queueApprovalPolicy("RESHARE", "k2")
+	queueApprovalPolicy("REQSMPCADDR", "k3")
+	if assert.Len(t, policyQueue, 2) {
+		assert.Equal(t, "k1", policyQueue[0].Key)
+		assert.Equal(t, "REQSMPCADDR", policyQueue[1].CmdType)
+	}
+}
+
+func TestApprovalMode(t *testing.T) {
+	for _, mode := range []string{ModeGroupApproval, ModeAgreed, ModePolicyApproval} {
+		assert.NoError(t, checkMode(mode), mode)
+	}
+
+	for _, mode := range []string{"", "3", "00", "policy"} {
+		assert.Error(t, checkMode(mode), mode)
+	}
+
+	// the policy mode is approved by the nodes of the group like mode 0,but only its requests are decided by the policy
+	assert.True(t, isGroupApprovalMode(ModeGroupApproval))
+	assert.True(t, isGroupApprovalMode(ModePolicyApproval))
+	assert.False(t, isGroupApprovalMode(ModeAgreed))
+	assert.NotEqual(t, ModeGroupApproval, ModePolicyApproval)
+}
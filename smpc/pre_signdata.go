@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
 	dberrors "github.com/syndtr/goleveldb/leveldb/errors"
 	"math/big"
 	"strconv"
@@ -100,6 +101,7 @@ type PreSignData struct {
 	Gid    string
 	Used   bool
 	Index  int
+	Frost  *frost.PrePubData // the FROST nonces and commitment list of ED25519 pubkey,nil for EC pubkey
 }
 
 // MarshalJSON marshal PreSignData data struct to json byte
@@ -109,6 +111,15 @@ func (psd *PreSignData) MarshalJSON() ([]byte, error) {
 		used = "true"
 	}
 
+	fr := ""
+	if psd.Frost != nil {
+		b, err := psd.Frost.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		fr = string(b)
+	}
+
 	return json.Marshal(struct {
 		Key    string `json:"Key"`
 		K1     string `json:"K1"`
@@ -118,6 +129,7 @@ func (psd *PreSignData) MarshalJSON() ([]byte, error) {
 		Gid    string `json:"Gid"`
 		Used   string `json:"Used"`
 		Index  string `json:"Index"`
+		Frost  string `json:"Frost"`
 	}{
 		Key:    psd.Key,
 		K1:     fmt.Sprintf("%v", psd.K1),
@@ -127,6 +139,7 @@ func (psd *PreSignData) MarshalJSON() ([]byte, error) {
 		Gid:    psd.Gid,
 		Used:   used,
 		Index:  strconv.Itoa(psd.Index),
+		Frost:  fr,
 	})
 }
 
@@ -141,6 +154,7 @@ func (psd *PreSignData) UnmarshalJSON(raw []byte) error {
 		Gid    string `json:"Gid"`
 		Used   string `json:"Used"`
 		Index  string `json:"Index"`
+		Frost  string `json:"Frost"`
 	}
 	if err := json.Unmarshal(raw, &pre); err != nil {
 		return err
@@ -159,6 +173,14 @@ func (psd *PreSignData) UnmarshalJSON(raw []byte) error {
 	}
 	psd.Index, _ = strconv.Atoi(pre.Index)
 
+	if pre.Frost != "" {
+		fr := &frost.PrePubData{}
+		if err := fr.UnmarshalJSON([]byte(pre.Frost)); err != nil {
+			return err
+		}
+		psd.Frost = fr
+	}
+
 	return nil
 }
 
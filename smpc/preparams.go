@@ -0,0 +1,238 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+)
+
+var (
+	// PreParamsCount the number of pre params kept in the local pool for every paillier key length,0 means the pool is disabled
+	PreParamsCount = 0
+
+	preParamsLock    sync.Mutex
+	preParamsLengths = map[int]bool{smpclibec2.DefaultPaillierKeyLength: true}
+	preParamsRefill  = make(chan struct{}, 1)
+	preParamsGen     = make(map[int]bool)
+)
+
+// PreParamsStatus the state of the pre params pool of one paillier key length
+type PreParamsStatus struct {
+	Length     int
+	Count      int
+	Target     int
+	Generating bool
+}
+
+// preParamsKey the key of pre params in the local db, prefixed by the paillier key length
+func preParamsKey(length int, pre *smpclibec2.PreParams) []byte {
+	h := Keccak256Hash([]byte(fmt.Sprintf("%v", pre.NtildeH1H2.Ntilde))).Hex()
+	return []byte(preParamsPrefix(length) + strings.ToLower(h))
+}
+
+func preParamsPrefix(length int) string {
+	return strconv.Itoa(length) + ":"
+}
+
+// putPreParamsToLocalDb encrypt the pre params by the node key and save it to local db
+func putPreParamsToLocalDb(length int, pre *smpclibec2.PreParams) error {
+	if preparamsdb == nil || pre == nil {
+		return fmt.Errorf("put pre params to db fail")
+	}
+
+	b, err := json.Marshal(pre)
+	if err != nil {
+		return err
+	}
+
+	cm, err := EncryptMsg(string(b), curEnode)
+	if err != nil {
+		common.Error("===============putPreParamsToLocalDb, encrypt pre params fail.=================", "err", err)
+		return err
+	}
+
+	return preparamsdb.Put(preParamsKey(length, pre), []byte(cm))
+}
+
+// TakePreParams take one pre params of paillier key length `length` out of the local pool for the dnode id,
+// any pre params can be used by any dnode of this node.
+// Every entry is decrypted and validated before it is taken,an entry that is not valid is deleted from the pool
+// and the next one is tried. The valid one is deleted before it is returned so that it is never used by two keygens,
+// if the delete fails it is left in the pool and the next entry is tried.
+// It returns nil if the pool is empty,then keygen/reshare generate the data itself.
+func TakePreParams(id string, length int) *smpclibec2.PreParams {
+	if preparamsdb == nil || PreParamsCount <= 0 {
+		return nil
+	}
+
+	preParamsLock.Lock()
+	defer preParamsLock.Unlock()
+
+	defer notifyPreParamsRefill(length)
+
+	iter := preparamsdb.NewIteratorWithPrefix([]byte(preParamsPrefix(length)))
+	defer iter.Release()
+
+	for iter.Next() {
+		key := []byte(string(iter.Key())) //must be deep copy
+		pre, err := decodePreParams(string(iter.Value()), length)
+		if err != nil {
+			common.Error("=====================TakePreParams,invalid pre params,delete it=====================", "key", string(key), "err", err)
+			if err := preparamsdb.Delete(key); err != nil {
+				common.Error("=====================TakePreParams,delete invalid pre params fail=====================", "key", string(key), "err", err)
+			}
+			continue
+		}
+
+		if err := preparamsdb.Delete(key); err != nil {
+			common.Error("=====================TakePreParams,delete pre params fail=====================", "key", string(key), "err", err)
+			continue
+		}
+
+		common.Info("=====================TakePreParams,take pre params from pool success=====================", "id", id, "length", length)
+		return pre
+	}
+
+	return nil
+}
+
+// decodePreParams decrypt the pre params saved in the local db by the node key,and validate it for paillier key length `length`
+func decodePreParams(value string, length int) (*smpclibec2.PreParams, error) {
+	da, err := DecryptMsg(value)
+	if err != nil {
+		return nil, err
+	}
+
+	pre := &smpclibec2.PreParams{}
+	if err := json.Unmarshal([]byte(da), pre); err != nil {
+		return nil, err
+	}
+
+	if err := pre.Validate(length); err != nil {
+		return nil, err
+	}
+
+	return pre, nil
+}
+
+// getPreParamsCount get the number of pre params of paillier key length `length` in the local pool
+func getPreParamsCount(length int) int {
+	if preparamsdb == nil {
+		return 0
+	}
+
+	count := 0
+	iter := preparamsdb.NewIteratorWithPrefix([]byte(preParamsPrefix(length)))
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+
+	return count
+}
+
+// notifyPreParamsRefill wake up the refill worker,the length is added to the lengths kept in the pool
+func notifyPreParamsRefill(length int) {
+	if smpclibec2.CheckPaillierKeyLength(length) != nil {
+		return
+	}
+
+	preParamsLengths[length] = true
+	select {
+	case preParamsRefill <- struct{}{}:
+	default:
+	}
+}
+
+// AutoGenPreParams keep PreParamsCount pre params in the local pool for every paillier key length that is used.
+// It is woken up after the pool is consumed,and checks it every hour anyway.
+func AutoGenPreParams() {
+	if PreParamsCount <= 0 || preparamsdb == nil {
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		for _, length := range getPreParamsLengths() {
+			for getPreParamsCount(length) < PreParamsCount {
+				setPreParamsGenerating(length, true)
+				pre, err := smpclibec2.GeneratePreParams(length)
+				if err == nil {
+					err = putPreParamsToLocalDb(length, pre)
+				}
+				setPreParamsGenerating(length, false)
+
+				if err != nil {
+					common.Error("=====================AutoGenPreParams,generate pre params fail=====================", "length", length, "err", err)
+					break
+				}
+
+				common.Info("=====================AutoGenPreParams,generate pre params success=====================", "length", length, "count", getPreParamsCount(length), "target", PreParamsCount)
+			}
+		}
+
+		select {
+		case <-preParamsRefill:
+		case <-ticker.C:
+		}
+	}
+}
+
+func getPreParamsLengths() []int {
+	preParamsLock.Lock()
+	defer preParamsLock.Unlock()
+
+	lengths := make([]int, 0, len(preParamsLengths))
+	for length := range preParamsLengths {
+		lengths = append(lengths, length)
+	}
+	sort.Ints(lengths)
+
+	return lengths
+}
+
+func setPreParamsGenerating(length int, generating bool) {
+	preParamsLock.Lock()
+	defer preParamsLock.Unlock()
+	preParamsGen[length] = generating
+}
+
+// GetPreParamsStatus get the state of the pre params pool of every paillier key length kept in the pool
+func GetPreParamsStatus() []*PreParamsStatus {
+	lengths := getPreParamsLengths()
+	status := make([]*PreParamsStatus, 0, len(lengths))
+	for _, length := range lengths {
+		preParamsLock.Lock()
+		gen := preParamsGen[length]
+		preParamsLock.Unlock()
+
+		status = append(status, &PreParamsStatus{Length: length, Count: getPreParamsCount(length), Target: PreParamsCount, Generating: gen})
+	}
+
+	return status
+}
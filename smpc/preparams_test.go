@@ -0,0 +1,70 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/simulate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakePreParams(t *testing.T) {
+	pub := useTestKeyFile(t)
+
+	db, err := ethdb.NewLDBDatabase(t.TempDir(), cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	oldenode, olddb, oldcount := curEnode, preparamsdb, PreParamsCount
+	curEnode, preparamsdb, PreParamsCount = hex.EncodeToString(pub[1:]), db, 1
+	defer func() {
+		db.Close()
+		curEnode, preparamsdb, PreParamsCount = oldenode, olddb, oldcount
+	}()
+
+	pre, err := simulate.TestPreParams(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	length := smpclibec2.DefaultPaillierKeyLength
+	assert.NoError(t, putPreParamsToLocalDb(length, pre))
+
+	// the entries that can not be decrypted or are not valid sort before the good one,they are deleted and skipped
+	prefix := preParamsPrefix(length)
+	assert.NoError(t, preparamsdb.Put([]byte(prefix+"0"), []byte("not encrypted")))
+	cm, err := EncryptMsg(`{"PaillierSk":null}`, curEnode)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, preparamsdb.Put([]byte(prefix+"00"), []byte(cm)))
+	assert.Equal(t, 3, getPreParamsCount(length))
+
+	got := TakePreParams("0x01", length)
+	if !assert.NotNil(t, got) {
+		return
+	}
+	assert.Equal(t, 0, pre.NtildeH1H2.Ntilde.Cmp(got.NtildeH1H2.Ntilde))
+	assert.Equal(t, 0, getPreParamsCount(length), "the bad entries and the taken one are deleted")
+
+	assert.Nil(t, TakePreParams("0x01", length), "the pool is empty")
+}
@@ -30,6 +30,7 @@ import (
 	"crypto/sha512"
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
 	"github.com/fsn-dev/cryptoCoins/coins"
 )
 
@@ -355,13 +356,14 @@ func GetAccounts(geteracc, mode string) (interface{}, string, error) {
 // GetBip32ChildKey rootpubkey is the total public key of the root node
 // the inputcode format is "m / X1 / x2 /... / xn", where x1,..., xn is the index number of the child node of each level, which is in decimal format, for example: "m / 1234567890123456789012345678901234567890123456789012323455678901234" 
 // the return value is the sub public key of the X1 / x2 /... / xn sub node of the total public key of the root node.  
+// for ED25519 root pubkey,x1,...,xn must be the non-hardened index(< 2^31) of BIP32-Ed25519
 func GetBip32ChildKey(rootpubkey string, inputcode string) (string, string, error) {
 	if rootpubkey == "" || inputcode == "" {
 		return "", "param error", fmt.Errorf("param error")
 	}
 
 	indexs := strings.Split(inputcode, "/")
-	if len([]rune(rootpubkey)) != 130 || len(indexs) < 2 || indexs[0] != "m" {
+	if len(indexs) < 2 || indexs[0] != "m" {
 		return "", "param error", fmt.Errorf("param error")
 	}
 
@@ -376,12 +378,27 @@ func GetBip32ChildKey(rootpubkey string, inputcode string) (string, string, erro
 		return "", "get bip32 child key,not exist pubkey data", fmt.Errorf("get bip32 child key,not exist pubkey data")
 	}
 
-	_, ok := da.(*PubKeyData)
+	pubs, ok := da.(*PubKeyData)
 	if !ok {
 		common.Debug("============================get bip32 child key,pubkey data error==========================", "pubkey", rootpubkey)
 		return "", "get bip32 child key,pubkey data error", fmt.Errorf("get bip32 child key,pubkey data error")
 	}
 
+	if getPubKeyType(pubs) == "ED25519" {
+		childpk, _, err := getEdBip32ChildKey(smpcpks[:], inputcode)
+		if err != nil {
+			return "", "get bip32 child key fail", err
+		}
+
+		pubkeyhex := hex.EncodeToString(childpk[:])
+		common.Info("===================GetBip32ChildKey, get ed bip32 pubkey success===================", "rootpubkey", rootpubkey, "inputcode", inputcode, "child pubkey", pubkeyhex)
+		return pubkeyhex, "", nil
+	}
+
+	if len([]rune(rootpubkey)) != 130 || getPubKeyType(pubs) != "EC256K1" {
+		return "", "param error", fmt.Errorf("param error")
+	}
+
 	smpcpub := (da.(*PubKeyData)).Pub
 	smpcpkx, smpcpky := secp256k1.S256().Unmarshal(([]byte(smpcpub))[:])
 
@@ -503,4 +520,23 @@ func GetBip32ChildKey(rootpubkey string, inputcode string) (string, string, erro
 	return pubkeyhex, "", nil
 }
 
+// getEdBip32ChildKey get the BIP32-Ed25519 child pubkey of the ed25519 root pubkey along the path inputcode
+// tweak is the value that every share of root private key need to add for the child key
+func getEdBip32ChildKey(rootpubkey []byte, inputcode string) ([32]byte, [32]byte, error) {
+	var pk, c [32]byte
+	if len(rootpubkey) != 32 {
+		return pk, c, fmt.Errorf("invalid ed25519 pubkey")
+	}
+
+	// the pubkey generated before bip32 supported has no chain code
+	da := getBip32cFromLocalDb(rootpubkey)
+	if len(da) != 32 {
+		return pk, c, fmt.Errorf("get bip32c fail")
+	}
+
+	copy(pk[:], rootpubkey)
+	copy(c[:], da)
+	return ed.Bip32DerivePath(pk, c, inputcode)
+}
+
 
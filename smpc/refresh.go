@@ -0,0 +1,499 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/refresh"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/fsn-dev/cryptoCoins/coins"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	coincommon "github.com/fsn-dev/cryptoCoins/tools/common"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+)
+
+var (
+	// RefreshInterval the interval(seconds) of the scheduled share refresh,0 means disabled
+	RefreshInterval uint64 = 0
+)
+
+//----------------------------------------------------ECDSA start----------------------------------------------------------
+
+// Refresh execute the refresh command,the same group and threshold re-randomise the shares of pubkey,the pubkey does not change
+// raw : refresh command data,it is a TxDataRecoverShare with TxType "REFRESH" and no Enode,
+// it is approved by all nodes of the keygen group the same as the recover share command
+func Refresh(raw string) (string, string, error) {
+	if raw == "" {
+		return "", "", errors.New("param error")
+	}
+
+	key, _, _, txdata, err := CheckRaw(raw)
+	if err != nil {
+		common.Error("=====================Refresh,check raw data error ================", "raw", raw, "err", err)
+		return "", err.Error(), err
+	}
+
+	rf, ok := txdata.(*TxDataRecoverShare)
+	if !ok || rf.TxType != "REFRESH" {
+		return "", "check raw fail,it is not refresh command", fmt.Errorf("check raw data fail")
+	}
+
+	common.Debug("=====================Refresh, SendMsgToSmpcGroup ================", "raw", raw, "gid", rf.GroupID, "key", key)
+	SendMsgToSmpcGroup(raw, rf.GroupID)
+	SetUpMsgList(raw, curEnode)
+	return key, "", nil
+}
+
+// buildRefreshRaw build the refresh command of pubkey and sign it by the node key,used by the scheduled refresh
+func buildRefreshRaw(pubkey string, groupid string) (string, error) {
+	priv, err := getNodePrivate(KeyFile)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	rf := &TxDataRecoverShare{TxType: "REFRESH", PubKey: pubkey, GroupID: groupid, TimeStamp: timestamp}
+	payload, err := json.Marshal(rf)
+	if err != nil {
+		return "", err
+	}
+
+	tx := types.NewTransaction(0, coincommon.HexToAddress(policyToAddr), big.NewInt(0), 100000, big.NewInt(80000), payload)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(30400)), priv)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return "", err
+	}
+
+	return common.ToHex(b), nil
+}
+
+// getRefreshPubKeyData get the pubkey data that can be refreshed,only the EC256K1 and EC256R1 pubkeys are supported now
+func getRefreshPubKeyData(pubkey string) (*PubKeyData, error) {
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if !exsit || da == nil {
+		return nil, errors.New("pubkey data was not found")
+	}
+
+	pubs, ok := da.(*PubKeyData)
+	if !ok || pubs == nil || pubs.GroupID == "" {
+		return nil, errors.New("pubkey data error")
+	}
+
+	if len(pubs.Pub) != 65 {
+		return nil, errors.New("only ec pubkey can be refreshed")
+	}
+
+	return pubs, nil
+}
+
+// RefreshEC2 re-randomise the sku1 of pubkey with all nodes in the keygen group
+// the new sku1 replaces the old one atomically
+func RefreshEC2(msgprex string, pubs *PubKeyData, ch chan interface{}, id int) (*big.Int, error) {
+	if id < 0 || id >= len(workers) || pubs == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("no find worker")}
+		ch <- res
+		return nil, errors.New("no find worker")
+	}
+	w := workers[id]
+
+	threshold, err := getRefreshThreshold(pubs.LimitNum)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return nil, err
+	}
+
+	smpcpks := []byte(pubs.Pub)
+	da := getSkU1FromLocalDb(smpcpks[:])
+	if da == nil {
+		res := RPCSmpcRes{Ret: "", Tip: "refresh get sku1 fail", Err: fmt.Errorf("refresh get sku1 fail")}
+		ch <- res
+		return nil, errors.New("refresh get sku1 fail")
+	}
+
+	keytype := getPubKeyType(pubs)
+	sd := &keygen.LocalDNodeSaveData{}
+	sd.SkU1 = new(big.Int).SetBytes(da)
+	sd.Pkx, sd.Pky = elliptic.Unmarshal(ec2.GetCurve(keytype), smpcpks[:])
+	sd.IDs = GetGroupNodeUIDs(keytype, pubs.GroupID, pubs.GroupID)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, keytype, pubs.GroupID)
+	if sd.Pkx == nil || sd.CurDNodeID == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("get refresh save data fail")}
+		ch <- res
+		return nil, errors.New("get refresh save data fail")
+	}
+
+	// the public shares are refreshed with sku1,the data saved before they were added has none
+	if ids, pubShares, err := decodePubShares(pubs.PubShares); err == nil && len(ids) == len(sd.IDs) {
+		sd.PubShares = pubShares
+		for k, id := range ids {
+			if id.Cmp(sd.IDs[k]) != 0 {
+				sd.PubShares = nil
+				break
+			}
+		}
+	}
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.NodeCnt)
+	endCh := make(chan keygen.LocalDNodeSaveData, w.NodeCnt)
+	errChan := make(chan struct{})
+	refreshDNode := refresh.NewLocalDNode(outCh, endCh, w.NodeCnt, threshold, sd, keytype)
+	w.DNode = refreshDNode
+	refreshDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	refreshDNode.SetSessionKey(msgprex)
+	w.MsgToEnode = GetMsgToEnode(keytype, pubs.GroupID, pubs.GroupID)
+
+	var refreshWg sync.WaitGroup
+	refreshWg.Add(2)
+	go func() {
+		defer refreshWg.Done()
+		if err := refreshDNode.Start(); nil != err {
+			fmt.Printf("==========refresh node start err = %v ==========\n", err)
+			close(errChan)
+		}
+
+		for _, uid := range sd.IDs {
+			HandleRefresh(msgprex, uid)
+		}
+	}()
+	go RefreshProcessInboundMessages(msgprex, keytype, pubs.GroupID, commStopChan, &refreshWg, ch)
+	newsku1, err := processRefresh(msgprex, pubs.GroupID, hex.EncodeToString(smpcpks[:]), getPubKeyType(pubs), errChan, outCh, endCh)
+	if err != nil {
+		fmt.Printf("==========process refresh err = %v ==========\n", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return nil, err
+	}
+
+	close(commStopChan)
+	refreshWg.Wait()
+	return newsku1, nil
+}
+
+// getRefreshThreshold get threshold from limitnum,limitnum format is "t/n"
+func getRefreshThreshold(limitnum string) (int, error) {
+	nums := strings.Split(limitnum, "/")
+	if len(nums) != 2 {
+		return 0, errors.New("limitnum error")
+	}
+
+	threshold, err := strconv.Atoi(nums[0])
+	if err != nil {
+		return 0, err
+	}
+
+	return threshold, nil
+}
+
+// HandleRefresh Process pre-save msg for refresh
+func HandleRefresh(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "RefRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RefRound2Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RefRound2Message1")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RefRound3Message")
+	Handle(key, c1data)
+}
+
+// RefreshProcessInboundMessages Analyze the obtained P2P messages and enter next round,keytype is the type of the pubkey
+func RefreshProcessInboundMessages(msgprex string, keytype string, groupid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+
+	if msgprex == "" || groupid == "" {
+		return
+	}
+
+	fmt.Printf("start processing refresh inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop processing refresh inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := RefreshGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[REFRESH] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============refresh,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], keytype, groupid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============refresh,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== RefreshProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v ============\n", m, err)
+				saveBlame(msgprex, keytype, groupid, groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// RefreshGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func RefreshGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "ecdsa/refresh")
+}
+
+// putRefreshPubShares replace the public shares in the pubkey data under keys,keys[0] is the pubkey
+func putRefreshPubShares(keys [][]byte, pubShares string) error {
+	exsit, da := GetPubKeyData(keys[0])
+	if !exsit {
+		return errors.New("get pubkey data fail")
+	}
+
+	pd, ok := da.(*PubKeyData)
+	if !ok {
+		return errors.New("get pubkey data fail")
+	}
+
+	pubs := *pd
+	pubs.PubShares = pubShares
+	epubs, err := Encode2(&pubs)
+	if err != nil {
+		return err
+	}
+
+	ss, err := Compress([]byte(epubs))
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = PutPubKeyData(key, []byte(ss))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processRefresh  Obtain the data to be sent in each round and send it to other nodes until the end of the refresh command
+// the new sku1 is saved under the pubkey and all coin addresses in one batch
+func processRefresh(msgprex string, groupid string, pubkey string, keytype string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) (*big.Int, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========== processRefresh,error channel closed fail to start local smpc node ===========\n")
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * 300):
+			fmt.Printf("=========== processRefresh,refresh timeout ===========\n")
+			return nil, errors.New("refresh timeout")
+		case msg := <-outCh:
+			err := ReshareProcessOutCh(msgprex, groupid, msg)
+			if err != nil {
+				fmt.Printf("======== processRefresh,process outch err = %v ==========\n", err)
+				return nil, err
+			}
+		case msg := <-endCh:
+			smpcpks, err := hex.DecodeString(pubkey)
+			if err != nil {
+				return nil, err
+			}
+
+			ys := secp256k1.S256().Marshal(msg.Pkx, msg.Pky)
+			if !strings.EqualFold(pubkey, hex.EncodeToString(ys)) {
+				common.Info("===================== refresh fail,new pubkey != old pubkey ====================", "pubkey", pubkey, "key", msgprex)
+				return nil, errors.New("refresh fail,old pubkey != new pubkey")
+			}
+
+			keys := [][]byte{smpcpks[:]}
+			for _, ct := range getCoinTypes(keytype) {
+				if strings.EqualFold(ct, "ALL") {
+					continue
+				}
+
+				h := coins.NewCryptocoinHandler(ct)
+				if h == nil {
+					continue
+				}
+				ctaddr, err := h.PublicKeyToAddress(pubkey)
+				if err != nil {
+					continue
+				}
+
+				key := Keccak256Hash([]byte(strings.ToLower(ctaddr))).Hex()
+				keys = append(keys, []byte(key))
+			}
+
+			err = putSkU1BatchToLocalDb(keys, msg.SkU1.Bytes())
+			if err != nil {
+				return nil, err
+			}
+
+			if len(msg.PubShares) != 0 {
+				err = putRefreshPubShares(keys, encodePubShares(msg.IDs, msg.PubShares))
+				if err != nil {
+					common.Error("===================== refresh,put public shares fail ====================", "pubkey", pubkey, "key", msgprex, "err", err)
+					return nil, err
+				}
+			}
+
+			common.Info("===================== refresh finished successfully ====================", "pubkey", pubkey, "key", msgprex)
+			return msg.SkU1, nil
+		}
+	}
+}
+
+// AutoRefresh start a refresh of the shares of all ec pubkeys every interval seconds
+// the node with uid 1 in the keygen group signs the refresh command with its node key,
+// the other nodes approve it the same as a refresh command submitted by rpc
+func AutoRefresh(interval uint64) {
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, pubkey := range getRefreshPubKeys() {
+			pubs, err := getRefreshPubKeyData(pubkey)
+			if err != nil {
+				continue
+			}
+
+			_, uid := GetNodeUID(curEnode, getPubKeyType(pubs), pubs.GroupID)
+			if uid == nil || uid.Cmp(big.NewInt(1)) != 0 {
+				continue
+			}
+
+			raw, err := buildRefreshRaw(pubkey, pubs.GroupID)
+			if err != nil {
+				common.Error("=====================AutoRefresh,build refresh command fail=====================", "pubkey", pubkey, "err", err)
+				continue
+			}
+
+			key, _, err := Refresh(raw)
+			if err != nil {
+				common.Error("=====================AutoRefresh,start refresh fail=====================", "pubkey", pubkey, "err", err)
+				continue
+			}
+
+			common.Info("=====================AutoRefresh,refresh command sent,waiting for approval=====================", "pubkey", pubkey, "key", key)
+		}
+	}
+}
+
+// getRefreshPubKeys get all ec pubkeys generated by current node
+func getRefreshPubKeys() []string {
+	var pubkeys []string
+	if accountsdb == nil {
+		return pubkeys
+	}
+
+	exist := make(map[string]bool)
+	iter := accountsdb.NewIterator()
+	for iter.Next() {
+		value := string(iter.Value())
+		if value == "" || exist[strings.ToLower(value)] {
+			continue
+		}
+
+		pk, err := hex.DecodeString(value)
+		if err != nil || len(pk) != 65 {
+			continue
+		}
+
+		exist[strings.ToLower(value)] = true
+		pubkeys = append(pubkeys, value)
+	}
+	iter.Release()
+
+	return pubkeys
+}
+
+//-------------------------------------------------------ECDSA end-----------------------------------------------------------
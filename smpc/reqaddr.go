@@ -25,6 +25,8 @@ import (
 	keygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
 	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	srsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/sr25519/signing"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/fsn-dev/cryptoCoins/coins"
 	"math/big"
 	"sort"
@@ -47,6 +49,18 @@ var (
 	reqdataTimeout   = 60
 )
 
+const (
+	// SignProtocolGG20 presign by GG20,it is the default protocol
+	SignProtocolGG20 = "GG20"
+
+	// SignProtocolCGGMP21 presign by the three rounds presign of CGGMP21 (smpc-lib/ecdsa/presign),
+	// the paillier key and ntilde are made by keygen and the online sign is the same as GG20,see the package doc for the deviations from CGGMP21
+	SignProtocolCGGMP21 = "CGGMP21"
+
+	// SignProtocolFROST sign ED25519 by FROST,the nonce commitments are preprocessed and the signature is completed in a single online round
+	SignProtocolFROST = "FROST"
+)
+
 //------------------------------------------------------------------------
 
 // GetReqAddrNonce get keygen special tx nonce
@@ -109,15 +123,27 @@ type TxDataReqAddr struct {
 	AcceptTimeOut      string
 	TimeStamp string
 	Sigs      string
+	PaillierKeyLength string // bit length of paillier N and Ntilde,only for EC256K1 and EC256R1,"" is 2048
+	SignProtocol      string // GG20 || CGGMP21 for EC256K1 and EC256R1,"" is GG20; FROST for ED25519,"" is the default ed sign
 }
 
 // GetSmpcAddr Obtain SMPC addresses in different currencies in pubkey
 func GetSmpcAddr(pubkey string) (string, string, error) {
+	m, tip, err := GetSmpcAddrData(pubkey)
+	if err != nil {
+		return "", tip, err
+	}
+
+	b, _ := json.Marshal(m)
+	return string(b), "", nil
+}
+
+// GetSmpcAddrData get the coin addresses of the pubkey
+func GetSmpcAddrData(pubkey string) (*PubkeyRes, string, error) {
     	if pubkey == "" {
-	    return "","",errors.New("pubkey is nil")
+	    return nil,"",errors.New("pubkey is nil")
 	}
 
-	var m interface{}
 	addrmp := make(map[string]string)
 	for _, ct := range coins.Cointypes {
 		if strings.EqualFold(ct, "ALL") {
@@ -136,9 +162,7 @@ func GetSmpcAddr(pubkey string) (string, string, error) {
 		addrmp[ct] = ctaddr
 	}
 
-	m = &PubkeyRes{Account: "", PubKey: pubkey, SmpcAddress: addrmp}
-	b, _ := json.Marshal(m)
-	return string(b), "", nil
+	return &PubkeyRes{Account: "", PubKey: pubkey, SmpcAddress: addrmp}, "", nil
 }
 
 //-----------------------------------------------------------------------------
@@ -320,6 +344,7 @@ func ExecApproveKeyGen(raw string,from string,req *TxDataAcceptReqAddr,ac *Accep
     AcceptReqAddr(ac.Initiator, ac.Account, ac.Cointype, ac.GroupID, ac.Nonce, ac.LimitNum, ac.Mode, "false", accept, status, "", "", "", nil, ac.WorkID, "")
     
     w.msgacceptreqaddrres.PushBack(raw)
+    notifyApproval(req.Key, from, req.Accept)
     
     /////fix bug: miss accept msg for 7-11 test
     SendMsgToSmpcGroup(raw, ac.GroupID)
@@ -374,25 +399,35 @@ type ReqAddrStatus struct {
 
 // GetReqAddrStatus get the result of the keygen request by key
 func GetReqAddrStatus(key string) (string, string, error) {
+	los, tip, err := GetReqAddrStatusData(key)
+	if err != nil {
+		return "", tip, err
+	}
+
+	ret, _ := json.Marshal(los)
+	return string(ret), "", nil
+}
+
+// GetReqAddrStatusData get the result of the keygen request by key
+func GetReqAddrStatusData(key string) (*ReqAddrStatus, string, error) {
 	if key == "" {
-	    return "","",errors.New("param error")
+	    return nil,"",errors.New("param error")
 	}
 
 	exsit, da := GetPubKeyData([]byte(key))
 	///////
 	if !exsit || da == nil {
 		common.Debug("=====================GetReqAddrStatus,key does not exsit======================", "key", key)
-		return "", "smpc back-end internal error:get reqaddr accept data fail from db when GetReqAddrStatus", fmt.Errorf("get reqaddr accept data fail from db")
+		return nil, "smpc back-end internal error:get reqaddr accept data fail from db when GetReqAddrStatus", fmt.Errorf("get reqaddr accept data fail from db")
 	}
 
 	ac, ok := da.(*AcceptReqAddrData)
 	if !ok {
-		return "", "smpc back-end internal error:get reqaddr accept data error from db when GetReqAddrStatus", fmt.Errorf("get reqaddr accept data error from db")
+		return nil, "smpc back-end internal error:get reqaddr accept data error from db when GetReqAddrStatus", fmt.Errorf("get reqaddr accept data error from db")
 	}
 
 	los := &ReqAddrStatus{Status: ac.Status, PubKey: ac.PubKey, Tip: ac.Tip, Error: ac.Error, AllReply: ac.AllReply, TimeStamp: ac.TimeStamp}
-	ret, _ := json.Marshal(los)
-	return string(ret), "", nil
+	return los, "", nil
 }
 
 //------------------------------------------------------------------------------
@@ -500,7 +535,7 @@ func GetCurNodeReqAddrInfo(geteracc string) ([]*ReqAddrReply, string, error) {
 				return
 			}
 
-			if vv.Mode == "0" && !CheckAcc(curEnode, geteracc, vv.Sigs) {
+			if isGroupApprovalMode(vv.Mode) && !CheckAcc(curEnode, geteracc, vv.Sigs) {
 				return
 			}
 
@@ -537,13 +572,162 @@ type PubKeyData struct {
 	Mode           string
 	KeyGenTime     string
 	RefReShareKeys string //key1:key2...
+	KeyType        string //EC256K1 || EC256R1 || ED25519 || SR25519,"" is the data generated before EC256R1 supported
+	PaillierKeyLength string // bit length of paillier N and Ntilde,"" is the data generated before it is configurable,it is 2048
+	SignProtocol      string // GG20 || CGGMP21 || FROST,the protocol used by presign,"" is GG20 for EC256K1 and EC256R1,the default ed sign for ED25519
+	PubShares         string // uid:x:y|uid:x:y...,the public shares sku1*G of the nodes of EC256K1 and EC256R1,"" is the data generated before they are saved
+}
+
+// encodePubShares encode the public shares of ids for PubKeyData,"" if they are unknown
+func encodePubShares(ids smpclib.SortableIDSSlice, pubShares [][]*big.Int) string {
+	if len(ids) == 0 || len(pubShares) != len(ids) {
+		return ""
+	}
+
+	ss := make([]string, len(ids))
+	for k, id := range ids {
+		if id == nil || len(pubShares[k]) != 2 || pubShares[k][0] == nil || pubShares[k][1] == nil {
+			return ""
+		}
+		ss[k] = fmt.Sprintf("%v:%v:%v", id, pubShares[k][0], pubShares[k][1])
+	}
+
+	return strings.Join(ss, "|")
+}
+
+// decodePubShares decode the public shares in PubKeyData,return the uids and the public shares in the same order
+func decodePubShares(s string) (smpclib.SortableIDSSlice, [][]*big.Int, error) {
+	if s == "" {
+		return nil, nil, errors.New("no public shares")
+	}
+
+	ss := strings.Split(s, "|")
+	ids := make(smpclib.SortableIDSSlice, len(ss))
+	pubShares := make([][]*big.Int, len(ss))
+	for k, v := range ss {
+		mm := strings.Split(v, ":")
+		if len(mm) != 3 {
+			return nil, nil, errors.New("invalid public share")
+		}
+
+		id, ok1 := new(big.Int).SetString(mm[0], 10)
+		x, ok2 := new(big.Int).SetString(mm[1], 10)
+		y, ok3 := new(big.Int).SetString(mm[2], 10)
+		if !ok1 || !ok2 || !ok3 {
+			return nil, nil, errors.New("invalid public share")
+		}
+
+		ids[k] = id
+		pubShares[k] = []*big.Int{x, y}
+	}
+
+	return ids, pubShares, nil
+}
+
+// getPubKeyType get the keytype of the pubkey,the old data has no KeyType,it is EC256K1 or ED25519 by the length of pubkey
+func getPubKeyType(pubs *PubKeyData) string {
+	if pubs == nil {
+		return ""
+	}
+
+	if pubs.KeyType != "" {
+		return pubs.KeyType
+	}
+
+	if len(pubs.Pub) == 65 {
+		return "EC256K1"
+	}
+
+	return "ED25519"
+}
+
+// getPaillierKeyLength get the bit length of paillier N and Ntilde of the pubkey,the old data has no PaillierKeyLength,it is 2048
+func getPaillierKeyLength(pubs *PubKeyData) int {
+	if pubs == nil || pubs.PaillierKeyLength == "" {
+		return PaillierKeyLength
+	}
+
+	length, err := strconv.Atoi(pubs.PaillierKeyLength)
+	if err != nil {
+		return PaillierKeyLength
+	}
+
+	return length
+}
+
+// parsePaillierKeyLength parse the paillier key length in command data,"" is the default length
+func parsePaillierKeyLength(length string) (int, error) {
+	if length == "" {
+		return PaillierKeyLength, nil
+	}
+
+	l, err := strconv.Atoi(length)
+	if err != nil {
+		return 0, fmt.Errorf("invalid paillier key length")
+	}
+
+	if err := smpclibec2.CheckPaillierKeyLength(l); err != nil {
+		return 0, err
+	}
+
+	return l, nil
+}
+
+// getSignProtocol get the presign protocol of the pubkey,the old data has no SignProtocol,it is GG20
+func getSignProtocol(pubs *PubKeyData) string {
+	if pubs == nil || pubs.SignProtocol == "" {
+		return SignProtocolGG20
+	}
+
+	return pubs.SignProtocol
+}
+
+// parseSignProtocol parse the presign protocol of keytype in command data
+// "" is GG20 for EC256K1 and EC256R1,and the default ed sign for ED25519
+func parseSignProtocol(keytype string, protocol string) (string, error) {
+	switch keytype {
+	case "EC256K1", "EC256R1":
+		if protocol == "" {
+			return SignProtocolGG20, nil
+		}
+
+		if protocol != SignProtocolGG20 && protocol != SignProtocolCGGMP21 {
+			return "", fmt.Errorf("invalid sign protocol")
+		}
+	case "ED25519":
+		if protocol != "" && protocol != SignProtocolFROST {
+			return "", fmt.Errorf("invalid sign protocol")
+		}
+	default:
+		if protocol != "" {
+			return "", fmt.Errorf("sign protocol is not supported by %v", keytype)
+		}
+	}
+
+	return protocol, nil
+}
+
+// isFROST whether the ED25519 pubkey is signed by FROST
+func isFROST(pubs *PubKeyData) bool {
+	return pubs != nil && pubs.SignProtocol == SignProtocolFROST
+}
+
+// getCoinTypes get the cointypes whose address can be derived from the pubkey of keytype
+// all coins use secp256k1 or ed25519 pubkey,so there is no coin address for EC256R1 and SR25519
+func getCoinTypes(keytype string) []string {
+	if keytype == "EC256R1" || keytype == "SR25519" {
+		return nil
+	}
+
+	return coins.Cointypes
 }
 
 // smpcGenPubKey generate the pubkey 
 // ec2
 // msgprex = hash
-// cointype = keytype    // EC256K1||ed25519
-func smpcGenPubKey(msgprex string, account string, cointype string, ch chan interface{}, mode string, nonce string) {
+// cointype = keytype    // EC256K1||EC256R1||ed25519||SR25519,SR25519 use the ed25519 keygen and save the ristretto255 encoding of pubkey
+// deal is not nil when an existing private key is imported,the shares are dealt by the dealer instead of being generated by keygen
+func smpcGenPubKey(msgprex string, account string, cointype string, ch chan interface{}, mode string, nonce string, deal *ImportKeyDeal) {
 	if msgprex == "" || account == "" || cointype == "" || mode == "" || nonce == "" {
 	    res := RPCSmpcRes{Ret: "", Tip: "param error", Err: errors.New("param error")}
 	    ch <- res
@@ -560,14 +744,18 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 
 	curEnode = GetSelfEnode()
 
-	if cointype == "ED25519" {
+	if cointype == "ED25519" || cointype == "SR25519" {
 		ok2 := false
 		for j := 0; j < recalcTimes; j++ {
 			if len(ch) != 0 {
 				<-ch
 			}
 
-			ok2 = KeyGenerateDEDDSA(msgprex, ch, id, cointype)
+			if deal != nil {
+				ok2 = ImportKeyEDDSA(msgprex, ch, id, deal)
+			} else {
+				ok2 = KeyGenerateDEDDSA(msgprex, ch, id, "ED25519")
+			}
 			if ok2 {
 				break
 			}
@@ -587,6 +775,17 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 			return
 		}
 		sedpk := []byte(itertmp.Value.(string))
+		if cointype == "SR25519" {
+			var edpk [32]byte
+			copy(edpk[:], sedpk)
+			srpk, err := srsigning.PubKeyFromEd(edpk)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:encode sr25519 pubkey fail", Err: err}
+				ch <- res
+				return
+			}
+			sedpk = srpk[:]
+		}
 
 		itertmp = workers[id].edsave.Front()
 		if itertmp == nil {
@@ -604,10 +803,20 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 		}
 
 		sedsku1 := itertmp.Value.(string)
+
+		//bip32
+		itertmp = workers[id].bip32c.Front()
+		if itertmp == nil {
+			res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get c for bip32 fail in req ed pubkey", Err: fmt.Errorf("get c for bip32 fail in req ed pubkey")}
+			ch <- res
+			return
+		}
+		sedbip32c := itertmp.Value.(string)
+
 		tt := fmt.Sprintf("%v", time.Now().UnixNano()/1e6)
 		pubkeyhex := hex.EncodeToString(sedpk)
 
-		pubs := &PubKeyData{Key: msgprex, Account: account, Pub: string(sedpk), Save: sedsave, Nonce: nonce, GroupID: wk.groupid, LimitNum: wk.limitnum, Mode: mode, KeyGenTime: tt}
+		pubs := &PubKeyData{Key: msgprex, Account: account, Pub: string(sedpk), Save: sedsave, Nonce: nonce, GroupID: wk.groupid, LimitNum: wk.limitnum, Mode: mode, KeyGenTime: tt, KeyType: cointype, SignProtocol: wk.signprotocol}
 		epubs, err := Encode2(pubs)
 		if err != nil {
 			common.Error("===============smpcGenPubKey,encode fail=================", "err", err, "account", account, "pubkey", pubkeyhex, "nonce", nonce, "key", msgprex)
@@ -653,7 +862,14 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 			return
 		}
 
-		for _, ct := range coins.Cointypes {
+		err = putBip32cToLocalDb(sedpk[:], []byte(sedbip32c))
+		if err != nil {
+			res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error: put bip32c data fail", Err: err}
+			ch <- res
+			return
+		}
+
+		for _, ct := range getCoinTypes(cointype) {
 			if strings.EqualFold(ct, "ALL") {
 				continue
 			}
@@ -689,6 +905,13 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 				ch <- res
 				return
 			}
+
+			err = putBip32cToLocalDb([]byte(key), []byte(sedbip32c))
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error: put bip32c data fail", Err: err}
+				ch <- res
+				return
+			}
 		}
 
 		res := RPCSmpcRes{Ret: pubkeyhex, Tip: "", Err: nil}
@@ -702,7 +925,11 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 			<-ch
 		}
 
-		ok = KeyGenerateDECDSA(msgprex, ch, id, cointype)
+		if deal != nil {
+			ok = ImportKeyECDSA(msgprex, ch, id, cointype, deal)
+		} else {
+			ok = KeyGenerateDECDSA(msgprex, ch, id, cointype)
+		}
 		if ok {
 			break
 		}
@@ -775,7 +1002,12 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 	pubkeyhex := hex.EncodeToString(ys)
 	common.Info("================ smpc_genpubkey,pubkey generated successfully ===================","pkx",pkx,"pky",pky,"pubkey hex",pubkeyhex)
 
-	pubs := &PubKeyData{Key: msgprex, Account: account, Pub: string(ys), Save: save, Nonce: nonce, GroupID: wk.groupid, LimitNum: wk.limitnum, Mode: mode, KeyGenTime: tt}
+	pubshares := ""
+	if iter = workers[id].pubshares.Front(); iter != nil {
+		pubshares = iter.Value.(string)
+	}
+
+	pubs := &PubKeyData{Key: msgprex, Account: account, Pub: string(ys), Save: save, Nonce: nonce, GroupID: wk.groupid, LimitNum: wk.limitnum, Mode: mode, KeyGenTime: tt, KeyType: cointype, PaillierKeyLength: fmt.Sprintf("%v", wk.paillierkeylength), SignProtocol: wk.signprotocol, PubShares: pubshares}
 	epubs, err := Encode2(pubs)
 	if err != nil {
 		common.Error("===============smpcGenPubKey,encode fail===================", "err", err, "account", account, "pubkey", pubkeyhex, "nonce", nonce, "key", rk)
@@ -816,7 +1048,7 @@ func smpcGenPubKey(msgprex string, account string, cointype string, ch chan inte
 		return
 	}
 
-	for _, ct := range coins.Cointypes {
+	for _, ct := range getCoinTypes(cointype) {
 		if strings.EqualFold(ct, "ALL") {
 			continue
 		}
@@ -903,10 +1135,11 @@ func KeyGenerateDECDSA(msgprex string, ch chan interface{}, id int, cointype str
 	outCh := make(chan smpclib.Message, ns)
 	endCh := make(chan keygen.LocalDNodeSaveData, ns)
 	errChan := make(chan struct{})
-	keyGenDNode := keygen.NewLocalDNode(outCh, endCh, ns, w.ThresHold, 2048)
+	keyGenDNode := keygen.NewLocalDNode(outCh, endCh, ns, w.ThresHold, w.paillierkeylength, cointype)
 	w.DNode = keyGenDNode
-	_,UID := GetNodeUID(curEnode, "EC256K1",w.groupid)
+	_,UID := GetNodeUID(curEnode, cointype,w.groupid)
 	keyGenDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	keyGenDNode.SetSessionKey(msgprex)
 	//fmt.Printf("=========== KeyGenerateDECDSA, current node uid = %v ===========\n", keyGenDNode.DNodeID())
 
 	w.MsgToEnode[w.DNode.DNodeID()] = curEnode
@@ -930,8 +1163,8 @@ func KeyGenerateDECDSA(msgprex string, ch chan interface{}, id int, cointype str
 			}
 		}
 	}()
-	go ProcessInboundMessages(msgprex, commStopChan, &keyGenWg, ch)
-	err := processKeyGen(msgprex, errChan, outCh, endCh)
+	go ProcessInboundMessages(msgprex, cointype, commStopChan, &keyGenWg, ch)
+	err := processKeyGen(msgprex, cointype, errChan, outCh, endCh)
 	if err != nil {
 		log.Error("==========KeyGenerateDECDSA,process keygen error============","key",msgprex,"err",err)
 		close(commStopChan)
@@ -982,6 +1215,7 @@ func KeyGenerateDEDDSA(msgprex string, ch chan interface{}, id int, cointype str
 	w.DNode = keyGenDNode
 	_,UID := GetNodeUID(curEnode, "ED25519",w.groupid)
 	keyGenDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	keyGenDNode.SetSessionKey(msgprex)
 	w.MsgToEnode[w.DNode.DNodeID()] = curEnode
 
 	var keyGenWg sync.WaitGroup
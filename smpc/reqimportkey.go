@@ -0,0 +1,434 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/log"
+	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/importkey"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/fsn-dev/cryptoCoins/coins/types"
+	"github.com/fsn-dev/cryptoCoins/tools/rlp"
+)
+
+//--------------------------------------------------------------------------------
+
+// ImportKeyDeal the shares of an existing private key dealt to the nodes of a group by a trusted dealer
+type ImportKeyDeal struct {
+	PubKey string            // hex of the pubkey,the same format as the pubkey generated by keygen
+	PolyG  []string          // hex of the feldman commitments,PolyG[0] is the pubkey
+	C      string            // hex of the chain code for bip32
+	Shares map[string]string // enode id --> hex of the share encrypted to the enode
+}
+
+// TxDataImportKey the data of the special tx of importing an existing private key
+// it is approved and executed just like keygen,only the shares come from the dealer instead of the group
+type TxDataImportKey struct {
+	TxDataReqAddr
+	Deal *ImportKeyDeal
+}
+
+// ImportKey execute the import key command
+// raw : import key command data
+func ImportKey(raw string) (string, string, error) {
+	if raw == "" {
+		return "", "", errors.New("param error")
+	}
+
+	key, _, _, txdata, err := CheckRaw(raw)
+	if err != nil {
+		common.Error("============ImportKey,check raw data error==============", "err ", err)
+		return "", err.Error(), err
+	}
+
+	req, ok := txdata.(*TxDataReqAddr)
+	if !ok || req.TxType != "IMPORTKEY" {
+		return "", "check raw fail,it is not import key tx data", fmt.Errorf("check raw fail,it is not import key tx data")
+	}
+
+	common.Debug("============ImportKey,SendMsgToSmpcGroup===============", "raw ", raw, "gid ", req.GroupID, "key ", key)
+	SendMsgToSmpcGroup(raw, req.GroupID)
+	SetUpMsgList(raw, curEnode)
+	return key, "", nil
+}
+
+// getImportKeyDeal get the deal in the import key command data
+func getImportKeyDeal(raw string) (*ImportKeyDeal, error) {
+	tx := new(types.Transaction)
+	raws := common.FromHex(raw)
+	if err := rlp.DecodeBytes(raws, tx); err != nil {
+		return nil, err
+	}
+
+	req := TxDataImportKey{}
+	err := json.Unmarshal(tx.Data(), &req)
+	if err != nil || req.TxType != "IMPORTKEY" || req.Deal == nil {
+		return nil, errors.New("it is not import key tx data")
+	}
+
+	return req.Deal, nil
+}
+
+// checkImportKeyDeal check the deal of the import key command before the group approves it
+// the shares can only be verified by their owners,it is done during the import
+func checkImportKeyDeal(req *TxDataImportKey, threshold int) error {
+	if req.Keytype != "EC256K1" && req.Keytype != "ED25519" {
+		return errors.New("only EC256K1 and ED25519 key can be imported")
+	}
+
+	deal := req.Deal
+	if deal == nil || deal.PubKey == "" {
+		return errors.New("no import key deal")
+	}
+
+	if len(deal.PolyG) != threshold {
+		return errors.New("the count of feldman commitments is not equal to threshold")
+	}
+
+	if !strings.EqualFold(deal.PubKey, deal.PolyG[0]) {
+		return errors.New("the pubkey is not the commitment of the secret")
+	}
+
+	if req.Keytype == "ED25519" {
+		if _, err := getImportKeyCfsB(deal); err != nil {
+			return err
+		}
+	} else if _, err := getImportKeyPolyG(deal); err != nil {
+		return err
+	}
+
+	if c, err := hex.DecodeString(deal.C); err != nil || len(c) == 0 || len(c) > 32 {
+		return errors.New("invalid chain code")
+	}
+
+	_, nodes := GetGroup(req.GroupID)
+	others := strings.Split(nodes, common.Sep2)
+	if len(deal.Shares) != len(others) {
+		return errors.New("the count of shares is not equal to the count of nodes in group")
+	}
+
+	for _, v := range others {
+		if deal.Shares[ParseNode(v)] == "" {
+			return errors.New("no share for the node " + ParseNode(v))
+		}
+	}
+
+	pk, _ := hex.DecodeString(deal.PubKey)
+	if exsit, _ := GetPubKeyData(pk); exsit {
+		return errors.New("the pubkey already exists")
+	}
+
+	return nil
+}
+
+// getImportKeyPolyG decode the secp256k1 feldman commitments of the deal
+func getImportKeyPolyG(deal *ImportKeyDeal) (*smpclibec2.PolyGStruct2, error) {
+	polyG := make([][]*big.Int, 0)
+	for _, v := range deal.PolyG {
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, errors.New("invalid feldman commitment")
+		}
+
+		x, y := secp256k1.S256().Unmarshal(b)
+		if x == nil || y == nil {
+			return nil, errors.New("invalid feldman commitment")
+		}
+
+		polyG = append(polyG, []*big.Int{x, y})
+	}
+
+	return &smpclibec2.PolyGStruct2{PolyG: polyG}, nil
+}
+
+// getImportKeyCfsB decode the ed25519 feldman commitments of the deal
+func getImportKeyCfsB(deal *ImportKeyDeal) ([][32]byte, error) {
+	cfsBBytes := make([][32]byte, len(deal.PolyG))
+	for k, v := range deal.PolyG {
+		b, err := hex.DecodeString(v)
+		if err != nil || len(b) != 32 {
+			return nil, errors.New("invalid feldman commitment")
+		}
+
+		copy(cfsBBytes[k][:], b)
+	}
+
+	return cfsBBytes, nil
+}
+
+// getImportKeyShare decrypt the share dealt to current node
+func getImportKeyShare(deal *ImportKeyDeal) ([]byte, error) {
+	cm, err := hex.DecodeString(deal.Shares[curEnode])
+	if err != nil || len(cm) == 0 {
+		return nil, errors.New("no share for current node")
+	}
+
+	m, err := DecryptMsg(string(cm))
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(m)
+}
+
+//----------------------------------------------------ECDSA start----------------------------------------------------------
+
+// ImportKeyECDSA verify the share dealt to current node and generate the paillier key and ntilde with the group
+// the save data is pushed to the worker just like keygen
+func ImportKeyECDSA(msgprex string, ch chan interface{}, id int, keytype string, deal *ImportKeyDeal) bool {
+	if id < 0 || id >= RPCMaxWorker || id >= len(workers) || deal == nil {
+		res := RPCSmpcRes{Ret: "", Err: GetRetErr(ErrGetWorkerIDError)}
+		ch <- res
+		return false
+	}
+
+	w := workers[id]
+	ns, _ := GetGroup(w.groupid)
+	if ns != w.NodeCnt {
+		res := RPCSmpcRes{Ret: "", Err: GetRetErr(ErrGroupNotReady)}
+		ch <- res
+		return false
+	}
+
+	polyG, err := getImportKeyPolyG(deal)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return false
+	}
+
+	share, err := getImportKeyShare(deal)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "get the share of current node fail", Err: err}
+		ch <- res
+		return false
+	}
+
+	c, _ := hex.DecodeString(deal.C)
+	sd := &keygen.LocalDNodeSaveData{}
+	sd.Pkx, sd.Pky = polyG.PolyG[0][0], polyG.PolyG[0][1]
+	sd.C = new(big.Int).SetBytes(c)
+	sd.SkU1 = new(big.Int).SetBytes(share)
+	sd.IDs = GetGroupNodeUIDs(keytype, w.groupid, w.groupid)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, keytype, w.groupid)
+	if sd.CurDNodeID == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("current node is not in the group")}
+		ch <- res
+		return false
+	}
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, ns)
+	endCh := make(chan keygen.LocalDNodeSaveData, ns)
+	errChan := make(chan struct{})
+	importDNode := importkey.NewLocalDNode(outCh, endCh, ns, w.ThresHold, w.paillierkeylength, sd, polyG, keytype)
+	w.DNode = importDNode
+	importDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	importDNode.SetSessionKey(msgprex)
+	w.MsgToEnode = GetMsgToEnode(keytype, w.groupid, w.groupid)
+
+	var importWg sync.WaitGroup
+	importWg.Add(2)
+	go func() {
+		defer importWg.Done()
+		if err := importDNode.Start(); nil != err {
+			log.Error("==========ImportKeyECDSA, node start error============", "key", msgprex, "err", err)
+			close(errChan)
+		}
+
+		for _, uid := range sd.IDs {
+			HandleImportKey(msgprex, uid)
+		}
+	}()
+	go ImportKeyProcessInboundMessages(msgprex, w.groupid, commStopChan, &importWg, ch)
+	err = processKeyGen(msgprex, keytype, errChan, outCh, endCh)
+	if err != nil {
+		log.Error("==========ImportKeyECDSA,process import key error============", "key", msgprex, "err", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return false
+	}
+
+	close(commStopChan)
+	importWg.Wait()
+
+	return true
+}
+
+// HandleImportKey Process pre-save msg for import key
+func HandleImportKey(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "ImpRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "ImpRound2Message")
+	Handle(key, c1data)
+}
+
+// ImportKeyProcessInboundMessages Analyze the obtained P2P messages and enter next round
+func ImportKeyProcessInboundMessages(msgprex string, groupid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+
+	if msgprex == "" || groupid == "" {
+		return
+	}
+
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer log.Info("stop processing import key inbound messages", "key", msgprex)
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := ImportKeyGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[IMPORTKEY] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============import key,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], "EC256K1", groupid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============import key,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				log.Error("========== ImportKeyProcessInboundMessages, dnode update fail ============", "key", msgprex, "err", err)
+				saveBlame(msgprex, "EC256K1", groupid, groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// ImportKeyGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func ImportKeyGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "ecdsa/importkey")
+}
+
+//-------------------------------------------------------ECDSA end-----------------------------------------------------------
+
+//----------------------------------------------------EDDSA start----------------------------------------------------------
+
+// ImportKeyEDDSA verify the share dealt to current node against the feldman commitments and push the save data to the worker
+// there are no paillier key and ntilde for ed25519,so no message is exchanged
+func ImportKeyEDDSA(msgprex string, ch chan interface{}, id int, deal *ImportKeyDeal) bool {
+	if id < 0 || id >= RPCMaxWorker || id >= len(workers) || deal == nil {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:no find worker id", Err: GetRetErr(ErrGetWorkerIDError)}
+		ch <- res
+		return false
+	}
+
+	w := workers[id]
+	cfsBBytes, err := getImportKeyCfsB(deal)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return false
+	}
+
+	s, err := getImportKeyShare(deal)
+	if err != nil || len(s) != 32 {
+		res := RPCSmpcRes{Ret: "", Tip: "get the share of current node fail", Err: fmt.Errorf("get the share of current node fail")}
+		ch <- res
+		return false
+	}
+
+	var share [32]byte
+	copy(share[:], s)
+	var c [32]byte
+	cb, _ := hex.DecodeString(deal.C)
+	copy(c[:], cb)
+
+	ids := GetGroupNodeUIDs("ED25519", w.groupid, w.groupid)
+	_, curid := GetNodeUID(curEnode, "ED25519", w.groupid)
+	msg, err := edkeygen.ImportSaveData(share, cfsBBytes, c, ids, curid)
+	if err != nil {
+		log.Error("==========ImportKeyEDDSA,verify the share fail==========", "key", msgprex, "err", err)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return false
+	}
+
+	w.edsku1.PushBack(string(msg.Sk[:]))
+	w.edpk.PushBack(string(msg.FinalPkBytes[:]))
+	w.bip32c.PushBack(string(msg.C[:]))
+
+	ss := "XXX" + common.Sep11 + string(msg.Pk[:]) + common.Sep11 + string(msg.TSk[:]) + common.Sep11 + string(msg.FinalPkBytes[:])
+	w.edsave.PushBack(ss)
+	return true
+}
+
+//-------------------------------------------------------EDDSA end-----------------------------------------------------------
@@ -0,0 +1,502 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/recovery"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/fsn-dev/cryptoCoins/coins"
+)
+
+//--------------------------------------------------------------------------------
+
+// TxDataRecoverShare the data of the special tx of recover share or refresh
+// RECOVERSHARE: the nodes of the keygen group re-create the sku1 of Enode,the pubkey and the uid of Enode do not change
+// REFRESH: the nodes of the keygen group re-randomise their sku1,Enode is empty
+type TxDataRecoverShare struct {
+	TxType        string // RECOVERSHARE || REFRESH
+	PubKey        string
+	GroupID       string
+	Enode         string // the enode id of the node that lost its sku1,empty for REFRESH
+	AcceptTimeOut string
+	TimeStamp     string
+}
+
+// RecoverShare execute the recover share command
+// raw : recover share command data
+func RecoverShare(raw string) (string, string, error) {
+	if raw == "" {
+		return "", "", errors.New("param error")
+	}
+
+	key, _, _, txdata, err := CheckRaw(raw)
+	if err != nil {
+		common.Error("=====================RecoverShare,check raw data error ================", "raw", raw, "err", err)
+		return "", err.Error(), err
+	}
+
+	rs, ok := txdata.(*TxDataRecoverShare)
+	if !ok || rs.TxType != "RECOVERSHARE" {
+		return "", "check raw fail,it is not recover share command", fmt.Errorf("check raw data fail")
+	}
+
+	common.Debug("=====================RecoverShare, SendMsgToSmpcGroup ================", "raw", raw, "gid", rs.GroupID, "key", key)
+	SendMsgToSmpcGroup(raw, rs.GroupID)
+	SetUpMsgList(raw, curEnode)
+	return key, "", nil
+}
+
+//-----------------------------------------------------------------------------------
+
+// RPCAcceptRecoverShare Agree to the recover share request
+// raw : accept data, including the key of the recover share request
+func RPCAcceptRecoverShare(raw string) (string, string, error) {
+	if raw == "" {
+		return "", "", errors.New("param error")
+	}
+
+	_, _, _, txdata, err := CheckRaw(raw)
+	if err != nil {
+		common.Error("=====================RPCAcceptRecoverShare,check raw data error ================", "raw", raw, "err", err)
+		return "Failure", err.Error(), err
+	}
+
+	acceptrs, ok := txdata.(*TxDataAcceptRecoverShare)
+	if !ok {
+		return "Failure", "check raw fail,it is not *TxDataAcceptRecoverShare", fmt.Errorf("check raw fail,it is not *TxDataAcceptRecoverShare")
+	}
+
+	exsit, da := GetRecoverShareInfoData([]byte(acceptrs.Key))
+	if exsit {
+		ac, ok := da.(*AcceptRecoverShareData)
+		if ok && ac != nil {
+			common.Debug("=====================RPCAcceptRecoverShare, SendMsgToSmpcGroup ================", "raw", raw, "gid", ac.GroupID, "key", acceptrs.Key)
+			SendMsgToSmpcGroup(raw, ac.GroupID)
+			SetUpMsgList(raw, curEnode)
+			return "Success", "", nil
+		}
+	}
+
+	return "Failure", "accept fail", fmt.Errorf("accept fail")
+}
+
+//-------------------------------------------------------------------------------------
+
+// RecoverShareStatus recover share or refresh result
+type RecoverShareStatus struct {
+	TxType    string
+	Status    string
+	Pubkey    string
+	Enode     string
+	Tip       string
+	Error     string
+	AllReply  []NodeReply
+	TimeStamp string
+}
+
+// GetRecoverShareStatus get the result of the recover share request by key
+func GetRecoverShareStatus(key string) (string, string, error) {
+	los, tip, err := GetRecoverShareStatusData(key)
+	if err != nil {
+		return "", tip, err
+	}
+
+	ret, _ := json.Marshal(los)
+	return string(ret), "", nil
+}
+
+// GetRecoverShareStatusData get the result of the recover share request by key
+func GetRecoverShareStatusData(key string) (*RecoverShareStatus, string, error) {
+	if key == "" {
+		return nil, "", errors.New("param error")
+	}
+
+	exsit, da := GetPubKeyData([]byte(key))
+	if !exsit || da == nil {
+		exsit, da = GetRecoverShareInfoData([]byte(key))
+	}
+
+	if !exsit || da == nil {
+		return nil, "smpc back-end internal error:get recover share accept data fail from db when GetRecoverShareStatus", fmt.Errorf("get recover share accept data fail from db")
+	}
+
+	ac, ok := da.(*AcceptRecoverShareData)
+	if !ok {
+		return nil, "smpc back-end internal error:get recover share accept data error from db when GetRecoverShareStatus", fmt.Errorf("get recover share accept data error from db")
+	}
+
+	los := &RecoverShareStatus{TxType: getRecoverShareTxType(ac), Status: ac.Status, Pubkey: ac.PubKey, Enode: ac.Enode, Tip: ac.Tip, Error: ac.Error, AllReply: ac.AllReply, TimeStamp: ac.TimeStamp}
+	return los, "", nil
+}
+
+//-------------------------------------------------------------------------------------
+
+// RecoverShareCurNodeInfo the data of current node's approve list
+type RecoverShareCurNodeInfo struct {
+	TxType    string
+	Key       string
+	PubKey    string
+	GroupID   string
+	Enode     string
+	Account   string
+	TimeStamp string
+}
+
+// RecoverShareCurNodeInfoSort sort the info of current node's approve list
+type RecoverShareCurNodeInfoSort struct {
+	Info []*RecoverShareCurNodeInfo
+}
+
+// Len get the count of arrary elements
+func (r *RecoverShareCurNodeInfoSort) Len() int {
+	return len(r.Info)
+}
+
+// Less weather r.Info[i] < r.Info[j]
+func (r *RecoverShareCurNodeInfoSort) Less(i, j int) bool {
+	itime, _ := new(big.Int).SetString(r.Info[i].TimeStamp, 10)
+	jtime, _ := new(big.Int).SetString(r.Info[j].TimeStamp, 10)
+	return itime.Cmp(jtime) >= 0
+}
+
+// Swap swap value of r.Info[i] and r.Info[j]
+func (r *RecoverShareCurNodeInfoSort) Swap(i, j int) {
+	r.Info[i], r.Info[j] = r.Info[j], r.Info[i]
+}
+
+// GetCurNodeRecoverShareInfo  Get current node's recover share command approval list
+func GetCurNodeRecoverShareInfo() ([]*RecoverShareCurNodeInfo, string, error) {
+	var ret []*RecoverShareCurNodeInfo
+	if recovershareinfodb == nil {
+		return ret, "", nil
+	}
+
+	iter := recovershareinfodb.NewIterator()
+	for iter.Next() {
+		key := []byte(string(iter.Key())) //must be deep copy, Otherwise, an error will be reported: "panic: JSON decoder out of sync - data changing underfoot?"
+		exsit, da := GetRecoverShareInfoData(key)
+		if !exsit || da == nil {
+			continue
+		}
+
+		vv, ok := da.(*AcceptRecoverShareData)
+		if vv == nil || !ok {
+			continue
+		}
+
+		if vv.Deal == "true" || vv.Status != "Pending" {
+			continue
+		}
+
+		los := &RecoverShareCurNodeInfo{TxType: getRecoverShareTxType(vv), Key: string(key), PubKey: vv.PubKey, GroupID: vv.GroupID, Enode: vv.Enode, Account: vv.Account, TimeStamp: vv.TimeStamp}
+		ret = append(ret, los)
+	}
+	iter.Release()
+
+	infosort := RecoverShareCurNodeInfoSort{Info: ret}
+	sort.Sort(&infosort)
+
+	return infosort.Info, "", nil
+}
+
+//----------------------------------------------------ECDSA start----------------------------------------------------------
+
+// getRecoverSharePubKeyData get the pubkey data whose share can be recovered,only the EC256K1 and EC256R1 pubkeys are supported now
+func getRecoverSharePubKeyData(pubkey string, groupid string) (*PubKeyData, error) {
+	pubs, err := getRefreshPubKeyData(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(pubs.GroupID, groupid) {
+		return nil, errors.New("the group id is not the keygen group of pubkey")
+	}
+
+	return pubs, nil
+}
+
+// RecoverShareEC2 re-create the sku1 of enode with all nodes in the keygen group
+// none of the nodes learns the secret,only the node of enode gets its sku1 back and saves it
+func RecoverShareEC2(msgprex string, pubs *PubKeyData, enode string, ch chan interface{}, id int) (*big.Int, error) {
+	if id < 0 || id >= len(workers) || pubs == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("no find worker")}
+		ch <- res
+		return nil, errors.New("no find worker")
+	}
+	w := workers[id]
+
+	threshold, err := getRefreshThreshold(pubs.LimitNum)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return nil, err
+	}
+
+	keytype := getPubKeyType(pubs)
+	_, recoverid := GetNodeUID(enode, keytype, pubs.GroupID)
+	if recoverid == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("the node to recover is not in the group")}
+		ch <- res
+		return nil, errors.New("the node to recover is not in the group")
+	}
+
+	smpcpks := []byte(pubs.Pub)
+	sd := &keygen.LocalDNodeSaveData{}
+	sd.Pkx, sd.Pky = elliptic.Unmarshal(ec2.GetCurve(keytype), smpcpks[:])
+	sd.IDs = GetGroupNodeUIDs(keytype, pubs.GroupID, pubs.GroupID)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, keytype, pubs.GroupID)
+	if sd.Pkx == nil || sd.CurDNodeID == nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("get recover share save data fail")}
+		ch <- res
+		return nil, errors.New("get recover share save data fail")
+	}
+
+	// the recovering node does not use its sku1 even if some of it is still left in local db
+	if sd.CurDNodeID.Cmp(recoverid) != 0 {
+		da := getSkU1FromLocalDb(smpcpks[:])
+		if da == nil {
+			res := RPCSmpcRes{Ret: "", Tip: "recover share get sku1 fail", Err: fmt.Errorf("recover share get sku1 fail")}
+			ch <- res
+			return nil, errors.New("recover share get sku1 fail")
+		}
+		sd.SkU1 = new(big.Int).SetBytes(da)
+	}
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.NodeCnt)
+	endCh := make(chan keygen.LocalDNodeSaveData, w.NodeCnt)
+	errChan := make(chan struct{})
+	recoverDNode := recovery.NewLocalDNode(outCh, endCh, w.NodeCnt, threshold, sd, recoverid, keytype)
+	w.DNode = recoverDNode
+	recoverDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	recoverDNode.SetSessionKey(msgprex)
+	w.MsgToEnode = GetMsgToEnode(keytype, pubs.GroupID, pubs.GroupID)
+
+	var recoverWg sync.WaitGroup
+	recoverWg.Add(2)
+	go func() {
+		defer recoverWg.Done()
+		if err := recoverDNode.Start(); nil != err {
+			fmt.Printf("==========recover share node start err = %v ==========\n", err)
+			close(errChan)
+		}
+
+		for _, uid := range sd.IDs {
+			HandleRecoverShare(msgprex, uid)
+		}
+	}()
+	go RecoverShareProcessInboundMessages(msgprex, keytype, pubs.GroupID, commStopChan, &recoverWg, ch)
+	sku1, err := processRecoverShare(msgprex, pubs.GroupID, hex.EncodeToString(smpcpks[:]), getPubKeyType(pubs), sd.CurDNodeID.Cmp(recoverid) == 0, errChan, outCh, endCh)
+	if err != nil {
+		fmt.Printf("==========process recover share err = %v ==========\n", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return nil, err
+	}
+
+	close(commStopChan)
+	recoverWg.Wait()
+	return sku1, nil
+}
+
+// HandleRecoverShare Process pre-save msg for recover share
+func HandleRecoverShare(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "RecRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RecRound2Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RecRound2Message1")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RecRound3Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "RecRound4Message")
+	Handle(key, c1data)
+}
+
+// RecoverShareProcessInboundMessages Analyze the obtained P2P messages and enter next round,keytype is the type of the pubkey
+func RecoverShareProcessInboundMessages(msgprex string, keytype string, groupid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+
+	if msgprex == "" || groupid == "" {
+		return
+	}
+
+	fmt.Printf("start processing recover share inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop processing recover share inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := RecoverShareGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[RECOVERSHARE] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============recover share,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], keytype, groupid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============recover share,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== RecoverShareProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v ============\n", m, err)
+				saveBlame(msgprex, keytype, groupid, groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// RecoverShareGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func RecoverShareGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "ecdsa/recovery")
+}
+
+// processRecoverShare  Obtain the data to be sent in each round and send it to other nodes until the end of the recover share command
+// the recovering node saves the recovered sku1 under the pubkey and all coin addresses
+func processRecoverShare(msgprex string, groupid string, pubkey string, keytype string, recovering bool, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) (*big.Int, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========== processRecoverShare,error channel closed fail to start local smpc node ===========\n")
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * 300):
+			fmt.Printf("=========== processRecoverShare,recover share timeout ===========\n")
+			return nil, errors.New("recover share timeout")
+		case msg := <-outCh:
+			err := ReshareProcessOutCh(msgprex, groupid, msg)
+			if err != nil {
+				fmt.Printf("======== processRecoverShare,process outch err = %v ==========\n", err)
+				return nil, err
+			}
+		case msg := <-endCh:
+			if !recovering {
+				common.Info("===================== recover share finished,the share of current node does not change ====================", "pubkey", pubkey, "key", msgprex)
+				return msg.SkU1, nil
+			}
+
+			smpcpks, err := hex.DecodeString(pubkey)
+			if err != nil {
+				return nil, err
+			}
+
+			ys := secp256k1.S256().Marshal(msg.Pkx, msg.Pky)
+			if !strings.EqualFold(pubkey, hex.EncodeToString(ys)) || msg.SkU1 == nil {
+				common.Info("===================== recover share fail,pubkey != old pubkey ====================", "pubkey", pubkey, "key", msgprex)
+				return nil, errors.New("recover share fail,pubkey != old pubkey")
+			}
+
+			err = putSkU1ToLocalDb(smpcpks[:], msg.SkU1.Bytes())
+			if err != nil {
+				return nil, err
+			}
+
+			for _, ct := range getCoinTypes(keytype) {
+				if strings.EqualFold(ct, "ALL") {
+					continue
+				}
+
+				h := coins.NewCryptocoinHandler(ct)
+				if h == nil {
+					continue
+				}
+				ctaddr, err := h.PublicKeyToAddress(pubkey)
+				if err != nil {
+					continue
+				}
+
+				key := Keccak256Hash([]byte(strings.ToLower(ctaddr))).Hex()
+				err = putSkU1ToLocalDb([]byte(key), msg.SkU1.Bytes())
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			common.Info("===================== recover share finished successfully ====================", "pubkey", pubkey, "key", msgprex)
+			return msg.SkU1, nil
+		}
+	}
+}
+
+//-------------------------------------------------------ECDSA end-----------------------------------------------------------
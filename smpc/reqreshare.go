@@ -17,6 +17,7 @@
 package smpc
 
 import (
+	"crypto/elliptic"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -25,7 +26,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
@@ -117,6 +117,8 @@ type TxDataReShare struct {
 	AcceptTimeOut      string
 	Sigs      string
 	TimeStamp string
+	Keytype   string // EC256K1 or ED25519,default EC256K1
+	SignProtocol      string // presign protocol of the pubkey,GG20 or CGGMP21 for EC256K1 and EC256R1,"" is GG20; FROST for ED25519
 }
 
 // ReShare execute the reshare command
@@ -191,23 +193,33 @@ type ReShareStatus struct {
 
 // GetReShareStatus get the result of the reshare request by key
 func GetReShareStatus(key string) (string, string, error) {
+	los, tip, err := GetReShareStatusData(key)
+	if err != nil {
+		return "", tip, err
+	}
+
+	ret, _ := json.Marshal(los)
+	return string(ret), "", nil
+}
+
+// GetReShareStatusData get the result of the reshare request by key
+func GetReShareStatusData(key string) (*ReShareStatus, string, error) {
     	if key == "" {
-	    return "","",errors.New("param error")
+	    return nil,"",errors.New("param error")
 	}
 
 	exsit, da := GetPubKeyData([]byte(key))
 	if !exsit || da == nil {
-		return "", "smpc back-end internal error:get reshare accept data fail from db when GetReShareStatus", fmt.Errorf("get reshare accept data fail from db")
+		return nil, "smpc back-end internal error:get reshare accept data fail from db when GetReShareStatus", fmt.Errorf("get reshare accept data fail from db")
 	}
 
 	ac, ok := da.(*AcceptReShareData)
 	if !ok {
-		return "", "smpc back-end internal error:get reshare accept data error from db when GetReShareStatus", fmt.Errorf("get reshare accept data error from db")
+		return nil, "smpc back-end internal error:get reshare accept data error from db when GetReShareStatus", fmt.Errorf("get reshare accept data error from db")
 	}
 
 	los := &ReShareStatus{Status: ac.Status, Pubkey: ac.PubKey, Tip: ac.Tip, Error: ac.Error, AllReply: ac.AllReply, TimeStamp: ac.TimeStamp}
-	ret, _ := json.Marshal(los)
-	return string(ret), "", nil
+	return los, "", nil
 }
 
 //-------------------------------------------------------------------------------------
@@ -303,10 +315,10 @@ func GetCurNodeReShareInfo() ([]*ReShareCurNodeInfo, string, error) {
 // _reshare execute reshare
 // param groupid is not subgroupid
 // w.groupid is subgroupid
-func _reshare(wsid string, initator string, groupid string, pubkey string, account string, mode string, sigs string, ch chan interface{}) {
+func _reshare(wsid string, initator string, groupid string, pubkey string, account string, mode string, sigs string, keytype string, ch chan interface{}) {
 
 	rch := make(chan interface{}, 1)
-	smpcReshare(wsid, initator, groupid, pubkey, account, mode, sigs, rch)
+	smpcReshare(wsid, initator, groupid, pubkey, account, mode, sigs, keytype, rch)
 	ret, _, cherr := GetChannelValue(cht, rch)
 	if ret != "" {
 		w, err := FindWorker(wsid)
@@ -349,10 +361,10 @@ func _reshare(wsid string, initator string, groupid string, pubkey string, accou
 //---------------------------------------------------------------------------------------
 
 // smpcReshare execute reshare
-// ec2
+// ec2 or ed
 // msgprex = hash
 // return value is the backup for smpc sig.
-func smpcReshare(msgprex string, initator string, groupid string, pubkey string, account string, mode string, sigs string, ch chan interface{}) {
+func smpcReshare(msgprex string, initator string, groupid string, pubkey string, account string, mode string, sigs string, keytype string, ch chan interface{}) {
 
 	w, err := FindWorker(msgprex)
 	if w == nil || err != nil {
@@ -368,7 +380,11 @@ func smpcReshare(msgprex string, initator string, groupid string, pubkey string,
 			<-ch1
 		}
 
-		ReShareEC2(msgprex, initator, groupid, pubkey, account, mode, sigs, ch1, id)
+		if keytype == "ED25519" {
+			ReShareED(msgprex, initator, groupid, pubkey, account, mode, sigs, ch1, id)
+		} else {
+			ReShareEC2(msgprex, initator, groupid, pubkey, account, mode, sigs, keytype, ch1, id)
+		}
 		ret, _, cherr := GetChannelValue(cht, ch1)
 		if ret != "" && cherr == nil {
 			res := RPCSmpcRes{Ret: ret, Tip: "", Err: cherr}
@@ -386,7 +402,7 @@ func smpcReshare(msgprex string, initator string, groupid string, pubkey string,
 // ReShareEC2 execute reshare
 // msgprex = hash
 // return value is the backup for the smpc sig
-func ReShareEC2(msgprex string, initator string, groupid string, pubkey string, account string, mode string, sigs string, ch chan interface{}, id int) {
+func ReShareEC2(msgprex string, initator string, groupid string, pubkey string, account string, mode string, sigs string, keytype string, ch chan interface{}, id int) {
 	if id < 0 || id >= len(workers) {
 		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("no find worker")}
 		ch <- res
@@ -452,11 +468,11 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 		}
 		//
 		sd.SkU1 = sku1
-		pkx, pky := secp256k1.S256().Unmarshal(smpcpks[:])
+		pkx, pky := elliptic.Unmarshal(ec2.GetCurve(keytype), smpcpks[:])
 		sd.Pkx = pkx
 		sd.Pky = pky
 
-		sd.U1PaillierSk = GetCurNodePaillierSkFromSaveData(save, (da.(*PubKeyData)).GroupID, "EC256K1")
+		sd.U1PaillierSk = GetCurNodePaillierSkFromSaveData(save, (da.(*PubKeyData)).GroupID, keytype)
 
 		U1PaillierPk := make([]*ec2.PublicKey, w.NodeCnt)
 		U1NtildeH1H2 := make([]*ec2.NtildeH1H2, w.NodeCnt)
@@ -467,15 +483,15 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 		sd.U1PaillierPk = U1PaillierPk
 		sd.U1NtildeH1H2 = U1NtildeH1H2
 
-		sd.IDs = GetGroupNodeUIDs("EC256K1",groupid,groupid) // 1,2,3,4,6
-		_,sd.CurDNodeID = GetNodeUID(curEnode,"EC256K1",groupid) 
+		sd.IDs = GetGroupNodeUIDs(keytype,groupid,groupid) // 1,2,3,4,6
+		_,sd.CurDNodeID = GetNodeUID(curEnode,keytype,groupid) 
 
 		//msgtoenode := GetMsgToEnode("EC256K1",(da.(*PubKeyData)).GroupID,(da.(*PubKeyData)).GroupID)
 		//kgsave := &KGLocalDBSaveData{Save: sd, MsgToEnode: msgtoenode}
 
 		found := false
-		ids := GetGroupNodeUIDs("EC256K1",groupid,w.groupid)
-		_,uid := GetNodeUID(curEnode,"EC256K1",groupid)
+		ids := GetGroupNodeUIDs(keytype,groupid,w.groupid)
+		_,uid := GetNodeUID(curEnode,keytype,groupid)
 		for _,v := range ids {
 		    if v.Cmp(uid) == 0 {
 			found = true
@@ -490,7 +506,7 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 
 		if oldnode {
 		
-			oldindex,_ := GetNodeUID(curEnode,"EC256K1",(da.(*PubKeyData)).GroupID)
+			oldindex,_ := GetNodeUID(curEnode,keytype,(da.(*PubKeyData)).GroupID)
 			sd.U1NtildePrivData = GetNtildePrivDataByIndexFromSaveData(save,w.NodeCnt)
 			if sd.U1NtildePrivData == nil {
 				res := RPCSmpcRes{Ret: "", Tip: "get ntilde priv data fail", Err: fmt.Errorf("get ntilde priv data fail")}
@@ -503,10 +519,11 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 			outCh := make(chan smpclib.Message, ns)
 			endCh := make(chan keygen.LocalDNodeSaveData, ns)
 			errChan := make(chan struct{})
-			reshareDNode := reshare.NewLocalDNode(outCh, endCh, ns, w.ThresHold, 2048, sd, true,oldindex)
+			reshareDNode := reshare.NewLocalDNode(outCh, endCh, ns, w.ThresHold, w.paillierkeylength, sd, true,oldindex, keytype)
 			w.DNode = reshareDNode
-			_,UID := GetNodeUID(curEnode,"EC256K1",groupid)
+			_,UID := GetNodeUID(curEnode,keytype,groupid)
 			reshareDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+			reshareDNode.SetSessionKey(msgprex)
 
 			uid, _ := new(big.Int).SetString(w.DNode.DNodeID(), 10)
 			w.MsgToEnode[fmt.Sprintf("%v", uid)] = curEnode
@@ -523,7 +540,7 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 				HandleC1Data(nil, w.sid)
 			}()
 			go ReshareProcessInboundMessages(msgprex, commStopChan, &reshareWg, ch)
-			newsku1, err := processReshare(msgprex, groupid, pubkey, account, mode, sigs, errChan, outCh, endCh)
+			newsku1, err := processReshare(msgprex, groupid, pubkey, account, mode, sigs, keytype, errChan, outCh, endCh)
 			if err != nil {
 				fmt.Printf("==========process reshare err = %v ==========\n", err)
 				close(commStopChan)
@@ -545,10 +562,11 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 	outCh := make(chan smpclib.Message, ns)
 	endCh := make(chan keygen.LocalDNodeSaveData, ns)
 	errChan := make(chan struct{})
-	reshareDNode := reshare.NewLocalDNode(outCh, endCh, ns, w.ThresHold, 2048, nil, false,-1)
+	reshareDNode := reshare.NewLocalDNode(outCh, endCh, ns, w.ThresHold, w.paillierkeylength, nil, false,-1, keytype)
 	w.DNode = reshareDNode
-	_,UID := GetNodeUID(curEnode,"EC256K1",groupid)
+	_,UID := GetNodeUID(curEnode,keytype,groupid)
 	reshareDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	reshareDNode.SetSessionKey(msgprex)
 
 	uid, _ := new(big.Int).SetString(w.DNode.DNodeID(), 10)
 	w.MsgToEnode[fmt.Sprintf("%v", uid)] = curEnode
@@ -565,7 +583,7 @@ func ReShareEC2(msgprex string, initator string, groupid string, pubkey string,
 		HandleC1Data(nil, w.sid)
 	}()
 	go ReshareProcessInboundMessages(msgprex, commStopChan, &reshareWg, ch)
-	newsku1, err := processReshare(msgprex, groupid, pubkey, account, mode, sigs, errChan, outCh, endCh)
+	newsku1, err := processReshare(msgprex, groupid, pubkey, account, mode, sigs, keytype, errChan, outCh, endCh)
 	if err != nil {
 		fmt.Printf("==========process reshare err = %v ==========\n", err)
 		close(commStopChan)
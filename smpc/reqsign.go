@@ -17,6 +17,8 @@
 package smpc
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -33,7 +35,9 @@ import (
 	"github.com/anyswap/FastMulThreshold-DSA/p2p/discover"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/presign"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
 	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
 	edsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
@@ -186,7 +190,7 @@ func DoSign(sbd *SignPickData, workid int, sender string, ch chan interface{}) e
 
 	w.SmpcFrom = sig.PubKey // pubkey replace smpcfrom in sign
 
-	if sig.Mode == "0" { // self-group
+	if isGroupApprovalMode(sig.Mode) { // self-group
 		var reply bool
 		var tip string
 		timeout := make(chan bool, 1)
@@ -303,7 +307,7 @@ func DoSign(sbd *SignPickData, workid int, sender string, ch chan interface{}) e
 	}
 
 	rch := make(chan interface{}, 1)
-	sign(w.sid, from, sig.PubKey, sig.InputCode, sig.MsgHash, sig.Keytype, nonce, sig.Mode, sbd.PickData, rch)
+	sign(w.sid, from, sig.PubKey, sig.InputCode, sig.MsgHash, sig.Keytype, nonce, sig.Mode, sig.TapTweak, sbd.PickData, rch)
 	chret, tip, cherr := GetChannelValue(waitallgg20+20, rch)
 	if chret != "" {
 		res := RPCSmpcRes{Ret: chret, Tip: "", Err: nil}
@@ -431,6 +435,7 @@ func ExecApproveSigning(raw string,from string,sig *TxDataAcceptSign,ac *AcceptS
 	AcceptSign(ac.Initiator, ac.Account, ac.PubKey, ac.MsgHash, ac.Keytype, ac.GroupID, ac.Nonce, ac.LimitNum, ac.Mode, "false", accept, status, "", "", "", nil, ac.WorkID)
 	
 	w.msgacceptsignres.PushBack(raw)
+	notifyApproval(sig.Key, from, sig.Accept)
 	/////fix bug: miss accept msg for 7-11 test
 	SendMsgToSmpcGroup(raw, ac.GroupID)
 	/////
@@ -496,6 +501,7 @@ type TxDataSign struct {
 	Mode       string
 	AcceptTimeOut      string
 	TimeStamp  string
+	TapTweak   string // SCHNORR256K1 only,"" no tweak,"TAPROOT" BIP-341 tweak without script tree,or hex of the merkle root
 }
 
 // Sign execute the sign command
@@ -518,7 +524,8 @@ func Sign(raw string) (string, string, error) {
 
 	common.Debug("=====================Sign================", "key", key, "from", from, "raw", raw)
 
-	if sig.Keytype == "ED25519" {
+	// the ED25519 pubkey signed by FROST picks the pre-sign data the same as EC pubkey
+	if (sig.Keytype == "ED25519" && !isFROSTPubKey(sig.PubKey)) || sig.Keytype == "SR25519" || sig.Keytype == "SCHNORR256K1" {
 		pickdata := make([]*PickHashData, 0)
 		pickhash := make([]*PickHashKey, 0)
 		m := make(map[string]string)
@@ -573,9 +580,10 @@ func HandleRPCSign() {
 			_, ok := da.(*PubKeyData)
 			common.Debug("=========================HandleRpcSign======================", "rsd.Pubkey", rsd.PubKey, "key", rsd.Key, "exsit", exsit, "ok", ok)
 			if ok {
+				inputcode := getPreSignInputCode(rsd.PubKey, rsd.InputCode)
 				var pub string
-				if rsd.InputCode != "" {
-					pub = Keccak256Hash([]byte(strings.ToLower(rsd.PubKey + ":" + rsd.InputCode + ":" + rsd.GroupID))).Hex()
+				if inputcode != "" {
+					pub = Keccak256Hash([]byte(strings.ToLower(rsd.PubKey + ":" + inputcode + ":" + rsd.GroupID))).Hex()
 				} else {
 					pub = Keccak256Hash([]byte(strings.ToLower(rsd.PubKey + ":" + rsd.GroupID))).Hex()
 				}
@@ -584,8 +592,9 @@ func HandleRPCSign() {
 				pickdata := make([]*PickHashData, 0)
 				pickhash := make([]*PickHashKey, 0)
 				for _, vv := range rsd.MsgHash {
-					pick := PickPreSignData(rsd.PubKey, rsd.InputCode, rsd.GroupID)
+					pick := PickPreSignData(rsd.PubKey, inputcode, rsd.GroupID)
 					if pick == nil {
+						attachPreSignBlames(rsd.Key, rsd.PubKey, inputcode, rsd.GroupID)
 						bret = true
 						break
 					}
@@ -597,8 +606,8 @@ func HandleRPCSign() {
 					pickdata = append(pickdata, phd)
 
 					//check pre sigal
-					if rsd.InputCode != "" {
-						if GetTotalCount(rsd.PubKey, rsd.InputCode, rsd.GroupID) >= (PreBip32DataCount/2) && GetTotalCount(rsd.PubKey, rsd.InputCode, rsd.GroupID) <= PreBip32DataCount {
+					if inputcode != "" {
+						if GetTotalCount(rsd.PubKey, inputcode, rsd.GroupID) >= (PreBip32DataCount/2) && GetTotalCount(rsd.PubKey, inputcode, rsd.GroupID) <= PreBip32DataCount {
 							PutPreSigal(pub, false)
 						} else {
 							PutPreSigal(pub, true)
@@ -683,30 +692,41 @@ type SignStatus struct {
 	Error     string
 	AllReply  []NodeReply
 	TimeStamp string
+	Blame     []*BlameRecord
 }
 
 // GetSignStatus get the result of the sign request by key
 func GetSignStatus(key string) (string, string, error) {
+	los, tip, err := GetSignStatusData(key)
+	if err != nil {
+		return "", tip, err
+	}
+
+	ret, _ := json.Marshal(los)
+	return string(ret), "", nil
+}
+
+// GetSignStatusData get the result of the sign request by key
+func GetSignStatusData(key string) (*SignStatus, string, error) {
     	if key  == "" {
-	    return "","",errors.New("param error")
+	    return nil,"",errors.New("param error")
 	}
 
 	exsit, da := GetPubKeyData([]byte(key))
 	if !exsit || da == nil {
 		common.Debug("=================GetSignStatus,get sign accept data fail from db================", "key", key)
-		return "", "smpc back-end internal error:get sign accept data fail from db when GetSignStatus", fmt.Errorf("get sign accept data fail from db")
+		return nil, "smpc back-end internal error:get sign accept data fail from db when GetSignStatus", fmt.Errorf("get sign accept data fail from db")
 	}
 
 	ac, ok := da.(*AcceptSignData)
 	if !ok {
 		common.Error("=================GetSignStatus,get sign accept data error from db================", "key", key)
-		return "", "smpc back-end internal error:get sign accept data error from db when GetSignStatus", fmt.Errorf("get sign accept data error from db")
+		return nil, "smpc back-end internal error:get sign accept data error from db when GetSignStatus", fmt.Errorf("get sign accept data error from db")
 	}
 
 	rsvs := strings.Split(ac.Rsv, ":")
-	los := &SignStatus{Status: ac.Status, Rsv: rsvs[:len(rsvs)-1], Tip: ac.Tip, Error: ac.Error, AllReply: ac.AllReply, TimeStamp: ac.TimeStamp}
-	ret, _ := json.Marshal(los)
-	return string(ret), "", nil
+	los := &SignStatus{Status: ac.Status, Rsv: rsvs[:len(rsvs)-1], Tip: ac.Tip, Error: ac.Error, AllReply: ac.AllReply, TimeStamp: ac.TimeStamp, Blame: GetBlameRecords(key)}
+	return los, "", nil
 }
 
 //--------------------------------------------------------------------------------------------------
@@ -841,8 +861,8 @@ func GetCurNodeSignInfo(geteracc string) ([]*SignCurNodeInfo, string, error) {
 //----------------------------------------------------------------------------------------------------------
 
 // sign execut the sign command,including ec and ed.
-// keytype : EC256K1 || ED25519
-func sign(wsid string, account string, pubkey string, inputcode string, unsignhash []string, keytype string, nonce string, mode string, pickdata []*PickHashData, ch chan interface{}) {
+// keytype : EC256K1 || EC256R1 || ED25519 || SR25519 || SCHNORR256K1
+func sign(wsid string, account string, pubkey string, inputcode string, unsignhash []string, keytype string, nonce string, mode string, taptweak string, pickdata []*PickHashData, ch chan interface{}) {
 	smpcpks, err := hex.DecodeString(pubkey)
 	if err != nil {
 	    res := RPCSmpcRes{Ret: "", Tip: "", Err: err}
@@ -871,7 +891,7 @@ func sign(wsid string, account string, pubkey string, inputcode string, unsignha
 
 	var smpcpkx *big.Int
 	var smpcpky *big.Int
-	if keytype == "EC256K1" {
+	if keytype == "EC256K1" || keytype == "EC256R1" || keytype == "SCHNORR256K1" {
 		smpcpks := []byte(smpcpub)
 		smpcpkx, smpcpky = secp256k1.S256().Unmarshal(smpcpks[:])
 	}
@@ -894,8 +914,41 @@ func sign(wsid string, account string, pubkey string, inputcode string, unsignha
 	var result string
 	var cherrtmp error
 	rch := make(chan interface{}, 1)
-	if keytype == "ED25519" {
-		signED(wsid, unsignhash, save, sku1, smpcpub, keytype, rch)
+	if keytype == "ED25519" && len(pickdata) != 0 {
+		signFROST(wsid, unsignhash, save, pubkey, inputcode, pickdata, rch)
+		ret, tip, cherr := GetChannelValue(waitall, rch)
+		if cherr != nil {
+			res := RPCSmpcRes{Ret: "", Tip: tip, Err: cherr}
+			ch <- res
+			return
+		}
+
+		result = ret
+		cherrtmp = cherr
+	} else if keytype == "ED25519" {
+		signED(wsid, unsignhash, save, sku1, smpcpub, inputcode, keytype, rch)
+		ret, tip, cherr := GetChannelValue(waitall, rch)
+		if cherr != nil {
+			res := RPCSmpcRes{Ret: "", Tip: tip, Err: cherr}
+			ch <- res
+			return
+		}
+
+		result = ret
+		cherrtmp = cherr
+	} else if keytype == "SR25519" {
+		signSr25519(wsid, unsignhash, save, pubkey, rch)
+		ret, tip, cherr := GetChannelValue(waitall, rch)
+		if cherr != nil {
+			res := RPCSmpcRes{Ret: "", Tip: tip, Err: cherr}
+			ch <- res
+			return
+		}
+
+		result = ret
+		cherrtmp = cherr
+	} else if keytype == "SCHNORR256K1" {
+		signSchnorr(wsid, unsignhash, sku1, smpcpkx, smpcpky, taptweak, rch)
 		ret, tip, cherr := GetChannelValue(waitall, rch)
 		if cherr != nil {
 			res := RPCSmpcRes{Ret: "", Tip: tip, Err: cherr}
@@ -928,7 +981,13 @@ func sign(wsid string, account string, pubkey string, inputcode string, unsignha
 
 		//bug
 		rets := []rune(rsv)
-		if keytype != "ED25519" && len(rets) != 130 {
+		if (keytype == "SCHNORR256K1" || keytype == "SR25519") && len(rets) != 128 {
+			res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:wrong signature size", Err: GetRetErr(ErrSmpcSigWrongSize)}
+			ch <- res
+			return
+		}
+
+		if (keytype == "EC256K1" || keytype == "EC256R1") && len(rets) != 130 {
 			res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:wrong rsv size", Err: GetRetErr(ErrSmpcSigWrongSize)}
 			ch <- res
 			return
@@ -1395,9 +1454,15 @@ func PreSignEC3(msgprex string, save string, sku1 *big.Int, pkx *big.Int,pky *bi
 	endCh := make(chan signing.PrePubData, w.ThresHold)
 	finalizeendCh := make(chan *big.Int, w.ThresHold)
 	errChan := make(chan struct{})
-	signDNode := signing.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, PaillierKeyLength, false, nil, nil, finalizeendCh)
+	var signDNode smpclib.DNode
+	if getSignProtocol(pubs) == SignProtocolCGGMP21 {
+		signDNode = presign.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, getPaillierKeyLength(pubs), cointype)
+	} else {
+		signDNode = signing.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, getPaillierKeyLength(pubs), false, nil, nil, finalizeendCh, cointype)
+	}
 	w.DNode = signDNode
 	signDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	signDNode.SetSessionKey(msgprex)
 
 	var signWg sync.WaitGroup
 	signWg.Add(2)
@@ -1530,10 +1595,11 @@ func SignEC3(msgprex string, message string, cointype string, save string, pkx *
 	finalizeendCh := make(chan *big.Int, w.ThresHold)
 	errChan := make(chan struct{})
 	predata := &signing.PrePubData{K1: pre.K1, R: pre.R, Ry: pre.Ry, Sigma1: pre.Sigma1}
-	signDNode := signing.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, PaillierKeyLength, true, predata, mMtA, finalizeendCh)
+	signDNode := signing.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, getPaillierKeyLength(pubs), true, predata, mMtA, finalizeendCh, cointype)
 	w.DNode = signDNode
 	_,UID := GetNodeUID(curEnode, "EC256K1",pubs.GroupID)
 	signDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	signDNode.SetSessionKey(msgprex)
 
 	var signWg sync.WaitGroup
 	signWg.Add(2)
@@ -1620,10 +1686,11 @@ func SignEC3(msgprex string, message string, cointype string, save string, pkx *
 
 	// 3. justify the s
 	bb := false
-	halfN := new(big.Int).Div(secp256k1.S256().N, big.NewInt(2))
+	curveN := ec2.GetCurve(cointype).Params().N
+	halfN := new(big.Int).Div(curveN, big.NewInt(2))
 	if s.Cmp(halfN) > 0 {
 		bb = true
-		s = new(big.Int).Sub(secp256k1.S256().N, s)
+		s = new(big.Int).Sub(curveN, s)
 	}
 
 	zero, _ := new(big.Int).SetString("0", 10)
@@ -1648,6 +1715,16 @@ func SignEC3(msgprex string, message string, cointype string, save string, pkx *
 		invert = true
 	}
 
+	if cointype == "EC256R1" {
+		// no pubkey recovery for secp256r1,v is only the parity of R.y (flipped if s was normalized)
+		recid := int32(pre.Ry.Bit(0))
+		if bb {
+			recid ^= 1
+		}
+		signature.SetRecoveryParam(recid)
+		return finishSignEC3(msgprex, message, cointype, signature, pkx, pky, ch)
+	}
+
 	recid := smpclib.DECDSASignCalcv(pre.R, pre.Ry, pkx, pky, signature.GetR(), signature.GetS(), hashBytes, invert)
 	common.Debug("=====================SignEC3,first get recid =================", "recid", recid, "key", msgprex)
 
@@ -1678,7 +1755,12 @@ func SignEC3(msgprex string, message string, cointype string, save string, pkx *
 	signature.SetRecoveryParam(int32(recid))
 	common.Debug("=====================SignEC3,terminal get recid =================", "recid", signature.GetRecoveryParam(), "key", msgprex)
 
-	if !DECDSASignVerifyRSV(signature.GetR(), signature.GetS(), signature.GetRecoveryParam(), message, pkx, pky) {
+	return finishSignEC3(msgprex, message, cointype, signature, pkx, pky, ch)
+}
+
+// finishSignEC3 verify the signature and return the rsv string
+func finishSignEC3(msgprex string, message string, cointype string, signature *ECDSASignature, pkx *big.Int, pky *big.Int, ch chan interface{}) string {
+	if !DECDSASignVerifyRSV(signature.GetR(), signature.GetS(), signature.GetRecoveryParam(), message, pkx, pky, cointype) {
 		common.Error("=================SignEC3,verify fail==============", "key", msgprex)
 		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("sign verify fail")}
 		ch <- res
@@ -1808,14 +1890,25 @@ func GetSignString(r *big.Int, s *big.Int, v int) string {
 }
 
 // DECDSASignVerifyRSV verify RSV
-func DECDSASignVerifyRSV(r *big.Int, s *big.Int, v int32, message string, pkx *big.Int, pky *big.Int) bool {
+func DECDSASignVerifyRSV(r *big.Int, s *big.Int, v int32, message string, pkx *big.Int, pky *big.Int, keytype string) bool {
+	if keytype == "EC256R1" {
+		hashBytes, err := hex.DecodeString(message)
+		if err != nil {
+			return false
+		}
+
+		pk := &ecdsa.PublicKey{Curve: elliptic.P256(), X: pkx, Y: pky}
+		return ecdsa.Verify(pk, hashBytes, r, s)
+	}
+
 	return smpclib.Verify2(r, s, v, message, pkx, pky)
 }
 
 //--------------------------------------------------------------------------------------------------
 
 // signED execute the sign command with ed algorithm 
-func signED(msgprex string, txhash []string, save string, sku1 *big.Int, pk string, keytype string, ch chan interface{}) string {
+// inputcode is the BIP32-Ed25519 derivation path of the child key,"" means signing with the root key
+func signED(msgprex string, txhash []string, save string, sku1 *big.Int, pk string, inputcode string, keytype string, ch chan interface{}) string {
 
 	tmp := make([]string, 0)
 	for _, v := range txhash {
@@ -1849,7 +1942,7 @@ func signED(msgprex string, txhash []string, save string, sku1 *big.Int, pk stri
 				<-ch1
 			}
 
-			bakSig = SignED(msgprex, save, sku1, v, keytype, pk, ch1, id)
+			bakSig = SignED(msgprex, save, sku1, v, inputcode, keytype, pk, ch1, id)
 			ret, _, cherr := GetChannelValue(cht, ch1)
 			if ret != "" && cherr == nil {
 				result += ret
@@ -1876,7 +1969,7 @@ func signED(msgprex string, txhash []string, save string, sku1 *big.Int, pk stri
 // SignED execute the sign command with ed algorithm 
 // msgprex = hash
 // return value is the backup for the smpc sign
-func SignED(msgprex string, save string, sku1 *big.Int, message string, cointype string, pk string, ch chan interface{}, id int) string {
+func SignED(msgprex string, save string, sku1 *big.Int, message string, inputcode string, cointype string, pk string, ch chan interface{}, id int) string {
 	if id < 0 || id >= len(workers) || id >= RPCMaxWorker {
 		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get worker id fail", Err: GetRetErr(ErrGetWorkerIDError)}
 		ch <- res
@@ -1955,6 +2048,19 @@ func SignED(msgprex string, save string, sku1 *big.Int, message string, cointype
 	va = []byte(mm[3])
 	copy(pkfinal[:], va[:32])
 
+	// sign with the bip32 child key: child share = share + tweak
+	if inputcode != "" {
+		childpk, tweak, err := getEdBip32ChildKey(smpcpks[:], inputcode)
+		if err != nil {
+			res := RPCSmpcRes{Ret: "", Tip: "ed sign get bip32 child key fail", Err: err}
+			ch <- res
+			return ""
+		}
+
+		ed.ScAdd(&tsk, &tsk, &tweak)
+		pkfinal = childpk
+	}
+
 	sd.Sk = sk
 	sd.TSk = tsk
 	sd.FinalPkBytes = pkfinal
@@ -1984,6 +2090,7 @@ func SignED(msgprex string, save string, sku1 *big.Int, message string, cointype
 	w.DNode = signDNode
 	_,UID := GetNodeUID(curEnode, "ED25519",pubs.GroupID)
 	signDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	signDNode.SetSessionKey(msgprex)
 
 	var signWg sync.WaitGroup
 	signWg.Add(2)
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
-	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/reshare"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
@@ -60,8 +59,7 @@ func ReshareProcessInboundMessages(msgprex string, finishChan chan struct{}, wg
 			return
 		case m := <-w.SmpcMsg:
 
-			msgmap := make(map[string]string)
-			err := json.Unmarshal([]byte(m), &msgmap)
+			msgmap, err := decodeP2pMsg(m)
 			if err != nil {
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
@@ -133,7 +131,7 @@ func ReshareProcessInboundMessages(msgprex string, finishChan chan struct{}, wg
 				return
 			}
 
-			_,ID := GetNodeUID(msgmap["ENode"], "EC256K1",pubs.GroupID)
+			_,ID := GetNodeUID(msgmap["ENode"], getPubKeyType(pubs),pubs.GroupID)
 			id := fmt.Sprintf("%v", ID)
 			uid := hex.EncodeToString([]byte(id))
 			if !strings.EqualFold(uid,mm.GetFromID()) {
@@ -183,194 +181,11 @@ func ReshareProcessInboundMessages(msgprex string, finishChan chan struct{}, wg
 
 // ReshareGetRealMessage get the message data struct by map. (p2p msg ---> map)
 func ReshareGetRealMessage(msg map[string]string) smpclib.Message {
-	if msg == nil {
-	    return nil
-	}
-
-	from := msg["FromID"]
-	if from == "" {
-	    return nil
-	}
-
-	var to []string
-	v, ok := msg["ToID"]
-	if ok && v != "" {
-		to = strings.Split(v, ":")
-	}
-
-	index, indexerr := strconv.Atoi(msg["FromIndex"])
-	if indexerr != nil {
-		return nil
-	}
-
-	//1 message
-	if msg["Type"] == "ReRound1Message" {
-	    if msg["ComC"] == "" {
-		return nil
-	    }
-
-		comc, _ := new(big.Int).SetString(msg["ComC"], 10)
-		if comc == nil {
-		    return nil
-		}
-
-		re := &reshare.ReRound1Message{
-			ReRoundMessage: new(reshare.ReRoundMessage),
-			ComC:                comc,
-		}
-		re.SetFromID(from)
-		re.SetFromIndex(index)
-		re.ToID = to
-		return re
-	}
-
-	//2 message
-	if msg["Type"] == "ReRound2Message" {
-	    if msg["ID"] == "" || msg["Share"] == "" {
-		return nil
-	    }
-
-		id, _ := new(big.Int).SetString(msg["ID"], 10)
-		sh, _ := new(big.Int).SetString(msg["Share"], 10)
-		re := &reshare.ReRound2Message{
-			ReRoundMessage: new(reshare.ReRoundMessage),
-			ID:                  id,
-			Share:               sh,
-		}
-		re.SetFromID(from)
-		re.SetFromIndex(index)
-		re.ToID = to
-		fmt.Printf("============ GetRealMessage, get real message 2 success, share struct id = %v, share = %v, msg map = %v ===========\n", re.ID, re.Share, msg)
-		return re
-	}
-
-	//2-1 message
-	if msg["Type"] == "ReRound2Message1" {
-	    if msg["ComD"] == "" || msg["SkP1PolyG"] == "" {
-		return nil
-	    }
-
-		ugd := strings.Split(msg["ComD"], ":")
-		u1gd := make([]*big.Int, len(ugd))
-		for k, v := range ugd {
-			u1gd[k], _ = new(big.Int).SetString(v, 10)
-			if u1gd[k] == nil {
-			    return nil
-			}
-		}
-
-		uggtmp := strings.Split(msg["SkP1PolyG"], "|")
-		ugg := make([][]*big.Int, len(uggtmp))
-		for k, v := range uggtmp {
-		    if v == "" {
-			return nil
-		    }
-
-			uggtmp2 := strings.Split(v, ":")
-			tmp := make([]*big.Int, len(uggtmp2))
-			for kk, vv := range uggtmp2 {
-			    if vv == "" {
-				return nil
-			    }
-
-				tmp[kk], _ = new(big.Int).SetString(vv, 10)
-				if tmp[kk] == nil {
-				    return nil
-				}
-			}
-			ugg[k] = tmp
-		}
-
-		re := &reshare.ReRound2Message1{
-			ReRoundMessage: new(reshare.ReRoundMessage),
-			ComD:                u1gd,
-			SkP1PolyG:           ugg,
-		}
-		re.SetFromID(from)
-		re.SetFromIndex(index)
-		re.ToID = to
-		return re
-	}
-
-	//3 message
-	if msg["Type"] == "ReRound3Message" {
-	    if msg["U1PaillierPk"] == "" {
-		return nil
-	    }
-
-		pub := &ec2.PublicKey{}
-		err := pub.UnmarshalJSON([]byte(msg["U1PaillierPk"]))
-		if err == nil {
-			//fmt.Printf("============ ReshareGetRealMessage, get real message 3 success, msg map = %v ===========\n", msg)
-			re := &reshare.ReRound3Message{
-				ReRoundMessage: new(reshare.ReRoundMessage),
-				U1PaillierPk:        pub,
-			}
-			re.SetFromID(from)
-			re.SetFromIndex(index)
-			re.ToID = to
-			return re
-		}
-	}
-
-	//4 message
-	if msg["Type"] == "ReRound4Message" {
-	    if msg["U1NtildeH1H2"] == "" || msg["NtildeProof1"] == "" || msg["NtildeProof2"] == "" {
-		return nil
-	    }
-
-		nti := &ec2.NtildeH1H2{}
-		if err := nti.UnmarshalJSON([]byte(msg["U1NtildeH1H2"])); err == nil {
-			pf1 := &ec2.NtildeProof{}
-			if err := pf1.UnmarshalJSON([]byte(msg["NtildeProof1"])); err == nil {
-				pf2 := &ec2.NtildeProof{}
-				if err := pf2.UnmarshalJSON([]byte(msg["NtildeProof2"])); err == nil {
-					//fmt.Printf("============ ReshareGetRealMessage, get real message 4 success, msg map = %v ===========\n", msg)
-					re := &reshare.ReRound4Message{
-						ReRoundMessage: new(reshare.ReRoundMessage),
-						U1NtildeH1H2:        nti,
-						NtildeProof1:        pf1,
-						NtildeProof2:        pf2,
-					}
-					re.SetFromID(from)
-					re.SetFromIndex(index)
-					re.ToID = to
-					return re
-				}
-			}
-		}
-	}
-
-	//5 message
-	if msg["Type"] == "ReRound5Message" {
-		//fmt.Printf("============ ReshareGetRealMessage, get real message 5 success, msg map = %v ===========\n", msg)
-		if msg["NewSkOk"] == "" {
-		    return nil
-		}
-
-		re := &reshare.ReRound5Message{
-			ReRoundMessage: new(reshare.ReRoundMessage),
-			NewSkOk:             msg["NewSkOk"],
-		}
-		re.SetFromID(from)
-		re.SetFromIndex(index)
-		re.ToID = to
-		return re
-	}
-
-	//fmt.Printf("============ ReshareGetRealMessage, get real message 0 success, msg map = %v ===========\n", msg)
-	re := &reshare.ReRound0Message{
-		ReRoundMessage: new(reshare.ReRoundMessage),
-	}
-	re.SetFromID(from)
-	re.SetFromIndex(-1)
-	re.ToID = to
-
-	return re
+	return getRealMessage(msg, "ecdsa/reshare")
 }
 
 // processReshare  Obtain the data to be sent in each round and send it to other nodes until the end of the reshare command 
-func processReshare(msgprex string, groupid string, pubkey string, account string, mode string, sigs string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) (*big.Int, error) {
+func processReshare(msgprex string, groupid string, pubkey string, account string, mode string, sigs string, keytype string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan keygen.LocalDNodeSaveData) (*big.Int, error) {
 	for {
 		select {
 		case <-errChan:
@@ -425,7 +240,7 @@ func processReshare(msgprex string, groupid string, pubkey string, account strin
 				return nil, err
 			}
 
-			for _, ct := range coins.Cointypes {
+			for _, ct := range getCoinTypes(keytype) {
 				if strings.EqualFold(ct, "ALL") {
 					continue
 				}
@@ -454,14 +269,18 @@ func processReshare(msgprex string, groupid string, pubkey string, account strin
 			}
 
 			//**************TODO***************
-			//default EC256K1 for reshare
-			//ED25519 is not ready!!!
+			//EC256K1 or EC256R1,ED25519 see processReshareED
 
-			rk := Keccak256Hash([]byte(strings.ToLower(account + ":" + "EC256K1" + ":" + groupid + ":" + nonce + ":" + w.limitnum + ":" + mode))).Hex() //reqaddr key
+			rk := Keccak256Hash([]byte(strings.ToLower(account + ":" + keytype + ":" + groupid + ":" + nonce + ":" + w.limitnum + ":" + mode))).Hex() //reqaddr key
 			//**********************************
 
+			// the new nodes got the paillier key length from the old nodes during reshare,all paillier N have the length
+			if w.paillierkeylength == 0 && len(msg.U1PaillierPk) != 0 && msg.U1PaillierPk[0] != nil && msg.U1PaillierPk[0].N != nil {
+				w.paillierkeylength = msg.U1PaillierPk[0].N.BitLen()
+			}
+
 			tt := fmt.Sprintf("%v", time.Now().UnixNano()/1e6)
-			pubs := &PubKeyData{Key: rk, Account: account, Pub: string(smpcpks[:]), Save: string(s), Nonce: nonce, GroupID: groupid, LimitNum: w.limitnum, Mode: mode, KeyGenTime: tt, RefReShareKeys: msgprex}
+			pubs := &PubKeyData{Key: rk, Account: account, Pub: string(smpcpks[:]), Save: string(s), Nonce: nonce, GroupID: groupid, LimitNum: w.limitnum, Mode: mode, KeyGenTime: tt, RefReShareKeys: msgprex, KeyType: keytype, PaillierKeyLength: fmt.Sprintf("%v", w.paillierkeylength), SignProtocol: w.signprotocol, PubShares: encodePubShares(msg.IDs, msg.PubShares)}
 			epubs, err := Encode2(pubs)
 			if err != nil {
 				return nil, errors.New("encode PubKeyData fail in req ec2 pubkey")
@@ -500,7 +319,7 @@ func processReshare(msgprex string, groupid string, pubkey string, account strin
 				return nil, err
 			}
 
-			for _, ct := range coins.Cointypes {
+			for _, ct := range getCoinTypes(keytype) {
 				if strings.EqualFold(ct, "ALL") {
 					continue
 				}
@@ -537,13 +356,13 @@ func processReshare(msgprex string, groupid string, pubkey string, account strin
 				}
 			}
 
-			ac := &AcceptReqAddrData{Initiator: curEnode, Account: account, Cointype: "EC256K1", GroupID: groupid, Nonce: nonce, LimitNum: w.limitnum, Mode: mode, TimeStamp: tt, Deal: "true", Accept: "true", Status: "Success", PubKey: pubkey, Tip: "", Error: "", AllReply: allreply, WorkID: wid, Sigs: sigs}
+			ac := &AcceptReqAddrData{Initiator: curEnode, Account: account, Cointype: keytype, GroupID: groupid, Nonce: nonce, LimitNum: w.limitnum, Mode: mode, TimeStamp: tt, Deal: "true", Accept: "true", Status: "Success", PubKey: pubkey, Tip: "", Error: "", AllReply: allreply, WorkID: wid, Sigs: sigs}
 			err = SaveAcceptReqAddrData(ac)
 			if err != nil {
 				return nil, errors.New("save reqaddr accept data fail")
 			}
 
-			if mode == "0" {
+			if isGroupApprovalMode(mode) {
 				sigs2 := strings.Split(ac.Sigs, common.Sep)
 				cnt, _ := strconv.Atoi(sigs2[0])
 				for j := 0; j < cnt; j++ {
@@ -625,25 +444,31 @@ func ReshareProcessOutCh(msgprex string, groupid string, msg smpclib.Message) er
 		return fmt.Errorf("get worker fail")
 	}
 
+	notifyRound(msgprex, msg.GetMsgType())
+
 	sig,err := sigP2pMsg(msg,curEnode)
 	if err != nil {
 	    return err
 	}
 
-	msgmap := msg.OutMap()
+	msgmap, err := getWireMsgMap(msg, groupid)
+	if err != nil {
+		return err
+	}
+
 	msgmap["Key"] = msgprex
 	msgmap["ENode"] = curEnode
 	msgmap["Sig"] = hex.EncodeToString(sig)
-	s, err := json.Marshal(msgmap)
+	s, err := encodeP2pMsg(msgmap)
 	if err != nil {
 		fmt.Printf("====================ReshareProcessOutCh, marshal err = %v ========================\n", err)
 		return err
 	}
 
 	if msg.IsBroadcast() {
-		fmt.Printf("=========== ReshareProcessOutCh,broacast msg = %v, group id = %v ===========\n", string(s), groupid)
+		fmt.Printf("=========== ReshareProcessOutCh,broacast msg = %v, group id = %v ===========\n", msg.GetMsgType(), groupid)
 
-		SendMsgToSmpcGroup(string(s), groupid)
+		SendMsgToSmpcGroup(s, groupid)
 	} else {
 		for _, v := range msg.GetToID() {
 			fmt.Printf("===============ReshareProcessOutCh, to id = %v,groupid = %v ==============\n", v, groupid)
@@ -655,8 +480,8 @@ func ReshareProcessOutCh(msgprex string, groupid string, msg smpclib.Message) er
 				//fmt.Printf("===============ReshareProcessOutCh, enode = %v,node2 = %v ==============\n",enode,node2)
 
 				if strings.EqualFold(enode, node2) {
-					fmt.Printf("=========== ReshareProcessOutCh,send msg = %v, group id = %v,send to peer = %v ===========\n", string(s), groupid, node)
-					SendMsgToPeer(node, string(s))
+					fmt.Printf("=========== ReshareProcessOutCh,send msg = %v, group id = %v,send to peer = %v ===========\n", msg.GetMsgType(), groupid, node)
+					SendMsgToPeer(node, s)
 					break
 				}
 			}
@@ -0,0 +1,492 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	edreshare "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/reshare"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	"github.com/fsn-dev/cryptoCoins/coins"
+)
+
+//----------------------------------------------------EDDSA start----------------------------------------------------------
+
+// EdReshareProcessInboundMessages Analyze the obtained P2P messages and enter next round
+// groupid is the new group that the pubkey is reshared to
+func EdReshareProcessInboundMessages(msgprex string, groupid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+
+	if msgprex == "" || groupid == "" {
+		return
+	}
+
+	fmt.Printf("start processing ed reshare inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop processing ed reshare inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := EdReshareGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[ED RESHARE] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			common.Debug("===============ed reshare,check p2p msg===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============ed reshare,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			// the dnode id of ed reshare is the uid in the new group
+			_, ID := GetNodeUID(msgmap["ENode"], "ED25519", groupid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============ed reshare,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			// check whether 'from' is in the group
+			succ := false
+			_, nodes := GetGroup(groupid)
+			others := strings.Split(nodes, common.Sep2)
+			for _, v := range others {
+				node2 := ParseNode(v)
+				if strings.EqualFold(node2, msgmap["ENode"]) {
+					succ = true
+					break
+				}
+			}
+
+			if !succ {
+				common.Error("===============ed reshare,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+			////
+
+			if msgmap["Type"] == "ReRound0Message" { //0 message
+				w.MsgToEnode[mm.GetFromID()] = msgmap["ENode"]
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== EdReshareProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v ============\n", m, err)
+				saveBlame(msgprex, "ED25519", groupid, groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// edReshareDecode32 hex string --> [32]byte
+func edReshareDecode32(s string) ([32]byte, bool) {
+	var ret [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return ret, false
+	}
+
+	copy(ret[:], b)
+	return ret, true
+}
+
+// edReshareDecode64 hex string --> [64]byte
+func edReshareDecode64(s string) ([64]byte, bool) {
+	var ret [64]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 64 {
+		return ret, false
+	}
+
+	copy(ret[:], b)
+	return ret, true
+}
+
+// EdReshareGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func EdReshareGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "eddsa/reshare")
+}
+
+// putEdReshareAccountKey add the reqaddr key rk to the key list of account
+func putEdReshareAccountKey(account string, rk string) error {
+	exsit, da := GetPubKeyData([]byte(strings.ToLower(account)))
+	if !exsit {
+		return PutPubKeyData([]byte(strings.ToLower(account)), []byte(rk))
+	}
+
+	keys := strings.Split(string(da.([]byte)), ":")
+	for _, v := range keys {
+		if strings.EqualFold(v, rk) {
+			return nil
+		}
+	}
+
+	da2 := string(da.([]byte)) + ":" + rk
+	return PutPubKeyData([]byte(strings.ToLower(account)), []byte(da2))
+}
+
+// processReshareED  Obtain the data to be sent in each round and send it to other nodes until the end of the ed reshare command
+func processReshareED(msgprex string, groupid string, pubkey string, account string, mode string, sigs string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan edkeygen.LocalDNodeSaveData) (*big.Int, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========== processReshareED,error channel closed fail to start local smpc node ===========\n")
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * 300):
+			fmt.Printf("=========== processReshareED,reshare timeout ===========\n")
+			return nil, errors.New("ed reshare timeout")
+		case msg := <-outCh:
+			err := ReshareProcessOutCh(msgprex, groupid, msg)
+			if err != nil {
+				fmt.Printf("======== processReshareED,process outch err = %v ==========\n", err)
+				return nil, err
+			}
+		case msg := <-endCh:
+			w, err := FindWorker(msgprex)
+			if w == nil || err != nil {
+				return nil, fmt.Errorf("get worker fail")
+			}
+
+			smpcpks, err := hex.DecodeString(pubkey)
+			if err != nil {
+				return nil, err
+			}
+
+			pubkeyhex := hex.EncodeToString(msg.FinalPkBytes[:])
+			if !strings.EqualFold(pubkey, pubkeyhex) {
+				common.Info("===================== ed reshare fail,new pubkey != old pubkey ====================", "old pubkey", pubkey, "new pubkey", pubkeyhex, "key", msgprex)
+				return nil, errors.New("ed reshare fail,old pubkey != new pubkey")
+			}
+
+			w.edsku1.PushBack(string(msg.Sk[:]))
+			w.edpk.PushBack(string(msg.FinalPkBytes[:]))
+			s := "XXX" + common.Sep11 + string(msg.Pk[:]) + common.Sep11 + string(msg.TSk[:]) + common.Sep11 + string(msg.FinalPkBytes[:])
+			w.edsave.PushBack(s)
+			fmt.Printf("\n===========ed reshare finished successfully, pk = %v ===========\n", pubkeyhex)
+
+			//set new sk
+			err = putSkU1ToLocalDb(smpcpks[:], msg.Sk[:])
+			if err != nil {
+				return nil, err
+			}
+
+			nonce, _, err := GetReqAddrNonce(account) //reqaddr nonce
+			if err != nil {
+				nonce = "0"
+			}
+
+			rk := Keccak256Hash([]byte(strings.ToLower(account + ":" + "ED25519" + ":" + groupid + ":" + nonce + ":" + w.limitnum + ":" + mode))).Hex() //reqaddr key
+
+			tt := fmt.Sprintf("%v", time.Now().UnixNano()/1e6)
+			pubs := &PubKeyData{Key: rk, Account: account, Pub: string(smpcpks[:]), Save: s, Nonce: nonce, GroupID: groupid, LimitNum: w.limitnum, Mode: mode, KeyGenTime: tt, RefReShareKeys: msgprex, KeyType: "ED25519", SignProtocol: w.signprotocol}
+			epubs, err := Encode2(pubs)
+			if err != nil {
+				return nil, errors.New("encode PubKeyData fail in req ed pubkey")
+			}
+
+			ss1, err := Compress([]byte(epubs))
+			if err != nil {
+				return nil, errors.New("compress PubKeyData fail in req ed pubkey")
+			}
+
+			exsit, pda := GetPubKeyData(smpcpks[:])
+			if exsit {
+				daa, ok := pda.(*PubKeyData)
+				if ok {
+					//check mode
+					if daa.Mode != mode {
+						return nil, errors.New("check mode fail")
+					}
+					//
+
+					//check account
+					if !strings.EqualFold(account, daa.Account) {
+						return nil, errors.New("check accout fail")
+					}
+					//
+
+					err = DeletePubKeyData([]byte(daa.Key))
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			err = PutPubKeyData(smpcpks[:], []byte(ss1))
+			if err != nil {
+				return nil, err
+			}
+
+			err = PutAccountDataToDb(smpcpks[:], []byte(pubkeyhex))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, ct := range coins.Cointypes {
+				if strings.EqualFold(ct, "ALL") {
+					continue
+				}
+
+				h := coins.NewCryptocoinHandler(ct)
+				if h == nil {
+					continue
+				}
+				ctaddr, err := h.PublicKeyToAddress(pubkeyhex)
+				if err != nil {
+					continue
+				}
+
+				key := Keccak256Hash([]byte(strings.ToLower(ctaddr))).Hex()
+				err = PutPubKeyData([]byte(key), []byte(ss1))
+				if err != nil {
+					return nil, err
+				}
+
+				err = PutAccountDataToDb([]byte(key), []byte(pubkeyhex))
+				if err != nil {
+					return nil, err
+				}
+
+				err = putSkU1ToLocalDb([]byte(key), msg.Sk[:])
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			_, err = SetReqAddrNonce(account, nonce)
+			if err != nil {
+				return nil, errors.New("set reqaddr nonce fail")
+			}
+
+			wid := -1
+			var allreply []NodeReply
+			exsit, da2 := GetReShareInfoData([]byte(msgprex))
+			if exsit {
+				acr, ok := da2.(*AcceptReShareData)
+				if ok {
+					wid = acr.WorkID
+					allreply = acr.AllReply
+				}
+			}
+
+			ac := &AcceptReqAddrData{Initiator: curEnode, Account: account, Cointype: "ED25519", GroupID: groupid, Nonce: nonce, LimitNum: w.limitnum, Mode: mode, TimeStamp: tt, Deal: "true", Accept: "true", Status: "Success", PubKey: pubkey, Tip: "", Error: "", AllReply: allreply, WorkID: wid, Sigs: sigs}
+			err = SaveAcceptReqAddrData(ac)
+			if err != nil {
+				return nil, errors.New("save reqaddr accept data fail")
+			}
+
+			if isGroupApprovalMode(mode) {
+				sigs2 := strings.Split(ac.Sigs, common.Sep)
+				cnt, _ := strconv.Atoi(sigs2[0])
+				for j := 0; j < cnt; j++ {
+					fr := sigs2[2*j+2]
+					err = putEdReshareAccountKey(fr, rk)
+					if err != nil {
+						return nil, err
+					}
+				}
+			} else {
+				err = putEdReshareAccountKey(account, rk)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			_, err2 := AcceptReqAddr("", account, "ALL", groupid, nonce, w.limitnum, mode, "true", "true", "Success", pubkey, "", "", nil, w.id, "")
+			if err2 != nil {
+				return nil, err2
+			}
+
+			return new(big.Int).SetBytes(msg.TSk[:]), nil
+		}
+	}
+}
+
+// ReShareED execute ed reshare
+// msgprex = hash
+// return value is the backup for the smpc sig
+func ReShareED(msgprex string, initator string, groupid string, pubkey string, account string, mode string, sigs string, ch chan interface{}, id int) {
+	if id < 0 || id >= len(workers) {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("no find worker")}
+		ch <- res
+		return
+	}
+
+	w := workers[id]
+	if w.groupid == "" {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("get group id fail")}
+		ch <- res
+		return
+	}
+
+	ns, _ := GetGroup(groupid)
+	if ns != w.NodeCnt {
+		res := RPCSmpcRes{Ret: "", Err: GetRetErr(ErrGroupNotReady)}
+		ch <- res
+		return
+	}
+
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return
+	}
+
+	var sd *edkeygen.LocalDNodeSaveData
+	var idreshare smpclib.SortableIDSSlice
+	oldnode := false
+
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if exsit {
+		pubs, ok := da.(*PubKeyData)
+		if !ok || pubs.GroupID == "" {
+			res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get sign data from db fail", Err: fmt.Errorf("get sign data from db fail")}
+			ch <- res
+			return
+		}
+
+		// only the node of keygen group that also in the ts group take part in the reshare as old node
+		idreshare = GetGroupNodeUIDs("ED25519", pubs.GroupID, w.groupid)
+		_, uid := GetNodeUID(curEnode, "ED25519", pubs.GroupID)
+		for _, v := range idreshare {
+			if uid != nil && v.Cmp(uid) == 0 {
+				oldnode = true
+				break
+			}
+		}
+
+		if oldnode {
+			mm := strings.Split(pubs.Save, common.Sep11)
+			if len(mm) < 4 || len(mm[2]) < 32 || len(mm[3]) < 32 {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("ed reshare get save data fail")}
+				ch <- res
+				return
+			}
+
+			da2 := getSkU1FromLocalDb(smpcpks[:])
+			if da2 == nil {
+				res := RPCSmpcRes{Ret: "", Tip: "ed reshare get sku1 fail", Err: fmt.Errorf("ed reshare get sku1 fail")}
+				ch <- res
+				return
+			}
+
+			sd = &edkeygen.LocalDNodeSaveData{}
+			copy(sd.Sk[:], da2)
+			copy(sd.Pk[:], []byte(mm[1]))
+			copy(sd.TSk[:], []byte(mm[2]))
+			copy(sd.FinalPkBytes[:], []byte(mm[3]))
+			sd.IDs = GetGroupNodeUIDs("ED25519", pubs.GroupID, pubs.GroupID)
+			sd.CurDNodeID = uid
+		} else {
+			idreshare = nil
+		}
+	}
+
+	fmt.Printf("================= ReShareED,oldnode = %v, groupid = %v, w.groupid = %v,w.ThresHold = %v,w.sid = %v, msgprex = %v =======================\n", oldnode, groupid, w.groupid, w.ThresHold, w.sid, msgprex)
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, ns)
+	endCh := make(chan edkeygen.LocalDNodeSaveData, ns)
+	errChan := make(chan struct{})
+	reshareDNode := edreshare.NewLocalDNode(outCh, endCh, ns, w.ThresHold, sd, oldnode, idreshare)
+	w.DNode = reshareDNode
+	_, UID := GetNodeUID(curEnode, "ED25519", groupid)
+	reshareDNode.SetDNodeID(fmt.Sprintf("%v", UID))
+	reshareDNode.SetSessionKey(msgprex)
+	w.MsgToEnode[w.DNode.DNodeID()] = curEnode
+
+	var reshareWg sync.WaitGroup
+	reshareWg.Add(2)
+	go func() {
+		defer reshareWg.Done()
+		if err := reshareDNode.Start(); nil != err {
+			fmt.Printf("==========ed reshare node start err = %v ==========\n", err)
+			close(errChan)
+		}
+
+		HandleC1Data(nil, w.sid)
+	}()
+	go EdReshareProcessInboundMessages(msgprex, groupid, commStopChan, &reshareWg, ch)
+	newsk, err := processReshareED(msgprex, groupid, pubkey, account, mode, sigs, errChan, outCh, endCh)
+	if err != nil {
+		fmt.Printf("==========process ed reshare err = %v ==========\n", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return
+	}
+
+	res := RPCSmpcRes{Ret: fmt.Sprintf("%v", newsk), Err: nil}
+	ch <- res
+	close(commStopChan)
+	reshareWg.Wait()
+}
+
+//-------------------------------------------------------EDDSA end-----------------------------------------------------------
@@ -17,16 +17,13 @@
 package smpc
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
-	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
 	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/signing"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/anyswap/FastMulThreshold-DSA/log"
 	"math/big"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -66,8 +63,7 @@ func SignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sy
 			}
 			///
 
-			msgmap := make(map[string]string)
-			err := json.Unmarshal([]byte(m), &msgmap)
+			msgmap, err := decodeP2pMsg(m)
 			if err != nil {
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
@@ -140,7 +136,8 @@ func SignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sy
 				return
 			}
 
-			_,ID := GetNodeUID(msgmap["ENode"], "EC256K1",pubs.GroupID)
+			keytype := getPubKeyType(pubs)
+			_,ID := GetNodeUID(msgmap["ENode"], keytype,pubs.GroupID)
 			id := fmt.Sprintf("%v", ID)
 			uid := hex.EncodeToString([]byte(id))
 			if !strings.EqualFold(uid,mm.GetFromID()) {
@@ -173,6 +170,11 @@ func SignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sy
 			_, err = w.DNode.Update(mm)
 			if err != nil {
 				log.Error("========== SignProcessInboundMessages, dnode update fail===========","receiv smpc msg",m,"err",err)
+				blamekey := w.blamekey
+				if blamekey == "" {
+				    blamekey = msgprex
+				}
+				saveBlame(blamekey, keytype, pubs.GroupID, w.groupid, err)
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
 				return
@@ -201,337 +203,7 @@ func SignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *sy
 
 // SignGetRealMessage get the message data struct by map. (p2p msg ---> map)
 func SignGetRealMessage(msg map[string]string) smpclib.Message {
-    	if msg == nil {
-	    return nil
-	}
-
-	from := msg["FromID"]
-	if from == "" {
-	    return nil
-	}
-
-	var to []string
-	v, ok := msg["ToID"]
-	if ok && v != "" {
-		to = strings.Split(v, ":")
-	}
-
-	index, indexerr := strconv.Atoi(msg["FromIndex"])
-	if indexerr != nil {
-		return nil
-	}
-
-	//1 message
-	if msg["Type"] == "SignRound1Message" {
-	    if msg["C11"] == "" {
-		return nil
-	    }
-
-		c11, _ := new(big.Int).SetString(msg["C11"], 10)
-		if c11 == nil {
-		    return nil
-		}
-
-		if msg["ComWiC"] == "" {
-		    return nil
-		}
-
-		wic, _ := new(big.Int).SetString(msg["ComWiC"], 10)
-		if wic == nil {
-		    return nil
-		}
-
-		srm := &signing.SignRound1Message{
-			SignRoundMessage: new(signing.SignRoundMessage),
-			C11:              c11,
-			ComWiC:           wic,
-		}
-		
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-		return srm
-	}
-
-	//2 message
-	if msg["Type"] == "SignRound2Message" {
-	    if msg["U1u1MtAZK1Proof"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.MtARangeProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["U1u1MtAZK1Proof"])); err == nil {
-
-			srm := &signing.SignRound2Message{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				U1u1MtAZK1Proof:  proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-
-			return srm
-		}
-
-		return nil
-	}
-
-	//3 message
-	if msg["Type"] == "SignRound3Message" {
-	    if msg["Kc"] == "" {
-		return nil
-	    }
-
-	    kc, _ := new(big.Int).SetString(msg["Kc"], 10)
-	    if kc == nil {
-		return nil
-	    }
-
-	    if msg["ComWiD"] == "" {
-		return nil
-	    }
-
-		tmp := strings.Split(msg["ComWiD"], ":")
-		dtmp := make([]*big.Int, len(tmp))
-		for k, v := range tmp {
-			dtmp[k], _ = new(big.Int).SetString(v, 10)
-			if dtmp[k] == nil {
-			    return nil
-			}
-		}
-
-	    srm := &signing.SignRound3Message{
-		    SignRoundMessage: new(signing.SignRoundMessage),
-		    Kc:               kc,
-		    ComWiD:dtmp,
-	    }
-	    srm.SetFromID(from)
-	    srm.SetFromIndex(index)
-	    srm.ToID = to
-	    return srm
-	}
-
-	//4 message
-	if msg["Type"] == "SignRound4Message" {
-	    if msg["U1u1MtAZK2Proof"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.MtARespZKProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["U1u1MtAZK2Proof"])); err == nil {
-		    if msg["U1KGamma1Cipher"] == "" {
-			return nil
-		    }
-
-			cipher, _ := new(big.Int).SetString(msg["U1KGamma1Cipher"], 10)
-			if cipher == nil {
-			    return nil
-			}
-
-			srm := &signing.SignRound4Message{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				U1KGamma1Cipher:  cipher,
-				U1u1MtAZK2Proof:  proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-			return srm
-		}
-
-		return nil
-	}
-
-	//4-1 message
-	if msg["Type"] == "SignRound4Message1" {
-	    if msg["U1u1MtAZK3Proof"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.MtAwcRespZKProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["U1u1MtAZK3Proof"])); err == nil {
-		    if msg["U1Kw1Cipher"] == "" {
-			return nil
-		    }
-
-			cipher, _ := new(big.Int).SetString(msg["U1Kw1Cipher"], 10)
-			if cipher == nil {
-			    return nil
-			}
-
-			srm := &signing.SignRound4Message1{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				U1Kw1Cipher:      cipher,
-				U1u1MtAZK3Proof:  proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-			return srm
-		}
-
-		return nil
-	}
-
-	//5 message
-	if msg["Type"] == "SignRound5Message" {
-	    if msg["Tpf"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.TProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["Tpf"])); err == nil {
-		    if msg["Delta1"] == "" || msg["T1X"] == "" || msg["T1Y"] == "" {
-			return nil
-		    }
-
-		    delta, _ := new(big.Int).SetString(msg["Delta1"], 10)
-		    t1x, _ := new(big.Int).SetString(msg["T1X"], 10)
-		    t1y, _ := new(big.Int).SetString(msg["T1Y"], 10)
-
-		    if delta == nil || t1x == nil || t1y == nil {
-			return nil
-		    }
-
-		    srm := &signing.SignRound5Message{
-			    SignRoundMessage: new(signing.SignRoundMessage),
-			    Delta1:           delta,
-			    T1X:           t1x,
-			    T1Y:           t1y,
-			    Tpf:	proof,
-		    }
-		    srm.SetFromID(from)
-		    srm.SetFromIndex(index)
-		    srm.ToID = to
-		    return srm
-		}
-
-		return nil
-	}
-
-	//6 message
-	if msg["Type"] == "SignRound6Message" {
-	    if msg["U1GammaZKProof"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.ZkUProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["U1GammaZKProof"])); err == nil {
-		    if msg["CommU1D"] == "" {
-			return nil
-		    }
-
-			tmp := strings.Split(msg["CommU1D"], ":")
-			dtmp := make([]*big.Int, len(tmp))
-			for k, v := range tmp {
-				dtmp[k], _ = new(big.Int).SetString(v, 10)
-				if dtmp[k] == nil {
-				    return nil
-				}
-			}
-
-			srm := &signing.SignRound6Message{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				CommU1D:          dtmp,
-				U1GammaZKProof:   proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-			return srm
-		}
-
-		return nil
-	}
-
-	//7 message
-	if msg["Type"] == "SignRound7Message" {
-	    if msg["PdlwSlackPf"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.PDLwSlackProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["PdlwSlackPf"])); err == nil {
-		    if msg["K1RX"] == "" || msg["K1RY"] == "" {
-			return nil
-		    }
-
-			k1rx, _ := new(big.Int).SetString(msg["K1RX"], 10)
-			k1ry, _ := new(big.Int).SetString(msg["K1RY"], 10)
-			if k1rx == nil || k1ry == nil {
-			    return nil
-			}
-
-			srm := &signing.SignRound7Message{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				K1RX:          k1rx,
-				K1RY:          k1ry,
-				PdlwSlackPf:   proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-			return srm
-		}
-
-		return nil
-	}
-
-	// 8 message
-	if msg["Type"] == "SignRound8Message" {
-	    if msg["STpf"] == "" {
-		return nil
-	    }
-
-		proof := &ec2.STProof{}
-		if err := proof.UnmarshalJSON([]byte(msg["STpf"])); err == nil {
-		    if msg["S1X"] == "" || msg["S1Y"] == "" {
-			return nil
-		    }
-
-			s1x, _ := new(big.Int).SetString(msg["S1X"], 10)
-			s1y, _ := new(big.Int).SetString(msg["S1Y"], 10)
-			if s1x == nil || s1y == nil {
-			    return nil
-			}
-
-			srm := &signing.SignRound8Message{
-				SignRoundMessage: new(signing.SignRoundMessage),
-				S1X:          s1x,
-				S1Y:          s1y,
-				STpf:   proof,
-			}
-			srm.SetFromID(from)
-			srm.SetFromIndex(index)
-			srm.ToID = to
-			return srm
-		}
-
-		return nil
-	}
-	
-	// 9 message
-	if msg["Type"] == "SignRound9Message" {
-	    if msg["Us1"] == "" {
-		return nil
-	    }
-
-		us1, _ := new(big.Int).SetString(msg["Us1"], 10)
-		if us1 == nil {
-		    return nil
-		}
-
-		srm := &signing.SignRound9Message{
-			SignRoundMessage: new(signing.SignRoundMessage),
-			Us1:               us1,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-		return srm
-	}
-
-	return nil
+	return getRealMessage(msg, "ecdsa/signing", "ecdsa/presign")
 }
 
 // processSign  Obtain the data to be sent in each round and send it to other nodes until the end of the sign command 
@@ -610,26 +282,32 @@ func SignProcessOutCh(msgprex string, msgtoenode map[string]string, msg smpclib.
 		return fmt.Errorf("get worker fail")
 	}
 
+	notifyRound(msgprex, msg.GetMsgType())
+
 	sig,err := sigP2pMsg(msg,curEnode)
 	if err != nil {
 	    return err
 	}
 
-	msgmap := msg.OutMap()
+	if gid == "" {
+		gid = w.groupid
+	}
+
+	msgmap, err := getWireMsgMap(msg, gid)
+	if err != nil {
+		return err
+	}
+
 	msgmap["Key"] = msgprex
 	msgmap["ENode"] = curEnode
 	msgmap["Sig"] = hex.EncodeToString(sig)
-	s, err := json.Marshal(msgmap)
+	s, err := encodeP2pMsg(msgmap)
 	if err != nil {
 		return err
 	}
 
-	if gid == "" {
-		gid = w.groupid
-	}
-
 	if msg.IsBroadcast() {
-		SendMsgToSmpcGroup(string(s), gid)
+		SendMsgToSmpcGroup(s, gid)
 	} else {
 		for _, v := range msg.GetToID() {
 			enode := msgtoenode[v]
@@ -639,7 +317,7 @@ func SignProcessOutCh(msgprex string, msgtoenode map[string]string, msg smpclib.
 				node2 := ParseNode(node)
 				if strings.EqualFold(enode, node2) {
 					//SendMsgToPeer(node, string(s))
-					SendMsgToPeerWithBrodcast(msgprex,node,string(s),gid)
+					SendMsgToPeerWithBrodcast(msgprex,node,s,gid)
 					break
 				}
 			}
@@ -18,12 +18,10 @@ package smpc
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	edsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
 	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -54,8 +52,7 @@ func EdSignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *
 			return
 		case m := <-w.SmpcMsg:
 
-			msgmap := make(map[string]string)
-			err := json.Unmarshal([]byte(m), &msgmap)
+			msgmap, err := decodeP2pMsg(m)
 
 			//fmt.Printf("=================== EdSignProcessInboundMessages, msg = %v, err = %v, key = %v ====================\n", m, err, msgprex)
 			if err != nil {
@@ -156,6 +153,7 @@ func EdSignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *
 			_, err = w.DNode.Update(mm)
 			if err != nil {
 				fmt.Printf("========== EdSignProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v, key = %v ============\n", m, err, msgprex)
+				saveBlame(msgprex, "ED25519", pubs.GroupID, w.groupid, err)
 				res := RPCSmpcRes{Ret: "", Err: err}
 				ch <- res
 				return
@@ -166,177 +164,7 @@ func EdSignProcessInboundMessages(msgprex string, finishChan chan struct{}, wg *
 
 // EdSignGetRealMessage get the message data struct by map. (p2p msg ---> map)
 func EdSignGetRealMessage(msg map[string]string) smpclib.Message {
-    	if msg == nil {
-	    return nil
-	}
-
-	from := msg["FromID"]
-	if from == "" {
-	    return nil
-	}
-
-	var to []string
-	v, ok := msg["ToID"]
-	if ok && v != "" {
-		to = strings.Split(v, ":")
-	}
-
-	index, indexerr := strconv.Atoi(msg["FromIndex"])
-	if indexerr != nil {
-		return nil
-	}
-
-	//1 message
-	if msg["Type"] == "SignRound1Message" {
-	    if msg["CR"] == "" {
-		return nil
-	    }
-
-		cr, err := hex.DecodeString(msg["CR"])
-		if cr == nil || err != nil {
-		    return nil
-		}
-
-		var CR [32]byte
-		copy(CR[:], cr[:])
-
-		srm := &edsigning.SignRound1Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			CR:               CR,
-		}
-
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-		return srm
-	}
-
-	//2 message
-	if msg["Type"] == "SignRound2Message" {
-	    if msg["ZkR"] == "" {
-		return nil
-	    }
-
-		zkr, err := hex.DecodeString(msg["ZkR"])
-		if zkr == nil || err != nil {
-		    return nil
-		}
-
-		var ZkR [64]byte
-		copy(ZkR[:], zkr[:])
-
-		srm := &edsigning.SignRound2Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			ZkR:              ZkR,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-
-		return srm
-	}
-
-	//3 message
-	if msg["Type"] == "SignRound3Message" {
-	    if msg["DR"] == "" {
-		return nil
-	    }
-
-		dr, err := hex.DecodeString(msg["DR"])
-		if dr == nil || err != nil {
-		    return nil
-		}
-
-		var DR [64]byte
-		copy(DR[:], dr[:])
-
-		srm := &edsigning.SignRound3Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			DR:               DR,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-
-		return srm
-	}
-
-	//4 message
-	if msg["Type"] == "SignRound4Message" {
-	    if msg["CSB"] == "" {
-		return nil
-	    }
-
-		csb, err := hex.DecodeString(msg["CSB"])
-		if csb == nil || err != nil {
-		    return nil
-		}
-
-		var CSB [32]byte
-		copy(CSB[:], csb[:])
-
-		srm := &edsigning.SignRound4Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			CSB:              CSB,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-
-		return srm
-	}
-
-	//5 message
-	if msg["Type"] == "SignRound5Message" {
-	    if msg["DSB"] == "" {
-		return nil
-	    }
-
-		dsb, err := hex.DecodeString(msg["DSB"])
-		if dsb == nil || err != nil {
-		    return nil
-		}
-
-		var DSB [64]byte
-		copy(DSB[:], dsb[:])
-
-		srm := &edsigning.SignRound5Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			DSB:              DSB,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-
-		return srm
-	}
-
-	//6 message
-	if msg["Type"] == "SignRound6Message" {
-	    if msg["S"] == "" {
-		return nil
-	    }
-
-		s, err := hex.DecodeString(msg["S"])
-		if s == nil || err != nil {
-		    return nil
-		}
-
-		var S [32]byte
-		copy(S[:], s[:])
-
-		srm := &edsigning.SignRound6Message{
-			SignRoundMessage: new(edsigning.SignRoundMessage),
-			S:                S,
-		}
-		srm.SetFromID(from)
-		srm.SetFromIndex(index)
-		srm.ToID = to
-
-		return srm
-	}
-
-	return nil
+	return getRealMessage(msg, "eddsa/signing")
 }
 
 // processSigned  Obtain the data to be sent in each round and send it to other nodes until the end of the sign command 
@@ -0,0 +1,467 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ed"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/frost"
+	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	edsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/signing"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+//--------------------------------------------------------FROST start-------------------------------------------------------
+
+// isFROSTPubKey whether the pubkey is ED25519 pubkey signed by FROST
+func isFROSTPubKey(pubkey string) bool {
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return false
+	}
+
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if !exsit || da == nil {
+		return false
+	}
+
+	pubs, ok := da.(*PubKeyData)
+	return ok && getPubKeyType(pubs) == "ED25519" && isFROST(pubs)
+}
+
+// getPreSignInputCode get the inputcode of the pre-sign data used by the sign command
+// the FROST nonces are independent of the key,so the bip32 child keys use the pre-sign data of the root key
+func getPreSignInputCode(pubkey string, inputcode string) string {
+	if inputcode != "" && isFROSTPubKey(pubkey) {
+		return ""
+	}
+
+	return inputcode
+}
+
+// getFrostSaveData get the ED25519 keygen share of current node from local save data
+// inputcode is the BIP32-Ed25519 derivation path of the child key,"" means the root key
+func getFrostSaveData(save string, pubkey string, inputcode string) (*edkeygen.LocalDNodeSaveData, *PubKeyData, error) {
+	mm := strings.Split(save, common.Sep11)
+	if len(mm) < 4 || len(mm[2]) < 32 || len(mm[3]) < 32 {
+		return nil, nil, errors.New("frost get local save data fail")
+	}
+
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if !exsit || da == nil {
+		return nil, nil, errors.New("frost get local save data fail")
+	}
+
+	pubs, ok := da.(*PubKeyData)
+	if !ok || pubs.GroupID == "" {
+		return nil, nil, errors.New("frost get local save data fail")
+	}
+
+	sd := &edkeygen.LocalDNodeSaveData{}
+	copy(sd.TSk[:], []byte(mm[2])[:32])
+	copy(sd.FinalPkBytes[:], []byte(mm[3])[:32])
+
+	// sign with the bip32 child key: child share = share + tweak
+	if inputcode != "" {
+		childpk, tweak, err := getEdBip32ChildKey(smpcpks[:], inputcode)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ed.ScAdd(&sd.TSk, &sd.TSk, &tweak)
+		sd.FinalPkBytes = childpk
+	}
+
+	sd.IDs = GetGroupNodeUIDs("ED25519", pubs.GroupID, pubs.GroupID)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, "ED25519", pubs.GroupID)
+	return sd, pubs, nil
+}
+
+// PreSignFROST execute the FROST preprocessing,generate the nonces and the commitment list of the signers in w.groupid
+// msgprex = hash
+// the return value is the generated pre-sign data.
+func PreSignFROST(msgprex string, save string, pubkey string, ch chan interface{}, id int) *PreSignData {
+	if id < 0 || id >= len(workers) {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("no find worker")}
+		ch <- res
+		return nil
+	}
+
+	w := workers[id]
+	if w.groupid == "" {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("get group id fail")}
+		ch <- res
+		return nil
+	}
+
+	sd, pubs, err := getFrostSaveData(save, pubkey, "")
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "frost presign get local save data fail", Err: err}
+		ch <- res
+		return nil
+	}
+
+	msgtoenode := GetMsgToEnode("ED25519", pubs.GroupID, pubs.GroupID)
+	idsign := GetGroupNodeUIDs("ED25519", pubs.GroupID, w.groupid)
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.ThresHold)
+	endCh := make(chan frost.PrePubData, w.ThresHold)
+	errChan := make(chan struct{})
+	signDNode := frost.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, false, nil, nil, nil)
+	w.DNode = signDNode
+	signDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	signDNode.SetSessionKey(msgprex)
+
+	var signWg sync.WaitGroup
+	signWg.Add(2)
+	go func() {
+		defer signWg.Done()
+		if err := signDNode.Start(); nil != err {
+			common.Error("==========PreSignFROST, node start fail=======", "key", msgprex, "err", err)
+			close(errChan)
+		}
+
+		for _, uid := range idsign {
+			HandleFrostSign(msgprex, uid)
+		}
+	}()
+	go FrostSignProcessInboundMessages(msgprex, pubs.GroupID, commStopChan, &signWg, ch)
+
+	pre, err := processFrostPreSign(msgprex, msgtoenode, errChan, outCh, endCh)
+	if err != nil || pre == nil {
+		common.Debug("==========================PreSignFROST,process presign fail===========================", "key", msgprex, "err", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return nil
+	}
+
+	close(commStopChan)
+	signWg.Wait()
+
+	return &PreSignData{Key: msgprex, Gid: w.groupid, Used: false, Index: -1, Frost: pre}
+}
+
+// signFROST execute the sign command of ED25519 pubkey with FROST,every hash is signed with the pre-sign data picked for it
+func signFROST(msgprex string, txhash []string, save string, pubkey string, inputcode string, pickdata []*PickHashData, ch chan interface{}) string {
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:no find worker", Err: GetRetErr(ErrNoFindWorker)}
+		ch <- res
+		return ""
+	}
+	id := w.id
+
+	curEnode = GetSelfEnode()
+
+	var result string
+	for _, v := range txhash {
+		vv := strings.TrimPrefix(v, "0x")
+
+		var pick *PreSignData
+		for _, val := range pickdata {
+			if strings.EqualFold(val.Hash, ("0x"+vv)) || strings.EqualFold(val.Hash, vv) {
+				pick = val.Pre
+				break
+			}
+		}
+		if pick == nil || pick.Frost == nil {
+			common.Error("======================signFROST, no pre-sign data for the hash==================", "unsign txhash", vv, "msgprex", msgprex)
+			break
+		}
+
+		// the nonces can only be used once,so there is no retry
+		var ch1 = make(chan interface{}, 1)
+		SignFROST(msgprex, save, pubkey, inputcode, vv, pick.Frost, ch1, id)
+		ret, _, cherr := GetChannelValue(cht, ch1)
+		if ret == "" || cherr != nil {
+			common.Error("======================signFROST, sign error====================", "unsign txhash", vv, "msgprex", msgprex, "err", cherr)
+			break
+		}
+
+		result += ret
+		result += ":"
+	}
+
+	result += "NULL"
+	tmps := strings.Split(result, ":")
+	if len(tmps) == (len(txhash) + 1) {
+		res := RPCSmpcRes{Ret: result, Tip: "", Err: nil}
+		ch <- res
+		return ""
+	}
+
+	res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error: sign fail", Err: fmt.Errorf("sign fail")}
+	ch <- res
+	return ""
+}
+
+// SignFROST sign the message in a single round with the FROST pre-sign data
+// msgprex = hash
+// message is the hex of the bytes to be signed
+func SignFROST(msgprex string, save string, pubkey string, inputcode string, message string, pre *frost.PrePubData, ch chan interface{}, id int) {
+	if id < 0 || id >= len(workers) || id >= RPCMaxWorker {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get worker id fail", Err: GetRetErr(ErrGetWorkerIDError)}
+		ch <- res
+		return
+	}
+
+	w := workers[id]
+	if w.groupid == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "get group id fail", Err: fmt.Errorf("get group id fail")}
+		ch <- res
+		return
+	}
+
+	msg, err := hex.DecodeString(message)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "", Err: fmt.Errorf("frost sign message must be hex string")}
+		ch <- res
+		return
+	}
+
+	sd, pubs, err := getFrostSaveData(save, pubkey, inputcode)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "frost sign get local save data fail", Err: err}
+		ch <- res
+		return
+	}
+
+	msgtoenode := GetMsgToEnode("ED25519", pubs.GroupID, pubs.GroupID)
+	idsign := GetGroupNodeUIDs("ED25519", pubs.GroupID, w.groupid)
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.ThresHold)
+	finalizeendCh := make(chan edsigning.EdSignData, w.ThresHold)
+	errChan := make(chan struct{})
+	signDNode := frost.NewLocalDNode(outCh, nil, sd, idsign, sd.CurDNodeID, w.ThresHold, true, pre, msg, finalizeendCh)
+	w.DNode = signDNode
+	signDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	signDNode.SetSessionKey(msgprex)
+
+	var signWg sync.WaitGroup
+	signWg.Add(2)
+	go func() {
+		defer signWg.Done()
+		if err := signDNode.Start(); nil != err {
+			fmt.Printf("==========SignFROST, node start, key = %v, err = %v ==========\n", msgprex, err)
+			close(errChan)
+		}
+
+		for _, uid := range idsign {
+			HandleFrostSign(msgprex, uid)
+		}
+	}()
+	go FrostSignProcessInboundMessages(msgprex, pubs.GroupID, commStopChan, &signWg, ch)
+	data, err := processFrostSign(msgprex, msgtoenode, errChan, outCh, finalizeendCh)
+	if err != nil || data == nil {
+		common.Debug("================SignFROST,process sign fail========================", "key", msgprex, "err", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return
+	}
+
+	close(commStopChan)
+	signWg.Wait()
+
+	signature := new([64]byte)
+	copy(signature[:], data.Rx[:])
+	copy(signature[32:], data.Sx[:])
+	sig := hex.EncodeToString(signature[:])
+	common.Info("================SignFROST,get the signature========================", "key", msgprex, "sig", sig)
+	res := RPCSmpcRes{Ret: sig, Tip: "", Err: nil}
+	ch <- res
+}
+
+// HandleFrostSign Process pre-save msg for FROST presign and sign
+func HandleFrostSign(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "FrostPreSignRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "FrostSignRound1Message")
+	Handle(key, c1data)
+}
+
+// FrostSignProcessInboundMessages Analyze the obtained P2P messages and enter next round
+// gid is the keygen group id
+func FrostSignProcessInboundMessages(msgprex string, gid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+	if msgprex == "" || gid == "" {
+		return
+	}
+
+	fmt.Printf("start frost sign processing inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to frost sign process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop frost sign processing inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := FrostGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to frost sign process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[FROST SIGN] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============frost sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], "ED25519", gid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============frost sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			// check whether 'from' is in the group
+			succ := false
+			_, nodes := GetGroup(w.groupid)
+			others := strings.Split(nodes, common.Sep2)
+			for _, v := range others {
+				node2 := ParseNode(v)
+				if strings.EqualFold(node2, msgmap["ENode"]) {
+					succ = true
+					break
+				}
+			}
+
+			if !succ {
+				common.Error("===============frost sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== FrostSignProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v, key = %v ============\n", m, err, msgprex)
+				saveBlame(msgprex, "ED25519", gid, w.groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// FrostGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func FrostGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "eddsa/frost")
+}
+
+// processFrostPreSign  Obtain the data to be sent in each round and send it to other nodes until the end of the FROST preprocessing
+func processFrostPreSign(msgprex string, msgtoenode map[string]string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan frost.PrePubData) (*frost.PrePubData, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========================== processFrostPreSign,error channel closed fail to start local smpc node, key = %v =====================\n", msgprex)
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * time.Duration(EdSignTimeout)):
+			fmt.Printf("========================== processFrostPreSign,presign timeout, key = %v ==========================\n", msgprex)
+			return nil, errors.New("frost presign timeout")
+		case msg := <-outCh:
+			err := SignProcessOutCh(msgprex, msgtoenode, msg, "")
+			if err != nil {
+				fmt.Printf("======================= processFrostPreSign, presign process outch err = %v, key = %v ====================\n", err, msgprex)
+				return nil, err
+			}
+		case msg := <-endCh:
+			return &msg, nil
+		}
+	}
+}
+
+// processFrostSign  Obtain the data to be sent and send it to other nodes until the end of the FROST sign
+func processFrostSign(msgprex string, msgtoenode map[string]string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan edsigning.EdSignData) (*edsigning.EdSignData, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========================== processFrostSign,error channel closed fail to start local smpc node, key = %v =====================\n", msgprex)
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * time.Duration(EdSignTimeout)):
+			fmt.Printf("========================== processFrostSign,sign timeout, key = %v ==========================\n", msgprex)
+			return nil, errors.New("frost sign timeout")
+		case msg := <-outCh:
+			err := SignProcessOutCh(msgprex, msgtoenode, msg, "")
+			if err != nil {
+				fmt.Printf("======================= processFrostSign, sign process outch err = %v, key = %v ====================\n", err, msgprex)
+				return nil, err
+			}
+		case msg := <-endCh:
+			return &msg, nil
+		}
+	}
+}
+
+//-------------------------------------------------------FROST end---------------------------------------------------
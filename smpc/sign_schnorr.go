@@ -0,0 +1,361 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto/secp256k1"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	schsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/schnorr/signing"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+//--------------------------------------------------------SCHNORR start-------------------------------------------------------
+
+// getTapTweak get the BIP-341 merkle root from the taptweak of sign command
+// "" : no tweak,return nil
+// "TAPROOT" : tweak without script tree,return empty merkle root
+// hex string : the 32 bytes merkle root
+func getTapTweak(taptweak string) ([]byte, error) {
+	if taptweak == "" {
+		return nil, nil
+	}
+
+	if strings.EqualFold(taptweak, "TAPROOT") {
+		return []byte{}, nil
+	}
+
+	root, err := hex.DecodeString(strings.TrimPrefix(taptweak, "0x"))
+	if err != nil || len(root) != 32 {
+		return nil, errors.New("invalid taptweak")
+	}
+
+	return root, nil
+}
+
+// GetSchnorrPubKey get the x-only pubkey(hex) that verifies the schnorr signatures of pubkey with taptweak
+func GetSchnorrPubKey(pubkey string, taptweak string) (string, error) {
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return "", err
+	}
+
+	if len(smpcpks) != 65 {
+		return "", errors.New("schnorr sign need EC256K1 pubkey")
+	}
+
+	tweak, err := getTapTweak(taptweak)
+	if err != nil {
+		return "", err
+	}
+
+	pkx, pky := secp256k1.S256().Unmarshal(smpcpks[:])
+	xonly, err := schsigning.XOnlyPubKey(pkx, pky, tweak)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(xonly), nil
+}
+
+// signSchnorr execute the sign command with BIP-340 schnorr algorithm
+func signSchnorr(msgprex string, txhash []string, sku1 *big.Int, pkx *big.Int, pky *big.Int, taptweak string, ch chan interface{}) string {
+	tweak, err := getTapTweak(taptweak)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "", Err: err}
+		ch <- res
+		return ""
+	}
+
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:no find worker", Err: GetRetErr(ErrNoFindWorker)}
+		ch <- res
+		return ""
+	}
+	id := w.id
+
+	var result string
+	for _, v := range txhash {
+		var ch1 = make(chan interface{}, 1)
+		for i := 0; i < recalcTimes; i++ {
+			if len(ch1) != 0 {
+				<-ch1
+			}
+
+			SignSchnorr(msgprex, sku1, pkx, pky, strings.TrimPrefix(v, "0x"), tweak, ch1, id)
+			ret, _, cherr := GetChannelValue(cht, ch1)
+			if ret != "" && cherr == nil {
+				result += ret
+				result += ":"
+				break
+			}
+
+			time.Sleep(time.Duration(3) * time.Second)
+		}
+	}
+
+	result += "NULL"
+	tmps := strings.Split(result, ":")
+	if len(tmps) == (len(txhash) + 1) {
+		res := RPCSmpcRes{Ret: result, Tip: "", Err: nil}
+		ch <- res
+	}
+
+	return ""
+}
+
+// SignSchnorr execute the sign command with BIP-340 schnorr algorithm,the signers use the EC256K1 keygen shares
+// msgprex = hash
+// message is the hex of the 32 bytes message,tweak is nil if no taproot tweak
+func SignSchnorr(msgprex string, sku1 *big.Int, pkx *big.Int, pky *big.Int, message string, tweak []byte, ch chan interface{}, id int) {
+	if id < 0 || id >= len(workers) || id >= RPCMaxWorker {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get worker id fail", Err: GetRetErr(ErrGetWorkerIDError)}
+		ch <- res
+		return
+	}
+
+	w := workers[id]
+	if w.groupid == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "get group id fail", Err: fmt.Errorf("get group id fail")}
+		ch <- res
+		return
+	}
+
+	msg, err := hex.DecodeString(message)
+	if err != nil || len(msg) != 32 {
+		res := RPCSmpcRes{Ret: "", Tip: "", Err: fmt.Errorf("schnorr sign message must be 32 bytes")}
+		ch <- res
+		return
+	}
+
+	smpcpks := secp256k1.S256().Marshal(pkx, pky)
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if !exsit || da == nil {
+		res := RPCSmpcRes{Ret: "", Tip: "schnorr sign get local save data fail", Err: fmt.Errorf("schnorr sign get local save data fail")}
+		ch <- res
+		return
+	}
+
+	pubs, ok := da.(*PubKeyData)
+	if !ok || pubs.GroupID == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "schnorr sign get local save data fail", Err: fmt.Errorf("schnorr sign get local save data fail")}
+		ch <- res
+		return
+	}
+
+	sd := &keygen.LocalDNodeSaveData{}
+	sd.SkU1 = sku1
+	sd.Pkx = pkx
+	sd.Pky = pky
+	sd.IDs = GetGroupNodeUIDs("EC256K1", pubs.GroupID, pubs.GroupID)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, "EC256K1", pubs.GroupID)
+	msgtoenode := GetMsgToEnode("EC256K1", pubs.GroupID, pubs.GroupID)
+
+	idsign := GetGroupNodeUIDs("EC256K1", pubs.GroupID, w.groupid)
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.ThresHold)
+	endCh := make(chan schsigning.SchnorrSignData, w.ThresHold)
+	errChan := make(chan struct{})
+	signDNode := schsigning.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, new(big.Int).SetBytes(msg), tweak)
+	w.DNode = signDNode
+	signDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	signDNode.SetSessionKey(msgprex)
+
+	var signWg sync.WaitGroup
+	signWg.Add(2)
+	go func() {
+		defer signWg.Done()
+		if err := signDNode.Start(); nil != err {
+			fmt.Printf("==========SignSchnorr, node start, key = %v, err = %v ==========\n", msgprex, err)
+			close(errChan)
+		}
+
+		for _, uid := range idsign {
+			HandleSchnorrSign(msgprex, uid)
+		}
+	}()
+	go SchnorrSignProcessInboundMessages(msgprex, pubs.GroupID, commStopChan, &signWg, ch)
+	data, err := processSchnorrSign(msgprex, msgtoenode, errChan, outCh, endCh)
+	if err != nil || data == nil {
+		common.Debug("================SignSchnorr,process sign fail========================", "key", msgprex, "err", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return
+	}
+
+	close(commStopChan)
+	signWg.Wait()
+
+	sig := hex.EncodeToString(append(data.Rx[:], data.S[:]...))
+	common.Info("================SignSchnorr,get the signature========================", "key", msgprex, "sig", sig, "xonly pubkey", hex.EncodeToString(data.XOnlyPk[:]))
+	res := RPCSmpcRes{Ret: sig, Tip: "", Err: nil}
+	ch <- res
+}
+
+// HandleSchnorrSign Process pre-save msg for schnorr sign
+func HandleSchnorrSign(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "SchnorrSignRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "SchnorrSignRound2Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "SchnorrSignRound3Message")
+	Handle(key, c1data)
+}
+
+// SchnorrSignProcessInboundMessages Analyze the obtained P2P messages and enter next round
+// gid is the keygen group id
+func SchnorrSignProcessInboundMessages(msgprex string, gid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+	if msgprex == "" || gid == "" {
+		return
+	}
+
+	fmt.Printf("start schnorr sign processing inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to schnorr sign process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop schnorr sign processing inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := SchnorrSignGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to schnorr sign process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[SCHNORR SIGN] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============schnorr sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], "EC256K1", gid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============schnorr sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			// check whether 'from' is in the group
+			succ := false
+			_, nodes := GetGroup(w.groupid)
+			others := strings.Split(nodes, common.Sep2)
+			for _, v := range others {
+				node2 := ParseNode(v)
+				if strings.EqualFold(node2, msgmap["ENode"]) {
+					succ = true
+					break
+				}
+			}
+
+			if !succ {
+				common.Error("===============schnorr sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== SchnorrSignProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v, key = %v ============\n", m, err, msgprex)
+				saveBlame(msgprex, "EC256K1", gid, w.groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// SchnorrSignGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func SchnorrSignGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "schnorr/signing")
+}
+
+// processSchnorrSign  Obtain the data to be sent in each round and send it to other nodes until the end of the schnorr sign command
+func processSchnorrSign(msgprex string, msgtoenode map[string]string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan schsigning.SchnorrSignData) (*schsigning.SchnorrSignData, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========================== processSchnorrSign,error channel closed fail to start local smpc node, key = %v =====================\n", msgprex)
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * time.Duration(EdSignTimeout)):
+			fmt.Printf("========================== processSchnorrSign,sign timeout, key = %v ==========================\n", msgprex)
+			return nil, errors.New("schnorr sign timeout")
+		case msg := <-outCh:
+			err := SignProcessOutCh(msgprex, msgtoenode, msg, "")
+			if err != nil {
+				fmt.Printf("======================= processSchnorrSign, sign process outch err = %v, key = %v ====================\n", err, msgprex)
+				return nil, err
+			}
+		case msg := <-endCh:
+			return &msg, nil
+		}
+	}
+}
+
+//-------------------------------------------------------SCHNORR end---------------------------------------------------
@@ -0,0 +1,333 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+	edkeygen "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/eddsa/keygen"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+	srsigning "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/sr25519/signing"
+)
+
+//--------------------------------------------------------SR25519 start-------------------------------------------------------
+
+// signSr25519 execute the sign command with sr25519(schnorrkel) algorithm
+func signSr25519(msgprex string, txhash []string, save string, pubkey string, ch chan interface{}) string {
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:no find worker", Err: GetRetErr(ErrNoFindWorker)}
+		ch <- res
+		return ""
+	}
+	id := w.id
+
+	curEnode = GetSelfEnode()
+
+	var result string
+	for _, v := range txhash {
+		var ch1 = make(chan interface{}, 1)
+		for i := 0; i < recalcTimes; i++ {
+			if len(ch1) != 0 {
+				<-ch1
+			}
+
+			SignSr25519(msgprex, save, pubkey, strings.TrimPrefix(v, "0x"), ch1, id)
+			ret, _, cherr := GetChannelValue(cht, ch1)
+			if ret != "" && cherr == nil {
+				result += ret
+				result += ":"
+				break
+			}
+
+			time.Sleep(time.Duration(3) * time.Second)
+		}
+	}
+
+	result += "NULL"
+	tmps := strings.Split(result, ":")
+	if len(tmps) == (len(txhash) + 1) {
+		res := RPCSmpcRes{Ret: result, Tip: "", Err: nil}
+		ch <- res
+	}
+
+	return ""
+}
+
+// SignSr25519 execute the sign command with sr25519 algorithm,the signers use the ED25519 keygen shares
+// msgprex = hash
+// message is the hex of the bytes to be signed,it is signed under the "substrate" signing context
+func SignSr25519(msgprex string, save string, pubkey string, message string, ch chan interface{}, id int) {
+	if id < 0 || id >= len(workers) || id >= RPCMaxWorker {
+		res := RPCSmpcRes{Ret: "", Tip: "smpc back-end internal error:get worker id fail", Err: GetRetErr(ErrGetWorkerIDError)}
+		ch <- res
+		return
+	}
+
+	w := workers[id]
+	if w.groupid == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "get group id fail", Err: fmt.Errorf("get group id fail")}
+		ch <- res
+		return
+	}
+
+	msg, err := hex.DecodeString(message)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "", Err: fmt.Errorf("sr25519 sign message must be hex string")}
+		ch <- res
+		return
+	}
+
+	mm := strings.Split(save, common.Sep11)
+	if len(mm) < 4 || len(mm[2]) < 32 || len(mm[3]) < 32 {
+		res := RPCSmpcRes{Ret: "", Tip: "sr25519 sign get local save data fail", Err: fmt.Errorf("sr25519 sign get local save data fail")}
+		ch <- res
+		return
+	}
+
+	smpcpks, err := hex.DecodeString(pubkey)
+	if err != nil {
+		res := RPCSmpcRes{Ret: "", Tip: "", Err: err}
+		ch <- res
+		return
+	}
+
+	exsit, da := GetPubKeyData(smpcpks[:])
+	if !exsit || da == nil {
+		res := RPCSmpcRes{Ret: "", Tip: "sr25519 sign get local save data fail", Err: fmt.Errorf("sr25519 sign get local save data fail")}
+		ch <- res
+		return
+	}
+
+	pubs, ok := da.(*PubKeyData)
+	if !ok || pubs.GroupID == "" {
+		res := RPCSmpcRes{Ret: "", Tip: "sr25519 sign get local save data fail", Err: fmt.Errorf("sr25519 sign get local save data fail")}
+		ch <- res
+		return
+	}
+
+	sd := &edkeygen.LocalDNodeSaveData{}
+	copy(sd.TSk[:], []byte(mm[2])[:32])
+	copy(sd.FinalPkBytes[:], []byte(mm[3])[:32])
+	sd.IDs = GetGroupNodeUIDs("ED25519", pubs.GroupID, pubs.GroupID)
+	_, sd.CurDNodeID = GetNodeUID(curEnode, "ED25519", pubs.GroupID)
+	msgtoenode := GetMsgToEnode("ED25519", pubs.GroupID, pubs.GroupID)
+
+	idsign := GetGroupNodeUIDs("ED25519", pubs.GroupID, w.groupid)
+
+	commStopChan := make(chan struct{})
+	outCh := make(chan smpclib.Message, w.ThresHold)
+	endCh := make(chan srsigning.SrSignData, w.ThresHold)
+	errChan := make(chan struct{})
+	signDNode := srsigning.NewLocalDNode(outCh, endCh, sd, idsign, sd.CurDNodeID, w.ThresHold, srsigning.SubstrateContext, msg)
+	w.DNode = signDNode
+	signDNode.SetDNodeID(fmt.Sprintf("%v", sd.CurDNodeID))
+	signDNode.SetSessionKey(msgprex)
+
+	var signWg sync.WaitGroup
+	signWg.Add(2)
+	go func() {
+		defer signWg.Done()
+		if err := signDNode.Start(); nil != err {
+			fmt.Printf("==========SignSr25519, node start, key = %v, err = %v ==========\n", msgprex, err)
+			close(errChan)
+		}
+
+		for _, uid := range idsign {
+			HandleSrSign(msgprex, uid)
+		}
+	}()
+	go SrSignProcessInboundMessages(msgprex, pubs.GroupID, commStopChan, &signWg, ch)
+	data, err := processSrSign(msgprex, msgtoenode, errChan, outCh, endCh)
+	if err != nil || data == nil {
+		common.Debug("================SignSr25519,process sign fail========================", "key", msgprex, "err", err)
+		close(commStopChan)
+		res := RPCSmpcRes{Ret: "", Err: err}
+		ch <- res
+		return
+	}
+
+	close(commStopChan)
+	signWg.Wait()
+
+	signature := data.Signature()
+	sig := hex.EncodeToString(signature[:])
+	common.Info("================SignSr25519,get the signature========================", "key", msgprex, "sig", sig, "pubkey", hex.EncodeToString(data.Pk[:]))
+	res := RPCSmpcRes{Ret: sig, Tip: "", Err: nil}
+	ch <- res
+}
+
+// HandleSrSign Process pre-save msg for sr25519 sign
+func HandleSrSign(key string, uid *big.Int) {
+	uidtmp := fmt.Sprintf("%v", uid)
+	tmp := hex.EncodeToString([]byte(uidtmp))
+	c1data := strings.ToLower(key + "-" + tmp + "-" + "SrSignRound1Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "SrSignRound2Message")
+	Handle(key, c1data)
+	c1data = strings.ToLower(key + "-" + tmp + "-" + "SrSignRound3Message")
+	Handle(key, c1data)
+}
+
+// SrSignProcessInboundMessages Analyze the obtained P2P messages and enter next round
+// gid is the keygen group id
+func SrSignProcessInboundMessages(msgprex string, gid string, finishChan chan struct{}, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+	if msgprex == "" || gid == "" {
+		return
+	}
+
+	fmt.Printf("start sr25519 sign processing inbound messages\n")
+	w, err := FindWorker(msgprex)
+	if w == nil || err != nil {
+		res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to sr25519 sign process inbound messages")}
+		ch <- res
+		return
+	}
+
+	defer fmt.Printf("stop sr25519 sign processing inbound messages\n")
+	for {
+		select {
+		case <-finishChan:
+			return
+		case m := <-w.SmpcMsg:
+
+			msgmap, err := decodeP2pMsg(m)
+			if err != nil {
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			mm := SrSignGetRealMessage(msgmap)
+			if mm == nil {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("fail to sr25519 sign process inbound messages")}
+				ch <- res
+				return
+			}
+
+			//check sig
+			if msgmap["Sig"] == "" || msgmap["ENode"] == "" {
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("verify sig fail")}
+				ch <- res
+				return
+			}
+
+			sig, err := hex.DecodeString(msgmap["Sig"])
+			if err != nil {
+				common.Error("[SR25519 SIGN] decode msg sig data error", "err", err, "key", msgprex)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+
+			if !checkP2pSig(sig, mm, msgmap["ENode"]) {
+				common.Error("===============sr25519 sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			// check fromID
+			_, ID := GetNodeUID(msgmap["ENode"], "ED25519", gid)
+			id := fmt.Sprintf("%v", ID)
+			uid := hex.EncodeToString([]byte(id))
+			if ID == nil || !strings.EqualFold(uid, mm.GetFromID()) {
+				common.Error("===============sr25519 sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"], "err", "check from ID fail")
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check from ID fail")}
+				ch <- res
+				return
+			}
+
+			// check whether 'from' is in the group
+			succ := false
+			_, nodes := GetGroup(w.groupid)
+			others := strings.Split(nodes, common.Sep2)
+			for _, v := range others {
+				node2 := ParseNode(v)
+				if strings.EqualFold(node2, msgmap["ENode"]) {
+					succ = true
+					break
+				}
+			}
+
+			if !succ {
+				common.Error("===============sr25519 sign,check p2p msg fail===============", "sig", sig, "sender", msgmap["ENode"], "msg type", msgmap["Type"])
+				res := RPCSmpcRes{Ret: "", Err: fmt.Errorf("check msg sig fail")}
+				ch <- res
+				return
+			}
+
+			_, err = w.DNode.Update(mm)
+			if err != nil {
+				fmt.Printf("========== SrSignProcessInboundMessages, dnode update fail, receiv smpc msg = %v, err = %v, key = %v ============\n", m, err, msgprex)
+				saveBlame(msgprex, "ED25519", gid, w.groupid, err)
+				res := RPCSmpcRes{Ret: "", Err: err}
+				ch <- res
+				return
+			}
+		}
+	}
+}
+
+// srDecodeHex decode the hex string to the fixed size bytes
+func srDecodeHex(s string, dst []byte) bool {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(dst) {
+		return false
+	}
+
+	copy(dst, b)
+	return true
+}
+
+// SrSignGetRealMessage get the message data struct by map. (p2p msg ---> map)
+func SrSignGetRealMessage(msg map[string]string) smpclib.Message {
+	return getRealMessage(msg, "sr25519/signing")
+}
+
+// processSrSign  Obtain the data to be sent in each round and send it to other nodes until the end of the sr25519 sign command
+func processSrSign(msgprex string, msgtoenode map[string]string, errChan chan struct{}, outCh <-chan smpclib.Message, endCh <-chan srsigning.SrSignData) (*srsigning.SrSignData, error) {
+	for {
+		select {
+		case <-errChan:
+			fmt.Printf("=========================== processSrSign,error channel closed fail to start local smpc node, key = %v =====================\n", msgprex)
+			return nil, errors.New("error channel closed fail to start local smpc node")
+
+		case <-time.After(time.Second * time.Duration(EdSignTimeout)):
+			fmt.Printf("========================== processSrSign,sign timeout, key = %v ==========================\n", msgprex)
+			return nil, errors.New("sr25519 sign timeout")
+		case msg := <-outCh:
+			err := SignProcessOutCh(msgprex, msgtoenode, msg, "")
+			if err != nil {
+				fmt.Printf("======================= processSrSign, sign process outch err = %v, key = %v ====================\n", err, msgprex)
+				return nil, err
+			}
+		case msg := <-endCh:
+			return &msg, nil
+		}
+	}
+}
+
+//-------------------------------------------------------SR25519 end---------------------------------------------------
@@ -20,6 +20,7 @@ import (
 	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
 	p2psmpc "github.com/anyswap/FastMulThreshold-DSA/p2p/layer2"
 	smpclibec2 "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/crypto/ec2"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
 	"github.com/fsn-dev/cryptoCoins/coins"
 	cryptocoinsconfig "github.com/fsn-dev/cryptoCoins/coins/config"
 	"github.com/fsn-dev/cryptoCoins/coins/eos"
@@ -46,6 +47,8 @@ func init() {
 	RegP2pBroadcastInGroupOthersCallBack(p2psmpc.SdkProtocol_broadcastInGroupOthers)
 	RegP2pSendMsgToPeerCallBack(p2psmpc.SendMsgToPeer)
 	RegP2pParseNodeCallBack(p2psmpc.ParseNodeID)
+	RegP2pNegotiateGroupVersionCallBack(p2psmpc.NegotiateGroupVersion)
+	p2psmpc.SetProtocolVersions(smpclib.SupportedWireVersions())
 	RegSmpcGetEosAccountCallBack(eos.GetEosAccount)
 	InitChan()
 }
@@ -60,13 +63,17 @@ type LunchParams struct {
 	MaxAcceptTime    uint64
 	Bip32Pre     uint64
 	SyncPreSign string
+	RefreshInterval uint64 // seconds,0 means no scheduled share refresh
+	PreParamsNum uint64 // the number of pre-generated paillier key and ntilde data kept in local db,0 means disabled
+	Webhooks []string // the http(s) urls the keygen/sign/reshare events are posted to
+	ApprovalPolicy string // the toml/json file of the policy that approves the keygen/sign requests automatically,empty means approving by hand
 }
 
 // Start init gsmpc
 // 1. Initialization: local database (including general database, private key database, bip32 c value database,bip32 pre-sign data database, pre-sign data database, public key group information database, database for saving data related to generate pubkey command, database for saving data related to signature command, database for saving data related to resare command, pubkey), P2P callback function, Crypto coins configuration, startup parameters (including the number of pre generated packets, the timeout waiting for P2P information, the number of automatic retries after failed address application or signature, the timeout agreed by the nodes, whether to synchronize pre generated packets between nodes, etc.), and the enodeid of the local node.
 // 2. Load the pubkeys generated by history and execute it only once.
 // 3. Generate 4 large prime numbers
-// 4. Execute automatic pre generation of data packets.
+// 4. Execute automatic pre generation of data packets,and keep the pool of pre-generated paillier key and ntilde data in local db filled.
 // 5. Listen for the arrival of the sign command.
 // 6. Delete the data related to generating pubkey command, the signature command and the restore command from the corresponding sub database, and correspondingly change the status of the command data to timeout in the general database.
 func Start(params *LunchParams) {
@@ -104,8 +111,28 @@ func Start(params *LunchParams) {
 
 	AutoPreGenSignData()
 
+	PreParamsCount = int(params.PreParamsNum)
+	if PreParamsCount > 0 {
+		smpclibec2.RegPreParamsCallBack(TakePreParams)
+		go AutoGenPreParams()
+	}
+
 	go HandleRPCSign()
 
+	// start it before cleaning up,so that the requests timed out by the restart are posted too
+	err = StartWebhook(params.Webhooks)
+	if err != nil {
+		common.Error("======================smpc.Start,start webhook fail======================", "err", err)
+	}
+
+	err = StartApprovalPolicy(params.ApprovalPolicy)
+	if err != nil {
+		info := "======================smpc.Start,load approval policy fail," + err.Error() + ",so terminate smpc node startup"
+		common.Error(info)
+		os.Exit(1)
+		return
+	}
+
 	// do this must after openning accounts db success,but get accloaded must before it
 	if !accloaded {
 		go CopyAllAccountsFromDb()
@@ -114,7 +141,13 @@ func Start(params *LunchParams) {
 	CleanUpAllReqAddrInfo()
 	CleanUpAllSignInfo()
 	CleanUpAllReshareInfo()
+	CleanUpAllRecoverShareInfo()
+
+	RefreshInterval = params.RefreshInterval
+	if RefreshInterval > 0 {
+		go AutoRefresh(RefreshInterval)
+	}
 
-	common.Info("================================smpc.Start,init finish.========================", "curEnode", curEnode, "waitmsg", WaitMsgTimeGG20, "trytimes", recalcTimes, "presignnum", PrePubDataCount, "bip32pre", PreBip32DataCount)
+	common.Info("================================smpc.Start,init finish.========================", "curEnode", curEnode, "waitmsg", WaitMsgTimeGG20, "trytimes", recalcTimes, "presignnum", PrePubDataCount, "bip32pre", PreBip32DataCount, "refreshinterval", RefreshInterval, "preparamsnum", PreParamsCount)
 }
 
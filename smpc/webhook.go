@@ -0,0 +1,320 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	"github.com/anyswap/FastMulThreshold-DSA/internal/common"
+)
+
+const (
+	// WebhookMaxAttempts the max times to deliver one event to one webhook,the event is dropped after that
+	WebhookMaxAttempts = 20
+
+	webhookMinBackoff = 5 * time.Second
+	webhookMaxBackoff = time.Hour
+	webhookTimeout    = 10 * time.Second
+)
+
+var (
+	// WebhookURLs the http(s) urls the events of the requests are posted to
+	WebhookURLs []string
+
+	webhookdb     *ethdb.LDBDatabase
+	webhookWakeup = make(chan struct{}, 1)
+	webhookClient = &http.Client{Timeout: webhookTimeout}
+)
+
+// WebhookEvent the body posted to the webhooks
+type WebhookEvent struct {
+	ID    string
+	Enode string
+	*Event
+}
+
+// webhookDelivery one event waiting in the outbox to be delivered to one webhook
+type webhookDelivery struct {
+	URL      string
+	Body     string
+	Attempts int
+	NextTime int64 // unix nano
+}
+
+// GetWebhookDir get the dir of database for saving the outbox of the webhook events
+func GetWebhookDir() string {
+	dir := common.DefaultDataDir()
+	dir += "/smpcdata/smpcwebhook" + curEnode
+	return dir
+}
+
+// GetSmpcWebhookDb open database for saving the outbox of the webhook events
+func GetSmpcWebhookDb() *ethdb.LDBDatabase {
+	dir := GetWebhookDir()
+	webhookdb, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if err != nil {
+		common.Error("======================smpc.Start,open webhookdb fail======================", "err", err, "dir", dir)
+		return nil
+	}
+
+	return webhookdb
+}
+
+// StartWebhook open the outbox and post the keygen/sign/reshare events to the webhooks,
+// the undelivered events left in the outbox by the last run are delivered first.
+// It must be called before the node handles any request,the events are put to the outbox by putWebhookOutbox.
+func StartWebhook(urls []string) error {
+	WebhookURLs = make([]string, 0, len(urls))
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("invalid webhook url %v", u)
+		}
+
+		WebhookURLs = append(WebhookURLs, u)
+	}
+
+	if len(WebhookURLs) == 0 {
+		return nil
+	}
+
+	webhookdb = GetSmpcWebhookDb()
+	if webhookdb == nil {
+		return fmt.Errorf("open webhookdb fail")
+	}
+
+	go deliverWebhooks()
+
+	common.Info("=====================StartWebhook,webhook started=====================", "urls", WebhookURLs)
+	return nil
+}
+
+// putWebhookOutbox save the event to the outbox in the goroutine that fires it,so that the event is not lost
+// when the node stops before it is delivered,and wake up the delivery
+func putWebhookOutbox(ev *Event) {
+	if webhookdb == nil || !isWebhookEvent(ev) {
+		return
+	}
+
+	if err := putWebhookEvent(ev); err != nil {
+		common.Error("=====================putWebhookOutbox,put event to outbox fail=====================", "key", ev.Key, "type", ev.Type, "err", err)
+		return
+	}
+
+	wakeupWebhook()
+}
+
+// isWebhookEvent only the keygen/sign/reshare requests waiting for approval or finished are posted
+func isWebhookEvent(ev *Event) bool {
+	if ev == nil {
+		return false
+	}
+
+	if ev.CmdType != "REQSMPCADDR" && ev.CmdType != "SIGN" && ev.CmdType != "RESHARE" {
+		return false
+	}
+
+	if ev.Type == EventPendingApproval {
+		return true
+	}
+
+	return ev.Type == EventFinished && (ev.Status == "Success" || ev.Status == "Failure" || ev.Status == "Timeout")
+}
+
+// putWebhookEvent save the event to the outbox,one delivery for every webhook
+func putWebhookEvent(ev *Event) error {
+	id := Keccak256Hash([]byte(strings.ToLower(ev.Type + ":" + ev.Key + ":" + ev.Status))).Hex()
+	body, err := json.Marshal(&WebhookEvent{ID: id, Enode: curEnode, Event: ev})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	for i, u := range WebhookURLs {
+		d := &webhookDelivery{URL: u, Body: string(body), NextTime: now}
+		if err := putWebhookDelivery(webhookDeliveryKey(now, i, id), d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webhookDeliveryKey the key of the delivery in the outbox,ordered by the time the event happened
+func webhookDeliveryKey(t int64, index int, id string) []byte {
+	return []byte(fmt.Sprintf("%020d:%03d:%v", t, index, id))
+}
+
+func putWebhookDelivery(key []byte, d *webhookDelivery) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return webhookdb.Put(key, b)
+}
+
+func wakeupWebhook() {
+	select {
+	case webhookWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// deliverWebhooks post the events in the outbox,the failed deliveries are retried with exponential backoff
+func deliverWebhooks() {
+	for {
+		next := deliverWebhooksOnce()
+
+		wait := webhookMaxBackoff
+		if next > 0 {
+			wait = time.Duration(next - time.Now().UnixNano())
+			if wait < 0 {
+				wait = 0
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-webhookWakeup:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// deliverWebhooksOnce post the deliveries that are due,return the time of the next retry,0 if the outbox is empty
+func deliverWebhooksOnce() int64 {
+	var next int64
+
+	iter := webhookdb.NewIterator()
+	defer iter.Release()
+
+	for iter.Next() {
+		key := []byte(string(iter.Key())) //must be deep copy
+		d := &webhookDelivery{}
+		if err := json.Unmarshal(iter.Value(), d); err != nil {
+			common.Error("=====================deliverWebhooks,invalid delivery in outbox,drop it=====================", "key", string(key), "err", err)
+			webhookdb.Delete(key)
+			continue
+		}
+
+		now := time.Now().UnixNano()
+		if d.NextTime > now {
+			if next == 0 || d.NextTime < next {
+				next = d.NextTime
+			}
+			continue
+		}
+
+		err := postWebhook(d.URL, []byte(d.Body))
+		if err == nil {
+			webhookdb.Delete(key)
+			continue
+		}
+
+		d.Attempts++
+		if d.Attempts >= WebhookMaxAttempts {
+			common.Error("=====================deliverWebhooks,post event fail too many times,drop it=====================", "url", d.URL, "attempts", d.Attempts, "err", err)
+			webhookdb.Delete(key)
+			continue
+		}
+
+		d.NextTime = now + int64(webhookBackoff(d.Attempts))
+		common.Debug("=====================deliverWebhooks,post event fail,retry later=====================", "url", d.URL, "attempts", d.Attempts, "err", err)
+		if err := putWebhookDelivery(key, d); err != nil {
+			common.Error("=====================deliverWebhooks,update delivery fail=====================", "key", string(key), "err", err)
+		}
+
+		if next == 0 || d.NextTime < next {
+			next = d.NextTime
+		}
+	}
+
+	return next
+}
+
+// webhookBackoff the time to wait before the next attempt,it is doubled after every failed attempt
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookMinBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+
+	return backoff
+}
+
+// SignWebhookBody sign the keccak256 hash of the body with the node key,
+// the receiver recovers the pubkey from the signature and compares it with the enode id
+func SignWebhookBody(body []byte) (string, error) {
+	priv, err := getNodePrivate(KeyFile)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := crypto.Sign(crypto.Keccak256(body), priv)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sig), nil
+}
+
+// postWebhook post the signed body to the url,the response must be 2xx
+func postWebhook(url string, body []byte) error {
+	sig, err := SignWebhookBody(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Smpc-Enode", curEnode)
+	req.Header.Set("X-Smpc-Signature", sig)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook response status %v", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anyswap/FastMulThreshold-DSA/crypto"
+	"github.com/anyswap/FastMulThreshold-DSA/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestKeyFile save a new node key to a temp file and use it as KeyFile until the test ends
+func useTestKeyFile(t *testing.T) []byte {
+	prv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "node.key")
+	if err := crypto.SaveECDSA(file, prv); err != nil {
+		t.Fatal(err)
+	}
+
+	old := KeyFile
+	KeyFile = file
+	t.Cleanup(func() { KeyFile = old })
+
+	return crypto.FromECDSAPub(&prv.PublicKey)
+}
+
+func TestSignWebhookBody(t *testing.T) {
+	pub := useTestKeyFile(t)
+	body := []byte(`{"ID":"0x01","Type":"Finished"}`)
+
+	sig, err := SignWebhookBody(body)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b, err := hex.DecodeString(sig)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the receiver recovers the node pubkey from the signature of the body
+	rpub, err := crypto.Ecrecover(crypto.Keccak256(body), b)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pub, rpub)
+	}
+
+	rpub, err = crypto.Ecrecover(crypto.Keccak256([]byte(`{"ID":"0x02"}`)), b)
+	if err == nil {
+		assert.NotEqual(t, pub, rpub, "other body")
+	}
+
+	KeyFile = ""
+	_, err = SignWebhookBody(body)
+	assert.Error(t, err)
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{0, webhookMinBackoff},
+		{1, webhookMinBackoff},
+		{2, 2 * webhookMinBackoff},
+		{3, 4 * webhookMinBackoff},
+		{10, 512 * webhookMinBackoff},
+		{11, webhookMaxBackoff},
+		{WebhookMaxAttempts, webhookMaxBackoff},
+		{1000, webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.backoff, webhookBackoff(tt.attempts), "attempts %v", tt.attempts)
+	}
+}
+
+func TestWebhookRedelivery(t *testing.T) {
+	pub := useTestKeyFile(t)
+
+	var lock sync.Mutex
+	fail := true
+	var bodies [][]byte
+	var sigs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, b)
+		sigs = append(sigs, r.Header.Get("X-Smpc-Signature"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	db, err := ethdb.NewLDBDatabase(dir, cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	oldurls, olddb := WebhookURLs, webhookdb
+	WebhookURLs, webhookdb = []string{srv.URL}, db
+	defer func() {
+		webhookdb.Close()
+		WebhookURLs, webhookdb = oldurls, olddb
+	}()
+
+	// the event is in the outbox as soon as it is fired,the other events are not posted
+	notifyRound("0xwebhook", "1")
+	notifyFinished("SIGN", "0xwebhook", "0xaccount", "04abcd", "0xgroup", "Success", "rsv", "", "")
+	outbox := func() []*webhookDelivery {
+		var ds []*webhookDelivery
+		iter := webhookdb.NewIterator()
+		defer iter.Release()
+		for iter.Next() {
+			d := &webhookDelivery{}
+			assert.NoError(t, json.Unmarshal(iter.Value(), d))
+			ds = append(ds, d)
+		}
+		return ds
+	}
+	if !assert.Equal(t, 1, len(outbox()), "outbox") {
+		return
+	}
+
+	// the webhook is down,the delivery is retried later
+	next := deliverWebhooksOnce()
+	assert.True(t, next > time.Now().UnixNano(), "next retry")
+	ds := outbox()
+	if !assert.Equal(t, 1, len(ds)) {
+		return
+	}
+	assert.Equal(t, 1, ds[0].Attempts)
+
+	// restart the node,the undelivered event is still in the outbox and delivered once it is due
+	webhookdb.Close()
+	webhookdb, err = ethdb.NewLDBDatabase(dir, cache, handles)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	iter := webhookdb.NewIterator()
+	for iter.Next() {
+		key := []byte(string(iter.Key()))
+		d := &webhookDelivery{}
+		assert.NoError(t, json.Unmarshal(iter.Value(), d))
+		d.NextTime = time.Now().UnixNano()
+		assert.NoError(t, putWebhookDelivery(key, d))
+	}
+	iter.Release()
+
+	lock.Lock()
+	fail = false
+	lock.Unlock()
+
+	assert.Equal(t, int64(0), deliverWebhooksOnce(), "outbox is empty")
+	assert.Equal(t, 0, len(outbox()))
+
+	lock.Lock()
+	defer lock.Unlock()
+	if !assert.Equal(t, 1, len(bodies), "delivered once") {
+		return
+	}
+
+	ev := &WebhookEvent{}
+	if assert.NoError(t, json.Unmarshal(bodies[0], ev)) {
+		assert.Equal(t, EventFinished, ev.Type)
+		assert.Equal(t, "0xwebhook", ev.Key)
+		assert.Equal(t, "rsv", ev.Result)
+		assert.NotEqual(t, "", ev.ID)
+	}
+
+	sig, err := hex.DecodeString(sigs[0])
+	if assert.NoError(t, err) {
+		rpub, err := crypto.Ecrecover(crypto.Keccak256(bodies[0]), sig)
+		if assert.NoError(t, err) {
+			assert.Equal(t, pub, rpub, "signed by the node key")
+		}
+	}
+}
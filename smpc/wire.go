@@ -0,0 +1,158 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/anyswap/FastMulThreshold-DSA/log"
+	"github.com/anyswap/FastMulThreshold-DSA/p2p/rlp"
+	smpclib "github.com/anyswap/FastMulThreshold-DSA/smpc-lib/smpc"
+)
+
+// getWireMsgMap get the p2p msg map of msg that is sent to the group groupid.
+// The message is carried in "Wire" as the raw bytes of the binary wire format of smpc-lib,encoded with the highest version supported by all nodes of the group,
+// "Type" and "FromID" are copied out of it because the msg is routed (and pre-saved in C1Data) by them before it is decoded.
+// The map is sent by encodeP2pMsg.
+func getWireMsgMap(msg smpclib.Message, groupid string) (map[string]string, error) {
+	version := uint(smpclib.WireVersion)
+	if NegotiateGroupVersion != nil {
+		v, err := NegotiateGroupVersion(groupid)
+		if err != nil {
+			return nil, err
+		}
+		version = v
+	}
+
+	b, err := smpclib.EncodeMessageVersion(msg, version)
+	if err != nil {
+		return nil, err
+	}
+
+	msgmap := make(map[string]string)
+	msgmap["Type"] = msg.GetMsgType()
+	msgmap["FromID"] = msg.GetFromID()
+	msgmap["Wire"] = string(b)
+	return msgmap, nil
+}
+
+// getWireMessage decode the message in the p2p msg map,it must be a message of one of the protocol packages pkgs,such as "ecdsa/keygen"
+func getWireMessage(msgmap map[string]string, pkgs ...string) (smpclib.Message, error) {
+	if msgmap == nil || msgmap["Wire"] == "" {
+		return nil, errors.New("no wire data in p2p msg")
+	}
+
+	msg, err := smpclib.DecodeMessage([]byte(msgmap["Wire"]))
+	if err != nil {
+		return nil, err
+	}
+
+	name := smpclib.WireName(msg)
+	found := false
+	for _, pkg := range pkgs {
+		if strings.HasPrefix(name, pkg+".") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("unexpected p2p msg type " + name)
+	}
+
+	if msg.GetMsgType() != msgmap["Type"] || msg.GetFromID() != msgmap["FromID"] {
+		return nil, errors.New("p2p msg type or from id does not match the wire data")
+	}
+
+	return msg, nil
+}
+
+// getRealMessage decode the message in the p2p msg map,nil if it is not a message of the protocol packages pkgs
+func getRealMessage(msgmap map[string]string, pkgs ...string) smpclib.Message {
+	msg, err := getWireMessage(msgmap, pkgs...)
+	if err != nil {
+		log.Error("====================getRealMessage,decode p2p msg fail==================", "type", msgmap["Type"], "err", err)
+		return nil
+	}
+
+	return msg
+}
+
+// p2pMsgField one key/value of the binary p2p msg envelope
+type p2pMsgField struct {
+	Key   string
+	Value string
+}
+
+// encodeP2pMsg encode the p2p msg map of a protocol message to the binary envelope,
+// the rlp list of its key/value pairs sorted by key,so that the wire data is sent as raw bytes instead of being escaped in json
+func encodeP2pMsg(msgmap map[string]string) (string, error) {
+	if len(msgmap) == 0 {
+		return "", errors.New("empty p2p msg")
+	}
+
+	keys := make([]string, 0, len(msgmap))
+	for k := range msgmap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]p2pMsgField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, p2pMsgField{Key: k, Value: msgmap[k]})
+	}
+
+	b, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// decodeP2pMsg decode the p2p msg to the msg map,
+// it is the binary envelope of encodeP2pMsg if it starts with a rlp list prefix and a json object (as all other p2p msgs) otherwise
+func decodeP2pMsg(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, errors.New("empty p2p msg")
+	}
+
+	msgmap := make(map[string]string)
+	if s[0] < 0xc0 {
+		if err := json.Unmarshal([]byte(s), &msgmap); err != nil {
+			return nil, err
+		}
+
+		return msgmap, nil
+	}
+
+	var fields []p2pMsgField
+	if err := rlp.DecodeBytes([]byte(s), &fields); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		if _, ok := msgmap[f.Key]; ok {
+			return nil, errors.New("duplicate key " + f.Key + " in p2p msg")
+		}
+		msgmap[f.Key] = f.Value
+	}
+
+	return msgmap, nil
+}
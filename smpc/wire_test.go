@@ -0,0 +1,97 @@
+/*
+ *  Copyright (C) 2020-2021  AnySwap Ltd. All rights reserved.
+ *  Copyright (C) 2020-2021  haijun.cai@anyswap.exchange
+ *
+ *  This library is free software; you can redistribute it and/or
+ *  modify it under the Apache License, Version 2.0.
+ *
+ *  This library is distributed in the hope that it will be useful,
+ *  but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package smpc
+
+import (
+	"testing"
+
+	"github.com/anyswap/FastMulThreshold-DSA/smpc-lib/ecdsa/keygen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestP2pMsgEnvelope(t *testing.T) {
+	msgmap := map[string]string{
+		"Key":  "0xabc",
+		"Type": "KGRound0Message",
+		"Wire": string([]byte{0xc0, 0x00, 0xff, 0xfe, '"', '\\'}),
+	}
+
+	s, err := encodeP2pMsg(msgmap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, s[0] >= 0xc0, "rlp list")
+	assert.NotContains(t, s, "\\u", "not escaped")
+
+	msgmap2, err := decodeP2pMsg(s)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msgmap, msgmap2)
+	}
+
+	// the json p2p msgs are still read
+	msgmap2, err = decodeP2pMsg(`{"Key":"0xabc","Type":"SignData"}`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"Key": "0xabc", "Type": "SignData"}, msgmap2)
+	}
+
+	_, err = encodeP2pMsg(nil)
+	assert.Error(t, err)
+	_, err = decodeP2pMsg("")
+	assert.Error(t, err)
+	_, err = decodeP2pMsg(s[:len(s)-1])
+	assert.Error(t, err, "truncated")
+
+	dup := string([]byte{0xca, 0xc4, 'K', 'e', 'y', '1', 0xc4, 'K', 'e', 'y', '2'})
+	_, err = decodeP2pMsg(dup)
+	assert.Error(t, err, "duplicate key")
+}
+
+func TestWireMsgMap(t *testing.T) {
+	// no group,encode with the current version
+	negotiate := NegotiateGroupVersion
+	NegotiateGroupVersion = nil
+	defer func() { NegotiateGroupVersion = negotiate }()
+
+	msg := &keygen.KGRound0Message{KGRoundMessage: &keygen.KGRoundMessage{FromID: "31", FromIndex: 0}}
+	msgmap, err := getWireMsgMap(msg, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	msgmap["Key"] = "0xabc"
+
+	s, err := encodeP2pMsg(msgmap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	msgmap2, err := decodeP2pMsg(s)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg2, err := getWireMessage(msgmap2, "ecdsa/keygen")
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg.GetFromID(), msg2.GetFromID())
+		assert.Equal(t, msg.GetMsgType(), msg2.GetMsgType())
+	}
+
+	_, err = getWireMessage(msgmap2, "ecdsa/signing")
+	assert.Error(t, err, "message of another protocol")
+
+	msgmap2["FromID"] = "32"
+	_, err = getWireMessage(msgmap2, "ecdsa/keygen")
+	assert.Error(t, err, "from id does not match")
+}
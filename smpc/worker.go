@@ -119,10 +119,14 @@ type RPCReqWorker struct {
 	NodeCnt          int
 	ThresHold        int
 	sid              string //save the key
+	paillierkeylength int   //bit length of paillier N and Ntilde of the key
+	signprotocol     string //presign protocol of the key,GG20 or CGGMP21
+	blamekey         string //the key that blame records are saved under
 	approved         bool
 	//
 	msgacceptreqaddrres *list.List
 	msgacceptreshareres *list.List
+	msgacceptrecovershareres *list.List
 	msgacceptsignres    *list.List
 
 	msgsyncpresign    *list.List
@@ -152,9 +156,11 @@ type RPCReqWorker struct {
 	save   *list.List
 	sku1   *list.List
 	bip32c *list.List
+	pubshares *list.List
 
 	bacceptreqaddrres chan bool
 	bacceptreshareres chan bool
+	bacceptrecovershareres chan bool
 	bacceptsignres    chan bool
 	bsendreshareres   chan bool
 	bsendsignres      chan bool
@@ -211,6 +217,8 @@ type RPCReqWorker struct {
 	acceptWaitReqAddrChan chan string
 	acceptReShareChan     chan string
 	acceptWaitReShareChan chan string
+	acceptRecoverShareChan     chan string
+	acceptWaitRecoverShareChan chan string
 	acceptSignChan        chan string
 	acceptWaitSignChan    chan string
 
@@ -251,6 +259,7 @@ func NewRPCReqWorker(workerPool chan chan RPCReq) *RPCReqWorker {
 		msgpaillierkey:      list.New(),
 		msgacceptreqaddrres: list.New(),
 		msgacceptreshareres: list.New(),
+		msgacceptrecovershareres: list.New(),
 		msgacceptsignres:    list.New(),
 		msgsyncpresign:      list.New(),
 
@@ -260,9 +269,11 @@ func NewRPCReqWorker(workerPool chan chan RPCReq) *RPCReqWorker {
 		save:   list.New(),
 		sku1:   list.New(),
 		bip32c: list.New(),
+		pubshares: list.New(),
 
 		bacceptreqaddrres: make(chan bool, 1),
 		bacceptreshareres: make(chan bool, 1),
+		bacceptrecovershareres: make(chan bool, 1),
 		bacceptsignres:    make(chan bool, 1),
 		bsendreshareres:   make(chan bool, 1),
 		bsendsignres:      make(chan bool, 1),
@@ -315,6 +326,8 @@ func NewRPCReqWorker(workerPool chan chan RPCReq) *RPCReqWorker {
 		msgeds:      list.New(),
 
 		sid:       "",
+		paillierkeylength: PaillierKeyLength,
+		signprotocol:      SignProtocolGG20,
 		approved:      false,
 		NodeCnt:   5,
 		ThresHold: 5,
@@ -324,6 +337,8 @@ func NewRPCReqWorker(workerPool chan chan RPCReq) *RPCReqWorker {
 
 		acceptReShareChan:     make(chan string, 1),
 		acceptWaitReShareChan: make(chan string, 1),
+		acceptRecoverShareChan:     make(chan string, 1),
+		acceptWaitRecoverShareChan: make(chan string, 1),
 		acceptSignChan:        make(chan string, 1),
 		acceptWaitSignChan:    make(chan string, 1),
 
@@ -342,6 +357,9 @@ func (w *RPCReqWorker) Clear() {
 	common.Debug("======================RpcReqWorker.Clear======================", "w.id", w.id, "w.groupid", w.groupid, "key", w.sid)
 
 	w.sid = ""
+	w.paillierkeylength = PaillierKeyLength
+	w.signprotocol = SignProtocolGG20
+	w.blamekey = ""
 	w.approved = false
 	w.groupid = ""
 	w.limitnum = ""
@@ -356,6 +374,11 @@ func (w *RPCReqWorker) Clear() {
 		w.msgacceptreshareres.Remove(e)
 	}
 
+	for e := w.msgacceptrecovershareres.Front(); e != nil; e = next {
+		next = e.Next()
+		w.msgacceptrecovershareres.Remove(e)
+	}
+
 	for e := w.msgacceptsignres.Front(); e != nil; e = next {
 		next = e.Next()
 		w.msgacceptsignres.Remove(e)
@@ -476,6 +499,11 @@ func (w *RPCReqWorker) Clear() {
 		w.bip32c.Remove(e)
 	}
 
+	for e := w.pubshares.Front(); e != nil; e = next {
+		next = e.Next()
+		w.pubshares.Remove(e)
+	}
+
 	for e := w.pky.Front(); e != nil; e = next {
 		next = e.Next()
 		w.pky.Remove(e)
@@ -517,6 +545,9 @@ func (w *RPCReqWorker) Clear() {
 	if len(w.bacceptreshareres) == 1 {
 		<-w.bacceptreshareres
 	}
+	if len(w.bacceptrecovershareres) == 1 {
+		<-w.bacceptrecovershareres
+	}
 	if len(w.bacceptsignres) == 1 {
 		<-w.bacceptsignres
 	}
@@ -677,6 +708,12 @@ func (w *RPCReqWorker) Clear() {
 	if len(w.acceptReShareChan) == 1 {
 		<-w.acceptReShareChan
 	}
+	if len(w.acceptRecoverShareChan) == 1 {
+		<-w.acceptRecoverShareChan
+	}
+	if len(w.acceptWaitRecoverShareChan) == 1 {
+		<-w.acceptWaitRecoverShareChan
+	}
 	if len(w.acceptSignChan) == 1 {
 		<-w.acceptSignChan
 	}
@@ -704,6 +741,11 @@ func (w *RPCReqWorker) Clear2() {
 		w.msgacceptreshareres.Remove(e)
 	}
 
+	for e := w.msgacceptrecovershareres.Front(); e != nil; e = next {
+		next = e.Next()
+		w.msgacceptrecovershareres.Remove(e)
+	}
+
 	for e := w.msgacceptsignres.Front(); e != nil; e = next {
 		next = e.Next()
 		w.msgacceptsignres.Remove(e)
@@ -824,6 +866,11 @@ func (w *RPCReqWorker) Clear2() {
 		w.bip32c.Remove(e)
 	}
 
+	for e := w.pubshares.Front(); e != nil; e = next {
+		next = e.Next()
+		w.pubshares.Remove(e)
+	}
+
 	for e := w.pky.Front(); e != nil; e = next {
 		next = e.Next()
 		w.pky.Remove(e)
@@ -865,6 +912,9 @@ func (w *RPCReqWorker) Clear2() {
 	if len(w.bacceptreshareres) == 1 {
 		<-w.bacceptreshareres
 	}
+	if len(w.bacceptrecovershareres) == 1 {
+		<-w.bacceptrecovershareres
+	}
 	if len(w.bacceptsignres) == 1 {
 		<-w.bacceptsignres
 	}
@@ -1025,6 +1075,12 @@ func (w *RPCReqWorker) Clear2() {
 	if len(w.acceptReShareChan) == 1 {
 		<-w.acceptReShareChan
 	}
+	if len(w.acceptRecoverShareChan) == 1 {
+		<-w.acceptRecoverShareChan
+	}
+	if len(w.acceptWaitRecoverShareChan) == 1 {
+		<-w.acceptWaitRecoverShareChan
+	}
 	if len(w.acceptSignChan) == 1 {
 		<-w.acceptSignChan
 	}